@@ -0,0 +1,100 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// ErrPermanentlyAbsent is returned, without invoking the refresh function, by fetches of a key marked permanently
+// absent, see WithAbsenceStore. A refresh function may return it, possibly wrapped, to mark its key absent.
+var ErrPermanentlyAbsent = errors.New("key is permanently absent")
+
+// absenceMarkers records keys that will never exist, such as deleted users, in a dedicated store shared by every
+// process. A nil *absenceMarkers is valid and never reports a key absent.
+type absenceMarkers struct {
+	store store.Cacher[bool]
+	ttl   time.Duration
+}
+
+// absent reports whether the key is marked absent. Errors of the store are logged and treated as not absent.
+func (a *absenceMarkers) absent(ctx context.Context, key string) bool {
+	if a == nil {
+		return false
+	}
+	marked, exists, err := a.store.Get(ctx, key)
+	if err != nil {
+		slog.Warn("Cannot get absence marker", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+	return exists && marked
+}
+
+// mark marks the key absent, for the configured ttl when the store supports per-entry TTLs.
+func (a *absenceMarkers) mark(ctx context.Context, key string) error {
+	if a == nil {
+		return store.ErrNotSupported
+	}
+	if setter, ok := a.store.(store.TTLSetter[bool]); ok && a.ttl > 0 {
+		return setter.SetWithTTL(ctx, key, true, a.ttl)
+	}
+	return a.store.Set(ctx, key, true)
+}
+
+// clear removes the absence marker of the key.
+func (a *absenceMarkers) clear(ctx context.Context, key string) error {
+	if a == nil {
+		return store.ErrNotSupported
+	}
+	return store.Delete(ctx, a.store, key)
+}
+
+// markAbsent marks the key absent and removes its cached value, ignoring stores that cannot delete entries.
+func markAbsent[V any](ctx context.Context, a *absenceMarkers, values store.Cacher[V], key string) error {
+	if err := a.mark(ctx, key); err != nil {
+		return err
+	}
+	if err := store.Delete(ctx, values, key); err != nil && !errors.Is(err, store.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// recordAbsence marks the key absent when its refresh function returned ErrPermanentlyAbsent.
+func recordAbsence[V any](ctx context.Context, a *absenceMarkers, values store.Cacher[V], key string, err error) {
+	if a == nil || !errors.Is(err, ErrPermanentlyAbsent) {
+		return
+	}
+	if err := markAbsent(context.WithoutCancel(ctx), a, values, key); err != nil {
+		slog.Warn("Cannot mark key absent", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+}
+
+// MarkAbsent marks the key permanently absent and removes its cached value: until the marker expires or is cleared
+// with ClearAbsent, fetches return ErrPermanentlyAbsent without invoking the refresh function.
+// Returns store.ErrNotSupported when no absence store is configured.
+func (ec *EchoCache[T]) MarkAbsent(ctx context.Context, key string) error {
+	return markAbsent(ctx, ec.absent, ec.store, ec.key(key))
+}
+
+// ClearAbsent removes the absence marker of the key, for instance when a deleted entity is restored.
+// Returns store.ErrNotSupported when no absence store is configured or it cannot delete entries.
+func (ec *EchoCache[T]) ClearAbsent(ctx context.Context, key string) error {
+	return ec.absent.clear(ctx, ec.key(key))
+}
+
+// MarkAbsent cancels the background refresh pending for the key, marks it permanently absent and removes its
+// cached value: until the marker expires or is cleared with ClearAbsent, fetches return ErrPermanentlyAbsent without
+// invoking the refresh function. Returns store.ErrNotSupported when no absence store is configured.
+func (ec *EchoCacheLazy[T]) MarkAbsent(ctx context.Context, key string) error {
+	ec.CancelPending(key)
+	return markAbsent(ctx, ec.absent, ec.store, key)
+}
+
+// ClearAbsent removes the absence marker of the key, for instance when a deleted entity is restored.
+// Returns store.ErrNotSupported when no absence store is configured or it cannot delete entries.
+func (ec *EchoCacheLazy[T]) ClearAbsent(ctx context.Context, key string) error {
+	return ec.absent.clear(ctx, key)
+}
@@ -0,0 +1,85 @@
+package echocache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_MarkAbsent verifies that a key marked absent short-circuits fetches until its marker is cleared.
+func TestEchoCache_MarkAbsent(t *testing.T) {
+	ctx := context.Background()
+	markers := store.NewLRUCache[bool](10)
+	ec := NewEchoCache[string](store.NewLRUCache[string](10), WithAbsenceStore(markers, 24*time.Hour))
+	require.NoError(t, ec.BulkSet(ctx, map[string]string{"user:1": "alice"}))
+
+	var calls atomic.Int32
+	refresh := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		return "bob", nil
+	}
+
+	require.NoError(t, ec.MarkAbsent(ctx, "user:1"))
+	_, exists, err := ec.FetchWithCache(ctx, "user:1", refresh)
+	assert.ErrorIs(t, err, ErrPermanentlyAbsent)
+	assert.False(t, exists)
+	assert.Zero(t, calls.Load())
+
+	require.NoError(t, ec.ClearAbsent(ctx, "user:1"))
+	value, exists, err := ec.FetchWithCache(ctx, "user:1", refresh)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "bob", value)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// TestEchoCache_RefreshReturnsAbsent verifies that a refresh function returning ErrPermanentlyAbsent marks its key.
+func TestEchoCache_RefreshReturnsAbsent(t *testing.T) {
+	ctx := context.Background()
+	markers := store.NewLRUCache[bool](10)
+	ec := NewEchoCache[string](store.NewLRUCache[string](10), WithAbsenceStore(markers, 0))
+
+	var calls atomic.Int32
+	refresh := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		return "", fmt.Errorf("user 7: %w", ErrPermanentlyAbsent)
+	}
+	for range 3 {
+		_, _, err := ec.FetchWithCache(ctx, "user:7", refresh)
+		assert.ErrorIs(t, err, ErrPermanentlyAbsent)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	marked, exists, err := markers.Get(ctx, "user:7")
+	require.NoError(t, err)
+	assert.True(t, exists && marked)
+}
+
+// TestEchoCache_MarkAbsentNotConfigured verifies that marking a key without an absence store is not supported.
+func TestEchoCache_MarkAbsentNotConfigured(t *testing.T) {
+	ec := NewEchoCache[string](store.NewLRUCache[string](10))
+	assert.ErrorIs(t, ec.MarkAbsent(context.Background(), "k"), store.ErrNotSupported)
+	assert.ErrorIs(t, ec.ClearAbsent(context.Background(), "k"), store.ErrNotSupported)
+}
+
+// TestEchoCacheLazy_MarkAbsent verifies that the lazy cache drops the stale value of a key marked absent.
+func TestEchoCacheLazy_MarkAbsent(t *testing.T) {
+	ctx := context.Background()
+	ec := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Second, WithAbsenceStore(store.NewLRUCache[bool](10), time.Hour))
+	defer ec.ShutdownLazyRefresh()
+	require.NoError(t, ec.BulkSet(ctx, map[string]string{"user:1": "alice"}))
+
+	require.NoError(t, ec.MarkAbsent(ctx, "user:1"))
+	_, exists, err := ec.FetchWithLazyRefresh(ctx, "user:1", func(ctx context.Context) (string, error) {
+		t.Fatal("refresh function invoked for an absent key")
+		return "", nil
+	}, time.Minute)
+	assert.ErrorIs(t, err, ErrPermanentlyAbsent)
+	assert.False(t, exists)
+}
@@ -0,0 +1,108 @@
+package echocache
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/logocomune/echocache/store"
+	"net/http"
+	"strconv"
+)
+
+// AdminHandler returns an HTTP handler exposing the registered caches to operators:
+//
+//	GET  /caches              statistics of every cache, by name
+//	GET  /caches/{name}       statistics of a single cache
+//	POST /caches/{name}/clear removes every entry of a cache
+//	POST /caches/{name}/resize?size=N changes the capacity of an in-memory cache
+//	GET  /caches/{name}/keys/{key} metadata of a single key: age, TTL, revision, size and producer node
+//	GET  /cachestats          aggregated statistics, see StatsHandler
+//
+// The handler is meant to be mounted on an internal listener, e.g. with http.StripPrefix("/admin", r.AdminHandler()).
+func (r *Registry) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /caches", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, r.Stats())
+	})
+	mux.HandleFunc("GET /caches/{name}", func(w http.ResponseWriter, req *http.Request) {
+		h, ok := r.Handle(req.PathValue("name"))
+		if !ok {
+			http.Error(w, "cache not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, h.Stats())
+	})
+	mux.HandleFunc("POST /caches/{name}/clear", func(w http.ResponseWriter, req *http.Request) {
+		h, ok := r.Handle(req.PathValue("name"))
+		if !ok {
+			http.Error(w, "cache not found", http.StatusNotFound)
+			return
+		}
+		err := h.Clear(req.Context())
+		switch {
+		case errors.Is(err, store.ErrNotSupported):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("POST /caches/{name}/resize", func(w http.ResponseWriter, req *http.Request) {
+		h, ok := r.Handle(req.PathValue("name"))
+		if !ok {
+			http.Error(w, "cache not found", http.StatusNotFound)
+			return
+		}
+		resizer, ok := h.(Resizable)
+		if !ok {
+			http.Error(w, store.ErrNotSupported.Error(), http.StatusNotImplemented)
+			return
+		}
+		size, err := strconv.Atoi(req.URL.Query().Get("size"))
+		if err != nil {
+			http.Error(w, "invalid size", http.StatusBadRequest)
+			return
+		}
+		evicted, err := resizer.Resize(size)
+		switch {
+		case errors.Is(err, store.ErrNotSupported):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		case errors.Is(err, store.ErrInvalidSize):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusOK, map[string]int{"size": size, "evicted": evicted})
+		}
+	})
+	mux.HandleFunc("GET /caches/{name}/keys/{key...}", func(w http.ResponseWriter, req *http.Request) {
+		h, ok := r.Handle(req.PathValue("name"))
+		if !ok {
+			http.Error(w, "cache not found", http.StatusNotFound)
+			return
+		}
+		inspector, ok := h.(KeyInspector)
+		if !ok {
+			http.Error(w, store.ErrNotSupported.Error(), http.StatusNotImplemented)
+			return
+		}
+		info, err := inspector.Inspect(req.Context(), req.PathValue("key"))
+		switch {
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		case !info.Exists:
+			writeJSON(w, http.StatusNotFound, info)
+		default:
+			writeJSON(w, http.StatusOK, info)
+		}
+	})
+	mux.Handle("GET /cachestats", r.StatsHandler())
+	return mux
+}
+
+// writeJSON writes v as the JSON body of the response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,186 @@
+package echocache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// FetchManyWithCache is FetchWithCache for many keys, as served by list endpoints: the keys are read from the store
+// in a single round-trip when it implements store.BulkGetter, with MGET on Redis, the misses are computed with a
+// single call to refreshFn and written back in bulk. The result holds the value of every key found or computed; keys
+// refreshFn returned no value for are left out, and so are keys with an active tombstone, which are not computed.
+// When refreshFn fails, the values found in the cache are returned along with its error.
+//
+// Keys marked permanently absent and keys in failure cooldown are left out without being computed, a refresh budget
+// that is exceeded fails the batch with ErrBudgetExceeded, and the refresh hook is called for every computed key.
+// Unlike single-key fetches, the batch is neither shared with concurrent fetches through singleflight nor guarded by
+// the distributed lock, and ErrPermanentlyAbsent returned by refreshFn marks no key absent.
+func (ec *EchoCache[T]) FetchManyWithCache(ctx context.Context, keys []string, refreshFn BatchRefreshFunc[T]) (map[string]T, error) {
+	if ec.keys == nil {
+		return ec.fetchMany(ctx, keys, refreshFn)
+	}
+	// Transform every key once, so the values are read and stored under the same keys and returned under the keys
+	// given, even when refreshFn runs after a time bucket ended.
+	keyed := make(map[string]string, len(keys))
+	raw := make(map[string]string, len(keys))
+	storeKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		k := ec.keys(key)
+		keyed[key] = k
+		raw[k] = key
+		storeKeys = append(storeKeys, k)
+	}
+	found, err := ec.fetchMany(ctx, storeKeys, func(ctx context.Context, keys []string) (map[string]T, error) {
+		rawKeys := make([]string, len(keys))
+		for i, key := range keys {
+			rawKeys[i] = raw[key]
+		}
+		computed, err := refreshFn(ctx, rawKeys)
+		values := make(map[string]T, len(computed))
+		for key, value := range computed {
+			if k, ok := keyed[key]; ok {
+				values[k] = value
+			}
+		}
+		return values, err
+	})
+	result := make(map[string]T, len(found))
+	for key, value := range found {
+		result[raw[key]] = value
+	}
+	return result, err
+}
+
+// fetchMany implements FetchManyWithCache for store keys.
+func (ec *EchoCache[T]) fetchMany(ctx context.Context, keys []string, refreshFn BatchRefreshFunc[T]) (map[string]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result := make(map[string]T, len(keys))
+	lookup := make([]string, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		if ec.graves.active(key) {
+			ec.counters.miss(key)
+			continue
+		}
+		if shared, ok := sharedValue[T](ec.bus, ec.busTopic, key); ok {
+			ec.counters.hit(key)
+			result[key] = shared
+			continue
+		}
+		lookup = append(lookup, key)
+	}
+
+	found, err := store.BulkGet[T](ctx, ec.store, lookup)
+	if err != nil {
+		// Log the error but proceed with computation.
+		slog.Warn("Cannot get values from cache", slog.String("error", err.Error()), slog.Int("keys", len(lookup)))
+	}
+	missing := make([]string, 0, len(lookup))
+	for _, key := range lookup {
+		if value, ok := found[key]; ok {
+			ec.counters.hit(key)
+			result[key] = value
+			continue
+		}
+		ec.counters.miss(key)
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	settings := ec.settings.Load()
+	refresh := missing[:0]
+	for _, key := range missing {
+		if ec.absent.absent(ctx, key) {
+			continue
+		}
+		if settings.cooldown.blocked(key) {
+			ec.counters.failure(key)
+			continue
+		}
+		refresh = append(refresh, key)
+	}
+	if len(refresh) == 0 {
+		return result, nil
+	}
+	if settings.budget.exceeded(ctx) {
+		for _, key := range refresh {
+			ec.counters.failure(key)
+		}
+		return result, ErrBudgetExceeded
+	}
+
+	versions := make(map[string]uint64, len(refresh))
+	for _, key := range refresh {
+		ec.inFlight.start(key)
+		versions[key] = ec.versions.begin(key)
+		defer ec.versions.end(key)
+	}
+	start := time.Now()
+	computed, err := refreshFn(ctx, refresh)
+	elapsed := time.Since(start)
+	for _, key := range refresh {
+		ec.inFlight.done(key)
+	}
+	if err == nil {
+		settings.budget.observe(elapsed)
+	}
+	rid := correlationID(ctx, newID(ec.ids, requestIDLength))
+	for _, key := range refresh {
+		settings.cooldown.record(ctx, key, err)
+		if hook := settings.opts.refreshHook; hook != nil {
+			hook(RefreshEvent{Key: key, RequestID: rid, Duration: elapsed, Err: err})
+		}
+	}
+	if err != nil {
+		for _, key := range refresh {
+			ec.counters.failure(key)
+		}
+		return result, err
+	}
+
+	entries := make(map[string]T, len(refresh))
+	for _, key := range refresh {
+		if value, ok := computed[key]; ok {
+			result[key] = value
+			entries[key] = value
+		}
+	}
+	undo := func(key string) {
+		if err := store.Delete(context.WithoutCancel(ctx), ec.store, key); err != nil {
+			slog.Warn("Cannot delete superseded value", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		}
+	}
+	entries, err = writeEntries(ec.graves, entries, func(entries map[string]T) error {
+		return store.BulkSet[T](ctx, ec.store, entries)
+	}, undo)
+	if err != nil {
+		// Log the error but still return the computed values.
+		slog.Warn("Failed to store computed values in cache", slog.String("error", err.Error()), slog.Int("keys", len(entries)))
+	}
+	for key := range entries {
+		if ec.versions.superseded(key, versions[key]) {
+			// Updated by UpdateThrough while computing: the value may precede the update.
+			delete(entries, key)
+			undo(key)
+		}
+	}
+	createdAt := time.Now()
+	for key, value := range entries {
+		ec.fallback.set(ctx, key, value, createdAt)
+		ec.bus.publishIf(BusEvent{Topic: ec.busTopic, Key: key, Kind: BusWarmed, Value: value}, func() bool {
+			return !ec.graves.active(key) && !ec.versions.superseded(key, versions[key])
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyInvalidatingStore invalidates one key through ec right before writing it, as an Invalidate landing between the
+// tombstone check of a batch fetch and its write.
+type keyInvalidatingStore struct {
+	store.Cacher[string]
+	ec  *EchoCache[string]
+	key string
+}
+
+// Set invalidates the key first when it is the configured one, then writes the value.
+func (s *keyInvalidatingStore) Set(ctx context.Context, key string, value string) error {
+	if key == s.key {
+		if err := s.ec.Invalidate(ctx, key); err != nil {
+			return err
+		}
+	}
+	return s.Cacher.Set(ctx, key, value)
+}
+
+// Delete removes the key from the wrapped store.
+func (s *keyInvalidatingStore) Delete(ctx context.Context, key string) error {
+	return store.Delete(ctx, s.Cacher, key)
+}
+
+// TestEchoCache_FetchManyTombstoneWriteRace verifies that a batch value written while its key is invalidated does not
+// survive the invalidation.
+func TestEchoCache_FetchManyTombstoneWriteRace(t *testing.T) {
+	lru := store.NewLRUCache[string](10)
+	s := &keyInvalidatingStore{Cacher: lru, key: "a"}
+	ec := NewEchoCache[string](s, WithTombstones(time.Hour))
+	s.ec = ec
+
+	values, err := ec.FetchManyWithCache(t.Context(), []string{"a", "b"}, func(ctx context.Context, keys []string) (map[string]string, error) {
+		return map[string]string{"a": "old", "b": "new"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "old", "b": "new"}, values)
+	_, exists, _ := lru.Get(t.Context(), "a")
+	assert.False(t, exists, "a value written during the invalidation must be removed")
+	_, exists, _ = lru.Get(t.Context(), "b")
+	assert.True(t, exists)
+}
+
+// TestEchoCache_FetchManySafeguards verifies that batch fetches skip keys in failure cooldown and report every
+// computed key to the refresh hook.
+func TestEchoCache_FetchManySafeguards(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []RefreshEvent
+	)
+	ec := NewEchoCache[string](store.NewLRUCache[string](10), WithFailureCooldown(time.Minute, time.Hour), WithRefreshHook(func(e RefreshEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}))
+
+	_, err := ec.FetchManyWithCache(t.Context(), []string{"a"}, func(ctx context.Context, keys []string) (map[string]string, error) {
+		return nil, errors.New("upstream down")
+	})
+	assert.EqualError(t, err, "upstream down")
+
+	var requested []string
+	values, err := ec.FetchManyWithCache(t.Context(), []string{"a", "b"}, func(ctx context.Context, keys []string) (map[string]string, error) {
+		requested = keys
+		return map[string]string{"a": "1", "b": "2"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, requested)
+	assert.Equal(t, map[string]string{"b": "2"}, values)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, "a", events[0].Key)
+	assert.EqualError(t, events[0].Err, "upstream down")
+	assert.Equal(t, "b", events[1].Key)
+	assert.NoError(t, events[1].Err)
+}
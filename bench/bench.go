@@ -0,0 +1,206 @@
+// Package bench provides a reusable load generator to compare cache backends and configurations
+// (key cardinality, zipf skew, value size) before running them in production.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/store"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config describes a load test run.
+// Keys is the key cardinality and Skew the zipf exponent of the key distribution (values <= 1 select keys uniformly).
+// Each miss is resolved by Loader, defaulting to a function returning ValueSize random bytes after LoaderLatency.
+type Config struct {
+	Name          string
+	Cache         store.Cacher[[]byte]
+	Keys          int
+	Skew          float64
+	ValueSize     int
+	Operations    int
+	Concurrency   int
+	LoaderLatency time.Duration
+	Loader        func(ctx context.Context, key string) ([]byte, error)
+}
+
+// Report summarizes the outcome of a load test run.
+type Report struct {
+	Name       string
+	Operations int
+	Hits       int
+	Misses     int
+	Loads      int
+	Errors     int
+	HitRatio   float64
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	Elapsed    time.Duration
+	Throughput float64
+}
+
+// String renders the report on a single line, suitable for side-by-side comparisons.
+func (r Report) String() string {
+	return fmt.Sprintf("%s: ops=%d hit_ratio=%.3f loads=%d errors=%d p50=%s p90=%s p99=%s max=%s throughput=%.0f/s",
+		r.Name, r.Operations, r.HitRatio, r.Loads, r.Errors, r.P50, r.P90, r.P99, r.Max, r.Throughput)
+}
+
+// Run executes the load test described by cfg through an EchoCache built on cfg.Cache and returns its report.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if cfg.Cache == nil {
+		return Report{}, errors.New("bench: no cache configured")
+	}
+	if cfg.Keys <= 0 {
+		cfg.Keys = 1000
+	}
+	if cfg.Operations <= 0 {
+		cfg.Operations = 10000
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.ValueSize <= 0 {
+		cfg.ValueSize = 128
+	}
+	loader := cfg.Loader
+	if loader == nil {
+		loader = defaultLoader(cfg.ValueSize, cfg.LoaderLatency)
+	}
+
+	counting := &countingCacher{inner: cfg.Cache}
+	ec := echocache.NewEchoCache[[]byte](counting)
+
+	var (
+		loads     atomic.Int64
+		errs      atomic.Int64
+		remaining atomic.Int64
+		wg        sync.WaitGroup
+	)
+	remaining.Store(int64(cfg.Operations))
+	latencies := make([][]time.Duration, cfg.Concurrency)
+
+	start := time.Now()
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			next := keyGenerator(cfg.Keys, cfg.Skew, uint64(worker))
+			for remaining.Add(-1) >= 0 {
+				if ctx.Err() != nil {
+					return
+				}
+				key := next()
+				opStart := time.Now()
+				_, _, err := ec.FetchWithCache(ctx, key, func(ctx context.Context) ([]byte, error) {
+					loads.Add(1)
+					return loader(ctx, key)
+				})
+				latencies[worker] = append(latencies[worker], time.Since(opStart))
+				if err != nil {
+					errs.Add(1)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	all := slices.Concat(latencies...)
+	slices.Sort(all)
+	report := Report{
+		Name:       cfg.Name,
+		Operations: len(all),
+		Hits:       int(counting.hits.Load()),
+		Misses:     int(counting.misses.Load()),
+		Loads:      int(loads.Load()),
+		Errors:     int(errs.Load()),
+		P50:        percentile(all, 0.50),
+		P90:        percentile(all, 0.90),
+		P99:        percentile(all, 0.99),
+		Elapsed:    elapsed,
+	}
+	if len(all) > 0 {
+		report.Max = all[len(all)-1]
+		report.Throughput = float64(len(all)) / elapsed.Seconds()
+	}
+	if lookups := report.Hits + report.Misses; lookups > 0 {
+		report.HitRatio = float64(report.Hits) / float64(lookups)
+	}
+	return report, ctx.Err()
+}
+
+// keyGenerator returns a function producing keys following a zipf distribution with the given skew,
+// or a uniform distribution when skew <= 1.
+func keyGenerator(keys int, skew float64, seed uint64) func() string {
+	r := rand.New(rand.NewPCG(seed, uint64(time.Now().UnixNano())))
+	if skew <= 1 {
+		return func() string {
+			return "key:" + strconv.Itoa(r.IntN(keys))
+		}
+	}
+	zipf := rand.NewZipf(r, skew, 1, uint64(keys-1))
+	return func() string {
+		return "key:" + strconv.FormatUint(zipf.Uint64(), 10)
+	}
+}
+
+// defaultLoader returns a loader producing random values of the given size after the given latency.
+func defaultLoader(size int, latency time.Duration) func(ctx context.Context, key string) ([]byte, error) {
+	return func(ctx context.Context, key string) ([]byte, error) {
+		if latency > 0 {
+			timer := time.NewTimer(latency)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		value := make([]byte, size)
+		for i := range value {
+			value[i] = byte(rand.IntN(256))
+		}
+		return value, nil
+	}
+}
+
+// percentile returns the p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+// countingCacher wraps a cache counting store hits and misses.
+type countingCacher struct {
+	inner  store.Cacher[[]byte]
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Get delegates to the wrapped cache and records the outcome.
+func (c *countingCacher) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, exists, err := c.inner.Get(ctx, key)
+	if exists {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return value, exists, err
+}
+
+// Set delegates to the wrapped cache.
+func (c *countingCacher) Set(ctx context.Context, key string, value []byte) error {
+	return c.inner.Set(ctx, key, value)
+}
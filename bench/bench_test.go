@@ -0,0 +1,37 @@
+package bench
+
+import (
+	"context"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRun verifies that a run produces a consistent report for a skewed workload.
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), Config{
+		Name:        "lru",
+		Cache:       store.NewLRUCache[[]byte](50),
+		Keys:        200,
+		Skew:        1.2,
+		ValueSize:   16,
+		Operations:  2000,
+		Concurrency: 4,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2000, report.Operations)
+	assert.Equal(t, 2000, report.Hits+report.Misses)
+	assert.Greater(t, report.HitRatio, 0.0)
+	assert.LessOrEqual(t, report.Loads, report.Misses)
+	assert.LessOrEqual(t, report.P50, report.P99)
+	assert.Contains(t, report.String(), "lru:")
+}
+
+// TestRun_RequiresCache verifies configuration validation.
+func TestRun_RequiresCache(t *testing.T) {
+	_, err := Run(context.Background(), Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package echocache
+
+import (
+	"sync"
+	"time"
+)
+
+// busSweepThreshold is the number of shared values above which expired ones are swept on every publication.
+const busSweepThreshold = 1024
+
+// BusEventKind is the kind of a BusEvent.
+type BusEventKind int
+
+const (
+	// BusInvalidated reports that a key was invalidated by one of the caches of the topic.
+	BusInvalidated BusEventKind = iota
+	// BusWarmed reports that a cache of the topic computed and stored a value for a key.
+	BusWarmed
+)
+
+// BusEvent is published on an EventBus. Value is the value computed for BusWarmed events, a T for EchoCache and a
+// store.StaleValue[T] for EchoCacheLazy.
+type BusEvent struct {
+	Topic string
+	Key   string
+	Kind  BusEventKind
+	Value any
+}
+
+// busKey identifies a shared value.
+type busKey struct {
+	topic string
+	key   string
+}
+
+// busValue is a shared value and its expiry.
+type busValue struct {
+	value any
+	until time.Time
+}
+
+// EventBus is a lightweight in-process event bus coordinating several caches over the same backend within one
+// process, such as per-module caches, see WithEventBus. Caches of a topic share their invalidations and, for
+// valueTTL when positive, the values they compute, so a value computed by one is served to the others without each
+// of them reading the backend. Subscribers are called synchronously and must not block.
+// A nil *EventBus is valid and does nothing.
+type EventBus struct {
+	valueTTL time.Duration
+
+	mu     sync.RWMutex
+	subs   map[string]map[uint64]func(BusEvent)
+	nextID uint64
+	values map[busKey]busValue
+}
+
+// NewEventBus creates an event bus sharing computed values for valueTTL; a non-positive valueTTL shares
+// invalidations only.
+func NewEventBus(valueTTL time.Duration) *EventBus {
+	return &EventBus{
+		valueTTL: valueTTL,
+		subs:     make(map[string]map[uint64]func(BusEvent)),
+		values:   make(map[busKey]busValue),
+	}
+}
+
+// Subscribe registers fn for the events of the topic and returns a function removing the subscription.
+func (b *EventBus) Subscribe(topic string, fn func(BusEvent)) func() {
+	if b == nil {
+		return func() {}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[uint64]func(BusEvent))
+	}
+	b.subs[topic][id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[topic], id)
+	}
+}
+
+// Publish records the value of a BusWarmed event, or forgets the shared value of a BusInvalidated one, and
+// delivers the event to every subscriber of its topic.
+func (b *EventBus) Publish(e BusEvent) {
+	b.publishIf(e, nil)
+}
+
+// publishIf publishes the event unless valid, when set, reports false. valid is evaluated with the bus locked, so an
+// invalidation published once it returned true is delivered after the event and forgets its value.
+func (b *EventBus) publishIf(e BusEvent, valid func() bool) {
+	if b == nil {
+		return
+	}
+	k := busKey{topic: e.Topic, key: e.Key}
+	now := time.Now()
+	b.mu.Lock()
+	if valid != nil && !valid() {
+		b.mu.Unlock()
+		return
+	}
+	switch {
+	case e.Kind == BusInvalidated:
+		delete(b.values, k)
+	case e.Kind == BusWarmed && b.valueTTL > 0:
+		if len(b.values) >= busSweepThreshold {
+			for key, v := range b.values {
+				if !now.Before(v.until) {
+					delete(b.values, key)
+				}
+			}
+		}
+		b.values[k] = busValue{value: e.Value, until: now.Add(b.valueTTL)}
+	}
+	subs := make([]func(BusEvent), 0, len(b.subs[e.Topic]))
+	for _, fn := range b.subs[e.Topic] {
+		subs = append(subs, fn)
+	}
+	b.mu.Unlock()
+	for _, fn := range subs {
+		fn(e)
+	}
+}
+
+// value returns the unexpired value shared for the key of the topic.
+func (b *EventBus) value(topic string, key string) (any, bool) {
+	if b == nil {
+		return nil, false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	v, ok := b.values[busKey{topic: topic, key: key}]
+	if !ok || !time.Now().Before(v.until) {
+		return nil, false
+	}
+	return v.value, true
+}
+
+// sharedValue returns the value shared on the bus for the key of the topic when it has type V.
+func sharedValue[V any](b *EventBus, topic string, key string) (V, bool) {
+	v, ok := b.value(topic, key)
+	if !ok {
+		var zeroValue V
+		return zeroValue, false
+	}
+	typed, ok := v.(V)
+	return typed, ok
+}
@@ -0,0 +1,124 @@
+package echocache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingGets counts the reads reaching a store.
+type countingGets[T any] struct {
+	store.Cacher[T]
+	gets atomic.Int32
+}
+
+// Get counts the read and delegates it.
+func (c *countingGets[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	c.gets.Add(1)
+	return c.Cacher.Get(ctx, key)
+}
+
+// Delete delegates to the wrapped store.
+func (c *countingGets[T]) Delete(ctx context.Context, key string) error {
+	return store.Delete(ctx, c.Cacher, key)
+}
+
+// TestEventBus_SharesValuesAndInvalidations verifies that caches of a topic serve each other's values without
+// reading the backend and share invalidations.
+func TestEventBus_SharesValuesAndInvalidations(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingGets[string]{Cacher: store.NewLRUCache[string](10)}
+	bus := NewEventBus(time.Minute)
+	a := NewEchoCache[string](backend, WithEventBus(bus, "users"))
+	b := NewEchoCache[string](backend, WithEventBus(bus, "users"), WithTombstones(time.Minute))
+
+	value, _, err := a.FetchWithCache(ctx, "u1", func(ctx context.Context) (string, error) {
+		return "alice", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", value)
+	gets := backend.gets.Load()
+
+	value, exists, err := b.FetchWithCache(ctx, "u1", func(ctx context.Context) (string, error) {
+		t.Fatal("value recomputed by the second cache")
+		return "", nil
+	})
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "alice", value)
+	assert.Equal(t, gets, backend.gets.Load())
+
+	require.NoError(t, a.Invalidate(ctx, "u1"))
+	_, exists, err = b.FetchWithCache(ctx, "u1", func(ctx context.Context) (string, error) {
+		return "stale", nil
+	})
+	require.NoError(t, err)
+	assert.False(t, exists, "the invalidation must leave a tombstone on the second cache")
+}
+
+// TestEventBus_Subscribe verifies delivery to the subscribers of a topic and unsubscription.
+func TestEventBus_Subscribe(t *testing.T) {
+	bus := NewEventBus(0)
+	var received []BusEvent
+	unsubscribe := bus.Subscribe("t", func(e BusEvent) {
+		received = append(received, e)
+	})
+	bus.Publish(BusEvent{Topic: "t", Key: "k", Kind: BusWarmed, Value: 1})
+	bus.Publish(BusEvent{Topic: "other", Key: "k", Kind: BusInvalidated})
+	unsubscribe()
+	bus.Publish(BusEvent{Topic: "t", Key: "k", Kind: BusInvalidated})
+
+	require.Len(t, received, 1)
+	assert.Equal(t, BusWarmed, received[0].Kind)
+	_, shared := bus.value("t", "k")
+	assert.False(t, shared, "values are not shared without a value TTL")
+}
+
+// TestEventBus_Lazy verifies that lazy caches serve values refreshed by another cache of the topic.
+func TestEventBus_Lazy(t *testing.T) {
+	ctx := context.Background()
+	backend := store.NewStaleWhileRevalidateLRUCache[string](10)
+	bus := NewEventBus(time.Minute)
+	a := NewLazyEchoCache[string](backend, time.Second, WithEventBus(bus, "lazy"))
+	defer a.ShutdownLazyRefresh()
+	b := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Second, WithEventBus(bus, "lazy"))
+	defer b.ShutdownLazyRefresh()
+
+	_, _, err := a.FetchWithLazyRefresh(ctx, "k", func(ctx context.Context) (string, error) {
+		return "v", nil
+	}, time.Minute)
+	require.NoError(t, err)
+
+	value, exists, err := b.FetchWithLazyRefresh(ctx, "k", func(ctx context.Context) (string, error) {
+		return "recomputed", nil
+	}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "v", value)
+}
+
+// TestEventBus_Unsubscribe verifies that closed caches no longer hold a subscription on the bus and that values
+// failing the publication check are not shared.
+func TestEventBus_Unsubscribe(t *testing.T) {
+	bus := NewEventBus(time.Minute)
+	subscribers := func() int {
+		bus.mu.RLock()
+		defer bus.mu.RUnlock()
+		return len(bus.subs["users"])
+	}
+	ec := NewEchoCache[string](store.NewLRUCache[string](10), WithEventBus(bus, "users"), WithTombstones(time.Minute))
+	lazy := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Second, WithEventBus(bus, "users"))
+	assert.Equal(t, 2, subscribers())
+	ec.Close()
+	lazy.ShutdownLazyRefresh()
+	assert.Zero(t, subscribers())
+
+	bus.publishIf(BusEvent{Topic: "users", Key: "u1", Kind: BusWarmed, Value: "old"}, func() bool { return false })
+	_, ok := bus.value("users", "u1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,139 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// LocalClearer is implemented by handles able to flush the entries their cache holds in process memory, such as
+// the handles returned by HandleOf and LazyHandleOf.
+type LocalClearer interface {
+	ClearLocal(ctx context.Context) error
+}
+
+// KeyInvalidator is implemented by handles able to invalidate single keys of their cache, such as the handles
+// returned by HandleOf and LazyHandleOf.
+type KeyInvalidator interface {
+	Invalidate(ctx context.Context, key string) error
+}
+
+// LocalInvalidator is implemented by handles able to drop single keys from the entries their cache holds in process
+// memory, such as the handles returned by HandleOf and LazyHandleOf.
+type LocalInvalidator interface {
+	InvalidateLocal(ctx context.Context, key string) error
+}
+
+// ClearCoordinator flushes the caches of a Registry on every instance of a cluster in one operation, for incident
+// response: BroadcastClear clears the shared backend once and a store.ClearBroadcaster, such as Redis pub/sub or
+// NATS, asks every instance running Run to flush its local entries. Namespaces are the names the caches are
+// registered under.
+type ClearCoordinator struct {
+	registry    *Registry
+	broadcaster store.ClearBroadcaster
+	origin      string
+}
+
+// NewClearCoordinator creates a coordinator for the caches of r. origin identifies this instance in the commands it
+// issues and is random when empty.
+func NewClearCoordinator(r *Registry, b store.ClearBroadcaster, origin string) *ClearCoordinator {
+	if origin == "" {
+		origin = randString(requestIDLength)
+	}
+	return &ClearCoordinator{registry: r, broadcaster: b, origin: origin}
+}
+
+// BroadcastClear clears the cache registered under namespace, every cache when namespace is empty, backend entries
+// included, then asks the other instances to flush their local entries. The command is broadcast even when the
+// local clear fails, and the errors are joined.
+func (c *ClearCoordinator) BroadcastClear(ctx context.Context, namespace string) error {
+	var errs []error
+	for name, h := range c.handles(namespace) {
+		if err := h.Clear(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	cmd := store.ClearCommand{Namespace: namespace, Origin: c.origin, IssuedAt: time.Now()}
+	if err := c.broadcaster.PublishClear(ctx, cmd); err != nil {
+		errs = append(errs, fmt.Errorf("broadcast: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// BroadcastInvalidate invalidates the keys in the cache registered under namespace, in every cache when namespace
+// is empty, backend entries included, then asks the other instances to drop their local copies. The command is
+// broadcast even when the local invalidation fails, and the errors are joined.
+func (c *ClearCoordinator) BroadcastInvalidate(ctx context.Context, namespace string, keys ...string) error {
+	var errs []error
+	for name, h := range c.handles(namespace) {
+		invalidator, ok := h.(KeyInvalidator)
+		if !ok {
+			continue
+		}
+		for _, key := range keys {
+			if err := invalidator.Invalidate(ctx, key); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", name, key, err))
+			}
+		}
+	}
+	cmd := store.ClearCommand{Namespace: namespace, Keys: keys, Origin: c.origin, IssuedAt: time.Now()}
+	if err := c.broadcaster.PublishClear(ctx, cmd); err != nil {
+		errs = append(errs, fmt.Errorf("broadcast: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Run listens for clear commands until ctx is done, flushing the local entries of the caches of their namespace, or
+// only the keys of the command when it lists some. Commands issued by this coordinator are skipped, BroadcastClear
+// and BroadcastInvalidate having already applied them to its caches.
+func (c *ClearCoordinator) Run(ctx context.Context) error {
+	return c.broadcaster.SubscribeClear(ctx, func(ctx context.Context, cmd store.ClearCommand) {
+		if cmd.Origin == c.origin {
+			return
+		}
+		if len(cmd.Keys) > 0 {
+			c.invalidateLocal(ctx, cmd)
+			return
+		}
+		for name, h := range c.handles(cmd.Namespace) {
+			clearer, ok := h.(LocalClearer)
+			if !ok {
+				continue
+			}
+			if err := clearer.ClearLocal(ctx); err != nil {
+				slog.Warn("Cannot flush local cache entries", slog.String("cache", name), slog.String("origin", cmd.Origin), slog.String("error", err.Error()))
+			}
+		}
+	})
+}
+
+// invalidateLocal drops the keys of the command from the local entries of the caches of its namespace.
+func (c *ClearCoordinator) invalidateLocal(ctx context.Context, cmd store.ClearCommand) {
+	for name, h := range c.handles(cmd.Namespace) {
+		invalidator, ok := h.(LocalInvalidator)
+		if !ok {
+			continue
+		}
+		for _, key := range cmd.Keys {
+			if err := invalidator.InvalidateLocal(ctx, key); err != nil {
+				slog.Warn("Cannot drop local cache entry", slog.String("cache", name), slog.String("cacheKey", key), slog.String("origin", cmd.Origin), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// handles returns the handle registered under namespace, or every handle when namespace is empty.
+func (c *ClearCoordinator) handles(namespace string) map[string]Handle {
+	if namespace == "" {
+		return c.registry.snapshot()
+	}
+	h, ok := c.registry.Handle(namespace)
+	if !ok {
+		return nil
+	}
+	return map[string]Handle{namespace: h}
+}
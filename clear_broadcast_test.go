@@ -0,0 +1,136 @@
+package echocache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBroadcaster delivers clear commands to every subscriber, like a pub/sub channel shared by instances.
+type memoryBroadcaster struct {
+	mu   sync.Mutex
+	subs []chan store.ClearCommand
+}
+
+func (m *memoryBroadcaster) PublishClear(_ context.Context, cmd store.ClearCommand) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sub := range m.subs {
+		sub <- cmd
+	}
+	return nil
+}
+
+func (m *memoryBroadcaster) SubscribeClear(ctx context.Context, handler func(ctx context.Context, cmd store.ClearCommand)) error {
+	sub := make(chan store.ClearCommand, 8)
+	m.mu.Lock()
+	m.subs = append(m.subs, sub)
+	m.mu.Unlock()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case cmd := <-sub:
+			handler(ctx, cmd)
+		}
+	}
+}
+
+func (m *memoryBroadcaster) subscribers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.subs)
+}
+
+// TestClearCoordinator_BroadcastClear verifies that one call flushes the shared backend and the L1 of every instance.
+func TestClearCoordinator_BroadcastClear(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	shared := store.NewLRUCache[string](100)
+	broadcaster := &memoryBroadcaster{}
+
+	type instance struct {
+		l1    store.Cacher[string]
+		users *EchoCache[string]
+		coord *ClearCoordinator
+	}
+	newInstance := func() instance {
+		l1 := store.NewLRUCache[string](100)
+		users := NewEchoCache[string](store.NewTieredCache[string](l1, shared))
+		r := NewRegistry()
+		require.NoError(t, RegisterCache(r, "users", users))
+		require.NoError(t, RegisterCache(r, "orders", NewEchoCache[string](store.NewLRUCache[string](10))))
+		coord := NewClearCoordinator(r, broadcaster, "")
+		go func() { _ = coord.Run(ctx) }()
+		return instance{l1: l1, users: users, coord: coord}
+	}
+	a, b := newInstance(), newInstance()
+	require.Eventually(t, func() bool { return broadcaster.subscribers() == 2 }, time.Second, time.Millisecond)
+
+	refresh := func(ctx context.Context) (string, error) { return "alice", nil }
+	for _, i := range []instance{a, b} {
+		_, _, err := i.users.FetchWithCache(ctx, "u1", refresh)
+		require.NoError(t, err)
+	}
+	_, exists, _ := b.l1.Get(ctx, "u1")
+	require.True(t, exists)
+
+	require.NoError(t, a.coord.BroadcastClear(ctx, "users"))
+	_, exists, _ = a.l1.Get(ctx, "u1")
+	assert.False(t, exists)
+	size, _ := store.Len(shared)
+	assert.Equal(t, 0, size)
+	require.Eventually(t, func() bool {
+		_, exists, _ := b.l1.Get(ctx, "u1")
+		return !exists
+	}, time.Second, time.Millisecond)
+}
+
+// TestClearCoordinator_BroadcastInvalidate verifies that only the listed keys are dropped from the shared backend
+// and from the L1 of every instance.
+func TestClearCoordinator_BroadcastInvalidate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	shared := store.NewLRUCache[string](100)
+	broadcaster := &memoryBroadcaster{}
+
+	newInstance := func() (store.Cacher[string], *EchoCache[string], *ClearCoordinator) {
+		l1 := store.NewLRUCache[string](100)
+		users := NewEchoCache[string](store.NewTieredCache[string](l1, shared))
+		r := NewRegistry()
+		require.NoError(t, RegisterCache(r, "users", users))
+		coord := NewClearCoordinator(r, broadcaster, "")
+		go func() { _ = coord.Run(ctx) }()
+		return l1, users, coord
+	}
+	l1A, usersA, coordA := newInstance()
+	l1B, usersB, _ := newInstance()
+	require.Eventually(t, func() bool { return broadcaster.subscribers() == 2 }, time.Second, time.Millisecond)
+
+	refresh := func(ctx context.Context) (string, error) { return "alice", nil }
+	for _, users := range []*EchoCache[string]{usersA, usersB} {
+		for _, key := range []string{"u1", "u2"} {
+			_, _, err := users.FetchWithCache(ctx, key, refresh)
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, coordA.BroadcastInvalidate(ctx, "users", "u1"))
+	_, exists, _ := l1A.Get(ctx, "u1")
+	assert.False(t, exists)
+	_, exists, _ = shared.Get(ctx, "u1")
+	assert.False(t, exists)
+	require.Eventually(t, func() bool {
+		_, exists, _ := l1B.Get(ctx, "u1")
+		return !exists
+	}, time.Second, time.Millisecond)
+	for _, l1 := range []store.Cacher[string]{l1A, l1B, shared} {
+		_, exists, _ := l1.Get(ctx, "u2")
+		assert.True(t, exists)
+	}
+}
@@ -0,0 +1,80 @@
+// Command echocache-server exposes an echocache backend over NATS request/reply, so that services written in other
+// languages and edge processes share the cache with the same keying and codecs as the Go applications.
+//
+// It builds the store from the same configuration as the application (see the config package) and answers JSON
+// requests on SUBJECT.get, SUBJECT.set and SUBJECT.invalidate, publishing invalidated keys on SUBJECT.invalidated:
+//
+//	echocache-server -config cache.yaml -subject echocache -nats nats://localhost:4222
+//	nats request echocache.set '{"key":"user:42","value":{"name":"alice"},"ttlMs":60000}'
+//	nats request echocache.get '{"key":"user:42"}'
+//
+// Go processes can use the service as a store with store.NewNatsServiceCache. Several instances can serve the same
+// subject: requests are balanced among them. Environment variables prefixed with the -env value override the
+// configuration file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/logocomune/echocache/config"
+	"github.com/logocomune/echocache/store"
+	"github.com/nats-io/nats.go"
+)
+
+// main runs the server until it is interrupted and exits with its status code.
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run parses the flags, builds the store and serves it until ctx is done, returning the exit status.
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("echocache-server", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "", "path of the YAML cache configuration")
+	envPrefix := fs.String("env", "ECHOCACHE", "prefix of the environment variables overriding the configuration")
+	subject := fs.String("subject", "echocache", "subject prefix of the service requests")
+	natsURL := fs.String("nats", "", "URL of the NATS server the service listens on (default: the nats.url of the configuration or "+nats.DefaultURL+")")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := serve(ctx, *configPath, *envPrefix, *subject, *natsURL); err != nil {
+		fmt.Fprintln(stderr, "echocache-server:", err)
+		return 1
+	}
+	return 0
+}
+
+// serve connects to NATS and answers the requests on subject with the configured store until ctx is done.
+func serve(ctx context.Context, configPath string, envPrefix string, subject string, natsURL string) error {
+	cfg, err := config.LoadWithEnv(configPath, envPrefix)
+	if err != nil {
+		return err
+	}
+	if natsURL == "" {
+		natsURL = cfg.NATS.URL
+	}
+	if natsURL == "" {
+		natsURL = nats.DefaultURL
+	}
+	c, closer, err := config.NewStore[json.RawMessage](ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	nc, err := nats.Connect(natsURL, nats.Name("echocache-server"))
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	return store.NewNatsService(nc, subject, c).Serve(ctx)
+}
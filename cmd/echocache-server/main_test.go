@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRun verifies flag and configuration validation and the report of connection failures.
+func TestRun(t *testing.T) {
+	var stderr bytes.Buffer
+	assert.Equal(t, 2, run(context.Background(), []string{"-unknown"}, &stderr))
+
+	stderr.Reset()
+	assert.Equal(t, 1, run(context.Background(), []string{"-env", "ECHOCACHE_SERVER_TEST"}, &stderr))
+	assert.Contains(t, stderr.String(), "invalid cache configuration")
+
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("backend: lru\nsize: 10\n"), 0o600))
+	stderr.Reset()
+	code := run(context.Background(), []string{"-config", path, "-env", "ECHOCACHE_SERVER_TEST", "-nats", "nats://127.0.0.1:1"}, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "echocache-server:")
+}
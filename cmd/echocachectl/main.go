@@ -0,0 +1,277 @@
+// Command echocachectl inspects and modifies the entries of an echocache backend from the command line.
+//
+// It builds the store from the same configuration as the application (see the config package), so keys are
+// prefixed, sanitized and hashed and values are decoded exactly as the application sees them:
+//
+//	echocachectl -config cache.yaml get user:42
+//	echocachectl -config cache.yaml set -ttl 1m user:42 '{"name":"alice"}'
+//	echocachectl -config cache.yaml del user:42
+//	echocachectl -config cache.yaml scan -limit 10 'user:*'
+//	echocachectl -config cache.yaml ttl user:42
+//	echocachectl -config cache.yaml stats 'user:*'
+//	echocachectl -config cache.yaml invalidate -channel cache-invalidation -namespace users user:42
+//
+// stats reports the number of keys and the hit and miss totals written to the backend by an echocache.StatsPersister.
+// invalidate deletes the keys from the backend and publishes a store.ClearCommand listing them on the channel, which
+// instances running an echocache.ClearCoordinator on the same channel apply to their local entries.
+//
+// Environment variables prefixed with the -env value override the configuration file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/config"
+	"github.com/logocomune/echocache/store"
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+)
+
+// main runs the command and exits with its status code.
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run parses the global flags, builds the store and dispatches the command, returning the exit status.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("echocachectl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "", "path of the YAML cache configuration")
+	envPrefix := fs.String("env", "ECHOCACHE", "prefix of the environment variables overriding the configuration")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of the whole command")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: echocachectl [flags] get|set|del|scan|ttl|stats|invalidate [args]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, *envPrefix)
+	if err != nil {
+		fmt.Fprintln(stderr, "echocachectl:", err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	if err := dispatch(ctx, cfg, fs.Arg(0), fs.Args()[1:], stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintln(stderr, "echocachectl:", err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig reads the configuration file, if any, and applies the environment overrides with config.LoadWithEnv.
+// The in-process L1 layer is dropped since the command only acts on the shared backend.
+func loadConfig(path string, envPrefix string) (config.Config, error) {
+	cfg, err := config.LoadWithEnv(path, envPrefix)
+	if err != nil && !errors.Is(err, config.ErrInvalidConfig) {
+		return cfg, err
+	}
+	cfg.L1 = nil
+	return cfg, cfg.Validate()
+}
+
+// dispatch builds the store and runs the named command with its arguments.
+func dispatch(ctx context.Context, cfg config.Config, name string, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	ttl := fs.Duration("ttl", 0, "time-to-live of the written value (set only; 0 uses the store default)")
+	limit := fs.Int("limit", 0, "maximum number of keys returned (scan only; 0 means no limit)")
+	channel := fs.String("channel", "", "Redis channel or NATS subject the invalidation is published on (invalidate only)")
+	namespace := fs.String("namespace", "", "registry name of the cache the keys belong to, every cache when empty (invalidate only)")
+	statsKey := fs.String("stats-key", "echocache-stats", "key of the statistics written by echocache.StatsPersister (stats only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, closer, err := config.NewStore[json.RawMessage](ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	switch name {
+	case "get":
+		if fs.NArg() != 1 {
+			return errors.New("usage: get KEY")
+		}
+		value, exists, err := c.Get(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("key %q not found", fs.Arg(0))
+		}
+		_, err = fmt.Fprintln(stdout, string(value))
+		return err
+	case "set":
+		if fs.NArg() != 2 {
+			return errors.New("usage: set [-ttl D] KEY JSON")
+		}
+		value := json.RawMessage(fs.Arg(1))
+		if !json.Valid(value) {
+			return errors.New("value is not valid JSON")
+		}
+		if *ttl <= 0 {
+			return c.Set(ctx, fs.Arg(0), value)
+		}
+		setter, ok := c.(store.TTLSetter[json.RawMessage])
+		if !ok {
+			return fmt.Errorf("-ttl: %w", store.ErrNotSupported)
+		}
+		return setter.SetWithTTL(ctx, fs.Arg(0), value, *ttl)
+	case "del":
+		if fs.NArg() != 1 {
+			return errors.New("usage: del KEY")
+		}
+		return store.Delete(ctx, c, fs.Arg(0))
+	case "scan":
+		keys, err := scan(ctx, c, fs.Args(), *limit)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fmt.Fprintln(stdout, key)
+		}
+		return nil
+	case "ttl":
+		if fs.NArg() != 1 {
+			return errors.New("usage: ttl KEY")
+		}
+		inspector, ok := c.(store.TTLInspector)
+		if !ok {
+			return store.ErrNotSupported
+		}
+		remaining, exists, err := inspector.TTL(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// TTL does not tell a missing key from one without expiry.
+			_, found, err := c.Get(ctx, fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			if !found {
+				return fmt.Errorf("key %q not found", fs.Arg(0))
+			}
+			_, err = fmt.Fprintln(stdout, "no expiration")
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, remaining.Round(time.Millisecond))
+		return err
+	case "stats":
+		keys, err := scan(ctx, c, fs.Args(), 0)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "backend: %s\n", cfg.Backend)
+		fmt.Fprintf(stdout, "prefix:  %s\n", cfg.Prefix)
+		fmt.Fprintf(stdout, "keys:    %d\n", len(keys))
+		return printStats(ctx, c, *statsKey, stdout)
+	case "invalidate":
+		if fs.NArg() == 0 || *channel == "" {
+			return errors.New("usage: invalidate -channel NAME [-namespace NAME] KEY...")
+		}
+		for _, key := range fs.Args() {
+			if err := store.Delete(ctx, c, key); err != nil && !errors.Is(err, store.ErrNotSupported) {
+				return err
+			}
+		}
+		return broadcast(ctx, cfg, *channel, store.ClearCommand{
+			Namespace: *namespace,
+			Keys:      fs.Args(),
+			Origin:    "echocachectl",
+			IssuedAt:  time.Now(),
+		})
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// scan lists the keys matching the optional pattern argument, all keys by default.
+func scan(ctx context.Context, c store.Cacher[json.RawMessage], args []string, limit int) ([]string, error) {
+	pattern := "*"
+	if len(args) > 0 {
+		pattern = args[0]
+	}
+	scanner, ok := c.(store.Scanner)
+	if !ok {
+		return nil, store.ErrNotSupported
+	}
+	return scanner.Scan(ctx, pattern, limit)
+}
+
+// printStats prints the hit and miss totals of every cache found in the statistics document stored under key, or a
+// note when there is none.
+func printStats(ctx context.Context, c store.Cacher[json.RawMessage], key string, stdout io.Writer) error {
+	data, exists, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err = fmt.Fprintf(stdout, "no hit/miss statistics under %q: run an echocache.StatsPersister to record them\n", key)
+		return err
+	}
+	var stats echocache.PersistedStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return fmt.Errorf("decode statistics: %w", err)
+	}
+	fmt.Fprintf(stdout, "since:   %s\n", stats.Since.Format(time.RFC3339))
+	fmt.Fprintf(stdout, "updated: %s\n", stats.UpdatedAt.Format(time.RFC3339))
+	names := make([]string, 0, len(stats.Caches))
+	for name := range stats.Caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := stats.Caches[name]
+		fmt.Fprintf(stdout, "%s: hits=%d stale=%d misses=%d errors=%d ratio=%.3f\n", name, t.Hits, t.StaleHits, t.Misses, t.Errors, t.HitRatio())
+	}
+	return nil
+}
+
+// broadcast publishes the command on the Redis channel or NATS subject with the library broadcaster, so that every
+// instance running a ClearCoordinator on it applies the command to its local entries.
+func broadcast(ctx context.Context, cfg config.Config, channel string, cmd store.ClearCommand) error {
+	switch cfg.Backend {
+	case config.BackendRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Username: cfg.Redis.Username,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer client.Close()
+		return store.NewRedisBroadcaster(client, channel).PublishClear(ctx, cmd)
+	case config.BackendNATS:
+		nc, err := nats.Connect(cfg.NATS.URL)
+		if err != nil {
+			return err
+		}
+		defer nc.Close()
+		return store.NewNatsBroadcaster(nc, channel).PublishClear(ctx, cmd)
+	}
+	return fmt.Errorf("invalidation broadcasts require a redis or nats backend, not %s", cfg.Backend)
+}
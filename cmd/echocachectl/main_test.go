@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/config"
+	"github.com/logocomune/echocache/store"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCommand runs echocachectl against an in-memory configuration and returns its exit status and outputs.
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	return runWithConfig(t, "backend: lru\nsize: 10\nprefix: app\n", args...)
+}
+
+// runWithConfig runs echocachectl against the given YAML configuration and returns its exit status and outputs.
+func runWithConfig(t *testing.T, yaml string, args ...string) (int, string, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"-config", path, "-env", "ECHOCACHECTL_TEST"}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// TestRun verifies command dispatch, argument validation and error reporting.
+func TestRun(t *testing.T) {
+	code, _, _ := runCommand(t, "set", "k", `{"a":1}`)
+	assert.Equal(t, 0, code)
+
+	code, _, stderr := runCommand(t, "set", "k", "not json")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "not valid JSON")
+
+	code, _, stderr = runCommand(t, "get", "k")
+	assert.Equal(t, 1, code, "every run builds a fresh in-memory store")
+	assert.Contains(t, stderr, "not found")
+
+	code, stdout, _ := runCommand(t, "stats")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "keys:    0")
+
+	code, _, stderr = runCommand(t, "ttl", "k")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "not supported")
+
+	code, _, stderr = runCommand(t, "invalidate", "-channel", "inv", "k")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "require a redis or nats backend")
+
+	code, _, _ = runCommand(t, "frobnicate")
+	assert.Equal(t, 1, code)
+
+	code, _, _ = runCommand(t)
+	assert.Equal(t, 2, code)
+}
+
+// TestRun_TTLNotFound verifies that ttl reports missing keys as not found rather than as keys without expiry.
+func TestRun_TTLNotFound(t *testing.T) {
+	code, stdout, stderr := runWithConfig(t, "backend: timing-wheel\nttl: 1m\nprefix: app\n", "ttl", "k")
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "not found")
+}
+
+// TestRun_InvalidateBroadcast verifies end to end that invalidate removes the key from the backend and from the
+// local layer of an instance running a ClearCoordinator on the channel.
+func TestRun_InvalidateBroadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+	yaml := fmt.Sprintf("backend: redis\nttl: 1m\nprefix: app\nredis:\n  addr: %s\n", server.Addr())
+
+	cfg, err := config.Load(strings.NewReader(yaml + "l1:\n  backend: lru\n  size: 10\n"))
+	require.NoError(t, err)
+	tiered, closer, err := config.NewStore[string](ctx, cfg)
+	require.NoError(t, err)
+	defer closer.Close()
+	users := echocache.NewEchoCache[string](tiered)
+	registry := echocache.NewRegistry()
+	require.NoError(t, echocache.RegisterCache(registry, "users", users))
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	coordinator := echocache.NewClearCoordinator(registry, store.NewRedisBroadcaster(client, "inv"), "app-1")
+	go func() { _ = coordinator.Run(ctx) }()
+	require.Eventually(t, func() bool { return len(server.PubSubChannels("inv")) == 1 }, time.Second, time.Millisecond)
+
+	for _, key := range []string{"u1", "u2"} {
+		_, _, err := users.FetchWithCache(ctx, key, func(ctx context.Context) (string, error) { return "alice", nil })
+		require.NoError(t, err)
+	}
+
+	code, _, stderr := runWithConfig(t, yaml, "invalidate", "-channel", "inv", "-namespace", "users", "u1")
+	require.Equal(t, 0, code, stderr)
+	code, _, _ = runWithConfig(t, yaml, "get", "u1")
+	assert.Equal(t, 1, code)
+	require.Eventually(t, func() bool {
+		_, exists, _ := tiered.Get(ctx, "u1")
+		return !exists
+	}, time.Second, time.Millisecond)
+	_, exists, err := tiered.Get(ctx, "u2")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+// TestRun_Stats verifies that stats reports the hit and miss totals persisted by a StatsPersister.
+func TestRun_Stats(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	yaml := fmt.Sprintf("backend: redis\nttl: 1m\nprefix: app\nredis:\n  addr: %s\n", server.Addr())
+
+	code, stdout, _ := runWithConfig(t, yaml, "stats")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "no hit/miss statistics")
+
+	cfg, err := config.Load(strings.NewReader(yaml))
+	require.NoError(t, err)
+	statsStore, closer, err := config.NewStore[echocache.PersistedStats](ctx, cfg)
+	require.NoError(t, err)
+	defer closer.Close()
+	users := echocache.NewEchoCache[string](store.NewLRUCache[string](10))
+	registry := echocache.NewRegistry()
+	require.NoError(t, echocache.RegisterCache(registry, "users", users))
+	for range 2 {
+		_, _, err := users.FetchWithCache(ctx, "u1", func(ctx context.Context) (string, error) { return "alice", nil })
+		require.NoError(t, err)
+	}
+	require.NoError(t, echocache.NewStatsPersister(registry, statsStore, echocache.StatsPersisterConfig{}).Flush(ctx))
+
+	code, stdout, _ = runWithConfig(t, yaml, "stats")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "keys:    1")
+	assert.Contains(t, stdout, "users: hits=1 stale=0 misses=1 errors=0 ratio=0.500")
+}
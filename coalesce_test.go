@@ -0,0 +1,109 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCoalescer verifies that concurrent misses of different keys are computed by a single batch call.
+func TestCoalescer(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	coalescer := NewCoalescer[int](20*time.Millisecond, 0, func(ctx context.Context, keys []string) (map[string]int, error) {
+		calls.Add(1)
+		values := make(map[string]int, len(keys))
+		for _, key := range keys {
+			if key != "missing" {
+				values[key], _ = strconv.Atoi(key)
+			}
+		}
+		return values, nil
+	})
+	cache := NewEchoCache[int](store.NewLRUCache[int](100))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := strconv.Itoa(i)
+			value, _, err := cache.FetchWithCache(ctx, key, coalescer.RefreshFunc(key))
+			assert.NoError(t, err)
+			assert.Equal(t, i, value)
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, _, err := cache.FetchWithCache(ctx, "missing", coalescer.RefreshFunc("missing"))
+	assert.ErrorIs(t, err, ErrBatchKeyMissing)
+}
+
+// TestCoalescer_MaxBatch verifies that full batches are sent without waiting for the window and that batch errors
+// reach every caller.
+func TestCoalescer_MaxBatch(t *testing.T) {
+	ctx := context.Background()
+	boom := errors.New("boom")
+	var sizes []int
+	var mu sync.Mutex
+	coalescer := NewCoalescer[int](time.Hour, 2, func(ctx context.Context, keys []string) (map[string]int, error) {
+		mu.Lock()
+		sizes = append(sizes, len(keys))
+		mu.Unlock()
+		return nil, boom
+	})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			_, err := coalescer.RefreshFunc(key)(ctx)
+			assert.ErrorIs(t, err, boom)
+		}(key)
+	}
+	wg.Wait()
+	assert.Equal(t, []int{2}, sizes)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := coalescer.RefreshFunc("c")(cancelled)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+// TestCoalescer_Timeout verifies that batches run with a deadline, the default one or the one set with
+// WithBatchTimeout, and that a batch exceeding it fails.
+func TestCoalescer_Timeout(t *testing.T) {
+	ctx := context.Background()
+	deadlines := make(chan time.Time, 1)
+	fn := func(ctx context.Context, keys []string) (map[string]int, error) {
+		deadline, _ := ctx.Deadline()
+		deadlines <- deadline
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	_, err := NewCoalescer[int](0, 1, fn, WithBatchTimeout(20*time.Millisecond)).RefreshFunc("a")(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.WithinDuration(t, start.Add(20*time.Millisecond), <-deadlines, 10*time.Millisecond)
+
+	defaulted := NewCoalescer[int](0, 1, func(ctx context.Context, keys []string) (map[string]int, error) {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(defaultBatchTimeout), deadline, time.Second)
+		return map[string]int{"a": 1}, nil
+	})
+	value, err := defaulted.RefreshFunc("a")(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
@@ -0,0 +1,232 @@
+// Package codecbench runs sample values through the available codecs and reports the encoded size and the CPU time
+// of each, so that the codec of a cache is chosen from measurements on its real payloads rather than by guess.
+// AutoSelect picks the best codec for a cache at startup.
+package codecbench
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// defaultIterations is the number of times each sample is encoded and decoded when Config.Iterations is not set.
+const defaultIterations = 10
+
+// ErrNoSamples is returned when a run is given no sample values.
+var ErrNoSamples = errors.New("codecbench: no sample values")
+
+// ErrNoCodec is returned by AutoSelect when no candidate could encode and decode the samples.
+var ErrNoCodec = errors.New("codecbench: no codec handles the samples")
+
+// Candidate is a codec under comparison.
+type Candidate struct {
+	Name  string
+	Codec store.Codec
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Candidate
+	// compressed holds the compressing codecs built by Candidates, by candidate name, so their Zstandard encoders
+	// and decoders are created once rather than on every call.
+	compressed = make(map[string]*store.CompressingCodec)
+)
+
+// Register adds a codec to the candidates compared by default, replacing the one registered under the same name.
+// It is meant for codecs the store package does not ship, such as msgpack or protobuf adapters, which are then
+// compared both alone and with gzip and zstd compression.
+func Register(name string, c store.Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, algorithm := range compressions {
+		delete(compressed, name+"+"+algorithm.name)
+	}
+	for i, candidate := range registry {
+		if candidate.Name == name {
+			registry[i].Codec = c
+			return
+		}
+	}
+	registry = append(registry, Candidate{Name: name, Codec: c})
+}
+
+// Candidates returns the candidates compared by default: JSON and every registered codec, each alone and with gzip
+// and zstd compression, named after the codec and the algorithm, such as "json+zstd".
+func Candidates() ([]Candidate, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	bases := append([]Candidate{{Name: "json", Codec: store.JSONCodec{}}}, registry...)
+
+	candidates := make([]Candidate, 0, 3*len(bases))
+	for _, base := range bases {
+		candidates = append(candidates, base)
+		for _, algorithm := range compressions {
+			name := base.Name + "+" + algorithm.name
+			compressing, ok := compressed[name]
+			if !ok {
+				var err error
+				if compressing, err = store.NewCompressingCodec(base.Codec, algorithm.id, 0); err != nil {
+					return nil, err
+				}
+				compressed[name] = compressing
+			}
+			candidates = append(candidates, Candidate{Name: name, Codec: compressing})
+		}
+	}
+	return candidates, nil
+}
+
+// compressions are the algorithms each base codec is compared with.
+var compressions = []struct {
+	name string
+	id   store.CompressionAlgorithm
+}{{"gzip", store.CompressionGzip}, {"zstd", store.CompressionZstd}}
+
+// Config describes a run. Candidates defaults to Candidates() and Iterations, the number of times each sample is
+// encoded and decoded by each candidate, to 10.
+type Config struct {
+	Candidates []Candidate
+	Iterations int
+}
+
+// Result is the outcome of a candidate: the mean encoded size of a sample, in bytes, and the mean time to encode
+// and decode one. Err is set when the candidate failed to encode or decode a sample.
+type Result struct {
+	Name   string
+	Codec  store.Codec
+	Bytes  int
+	Encode time.Duration
+	Decode time.Duration
+	Err    error
+}
+
+// CPU returns the mean time spent encoding and decoding a sample.
+func (r Result) CPU() time.Duration {
+	return r.Encode + r.Decode
+}
+
+// String renders the result on a single line, suitable for side-by-side comparisons.
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s: error=%v", r.Name, r.Err)
+	}
+	return fmt.Sprintf("%s: bytes=%d encode=%s decode=%s", r.Name, r.Bytes, r.Encode, r.Decode)
+}
+
+// Objective is the criterion Best ranks the candidates by.
+type Objective int
+
+const (
+	// Balanced weighs size and CPU time equally, each relative to the best candidate.
+	Balanced Objective = iota
+	// SmallestSize prefers the smallest encoding.
+	SmallestSize
+	// LeastCPU prefers the fastest encoding and decoding.
+	LeastCPU
+)
+
+// Report holds the results of a run, in the order of the candidates.
+type Report struct {
+	Samples int
+	Results []Result
+}
+
+// Best returns the successful result ranking first by the objective, or false when every candidate failed.
+func (r Report) Best(objective Objective) (Result, bool) {
+	ok := slices.DeleteFunc(slices.Clone(r.Results), func(res Result) bool { return res.Err != nil })
+	if len(ok) == 0 {
+		return Result{}, false
+	}
+	minBytes := max(slices.MinFunc(ok, func(a, b Result) int { return cmp.Compare(a.Bytes, b.Bytes) }).Bytes, 1)
+	minCPU := max(slices.MinFunc(ok, func(a, b Result) int { return cmp.Compare(a.CPU(), b.CPU()) }).CPU(), 1)
+	score := func(res Result) float64 {
+		size := float64(res.Bytes) / float64(minBytes)
+		cpu := float64(res.CPU()) / float64(minCPU)
+		switch objective {
+		case SmallestSize:
+			return size
+		case LeastCPU:
+			return cpu
+		}
+		return size + cpu
+	}
+	return slices.MinFunc(ok, func(a, b Result) int { return cmp.Compare(score(a), score(b)) }), true
+}
+
+// Run encodes and decodes the samples with every candidate and returns the measurements.
+func Run[T any](samples []T, cfg Config) (Report, error) {
+	if len(samples) == 0 {
+		return Report{}, ErrNoSamples
+	}
+	if cfg.Iterations <= 0 {
+		cfg.Iterations = defaultIterations
+	}
+	candidates := cfg.Candidates
+	if candidates == nil {
+		var err error
+		if candidates, err = Candidates(); err != nil {
+			return Report{}, err
+		}
+	}
+	report := Report{Samples: len(samples), Results: make([]Result, 0, len(candidates))}
+	for _, candidate := range candidates {
+		report.Results = append(report.Results, measure(candidate, samples, cfg.Iterations))
+	}
+	return report, nil
+}
+
+// AutoSelect runs the samples through the candidates and returns the codec of the best one for the objective,
+// along with the report, to pass to store.WithCodec when the cache is created.
+func AutoSelect[T any](samples []T, objective Objective, cfg Config) (store.Codec, Report, error) {
+	report, err := Run(samples, cfg)
+	if err != nil {
+		return nil, report, err
+	}
+	best, ok := report.Best(objective)
+	if !ok {
+		return nil, report, ErrNoCodec
+	}
+	return best.Codec, report, nil
+}
+
+// measure encodes and decodes every sample iterations times with the candidate.
+func measure[T any](candidate Candidate, samples []T, iterations int) Result {
+	res := Result{Name: candidate.Name, Codec: candidate.Codec}
+	encoded := make([][]byte, len(samples))
+	var size int
+	start := time.Now()
+	for range iterations {
+		for i, sample := range samples {
+			data, err := candidate.Codec.Marshal(sample)
+			if err != nil {
+				res.Err = fmt.Errorf("encode: %w", err)
+				return res
+			}
+			encoded[i] = data
+		}
+	}
+	res.Encode = time.Since(start) / time.Duration(iterations*len(samples))
+
+	start = time.Now()
+	for range iterations {
+		for _, data := range encoded {
+			var value T
+			if err := candidate.Codec.Unmarshal(data, &value); err != nil {
+				res.Err = fmt.Errorf("decode: %w", err)
+				return res
+			}
+		}
+	}
+	res.Decode = time.Since(start) / time.Duration(iterations*len(samples))
+
+	for _, data := range encoded {
+		size += len(data)
+	}
+	res.Bytes = size / len(samples)
+	return res
+}
@@ -0,0 +1,102 @@
+package codecbench
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingCodec is a codec unable to encode anything, standing for a codec that does not support the sample type.
+type failingCodec struct{}
+
+// Marshal always fails.
+func (failingCodec) Marshal(any) ([]byte, error) {
+	return nil, errors.New("unsupported type")
+}
+
+// Unmarshal always fails.
+func (failingCodec) Unmarshal([]byte, any) error {
+	return errors.New("unsupported type")
+}
+
+// sample is a repetitive payload that compresses well.
+type sample struct {
+	ID   int
+	Body string
+}
+
+// TestRun verifies that every default and registered candidate is measured and that failures are reported per
+// candidate.
+func TestRun(t *testing.T) {
+	Register("broken", failingCodec{})
+	samples := []sample{{ID: 1, Body: strings.Repeat("cache ", 200)}, {ID: 2, Body: strings.Repeat("value ", 200)}}
+
+	report, err := Run(samples, Config{Iterations: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Samples)
+	names := make([]string, 0, len(report.Results))
+	for _, res := range report.Results {
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"json", "json+gzip", "json+zstd", "broken", "broken+gzip", "broken+zstd"}, names)
+	assert.NoError(t, report.Results[0].Err)
+	assert.Less(t, report.Results[2].Bytes, report.Results[0].Bytes)
+	assert.Error(t, report.Results[3].Err)
+	assert.Contains(t, report.Results[3].String(), "error=")
+
+	best, ok := report.Best(SmallestSize)
+	require.True(t, ok)
+	assert.Contains(t, best.Name, "+")
+
+	_, err = Run([]sample{}, Config{})
+	assert.ErrorIs(t, err, ErrNoSamples)
+}
+
+// TestCandidates verifies that the compressing candidates are built once and rebuilt when their codec is registered
+// again.
+func TestCandidates(t *testing.T) {
+	Register("reused", store.JSONCodec{})
+	first, err := Candidates()
+	require.NoError(t, err)
+	second, err := Candidates()
+	require.NoError(t, err)
+	require.Equal(t, len(first), len(second))
+	for i := range first {
+		if strings.Contains(first[i].Name, "+") {
+			assert.Same(t, first[i].Codec, second[i].Codec, first[i].Name)
+		}
+	}
+
+	Register("reused", store.JSONCodec{})
+	third, err := Candidates()
+	require.NoError(t, err)
+	for i := range first {
+		if strings.HasPrefix(first[i].Name, "reused+") {
+			assert.NotSame(t, first[i].Codec, third[i].Codec, first[i].Name)
+		} else if strings.Contains(first[i].Name, "+") {
+			assert.Same(t, first[i].Codec, third[i].Codec, first[i].Name)
+		}
+	}
+}
+
+// TestAutoSelect verifies that the selected codec comes from a successful candidate and that a run where every
+// candidate fails is an error.
+func TestAutoSelect(t *testing.T) {
+	samples := []sample{{ID: 1, Body: "short"}}
+	codec, report, err := AutoSelect(samples, LeastCPU, Config{Candidates: []Candidate{
+		{Name: "broken", Codec: failingCodec{}},
+		{Name: "json", Codec: store.JSONCodec{}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, store.JSONCodec{}, codec)
+	assert.Len(t, report.Results, 2)
+	assert.Greater(t, report.Results[1].CPU(), time.Duration(0))
+
+	_, _, err = AutoSelect(samples, Balanced, Config{Candidates: []Candidate{{Name: "broken", Codec: failingCodec{}}}})
+	assert.ErrorIs(t, err, ErrNoCodec)
+}
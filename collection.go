@@ -0,0 +1,137 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"github.com/logocomune/echocache/store"
+	"golang.org/x/sync/singleflight"
+	"log/slog"
+)
+
+// CollectionCache caches lists of entities in normalized form: each entity is stored once under its own ID in the
+// item store, while each list only stores the IDs of its members. Updating an item therefore patches every list
+// containing it, and invalidating an item forces the lists containing it to be recomputed on their next read.
+type CollectionCache[T any] struct {
+	items store.Cacher[T]
+	lists store.Cacher[[]string]
+	id    func(T) string
+	sf    singleflight.Group
+}
+
+// NewCollectionCache creates a normalized collection cache storing entities in items and ID lists in lists.
+// The id function extracts the unique identifier of an entity.
+func NewCollectionCache[T any](items store.Cacher[T], lists store.Cacher[[]string], id func(T) string) *CollectionCache[T] {
+	return &CollectionCache[T]{
+		items: items,
+		lists: lists,
+		id:    id,
+	}
+}
+
+// FetchList returns the entities of the list identified by listKey. The list is served from the cache only when the
+// ID list and every referenced item are present; otherwise refreshFn is called once and both the items and the
+// ID list are written back.
+func (c *CollectionCache[T]) FetchList(ctx context.Context, listKey string, refreshFn store.RefreshFunc[[]T]) ([]T, bool, error) {
+	if items, ok := c.cachedList(ctx, listKey); ok {
+		return items, true, nil
+	}
+
+	result, err, _ := c.sf.Do(listKey, func() (interface{}, error) {
+		items, err := refreshFn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, len(items))
+		for i, item := range items {
+			ids[i] = c.id(item)
+			if err := c.items.Set(ctx, ids[i], item); err != nil {
+				slog.Warn("Failed to store collection item in cache", slog.String("key", ids[i]), slog.String("error", err.Error()))
+			}
+		}
+		if err := c.lists.Set(ctx, listKey, ids); err != nil {
+			slog.Warn("Failed to store collection list in cache", slog.String("key", listKey), slog.String("error", err.Error()))
+		}
+		return items, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	items, ok := result.([]T)
+	if !ok {
+		return nil, false, errors.New("type assertion failed for computed collection")
+	}
+	return items, true, nil
+}
+
+// FetchItem returns a single entity by ID, computing it with refreshFn on a miss.
+func (c *CollectionCache[T]) FetchItem(ctx context.Context, id string, refreshFn store.RefreshFunc[T]) (T, bool, error) {
+	value, exists, err := c.items.Get(ctx, id)
+	if exists {
+		return value, true, nil
+	}
+	if err != nil {
+		slog.Warn("Cannot get collection item from cache", slog.String("error", err.Error()), slog.String("cacheKey", id))
+	}
+	result, err, _ := c.sf.Do("item:"+id, func() (interface{}, error) {
+		item, err := refreshFn(ctx)
+		if err != nil {
+			return item, err
+		}
+		if err := c.items.Set(ctx, id, item); err != nil {
+			slog.Warn("Failed to store collection item in cache", slog.String("key", id), slog.String("error", err.Error()))
+		}
+		return item, nil
+	})
+	if err != nil {
+		var zeroValue T
+		return zeroValue, false, err
+	}
+	if result == nil {
+		// A nil item of an interface type.
+		var zeroValue T
+		return zeroValue, true, nil
+	}
+	item, ok := result.(T)
+	if !ok {
+		var zeroValue T
+		return zeroValue, false, errors.New("type assertion failed for computed collection item")
+	}
+	return item, true, nil
+}
+
+// SetItem stores an updated entity. Every cached list referencing it serves the new version on its next read.
+func (c *CollectionCache[T]) SetItem(ctx context.Context, item T) error {
+	return c.items.Set(ctx, c.id(item), item)
+}
+
+// InvalidateItem removes an entity from the cache. Lists containing it are recomputed on their next read.
+// Returns store.ErrNotSupported if the item store cannot delete entries.
+func (c *CollectionCache[T]) InvalidateItem(ctx context.Context, id string) error {
+	return store.Delete(ctx, c.items, id)
+}
+
+// InvalidateList removes a list of IDs from the cache, e.g. after an entity was added to or removed from it.
+// Returns store.ErrNotSupported if the list store cannot delete entries.
+func (c *CollectionCache[T]) InvalidateList(ctx context.Context, listKey string) error {
+	return store.Delete(ctx, c.lists, listKey)
+}
+
+// cachedList resolves a cached ID list into entities, reporting false if the list or any of its items is missing.
+func (c *CollectionCache[T]) cachedList(ctx context.Context, listKey string) ([]T, bool) {
+	ids, exists, err := c.lists.Get(ctx, listKey)
+	if err != nil {
+		slog.Warn("Cannot get collection list from cache", slog.String("error", err.Error()), slog.String("cacheKey", listKey))
+	}
+	if !exists {
+		return nil, false
+	}
+	items := make([]T, 0, len(ids))
+	for _, id := range ids {
+		item, exists, err := c.items.Get(ctx, id)
+		if err != nil || !exists {
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
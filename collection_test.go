@@ -0,0 +1,71 @@
+package echocache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testUser is a simple entity used to exercise collection caching.
+type testUser struct {
+	ID   string
+	Name string
+}
+
+// TestCollectionCache verifies list caching, item patching and invalidation of containing lists.
+func TestCollectionCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCollectionCache[testUser](store.NewLRUCache[testUser](100), store.NewLRUCache[[]string](100), func(u testUser) string {
+		return u.ID
+	})
+	calls := 0
+	loadList := func(ctx context.Context) ([]testUser, error) {
+		calls++
+		return []testUser{{ID: "1", Name: "alice"}, {ID: "2", Name: "bob"}}, nil
+	}
+
+	users, found, err := cache.FetchList(ctx, "admins", loadList)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, users, 2)
+
+	require.NoError(t, cache.SetItem(ctx, testUser{ID: "2", Name: "robert"}))
+	users, _, _ = cache.FetchList(ctx, "admins", loadList)
+	assert.Equal(t, "robert", users[1].Name)
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, cache.InvalidateItem(ctx, "1"))
+	users, _, _ = cache.FetchList(ctx, "admins", loadList)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "bob", users[1].Name)
+
+	item, found, err := cache.FetchItem(ctx, "1", func(ctx context.Context) (testUser, error) {
+		t.Error("item must be served from cache")
+		return testUser{}, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "alice", item.Name)
+
+	require.NoError(t, cache.InvalidateList(ctx, "admins"))
+	_, _, _ = cache.FetchList(ctx, "admins", loadList)
+	assert.Equal(t, 3, calls)
+}
+
+// TestCollectionCache_NilInterfaceItem verifies that a nil item of an interface type is returned as the zero value.
+func TestCollectionCache_NilInterfaceItem(t *testing.T) {
+	cache := NewCollectionCache[fmt.Stringer](store.NewLRUCache[fmt.Stringer](10), store.NewLRUCache[[]string](10), func(s fmt.Stringer) string {
+		return s.String()
+	})
+
+	item, exists, err := cache.FetchItem(t.Context(), "missing", func(ctx context.Context) (fmt.Stringer, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Nil(t, item)
+}
@@ -0,0 +1,146 @@
+package compat
+
+import (
+	"context"
+	"errors"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// ErrNotFound is returned by KeyValue adapters when the key is missing, the way gocache and similar libraries report
+// a miss through an error rather than a boolean.
+var ErrNotFound = errors.New("compat: key not found")
+
+// KeyValue is the interface shared by gocache's CacheInterface and most Go cache libraries once their per-call options
+// are bound: a miss is reported as an error and entries can be deleted or cleared.
+type KeyValue[T any] interface {
+	Get(ctx context.Context, key string) (T, error)
+	Set(ctx context.Context, key string, value T) error
+	Delete(ctx context.Context, key string) error
+	Clear(ctx context.Context) error
+}
+
+// KeyValueFuncs implements KeyValue with closures, so an external cache can be bound without a wrapper type, e.g.
+//
+//	compat.KeyValueFuncs[string]{
+//		GetFunc: func(ctx context.Context, key string) (string, error) { return gc.Get(ctx, key) },
+//		SetFunc: func(ctx context.Context, key string, value string) error {
+//			return gc.Set(ctx, key, value, store.WithExpiration(time.Minute))
+//		},
+//	}
+//
+// Delete and Clear return store.ErrNotSupported when their function is nil.
+type KeyValueFuncs[T any] struct {
+	GetFunc    func(ctx context.Context, key string) (T, error)
+	SetFunc    func(ctx context.Context, key string, value T) error
+	DeleteFunc func(ctx context.Context, key string) error
+	ClearFunc  func(ctx context.Context) error
+}
+
+// Get calls GetFunc.
+func (f KeyValueFuncs[T]) Get(ctx context.Context, key string) (T, error) {
+	return f.GetFunc(ctx, key)
+}
+
+// Set calls SetFunc.
+func (f KeyValueFuncs[T]) Set(ctx context.Context, key string, value T) error {
+	return f.SetFunc(ctx, key, value)
+}
+
+// Delete calls DeleteFunc.
+func (f KeyValueFuncs[T]) Delete(ctx context.Context, key string) error {
+	if f.DeleteFunc == nil {
+		return store.ErrNotSupported
+	}
+	return f.DeleteFunc(ctx, key)
+}
+
+// Clear calls ClearFunc.
+func (f KeyValueFuncs[T]) Clear(ctx context.Context) error {
+	if f.ClearFunc == nil {
+		return store.ErrNotSupported
+	}
+	return f.ClearFunc(ctx)
+}
+
+// externalCacher exposes a KeyValue as a store.Cacher.
+type externalCacher[T any] struct {
+	kv         KeyValue[T]
+	isNotFound func(error) bool
+}
+
+// FromKeyValue wraps an external cache as a store.Cacher, so it can back an EchoCache. isNotFound classifies the
+// errors the external cache returns on a miss; when nil, errors matching ErrNotFound are treated as misses.
+func FromKeyValue[T any](kv KeyValue[T], isNotFound func(error) bool) store.Cacher[T] {
+	if isNotFound == nil {
+		isNotFound = func(err error) bool { return errors.Is(err, ErrNotFound) }
+	}
+	return &externalCacher[T]{kv: kv, isNotFound: isNotFound}
+}
+
+// Get retrieves the value from the external cache, reporting a miss instead of its not-found error.
+func (c *externalCacher[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	value, err := c.kv.Get(ctx, key)
+	if err != nil {
+		var emptyValue T
+		if c.isNotFound(err) {
+			return emptyValue, false, nil
+		}
+		return emptyValue, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores the value in the external cache.
+func (c *externalCacher[T]) Set(ctx context.Context, key string, value T) error {
+	return c.kv.Set(ctx, key, value)
+}
+
+// Delete removes the key from the external cache.
+func (c *externalCacher[T]) Delete(ctx context.Context, key string) error {
+	return c.kv.Delete(ctx, key)
+}
+
+// Clear removes every entry of the external cache.
+func (c *externalCacher[T]) Clear(ctx context.Context) error {
+	return c.kv.Clear(ctx)
+}
+
+// cacherKeyValue exposes a store.Cacher as a KeyValue.
+type cacherKeyValue[T any] struct {
+	cacher store.Cacher[T]
+}
+
+// ToKeyValue exposes an echocache store where a KeyValue is expected. Misses are reported as ErrNotFound; Delete and
+// Clear return store.ErrNotSupported when the store cannot perform them.
+func ToKeyValue[T any](c store.Cacher[T]) KeyValue[T] {
+	return &cacherKeyValue[T]{cacher: c}
+}
+
+// Get retrieves the value from the store, returning ErrNotFound on a miss.
+func (c *cacherKeyValue[T]) Get(ctx context.Context, key string) (T, error) {
+	value, exists, err := c.cacher.Get(ctx, key)
+	if err != nil {
+		return value, err
+	}
+	if !exists {
+		var emptyValue T
+		return emptyValue, ErrNotFound
+	}
+	return value, nil
+}
+
+// Set stores the value in the store.
+func (c *cacherKeyValue[T]) Set(ctx context.Context, key string, value T) error {
+	return c.cacher.Set(ctx, key, value)
+}
+
+// Delete removes the key from the store.
+func (c *cacherKeyValue[T]) Delete(ctx context.Context, key string) error {
+	return store.Delete(ctx, c.cacher, key)
+}
+
+// Clear removes every entry of the store.
+func (c *cacherKeyValue[T]) Clear(ctx context.Context) error {
+	return store.Clear(ctx, c.cacher)
+}
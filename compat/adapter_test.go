@@ -0,0 +1,80 @@
+package compat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errMissing = errors.New("value not found")
+
+// mapCache mimics an external library reporting misses through its own error.
+type mapCache struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (m *mapCache) funcs() KeyValueFuncs[string] {
+	return KeyValueFuncs[string]{
+		GetFunc: func(ctx context.Context, key string) (string, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			value, ok := m.values[key]
+			if !ok {
+				return "", errMissing
+			}
+			return value, nil
+		},
+		SetFunc: func(ctx context.Context, key string, value string) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.values[key] = value
+			return nil
+		},
+	}
+}
+
+// TestFromKeyValue verifies that an external cache can back an EchoCache, with its miss error mapped to a miss.
+func TestFromKeyValue(t *testing.T) {
+	ctx := context.Background()
+	external := &mapCache{values: map[string]string{}}
+	cacher := FromKeyValue[string](external.funcs(), func(err error) bool { return errors.Is(err, errMissing) })
+
+	ec := echocache.NewEchoCache[string](cacher)
+	value, exists, err := ec.FetchWithCache(ctx, "k", func(ctx context.Context) (string, error) { return "v", nil })
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "v", value)
+	assert.Equal(t, "v", external.values["k"])
+
+	assert.ErrorIs(t, store.Delete(ctx, cacher, "k"), store.ErrNotSupported)
+}
+
+// TestToKeyValue verifies that echocache stores report misses as ErrNotFound through the KeyValue interface.
+func TestToKeyValue(t *testing.T) {
+	ctx := context.Background()
+	kv := ToKeyValue[string](store.NewLRUCache[string](10))
+
+	_, err := kv.Get(ctx, "k")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, kv.Set(ctx, "k", "v"))
+	value, err := kv.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", value)
+
+	require.NoError(t, kv.Delete(ctx, "k"))
+	_, err = kv.Get(ctx, "k")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	round := FromKeyValue[string](kv, nil)
+	_, exists, err := round.Get(ctx, "k")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
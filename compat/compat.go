@@ -0,0 +1,46 @@
+// Package compat provides the pre-EchoCache API (New, Memoize and a root-level Cacher) as thin wrappers around the
+// current echocache package, so existing users can upgrade by changing an import path and migrate call sites
+// incrementally. New code should use echocache.NewEchoCache and EchoCache.FetchWithCache directly.
+package compat
+
+import (
+	"context"
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/store"
+)
+
+// Cacher is the storage interface accepted by New.
+//
+// Deprecated: use store.Cacher.
+type Cacher[T any] = store.Cacher[T]
+
+// RefreshFunc computes the value of a missing key.
+//
+// Deprecated: use store.RefreshFunc.
+type RefreshFunc[T any] = store.RefreshFunc[T]
+
+// Cache memoizes computations through an echocache.EchoCache.
+//
+// Deprecated: use echocache.EchoCache.
+type Cache[T any] struct {
+	ec *echocache.EchoCache[T]
+}
+
+// New creates a cache backed by the given cacher. Options are forwarded to echocache.NewEchoCache.
+//
+// Deprecated: use echocache.NewEchoCache.
+func New[T any](cacher Cacher[T], opts ...echocache.Option) *Cache[T] {
+	return &Cache[T]{ec: echocache.NewEchoCache[T](cacher, opts...)}
+}
+
+// Memoize returns the cached value for key or computes it with fn, caching the result.
+//
+// Deprecated: use echocache.EchoCache.FetchWithCache.
+func (c *Cache[T]) Memoize(ctx context.Context, key string, fn RefreshFunc[T]) (T, bool, error) {
+	return c.ec.FetchWithCache(ctx, key, fn)
+}
+
+// EchoCache returns the underlying cache, so call sites can be migrated one at a time.
+func (c *Cache[T]) EchoCache() *echocache.EchoCache[T] {
+	return c.ec
+}
@@ -0,0 +1,32 @@
+package compat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemoize verifies that the legacy API delegates to EchoCache.
+func TestMemoize(t *testing.T) {
+	cache := New[string](store.NewLRUCache[string](10))
+	calls := 0
+	fn := func(ctx context.Context) (string, error) {
+		calls++
+		return "value", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		value, exists, err := cache.Memoize(context.Background(), "k", fn)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "value", value)
+	}
+	assert.Equal(t, 1, calls)
+
+	value, exists, err := cache.EchoCache().FetchWithCache(context.Background(), "k", fn)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "value", value)
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/store"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultRefreshTimeout is the lazy refresh timeout used when Refresh.Timeout is not configured.
+const defaultRefreshTimeout = 10 * time.Second
+
+// closers releases the connections and background goroutines opened while building a topology.
+type closers []func() error
+
+// Close releases every resource in reverse order of creation and returns the joined errors.
+func (c closers) Close() error {
+	var errs []error
+	for i := len(c) - 1; i >= 0; i-- {
+		if err := c[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewStore builds the store described by the configuration. The returned Closer releases its connections.
+// Store options are applied after the timeouts and codec taken from the configuration.
+func NewStore[T any](ctx context.Context, cfg Config, opts ...store.Option) (store.Cacher[T], io.Closer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	var cl closers
+	c, err := buildStore[T](ctx, cfg, opts, &cl)
+	if err != nil {
+		_ = cl.Close()
+		return nil, nil, err
+	}
+	return c, cl, nil
+}
+
+// NewStaleWhileRevalidateStore builds the stale-while-revalidate store described by the configuration.
+// The returned Closer releases its connections.
+func NewStaleWhileRevalidateStore[T any](ctx context.Context, cfg Config, opts ...store.Option) (store.StaleWhileRevalidateCache[T], io.Closer, error) {
+	c, cl, err := NewStore[store.StaleValue[T]](ctx, cfg, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	swr, ok := c.(store.StaleWhileRevalidateCache[T])
+	if !ok {
+		_ = cl.Close()
+		return nil, nil, fmt.Errorf("%w: %s backend does not support refresh locks", ErrInvalidConfig, cfg.Backend)
+	}
+	return swr, cl, nil
+}
+
+// New builds an EchoCache on top of the store described by the configuration.
+func New[T any](ctx context.Context, cfg Config, opts ...echocache.Option) (*echocache.EchoCache[T], io.Closer, error) {
+	return NewWithStoreOptions[T](ctx, cfg, nil, opts...)
+}
+
+// NewWithStoreOptions builds an EchoCache as New does, passing storeOpts to the store as NewStore does.
+func NewWithStoreOptions[T any](ctx context.Context, cfg Config, storeOpts []store.Option, opts ...echocache.Option) (*echocache.EchoCache[T], io.Closer, error) {
+	c, cl, err := NewStore[T](ctx, cfg, storeOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return echocache.NewEchoCache[T](c, opts...), cl, nil
+}
+
+// NewLazy builds an EchoCacheLazy on top of the stale-while-revalidate store described by the configuration, using
+// the configured refresh timeout and queue size. The returned Closer also shuts down the refresh worker.
+func NewLazy[T any](ctx context.Context, cfg Config, opts ...echocache.Option) (*echocache.EchoCacheLazy[T], io.Closer, error) {
+	return NewLazyWithStoreOptions[T](ctx, cfg, nil, opts...)
+}
+
+// NewLazyWithStoreOptions builds an EchoCacheLazy as NewLazy does, passing storeOpts to the store as NewStore does.
+func NewLazyWithStoreOptions[T any](ctx context.Context, cfg Config, storeOpts []store.Option, opts ...echocache.Option) (*echocache.EchoCacheLazy[T], io.Closer, error) {
+	c, cl, err := NewStaleWhileRevalidateStore[T](ctx, cfg, storeOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	timeout := cfg.Refresh.Timeout
+	if timeout <= 0 {
+		timeout = defaultRefreshTimeout
+	}
+	opts = append(cfg.Refresh.Options(), opts...)
+	lazy := echocache.NewLazyEchoCache[T](c, timeout, opts...)
+	return lazy, closers{cl.Close, func() error {
+		lazy.ShutdownLazyRefresh()
+		return nil
+	}}, nil
+}
+
+// Options returns the cache options described by the refresh section. Besides NewLazy, they can be passed to
+// EchoCacheLazy.Reconfigure after reloading the configuration, to change the refresh timeout and queue size at runtime.
+func (c RefreshConfig) Options() []echocache.Option {
+	opts := []echocache.Option{echocache.WithQueueSize(c.QueueSize)}
+	if c.Timeout > 0 {
+		opts = append(opts, echocache.WithLazyRefreshTimeout(c.Timeout))
+	}
+	return opts
+}
+
+// buildStore creates the backend described by the configuration, wrapping it in a tiered cache when L1 is set.
+// Resources that must be released are appended to cl.
+func buildStore[T any](ctx context.Context, cfg Config, opts []store.Option, cl *closers) (store.Cacher[T], error) {
+	storeOpts := append([]store.Option{}, timeoutOptions(cfg.Timeouts)...)
+	codec, err := cfg.Codec.codec()
+	if err != nil {
+		return nil, err
+	}
+	if codec != nil {
+		storeOpts = append(storeOpts, store.WithCodec(codec))
+	}
+	storeOpts = append(storeOpts, opts...)
+
+	var c store.Cacher[T]
+	switch cfg.Backend {
+	case BackendLRU:
+		c = store.NewLRUCache[T](cfg.Size, storeOpts...)
+	case BackendLRUExpirable:
+		c = store.NewLRUExpirableCache[T](cfg.Size, cfg.TTL, storeOpts...)
+	case BackendSingle:
+		c = store.NewSingleCache[T](cfg.TTL, storeOpts...)
+	case BackendTimingWheel:
+		wheel := store.NewTimingWheelCache[T](cfg.TTL, store.TimingWheelConfig[T]{}, storeOpts...)
+		*cl = append(*cl, func() error {
+			wheel.Close()
+			return nil
+		})
+		c = wheel
+	case BackendRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Username: cfg.Redis.Username,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		*cl = append(*cl, client.Close)
+		if err := client.Ping(ctx).Err(); err != nil {
+			return nil, err
+		}
+		c = store.NewRedisCache[T](client, cfg.Prefix, cfg.TTL, storeOpts...)
+	case BackendNATS:
+		nc, err := nats.Connect(cfg.NATS.URL)
+		if err != nil {
+			return nil, err
+		}
+		*cl = append(*cl, func() error {
+			nc.Close()
+			return nil
+		})
+		js, err := jetstream.New(nc)
+		if err != nil {
+			return nil, err
+		}
+		kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: cfg.NATS.Bucket, TTL: cfg.TTL})
+		if err != nil {
+			return nil, err
+		}
+		c = store.NewNatsCache[T](kv, cfg.Prefix, storeOpts...)
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q", ErrInvalidConfig, cfg.Backend)
+	}
+
+	if cfg.L1 == nil {
+		return c, nil
+	}
+	l1, err := buildStore[T](ctx, *cfg.L1, opts, cl)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewTieredCache[T](l1, c), nil
+}
+
+// timeoutOptions converts the configured timeouts into store options, skipping the ones left at zero.
+func timeoutOptions(t TimeoutsConfig) []store.Option {
+	var opts []store.Option
+	if t.Get > 0 {
+		opts = append(opts, store.WithGetTimeout(t.Get))
+	}
+	if t.Set > 0 {
+		opts = append(opts, store.WithSetTimeout(t.Set))
+	}
+	if t.Lock > 0 {
+		opts = append(opts, store.WithLockTimeout(t.Lock))
+	}
+	return opts
+}
@@ -0,0 +1,299 @@
+// Package config builds complete cache topologies (backend, addresses, TTLs, refresh queue and tiering) from a
+// struct, a YAML document or environment variables, so that services can switch between memory, Redis and NATS
+// backends purely by configuration.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values of Config.Backend.
+const (
+	BackendLRU          = "lru"
+	BackendLRUExpirable = "lru-expirable"
+	BackendSingle       = "single"
+	BackendTimingWheel  = "timing-wheel"
+	BackendRedis        = "redis"
+	BackendNATS         = "nats"
+)
+
+// ErrInvalidConfig is returned when a configuration cannot describe a valid cache.
+var ErrInvalidConfig = errors.New("invalid cache configuration")
+
+// Config describes a cache topology. When L1 is set, the backend described by Config becomes the shared L2 layer
+// of a tiered cache and L1 the in-process layer in front of it.
+type Config struct {
+	Backend  string         `yaml:"backend"`
+	Size     int            `yaml:"size"`
+	TTL      time.Duration  `yaml:"ttl"`
+	Prefix   string         `yaml:"prefix"`
+	Timeouts TimeoutsConfig `yaml:"timeouts"`
+	Codec    CodecConfig    `yaml:"codec"`
+	Redis    RedisConfig    `yaml:"redis"`
+	NATS     NATSConfig     `yaml:"nats"`
+	Refresh  RefreshConfig  `yaml:"refresh"`
+	L1       *Config        `yaml:"l1"`
+}
+
+// TimeoutsConfig holds the default per-operation timeouts applied to remote backends.
+type TimeoutsConfig struct {
+	Get  time.Duration `yaml:"get"`
+	Set  time.Duration `yaml:"set"`
+	Lock time.Duration `yaml:"lock"`
+}
+
+// CodecConfig selects the serialization of values in remote backends. Compression, when set to "gzip" or "zstd",
+// compresses JSON payloads of at least CompressionMinSize bytes with a store.CompressingCodec. Checksum, when set
+// to "crc32" or "xxhash", wraps the result in a store.ChecksumCodec.
+type CodecConfig struct {
+	Compression        string `yaml:"compression"`
+	CompressionMinSize int    `yaml:"compression_min_size"`
+	Checksum           string `yaml:"checksum"`
+}
+
+// RedisConfig holds the connection settings of the Redis backend.
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// NATSConfig holds the connection settings of the NATS backend. The key-value bucket is created when missing,
+// with TTL as its history expiration.
+type NATSConfig struct {
+	URL    string `yaml:"url"`
+	Bucket string `yaml:"bucket"`
+}
+
+// RefreshConfig holds the settings of the lazy refresh worker used by NewLazy.
+type RefreshConfig struct {
+	Timeout   time.Duration `yaml:"timeout"`
+	QueueSize int           `yaml:"queue_size"`
+}
+
+// Load decodes a YAML configuration and validates it.
+func Load(r io.Reader) (Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// LoadFile reads and validates the YAML configuration stored at path.
+func LoadFile(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// LoadWithEnv reads the YAML configuration stored at path, when path is not empty, applies the environment overrides
+// described by ApplyEnv and validates the result, so a file can be completed or overridden by the environment.
+func LoadWithEnv(path string, prefix string) (Config, error) {
+	var cfg Config
+	if path != "" {
+		var err error
+		if cfg, err = LoadFile(path); err != nil && !errors.Is(err, ErrInvalidConfig) {
+			return Config{}, err
+		}
+	}
+	if err := ApplyEnv(&cfg, prefix); err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// FromEnv builds a configuration from environment variables only. See ApplyEnv for the variable names.
+func FromEnv(prefix string) (Config, error) {
+	var cfg Config
+	if err := ApplyEnv(&cfg, prefix); err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// ApplyEnv overrides the configuration with environment variables named after the YAML keys, upper-cased, joined
+// with underscores and prefixed with prefix, e.g. ECHOCACHE_BACKEND, ECHOCACHE_REDIS_ADDR or ECHOCACHE_L1_SIZE.
+// Durations use the time.ParseDuration syntax. Variables that are not set leave the configuration untouched.
+func ApplyEnv(cfg *Config, prefix string) error {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), strings.ToUpper(prefix), os.LookupEnv, false)
+}
+
+// Validate reports whether the configuration describes a cache that can be built.
+func (c Config) Validate() error {
+	switch c.Backend {
+	case BackendLRU:
+		if c.Size <= 0 {
+			return fmt.Errorf("%w: %s backend requires a positive size", ErrInvalidConfig, c.Backend)
+		}
+	case BackendLRUExpirable:
+		if c.Size <= 0 || c.TTL <= 0 {
+			return fmt.Errorf("%w: %s backend requires a positive size and ttl", ErrInvalidConfig, c.Backend)
+		}
+	case BackendSingle, BackendTimingWheel:
+		if c.TTL <= 0 {
+			return fmt.Errorf("%w: %s backend requires a positive ttl", ErrInvalidConfig, c.Backend)
+		}
+	case BackendRedis:
+		if c.Redis.Addr == "" {
+			return fmt.Errorf("%w: redis backend requires redis.addr", ErrInvalidConfig)
+		}
+	case BackendNATS:
+		if c.NATS.URL == "" || c.NATS.Bucket == "" {
+			return fmt.Errorf("%w: nats backend requires nats.url and nats.bucket", ErrInvalidConfig)
+		}
+	case "":
+		return fmt.Errorf("%w: missing backend", ErrInvalidConfig)
+	default:
+		return fmt.Errorf("%w: unknown backend %q", ErrInvalidConfig, c.Backend)
+	}
+	if _, err := c.Codec.codec(); err != nil {
+		return err
+	}
+	if c.L1 == nil {
+		return nil
+	}
+	if !c.L1.isMemory() || c.L1.L1 != nil {
+		return fmt.Errorf("%w: l1 must be a single in-memory backend", ErrInvalidConfig)
+	}
+	if c.L1.Backend == BackendSingle {
+		// The single backend holds one value whatever the key, so it would serve it for every key of L2.
+		return fmt.Errorf("%w: %s backend cannot be used as l1", ErrInvalidConfig, BackendSingle)
+	}
+	return c.L1.Validate()
+}
+
+// codec returns the codec described by the configuration, or nil when the store default applies.
+func (c CodecConfig) codec() (store.Codec, error) {
+	var codec store.Codec = store.JSONCodec{}
+	configured := false
+	switch c.Compression {
+	case "":
+	case "gzip", "zstd":
+		algorithm := store.CompressionGzip
+		if c.Compression == "zstd" {
+			algorithm = store.CompressionZstd
+		}
+		compressing, err := store.NewCompressingCodec(codec, algorithm, c.CompressionMinSize)
+		if err != nil {
+			return nil, err
+		}
+		codec, configured = compressing, true
+	default:
+		return nil, fmt.Errorf("%w: unknown compression %q", ErrInvalidConfig, c.Compression)
+	}
+	switch c.Checksum {
+	case "":
+	case "crc32":
+		codec, configured = store.NewChecksumCodec(codec, store.ChecksumCRC32), true
+	case "xxhash":
+		codec, configured = store.NewChecksumCodec(codec, store.ChecksumXXHash), true
+	default:
+		return nil, fmt.Errorf("%w: unknown checksum %q", ErrInvalidConfig, c.Checksum)
+	}
+	if !configured {
+		return nil, nil
+	}
+	return codec, nil
+}
+
+// isMemory reports whether the backend lives in the process memory.
+func (c Config) isMemory() bool {
+	switch c.Backend {
+	case BackendLRU, BackendLRUExpirable, BackendSingle, BackendTimingWheel:
+		return true
+	}
+	return false
+}
+
+// durationType is used to recognize time.Duration fields, which are parsed with time.ParseDuration.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv walks the struct fields, deriving each variable name from the YAML tag, and sets the fields whose
+// variable is defined. Pointers to structs are only allocated when at least one of their variables is set, and never
+// inside a struct that was itself just allocated, which keeps recursive types such as Config.L1 finite.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool), allocated bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.ToUpper(strings.Split(field.Tag.Get("yaml"), ",")[0])
+		if name == "" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		fv := v.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			if err := applyEnv(fv, name, lookup, allocated); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+			if fv.IsNil() && allocated {
+				continue
+			}
+			target := fv
+			if fv.IsNil() {
+				target = reflect.New(field.Type.Elem())
+			}
+			before := reflect.Indirect(target).Interface()
+			if err := applyEnv(target.Elem(), name, lookup, allocated || fv.IsNil()); err != nil {
+				return err
+			}
+			if fv.IsNil() && !reflect.DeepEqual(before, target.Elem().Interface()) {
+				fv.Set(target)
+			}
+		default:
+			raw, ok := lookup(name)
+			if !ok {
+				continue
+			}
+			if err := setField(fv, raw); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setField parses raw into a string, integer or duration field.
+func setField(fv reflect.Value, raw string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
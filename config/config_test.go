@@ -0,0 +1,202 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad verifies that a YAML document is decoded, including durations and the tiered L1 section.
+func TestLoad(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`
+backend: redis
+ttl: 5m
+prefix: svc
+timeouts:
+  get: 50ms
+redis:
+  addr: localhost:6379
+  db: 2
+refresh:
+  timeout: 2s
+  queue_size: 64
+l1:
+  backend: lru-expirable
+  size: 100
+  ttl: 10s
+`))
+	require.NoError(t, err)
+	assert.Equal(t, BackendRedis, cfg.Backend)
+	assert.Equal(t, 5*time.Minute, cfg.TTL)
+	assert.Equal(t, 50*time.Millisecond, cfg.Timeouts.Get)
+	assert.Equal(t, RedisConfig{Addr: "localhost:6379", DB: 2}, cfg.Redis)
+	assert.Equal(t, RefreshConfig{Timeout: 2 * time.Second, QueueSize: 64}, cfg.Refresh)
+	require.NotNil(t, cfg.L1)
+	assert.Equal(t, Config{Backend: BackendLRUExpirable, Size: 100, TTL: 10 * time.Second}, *cfg.L1)
+
+	_, err = Load(strings.NewReader("backend: lru\nsize: 10\nunknown: 1\n"))
+	assert.Error(t, err, "unknown keys are rejected")
+}
+
+// TestLoadWithEnv verifies that the environment completes and overrides the configuration file, and that the result
+// is validated.
+func TestLoadWithEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("backend: lru\nprefix: svc\n"), 0o600))
+
+	_, err := LoadWithEnv(path, "LOADENV")
+	assert.ErrorIs(t, err, ErrInvalidConfig, "the file alone lacks the size")
+
+	t.Setenv("LOADENV_SIZE", "10")
+	cfg, err := LoadWithEnv(path, "LOADENV")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Backend: BackendLRU, Size: 10, Prefix: "svc"}, cfg)
+
+	t.Setenv("LOADENV_BACKEND", "lru")
+	cfg, err = LoadWithEnv("", "LOADENV")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Backend: BackendLRU, Size: 10}, cfg)
+
+	_, err = LoadWithEnv(filepath.Join(t.TempDir(), "missing.yaml"), "LOADENV")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// TestValidate verifies that incomplete or inconsistent configurations are rejected.
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		valid bool
+	}{
+		{name: "lru", cfg: Config{Backend: BackendLRU, Size: 10}, valid: true},
+		{name: "missing backend", cfg: Config{}},
+		{name: "unknown backend", cfg: Config{Backend: "memcached"}},
+		{name: "lru without size", cfg: Config{Backend: BackendLRU}},
+		{name: "single without ttl", cfg: Config{Backend: BackendSingle}},
+		{name: "redis without addr", cfg: Config{Backend: BackendRedis}},
+		{name: "nats without bucket", cfg: Config{Backend: BackendNATS, NATS: NATSConfig{URL: "nats://localhost:4222"}}},
+		{name: "unknown checksum", cfg: Config{Backend: BackendLRU, Size: 10, Codec: CodecConfig{Checksum: "md5"}}},
+		{name: "unknown compression", cfg: Config{Backend: BackendLRU, Size: 10, Codec: CodecConfig{Compression: "lz4"}}},
+		{name: "compressed", cfg: Config{Backend: BackendLRU, Size: 10, Codec: CodecConfig{Compression: "zstd", Checksum: "xxhash"}}, valid: true},
+		{name: "checksum", cfg: Config{Backend: BackendLRU, Size: 10, Codec: CodecConfig{Checksum: "crc32"}}, valid: true},
+		{name: "single l1", cfg: Config{Backend: BackendTimingWheel, TTL: time.Minute, L1: &Config{Backend: BackendSingle, TTL: time.Minute}}},
+		{name: "remote l1", cfg: Config{Backend: BackendLRU, Size: 10, L1: &Config{Backend: BackendRedis, Redis: RedisConfig{Addr: "x"}}}},
+		{name: "tiered", cfg: Config{Backend: BackendTimingWheel, TTL: time.Minute, L1: &Config{Backend: BackendLRU, Size: 10}}, valid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+			}
+		})
+	}
+}
+
+// TestApplyEnv verifies that environment variables named after the YAML keys override the configuration.
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_BACKEND":            "nats",
+		"APP_TTL":                "1h",
+		"APP_NATS_URL":           "nats://localhost:4222",
+		"APP_NATS_BUCKET":        "cache",
+		"APP_REFRESH_QUEUE_SIZE": "10",
+		"APP_L1_BACKEND":         "lru",
+		"APP_L1_SIZE":            "50",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	cfg := Config{Backend: BackendLRU, Size: 5, Prefix: "kept"}
+	require.NoError(t, applyEnv(reflectConfig(&cfg), "APP", lookup, false))
+	assert.Equal(t, BackendNATS, cfg.Backend)
+	assert.Equal(t, time.Hour, cfg.TTL)
+	assert.Equal(t, "kept", cfg.Prefix)
+	assert.Equal(t, NATSConfig{URL: "nats://localhost:4222", Bucket: "cache"}, cfg.NATS)
+	assert.Equal(t, 10, cfg.Refresh.QueueSize)
+	require.NotNil(t, cfg.L1)
+	assert.Equal(t, Config{Backend: BackendLRU, Size: 50}, *cfg.L1)
+	assert.NoError(t, cfg.Validate())
+
+	cfg = Config{}
+	require.NoError(t, applyEnv(reflectConfig(&cfg), "OTHER", lookup, false))
+	assert.Nil(t, cfg.L1, "L1 is only allocated when one of its variables is set")
+
+	env["APP_TTL"] = "soon"
+	assert.ErrorIs(t, applyEnv(reflectConfig(&cfg), "APP", lookup, false), ErrInvalidConfig)
+}
+
+// TestNew verifies that a tiered in-memory topology is built and usable through EchoCache.
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{Backend: BackendTimingWheel, TTL: time.Minute, L1: &Config{Backend: BackendLRU, Size: 10}}
+
+	cache, closer, err := New[string](ctx, cfg)
+	require.NoError(t, err)
+	defer closer.Close()
+
+	value, _, err := cache.FetchWithCache(ctx, "k", func(ctx context.Context) (string, error) {
+		return "v", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "v", value)
+
+	_, _, err = New[string](ctx, Config{Backend: BackendLRU})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+// TestNewWithStoreOptions verifies that store options are forwarded to the store.
+func TestNewWithStoreOptions(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	onEvict := store.WithEvictCallback(func(key string, _ string, _ store.EvictReason) {
+		evicted = append(evicted, key)
+	})
+
+	cache, closer, err := NewWithStoreOptions[string](ctx, Config{Backend: BackendLRU, Size: 1}, []store.Option{onEvict})
+	require.NoError(t, err)
+	defer closer.Close()
+	for _, key := range []string{"a", "b"} {
+		_, _, err := cache.FetchWithCache(ctx, key, func(ctx context.Context) (string, error) {
+			return key, nil
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"a"}, evicted)
+}
+
+// TestNewLazy verifies that the stale-while-revalidate topology is built with the configured refresh settings.
+func TestNewLazy(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{Backend: BackendLRU, Size: 10, Refresh: RefreshConfig{Timeout: time.Second, QueueSize: 1}}
+
+	swr, closer, err := NewStaleWhileRevalidateStore[string](ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, closer.Close())
+	var _ store.StaleWhileRevalidateCache[string] = swr
+
+	lazy, closer, err := NewLazy[string](ctx, cfg)
+	require.NoError(t, err)
+	value, _, err := lazy.FetchWithLazyRefresh(ctx, "k", func(ctx context.Context) (string, error) {
+		return "v", nil
+	}, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "v", value)
+	assert.NoError(t, closer.Close())
+}
+
+// reflectConfig returns the addressable struct value applyEnv operates on.
+func reflectConfig(cfg *Config) reflect.Value {
+	return reflect.ValueOf(cfg).Elem()
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/store"
+)
+
+// Namespace is a logical cache in a hierarchy sharing defaults, so that applications managing many caches describe
+// the common backend, TTL, codec, hooks and rate limits once. A namespace inherits from its parent:
+//   - fields of its Config left at their zero value take the value resolved for the parent, sections being merged
+//     field by field and L1 taken as a whole;
+//   - its cache and store options are applied after the parent's, overriding them.
+//
+// Prefix, unless set, is the prefix of the parent followed by ":" and the name of the namespace, so that sibling
+// namespaces sharing a backend never share keys. A namespace cannot reset an inherited value to zero.
+type Namespace struct {
+	name      string
+	parent    *Namespace
+	cfg       Config
+	opts      []echocache.Option
+	storeOpts []store.Option
+}
+
+// NewNamespace creates the root namespace of a hierarchy, holding the defaults of every descendant.
+func NewNamespace(name string, cfg Config, opts ...echocache.Option) *Namespace {
+	return &Namespace{name: name, cfg: cfg, opts: opts}
+}
+
+// Child creates a namespace inheriting from n and overriding the non-zero fields of cfg and the given options.
+func (n *Namespace) Child(name string, cfg Config, opts ...echocache.Option) *Namespace {
+	return &Namespace{name: name, parent: n, cfg: cfg, opts: opts}
+}
+
+// WithStoreOptions adds store options, such as a hooked or encrypting codec, applied after those of the parent and
+// of the configuration. It returns n, and must be called before caches of n or its descendants are built.
+func (n *Namespace) WithStoreOptions(opts ...store.Option) *Namespace {
+	n.storeOpts = append(n.storeOpts, opts...)
+	return n
+}
+
+// Name returns the full name of the namespace, the names from the root joined with dots, such as "app.users".
+func (n *Namespace) Name() string {
+	if n.parent == nil {
+		return n.name
+	}
+	return n.parent.Name() + "." + n.name
+}
+
+// Config returns the configuration resolved for the namespace.
+func (n *Namespace) Config() Config {
+	cfg := n.cfg
+	if n.parent == nil {
+		if cfg.Prefix == "" {
+			cfg.Prefix = n.name
+		}
+		return cfg
+	}
+	parent := n.parent.Config()
+	if cfg.Prefix == "" {
+		cfg.Prefix = strings.TrimPrefix(parent.Prefix+":"+n.name, ":")
+	}
+	inherit(reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(parent))
+	return cfg
+}
+
+// Options returns the cache options of the namespace, those of its ancestors first.
+func (n *Namespace) Options() []echocache.Option {
+	if n.parent == nil {
+		return append([]echocache.Option{}, n.opts...)
+	}
+	return append(n.parent.Options(), n.opts...)
+}
+
+// StoreOptions returns the store options of the namespace, those of its ancestors first.
+func (n *Namespace) StoreOptions() []store.Option {
+	if n.parent == nil {
+		return append([]store.Option{}, n.storeOpts...)
+	}
+	return append(n.parent.StoreOptions(), n.storeOpts...)
+}
+
+// NewForNamespace builds an EchoCache with the configuration and options resolved for the namespace, opts being
+// applied last.
+func NewForNamespace[T any](ctx context.Context, n *Namespace, opts ...echocache.Option) (*echocache.EchoCache[T], io.Closer, error) {
+	return NewWithStoreOptions[T](ctx, n.Config(), n.StoreOptions(), append(n.Options(), opts...)...)
+}
+
+// NewLazyForNamespace builds an EchoCacheLazy as NewLazy does, with the configuration and options resolved for the
+// namespace, opts being applied last.
+func NewLazyForNamespace[T any](ctx context.Context, n *Namespace, opts ...echocache.Option) (*echocache.EchoCacheLazy[T], io.Closer, error) {
+	return NewLazyWithStoreOptions[T](ctx, n.Config(), n.StoreOptions(), append(n.Options(), opts...)...)
+}
+
+// inherit sets the zero fields of the struct child to those of parent, recursing into nested sections.
+func inherit(child reflect.Value, parent reflect.Value) {
+	for i := range child.NumField() {
+		field := child.Field(i)
+		if field.Kind() == reflect.Struct {
+			inherit(field, parent.Field(i))
+			continue
+		}
+		if field.IsZero() {
+			field.Set(parent.Field(i))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNamespace_Config verifies that children inherit the zero fields of their configuration from their ancestors
+// and get a prefix of their own.
+func TestNamespace_Config(t *testing.T) {
+	root := NewNamespace("app", Config{
+		Backend:  BackendRedis,
+		TTL:      10 * time.Minute,
+		Timeouts: TimeoutsConfig{Get: 50 * time.Millisecond, Set: 100 * time.Millisecond},
+		Codec:    CodecConfig{Compression: "zstd"},
+		Redis:    RedisConfig{Addr: "localhost:6379"},
+	})
+	users := root.Child("users", Config{TTL: time.Hour, Timeouts: TimeoutsConfig{Get: 20 * time.Millisecond}})
+	sessions := users.Child("sessions", Config{Prefix: "sess", Codec: CodecConfig{Checksum: "crc32"}})
+
+	assert.Equal(t, "app.users.sessions", sessions.Name())
+	assert.Equal(t, "app", root.Config().Prefix)
+
+	cfg := users.Config()
+	assert.Equal(t, BackendRedis, cfg.Backend)
+	assert.Equal(t, time.Hour, cfg.TTL)
+	assert.Equal(t, "app:users", cfg.Prefix)
+	assert.Equal(t, TimeoutsConfig{Get: 20 * time.Millisecond, Set: 100 * time.Millisecond}, cfg.Timeouts)
+	assert.Equal(t, "localhost:6379", cfg.Redis.Addr)
+
+	cfg = sessions.Config()
+	assert.Equal(t, time.Hour, cfg.TTL)
+	assert.Equal(t, "sess", cfg.Prefix)
+	assert.Equal(t, CodecConfig{Compression: "zstd", Checksum: "crc32"}, cfg.Codec)
+	assert.Equal(t, 20*time.Millisecond, cfg.Timeouts.Get)
+}
+
+// TestNewForNamespace verifies that caches are built with the inherited options, those of the child overriding the
+// parent's.
+func TestNewForNamespace(t *testing.T) {
+	ctx := context.Background()
+	var hooks []string
+	hook := func(name string) echocache.Option {
+		return echocache.WithRefreshHook(func(echocache.RefreshEvent) { hooks = append(hooks, name) })
+	}
+	root := NewNamespace("app", Config{Backend: BackendLRU, Size: 10}, hook("root"))
+	inherited := root.Child("inherited", Config{})
+	overridden := root.Child("overridden", Config{}, hook("child"))
+
+	refresh := func(ctx context.Context) (string, error) { return "value", nil }
+	for _, n := range []*Namespace{inherited, overridden} {
+		ec, closer, err := NewForNamespace[string](ctx, n)
+		require.NoError(t, err)
+		_, _, err = ec.FetchWithCache(ctx, "key", refresh)
+		require.NoError(t, err)
+		require.NoError(t, closer.Close())
+	}
+	assert.Equal(t, []string{"root", "child"}, hooks)
+
+	lazy, closer, err := NewLazyForNamespace[string](ctx, root.Child("lazy", Config{Backend: BackendLRUExpirable, TTL: time.Minute}))
+	require.NoError(t, err)
+	defer closer.Close()
+	_, _, err = lazy.FetchWithLazyRefresh(ctx, "key", refresh, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"root", "child", "root"}, hooks)
+
+	_, _, err = NewForNamespace[string](ctx, NewNamespace("broken", Config{}))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
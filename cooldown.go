@@ -0,0 +1,109 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRefreshCooldown is returned when a key is missing from the cache and its refresh is suppressed
+// because previous refreshes failed repeatedly.
+var ErrRefreshCooldown = errors.New("refresh suppressed: key is cooling down after repeated failures")
+
+// failureSweepThreshold is the number of tracked keys above which stale ones are swept on every new failing key.
+const failureSweepThreshold = 1024
+
+// failureState tracks consecutive refresh failures of a key and the end of its current cooldown.
+type failureState struct {
+	failures int
+	until    time.Time
+}
+
+// failureTracker quarantines keys whose refresh keeps failing, applying an exponentially increasing cooldown.
+// Keys whose cooldown ended more than the maximum cooldown ago are forgotten, so keys that stop being requested do not
+// accumulate. A nil *failureTracker is valid and never blocks any key.
+type failureTracker struct {
+	mu    sync.Mutex
+	base  time.Duration
+	max   time.Duration
+	state map[string]*failureState
+}
+
+// newFailureTracker creates a tracker with the given initial and maximum cooldown.
+func newFailureTracker(base time.Duration, max time.Duration) *failureTracker {
+	if max < base {
+		max = base
+	}
+	return &failureTracker{
+		base:  base,
+		max:   max,
+		state: make(map[string]*failureState),
+	}
+}
+
+// setLimits changes the initial and maximum cooldown applied to the next failures.
+func (f *failureTracker) setLimits(base time.Duration, max time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if max < base {
+		max = base
+	}
+	f.base = base
+	f.max = max
+}
+
+// blocked reports whether refreshes of the key are currently suppressed.
+func (f *failureTracker) blocked(key string) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, ok := f.state[key]
+	return ok && time.Now().Before(st.until)
+}
+
+// record updates the failure state of the key after a refresh attempt run with ctx: a nil error resets it,
+// while an error extends the cooldown exponentially. Attempts cancelled, or whose ctx is done, are not failures of
+// the key and leave its state untouched.
+func (f *failureTracker) record(ctx context.Context, key string, err error) {
+	if f == nil {
+		return
+	}
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.state, key)
+		return
+	}
+	st, ok := f.state[key]
+	if !ok {
+		f.sweepLocked()
+		st = &failureState{}
+		f.state[key] = st
+	}
+	st.failures++
+	cooldown := f.base
+	for i := 1; i < st.failures && cooldown < f.max; i++ {
+		cooldown *= 2
+	}
+	st.until = time.Now().Add(min(cooldown, f.max))
+}
+
+// sweepLocked forgets the keys whose cooldown ended more than the maximum cooldown ago, once more than
+// failureSweepThreshold keys are tracked. Must be called with the lock held.
+func (f *failureTracker) sweepLocked() {
+	if len(f.state) < failureSweepThreshold {
+		return
+	}
+	stale := time.Now().Add(-f.max)
+	for k, st := range f.state {
+		if st.until.Before(stale) {
+			delete(f.state, k)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFailureTracker verifies exponential cooldown growth, capping and reset on success.
+func TestFailureTracker(t *testing.T) {
+	ctx := context.Background()
+	tracker := newFailureTracker(time.Minute, 3*time.Minute)
+	failure := errors.New("boom")
+
+	assert.False(t, tracker.blocked("k"))
+	tracker.record(ctx, "k", failure)
+	assert.True(t, tracker.blocked("k"))
+	assert.WithinDuration(t, time.Now().Add(time.Minute), tracker.state["k"].until, time.Second)
+
+	tracker.record(ctx, "k", failure)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), tracker.state["k"].until, time.Second)
+
+	tracker.record(ctx, "k", failure)
+	assert.WithinDuration(t, time.Now().Add(3*time.Minute), tracker.state["k"].until, time.Second)
+
+	tracker.record(ctx, "k", nil)
+	assert.False(t, tracker.blocked("k"))
+
+	var disabled *failureTracker
+	disabled.record(ctx, "k", failure)
+	assert.False(t, disabled.blocked("k"))
+}
+
+// TestFailureTracker_IgnoresCancellation verifies that cancelled refreshes do not count as failures.
+func TestFailureTracker_IgnoresCancellation(t *testing.T) {
+	tracker := newFailureTracker(time.Minute, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tracker.record(context.Background(), "k", context.Canceled)
+	tracker.record(context.Background(), "k", fmt.Errorf("fetch: %w", context.Canceled))
+	tracker.record(ctx, "k", errors.New("request aborted"))
+	assert.False(t, tracker.blocked("k"))
+	assert.Empty(t, tracker.state)
+
+	tracker.record(context.Background(), "k", context.DeadlineExceeded)
+	assert.True(t, tracker.blocked("k"))
+}
+
+// TestFailureTracker_Sweep verifies that keys whose cooldown ended long ago are forgotten.
+func TestFailureTracker_Sweep(t *testing.T) {
+	ctx := context.Background()
+	tracker := newFailureTracker(time.Minute, time.Hour)
+	failure := errors.New("boom")
+	for i := range failureSweepThreshold {
+		tracker.record(ctx, fmt.Sprintf("old-%d", i), failure)
+	}
+	tracker.record(ctx, "recent", failure)
+	for key, st := range tracker.state {
+		if key != "recent" {
+			st.until = time.Now().Add(-2 * time.Hour)
+		}
+	}
+	tracker.state["old-0"].until = time.Now().Add(-30 * time.Minute)
+
+	tracker.record(ctx, "new", failure)
+	assert.Len(t, tracker.state, 3)
+	assert.Contains(t, tracker.state, "old-0")
+	assert.Contains(t, tracker.state, "recent")
+	assert.Contains(t, tracker.state, "new")
+}
+
+// TestEchoCache_FailureCooldown verifies that a failing key is not recomputed during its cooldown.
+func TestEchoCache_FailureCooldown(t *testing.T) {
+	ctx := context.Background()
+	cache := NewEchoCache[string](store.NewLRUCache[string](10), WithFailureCooldown(time.Minute, time.Hour))
+	calls := 0
+	failing := func(ctx context.Context) (string, error) {
+		calls++
+		return "", errors.New("upstream down")
+	}
+
+	_, _, err := cache.FetchWithCache(ctx, "k", failing)
+	assert.EqualError(t, err, "upstream down")
+
+	_, exists, err := cache.FetchWithCache(ctx, "k", failing)
+	assert.ErrorIs(t, err, ErrRefreshCooldown)
+	assert.False(t, exists)
+	assert.Equal(t, 1, calls)
+}
+
+// TestEchoCacheLazy_FailureCooldown verifies that the lazy cache keeps serving stale data without scheduling refreshes.
+func TestEchoCacheLazy_FailureCooldown(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second, WithFailureCooldown(time.Minute, time.Hour))
+	defer cache.ShutdownLazyRefresh()
+
+	_ = swr.Set(ctx, "k", store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-time.Hour)})
+	cache.settings.Load().cooldown.record(ctx, "k", errors.New("boom"))
+
+	value, exists, err := cache.FetchWithLazyRefresh(ctx, "k", func(ctx context.Context) (string, error) {
+		t.Error("refresh must not run during cooldown")
+		return "", nil
+	}, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "stale", value)
+	assert.Empty(t, cache.queue)
+}
@@ -0,0 +1,113 @@
+package echocache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedLockCache simulates a store shared by several processes, with refresh locks visible to all of them.
+type sharedLockCache struct {
+	store.Cacher[string]
+	mu    sync.Mutex
+	locks map[string]string
+}
+
+// newSharedLockCache creates an empty shared store backed by an LRU cache.
+func newSharedLockCache() *sharedLockCache {
+	return &sharedLockCache{Cacher: store.NewLRUCache[string](10), locks: map[string]string{}}
+}
+
+// TryAcquireRefreshLock grants the lock unless another holder owns it.
+func (s *sharedLockCache) TryAcquireRefreshLock(_ context.Context, key string, randValue string, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if holder, ok := s.locks[key]; ok && holder != randValue {
+		return false, nil
+	}
+	s.locks[key] = randValue
+	return true, nil
+}
+
+// ReleaseRefreshLock releases the lock if it is owned by randValue.
+func (s *sharedLockCache) ReleaseRefreshLock(_ context.Context, key string, randValue string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[key] == randValue {
+		delete(s.locks, key)
+	}
+	return nil
+}
+
+// TestEchoCache_DistributedLock verifies that caches sharing a store compute a miss only once.
+func TestEchoCache_DistributedLock(t *testing.T) {
+	shared := newSharedLockCache()
+	var calls atomic.Int32
+	refreshFn := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		// Each EchoCache stands for a different process with its own singleflight group.
+		cache := NewEchoCache[string](shared, WithDistributedLock(time.Second, 5*time.Millisecond))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, exists, err := cache.FetchWithCache(context.Background(), "k", refreshFn)
+			assert.NoError(t, err)
+			assert.True(t, exists)
+			assert.Equal(t, "value", value)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Empty(t, shared.locks)
+}
+
+// TestEchoCache_DistributedLockWaitCancelled verifies that a caller waiting on a lock held elsewhere honors its context.
+func TestEchoCache_DistributedLockWaitCancelled(t *testing.T) {
+	shared := newSharedLockCache()
+	shared.locks["k"] = "other-process"
+	cache := NewEchoCache[string](shared, WithDistributedLock(time.Minute, 5*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, exists, err := cache.FetchWithCache(ctx, "k", func(ctx context.Context) (string, error) {
+		t.Error("refresh must not run while another process holds the lock")
+		return "", nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, exists)
+}
+
+// TestEchoCache_LockWaitMetric verifies that the time spent waiting on the distributed lock is reported.
+func TestEchoCache_LockWaitMetric(t *testing.T) {
+	shared := newSharedLockCache()
+	shared.locks["k"] = "other-process"
+	metrics := store.NewMemoryMetrics()
+	cache := NewEchoCache[string](shared, WithDistributedLock(time.Minute, 5*time.Millisecond), WithMetrics(metrics))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = shared.Set(context.Background(), "k", "value")
+	}()
+	value, _, err := cache.FetchWithCache(context.Background(), "k", func(ctx context.Context) (string, error) {
+		return "", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	waits := metrics.Durations(MetricLockWait, map[string]string{"outcome": "served"})
+	require.Len(t, waits, 1)
+	assert.GreaterOrEqual(t, waits[0], 20*time.Millisecond)
+}
@@ -0,0 +1,136 @@
+package echocache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// publishedSweepThreshold is the number of recorded publications above which expired ones are swept on every new
+// publication.
+const publishedSweepThreshold = 1024
+
+// DistributedRefresher serves values from an EchoCacheLazy and ships the refreshes of stale values through a
+// store.RefreshTransport instead of the in-memory queue, so they are durable and spread over every process running
+// Run. Since tasks cross process boundaries, the refresh function is given the key rather than captured per fetch.
+type DistributedRefresher[T any] struct {
+	cache     *EchoCacheLazy[T]
+	transport store.RefreshTransport
+	interval  time.Duration
+	refreshFn func(ctx context.Context, key string) (T, error)
+
+	mu        sync.Mutex
+	published map[string]time.Time
+}
+
+// NewDistributedRefresher creates a refresher for values of cache considered stale after interval, computed by
+// refreshFn and shipped through transport.
+func NewDistributedRefresher[T any](cache *EchoCacheLazy[T], transport store.RefreshTransport, interval time.Duration, refreshFn func(ctx context.Context, key string) (T, error)) *DistributedRefresher[T] {
+	return &DistributedRefresher[T]{
+		cache:     cache,
+		transport: transport,
+		interval:  interval,
+		refreshFn: refreshFn,
+		published: make(map[string]time.Time),
+	}
+}
+
+// Fetch returns the cached value of the key, publishing a refresh request when it is stale, or computes it in the
+// foreground on a miss. A refresh of the same key is published at most once per interval by this process.
+func (r *DistributedRefresher[T]) Fetch(ctx context.Context, key string) (T, bool, error) {
+	var zeroValue T
+	if err := ctx.Err(); err != nil {
+		return zeroValue, false, err
+	}
+	value, exists, err := r.cache.store.Get(ctx, key)
+	requestId := newID(r.cache.opts.ids, requestIDLength)
+	rid := correlationID(ctx, requestId)
+	if exists {
+		now := time.Now()
+		if value.CreatedAt.Add(r.interval).Before(now) {
+			r.cache.counters.staleHit(key)
+			if !r.cache.settings.Load().cooldown.blocked(key) && r.claim(key, now) {
+				req := store.RefreshRequest{Key: key, RequestID: rid, ObservedAt: value.CreatedAt, EnqueuedAt: now}
+				if err := r.transport.Publish(ctx, req); err != nil {
+					slog.Warn("Cannot publish refresh request", slog.String("key", key), slog.String("error", err.Error()), slog.String("requestId", rid))
+					r.release(key)
+				}
+			}
+		} else {
+			r.cache.counters.hit(key)
+		}
+		return value.Value, true, nil
+	}
+	if err != nil {
+		slog.Warn("Cannot get resultValue from cache", slog.String("error", err.Error()), slog.String("cacheKey", key), slog.String("requestId", rid))
+	}
+	r.cache.counters.miss(key)
+	if r.cache.settings.Load().cooldown.blocked(key) {
+		r.cache.counters.failure(key)
+		return zeroValue, false, ErrRefreshCooldown
+	}
+	result, computed, err := r.cache.processRefreshTask(refreshTask[T]{
+		key:           key,
+		computeFunc:   r.computeFunc(key),
+		requestId:     requestId,
+		correlationId: rid,
+	})
+	if err != nil {
+		r.cache.counters.failure(key)
+	}
+	return result, computed, err
+}
+
+// Run consumes refresh requests from the transport until ctx is done, skipping requests already satisfied by a value
+// newer than the one that triggered them. A failed refresh is reported to the transport, which delivers it again.
+func (r *DistributedRefresher[T]) Run(ctx context.Context) error {
+	return r.transport.Consume(ctx, func(ctx context.Context, req store.RefreshRequest) error {
+		current, exists, err := r.cache.store.Get(ctx, req.Key)
+		if err == nil && exists && current.CreatedAt.After(req.ObservedAt) {
+			return nil
+		}
+		_, _, err = r.cache.processRefreshTask(refreshTask[T]{
+			key:           req.Key,
+			computeFunc:   r.computeFunc(req.Key),
+			requestId:     newID(r.cache.opts.ids, requestIDLength),
+			correlationId: req.RequestID,
+			background:    true,
+		})
+		return err
+	})
+}
+
+// computeFunc binds the refresh function to the key.
+func (r *DistributedRefresher[T]) computeFunc(key string) store.RefreshFunc[T] {
+	return func(ctx context.Context) (T, error) {
+		return r.refreshFn(ctx, key)
+	}
+}
+
+// claim reports whether a refresh of the key may be published now, recording the publication.
+func (r *DistributedRefresher[T]) claim(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last, ok := r.published[key]; ok && now.Sub(last) < r.interval {
+		return false
+	}
+	if len(r.published) >= publishedSweepThreshold {
+		for k, last := range r.published {
+			if now.Sub(last) >= r.interval {
+				delete(r.published, k)
+			}
+		}
+	}
+	r.published[key] = now
+	return true
+}
+
+// release forgets the publication of the key, so that the next fetch publishes again.
+func (r *DistributedRefresher[T]) release(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.published, key)
+}
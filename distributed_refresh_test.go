@@ -0,0 +1,78 @@
+package echocache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chanTransport is an in-memory RefreshTransport redelivering failed requests.
+type chanTransport struct {
+	requests chan store.RefreshRequest
+}
+
+func (c chanTransport) Publish(_ context.Context, req store.RefreshRequest) error {
+	c.requests <- req
+	return nil
+}
+
+func (c chanTransport) Consume(ctx context.Context, handler func(ctx context.Context, req store.RefreshRequest) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case req := <-c.requests:
+			if err := handler(ctx, req); err != nil {
+				c.requests <- req
+			}
+		}
+	}
+}
+
+// TestDistributedRefresher verifies that stale values are refreshed by a worker consuming the transport and that a
+// key is published once per interval.
+func TestDistributedRefresher(t *testing.T) {
+	lru := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](lru, time.Second)
+	defer cache.ShutdownLazyRefresh()
+	transport := chanTransport{requests: make(chan store.RefreshRequest, 10)}
+
+	var calls atomic.Int32
+	refresher := NewDistributedRefresher(cache, transport, time.Minute, func(ctx context.Context, key string) (string, error) {
+		calls.Add(1)
+		return key + "-fresh", nil
+	})
+
+	value, found, err := refresher.Fetch(t.Context(), "k")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "k-fresh", value)
+
+	require.NoError(t, lru.Set(t.Context(), "k", store.StaleValue[string]{Value: "old", CreatedAt: time.Now().Add(-time.Hour)}))
+	for range 3 {
+		value, _, err = refresher.Fetch(t.Context(), "k")
+		require.NoError(t, err)
+		assert.Equal(t, "old", value)
+	}
+	assert.Len(t, transport.requests, 1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = refresher.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		current, _, _ := lru.Get(t.Context(), "k")
+		return current.Value == "k-fresh"
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, uint64(3), cache.Stats().StaleHits)
+}
@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"github.com/logocomune/echocache/store"
-	"golang.org/x/sync/singleflight"
+	"io"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// MetricLockWait is the time a miss waits on the distributed lock configured by WithDistributedLock, labelled by
+// outcome.
+const MetricLockWait = "echocache_lock_wait_duration"
+
 // NeverExpire represents a duration of 100 years, effectively used to denote a value that should never expire.
 const (
 	NeverExpire = time.Hour * 24 * 365 * 100
@@ -18,47 +24,150 @@ const (
 // EchoCache uses a Cacher interface for data storage and retrieval, supporting custom refresh functions for cache misses.
 // EchoCache ensures only one computation per key occurs simultaneously to optimize concurrent operations.
 type EchoCache[T any] struct {
-	store store.Cacher[T]
-	sf    singleflight.Group
+	store    store.Cacher[T]
+	sf       *shardedGroup
+	sfPrefix string
+	settings atomic.Pointer[tunables]
+	reconfMu sync.Mutex
+	inFlight *inFlightTracker
+	counters *cacheCounters
+	graves   *tombstoneTracker
+	versions *versionTracker
+	metrics  store.MetricsSink
+	ids      IDGenerator
+	absent   *absenceMarkers
+	loader   LoaderFunc[T]
+	bus      *EventBus
+	busTopic string
+	fallback *staleFallback[T]
+	unsub    func()
+	keys     KeyFunc
 }
 
 // NewEchoCache creates a new EchoCache instance to enable caching with optional singleflight for concurrent requests.
-func NewEchoCache[T any](cacher store.Cacher[T]) *EchoCache[T] {
-
-	return &EchoCache[T]{
-		store: cacher,
-		sf:    singleflight.Group{},
+func NewEchoCache[T any](cacher store.Cacher[T], opts ...Option) *EchoCache[T] {
+	o := newOptions(opts)
+	ec := &EchoCache[T]{
+		store:    cacher,
+		sf:       newShardedGroup(o.sfShards),
+		inFlight: newInFlightTracker(o.metrics),
+		counters: o.cacheCounters(),
+		graves:   o.tombstoneTracker(),
+		versions: newVersionTracker(),
+		metrics:  o.metrics,
+		ids:      o.ids,
+		absent:   o.absenceMarkers(),
+		bus:      o.bus,
+		busTopic: o.busTopic,
+		fallback: newStaleFallback[T](o),
+		keys:     o.keyFunc,
+	}
+	ec.settings.Store(o.tunables(nil))
+	if ec.graves != nil {
+		ec.unsub = ec.bus.Subscribe(ec.busTopic, func(e BusEvent) {
+			if e.Kind == BusInvalidated {
+				ec.graves.bury(e.Key)
+			}
+		})
 	}
+	return ec
 }
 
 // FetchWithCache retrieves a cached value by key or computes it using a given refresh function, caching the result for future use.
 // Returns the value, a boolean indicating if it was found or computed, and an error if computation or retrieval fails.
+// A context that is already done is reported immediately without touching the store.
+// A key with an active tombstone, see WithTombstones, is reported as not found, and a key marked permanently absent
+// returns ErrPermanentlyAbsent.
 func (ec *EchoCache[T]) FetchWithCache(ctx context.Context, key string, refreshFn store.RefreshFunc[T]) (T, bool, error) {
+	return ec.fetch(ctx, ec.key(key), refreshFn, 0)
+}
+
+// FetchWithCacheTTL is FetchWithCache storing the computed value for ttl instead of the lifetime configured on the
+// store, so that keys with different lifetimes share the same cache. The TTL is applied by stores implementing
+// store.TTLSetter; other stores keep their own lifetime. Concurrent fetches of a missing key share the computation
+// of the first caller, whose TTL applies.
+func (ec *EchoCache[T]) FetchWithCacheTTL(ctx context.Context, key string, refreshFn store.RefreshFunc[T], ttl time.Duration) (T, bool, error) {
+	return ec.fetch(ctx, ec.key(key), refreshFn, ttl)
+}
+
+// fetch implements FetchWithCache, storing computed values for ttl when positive.
+func (ec *EchoCache[T]) fetch(ctx context.Context, key string, refreshFn store.RefreshFunc[T], ttl time.Duration) (T, bool, error) {
 	var zeroValue T
+	if err := ctx.Err(); err != nil {
+		return zeroValue, false, err
+	}
+	if ec.graves.active(key) {
+		ec.counters.miss(key)
+		return zeroValue, false, nil
+	}
+	if shared, ok := sharedValue[T](ec.bus, ec.busTopic, key); ok {
+		ec.counters.hit(key)
+		return shared, true, nil
+	}
 
 	// Attempt to retrieve the resultValue from the cache.
 	value, exists, err := ec.store.Get(ctx, key)
 	if exists {
+		ec.counters.hit(key)
 		return value, true, nil
 	}
+	if ec.fallback != nil && ec.inFlight.computing(key) {
+		// Serve the expired copy rather than waiting for the computation already running.
+		if copied, ok := ec.fallback.get(ctx, key); ok {
+			ec.counters.staleHit(key)
+			return copied, true, nil
+		}
+	}
+	ec.counters.miss(key)
+	requestId := newID(ec.ids, requestIDLength)
+	rid := correlationID(ctx, requestId)
 	if err != nil {
 		// Log the error but proceed with computation.
-		slog.Warn("Cannot get resultValue from cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		slog.Warn("Cannot get resultValue from cache", slog.String("error", err.Error()), slog.String("cacheKey", key), slog.String("requestId", rid))
+	}
+	if ec.absent.absent(ctx, key) {
+		return zeroValue, false, ErrPermanentlyAbsent
+	}
+	settings := ec.settings.Load()
+	if settings.cooldown.blocked(key) {
+		ec.counters.failure(key)
+		return zeroValue, false, ErrRefreshCooldown
+	}
+	if settings.budget.exceeded(ctx) {
+		ec.counters.failure(key)
+		return zeroValue, false, ErrBudgetExceeded
 	}
 
-	requestId := randString(10)
+	version := ec.versions.begin(key)
+	defer ec.versions.end(key)
 	// Use singleflight to ensure only one computation is made per key.
-	sfResult, sfErr, _ := ec.sf.Do(key, func() (interface{}, error) {
-		v, e := refreshFn(ctx)
+	sfResult, sfErr, _ := ec.sf.Do(ec.sfPrefix+key, func() (interface{}, error) {
+		ec.inFlight.start(key)
+		defer ec.inFlight.done(key)
+		start := time.Now()
+		v, stored, e := ec.compute(ContextWithRequestID(ctx, rid), key, refreshFn, settings.opts, ttl, version)
+		if e == nil {
+			settings.budget.observe(time.Since(start))
+			if !ec.graves.active(key) && !ec.versions.superseded(key, version) {
+				ec.fallback.set(ctx, key, v, time.Now())
+			}
+		}
+		settings.cooldown.record(ctx, key, e)
+		recordAbsence(ctx, ec.absent, ec.store, key, e)
+		if hook := settings.opts.refreshHook; hook != nil {
+			hook(RefreshEvent{Key: key, RequestID: rid, Duration: time.Since(start), Err: e})
+		}
 		res := singleFlightResult[T]{
 			resultValue: v,
 			createdAt:   time.Now(),
 			requestId:   requestId,
+			stored:      stored,
 		}
 
 		return res, e
 	})
 	if sfErr != nil {
+		ec.counters.failure(key)
 		return zeroValue, false, sfErr
 	}
 
@@ -69,13 +178,242 @@ func (ec *EchoCache[T]) FetchWithCache(ctx context.Context, key string, refreshF
 		return zeroValue, false, errors.New("type assertion failed for computed resultValue")
 	}
 
-	if resolvedValue.requestId == requestId {
+	if resolvedValue.requestId == requestId && !resolvedValue.stored {
 		// Save the computed resultValue in the cache.
-		if err := ec.store.Set(ctx, key, resolvedValue.resultValue); err != nil {
+		if _, err := ec.setComputed(ctx, key, resolvedValue.resultValue, ttl, version); err != nil {
 			// Log the error but still return the computed resultValue.
-			slog.Warn("Failed to store resultValue in cache", slog.String("key", key), slog.String("error", err.Error()))
+			slog.Warn("Failed to store resultValue in cache", slog.String("key", key), slog.String("error", err.Error()), slog.String("requestId", rid))
 		}
 
 	}
+	if resolvedValue.requestId == requestId {
+		ec.bus.publishIf(BusEvent{Topic: ec.busTopic, Key: key, Kind: BusWarmed, Value: resolvedValue.resultValue}, func() bool {
+			return !ec.graves.active(key) && !ec.versions.superseded(key, version)
+		})
+	}
 	return resolvedValue.resultValue, true, nil
 }
+
+// Close removes the subscription of the cache to the event bus configured with WithEventBus, so the bus no longer
+// holds a reference to it. The cache stops receiving the invalidations of the other caches of the topic.
+func (ec *EchoCache[T]) Close() {
+	if ec.unsub != nil {
+		ec.unsub()
+	}
+}
+
+// InFlight returns the keys whose value is currently being computed by this cache, sorted.
+// A long list indicates a stampede or a cold start.
+func (ec *EchoCache[T]) InFlight() []string {
+	return ec.inFlight.list()
+}
+
+// Stats returns a snapshot of the fetch outcomes of this cache, the number of keys being computed and the store size.
+func (ec *EchoCache[T]) Stats() Stats {
+	stats := ec.counters.snapshot(ec.store)
+	stats.InFlight = len(ec.inFlight.list())
+	return stats
+}
+
+// Clear removes every entry from the underlying store.
+// Returns store.ErrNotSupported if the store can be neither cleared nor enumerated.
+func (ec *EchoCache[T]) Clear(ctx context.Context) error {
+	return store.Clear(ctx, ec.store)
+}
+
+// ClearLocal removes the entries the underlying store holds in process memory, leaving shared backends untouched.
+func (ec *EchoCache[T]) ClearLocal(ctx context.Context) error {
+	return store.ClearLocal(ctx, ec.store)
+}
+
+// InvalidateLocal removes the key from the entries held in process memory, leaving shared backends untouched, and
+// writes a tombstone for it like Invalidate. It applies invalidations broadcast by other instances, see
+// ClearCoordinator.
+func (ec *EchoCache[T]) InvalidateLocal(ctx context.Context, key string) error {
+	key = ec.key(key)
+	ec.graves.bury(key)
+	ec.bus.Publish(BusEvent{Topic: ec.busTopic, Key: key, Kind: BusInvalidated})
+	ec.fallback.delete(ctx, key)
+	return store.DeleteLocal(ctx, ec.store, key)
+}
+
+// Resize changes the capacity of the underlying in-memory store, returning the number of evicted entries.
+// Returns store.ErrNotSupported if the store cannot be resized.
+func (ec *EchoCache[T]) Resize(size int) (int, error) {
+	return store.Resize(ec.store, size)
+}
+
+// compute runs refreshFn for a missing key. When a distributed lock is configured and the store supports refresh locks,
+// only the lock holder computes and stores the value while the other callers wait for it to appear in the store.
+// The returned flag reports whether the value is already stored, for ttl when positive. version is the version of the
+// key noted before the fetch started, see setComputed.
+func (ec *EchoCache[T]) compute(ctx context.Context, key string, refreshFn store.RefreshFunc[T], o options, ttl time.Duration, version uint64) (T, bool, error) {
+	locker, ok := ec.store.(store.RefreshLocker)
+	if o.lockTTL <= 0 || !ok {
+		v, err := refreshFn(ctx)
+		return v, false, err
+	}
+
+	lockValue := newID(ec.ids, lockValueLength)
+	waitStart := time.Now()
+	for {
+		acquired, err := locker.TryAcquireRefreshLock(ctx, key, lockValue, o.lockTTL)
+		if err != nil {
+			ec.observeLockWait("error", waitStart)
+			slog.Warn("Cannot acquire distributed lock, computing locally", slog.String("error", err.Error()), slog.String("cacheKey", key))
+			v, err := refreshFn(ctx)
+			return v, false, err
+		}
+		if acquired {
+			ec.observeLockWait("acquired", waitStart)
+			break
+		}
+
+		timer := time.NewTimer(o.lockPoll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			ec.observeLockWait("cancelled", waitStart)
+			var zeroValue T
+			return zeroValue, false, ctx.Err()
+		case <-timer.C:
+		}
+		if v, exists, _ := ec.store.Get(ctx, key); exists {
+			ec.observeLockWait("served", waitStart)
+			return v, true, nil
+		}
+	}
+	defer func() {
+		if err := locker.ReleaseRefreshLock(context.WithoutCancel(ctx), key, lockValue); err != nil {
+			slog.Warn("Cannot release distributed lock", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		}
+	}()
+
+	// Another process may have stored the value between our miss and the lock acquisition.
+	if v, exists, _ := ec.store.Get(ctx, key); exists {
+		return v, true, nil
+	}
+	v, err := refreshFn(ctx)
+	if err != nil {
+		return v, false, err
+	}
+	if _, err := ec.setComputed(ctx, key, v, ttl, version); err != nil {
+		slog.Warn("Failed to store resultValue in cache", slog.String("key", key), slog.String("error", err.Error()))
+	}
+	return v, true, nil
+}
+
+// set stores the value for ttl when positive and the store implements store.TTLSetter, and with the lifetime of the
+// store otherwise.
+func (ec *EchoCache[T]) set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if setter, ok := ec.store.(store.TTLSetter[T]); ok && ttl > 0 {
+		return setter.SetWithTTL(ctx, key, value, ttl)
+	}
+	return ec.store.Set(ctx, key, value)
+}
+
+// setComputed stores a computed value like set, unless the key has an active tombstone, and reports whether the value
+// was kept. A value stored while the key is invalidated, or computed by a fetch that started at version before an
+// UpdateThrough of the key, is deleted again, so a refresh racing with Invalidate or UpdateThrough cannot bring the
+// old value back.
+func (ec *EchoCache[T]) setComputed(ctx context.Context, key string, value T, ttl time.Duration, version uint64) (bool, error) {
+	undo := func() {
+		if err := store.Delete(context.WithoutCancel(ctx), ec.store, key); err != nil {
+			slog.Warn("Cannot delete superseded value", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		}
+	}
+	kept, err := ec.graves.write(key, func() error {
+		return ec.set(ctx, key, value, ttl)
+	}, undo)
+	if kept && ec.versions.superseded(key, version) {
+		undo()
+		return false, nil
+	}
+	return kept, err
+}
+
+// observeLockWait reports to the metrics sink, if any, how long a miss waited on the distributed lock and how the
+// wait ended: acquired, served by the value stored by the lock holder, cancelled or error.
+func (ec *EchoCache[T]) observeLockWait(outcome string, start time.Time) {
+	if ec.metrics == nil {
+		return
+	}
+	ec.metrics.ObserveDuration(MetricLockWait, map[string]string{"outcome": outcome}, time.Since(start))
+}
+
+// Inspect reports the metadata of the key in the underlying store, such as its TTL, revision and encoded size,
+// without modifying it.
+func (ec *EchoCache[T]) Inspect(ctx context.Context, key string) (store.KeyInfo, error) {
+	return store.Inspect[T](ctx, ec.store, ec.key(key))
+}
+
+// GetField copies the field at path, such as "address.city", of the cached value into dst without decoding the
+// whole value, when the store implements store.FieldGetter. Returns false when the key is missing or has an active
+// tombstone, and store.ErrNotSupported if the store cannot project fields.
+func (ec *EchoCache[T]) GetField(ctx context.Context, key string, path string, dst any) (bool, error) {
+	key = ec.key(key)
+	if ec.graves.active(key) {
+		return false, nil
+	}
+	getter, ok := ec.store.(store.FieldGetter)
+	if !ok {
+		return false, store.ErrNotSupported
+	}
+	return getter.GetField(ctx, key, path, dst)
+}
+
+// Dump writes the entries of the underlying store as newline-delimited JSON for debugging purposes.
+// Returns store.ErrNotSupported if the store cannot enumerate its keys.
+func (ec *EchoCache[T]) Dump(ctx context.Context, w io.Writer, opts store.DumpOptions) error {
+	return store.Dump[T](ctx, ec.store, w, opts)
+}
+
+// BulkSet loads precomputed values into the underlying store, using pipelined writes when the store supports them.
+func (ec *EchoCache[T]) BulkSet(ctx context.Context, entries map[string]T) error {
+	return store.BulkSet[T](ctx, ec.store, filterTombstoned(ec.graves, ec.keyedEntries(entries)))
+}
+
+// SetMulti stores related entries together, in a single transaction when atomic is true and the store implements
+// store.AtomicSetter, and with best-effort batching otherwise. Keys with an active tombstone are skipped.
+func (ec *EchoCache[T]) SetMulti(ctx context.Context, entries map[string]T, atomic bool) error {
+	return store.SetMulti[T](ctx, ec.store, filterTombstoned(ec.graves, ec.keyedEntries(entries)), atomic)
+}
+
+// BulkSetStream loads values streamed from the channel into the underlying store in batches of batchSize. Keys with
+// an active tombstone are skipped. Returns the number of entries written.
+func (ec *EchoCache[T]) BulkSetStream(ctx context.Context, entries <-chan store.Entry[T], batchSize int) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return store.BulkSetStream[T](ctx, ec.store, filterTombstonedStream(ctx, ec.graves, ec.keyedStream(ctx, entries)), batchSize)
+}
+
+// Invalidate removes the key from the underlying store so that the next fetch recomputes it. With WithTombstones,
+// it also leaves a tombstone on the key, and with WithEventBus the invalidation is shared with the other caches of
+// the topic. Returns store.ErrNotSupported if the store cannot delete entries.
+func (ec *EchoCache[T]) Invalidate(ctx context.Context, key string) error {
+	return ec.invalidate(ctx, ec.key(key))
+}
+
+// invalidate implements Invalidate for the store key.
+func (ec *EchoCache[T]) invalidate(ctx context.Context, key string) error {
+	ec.graves.bury(key)
+	ec.bus.Publish(BusEvent{Topic: ec.busTopic, Key: key, Kind: BusInvalidated})
+	ec.fallback.delete(ctx, key)
+	return store.Delete(ctx, ec.store, key)
+}
+
+// Take returns the cached value for the key and removes it, so one-shot values such as tokens are consumed at most once.
+// Returns store.ErrNotSupported if the store does not implement store.Taker.
+func (ec *EchoCache[T]) Take(ctx context.Context, key string) (T, bool, error) {
+	return store.Take[T](ctx, ec.store, ec.key(key))
+}
+
+// PopulateIfAbsent stores the value only if the key is missing, so seed jobs never overwrite fresher values written by live traffic.
+// Returns true when the value was written, or store.ErrNotSupported if the store does not implement store.Populator.
+func (ec *EchoCache[T]) PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error) {
+	key = ec.key(key)
+	if ec.graves.active(key) {
+		return false, nil
+	}
+	return store.PopulateIfAbsent[T](ctx, ec.store, key, value)
+}
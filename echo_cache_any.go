@@ -0,0 +1,213 @@
+package echocache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/logocomune/echocache/store"
+)
+
+var (
+	// ErrUnknownType is returned when a cached value has a type name missing from the TypeRegistry.
+	ErrUnknownType = errors.New("cached value has an unregistered type")
+	// ErrTypeMismatch is returned when a cached value does not have the type requested by the caller.
+	ErrTypeMismatch = errors.New("cached value has an unexpected type")
+	// ErrDuplicateType is returned when a type name or a type is registered twice.
+	ErrDuplicateType = errors.New("type already registered")
+)
+
+// TypeRegistry maps type names to Go types, so that values of types unknown at compile time can be decoded after a
+// round trip through a serializing store. It is safe for concurrent use.
+type TypeRegistry struct {
+	mu     sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}
+
+// NewTypeRegistry creates an empty type registry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		byName: make(map[string]reflect.Type),
+		byType: make(map[reflect.Type]string),
+	}
+}
+
+// RegisterType registers T under name. The name is stored with every value of type T, so it must remain stable
+// across deployments. Returns ErrDuplicateType if the name or the type is already registered.
+func RegisterType[T any](r *TypeRegistry, name string) error {
+	return r.register(name, reflect.TypeFor[T]())
+}
+
+// register records the mapping between name and t.
+func (r *TypeRegistry) register(name string, t reflect.Type) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateType, name)
+	}
+	if _, ok := r.byType[t]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateType, t)
+	}
+	r.byName[name] = t
+	r.byType[t] = name
+	return nil
+}
+
+// name returns the registered name of the type of v, or its Go type name when the type is not registered.
+func (r *TypeRegistry) name(v any) string {
+	t := reflect.TypeOf(v)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name, ok := r.byType[t]; ok {
+		return name
+	}
+	return t.String()
+}
+
+// lookup returns the type registered under name.
+func (r *TypeRegistry) lookup(name string) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.byName[name]
+	return t, ok
+}
+
+// DynamicValue is the representation of the values of an EchoCacheAny in its store: the value together with the name
+// of its type. In memory stores it keeps the value as is; serializing stores encode it as {"type":..., "value":...}
+// and the value is decoded into its registered type when read.
+type DynamicValue struct {
+	Type  string
+	Value any
+	raw   json.RawMessage
+}
+
+// dynamicValueJSON is the JSON encoding of a DynamicValue.
+type dynamicValueJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MarshalJSON encodes the value together with its type name.
+func (d DynamicValue) MarshalJSON() ([]byte, error) {
+	if d.raw != nil {
+		return json.Marshal(dynamicValueJSON{Type: d.Type, Value: d.raw})
+	}
+	raw, err := json.Marshal(d.Value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(dynamicValueJSON{Type: d.Type, Value: raw})
+}
+
+// UnmarshalJSON keeps the encoded value until EchoCacheAny decodes it into its registered type.
+func (d *DynamicValue) UnmarshalJSON(data []byte) error {
+	var decoded dynamicValueJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*d = DynamicValue{Type: decoded.Type, raw: decoded.Value}
+	return nil
+}
+
+// EchoCacheAny is an EchoCache holding values of any type, for plugin systems and frameworks that cannot know the
+// value type at compile time. Types must be registered in its TypeRegistry for values to survive serializing stores;
+// FetchAs and As assert the type of the returned values safely.
+type EchoCacheAny struct {
+	ec    *EchoCache[DynamicValue]
+	types *TypeRegistry
+}
+
+// NewEchoCacheAny creates a cache of any-typed values stored in cacher, decoding serialized values with types.
+// A nil registry is replaced by an empty one, which is enough for in-memory stores.
+func NewEchoCacheAny(cacher store.Cacher[DynamicValue], types *TypeRegistry, opts ...Option) *EchoCacheAny {
+	if types == nil {
+		types = NewTypeRegistry()
+	}
+	return &EchoCacheAny{
+		ec:    NewEchoCache[DynamicValue](cacher, opts...),
+		types: types,
+	}
+}
+
+// Types returns the type registry of the cache.
+func (e *EchoCacheAny) Types() *TypeRegistry {
+	return e.types
+}
+
+// FetchWithCache retrieves a cached value by key or computes it with refreshFn, like EchoCache.FetchWithCache.
+// Returns ErrUnknownType if the stored value has a type missing from the registry.
+func (e *EchoCacheAny) FetchWithCache(ctx context.Context, key string, refreshFn func(ctx context.Context) (any, error)) (any, bool, error) {
+	dv, found, err := e.ec.FetchWithCache(ctx, key, func(ctx context.Context) (DynamicValue, error) {
+		v, err := refreshFn(ctx)
+		if err != nil || v == nil {
+			return DynamicValue{}, err
+		}
+		return DynamicValue{Type: e.types.name(v), Value: v}, nil
+	})
+	if err != nil || !found {
+		return nil, found, err
+	}
+	v, err := e.decode(dv)
+	return v, err == nil, err
+}
+
+// Invalidate removes the key from the underlying store so that the next fetch recomputes it.
+func (e *EchoCacheAny) Invalidate(ctx context.Context, key string) error {
+	return e.ec.Invalidate(ctx, key)
+}
+
+// Stats returns a snapshot of the fetch outcomes of the cache.
+func (e *EchoCacheAny) Stats() Stats {
+	return e.ec.Stats()
+}
+
+// decode returns the value held by dv, decoding it into its registered type when it was read from a serializing store.
+func (e *EchoCacheAny) decode(dv DynamicValue) (any, error) {
+	if dv.raw == nil || dv.Type == "" {
+		return dv.Value, nil
+	}
+	t, ok := e.types.lookup(dv.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, dv.Type)
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(dv.raw, ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// FetchAs fetches a value of type T through the any-typed cache, returning ErrTypeMismatch if the cached value has a
+// different type.
+func FetchAs[T any](ctx context.Context, e *EchoCacheAny, key string, refreshFn store.RefreshFunc[T]) (T, bool, error) {
+	v, found, err := e.FetchWithCache(ctx, key, func(ctx context.Context) (any, error) {
+		return refreshFn(ctx)
+	})
+	if err != nil || !found {
+		var zeroValue T
+		return zeroValue, found, err
+	}
+	typed, err := As[T](v)
+	return typed, err == nil, err
+}
+
+// As asserts that v has type T, returning ErrTypeMismatch instead of panicking when it does not. A nil v converts to
+// the zero value of T when T is an interface, pointer, map, slice or other nillable type.
+func As[T any](v any) (T, error) {
+	typed, ok := v.(T)
+	if ok {
+		return typed, nil
+	}
+	var zeroValue T
+	if v == nil {
+		switch reflect.TypeFor[T]().Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return zeroValue, nil
+		}
+	}
+	return zeroValue, fmt.Errorf("%w: %T is not %s", ErrTypeMismatch, v, reflect.TypeFor[T]())
+}
@@ -0,0 +1,86 @@
+package echocache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// anyPlugin is a value type registered in the tests of EchoCacheAny.
+type anyPlugin struct {
+	Name    string
+	Version int
+}
+
+// jsonRoundTripCache stores values JSON-encoded, like the remote stores do.
+type jsonRoundTripCache struct {
+	inner store.Cacher[[]byte]
+}
+
+func (c jsonRoundTripCache) Get(ctx context.Context, key string) (DynamicValue, bool, error) {
+	var value DynamicValue
+	data, exists, err := c.inner.Get(ctx, key)
+	if !exists || err != nil {
+		return value, exists, err
+	}
+	return value, true, json.Unmarshal(data, &value)
+}
+
+func (c jsonRoundTripCache) Set(ctx context.Context, key string, value DynamicValue) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.inner.Set(ctx, key, data)
+}
+
+// TestEchoCacheAny verifies that values of registered types survive a serializing store and that type assertions
+// are safe.
+func TestEchoCacheAny(t *testing.T) {
+	types := NewTypeRegistry()
+	require.NoError(t, RegisterType[anyPlugin](types, "plugin"))
+	assert.ErrorIs(t, RegisterType[anyPlugin](types, "other"), ErrDuplicateType)
+	assert.ErrorIs(t, RegisterType[string](types, "plugin"), ErrDuplicateType)
+
+	ec := NewEchoCacheAny(jsonRoundTripCache{inner: store.NewLRUCache[[]byte](10)}, types)
+	refresh := func(ctx context.Context) (anyPlugin, error) {
+		return anyPlugin{Name: "auth", Version: 2}, nil
+	}
+	for range 2 {
+		plugin, found, err := FetchAs(t.Context(), ec, "plugin:auth", refresh)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, anyPlugin{Name: "auth", Version: 2}, plugin)
+	}
+
+	_, _, err := FetchAs(t.Context(), ec, "plugin:auth", func(ctx context.Context) (string, error) { return "x", nil })
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+
+	_, _, err = ec.FetchWithCache(t.Context(), "unregistered", func(ctx context.Context) (any, error) { return 3.5, nil })
+	require.NoError(t, err)
+	_, _, err = ec.FetchWithCache(t.Context(), "unregistered", func(ctx context.Context) (any, error) { return 3.5, nil })
+	assert.ErrorIs(t, err, ErrUnknownType)
+}
+
+// TestEchoCacheAny_Memory verifies that in-memory stores keep values as is, without registration.
+func TestEchoCacheAny_Memory(t *testing.T) {
+	ec := NewEchoCacheAny(store.NewLRUCache[DynamicValue](10), nil)
+	for range 2 {
+		v, found, err := ec.FetchWithCache(t.Context(), "k", func(ctx context.Context) (any, error) { return []int{1, 2}, nil })
+		require.NoError(t, err)
+		assert.True(t, found)
+		values, err := As[[]int](v)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, values)
+	}
+
+	_, err := As[int]("x")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	ptr, err := As[*anyPlugin](nil)
+	require.NoError(t, err)
+	assert.Nil(t, ptr)
+}
@@ -70,56 +70,6 @@ func setupNatsForTest(ctx context.Context) (*natsContainer, error) {
 
 }
 
-// redisContainer represents a wrapper for a test container running a Redis instance.
-// It embeds testcontainers.Container and includes custom fields Host and Port for connection details.
-type redisContainer struct {
-	testcontainers.Container
-	Host string
-	Port string
-}
-
-// setupRedisForTest initializes a Redis container for testing, returning its connection details and any errors encountered.
-func setupRedisForTest(ctx context.Context) (*redisContainer, error) {
-	port := "6379"
-	proto := "tcp"
-	req := testcontainers.ContainerRequest{
-		Image:        "redis:7",
-		ExposedPorts: []string{port + "/" + proto},
-		WaitingFor:   wait.ForLog("Ready to accept connections"),
-	}
-	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
-		ContainerRequest: req,
-		Started:          true,
-	})
-	var redisC *redisContainer
-	if container != nil {
-		redisC = &redisContainer{
-			Container: container,
-		}
-	}
-	if err != nil {
-		return redisC, err
-	}
-	host, err := container.Host(ctx)
-	if err != nil {
-		return redisC, err
-	}
-	redisC.Host = host
-
-	natP, err := nat.NewPort(proto, port)
-	if err != nil {
-		return redisC, err
-	}
-	mappedPort, err := container.MappedPort(ctx, natP)
-	if err != nil {
-		return redisC, err
-	}
-	redisC.Port = mappedPort.Port()
-
-	return redisC, err
-
-}
-
 // commonIntegration01 validates the lazy cache integration by testing data retrieval, concurrent access, and lazy refresh behavior.
 func commonIntegration01(t *testing.T, e *EchoCacheLazy[TestStruct]) {
 	keyName := randString(10)
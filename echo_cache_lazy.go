@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"github.com/logocomune/echocache/store"
-	"golang.org/x/sync/singleflight"
+	"io"
 	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,46 +18,141 @@ import (
 // Refresh operations are managed with timeout and cancellation support for efficient processing.
 // This type is suitable for scenarios where background cache updates improve application performance.
 type EchoCacheLazy[T any] struct {
-	store          store.StaleWhileRevalidateCache[T]
-	sf             singleflight.Group
-	queue          chan refreshTask[T]
-	ctx            context.Context
-	cancel         context.CancelFunc
-	refreshTimeout time.Duration
+	store     store.StaleWhileRevalidateCache[T]
+	sf        *shardedGroup
+	queue     chan refreshTask[T]
+	ctx       context.Context
+	cancel    context.CancelFunc
+	settings  atomic.Pointer[tunables]
+	reconfMu  sync.Mutex
+	pendingMu sync.Mutex
+	pending   map[string]*PendingTask
+	inFlight  *inFlightTracker
+	waiters   map[string][]chan RefreshResult[T]
+	counters  *cacheCounters
+	graves    *tombstoneTracker
+	versions  *versionTracker
+	absent    *absenceMarkers
+	opts      options
+	unsub     func()
+	workersMu sync.Mutex
+	workers   []chan struct{}
+}
+
+// ErrRefreshCancelled is delivered to refresh notifications when the pending refresh is cancelled or the cache is shut down.
+var ErrRefreshCancelled = errors.New("background refresh cancelled")
+
+// ErrRefreshExpired is delivered to refresh notifications when the pending refresh is dropped because it waited in
+// the queue past the deadline set by WithStalenessDeadline.
+var ErrRefreshExpired = errors.New("background refresh expired in queue")
+
+// RefreshResult is the outcome of a background refresh delivered by FetchWithLazyRefreshNotify.
+type RefreshResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// PendingTask describes a background refresh waiting in the queue.
+// Attempts counts the refresh requests received for the key since the task was enqueued, including the first one,
+// and RequestID identifies the request that scheduled it. Deadline is the latest time the task may leave the queue,
+// zero when no staleness deadline is configured.
+type PendingTask struct {
+	Key        string
+	RequestID  string
+	EnqueuedAt time.Time
+	Deadline   time.Time
+	Attempts   int
+	// taskID identifies the queued task and its waiters, unlike RequestID which may come from the caller's context.
+	taskID string
 }
 
 // NewLazyEchoCache initializes a lazy echo cache with a specified stale-while-revalidate cacher and refresh timeout.
 // It starts a background goroutine to handle refresh tasks and returns a pointer to the configured EchoCacheLazy instance.
-func NewLazyEchoCache[T any](cacher store.StaleWhileRevalidateCache[T], refreshTimeout time.Duration) *EchoCacheLazy[T] {
+func NewLazyEchoCache[T any](cacher store.StaleWhileRevalidateCache[T], refreshTimeout time.Duration, opts ...Option) *EchoCacheLazy[T] {
 	ctx, cancel := context.WithCancel(context.Background())
+	o := newOptions(opts)
+	if o.refreshTimeout <= 0 {
+		o.refreshTimeout = refreshTimeout
+	}
 
 	lazyCache := EchoCacheLazy[T]{
-		store:          cacher,
-		sf:             singleflight.Group{},
-		queue:          make(chan refreshTask[T], 1000),
-		ctx:            ctx,
-		cancel:         cancel,
-		refreshTimeout: refreshTimeout,
-	}
-	go func() {
-
-		for {
-			select {
-			case task := <-lazyCache.queue:
-				_, _, _ = lazyCache.processRefreshTask(task, refreshTimeout)
-			case <-lazyCache.ctx.Done():
-				return
-
-			}
+		store:    cacher,
+		sf:       newShardedGroup(o.sfShards),
+		queue:    make(chan refreshTask[T], o.queueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		absent:   o.absenceMarkers(),
+		pending:  make(map[string]*PendingTask),
+		inFlight: newInFlightTracker(o.metrics),
+		waiters:  make(map[string][]chan RefreshResult[T]),
+		counters: o.cacheCounters(),
+		graves:   o.tombstoneTracker(),
+		versions: newVersionTracker(),
+		opts:     o,
+	}
+	lazyCache.settings.Store(o.tunables(nil))
+	lazyCache.unsub = o.bus.Subscribe(o.busTopic, func(e BusEvent) {
+		if e.Kind == BusInvalidated {
+			lazyCache.graves.bury(e.Key)
+			lazyCache.CancelPending(e.Key)
 		}
-
-	}()
+	})
+	lazyCache.scaleWorkers(o.refreshWorkers)
 
 	return &lazyCache
 }
 
-// ShutdownLazyRefresh gracefully shuts down the refresh process by canceling the context and closing the task queue.
+// scaleWorkers starts or stops refresh workers until n of them process the queue. Stopped workers exit once their
+// current refresh completes. No worker is started after ShutdownLazyRefresh.
+func (ec *EchoCacheLazy[T]) scaleWorkers(n int) {
+	ec.workersMu.Lock()
+	defer ec.workersMu.Unlock()
+	if ec.ctx.Err() != nil {
+		return
+	}
+	for len(ec.workers) < n {
+		stop := make(chan struct{})
+		ec.workers = append(ec.workers, stop)
+		go ec.refreshWorker(stop)
+	}
+	for len(ec.workers) > n {
+		last := len(ec.workers) - 1
+		close(ec.workers[last])
+		ec.workers = ec.workers[:last]
+	}
+}
+
+// refreshWorker processes the tasks of the queue until stop is closed or the cache is shut down.
+func (ec *EchoCacheLazy[T]) refreshWorker(stop <-chan struct{}) {
+	for {
+		select {
+		case task, ok := <-ec.queue:
+			if !ok {
+				ec.cancelWaiters()
+				return
+			}
+			if !ec.dequeuePending(task) {
+				continue
+			}
+			if current, skip, err := ec.skipTask(task); skip {
+				ec.notifyWaiters(task.requestId, RefreshResult[T]{Value: current, Err: err})
+				continue
+			}
+			value, _, err := ec.processRefreshTask(task)
+			ec.notifyWaiters(task.requestId, RefreshResult[T]{Value: value, Err: err})
+		case <-ec.ctx.Done():
+			ec.cancelWaiters()
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+// ShutdownLazyRefresh gracefully shuts down the refresh process by canceling the context and closing the task queue,
+// and removes the subscription of the cache to the event bus configured with WithEventBus.
 func (ec *EchoCacheLazy[T]) ShutdownLazyRefresh() {
+	ec.unsub()
 	ec.cancel()
 	close(ec.queue)
 }
@@ -63,52 +161,309 @@ func (ec *EchoCacheLazy[T]) ShutdownLazyRefresh() {
 // It uses a key to fetch a value from the cache and utilizes a provided function to refresh the value when necessary.
 // If the cached value exists but is older than the lazy refresh interval, a refresh task is sent to the queue.
 // If the value is missing or an error occurs during retrieval, a new value is computed immediately.
+// Options such as WithRefreshTimeout override the cache defaults for this call only.
+// A context that is already done is reported immediately, without reading the store or scheduling a refresh.
+// A missing key marked permanently absent, see WithAbsenceStore, returns ErrPermanentlyAbsent.
 // Returns the cached or computed value, a boolean indicating cache hit, and an error if any.
-func (ec *EchoCacheLazy[T]) FetchWithLazyRefresh(ctx context.Context, key string, refreshFn store.RefreshFunc[T], lazyRefreshInterval time.Duration) (T, bool, error) {
+func (ec *EchoCacheLazy[T]) FetchWithLazyRefresh(ctx context.Context, key string, refreshFn store.RefreshFunc[T], lazyRefreshInterval time.Duration, opts ...FetchOption) (T, bool, error) {
+	value, exists, _, err := ec.fetchLazy(ctx, key, refreshFn, lazyRefreshInterval, newFetchOptions(opts), nil, nil)
+	return value, exists, err
+}
 
-	// Attempt to retrieve the resultValue from the cache.
-	value, exists, err := ec.store.Get(ctx, key)
+// FetchWithLazyRefreshNotify behaves like FetchWithLazyRefresh and additionally returns a channel that receives the
+// outcome of the background refresh scheduled for a stale value, so callers that served stale data can push the fresh
+// value to their clients. The channel is buffered, receives exactly one RefreshResult and is then closed; it is nil
+// when no background refresh is pending for the key, such as on a fresh hit or a foreground computation.
+func (ec *EchoCacheLazy[T]) FetchWithLazyRefreshNotify(ctx context.Context, key string, refreshFn store.RefreshFunc[T], lazyRefreshInterval time.Duration, opts ...FetchOption) (T, bool, <-chan RefreshResult[T], error) {
+	notify := make(chan RefreshResult[T], 1)
+	value, exists, registered, err := ec.fetchLazy(ctx, key, refreshFn, lazyRefreshInterval, newFetchOptions(opts), notify, nil)
+	if !registered {
+		return value, exists, nil, err
+	}
+	return value, exists, notify, err
+}
 
+// fetchLazy implements FetchWithLazyRefresh. When notify is not nil, it is registered to receive the outcome of the
+// background refresh pending for the key, if any; the third result reports whether it was registered. When info is
+// not nil, it receives the age of the value served and whether a background refresh is pending.
+func (ec *EchoCacheLazy[T]) fetchLazy(ctx context.Context, key string, refreshFn store.RefreshFunc[T], lazyRefreshInterval time.Duration, o fetchOptions, notify chan RefreshResult[T], info *FetchInfo) (T, bool, bool, error) {
+	var zeroValue T
+	if err := ctx.Err(); err != nil {
+		return zeroValue, false, false, err
+	}
+	if ec.graves.active(key) {
+		ec.counters.miss(key)
+		return zeroValue, false, false, nil
+	}
+
+	// Attempt to retrieve the resultValue from the values shared on the bus, then from the cache.
+	value, exists := sharedValue[store.StaleValue[T]](ec.opts.bus, ec.opts.busTopic, key)
+	var err error
+	if !exists {
+		value, exists, err = ec.store.Get(ctx, key)
+	}
+
+	requestId := newID(ec.opts.ids, requestIDLength)
+	rid := correlationID(ctx, requestId)
 	now := time.Now()
+	settings := ec.settings.Load()
 	if exists {
-		if value.CreatedAt.Add(lazyRefreshInterval).Before(now) {
-			slog.Info("Send task to queue")
-			select {
-
-			case ec.queue <- refreshTask[T]{
-				key:         key,
-				computeFunc: refreshFn,
-				requestId:   randString(10),
-			}:
-			default:
-				slog.Warn("processRefreshTask: queue is full, task dropped", slog.String("key", key))
-			}
+		scheduled, registered := false, false
+		stale := value.CreatedAt.Add(lazyRefreshInterval).Before(now)
+		if stale {
+			ec.counters.staleHit(key)
+		} else {
+			ec.counters.hit(key)
+		}
+		if stale && !settings.cooldown.blocked(key) {
+			scheduled, registered = ec.enqueueRefresh(refreshTask[T]{
+				key:           key,
+				computeFunc:   refreshFn,
+				requestId:     requestId,
+				timeout:       o.refreshTimeout,
+				correlationId: rid,
+				background:    true,
+				deadline:      settings.opts.queueDeadline(now, value.CreatedAt, lazyRefreshInterval),
+				observedAt:    value.CreatedAt,
+			}, notify)
 		}
-		return value.Value, true, nil
+		if info != nil {
+			*info = FetchInfo{Age: max(now.Sub(value.CreatedAt), 0), Stale: stale, RefreshScheduled: scheduled}
+		}
+		return value.Value, true, registered, nil
 	}
 	if err != nil {
 		// Log the error but proceed with computation.
-		slog.Warn("Cannot get resultValue from cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		slog.Warn("Cannot get resultValue from cache", slog.String("error", err.Error()), slog.String("cacheKey", key), slog.String("requestId", rid))
+	}
+	ec.counters.miss(key)
+	if ec.absent.absent(ctx, key) {
+		return zeroValue, false, false, ErrPermanentlyAbsent
+	}
+	if settings.cooldown.blocked(key) {
+		ec.counters.failure(key)
+		return zeroValue, false, false, ErrRefreshCooldown
+	}
+	if settings.budget.exceeded(ctx) {
+		ec.counters.failure(key)
+		return zeroValue, false, false, ErrBudgetExceeded
 	}
 
 	task := refreshTask[T]{
-		key:         key,
-		computeFunc: refreshFn,
-		requestId:   randString(10),
+		key:           key,
+		computeFunc:   refreshFn,
+		requestId:     requestId,
+		timeout:       o.refreshTimeout,
+		correlationId: rid,
+	}
+	result, computed, err := ec.processRefreshTask(task)
+	if err != nil {
+		ec.counters.failure(key)
+	}
+	if info != nil {
+		*info = FetchInfo{Computed: computed}
+	}
+	return result, computed, false, err
+
+}
+
+// enqueueRefresh schedules a background refresh unless one is already pending for the same key, in which case only its attempt count is increased.
+// It reports whether a refresh is pending for the key and, when notify is not nil, registers it to receive the outcome
+// of the pending refresh, reporting whether it was.
+func (ec *EchoCacheLazy[T]) enqueueRefresh(task refreshTask[T], notify chan RefreshResult[T]) (bool, bool) {
+	ec.pendingMu.Lock()
+	defer ec.pendingMu.Unlock()
+	if p, ok := ec.pending[task.key]; ok {
+		p.Attempts++
+		if notify != nil {
+			ec.waiters[p.taskID] = append(ec.waiters[p.taskID], notify)
+		}
+		return true, notify != nil
+	}
+	slog.Info("Send task to queue", slog.String("key", task.key), slog.String("requestId", task.correlationId))
+	if len(ec.queue) >= ec.settings.Load().opts.queueSize {
+		slog.Warn("processRefreshTask: queue limit reached, task dropped", slog.String("key", task.key), slog.String("requestId", task.correlationId))
+		return false, false
+	}
+	select {
+	case ec.queue <- task:
+		ec.pending[task.key] = &PendingTask{
+			Key:        task.key,
+			EnqueuedAt: time.Now(),
+			Deadline:   task.deadline,
+			RequestID:  task.correlationId,
+			Attempts:   1,
+			taskID:     task.requestId,
+		}
+		if notify != nil {
+			ec.waiters[task.requestId] = append(ec.waiters[task.requestId], notify)
+		}
+		return true, notify != nil
+	default:
+		slog.Warn("processRefreshTask: queue is full, task dropped", slog.String("key", task.key), slog.String("requestId", task.correlationId))
+		return false, false
+	}
+}
+
+// dequeuePending removes the task from the pending set. It returns false when the task was cancelled and must be skipped.
+func (ec *EchoCacheLazy[T]) dequeuePending(task refreshTask[T]) bool {
+	ec.pendingMu.Lock()
+	defer ec.pendingMu.Unlock()
+	p, ok := ec.pending[task.key]
+	if !ok || p.taskID != task.requestId {
+		return false
+	}
+	delete(ec.pending, task.key)
+	return true
+}
+
+// skipTask reports whether a dequeued task must not run, because it waited past its deadline or because the value
+// was refreshed by another path, e.g. a foreground computation or another node, since the task was scheduled.
+// In the latter case the fresh value is returned so that it can be delivered to the waiters.
+func (ec *EchoCacheLazy[T]) skipTask(task refreshTask[T]) (T, bool, error) {
+	var zeroValue T
+	if !task.deadline.IsZero() && time.Now().After(task.deadline) {
+		slog.Warn("Refresh task expired in queue, task dropped", slog.String("key", task.key), slog.String("requestId", task.correlationId))
+		return zeroValue, true, ErrRefreshExpired
+	}
+	if task.observedAt.IsZero() {
+		return zeroValue, false, nil
 	}
-	return ec.processRefreshTask(task, lazyRefreshInterval)
+	current, exists, err := ec.store.Get(ec.ctx, task.key)
+	if err != nil || !exists || !current.CreatedAt.After(task.observedAt) {
+		return zeroValue, false, nil
+	}
+	slog.Info("Value refreshed by another path, task dropped", slog.String("key", task.key), slog.String("requestId", task.correlationId))
+	return current.Value, true, nil
+}
+
+// InFlight returns the keys whose value is currently being computed, in the foreground or in the background, sorted.
+func (ec *EchoCacheLazy[T]) InFlight() []string {
+	return ec.inFlight.list()
+}
+
+// PendingRefreshes returns a snapshot of the background refreshes waiting in the queue, oldest first.
+func (ec *EchoCacheLazy[T]) PendingRefreshes() []PendingTask {
+	ec.pendingMu.Lock()
+	tasks := make([]PendingTask, 0, len(ec.pending))
+	for _, p := range ec.pending {
+		tasks = append(tasks, *p)
+	}
+	ec.pendingMu.Unlock()
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].EnqueuedAt.Before(tasks[j].EnqueuedAt)
+	})
+	return tasks
+}
+
+// Stats returns a snapshot of the fetch outcomes of this cache, the number of keys being computed, the number of
+// background refreshes waiting in the queue and the store size.
+func (ec *EchoCacheLazy[T]) Stats() Stats {
+	stats := ec.counters.snapshot(ec.store)
+	stats.InFlight = len(ec.inFlight.list())
+	ec.pendingMu.Lock()
+	stats.Pending = len(ec.pending)
+	ec.pendingMu.Unlock()
+	stats.QueueCapacity = cap(ec.queue)
+	return stats
+}
+
+// Clear removes every entry from the underlying store.
+// Returns store.ErrNotSupported if the store can be neither cleared nor enumerated.
+func (ec *EchoCacheLazy[T]) Clear(ctx context.Context) error {
+	return store.Clear(ctx, ec.store)
+}
+
+// ClearLocal removes the entries the underlying store holds in process memory, leaving shared backends untouched.
+func (ec *EchoCacheLazy[T]) ClearLocal(ctx context.Context) error {
+	return store.ClearLocal(ctx, ec.store)
+}
+
+// InvalidateLocal removes the key from the entries held in process memory, leaving shared backends untouched, and
+// writes a tombstone for it like Invalidate. It applies invalidations broadcast by other instances, see
+// ClearCoordinator.
+func (ec *EchoCacheLazy[T]) InvalidateLocal(ctx context.Context, key string) error {
+	ec.graves.bury(key)
+	ec.CancelPending(key)
+	ec.opts.bus.Publish(BusEvent{Topic: ec.opts.busTopic, Key: key, Kind: BusInvalidated})
+	return store.DeleteLocal(ctx, ec.store, key)
+}
 
+// Resize changes the capacity of the underlying in-memory store, returning the number of evicted entries.
+// Returns store.ErrNotSupported if the store cannot be resized.
+func (ec *EchoCacheLazy[T]) Resize(size int) (int, error) {
+	return store.Resize(ec.store, size)
 }
 
-// processRefreshTask handles the computation and caching of a value, respecting the provided refresh timeout settings.
+// CancelPending drops the queued background refresh for the given key, if any. A refresh already running is not interrupted.
+// Returns true when a pending task was cancelled.
+func (ec *EchoCacheLazy[T]) CancelPending(key string) bool {
+	ec.pendingMu.Lock()
+	defer ec.pendingMu.Unlock()
+	p, ok := ec.pending[key]
+	if !ok {
+		return false
+	}
+	delete(ec.pending, key)
+	ec.resolveWaiters(p.taskID, RefreshResult[T]{Err: ErrRefreshCancelled})
+	return true
+}
+
+// notifyWaiters delivers the outcome of a background refresh to the channels registered for it.
+func (ec *EchoCacheLazy[T]) notifyWaiters(requestId string, result RefreshResult[T]) {
+	ec.pendingMu.Lock()
+	defer ec.pendingMu.Unlock()
+	ec.resolveWaiters(requestId, result)
+}
+
+// cancelWaiters resolves every registered channel with ErrRefreshCancelled.
+func (ec *EchoCacheLazy[T]) cancelWaiters() {
+	ec.pendingMu.Lock()
+	defer ec.pendingMu.Unlock()
+	for requestId := range ec.waiters {
+		ec.resolveWaiters(requestId, RefreshResult[T]{Err: ErrRefreshCancelled})
+	}
+}
+
+// resolveWaiters sends the result to the channels registered for the request and closes them. Must be called with pendingMu held.
+func (ec *EchoCacheLazy[T]) resolveWaiters(requestId string, result RefreshResult[T]) {
+	for _, ch := range ec.waiters[requestId] {
+		ch <- result
+		close(ch)
+	}
+	delete(ec.waiters, requestId)
+}
+
+// processRefreshTask handles the computation and caching of a value, respecting the task timeout or, when unset, the cache refresh timeout.
 // It uses singleflight to ensure only one computation per key is performed and updates the cache if successful.
-func (ec *EchoCacheLazy[T]) processRefreshTask(task refreshTask[T], refreshTimeout time.Duration) (T, bool, error) {
+func (ec *EchoCacheLazy[T]) processRefreshTask(task refreshTask[T]) (T, bool, error) {
 	var zeroValue T
 
-	taskContext, cancel := context.WithTimeout(ec.ctx, ec.refreshTimeout)
+	settings := ec.settings.Load()
+	timeout := task.timeout
+	if timeout <= 0 {
+		timeout = settings.opts.refreshTimeout
+	}
+	taskContext, cancel := context.WithTimeout(ec.ctx, timeout)
 	defer cancel()
+	if task.correlationId != "" {
+		taskContext = ContextWithRequestID(taskContext, task.correlationId)
+	}
+	version := ec.versions.begin(task.key)
+	defer ec.versions.end(task.key)
 	sfResult, sfErr, _ := ec.sf.Do(task.key, func() (interface{}, error) {
+		ec.inFlight.start(task.key)
+		defer ec.inFlight.done(task.key)
+		start := time.Now()
 		res, err := task.computeFunc(taskContext)
+		if err == nil {
+			settings.budget.observe(time.Since(start))
+		}
+		settings.cooldown.record(taskContext, task.key, err)
+		recordAbsence(taskContext, ec.absent, ec.store, task.key, err)
+		if hook := settings.opts.refreshHook; hook != nil {
+			hook(RefreshEvent{Key: task.key, RequestID: task.correlationId, Background: task.background, Duration: time.Since(start), Err: err})
+		}
 		return singleFlightResult[T]{
 			resultValue: res,
 			createdAt:   time.Now(),
@@ -117,7 +472,7 @@ func (ec *EchoCacheLazy[T]) processRefreshTask(task refreshTask[T], refreshTimeo
 	})
 
 	if sfErr != nil {
-		slog.Error("processRefreshTask: failed to refresh resultValue", slog.String("key", task.key), slog.String("error", sfErr.Error()))
+		slog.Error("processRefreshTask: failed to refresh resultValue", slog.String("key", task.key), slog.String("error", sfErr.Error()), slog.String("requestId", task.correlationId))
 		return zeroValue, false, sfErr
 	}
 
@@ -127,17 +482,113 @@ func (ec *EchoCacheLazy[T]) processRefreshTask(task refreshTask[T], refreshTimeo
 		slog.Error("processRefreshTask: type assertion to singleFlightResult failed", slog.String("key", task.key))
 		return zeroValue, false, errors.New("type assertion failed for computed resultValue")
 	}
-	if task.requestId == resolvedValue.requestId {
+	if task.requestId == resolvedValue.requestId && !ec.graves.active(task.key) {
 
 		cachedItem := store.StaleValue[T]{
 			Value:     resolvedValue.resultValue,
 			CreatedAt: resolvedValue.createdAt,
+			Producer:  ec.opts.nodeID,
+		}
+		undo := func() {
+			if err := store.Delete(context.WithoutCancel(taskContext), ec.store, task.key); err != nil {
+				slog.Warn("Cannot delete superseded value", slog.String("error", err.Error()), slog.String("cacheKey", task.key))
+			}
+		}
+		kept, err := ec.graves.write(task.key, func() error {
+			return ec.store.Set(taskContext, task.key, cachedItem)
+		}, undo)
+		if kept && ec.versions.superseded(task.key, version) {
+			undo()
 		}
-		if err := ec.store.Set(taskContext, task.key, cachedItem); err != nil {
+		if err != nil {
 			// Log the error but still return the computed resultValue.
-			slog.Warn("Failed to store resultValue in cache", slog.String("key", task.key), slog.String("error", err.Error()))
+			slog.Warn("Failed to store resultValue in cache", slog.String("key", task.key), slog.String("error", err.Error()), slog.String("requestId", task.correlationId))
 		}
+		ec.opts.bus.publishIf(BusEvent{Topic: ec.opts.busTopic, Key: task.key, Kind: BusWarmed, Value: cachedItem}, func() bool {
+			return !ec.graves.active(task.key) && !ec.versions.superseded(task.key, version)
+		})
 	}
 	return resolvedValue.resultValue, true, nil
 
 }
+
+// Invalidate cancels the background refresh pending for the key and removes it from the underlying store. With
+// WithTombstones, it also leaves a tombstone on the key, and with WithEventBus the invalidation is shared with the
+// other caches of the topic. Returns store.ErrNotSupported if the store cannot delete entries.
+func (ec *EchoCacheLazy[T]) Invalidate(ctx context.Context, key string) error {
+	ec.graves.bury(key)
+	ec.CancelPending(key)
+	ec.opts.bus.Publish(BusEvent{Topic: ec.opts.busTopic, Key: key, Kind: BusInvalidated})
+	return store.Delete(ctx, ec.store, key)
+}
+
+// Inspect reports the metadata of the key in the underlying store, such as its age, TTL, revision, encoded size and
+// the node that last refreshed it, without modifying it.
+func (ec *EchoCacheLazy[T]) Inspect(ctx context.Context, key string) (store.KeyInfo, error) {
+	return store.Inspect[store.StaleValue[T]](ctx, ec.store, key)
+}
+
+// GetField copies the field at path, such as "address.city", of the cached value into dst without decoding the
+// whole value, when the store implements store.FieldGetter. It does not schedule refreshes. Returns false when the
+// key is missing or has an active tombstone, and store.ErrNotSupported if the store cannot project fields.
+func (ec *EchoCacheLazy[T]) GetField(ctx context.Context, key string, path string, dst any) (bool, error) {
+	if ec.graves.active(key) {
+		return false, nil
+	}
+	getter, ok := ec.store.(store.FieldGetter)
+	if !ok {
+		return false, store.ErrNotSupported
+	}
+	if path != "" {
+		path = "." + path
+	}
+	return getter.GetField(ctx, key, "Value"+path, dst)
+}
+
+// Dump writes the entries of the underlying store as newline-delimited JSON, including the age of each entry.
+// Returns store.ErrNotSupported if the store cannot enumerate its keys.
+func (ec *EchoCacheLazy[T]) Dump(ctx context.Context, w io.Writer, opts store.DumpOptions) error {
+	return store.Dump[store.StaleValue[T]](ctx, ec.store, w, opts)
+}
+
+// BulkSet loads precomputed values into the underlying store, stamping them with the current time as creation date.
+func (ec *EchoCacheLazy[T]) BulkSet(ctx context.Context, entries map[string]T) error {
+	now := time.Now()
+	staleEntries := make(map[string]store.StaleValue[T], len(entries))
+	for key, value := range entries {
+		staleEntries[key] = store.StaleValue[T]{Value: value, CreatedAt: now, Producer: ec.opts.nodeID}
+	}
+	return store.BulkSet[store.StaleValue[T]](ctx, ec.store, filterTombstoned(ec.graves, staleEntries))
+}
+
+// SetMulti stores related entries together, stamped with the current time, in a single transaction when atomic is
+// true and the store implements store.AtomicSetter, and with best-effort batching otherwise. Keys with an active
+// tombstone are skipped.
+func (ec *EchoCacheLazy[T]) SetMulti(ctx context.Context, entries map[string]T, atomic bool) error {
+	now := time.Now()
+	staleEntries := make(map[string]store.StaleValue[T], len(entries))
+	for key, value := range entries {
+		staleEntries[key] = store.StaleValue[T]{Value: value, CreatedAt: now, Producer: ec.opts.nodeID}
+	}
+	return store.SetMulti[store.StaleValue[T]](ctx, ec.store, filterTombstoned(ec.graves, staleEntries), atomic)
+}
+
+// FetchWithRefresh is FetchWithLazyRefresh using the refresh interval the cache was created with by
+// NewLazyEchoCacheForInterval. It returns an error if the cache has no refresh interval.
+func (ec *EchoCacheLazy[T]) FetchWithRefresh(ctx context.Context, key string, refreshFn store.RefreshFunc[T], opts ...FetchOption) (T, bool, error) {
+	interval := ec.settings.Load().opts.refreshInterval
+	if interval <= 0 {
+		var zeroValue T
+		return zeroValue, false, errors.New("no refresh interval configured: create the cache with NewLazyEchoCacheForInterval")
+	}
+	return ec.FetchWithLazyRefresh(ctx, key, refreshFn, interval, opts...)
+}
+
+// PopulateIfAbsent stores the value, stamped with the current time, only if the key is missing.
+// Returns true when the value was written, or store.ErrNotSupported if the store does not implement store.Populator.
+func (ec *EchoCacheLazy[T]) PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error) {
+	if ec.graves.active(key) {
+		return false, nil
+	}
+	return store.PopulateIfAbsent[store.StaleValue[T]](ctx, ec.store, key, store.StaleValue[T]{Value: value, CreatedAt: time.Now(), Producer: ec.opts.nodeID})
+}
@@ -0,0 +1,257 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCacheLazy_RefreshTimeoutOverride verifies that WithRefreshTimeout overrides the default refresh timeout for a single call.
+func TestEchoCacheLazy_RefreshTimeoutOverride(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Hour)
+	defer cache.ShutdownLazyRefresh()
+
+	deadlineWithin := func(max time.Duration) store.RefreshFunc[string] {
+		return func(ctx context.Context) (string, error) {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.LessOrEqual(t, time.Until(deadline), max)
+			return "value", nil
+		}
+	}
+
+	_, _, err := cache.FetchWithLazyRefresh(ctx, "short", deadlineWithin(100*time.Millisecond), time.Minute, WithRefreshTimeout(100*time.Millisecond))
+	assert.NoError(t, err)
+
+	_, _, err = cache.FetchWithLazyRefresh(ctx, "default", deadlineWithin(time.Hour), time.Minute)
+	assert.NoError(t, err)
+}
+
+// TestEchoCacheLazy_RefreshTimeoutExceeded verifies that a computation exceeding the per-call timeout is cancelled.
+func TestEchoCacheLazy_RefreshTimeoutExceeded(t *testing.T) {
+	cache := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Hour)
+	defer cache.ShutdownLazyRefresh()
+
+	_, exists, err := cache.FetchWithLazyRefresh(context.Background(), "k", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}, time.Minute, WithRefreshTimeout(10*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, exists)
+}
+
+// TestEchoCacheLazy_PendingRefreshes verifies that queued refreshes are deduplicated, listed and cancellable.
+func TestEchoCacheLazy_PendingRefreshes(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second)
+	defer cache.ShutdownLazyRefresh()
+
+	stale := store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-time.Hour)}
+	for _, key := range []string{"busy", "a", "b"} {
+		require.NoError(t, swr.Set(ctx, key, stale))
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	_, _, _ = cache.FetchWithLazyRefresh(ctx, "busy", func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		return "fresh", nil
+	}, time.Second)
+	<-started
+
+	refreshed := make(chan string, 2)
+	refreshFn := func(key string) store.RefreshFunc[string] {
+		return func(ctx context.Context) (string, error) {
+			refreshed <- key
+			return "fresh", nil
+		}
+	}
+	_, _, _ = cache.FetchWithLazyRefresh(ctx, "a", refreshFn("a"), time.Second)
+	_, _, _ = cache.FetchWithLazyRefresh(ctx, "b", refreshFn("b"), time.Second)
+	_, _, _ = cache.FetchWithLazyRefresh(ctx, "a", refreshFn("a"), time.Second)
+
+	pending := cache.PendingRefreshes()
+	require.Len(t, pending, 2)
+	assert.Equal(t, "a", pending[0].Key)
+	assert.Equal(t, 2, pending[0].Attempts)
+	assert.Equal(t, "b", pending[1].Key)
+	assert.Equal(t, 1, pending[1].Attempts)
+
+	assert.True(t, cache.CancelPending("a"))
+	assert.False(t, cache.CancelPending("a"))
+	close(release)
+
+	select {
+	case key := <-refreshed:
+		assert.Equal(t, "b", key)
+	case <-time.After(time.Second):
+		t.Fatal("pending refresh was not processed")
+	}
+	assert.Empty(t, cache.PendingRefreshes())
+	assert.Empty(t, refreshed)
+}
+
+// TestEchoCacheLazy_CancelledContext verifies that a cancelled context neither computes nor schedules a refresh.
+func TestEchoCacheLazy_CancelledContext(t *testing.T) {
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second)
+	defer cache.ShutdownLazyRefresh()
+	require.NoError(t, swr.Set(context.Background(), "k", store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-time.Hour)}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, key := range []string{"k", "missing"} {
+		_, exists, err := cache.FetchWithLazyRefresh(ctx, key, func(ctx context.Context) (string, error) {
+			t.Error("refresh must not run with a cancelled context")
+			return "", nil
+		}, time.Second)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, exists)
+	}
+	assert.Empty(t, cache.PendingRefreshes())
+}
+
+// TestEchoCacheLazy_FetchWithLazyRefreshNotify verifies that callers served stale data are notified of the refreshed value.
+func TestEchoCacheLazy_FetchWithLazyRefreshNotify(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second)
+	defer cache.ShutdownLazyRefresh()
+
+	refreshFn := func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	}
+	value, exists, notify, err := cache.FetchWithLazyRefreshNotify(ctx, "k", refreshFn, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "fresh", value)
+	assert.Nil(t, notify, "foreground computations have nothing to notify")
+
+	require.NoError(t, swr.Set(ctx, "k", store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+	value, _, notify, err = cache.FetchWithLazyRefreshNotify(ctx, "k", refreshFn, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "stale", value)
+	require.NotNil(t, notify)
+
+	select {
+	case result := <-notify:
+		assert.NoError(t, result.Err)
+		assert.Equal(t, "fresh", result.Value)
+	case <-time.After(time.Second):
+		t.Fatal("refresh notification not delivered")
+	}
+	_, open := <-notify
+	assert.False(t, open)
+}
+
+// TestEchoCacheLazy_NotifyCancelled verifies that cancelling a pending refresh resolves its notifications.
+func TestEchoCacheLazy_NotifyCancelled(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second)
+	defer cache.ShutdownLazyRefresh()
+	stale := store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-time.Hour)}
+	require.NoError(t, swr.Set(ctx, "busy", stale))
+	require.NoError(t, swr.Set(ctx, "k", stale))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	_, _, _ = cache.FetchWithLazyRefresh(ctx, "busy", func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		return "fresh", nil
+	}, time.Second)
+	<-started
+
+	_, _, notify, err := cache.FetchWithLazyRefreshNotify(ctx, "k", func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	}, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, notify)
+	require.True(t, cache.CancelPending("k"))
+
+	result := <-notify
+	assert.ErrorIs(t, result.Err, ErrRefreshCancelled)
+}
+
+// TestEchoCacheLazy_StalenessDeadline verifies that staler values get shorter queue deadlines, that expired tasks are
+// dropped and that tasks whose value was refreshed by another path are skipped.
+func TestEchoCacheLazy_StalenessDeadline(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second, WithStalenessDeadline(time.Hour, 20*time.Millisecond))
+	defer cache.ShutdownLazyRefresh()
+	now := time.Now()
+	require.NoError(t, swr.Set(ctx, "busy", store.StaleValue[string]{Value: "stale", CreatedAt: now.Add(-2 * time.Minute)}))
+	require.NoError(t, swr.Set(ctx, "slightly", store.StaleValue[string]{Value: "stale", CreatedAt: now.Add(-2 * time.Minute)}))
+	require.NoError(t, swr.Set(ctx, "very", store.StaleValue[string]{Value: "stale", CreatedAt: now.Add(-100000 * time.Hour)}))
+	require.NoError(t, swr.Set(ctx, "replaced", store.StaleValue[string]{Value: "stale", CreatedAt: now.Add(-2 * time.Minute)}))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	_, _, _ = cache.FetchWithLazyRefresh(ctx, "busy", func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		return "fresh", nil
+	}, time.Minute)
+	<-started
+
+	refreshFn := func(ctx context.Context) (string, error) {
+		return "refreshed", nil
+	}
+	_, _, slightly, _ := cache.FetchWithLazyRefreshNotify(ctx, "slightly", refreshFn, time.Minute)
+	_, _, very, _ := cache.FetchWithLazyRefreshNotify(ctx, "very", refreshFn, time.Minute)
+	_, _, replaced, _ := cache.FetchWithLazyRefreshNotify(ctx, "replaced", refreshFn, time.Minute)
+
+	pending := cache.PendingRefreshes()
+	require.Len(t, pending, 3)
+	assert.Greater(t, pending[0].Deadline.Sub(pending[0].EnqueuedAt), 20*time.Minute)
+	assert.Less(t, pending[1].Deadline.Sub(pending[1].EnqueuedAt), time.Second)
+
+	require.NoError(t, swr.Set(ctx, "replaced", store.StaleValue[string]{Value: "other path", CreatedAt: time.Now()}))
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, "refreshed", (<-slightly).Value)
+	v := <-very
+	assert.ErrorIs(t, v.Err, ErrRefreshExpired)
+	result := <-replaced
+	assert.NoError(t, result.Err)
+	assert.Equal(t, "other path", result.Value)
+}
+
+// TestEchoCache_GetField verifies that both caches project fields of their values and report unsupported stores.
+func TestEchoCache_GetField(t *testing.T) {
+	ctx := context.Background()
+	type profile struct {
+		Name string `json:"name"`
+	}
+	ec := NewEchoCache[profile](store.NewLRUCache[profile](10))
+	_, _, err := ec.FetchWithCache(ctx, "user", func(ctx context.Context) (profile, error) { return profile{Name: "ada"}, nil })
+	require.NoError(t, err)
+	var name string
+	found, err := ec.GetField(ctx, "user", "name", &name)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "ada", name)
+
+	lazy := NewLazyEchoCache[profile](store.NewStaleWhileRevalidateLRUCache[profile](10), time.Second)
+	defer lazy.ShutdownLazyRefresh()
+	_, _, err = lazy.FetchWithLazyRefresh(ctx, "user", func(ctx context.Context) (profile, error) { return profile{Name: "bob"}, nil }, time.Minute)
+	require.NoError(t, err)
+	found, err = lazy.GetField(ctx, "user", "name", &name)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "bob", name)
+
+	_, err = NewEchoCache[profile](&mockCacher[profile]{cache: map[string]profile{}}).GetField(ctx, "user", "name", &name)
+	assert.ErrorIs(t, err, store.ErrNotSupported)
+}
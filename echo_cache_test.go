@@ -5,8 +5,11 @@ import (
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/logocomune/echocache/store"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // mockCacher is a generic struct that implements basic caching functionality for testing purposes.
@@ -125,4 +128,71 @@ func TestEchoCache_Memoize(t *testing.T) {
 		assert.Equal(t, "refreshed resultValue", value)
 	})
 
+	t.Run("cancelled_context", func(t *testing.T) {
+		mc := &mockCacher[string]{
+			cache: map[string]string{"test": "cached resultValue"},
+		}
+		cache := NewEchoCache[string](mc)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, exists, err := cache.FetchWithCache(cancelled, "test", func(ctx context.Context) (string, error) {
+			t.Error("refresh must not run with a cancelled context")
+			return "", nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, exists)
+	})
+
+}
+
+// TestEchoCache_FetchWithCacheTTL verifies that per-call TTLs reach stores supporting per-entry expiry and that
+// other stores keep their own lifetime.
+func TestEchoCache_FetchWithCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	wheel := store.NewTimingWheelCache[string](time.Hour, store.TimingWheelConfig[string]{})
+	defer wheel.Close()
+	ec := NewEchoCache[string](wheel)
+	refresh := func(ctx context.Context) (string, error) { return "value", nil }
+
+	_, _, err := ec.FetchWithCacheTTL(ctx, "short", refresh, time.Minute)
+	require.NoError(t, err)
+	_, _, err = ec.FetchWithCache(ctx, "default", refresh)
+	require.NoError(t, err)
+
+	short, _, err := wheel.TTL(ctx, "short")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, short, time.Minute)
+	long, _, err := wheel.TTL(ctx, "default")
+	require.NoError(t, err)
+	assert.Greater(t, long, time.Minute)
+
+	mc := &mockCacher[string]{cache: map[string]string{}}
+	value, found, err := NewEchoCache[string](mc).FetchWithCacheTTL(ctx, "key", refresh, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, "value", mc.cache["key"])
+}
+
+// TestEchoCache_InvalidateInstrumented verifies that Invalidate deletes the key through an instrumented store, on both
+// the eager and the lazy cache.
+func TestEchoCache_InvalidateInstrumented(t *testing.T) {
+	ctx := context.Background()
+	sink := store.NewMemoryMetrics()
+
+	lru := store.NewLRUCache[string](10)
+	ec := NewEchoCache[string](store.NewInstrumentedCache[string](lru, "lru", sink))
+	require.NoError(t, lru.Set(ctx, "k", "v"))
+	require.NoError(t, ec.Invalidate(ctx, "k"))
+	_, exists, _ := lru.Get(ctx, "k")
+	assert.False(t, exists)
+
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	lazy := NewLazyEchoCache[string](store.InstrumentStaleWhileRevalidate[string](swr, "lru", sink), time.Minute)
+	defer lazy.ShutdownLazyRefresh()
+	require.NoError(t, swr.Set(ctx, "k", store.StaleValue[string]{Value: "v", CreatedAt: time.Now()}))
+	require.NoError(t, lazy.Invalidate(ctx, "k"))
+	_, exists, _ = swr.Get(ctx, "k")
+	assert.False(t, exists)
 }
@@ -0,0 +1,44 @@
+package echocache
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// FetchInfo describes how FetchWithLazyRefreshInfo served a value.
+type FetchInfo struct {
+	// Found reports whether a value was served, found in the cache or computed, as the boolean of FetchWithLazyRefresh.
+	// It is false for keys with an active tombstone, see WithTombstones, whose zero value is returned without error.
+	Found bool
+	// Age is the time elapsed since the value served was computed; zero for a value computed by the call.
+	Age time.Duration
+	// Stale reports whether the value served is older than the lazy refresh interval.
+	Stale bool
+	// RefreshScheduled reports whether a background refresh of the key is pending, scheduled by this call or an earlier one.
+	RefreshScheduled bool
+	// Computed reports whether the value was computed in the foreground by this call because the key was missing.
+	Computed bool
+}
+
+// SetHeaders sets the HTTP Age header to the age of the value in seconds and, for a stale value, the
+// "Warning: 110 - \"Response is Stale\"" header.
+func (i FetchInfo) SetHeaders(h http.Header) {
+	h.Set("Age", strconv.FormatInt(int64(i.Age/time.Second), 10))
+	if i.Stale {
+		h.Set("Warning", `110 - "Response is Stale"`)
+	}
+}
+
+// FetchWithLazyRefreshInfo behaves like FetchWithLazyRefresh and additionally describes the value served: its age,
+// whether it is stale and whether a background refresh is pending, so HTTP handlers can emit accurate Age and
+// Warning headers.
+func (ec *EchoCacheLazy[T]) FetchWithLazyRefreshInfo(ctx context.Context, key string, refreshFn store.RefreshFunc[T], lazyRefreshInterval time.Duration, opts ...FetchOption) (T, FetchInfo, error) {
+	var info FetchInfo
+	value, found, _, err := ec.fetchLazy(ctx, key, refreshFn, lazyRefreshInterval, newFetchOptions(opts), nil, &info)
+	info.Found = found
+	return value, info, err
+}
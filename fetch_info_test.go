@@ -0,0 +1,54 @@
+package echocache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCacheLazy_FetchWithLazyRefreshInfo verifies the age, staleness and refresh reported for computed, fresh and stale values.
+func TestEchoCacheLazy_FetchWithLazyRefreshInfo(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewStaleWhileRevalidateLRUCache[string](10)
+	ec := NewLazyEchoCache[string](s, time.Second, WithTombstones(time.Minute))
+	defer ec.ShutdownLazyRefresh()
+	refresh := func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	}
+
+	value, info, err := ec.FetchWithLazyRefreshInfo(ctx, "computed", refresh, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", value)
+	assert.Equal(t, FetchInfo{Found: true, Computed: true}, info)
+
+	require.NoError(t, s.Set(ctx, "old", store.StaleValue[string]{Value: "old", CreatedAt: time.Now().Add(-90 * time.Second)}))
+	value, info, err = ec.FetchWithLazyRefreshInfo(ctx, "old", refresh, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "old", value)
+	assert.True(t, info.Found)
+	assert.True(t, info.Stale)
+	assert.True(t, info.RefreshScheduled)
+	assert.GreaterOrEqual(t, info.Age, 90*time.Second)
+
+	h := http.Header{}
+	info.SetHeaders(h)
+	assert.Equal(t, "90", h.Get("Age"))
+	assert.Equal(t, `110 - "Response is Stale"`, h.Get("Warning"))
+
+	_, info, err = ec.FetchWithLazyRefreshInfo(ctx, "computed", refresh, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, info.Stale)
+	assert.False(t, info.RefreshScheduled)
+	assert.Less(t, info.Age, time.Minute)
+
+	require.NoError(t, ec.Invalidate(ctx, "computed"))
+	value, info, err = ec.FetchWithLazyRefreshInfo(ctx, "computed", refresh, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, value)
+	assert.False(t, info.Found)
+}
@@ -0,0 +1,35 @@
+package echocache
+
+import (
+	"context"
+	"fmt"
+	"github.com/logocomune/echocache/store"
+	"reflect"
+)
+
+// defaultGroup is the package-level singleflight group shared by every GetOrCompute call.
+var defaultGroup = newShardedGroup(defaultSingleflightShards)
+
+// GetOrCompute is a minimal one-function facade over a Cacher: it returns the cached value for key or computes it with fn,
+// storing the result for future calls. Concurrent calls for the same cacher and key share a single computation
+// through a package-level singleflight group, so no EchoCache needs to be constructed for quick use cases.
+func GetOrCompute[T any](ctx context.Context, cacher store.Cacher[T], key string, fn store.RefreshFunc[T]) (T, bool, error) {
+	ec := &EchoCache[T]{
+		store:    cacher,
+		sf:       defaultGroup,
+		sfPrefix: cacherID(cacher) + "|",
+	}
+	ec.settings.Store(newOptions(nil).tunables(nil))
+	return ec.FetchWithCache(ctx, key, fn)
+}
+
+// cacherID returns a string identifying the cacher instance, used to namespace singleflight keys.
+func cacherID(c any) string {
+	v := reflect.ValueOf(c)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Slice:
+		return fmt.Sprintf("%T@%x", c, v.Pointer())
+	default:
+		return fmt.Sprintf("%T@%v", c, c)
+	}
+}
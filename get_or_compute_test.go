@@ -0,0 +1,56 @@
+package echocache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetOrCompute verifies that values are computed once, cached and shared between concurrent callers.
+func TestGetOrCompute(t *testing.T) {
+	ctx := context.Background()
+	cacher := store.NewLRUCache[string](10)
+	var calls atomic.Int32
+	fn := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return "computed", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, exists, err := GetOrCompute(ctx, cacher, "key", fn)
+			assert.NoError(t, err)
+			assert.True(t, exists)
+			assert.Equal(t, "computed", value)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	value, _, _ := GetOrCompute(ctx, cacher, "key", fn)
+	assert.Equal(t, "computed", value)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// TestGetOrCompute_DistinctCachers verifies that the same key on different cachers is computed independently.
+func TestGetOrCompute_DistinctCachers(t *testing.T) {
+	ctx := context.Background()
+	first := store.NewLRUCache[int](10)
+	second := store.NewLRUCache[int](10)
+
+	v1, _, _ := GetOrCompute(ctx, first, "key", func(ctx context.Context) (int, error) { return 1, nil })
+	v2, _, _ := GetOrCompute(ctx, second, "key", func(ctx context.Context) (int, error) { return 2, nil })
+
+	assert.Equal(t, 1, v1)
+	assert.Equal(t, 2, v2)
+	assert.NotEqual(t, cacherID(first), cacherID(second))
+}
@@ -0,0 +1,379 @@
+// Package httpcache provides an http.RoundTripper memoizing GET responses in an echocache store, so that any
+// http.Client can share a response cache with per-host freshness rules and revalidation of stale responses.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// Values of the XCache header added to the responses returned by Transport.
+const (
+	XCache          = "X-Cache"
+	CacheHit        = "HIT"
+	CacheMiss       = "MISS"
+	CacheRevalidate = "REVALIDATED"
+)
+
+// DefaultMaxBodySize is the size of the largest response body stored when WithMaxBodySize is not used.
+const DefaultMaxBodySize = 1 << 20
+
+// varyKeyHeader is the header recording, in stored responses, the digest of the request headers named by Vary.
+const varyKeyHeader = "X-Echocache-Vary-Key"
+
+// errInvalidEntry is returned when a stored entry cannot be decoded.
+var errInvalidEntry = errors.New("httpcache: invalid cache entry")
+
+// Hint overrides the caching of the requests carrying it in their context. TTL, when positive, replaces the
+// freshness lifetime given by the host rules; NoCache sends the request upstream without reading nor storing
+// the cached response.
+type Hint struct {
+	TTL     time.Duration
+	NoCache bool
+}
+
+// hintKey is the context key of the Hint.
+type hintKey struct{}
+
+// ContextWithHint returns a copy of ctx carrying the hint, to be attached to a request with http.Request.WithContext.
+func ContextWithHint(ctx context.Context, hint Hint) context.Context {
+	return context.WithValue(ctx, hintKey{}, hint)
+}
+
+// hintFromContext returns the hint carried by ctx, if any.
+func hintFromContext(ctx context.Context) Hint {
+	hint, _ := ctx.Value(hintKey{}).(Hint)
+	return hint
+}
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithBase sets the transport used to send requests upstream, http.DefaultTransport by default.
+func WithBase(base http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.base = base
+	}
+}
+
+// WithDefaultTTL sets how long responses of hosts without a specific rule are served without contacting them.
+// Without it, only the hosts configured with WithHostTTL are cached.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(t *Transport) {
+		t.defaultTTL = ttl
+	}
+}
+
+// WithHostTTL sets how long responses of the host, as found in the request URL including any port, are served
+// without contacting it. A non-positive ttl disables caching for the host.
+func WithHostTTL(host string, ttl time.Duration) Option {
+	return func(t *Transport) {
+		t.hostTTL[host] = ttl
+	}
+}
+
+// WithMaxBodySize sets the size of the largest response body stored, DefaultMaxBodySize by default. Larger responses
+// are passed through without being buffered.
+func WithMaxBodySize(size int64) Option {
+	return func(t *Transport) {
+		t.maxBodySize = size
+	}
+}
+
+// Transport is an http.RoundTripper memoizing successful GET responses. Fresh responses are served from the cache;
+// a response stays fresh for the lifetime given by the host rules or the Hint, shortened to the s-maxage or max-age
+// of its Cache-Control header when lower, and to zero by no-cache. Once they are stale, responses carrying an ETag or Last-Modified validator are revalidated with a conditional
+// request and served again on 304 Not Modified. Responses marked no-store or private, setting cookies, varying on
+// every header or larger than the maximum body size, and requests with a Range, Authorization or Cookie header, are
+// never cached. A response varying on some headers is only served to requests with the same values of these
+// headers as the request it was stored for. Responses are stored in full, so the store TTL should exceed the
+// freshness lifetime to leave room for revalidation.
+type Transport struct {
+	cache       store.Cacher[[]byte]
+	base        http.RoundTripper
+	defaultTTL  time.Duration
+	hostTTL     map[string]time.Duration
+	maxBodySize int64
+}
+
+// NewTransport creates a caching transport storing responses in cache.
+func NewTransport(cache store.Cacher[[]byte], opts ...Option) *Transport {
+	t := &Transport{cache: cache, base: http.DefaultTransport, hostTTL: make(map[string]time.Duration), maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Client returns an http.Client using the transport.
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// RoundTrip serves the request from the cache when possible and sends it upstream otherwise.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ttl, cacheable := t.ttl(req)
+	if !cacheable {
+		return t.base.RoundTrip(req)
+	}
+	ctx := req.Context()
+	key := req.Method + " " + req.URL.String()
+
+	data, exists, err := t.cache.Get(ctx, key)
+	if err != nil {
+		slog.Warn("Cannot get cached response", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+	if !exists {
+		return t.fetch(req, key, nil)
+	}
+	storedAt, cached, err := decodeEntry(data, req)
+	if err != nil {
+		slog.Warn("Cannot decode cached response", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		return t.fetch(req, key, nil)
+	}
+	if cached.Header.Get(varyKeyHeader) != varyKey(cached.Header, req) {
+		// Stored for a request with other values of the headers named by Vary.
+		_ = cached.Body.Close()
+		return t.fetch(req, key, nil)
+	}
+	cached.Header.Del(varyKeyHeader)
+	if lifetime, ok := maxAge(cached.Header); ok && lifetime < ttl {
+		ttl = lifetime
+	}
+	age := time.Since(storedAt)
+	if age < ttl {
+		cached.Header.Set("Age", strconv.Itoa(int(age.Seconds())))
+		cached.Header.Set(XCache, CacheHit)
+		return cached, nil
+	}
+	return t.fetch(req, key, cached)
+}
+
+// ttl returns the freshness lifetime of the response to the request and whether it may be cached at all.
+func (t *Transport) ttl(req *http.Request) (time.Duration, bool) {
+	for _, name := range []string{"Range", "Authorization", "Cookie"} {
+		if req.Header.Get(name) != "" {
+			return 0, false
+		}
+	}
+	if req.Method != http.MethodGet {
+		return 0, false
+	}
+	hint := hintFromContext(req.Context())
+	if hint.NoCache {
+		return 0, false
+	}
+	if hint.TTL > 0 {
+		return hint.TTL, true
+	}
+	ttl, ok := t.hostTTL[req.URL.Host]
+	if !ok {
+		ttl = t.defaultTTL
+	}
+	return ttl, ttl > 0
+}
+
+// fetch sends the request upstream, conditionally when a stale response with validators is available, and stores
+// the response when it can be cached.
+func (t *Transport) fetch(req *http.Request, key string, stale *http.Response) (*http.Response, error) {
+	outgoing := req
+	if stale != nil {
+		etag, modified := stale.Header.Get("ETag"), stale.Header.Get("Last-Modified")
+		if etag != "" || modified != "" {
+			outgoing = req.Clone(req.Context())
+			if etag != "" {
+				outgoing.Header.Set("If-None-Match", etag)
+			}
+			if modified != "" {
+				outgoing.Header.Set("If-Modified-Since", modified)
+			}
+		}
+	}
+	resp, err := t.base.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	if stale != nil && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		for name, values := range resp.Header {
+			stale.Header[name] = values
+		}
+		if storable(stale.Header) {
+			t.store(req, key, stale)
+		}
+		stale.Header.Set(XCache, CacheRevalidate)
+		return stale, nil
+	}
+	if resp.StatusCode != http.StatusOK || !storable(resp.Header) {
+		return resp, nil
+	}
+	if t.store(req, key, resp) {
+		resp.Header.Set(XCache, CacheMiss)
+	}
+	return resp, nil
+}
+
+// store writes the response to the request, whose body is buffered and replaced so it can still be read by the
+// caller, and reports whether the response could be cached. Bodies larger than the maximum size are not stored.
+func (t *Transport) store(req *http.Request, key string, resp *http.Response) bool {
+	if resp.ContentLength > t.maxBodySize {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBodySize+1))
+	if err != nil {
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		return false
+	}
+	if int64(len(body)) > t.maxBodySize {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return false
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	header := resp.Header.Clone()
+	header.Del(XCache)
+	header.Del("Age")
+	header.Del(varyKeyHeader)
+	if key := varyKey(header, req); key != "" {
+		header.Set(varyKeyHeader, key)
+	}
+	stored := &http.Response{
+		Status:        resp.Status,
+		StatusCode:    resp.StatusCode,
+		Proto:         resp.Proto,
+		ProtoMajor:    resp.ProtoMajor,
+		ProtoMinor:    resp.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+	raw, err := httputil.DumpResponse(stored, true)
+	if err != nil {
+		slog.Warn("Cannot serialize response", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		return true
+	}
+	entry := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(raw)), uint64(time.Now().UnixNano()))
+	if err := t.cache.Set(req.Context(), key, append(entry, raw...)); err != nil {
+		slog.Warn("Cannot store response", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+	return true
+}
+
+// decodeEntry parses a stored entry: the time it was stored followed by the serialized response.
+func decodeEntry(data []byte, req *http.Request) (time.Time, *http.Response, error) {
+	if len(data) < 8 {
+		return time.Time{}, nil, errInvalidEntry
+	}
+	storedAt := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data[8:])), req)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return storedAt, resp, nil
+}
+
+// storable reports whether a shared cache may store the response: its Cache-Control header must allow it, and it
+// must neither set cookies, which belong to a single user, nor vary on every request header.
+func storable(header http.Header) bool {
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	for _, directive := range strings.Split(strings.ToLower(header.Get("Cache-Control")), ",") {
+		switch strings.TrimSpace(directive) {
+		case "no-store", "private":
+			return false
+		}
+	}
+	for _, name := range varyNames(header) {
+		if name == "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// maxAge returns the freshness lifetime the Cache-Control header of the response allows a shared cache, s-maxage
+// taking precedence over max-age, and whether it sets one. A response marked no-cache must always be revalidated.
+func maxAge(header http.Header) (time.Duration, bool) {
+	maxAge, sharedMaxAge, noCache := -1, -1, false
+	for _, directive := range strings.Split(strings.ToLower(header.Get("Cache-Control")), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		switch {
+		case name == "no-cache":
+			noCache = true
+		case name == "s-maxage" && err == nil && seconds >= 0:
+			sharedMaxAge = seconds
+		case name == "max-age" && err == nil && seconds >= 0:
+			maxAge = seconds
+		}
+	}
+	switch {
+	case noCache:
+		return 0, true
+	case sharedMaxAge >= 0:
+		return time.Duration(sharedMaxAge) * time.Second, true
+	case maxAge >= 0:
+		return time.Duration(maxAge) * time.Second, true
+	}
+	return 0, false
+}
+
+// varyNames returns the request header names listed by the Vary header of the response.
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// varyKey returns a digest of the values the request has for the headers named by the Vary header of the response,
+// or "" when the response does not vary.
+func varyKey(header http.Header, req *http.Request) string {
+	names := varyNames(header)
+	if len(names) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, name := range names {
+		_, _ = io.WriteString(h, name+":"+strings.Join(req.Header.Values(name), ",")+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readCloser reads from a reader and closes a closer, such as a body whose beginning was already read.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader returns its error once the buffered part of a body has been read.
+type errReader struct {
+	err error
+}
+
+// Read returns the error of the reader.
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,193 @@
+package httpcache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// get performs a GET request with the client and returns the body and the X-Cache header.
+func get(t *testing.T, client *http.Client, ctx context.Context, target string) (string, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body), resp.Header.Get(XCache)
+}
+
+// TestTransport verifies that fresh responses are served from the cache, stale ones are revalidated with their
+// ETag and hints and host rules control what is cached.
+func TestTransport(t *testing.T) {
+	ctx := context.Background()
+	var requests, conditional atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "private")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = io.WriteString(w, "hello "+r.URL.Path)
+	}))
+	defer server.Close()
+	host := mustHost(t, server.URL)
+	client := NewTransport(store.NewLRUCache[[]byte](10), WithHostTTL(host, 50*time.Millisecond)).Client()
+
+	body, status := get(t, client, ctx, server.URL+"/a")
+	assert.Equal(t, "hello /a", body)
+	assert.Equal(t, CacheMiss, status)
+	body, status = get(t, client, ctx, server.URL+"/a")
+	assert.Equal(t, "hello /a", body)
+	assert.Equal(t, CacheHit, status)
+	assert.Equal(t, int32(1), requests.Load())
+
+	time.Sleep(60 * time.Millisecond)
+	body, status = get(t, client, ctx, server.URL+"/a")
+	assert.Equal(t, "hello /a", body)
+	assert.Equal(t, CacheRevalidate, status)
+	assert.Equal(t, int32(1), conditional.Load())
+	_, status = get(t, client, ctx, server.URL+"/a")
+	assert.Equal(t, CacheHit, status)
+
+	get(t, client, ContextWithHint(ctx, Hint{NoCache: true}), server.URL+"/a")
+	get(t, client, ctx, server.URL+"/private")
+	_, status = get(t, client, ctx, server.URL+"/private")
+	assert.Empty(t, status)
+	assert.Equal(t, int32(5), requests.Load())
+
+	other := NewTransport(store.NewLRUCache[[]byte](10)).Client()
+	_, status = get(t, other, ctx, server.URL+"/b")
+	assert.Empty(t, status, "hosts without rule are not cached")
+	_, status = get(t, other, ContextWithHint(ctx, Hint{TTL: time.Minute}), server.URL+"/b")
+	assert.Equal(t, CacheMiss, status)
+	_, status = get(t, other, ContextWithHint(ctx, Hint{TTL: time.Minute}), server.URL+"/b")
+	assert.Equal(t, CacheHit, status)
+}
+
+// TestTransport_Privacy verifies that responses tied to a user or to request headers are not served to other
+// requests, and that large bodies are passed through without being stored.
+func TestTransport_Privacy(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/session":
+			w.Header().Set("Set-Cookie", "session=secret")
+		case "/lang":
+			w.Header().Set("Vary", "Accept-Language")
+		case "/star":
+			w.Header().Set("Vary", "*")
+		case "/big":
+			_, _ = io.WriteString(w, "0123456789")
+			return
+		}
+		_, _ = io.WriteString(w, r.URL.Path+" "+r.Header.Get("Accept-Language")+" "+r.Header.Get("Cookie"))
+	}))
+	defer server.Close()
+	client := NewTransport(store.NewLRUCache[[]byte](10), WithDefaultTTL(time.Minute), WithMaxBodySize(9)).Client()
+
+	getWith := func(path string, header string, value string) (string, string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set(header, value)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body), resp.Header.Get(XCache)
+	}
+
+	get(t, client, ctx, server.URL+"/session")
+	_, status := get(t, client, ctx, server.URL+"/session")
+	assert.Empty(t, status, "responses setting cookies are not stored")
+
+	getWith("/c", "Cookie", "user=alice")
+	body, status := getWith("/c", "Cookie", "user=bob")
+	assert.Equal(t, "/c  user=bob", body, "requests with cookies are not cached")
+	assert.Empty(t, status)
+
+	getWith("/lang", "Accept-Language", "it")
+	body, status = getWith("/lang", "Accept-Language", "it")
+	assert.Equal(t, CacheHit, status)
+	assert.Equal(t, "/lang it ", body)
+	body, status = getWith("/lang", "Accept-Language", "en")
+	assert.Equal(t, CacheMiss, status)
+	assert.Equal(t, "/lang en ", body)
+
+	get(t, client, ctx, server.URL+"/star")
+	_, status = get(t, client, ctx, server.URL+"/star")
+	assert.Empty(t, status, "responses varying on every header are not stored")
+
+	body, status = get(t, client, ctx, server.URL+"/big")
+	assert.Equal(t, "0123456789", body)
+	assert.Empty(t, status)
+	body, status = get(t, client, ctx, server.URL+"/big")
+	assert.Equal(t, "0123456789", body)
+	assert.Empty(t, status, "bodies larger than the maximum size are not stored")
+}
+
+// TestTransport_MaxAge verifies that the max-age and s-maxage of responses shorten the lifetime given by the host
+// rules without extending it, and that no-cache responses are revalidated on every request.
+func TestTransport_MaxAge(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/short":
+			w.Header().Set("Cache-Control", "public, max-age=0")
+		case "/long":
+			w.Header().Set("Cache-Control", "max-age=3600")
+		case "/shared":
+			w.Header().Set("Cache-Control", "max-age=3600, s-maxage=0")
+		case "/no-cache":
+			w.Header().Set("Cache-Control", "no-cache, max-age=3600")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer server.Close()
+	client := NewTransport(store.NewLRUCache[[]byte](10), WithHostTTL(mustHost(t, server.URL), 50*time.Millisecond)).Client()
+
+	for _, path := range []string{"/short", "/shared", "/no-cache"} {
+		_, status := get(t, client, ctx, server.URL+path)
+		assert.Equal(t, CacheMiss, status, path)
+		body, status := get(t, client, ctx, server.URL+path)
+		assert.Equal(t, path, body)
+		assert.Equal(t, CacheRevalidate, status, path)
+	}
+
+	get(t, client, ctx, server.URL+"/long")
+	_, status := get(t, client, ctx, server.URL+"/long")
+	assert.Equal(t, CacheHit, status)
+	time.Sleep(60 * time.Millisecond)
+	_, status = get(t, client, ctx, server.URL+"/long")
+	assert.Equal(t, CacheRevalidate, status, "max-age cannot extend the lifetime of the host rule")
+}
+
+// mustHost returns the host of the URL.
+func mustHost(t *testing.T, raw string) string {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u.Host
+}
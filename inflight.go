@@ -0,0 +1,84 @@
+package echocache
+
+import (
+	"github.com/logocomune/echocache/store"
+	"sort"
+	"sync"
+)
+
+// MetricInFlight is the gauge reporting the number of keys currently being computed by a cache.
+const MetricInFlight = "echocache_inflight_computations"
+
+// inFlightTracker keeps track of the keys whose value is being computed, publishing their count as a gauge.
+// A nil *inFlightTracker is valid and tracks nothing.
+type inFlightTracker struct {
+	mu   sync.Mutex
+	keys map[string]int
+	sink store.MetricsSink
+}
+
+// newInFlightTracker creates a tracker publishing to the given sink, which may be nil.
+func newInFlightTracker(sink store.MetricsSink) *inFlightTracker {
+	return &inFlightTracker{
+		keys: make(map[string]int),
+		sink: sink,
+	}
+}
+
+// start marks the key as being computed.
+func (t *inFlightTracker) start(key string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keys[key]++
+	t.publish()
+}
+
+// done marks a computation of the key as completed.
+func (t *inFlightTracker) done(key string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.keys[key] <= 1 {
+		delete(t.keys, key)
+	} else {
+		t.keys[key]--
+	}
+	t.publish()
+}
+
+// computing reports whether the value of the key is being computed by the cache.
+func (t *inFlightTracker) computing(key string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.keys[key] > 0
+}
+
+// list returns the keys being computed, sorted.
+func (t *inFlightTracker) list() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	keys := make([]string, 0, len(t.keys))
+	for key := range t.keys {
+		keys = append(keys, key)
+	}
+	t.mu.Unlock()
+	sort.Strings(keys)
+	return keys
+}
+
+// publish updates the gauge with the number of keys being computed. Must be called with the lock held.
+func (t *inFlightTracker) publish() {
+	if t.sink != nil {
+		t.sink.SetGauge(MetricInFlight, nil, float64(len(t.keys)))
+	}
+}
@@ -0,0 +1,61 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_InFlight verifies that keys being computed are listed and counted in the gauge.
+func TestEchoCache_InFlight(t *testing.T) {
+	metrics := store.NewMemoryMetrics()
+	cache := NewEchoCache[string](store.NewLRUCache[string](10), WithMetrics(metrics))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, err := cache.FetchWithCache(context.Background(), "slow", func(ctx context.Context) (string, error) {
+			close(started)
+			<-release
+			return "value", nil
+		})
+		assert.NoError(t, err)
+	}()
+
+	<-started
+	assert.Equal(t, []string{"slow"}, cache.InFlight())
+	assert.Equal(t, 1.0, metrics.Gauge(MetricInFlight, nil))
+
+	close(release)
+	<-done
+	assert.Empty(t, cache.InFlight())
+	assert.Equal(t, 0.0, metrics.Gauge(MetricInFlight, nil))
+}
+
+// TestEchoCacheLazy_InFlight verifies that background refreshes are reported while running.
+func TestEchoCacheLazy_InFlight(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second)
+	defer cache.ShutdownLazyRefresh()
+	require.NoError(t, swr.Set(ctx, "k", store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-time.Hour)}))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	_, _, _ = cache.FetchWithLazyRefresh(ctx, "k", func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		return "fresh", nil
+	}, time.Second)
+
+	<-started
+	assert.Equal(t, []string{"k"}, cache.InFlight())
+	close(release)
+	assert.Eventually(t, func() bool { return len(cache.InFlight()) == 0 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,153 @@
+package echocache
+
+import (
+	"cmp"
+	"container/heap"
+	"math/rand/v2"
+	"slices"
+	"sync"
+)
+
+// KeyStat is the activity of one of the most fetched keys of a cache. Fetches is an estimate that may exceed the
+// real number of fetches by at most Error; Hits, Misses and Errors count the fetches observed since the key entered
+// the tracked set.
+type KeyStat struct {
+	Key     string `json:"key"`
+	Fetches uint64 `json:"fetches"`
+	Error   uint64 `json:"error"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Errors  uint64 `json:"errors"`
+}
+
+// WithKeyStats tracks per-key statistics of the topK most fetched keys, reported by Stats in TopKeys. Keys are counted
+// with the space-saving algorithm, so memory stays bounded by topK whatever the number of distinct keys. sampleRate in
+// (0, 1) records only that fraction of fetches, scaling the counts back, to reduce the cost on hot paths; other values
+// record every fetch.
+func WithKeyStats(topK int, sampleRate float64) Option {
+	return func(o *options) {
+		o.keyStatsTopK = topK
+		o.keyStatsRate = sampleRate
+	}
+}
+
+// keyOutcome is the outcome of a fetch recorded by keyTracker.
+type keyOutcome int
+
+const (
+	keyHit keyOutcome = iota
+	keyMiss
+	keyError
+)
+
+// keyTracker keeps the approximate top-K most fetched keys with the space-saving algorithm: when a new key arrives
+// and the set is full, it replaces the key with the lowest count and inherits that count as its error bound.
+// A nil *keyTracker is valid and tracks nothing.
+type keyTracker struct {
+	size int
+	rate float64
+
+	mu      sync.Mutex
+	entries map[string]*keyEntry
+	heap    keyHeap
+}
+
+// keyEntry is a tracked key with its position in the min-heap.
+type keyEntry struct {
+	KeyStat
+	index int
+}
+
+// newKeyTracker creates a tracker of the size most fetched keys, or returns nil when size is not positive.
+func newKeyTracker(size int, rate float64) *keyTracker {
+	if size <= 0 {
+		return nil
+	}
+	if rate <= 0 || rate >= 1 {
+		rate = 1
+	}
+	return &keyTracker{size: size, rate: rate, entries: make(map[string]*keyEntry, size)}
+}
+
+// record counts a fetch of key with the given outcome. Errors follow the miss of the same fetch, so they only update
+// keys already tracked.
+func (t *keyTracker) record(key string, outcome keyOutcome) {
+	if t == nil || (t.rate < 1 && rand.Float64() >= t.rate) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[key]
+	if outcome == keyError {
+		if ok {
+			e.Errors++
+		}
+		return
+	}
+	if !ok {
+		if len(t.heap) < t.size {
+			e = &keyEntry{KeyStat: KeyStat{Key: key}}
+			heap.Push(&t.heap, e)
+		} else {
+			e = t.heap[0]
+			delete(t.entries, e.Key)
+			e.KeyStat = KeyStat{Key: key, Fetches: e.Fetches, Error: e.Fetches}
+		}
+		t.entries[key] = e
+	}
+	e.Fetches++
+	if outcome == keyHit {
+		e.Hits++
+	} else {
+		e.Misses++
+	}
+	heap.Fix(&t.heap, e.index)
+}
+
+// snapshot returns the tracked keys by decreasing number of fetches, with counts scaled by the sample rate.
+func (t *keyTracker) snapshot() []KeyStat {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	stats := make([]KeyStat, 0, len(t.heap))
+	for _, e := range t.heap {
+		stats = append(stats, e.KeyStat)
+	}
+	t.mu.Unlock()
+
+	scale := func(n uint64) uint64 { return uint64(float64(n) / t.rate) }
+	for i := range stats {
+		s := &stats[i]
+		s.Fetches, s.Error, s.Hits, s.Misses, s.Errors = scale(s.Fetches), scale(s.Error), scale(s.Hits), scale(s.Misses), scale(s.Errors)
+	}
+	slices.SortFunc(stats, func(a, b KeyStat) int {
+		return cmp.Compare(b.Fetches, a.Fetches)
+	})
+	return stats
+}
+
+// keyHeap is a min-heap of tracked keys ordered by number of fetches.
+type keyHeap []*keyEntry
+
+func (h keyHeap) Len() int           { return len(h) }
+func (h keyHeap) Less(i, j int) bool { return h[i].Fetches < h[j].Fetches }
+
+func (h keyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *keyHeap) Push(x any) {
+	e := x.(*keyEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *keyHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,68 @@
+package echocache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestKeyTracker_TopK verifies that hot keys are retained with bounded memory while many cold keys pass through.
+func TestKeyTracker_TopK(t *testing.T) {
+	tracker := newKeyTracker(10, 1)
+	for i := range 10000 {
+		tracker.record("hot", keyHit)
+		if i%2 == 0 {
+			tracker.record("warm", keyMiss)
+		}
+		tracker.record(fmt.Sprintf("cold:%d", i), keyMiss)
+	}
+	tracker.record("hot", keyError)
+	tracker.record("unknown", keyError)
+
+	stats := tracker.snapshot()
+	require.Len(t, stats, 10)
+	assert.Len(t, tracker.entries, 10)
+	assert.Equal(t, KeyStat{Key: "hot", Fetches: 10000, Hits: 10000, Errors: 1}, stats[0])
+	// Keys fetched more than a tenth of the time are guaranteed to be tracked, within the reported error.
+	i := slices.IndexFunc(stats, func(s KeyStat) bool { return s.Key == "warm" })
+	require.GreaterOrEqual(t, i, 0)
+	assert.GreaterOrEqual(t, stats[i].Fetches, uint64(5000))
+	assert.LessOrEqual(t, stats[i].Fetches-stats[i].Error, uint64(5000))
+}
+
+// TestKeyTracker_Sampling verifies that sampled counts are scaled back to estimates of the real counts.
+func TestKeyTracker_Sampling(t *testing.T) {
+	tracker := newKeyTracker(2, 0.1)
+	for range 100000 {
+		tracker.record("a", keyHit)
+	}
+	stats := tracker.snapshot()
+	require.Len(t, stats, 1)
+	assert.InDelta(t, 100000, float64(stats[0].Fetches), 5000)
+	assert.Nil(t, newKeyTracker(0, 1))
+}
+
+// TestEchoCache_KeyStats verifies that the most fetched keys are reported by Stats.
+func TestEchoCache_KeyStats(t *testing.T) {
+	ctx := context.Background()
+	ec := NewEchoCache[string](store.NewLRUCache[string](10), WithKeyStats(2, 1))
+	refresh := func(ctx context.Context) (string, error) { return "v", nil }
+	for range 3 {
+		_, _, err := ec.FetchWithCache(ctx, "a", refresh)
+		require.NoError(t, err)
+	}
+	_, _, err := ec.FetchWithCache(ctx, "b", refresh)
+	require.NoError(t, err)
+
+	stats := ec.Stats()
+	assert.Equal(t, []KeyStat{
+		{Key: "a", Fetches: 3, Hits: 2, Misses: 1},
+		{Key: "b", Fetches: 1, Misses: 1},
+	}, stats.TopKeys)
+	assert.Nil(t, NewEchoCache[string](store.NewLRUCache[string](10)).Stats().TopKeys)
+}
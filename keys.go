@@ -0,0 +1,149 @@
+package echocache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// KeyFunc transforms a raw cache key into the key actually used to read and write the store, see WithKeyFunc.
+type KeyFunc func(key string) string
+
+// WithKeyFunc makes EchoCache read and write the store under fn(key) for every key it is given, for instance with
+// TimeBucketed to get a fresh key per time window. The key is transformed once per call, so a fetch reads and stores
+// the value under the same key even when a window ends while it computes. Refresh functions and the results of
+// FetchManyWithCache use the keys as given, while refresh hooks, events and key statistics report the transformed
+// keys. EchoCacheLazy ignores it.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = fn
+	}
+}
+
+// TimeBucketKey appends to key the time bucket of t rendered with a strftime-like layout such as ":%Y%m%d%H".
+// Supported verbs are %Y, %m, %d, %H, %M, %S, %j (day of year) and %% for a literal percent sign; t is converted to UTC first.
+func TimeBucketKey(key string, layout string, t time.Time) string {
+	return key + formatBucket(layout, t.UTC())
+}
+
+// TimeBucketed returns a KeyFunc that suffixes every key with the current UTC time bucket rendered with the given layout.
+// Naturally time-windowed computations (e.g. hourly aggregates) get a fresh key per window while old windows age out via TTL.
+func TimeBucketed(layout string) KeyFunc {
+	return timeBucketed(layout, time.Now)
+}
+
+// timeBucketed is TimeBucketed reading the current time from now.
+func timeBucketed(layout string, now func() time.Time) KeyFunc {
+	return func(key string) string {
+		return TimeBucketKey(key, layout, now())
+	}
+}
+
+// WindowBucketed returns a KeyFunc that suffixes every key with the Unix start time of the current window of the given size.
+// It is useful for windows that cannot be expressed with calendar fields, such as 15-minute buckets.
+func WindowBucketed(window time.Duration) KeyFunc {
+	return windowBucketed(window, time.Now)
+}
+
+// windowBucketed is WindowBucketed reading the current time from now.
+func windowBucketed(window time.Duration, now func() time.Time) KeyFunc {
+	return func(key string) string {
+		return key + ":" + strconv.FormatInt(now().UTC().Truncate(window).Unix(), 10)
+	}
+}
+
+// key returns the store key of the raw key, transformed by the KeyFunc set with WithKeyFunc if any.
+func (ec *EchoCache[T]) key(key string) string {
+	if ec.keys == nil {
+		return key
+	}
+	return ec.keys(key)
+}
+
+// keyedEntries returns the entries under their store key, see key.
+func (ec *EchoCache[T]) keyedEntries(entries map[string]T) map[string]T {
+	if ec.keys == nil {
+		return entries
+	}
+	keyed := make(map[string]T, len(entries))
+	for key, value := range entries {
+		keyed[ec.keys(key)] = value
+	}
+	return keyed
+}
+
+// keyedStream forwards the entries under their store key, see key, until entries is closed or ctx is done, then
+// closes the returned channel.
+func (ec *EchoCache[T]) keyedStream(ctx context.Context, entries <-chan store.Entry[T]) <-chan store.Entry[T] {
+	if ec.keys == nil {
+		return entries
+	}
+	keyed := make(chan store.Entry[T])
+	go func() {
+		defer close(keyed)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry, ok := <-entries:
+				if !ok {
+					return
+				}
+				entry.Key = ec.keys(entry.Key)
+				select {
+				case keyed <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return keyed
+}
+
+// formatBucket renders a strftime-like layout for the given time. Unknown verbs are copied verbatim.
+func formatBucket(layout string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		if c != '%' || i == len(layout)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch layout[i] {
+		case 'Y':
+			b.WriteString(strconv.Itoa(t.Year()))
+		case 'm':
+			writePadded(&b, int(t.Month()), 2)
+		case 'd':
+			writePadded(&b, t.Day(), 2)
+		case 'H':
+			writePadded(&b, t.Hour(), 2)
+		case 'M':
+			writePadded(&b, t.Minute(), 2)
+		case 'S':
+			writePadded(&b, t.Second(), 2)
+		case 'j':
+			writePadded(&b, t.YearDay(), 3)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(layout[i])
+		}
+	}
+	return b.String()
+}
+
+// writePadded writes n left-padded with zeros to the given width.
+func writePadded(b *strings.Builder, n int, width int) {
+	s := strconv.Itoa(n)
+	for i := len(s); i < width; i++ {
+		b.WriteByte('0')
+	}
+	b.WriteString(s)
+}
@@ -0,0 +1,97 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimeBucketKey verifies strftime-like rendering of time buckets appended to cache keys.
+func TestTimeBucketKey(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 7, 9, 3, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		layout   string
+		expected string
+	}{
+		{name: "hourly", layout: ":%Y%m%d%H", expected: "agg:2024030507"},
+		{name: "minute", layout: ":%H%M%S", expected: "agg:070903"},
+		{name: "day of year", layout: ":%Y-%j", expected: "agg:2024-065"},
+		{name: "escaped percent", layout: ":%%%Y", expected: "agg:%2024"},
+		{name: "unknown verb", layout: ":%q", expected: "agg:%q"},
+		{name: "trailing percent", layout: ":%", expected: "agg:%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TimeBucketKey("agg", tt.layout, ts))
+		})
+	}
+}
+
+// TestTimeBucketKey_ConvertsToUTC verifies that buckets do not depend on the caller's time zone.
+func TestTimeBucketKey_ConvertsToUTC(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	ts := time.Date(2024, time.March, 5, 1, 0, 0, 0, loc)
+	assert.Equal(t, "k:2024030423", TimeBucketKey("k", ":%Y%m%d%H", ts))
+}
+
+// TestWindowBucketed verifies that keys carry the Unix start time of the current window and change when it ends.
+func TestWindowBucketed(t *testing.T) {
+	now := time.Date(2024, time.March, 5, 7, 59, 59, 0, time.UTC)
+	fn := windowBucketed(15*time.Minute, func() time.Time { return now })
+
+	assert.Equal(t, "k:1709624700", fn("k"))
+	now = now.Add(time.Second)
+	assert.Equal(t, "k:1709625600", fn("k"))
+	now = now.Add(14*time.Minute + 59*time.Second)
+	assert.Equal(t, "k:1709625600", fn("k"))
+}
+
+// TestEchoCache_KeyFunc verifies that EchoCache reads and writes the store under the transformed keys, transforming
+// them once per call, while refresh functions and batch results keep the keys as given.
+func TestEchoCache_KeyFunc(t *testing.T) {
+	now := time.Date(2024, time.March, 5, 7, 30, 0, 0, time.UTC)
+	lru := store.NewLRUCache[string](10)
+	ec := NewEchoCache[string](lru, WithKeyFunc(timeBucketed(":%H", func() time.Time { return now })))
+
+	value, _, err := ec.FetchWithCache(t.Context(), "agg", func(ctx context.Context) (string, error) {
+		now = now.Add(time.Hour)
+		return "07", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "07", value)
+	stored, exists, _ := lru.Get(t.Context(), "agg:07")
+	assert.True(t, exists, "the value must be stored under the key it was fetched with")
+	assert.Equal(t, "07", stored)
+
+	value, _, err = ec.FetchWithCache(t.Context(), "agg", func(ctx context.Context) (string, error) {
+		return "08", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "08", value)
+
+	var requested []string
+	values, err := ec.FetchManyWithCache(t.Context(), []string{"agg", "other"}, func(ctx context.Context, keys []string) (map[string]string, error) {
+		requested = keys
+		return map[string]string{"other": "o"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other"}, requested)
+	assert.Equal(t, map[string]string{"agg": "08", "other": "o"}, values)
+	_, exists, _ = lru.Get(t.Context(), "other:08")
+	assert.True(t, exists)
+
+	require.NoError(t, ec.Invalidate(t.Context(), "agg"))
+	_, exists, _ = lru.Get(t.Context(), "agg:08")
+	assert.False(t, exists)
+	_, exists, _ = lru.Get(t.Context(), "agg:07")
+	assert.True(t, exists, "previous windows must be left to expire")
+
+	assert.ErrorIs(t, ec.Reconfigure(WithKeyFunc(WindowBucketed(time.Hour))), ErrNotReconfigurable)
+}
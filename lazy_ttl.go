@@ -0,0 +1,50 @@
+package echocache
+
+import (
+	"errors"
+	"fmt"
+	"github.com/logocomune/echocache/store"
+	"time"
+)
+
+// DefaultStoreTTLFactor is the multiple of the refresh interval used as store TTL by NewLazyEchoCacheForInterval.
+const DefaultStoreTTLFactor = 3
+
+// ErrInvalidStoreTTL is returned when the store TTL would let entries expire before they can be lazily refreshed.
+var ErrInvalidStoreTTL = errors.New("store TTL must exceed refresh interval plus refresh timeout")
+
+// StoreTTLForRefresh returns the store TTL derived from the lazy refresh interval with DefaultStoreTTLFactor.
+func StoreTTLForRefresh(refreshInterval time.Duration) time.Duration {
+	return DefaultStoreTTLFactor * refreshInterval
+}
+
+// ValidateStoreTTL checks that entries written to a store with the given TTL survive long enough to be served stale
+// and refreshed: the TTL must be longer than the refresh interval plus the refresh timeout.
+func ValidateStoreTTL(refreshInterval time.Duration, refreshTimeout time.Duration, storeTTL time.Duration) error {
+	if refreshInterval <= 0 {
+		return fmt.Errorf("refresh interval must be positive, got %s", refreshInterval)
+	}
+	if refreshTimeout <= 0 {
+		return fmt.Errorf("refresh timeout must be positive, got %s", refreshTimeout)
+	}
+	if storeTTL <= refreshInterval+refreshTimeout {
+		return fmt.Errorf("%w: ttl %s, refresh interval %s, refresh timeout %s", ErrInvalidStoreTTL, storeTTL, refreshInterval, refreshTimeout)
+	}
+	return nil
+}
+
+// NewLazyEchoCacheForInterval creates a lazy echo cache whose store TTL is derived from the refresh interval, so
+// stale entries never vanish mid-cycle. newStore is called with the derived TTL, DefaultStoreTTLFactor times the
+// refresh interval unless overridden with WithStoreTTLFactor, and the configuration is validated with ValidateStoreTTL.
+// The refresh interval is kept by the cache and used by FetchWithRefresh.
+func NewLazyEchoCacheForInterval[T any](refreshInterval time.Duration, refreshTimeout time.Duration, newStore func(ttl time.Duration) store.StaleWhileRevalidateCache[T], opts ...Option) (*EchoCacheLazy[T], error) {
+	o := newOptions(opts)
+	ttl := StoreTTLForRefresh(refreshInterval)
+	if o.storeTTLFactor > 0 {
+		ttl = time.Duration(o.storeTTLFactor * float64(refreshInterval))
+	}
+	if err := ValidateStoreTTL(refreshInterval, refreshTimeout, ttl); err != nil {
+		return nil, err
+	}
+	return NewLazyEchoCache[T](newStore(ttl), refreshTimeout, append(opts, WithLazyRefreshInterval(refreshInterval))...), nil
+}
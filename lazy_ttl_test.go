@@ -0,0 +1,63 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateStoreTTL verifies the accepted and rejected TTL configurations.
+func TestValidateStoreTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		timeout  time.Duration
+		ttl      time.Duration
+		wantErr  error
+	}{
+		{name: "valid", interval: time.Minute, timeout: time.Second, ttl: 3 * time.Minute},
+		{name: "ttl equal to interval", interval: time.Minute, timeout: time.Second, ttl: time.Minute, wantErr: ErrInvalidStoreTTL},
+		{name: "ttl shorter than interval plus timeout", interval: time.Minute, timeout: time.Minute, ttl: 2 * time.Minute, wantErr: ErrInvalidStoreTTL},
+		{name: "never expire", interval: time.Minute, timeout: time.Second, ttl: NeverExpire},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStoreTTL(tt.interval, tt.timeout, tt.ttl)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+	assert.Error(t, ValidateStoreTTL(0, time.Second, time.Hour))
+	assert.Error(t, ValidateStoreTTL(time.Minute, 0, time.Hour))
+}
+
+// TestNewLazyEchoCacheForInterval verifies that the store TTL is derived from the refresh interval.
+func TestNewLazyEchoCacheForInterval(t *testing.T) {
+	var storeTTL time.Duration
+	newStore := func(ttl time.Duration) store.StaleWhileRevalidateCache[string] {
+		storeTTL = ttl
+		return store.NewStaleWhileRevalidateExpiringLRUCache[string](10, ttl)
+	}
+
+	cache, err := NewLazyEchoCacheForInterval[string](time.Minute, time.Second, newStore)
+	require.NoError(t, err)
+	defer cache.ShutdownLazyRefresh()
+	assert.Equal(t, 3*time.Minute, storeTTL)
+
+	value, exists, err := cache.FetchWithRefresh(context.Background(), "k", func(ctx context.Context) (string, error) {
+		return "value", nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "value", value)
+
+	_, err = NewLazyEchoCacheForInterval[string](time.Minute, time.Minute, newStore, WithStoreTTLFactor(1.5))
+	assert.ErrorIs(t, err, ErrInvalidStoreTTL)
+}
@@ -0,0 +1,137 @@
+package echocache
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+const (
+	// defaultLeaderKey is the refresh lock key used by Elector when none is configured.
+	defaultLeaderKey = "leader"
+	// defaultLeaderTTL is the leadership lease used by Elector when none is configured.
+	defaultLeaderTTL = 15 * time.Second
+)
+
+// ElectorConfig configures an Elector. Key is the refresh lock key holding the leadership, "leader" by default, and
+// TTL the lease after which a leader that stopped renewing it loses the leadership, 15s by default. ID identifies
+// this process as lock holder and is random when empty. OnChange, when set, is called every time this process gains
+// or loses the leadership.
+type ElectorConfig struct {
+	Key      string
+	TTL      time.Duration
+	ID       string
+	OnChange func(leader bool)
+}
+
+// Elector elects a single leader among the processes sharing a store, so that cluster-wide duties such as scheduled
+// refreshes run on one instance only. Leadership is a lease on the refresh lock of the store, renewed every third
+// of the TTL: when the leader dies or loses the store, the lease expires and another process takes over. Stores
+// whose locks are local to the process, such as the in-memory ones, make every process a leader.
+type Elector struct {
+	locker store.RefreshLocker
+	cfg    ElectorConfig
+	leader atomic.Bool
+}
+
+// NewElector creates an elector campaigning on the refresh lock of locker.
+func NewElector(locker store.RefreshLocker, cfg ElectorConfig) *Elector {
+	if cfg.Key == "" {
+		cfg.Key = defaultLeaderKey
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultLeaderTTL
+	}
+	if cfg.ID == "" {
+		cfg.ID = randString(lockValueLength)
+	}
+	return &Elector{locker: locker, cfg: cfg}
+}
+
+// ID returns the holder identifier of this process.
+func (e *Elector) ID() string {
+	return e.cfg.ID
+}
+
+// IsLeader reports whether this process currently holds the leadership.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns for the leadership until ctx is done, then releases it. fn runs once per leadership term with a
+// context cancelled as soon as the leadership is lost, and Run waits for it to return before campaigning again.
+// A failed renewal keeps the leadership while the lease is still valid, so a single store error does not cause a
+// failover; the leader steps down as soon as the next renewal could come after the lease expired, leaving fn time to
+// return before another process can take over.
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context)) error {
+	interval := e.cfg.TTL / 3
+	// margin absorbs late ticks and the clock drift between this process and the store.
+	margin := e.cfg.TTL / 10
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var renewed time.Time
+	var stop context.CancelFunc
+	done := make(chan struct{})
+	stepDown := func() {
+		if stop == nil {
+			return
+		}
+		stop()
+		<-done
+		stop = nil
+		e.setLeader(false)
+	}
+
+	for {
+		// The lease starts at the latest when the store receives the request, so it is timed from before sending it.
+		attempt := time.Now()
+		acquired, err := e.locker.TryAcquireRefreshLock(ctx, e.cfg.Key, e.cfg.ID, e.cfg.TTL)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				slog.Warn("Cannot renew leadership", slog.String("key", e.cfg.Key), slog.String("error", err.Error()))
+			}
+			if stop != nil && time.Since(renewed)+interval+margin >= e.cfg.TTL {
+				stepDown()
+			}
+		case acquired:
+			renewed = attempt
+			if stop == nil {
+				var leadCtx context.Context
+				leadCtx, stop = context.WithCancel(ctx)
+				done = make(chan struct{})
+				e.setLeader(true)
+				go func() {
+					defer close(done)
+					fn(leadCtx)
+				}()
+			}
+		default:
+			stepDown()
+		}
+
+		select {
+		case <-ctx.Done():
+			if stop != nil {
+				stepDown()
+				if err := e.locker.ReleaseRefreshLock(context.WithoutCancel(ctx), e.cfg.Key, e.cfg.ID); err != nil {
+					slog.Warn("Cannot release leadership", slog.String("key", e.cfg.Key), slog.String("error", err.Error()))
+				}
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// setLeader records the leadership state, notifying OnChange.
+func (e *Elector) setLeader(leader bool) {
+	e.leader.Store(leader)
+	if e.cfg.OnChange != nil {
+		e.cfg.OnChange(leader)
+	}
+}
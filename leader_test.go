@@ -0,0 +1,151 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leaseLocker simulates refresh locks shared by several processes, expiring after their TTL. Holders marked down
+// get errors, like a process that lost its connection to the store. Latency delays the replies of the store.
+type leaseLocker struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+	down    map[string]bool
+	latency time.Duration
+}
+
+func (l *leaseLocker) TryAcquireRefreshLock(_ context.Context, _ string, randValue string, ttl time.Duration) (bool, error) {
+	defer time.Sleep(l.latency)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.down[randValue] {
+		return false, errors.New("store unreachable")
+	}
+	if l.holder != "" && l.holder != randValue && time.Now().Before(l.expires) {
+		return false, nil
+	}
+	l.holder, l.expires = randValue, time.Now().Add(ttl)
+	return true, nil
+}
+
+func (l *leaseLocker) ReleaseRefreshLock(_ context.Context, _ string, randValue string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == randValue {
+		l.holder = ""
+	}
+	return nil
+}
+
+func (l *leaseLocker) setDown(holder string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.down[holder] = true
+}
+
+// TestElector_Failover verifies that a single process leads at a time and that another takes over when it fails.
+func TestElector_Failover(t *testing.T) {
+	locker := &leaseLocker{down: map[string]bool{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var running atomic.Int32
+	var terms sync.Map
+	lead := func(id string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			assert.Equal(t, int32(1), running.Add(1))
+			terms.Store(id, true)
+			<-ctx.Done()
+			running.Add(-1)
+		}
+	}
+
+	a := NewElector(locker, ElectorConfig{ID: "a", TTL: 60 * time.Millisecond})
+	b := NewElector(locker, ElectorConfig{ID: "b", TTL: 60 * time.Millisecond})
+	var wg sync.WaitGroup
+	for _, e := range []*Elector{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.ErrorIs(t, e.Run(ctx, lead(e.ID())), context.Canceled)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return a.IsLeader() != b.IsLeader() }, time.Second, 5*time.Millisecond)
+	first, second := a, b
+	if b.IsLeader() {
+		first, second = b, a
+	}
+
+	locker.setDown(first.ID())
+	require.Eventually(t, second.IsLeader, time.Second, 5*time.Millisecond)
+	assert.False(t, first.IsLeader())
+	require.Eventually(t, func() bool {
+		_, led := terms.Load(second.ID())
+		return led
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+	assert.False(t, second.IsLeader())
+	assert.Zero(t, running.Load())
+	assert.Empty(t, locker.holder)
+}
+
+// TestElector_StepsDownBeforeLeaseExpiry verifies that a leader unable to renew its lease steps down before the
+// lease expires, so that its term never overlaps with the next leader's.
+func TestElector_StepsDownBeforeLeaseExpiry(t *testing.T) {
+	locker := &leaseLocker{down: map[string]bool{}, latency: 5 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	steppedDown := make(chan time.Time, 2)
+	e := NewElector(locker, ElectorConfig{ID: "a", TTL: 90 * time.Millisecond, OnChange: func(leader bool) {
+		if !leader {
+			steppedDown <- time.Now()
+		}
+	}})
+	go func() {
+		_ = e.Run(ctx, func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond) // winding down
+		})
+	}()
+
+	require.Eventually(t, e.IsLeader, time.Second, time.Millisecond)
+	locker.setDown("a")
+	locker.mu.Lock()
+	expires := locker.expires
+	locker.mu.Unlock()
+
+	select {
+	case at := <-steppedDown:
+		assert.True(t, at.Before(expires), "stepped down %v after the lease expired", at.Sub(expires))
+	case <-time.After(time.Second):
+		t.Fatal("leader did not step down")
+	}
+}
+
+// TestElector_OnChange verifies that leadership changes are reported and the lease is released on shutdown.
+func TestElector_OnChange(t *testing.T) {
+	locker := &leaseLocker{down: map[string]bool{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan bool, 2)
+	e := NewElector(locker, ElectorConfig{OnChange: func(leader bool) { changes <- leader }})
+	assert.Len(t, e.ID(), lockValueLength)
+
+	go func() {
+		<-changes
+		cancel()
+	}()
+	assert.ErrorIs(t, e.Run(ctx, func(ctx context.Context) { <-ctx.Done() }), context.Canceled)
+	assert.False(t, <-changes)
+	assert.Empty(t, locker.holder)
+}
@@ -0,0 +1,66 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// ErrNoLoader is returned by Get on an EchoCache created without a default loader.
+var ErrNoLoader = errors.New("echocache: no default loader registered")
+
+// LoaderFunc computes the value of a key, like the loader of a classic loading cache.
+type LoaderFunc[T any] func(ctx context.Context, key string) (T, error)
+
+// refreshFunc binds the loader to the key.
+func (l LoaderFunc[T]) refreshFunc(key string) store.RefreshFunc[T] {
+	return func(ctx context.Context) (T, error) {
+		return l(ctx, key)
+	}
+}
+
+// NewEchoCacheWithLoader creates an EchoCache whose misses are computed by loader, so call sites can use Get without
+// passing a refresh function. FetchWithCache still accepts a per-call refresh function overriding the loader.
+func NewEchoCacheWithLoader[T any](cacher store.Cacher[T], loader LoaderFunc[T], opts ...Option) *EchoCache[T] {
+	ec := NewEchoCache[T](cacher, opts...)
+	ec.loader = loader
+	return ec
+}
+
+// Get returns the cached value for the key or computes it with the default loader, with the same semantics as
+// FetchWithCache. Returns ErrNoLoader when the cache was not created by NewEchoCacheWithLoader.
+func (ec *EchoCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	if ec.loader == nil {
+		var zeroValue T
+		return zeroValue, false, ErrNoLoader
+	}
+	return ec.FetchWithCache(ctx, key, ec.loader.refreshFunc(key))
+}
+
+// GetIfPresent returns the cached value for the key without computing it on a miss, like getIfPresent of a
+// Caffeine or Guava loading cache. A key with an active tombstone, see WithTombstones, is reported as not found.
+func (ec *EchoCache[T]) GetIfPresent(ctx context.Context, key string) (T, bool, error) {
+	var zeroValue T
+	if err := ctx.Err(); err != nil {
+		return zeroValue, false, err
+	}
+	key = ec.key(key)
+	if ec.graves.active(key) {
+		ec.counters.miss(key)
+		return zeroValue, false, nil
+	}
+	value, exists, err := ec.store.Get(ctx, key)
+	if err != nil || !exists {
+		ec.counters.miss(key)
+		return zeroValue, false, err
+	}
+	ec.counters.hit(key)
+	return value, true, nil
+}
+
+// GetOrLoad returns the cached value for the key or computes it with the default loader, like get of a Caffeine or
+// Guava loading cache. It is equivalent to Get.
+func (ec *EchoCache[T]) GetOrLoad(ctx context.Context, key string) (T, bool, error) {
+	return ec.Get(ctx, key)
+}
@@ -0,0 +1,67 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_Get verifies that Get computes misses with the default loader and that FetchWithCache overrides it.
+func TestEchoCache_Get(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	ec := NewEchoCacheWithLoader[string](store.NewLRUCache[string](10), func(ctx context.Context, key string) (string, error) {
+		calls++
+		return "loaded:" + key, nil
+	})
+
+	value, exists, err := ec.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "loaded:a", value)
+
+	value, _, err = ec.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "loaded:a", value)
+	assert.Equal(t, 1, calls)
+
+	value, _, err = ec.FetchWithCache(ctx, "b", func(ctx context.Context) (string, error) {
+		return "override", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "override", value)
+	assert.Equal(t, 1, calls)
+}
+
+// TestEchoCache_GetWithoutLoader verifies that Get requires a default loader.
+func TestEchoCache_GetWithoutLoader(t *testing.T) {
+	ec := NewEchoCache[string](store.NewLRUCache[string](10))
+	_, _, err := ec.Get(context.Background(), "a")
+	assert.ErrorIs(t, err, ErrNoLoader)
+}
+
+// TestEchoCache_GetIfPresent verifies that GetIfPresent never invokes the loader, and that GetOrLoad does on a miss.
+func TestEchoCache_GetIfPresent(t *testing.T) {
+	ctx := context.Background()
+	ec := NewEchoCacheWithLoader[int](store.NewLRUCache[int](10), func(ctx context.Context, key string) (int, error) {
+		return len(key), nil
+	})
+
+	_, exists, err := ec.GetIfPresent(ctx, "abc")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	value, exists, err := ec.GetOrLoad(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 3, value)
+
+	value, exists, err = ec.GetIfPresent(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 3, value)
+	assert.Equal(t, uint64(1), ec.Stats().Hits)
+}
@@ -10,11 +10,21 @@ type singleFlightResult[T any] struct {
 	resultValue T
 	createdAt   time.Time
 	requestId   string
+	stored      bool
 }
 
 // refreshTask represents a task for refreshing a cache entry using a specified compute function.
+// A zero timeout means the cache default refresh timeout applies.
 type refreshTask[T any] struct {
 	key         string
 	computeFunc store.RefreshFunc[T]
 	requestId   string
+	timeout     time.Duration
+	// correlationId is the request ID of the caller that scheduled the task, used in logs and hooks.
+	correlationId string
+	background    bool
+	// deadline is the latest time the task may leave the queue; zero means no deadline.
+	deadline time.Time
+	// observedAt is the creation time of the stale value that triggered the task.
+	observedAt time.Time
 }
@@ -0,0 +1,56 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// ErrKeyNotComputed is returned when a MultiRefreshFunc does not produce a value for the requested key.
+var ErrKeyNotComputed = errors.New("multi-value refresh did not produce the requested key")
+
+// MultiRefreshFunc computes the values of several keys at once, such as a batch pricing call, returning them by key.
+type MultiRefreshFunc[T any] func(ctx context.Context) (map[string]T, error)
+
+// forKey adapts the function to a RefreshFunc computing key: the values of the other keys are handed to storeOthers
+// and the value of key is returned.
+func (fn MultiRefreshFunc[T]) forKey(key string, storeOthers func(ctx context.Context, entries map[string]T) error) store.RefreshFunc[T] {
+	return func(ctx context.Context) (T, error) {
+		var zeroValue T
+		values, err := fn(ctx)
+		if err != nil {
+			return zeroValue, err
+		}
+		value, ok := values[key]
+		others := make(map[string]T, len(values))
+		for k, v := range values {
+			if k != key {
+				others[k] = v
+			}
+		}
+		if err := storeOthers(ctx, others); err != nil {
+			slog.Warn("Failed to store values computed along the requested key", slog.String("cacheKey", key), slog.String("error", err.Error()))
+		}
+		if !ok {
+			return zeroValue, ErrKeyNotComputed
+		}
+		return value, nil
+	}
+}
+
+// FetchWithMultiRefresh is FetchWithCache for computations producing many keys at once: on a miss, refreshFn is
+// called once and every value it returns is stored, so that later fetches of the other keys are served from the
+// cache instead of calling upstream again. ErrKeyNotComputed is returned when the result does not contain key.
+func (ec *EchoCache[T]) FetchWithMultiRefresh(ctx context.Context, key string, refreshFn MultiRefreshFunc[T]) (T, bool, error) {
+	return ec.FetchWithCache(ctx, key, refreshFn.forKey(key, ec.BulkSet))
+}
+
+// FetchWithLazyMultiRefresh is FetchWithLazyRefresh for computations producing many keys at once: every value
+// returned by refreshFn, in the foreground on a miss or in a background refresh, is stored with the same creation
+// date, so the whole batch is refreshed together. ErrKeyNotComputed is returned when the result does not contain key.
+func (ec *EchoCacheLazy[T]) FetchWithLazyMultiRefresh(ctx context.Context, key string, refreshFn MultiRefreshFunc[T], lazyRefreshInterval time.Duration, opts ...FetchOption) (T, bool, error) {
+	return ec.FetchWithLazyRefresh(ctx, key, refreshFn.forKey(key, ec.BulkSet), lazyRefreshInterval, opts...)
+}
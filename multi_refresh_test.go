@@ -0,0 +1,56 @@
+package echocache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_FetchWithMultiRefresh verifies that one computation fills every key of the batch.
+func TestEchoCache_FetchWithMultiRefresh(t *testing.T) {
+	ctx := context.Background()
+	cache := NewEchoCache[int](store.NewLRUCache[int](10))
+	var calls atomic.Int32
+	prices := func(ctx context.Context) (map[string]int, error) {
+		calls.Add(1)
+		return map[string]int{"apple": 1, "pear": 2, "plum": 3}, nil
+	}
+
+	for key, want := range map[string]int{"apple": 1, "pear": 2, "plum": 3} {
+		value, exists, err := cache.FetchWithMultiRefresh(ctx, key, prices)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, want, value)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, _, err := cache.FetchWithMultiRefresh(ctx, "kiwi", prices)
+	assert.ErrorIs(t, err, ErrKeyNotComputed)
+	_, exists, _ := cache.GetIfPresent(ctx, "kiwi")
+	assert.False(t, exists)
+}
+
+// TestEchoCacheLazy_FetchWithLazyMultiRefresh verifies that the values computed along the requested key are stored.
+func TestEchoCacheLazy_FetchWithLazyMultiRefresh(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLazyEchoCache[int](store.NewStaleWhileRevalidateLRUCache[int](10), time.Second)
+	defer cache.ShutdownLazyRefresh()
+	var calls atomic.Int32
+	prices := func(ctx context.Context) (map[string]int, error) {
+		calls.Add(1)
+		return map[string]int{"apple": 1, "pear": 2}, nil
+	}
+
+	value, _, err := cache.FetchWithLazyMultiRefresh(ctx, "apple", prices, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	value, _, err = cache.FetchWithLazyMultiRefresh(ctx, "pear", prices, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,254 @@
+package echocache
+
+import (
+	"github.com/logocomune/echocache/store"
+	"time"
+)
+
+// defaultLockPollInterval is the interval at which callers waiting on a distributed lock check the store for the value.
+const defaultLockPollInterval = 50 * time.Millisecond
+
+// defaultQueueSize is the capacity of the EchoCacheLazy background refresh queue.
+const defaultQueueSize = 1000
+
+// defaultRefreshWorkers is the number of goroutines processing the EchoCacheLazy background refresh queue.
+const defaultRefreshWorkers = 1
+
+// Option configures optional behavior of EchoCache and EchoCacheLazy.
+type Option func(*options)
+
+// options holds the optional settings shared by EchoCache and EchoCacheLazy.
+type options struct {
+	cooldownBase     time.Duration
+	cooldownMax      time.Duration
+	lockTTL          time.Duration
+	lockPoll         time.Duration
+	metrics          store.MetricsSink
+	storeTTLFactor   float64
+	refreshHook      func(RefreshEvent)
+	queueSize        int
+	refreshWorkers   int
+	queueWaitMax     time.Duration
+	queueWaitMin     time.Duration
+	tombstoneTTL     time.Duration
+	ids              IDGenerator
+	absenceStore     store.Cacher[bool]
+	absenceTTL       time.Duration
+	bus              *EventBus
+	busTopic         string
+	nodeID           string
+	keyStatsTopK     int
+	keyStatsRate     float64
+	budgetMargin     float64
+	refreshTimeout   time.Duration
+	refreshInterval  time.Duration
+	sfShards         int
+	staleFallback    any
+	staleFallbackAge time.Duration
+	keyFunc          KeyFunc
+}
+
+// newOptions applies the given options on top of the defaults.
+func newOptions(opts []Option) options {
+	o := options{queueSize: defaultQueueSize, refreshWorkers: defaultRefreshWorkers, sfShards: defaultSingleflightShards}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithFailureCooldown enables per-key failure cooldown: after a refresh fails, no further refresh of the same key is
+// attempted for base, doubling after each consecutive failure up to max. During the cooldown foreground fetches return
+// ErrRefreshCooldown and the lazy cache keeps serving the stale value without scheduling background refreshes.
+func WithFailureCooldown(base time.Duration, max time.Duration) Option {
+	return func(o *options) {
+		o.cooldownBase = base
+		o.cooldownMax = max
+	}
+}
+
+// WithDistributedLock guards the miss path of EchoCache with the refresh lock of the store, so that a miss is computed
+// once across all processes sharing the store rather than once per process. The process holding the lock computes and
+// stores the value; the others poll the store every pollInterval until the value appears or the lock, held for at most
+// ttl, can be acquired. A non-positive pollInterval defaults to 50ms. Stores that do not implement store.RefreshLocker are unaffected.
+func WithDistributedLock(ttl time.Duration, pollInterval time.Duration) Option {
+	if pollInterval <= 0 {
+		pollInterval = defaultLockPollInterval
+	}
+	return func(o *options) {
+		o.lockTTL = ttl
+		o.lockPoll = pollInterval
+	}
+}
+
+// WithMetrics publishes cache-level metrics, such as the MetricInFlight gauge and MetricLockWait, to the given sink.
+func WithMetrics(sink store.MetricsSink) Option {
+	return func(o *options) {
+		o.metrics = sink
+	}
+}
+
+// WithStoreTTLFactor overrides DefaultStoreTTLFactor, the multiple of the refresh interval used as store TTL by
+// NewLazyEchoCacheForInterval.
+func WithStoreTTLFactor(factor float64) Option {
+	return func(o *options) {
+		o.storeTTLFactor = factor
+	}
+}
+
+// WithRefreshHook registers a function called after every computation of a cache value, in the foreground or in the
+// background, with its key, request ID, duration and error. The hook runs synchronously and must not block.
+func WithRefreshHook(hook func(RefreshEvent)) Option {
+	return func(o *options) {
+		o.refreshHook = hook
+	}
+}
+
+// WithQueueSize sets the capacity of the EchoCacheLazy background refresh queue. Refreshes requested while the queue
+// is full are dropped. Non-positive sizes keep the default of 1000.
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.queueSize = size
+		}
+	}
+}
+
+// WithRefreshWorkers sets the number of goroutines processing the EchoCacheLazy background refresh queue, so that
+// slow refreshes of some keys do not hold back the others. Non-positive counts keep the default of 1.
+func WithRefreshWorkers(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.refreshWorkers = n
+		}
+	}
+}
+
+// WithStalenessDeadline bounds how long a background refresh may wait in the EchoCacheLazy queue, from max for a
+// value that just became stale down to min as staleness grows: the allowed wait is max scaled by
+// interval / (interval + staleness), where staleness is how long the value has been past its refresh interval.
+// A task still queued past its deadline is dropped, so the next fetch schedules a new one instead of relying on a
+// backlog that is not keeping up. Without this option queued tasks never expire.
+func WithStalenessDeadline(max time.Duration, min time.Duration) Option {
+	if min > max {
+		min = max
+	}
+	return func(o *options) {
+		o.queueWaitMax = max
+		o.queueWaitMin = min
+	}
+}
+
+// WithTombstones makes Invalidate leave a tombstone on the key for ttl. While it lasts, fetches of the key report it
+// as not found without computing it, and values computed or loaded for it are not stored, so a refresh racing with
+// the deletion of an entity cannot cache its old value again. Tombstones are kept in the memory of the process.
+func WithTombstones(ttl time.Duration) Option {
+	return func(o *options) {
+		o.tombstoneTTL = ttl
+	}
+}
+
+// WithIDGenerator sets the generator of request IDs and distributed lock values, for instance CryptoIDs, a ULID
+// based IDGeneratorFunc or SequentialIDs in tests. By default they are random alphanumeric strings from math/rand.
+func WithIDGenerator(g IDGenerator) Option {
+	return func(o *options) {
+		o.ids = g
+	}
+}
+
+// WithAbsenceStore keeps the markers of permanently absent keys, set by MarkAbsent or by refresh functions returning
+// ErrPermanentlyAbsent, in the given store, for ttl when it implements store.TTLSetter and for its own TTL otherwise.
+// Unlike the failure cooldown, markers are shared by every process using the store and meant to last long: a fetch of
+// a marked key returns ErrPermanentlyAbsent without invoking the refresh function. The store is only consulted on misses.
+func WithAbsenceStore(s store.Cacher[bool], ttl time.Duration) Option {
+	return func(o *options) {
+		o.absenceStore = s
+		o.absenceTTL = ttl
+	}
+}
+
+// WithEventBus coordinates the cache with the other caches of the topic on the in-process bus: invalidations are
+// shared, leaving a tombstone with WithTombstones and cancelling pending background refreshes, and values computed
+// by any of them are served to the others while the bus keeps them. Caches of a topic must share the same backend
+// and value type.
+func WithEventBus(bus *EventBus, topic string) Option {
+	return func(o *options) {
+		o.bus = bus
+		o.busTopic = topic
+	}
+}
+
+// WithNodeID records id as the producer of the values written by EchoCacheLazy, see store.StaleValue, so operators
+// inspecting a key can tell which node last refreshed it. Use a hostname or pod name.
+func WithNodeID(id string) Option {
+	return func(o *options) {
+		o.nodeID = id
+	}
+}
+
+// queueDeadline returns the latest time a refresh of a value created at createdAt, stale after interval, may leave
+// the queue, or the zero time when no staleness deadline is configured.
+func (o options) queueDeadline(now time.Time, createdAt time.Time, interval time.Duration) time.Time {
+	if o.queueWaitMax <= 0 {
+		return time.Time{}
+	}
+	wait := o.queueWaitMax
+	if staleness := now.Sub(createdAt) - interval; staleness > 0 && interval > 0 {
+		wait = time.Duration(float64(o.queueWaitMax) * float64(interval) / float64(interval+staleness))
+	}
+	return now.Add(max(wait, o.queueWaitMin))
+}
+
+// failureTracker returns the failure tracker configured by the options, or nil when the cooldown is disabled.
+func (o options) failureTracker() *failureTracker {
+	if o.cooldownBase <= 0 {
+		return nil
+	}
+	return newFailureTracker(o.cooldownBase, o.cooldownMax)
+}
+
+// tombstoneTracker returns the tombstone tracker configured by the options, or nil when tombstones are disabled.
+func (o options) tombstoneTracker() *tombstoneTracker {
+	if o.tombstoneTTL <= 0 {
+		return nil
+	}
+	return newTombstoneTracker(o.tombstoneTTL)
+}
+
+// cacheCounters returns the fetch counters configured by the options, tracking the most fetched keys when enabled.
+func (o options) cacheCounters() *cacheCounters {
+	return &cacheCounters{keys: newKeyTracker(o.keyStatsTopK, o.keyStatsRate)}
+}
+
+// absenceMarkers returns the absence markers configured by the options, or nil when no absence store is set.
+func (o options) absenceMarkers() *absenceMarkers {
+	if o.absenceStore == nil {
+		return nil
+	}
+	return &absenceMarkers{store: o.absenceStore, ttl: o.absenceTTL}
+}
+
+// FetchOption configures a single fetch call.
+type FetchOption func(*fetchOptions)
+
+// fetchOptions holds the per-call settings of a fetch.
+type fetchOptions struct {
+	refreshTimeout time.Duration
+}
+
+// newFetchOptions applies the given per-call options.
+func newFetchOptions(opts []FetchOption) fetchOptions {
+	o := fetchOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithRefreshTimeout overrides, for a single call, the refresh timeout configured when the lazy cache was created.
+// It applies both to the foreground computation on a miss and to the background refresh scheduled for a stale value.
+func WithRefreshTimeout(d time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.refreshTimeout = d
+	}
+}
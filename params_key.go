@@ -0,0 +1,184 @@
+package echocache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// paramsKeyTag is the struct tag used to rename a field or, with "-", exclude it from parameter keys.
+const paramsKeyTag = "cachekey"
+
+// textMarshalerType is used to detect values that provide their own stable textual form, such as time.Time.
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// ParamsKey derives a cache key from an arbitrary parameters value by hashing a canonical encoding of it, so that every
+// call site building the same parameters gets the same key. Struct fields are encoded in name order, map entries in key
+// order and pointers by the value they point to, so declaration order and pointer identity do not affect the key.
+// Fields can be renamed with the `cachekey:"name"` tag or excluded with `cachekey:"-"`; unexported fields are ignored.
+// Values implementing encoding.TextMarshaler, such as time.Time, are encoded with MarshalText.
+// Returns an error for values that have no stable encoding, such as functions and channels.
+func ParamsKey(prefix string, params any) (string, error) {
+	h := sha256.New()
+	if err := writeParams(h, reflect.ValueOf(params)); err != nil {
+		return "", err
+	}
+	return prefix + ":" + hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// FetchWithParams fetches the result of fn for the given parameters through the cache, using ParamsKey to derive the key.
+func FetchWithParams[T any, P any](ctx context.Context, ec *EchoCache[T], prefix string, params P, fn func(ctx context.Context, params P) (T, error)) (T, bool, error) {
+	key, err := ParamsKey(prefix, params)
+	if err != nil {
+		var zeroValue T
+		return zeroValue, false, err
+	}
+	return ec.FetchWithCache(ctx, key, func(ctx context.Context) (T, error) {
+		return fn(ctx, params)
+	})
+}
+
+// writeParams writes the canonical encoding of v to h. Every value is prefixed with a type marker so that,
+// for instance, the string "1" and the integer 1 produce different keys.
+func writeParams(h hash.Hash, v reflect.Value) error {
+	if !v.IsValid() {
+		_, _ = h.Write([]byte("n;"))
+		return nil
+	}
+	if v.Type().Implements(textMarshalerType) && !isNilValue(v) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		writeParamString(h, "t", string(text))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			_, _ = h.Write([]byte("n;"))
+			return nil
+		}
+		return writeParams(h, v.Elem())
+	case reflect.Bool:
+		writeParamString(h, "b", strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeParamString(h, "i", strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeParamString(h, "u", strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		writeParamString(h, "f", strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Complex64, reflect.Complex128:
+		writeParamString(h, "c", strconv.FormatComplex(v.Complex(), 'g', -1, 128))
+	case reflect.String:
+		writeParamString(h, "s", v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			_, _ = h.Write([]byte("n;"))
+			return nil
+		}
+		writeParamString(h, "l", strconv.Itoa(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := writeParams(h, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return writeParamsMap(h, v)
+	case reflect.Struct:
+		return writeParamsStruct(h, v)
+	default:
+		return fmt.Errorf("cannot derive cache key from value of type %s", v.Type())
+	}
+	return nil
+}
+
+// isNilValue reports whether v is a nil pointer or interface.
+func isNilValue(v reflect.Value) bool {
+	return (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil()
+}
+
+// writeParamsMap writes the map entries sorted by the canonical encoding of their keys.
+func writeParamsMap(h hash.Hash, v reflect.Value) error {
+	if v.IsNil() {
+		_, _ = h.Write([]byte("n;"))
+		return nil
+	}
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		kh := sha256.New()
+		if err := writeParams(kh, iter.Key()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: string(kh.Sum(nil)), value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	writeParamString(h, "m", strconv.Itoa(len(entries)))
+	for _, e := range entries {
+		_, _ = h.Write([]byte(e.key))
+		if err := writeParams(h, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeParamsStruct writes the exported, non-excluded fields of a struct sorted by their (possibly tag-renamed) name.
+func writeParamsStruct(h hash.Hash, v reflect.Value) error {
+	type field struct {
+		name  string
+		value reflect.Value
+	}
+	t := v.Type()
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup(paramsKeyTag); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fields = append(fields, field{name: name, value: v.Field(i)})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+
+	writeParamString(h, "r", strconv.Itoa(len(fields)))
+	for _, f := range fields {
+		writeParamString(h, "k", f.name)
+		if err := writeParams(h, f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeParamString writes a length-prefixed, type-marked token so that adjacent tokens can never be confused.
+func writeParamString(h hash.Hash, marker string, s string) {
+	_, _ = h.Write([]byte(marker + strconv.Itoa(len(s)) + ":" + s + ";"))
+}
@@ -0,0 +1,95 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchParams is a parameters struct exercising tags, maps, pointers and unexported fields.
+type searchParams struct {
+	Query   string
+	Page    int
+	Filters map[string]string
+	Since   *time.Time
+	TraceID string `cachekey:"-"`
+	Limit   int    `cachekey:"size"`
+	private string
+}
+
+// searchParamsReordered declares the exported fields of searchParams in a different order.
+type searchParamsReordered struct {
+	Limit   int `cachekey:"size"`
+	Page    int
+	Since   *time.Time
+	Filters map[string]string
+	Query   string
+}
+
+// TestParamsKey verifies that equivalent parameters produce the same key and distinct parameters different keys.
+func TestParamsKey(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	base := searchParams{Query: "go", Page: 2, Filters: map[string]string{"a": "1", "b": "2"}, Since: &since, Limit: 10}
+	key, err := ParamsKey("search", base)
+	require.NoError(t, err)
+	assert.Regexp(t, `^search:[0-9a-f]{32}$`, key)
+
+	same := base
+	same.TraceID = "ignored"
+	same.private = "ignored"
+	same.Filters = map[string]string{"b": "2", "a": "1"}
+	sinceCopy := since
+	same.Since = &sinceCopy
+
+	tests := []struct {
+		name   string
+		params any
+		equal  bool
+	}{
+		{name: "excluded and unexported fields", params: same, equal: true},
+		{name: "different field order", params: searchParamsReordered{Query: "go", Page: 2, Filters: map[string]string{"a": "1", "b": "2"}, Since: &since, Limit: 10}, equal: true},
+		{name: "pointer to params", params: &base, equal: true},
+		{name: "different value", params: searchParams{Query: "go", Page: 3, Filters: base.Filters, Since: &since, Limit: 10}, equal: false},
+		{name: "nil pointer", params: searchParams{Query: "go", Page: 2, Filters: base.Filters, Limit: 10}, equal: false},
+		{name: "renamed field", params: searchParams{Query: "go", Page: 2, Filters: base.Filters, Since: &since, Limit: 11}, equal: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other, err := ParamsKey("search", tt.params)
+			require.NoError(t, err)
+			if tt.equal {
+				assert.Equal(t, key, other)
+			} else {
+				assert.NotEqual(t, key, other)
+			}
+		})
+	}
+
+	_, err = ParamsKey("search", struct{ Fn func() }{Fn: func() {}})
+	assert.Error(t, err)
+	k1, _ := ParamsKey("p", []any{"1"})
+	k2, _ := ParamsKey("p", []any{1})
+	assert.NotEqual(t, k1, k2)
+}
+
+// TestFetchWithParams verifies that calls with equivalent parameters share the cached result.
+func TestFetchWithParams(t *testing.T) {
+	cache := NewEchoCache[int](store.NewLRUCache[int](10))
+	calls := 0
+	compute := func(ctx context.Context, p searchParams) (int, error) {
+		calls++
+		return p.Page * 10, nil
+	}
+
+	for _, traceID := range []string{"a", "b"} {
+		value, exists, err := FetchWithParams(context.Background(), cache, "search", searchParams{Page: 4, TraceID: traceID}, compute)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, 40, value)
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,96 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// ErrDegraded is matched, with errors.Is, by the errors wrapped with Degraded.
+var ErrDegraded = errors.New("degraded result")
+
+// degradedError is an error returned along with a partial but usable value.
+type degradedError struct {
+	err error
+}
+
+// Error returns the message of the wrapped error, prefixed to tell the result is degraded.
+func (e *degradedError) Error() string {
+	return "degraded result: " + e.err.Error()
+}
+
+// Is reports whether target is ErrDegraded.
+func (e *degradedError) Is(target error) bool {
+	return target == ErrDegraded
+}
+
+// Unwrap returns the wrapped error.
+func (e *degradedError) Unwrap() error {
+	return e.err
+}
+
+// Degraded wraps err to tell PartialRefresh that the value returned with it is partial but usable, such as a
+// page assembled while one of its upstream services was failing. A nil err is returned unchanged.
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &degradedError{err: err}
+}
+
+// Partial is the envelope cached by the refresh functions built with PartialRefresh. Degraded reports that the value
+// was computed while an upstream was failing, and Reason holds the message of the error returned along with it.
+type Partial[T any] struct {
+	Value    T
+	Degraded bool   `json:",omitempty"`
+	Reason   string `json:",omitempty"`
+}
+
+// Err returns the degradation of the value as an error matching ErrDegraded, or nil for a complete value.
+func (p Partial[T]) Err() error {
+	if !p.Degraded {
+		return nil
+	}
+	return Degraded(errors.New(p.Reason))
+}
+
+// PartialPolicy tells PartialRefresh what to do with the partial values returned along with a Degraded error.
+type PartialPolicy int
+
+const (
+	// PartialFail reports degraded results as failures: nothing is cached and the fetch returns the error.
+	PartialFail PartialPolicy = iota
+	// PartialCache caches degraded results flagged as Degraded and serves them without error, so that callers can
+	// render what is available while the lazy refresh or the store TTL replaces them with a complete value.
+	PartialCache
+)
+
+// PartialRefresh adapts a refresh function that may return a partial value along with a Degraded error to a cache of
+// Partial values. Errors not wrapped with Degraded are returned as is, whatever the policy:
+//
+//	ec := echocache.NewEchoCache[echocache.Partial[Page]](s)
+//	page, _, err := ec.FetchWithCache(ctx, key, echocache.PartialRefresh(buildPage, echocache.PartialCache))
+//	if page.Degraded { ... }
+func PartialRefresh[T any](fn store.RefreshFunc[T], policy PartialPolicy) store.RefreshFunc[Partial[T]] {
+	return func(ctx context.Context) (Partial[T], error) {
+		value, err := fn(ctx)
+		switch {
+		case err == nil:
+			return Partial[T]{Value: value}, nil
+		case policy == PartialCache && errors.Is(err, ErrDegraded):
+			return Partial[T]{Value: value, Degraded: true, Reason: degradedReason(err)}, nil
+		default:
+			return Partial[T]{}, err
+		}
+	}
+}
+
+// degradedReason returns the message of the error wrapped by the outermost Degraded error in the chain of err.
+func degradedReason(err error) string {
+	var degraded *degradedError
+	if errors.As(err, &degraded) {
+		return degraded.err.Error()
+	}
+	return err.Error()
+}
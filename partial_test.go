@@ -0,0 +1,53 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPartialRefresh verifies that degraded results are cached with their flag under PartialCache and reported as
+// failures under PartialFail, while plain errors always fail.
+func TestPartialRefresh(t *testing.T) {
+	ctx := context.Background()
+	upstream := errors.New("reviews unavailable")
+	degraded := func(context.Context) (string, error) {
+		return "page without reviews", fmt.Errorf("building page: %w", Degraded(upstream))
+	}
+
+	cache := NewEchoCache[Partial[string]](store.NewLRUCache[Partial[string]](10))
+	page, exists, err := cache.FetchWithCache(ctx, "page", PartialRefresh(degraded, PartialCache))
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, Partial[string]{Value: "page without reviews", Degraded: true, Reason: "reviews unavailable"}, page)
+	assert.ErrorIs(t, page.Err(), ErrDegraded)
+
+	cached, exists, _ := cache.GetIfPresent(ctx, "page")
+	assert.True(t, exists)
+	assert.True(t, cached.Degraded)
+
+	_, _, err = cache.FetchWithCache(ctx, "strict", PartialRefresh(degraded, PartialFail))
+	assert.ErrorIs(t, err, ErrDegraded)
+	assert.ErrorIs(t, err, upstream)
+	_, exists, _ = cache.GetIfPresent(ctx, "strict")
+	assert.False(t, exists)
+
+	_, _, err = cache.FetchWithCache(ctx, "failed", PartialRefresh(func(context.Context) (string, error) {
+		return "", upstream
+	}, PartialCache))
+	assert.ErrorIs(t, err, upstream)
+	assert.NotErrorIs(t, err, ErrDegraded)
+
+	complete, _, err := cache.FetchWithCache(ctx, "complete", PartialRefresh(func(context.Context) (string, error) {
+		return "full page", nil
+	}, PartialCache))
+	require.NoError(t, err)
+	assert.False(t, complete.Degraded)
+	assert.NoError(t, complete.Err())
+	assert.NoError(t, Degraded(nil))
+}
@@ -0,0 +1,145 @@
+package echocache
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+const (
+	// defaultStatsKey is the key under which StatsPersister stores the totals when none is configured.
+	defaultStatsKey = "echocache-stats"
+	// defaultStatsInterval is the interval at which StatsPersister writes the totals when none is configured.
+	defaultStatsInterval = time.Minute
+)
+
+// CounterTotals are the cumulative fetch outcomes of a cache.
+type CounterTotals struct {
+	Hits      uint64 `json:"hits"`
+	StaleHits uint64 `json:"staleHits"`
+	Misses    uint64 `json:"misses"`
+	Errors    uint64 `json:"errors"`
+}
+
+// HitRatio returns the fraction of fetches served from the store, stale hits included, or 0 before the first fetch.
+func (t CounterTotals) HitRatio() float64 {
+	return Stats{Hits: t.Hits, StaleHits: t.StaleHits, Misses: t.Misses}.HitRatio()
+}
+
+// add returns the sum of the totals and the counters of the stats.
+func (t CounterTotals) add(s Stats) CounterTotals {
+	return CounterTotals{
+		Hits:      t.Hits + s.Hits,
+		StaleHits: t.StaleHits + s.StaleHits,
+		Misses:    t.Misses + s.Misses,
+		Errors:    t.Errors + s.Errors,
+	}
+}
+
+// PersistedStats is the document written by StatsPersister: the totals of every registered cache accumulated over
+// the lifetime of all the processes that wrote it, the first time it was written and the version of the last writer.
+type PersistedStats struct {
+	Version   string                   `json:"version,omitempty"`
+	Since     time.Time                `json:"since"`
+	UpdatedAt time.Time                `json:"updatedAt"`
+	Caches    map[string]CounterTotals `json:"caches"`
+}
+
+// StatsPersisterConfig configures a StatsPersister. Key is the store key of the document, "echocache-stats" by
+// default, and Interval how often it is written, every minute by default. Version, such as the release of the
+// application, is recorded in the document. Processes sharing a key overwrite each other: give each instance its own
+// key, and include the version in it to keep the trends of successive releases apart.
+type StatsPersisterConfig struct {
+	Key      string
+	Interval time.Duration
+	Version  string
+}
+
+// StatsPersister periodically writes the fetch counters of the caches of a registry to a store, added to the totals
+// found there when it starts, so that long-term cache effectiveness survives restarts.
+type StatsPersister struct {
+	registry *Registry
+	store    store.Cacher[PersistedStats]
+	cfg      StatsPersisterConfig
+	mu       sync.Mutex
+	base     PersistedStats
+	loaded   bool
+}
+
+// NewStatsPersister creates a persister writing the statistics of the registry to s.
+func NewStatsPersister(r *Registry, s store.Cacher[PersistedStats], cfg StatsPersisterConfig) *StatsPersister {
+	if cfg.Key == "" {
+		cfg.Key = defaultStatsKey
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultStatsInterval
+	}
+	return &StatsPersister{registry: r, store: s, cfg: cfg}
+}
+
+// Run loads the persisted totals, then writes the updated totals every interval until ctx is done, and a last time
+// before returning. Write errors are logged and retried at the next interval.
+func (p *StatsPersister) Run(ctx context.Context) error {
+	if err := p.load(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return p.Flush(context.WithoutCancel(ctx))
+		case <-ticker.C:
+			if err := p.Flush(ctx); err != nil {
+				slog.Warn("Cannot persist cache statistics", slog.String("error", err.Error()), slog.String("cacheKey", p.cfg.Key))
+			}
+		}
+	}
+}
+
+// Flush writes the persisted totals plus the counters of this process, loading the totals first if needed.
+func (p *StatsPersister) Flush(ctx context.Context) error {
+	if err := p.load(ctx); err != nil {
+		return err
+	}
+	return p.store.Set(ctx, p.cfg.Key, p.Totals())
+}
+
+// Totals returns the persisted totals loaded at start plus the counters of this process.
+func (p *StatsPersister) Totals() PersistedStats {
+	p.mu.Lock()
+	totals := p.base
+	totals.Caches = maps.Clone(p.base.Caches)
+	p.mu.Unlock()
+	if totals.Caches == nil {
+		totals.Caches = make(map[string]CounterTotals)
+	}
+	for name, stats := range p.registry.Stats() {
+		totals.Caches[name] = totals.Caches[name].add(stats)
+	}
+	totals.Version = p.cfg.Version
+	totals.UpdatedAt = time.Now().UTC()
+	if totals.Since.IsZero() {
+		totals.Since = totals.UpdatedAt
+	}
+	return totals
+}
+
+// load reads the persisted totals once. A missing document starts the totals from zero.
+func (p *StatsPersister) load(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.loaded {
+		return nil
+	}
+	base, _, err := p.store.Get(ctx, p.cfg.Key)
+	if err != nil {
+		return err
+	}
+	p.base, p.loaded = base, true
+	return nil
+}
@@ -0,0 +1,57 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStatsPersister verifies that the counters of a restarted process are added to the persisted totals.
+func TestStatsPersister(t *testing.T) {
+	ctx := context.Background()
+	backend := store.NewLRUCache[PersistedStats](10)
+	value := func(ctx context.Context) (string, error) { return "v", nil }
+	run := func(version string, fetches int) PersistedStats {
+		r := NewRegistry()
+		cache := NewEchoCache[string](store.NewLRUCache[string](10))
+		require.NoError(t, RegisterCache(r, "users", cache))
+		for i := 0; i < fetches; i++ {
+			_, _, err := cache.FetchWithCache(ctx, "k", value)
+			require.NoError(t, err)
+		}
+		p := NewStatsPersister(r, backend, StatsPersisterConfig{Version: version})
+		require.NoError(t, p.Flush(ctx))
+		stored, exists, err := backend.Get(ctx, defaultStatsKey)
+		require.NoError(t, err)
+		require.True(t, exists)
+		return stored
+	}
+
+	first := run("v1", 3)
+	assert.Equal(t, CounterTotals{Hits: 2, Misses: 1}, first.Caches["users"])
+	assert.Equal(t, "v1", first.Version)
+
+	second := run("v2", 2)
+	assert.Equal(t, CounterTotals{Hits: 3, Misses: 2}, second.Caches["users"])
+	assert.Equal(t, "v2", second.Version)
+	assert.Equal(t, first.Since, second.Since)
+	assert.InDelta(t, 0.6, second.Caches["users"].HitRatio(), 0.001)
+}
+
+// TestStatsPersister_Interval verifies that totals are written periodically while running.
+func TestStatsPersister_Interval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := store.NewLRUCache[PersistedStats](10)
+	p := NewStatsPersister(NewRegistry(), backend, StatsPersisterConfig{Key: "stats", Interval: 10 * time.Millisecond})
+	go func() { _ = p.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		_, exists, _ := backend.Get(ctx, "stats")
+		return exists
+	}, time.Second, 5*time.Millisecond)
+}
@@ -1,6 +1,54 @@
 package echocache
 
-import "math/rand/v2"
+import (
+	cryptorand "crypto/rand"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	// requestIDLength is the length of the request IDs generated by default.
+	requestIDLength = 10
+	// lockValueLength is the length of the distributed lock values generated by default.
+	lockValueLength = 16
+)
+
+// IDGenerator produces the identifiers used as request IDs and distributed lock values. Identifiers must be unique
+// across concurrent requests and, for lock values, across processes sharing a store.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to the IDGenerator interface, e.g. IDGeneratorFunc(func() string { return ulid.Make().String() }).
+type IDGeneratorFunc func() string
+
+// NewID calls f.
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// CryptoIDs returns a generator of 26-character base32 identifiers drawn from crypto/rand.
+func CryptoIDs() IDGenerator {
+	return IDGeneratorFunc(cryptorand.Text)
+}
+
+// SequentialIDs returns a generator of deterministic identifiers made of prefix and an increasing counter starting
+// at 1, for tests asserting on request IDs.
+func SequentialIDs(prefix string) IDGenerator {
+	var counter atomic.Uint64
+	return IDGeneratorFunc(func() string {
+		return prefix + strconv.FormatUint(counter.Add(1), 10)
+	})
+}
+
+// newID returns an identifier from the generator, or a random string of the given length when it is nil.
+func newID(g IDGenerator, length int) string {
+	if g == nil {
+		return randString(length)
+	}
+	return g.NewID()
+}
 
 // randString generates a random alphanumeric string of the specified length, ensuring the first character is a letter.
 func randString(length int) string {
@@ -0,0 +1,39 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithIDGenerator verifies that request IDs and lock values come from the configured generator.
+func TestWithIDGenerator(t *testing.T) {
+	shared := newSharedLockCache()
+	var events []RefreshEvent
+	cache := NewEchoCache[string](shared,
+		WithIDGenerator(SequentialIDs("id-")),
+		WithDistributedLock(time.Minute, 0),
+		WithRefreshHook(func(e RefreshEvent) { events = append(events, e) }),
+	)
+
+	var lockValue string
+	_, _, err := cache.FetchWithCache(context.Background(), "k", func(ctx context.Context) (string, error) {
+		lockValue = shared.locks["k"]
+		return "value", nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "id-1", events[0].RequestID)
+	assert.Equal(t, "id-2", lockValue)
+}
+
+// TestCryptoIDs verifies that crypto-random identifiers are distinct.
+func TestCryptoIDs(t *testing.T) {
+	ids := CryptoIDs()
+	assert.NotEqual(t, ids.NewID(), ids.NewID())
+	assert.Len(t, ids.NewID(), 26)
+	assert.Len(t, newID(nil, requestIDLength), requestIDLength)
+}
@@ -0,0 +1,156 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ErrNotReconfigurable is returned by Reconfigure when an option that can only be set at construction is changed.
+var ErrNotReconfigurable = errors.New("option cannot be changed after the cache is created")
+
+// Reconfigurable is implemented by caches whose tunables can be changed while they serve requests.
+type Reconfigurable interface {
+	Reconfigure(opts ...Option) error
+}
+
+// WithLazyRefreshTimeout overrides the refresh timeout EchoCacheLazy was created with. It is mostly useful with
+// Reconfigure, to shorten or extend refreshes during an incident.
+func WithLazyRefreshTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.refreshTimeout = d
+	}
+}
+
+// WithLazyRefreshInterval sets the refresh interval used by EchoCacheLazy.FetchWithRefresh, overriding the one given
+// to NewLazyEchoCacheForInterval.
+func WithLazyRefreshInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.refreshInterval = d
+	}
+}
+
+// tunables is the part of the configuration of a cache that Reconfigure replaces atomically.
+type tunables struct {
+	opts     options
+	cooldown *failureTracker
+	budget   *refreshBudget
+}
+
+// tunables returns the runtime settings described by the options. The failure states and refresh durations recorded
+// by prev, when not nil, are carried over.
+func (o options) tunables(prev *tunables) *tunables {
+	t := &tunables{opts: o, cooldown: o.failureTracker(), budget: newRefreshBudget(o.budgetMargin)}
+	if prev == nil {
+		return t
+	}
+	if t.cooldown != nil && prev.cooldown != nil {
+		prev.cooldown.setLimits(o.cooldownBase, o.cooldownMax)
+		t.cooldown = prev.cooldown
+	}
+	if t.budget != nil && prev.budget != nil {
+		t.budget.avg.Store(prev.budget.avg.Load())
+	}
+	return t
+}
+
+// reconfigure applies opts on top of the current options, rejecting changes to settings fixed at construction:
+// the metrics sink, tombstones, ID generator, absence store, event bus, node ID, key statistics, singleflight shards,
+// stale fallback and key function. Unless lazy is set, changes to the settings of the EchoCacheLazy refresh queue are
+// rejected too.
+func (o options) reconfigure(opts []Option, lazy bool) (options, error) {
+	next := o
+	for _, opt := range opts {
+		opt(&next)
+	}
+	if !sameValue(next.metrics, o.metrics) || next.tombstoneTTL != o.tombstoneTTL || !sameValue(next.ids, o.ids) ||
+		!sameValue(next.absenceStore, o.absenceStore) || next.absenceTTL != o.absenceTTL || next.bus != o.bus ||
+		next.busTopic != o.busTopic || next.nodeID != o.nodeID || next.keyStatsTopK != o.keyStatsTopK ||
+		next.keyStatsRate != o.keyStatsRate || next.storeTTLFactor != o.storeTTLFactor || next.sfShards != o.sfShards ||
+		!sameValue(next.staleFallback, o.staleFallback) || next.staleFallbackAge != o.staleFallbackAge ||
+		!sameValue(next.keyFunc, o.keyFunc) {
+		return o, ErrNotReconfigurable
+	}
+	if !lazy && (next.queueSize != o.queueSize || next.refreshWorkers != o.refreshWorkers ||
+		next.refreshTimeout != o.refreshTimeout || next.refreshInterval != o.refreshInterval ||
+		next.queueWaitMax != o.queueWaitMax || next.queueWaitMin != o.queueWaitMin) {
+		return o, ErrNotReconfigurable
+	}
+	return next, nil
+}
+
+// sameValue reports whether a and b hold the same value. Values that are not comparable, such as functions, maps
+// and slices, are compared by address; those without one, such as structs holding a slice, are reported as the same.
+func sameValue(a any, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return va.IsValid() == vb.IsValid()
+	}
+	if va.Type() != vb.Type() {
+		return false
+	}
+	if va.Comparable() {
+		return va.Equal(vb)
+	}
+	switch va.Kind() {
+	case reflect.Func, reflect.Map:
+		return va.Pointer() == vb.Pointer()
+	case reflect.Slice:
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	default:
+		return true
+	}
+}
+
+// Reconfigure changes the tunables of the cache while it serves requests: WithFailureCooldown, WithDistributedLock,
+// WithRefreshHook and WithDeadlineAwareRefresh. Fetches already running keep the settings they started with, and the
+// cooldown of failing keys is preserved. Options that can only be set at construction, and those of the EchoCacheLazy
+// refresh queue, which EchoCache does not use, return ErrNotReconfigurable, leaving the configuration unchanged.
+func (ec *EchoCache[T]) Reconfigure(opts ...Option) error {
+	ec.reconfMu.Lock()
+	defer ec.reconfMu.Unlock()
+	prev := ec.settings.Load()
+	next, err := prev.opts.reconfigure(opts, false)
+	if err != nil {
+		return err
+	}
+	ec.settings.Store(next.tunables(prev))
+	return nil
+}
+
+// Reconfigure changes the tunables of the cache while it serves requests. On top of those of EchoCache.Reconfigure,
+// it accepts WithLazyRefreshTimeout, WithLazyRefreshInterval, WithStalenessDeadline, WithQueueSize and
+// WithRefreshWorkers. The queue cannot grow past the capacity it was created with, so a larger size only lifts a
+// previous reduction. Workers removed by a smaller count exit once their current refresh completes.
+func (ec *EchoCacheLazy[T]) Reconfigure(opts ...Option) error {
+	ec.reconfMu.Lock()
+	defer ec.reconfMu.Unlock()
+	prev := ec.settings.Load()
+	next, err := prev.opts.reconfigure(opts, true)
+	if err != nil {
+		return err
+	}
+	ec.settings.Store(next.tunables(prev))
+	ec.scaleWorkers(next.refreshWorkers)
+	return nil
+}
+
+// ReconfigureFrom applies every set of options received on updates to target until ctx is done or updates is closed,
+// so that a watched configuration source, such as a file watcher or a key of a configuration service, can retune
+// caches without a redeploy. Errors are passed to onError when it is not nil.
+func ReconfigureFrom(ctx context.Context, target Reconfigurable, updates <-chan []Option, onError func(error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case opts, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := target.Reconfigure(opts...); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
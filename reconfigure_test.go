@@ -0,0 +1,145 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_Reconfigure verifies that the failure cooldown can be enabled and disabled at runtime and that
+// options fixed at construction are rejected.
+func TestEchoCache_Reconfigure(t *testing.T) {
+	ctx := context.Background()
+	cache := NewEchoCache[string](store.NewLRUCache[string](10), WithIDGenerator(IDGeneratorFunc(func() string { return "id" })))
+	calls := 0
+	failing := func(ctx context.Context) (string, error) {
+		calls++
+		return "", errors.New("boom")
+	}
+
+	require.NoError(t, cache.Reconfigure(WithFailureCooldown(time.Minute, time.Hour)))
+	_, _, err := cache.FetchWithCache(ctx, "k", failing)
+	assert.EqualError(t, err, "boom")
+	_, _, err = cache.FetchWithCache(ctx, "k", failing)
+	assert.ErrorIs(t, err, ErrRefreshCooldown)
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, cache.Reconfigure(WithFailureCooldown(time.Minute, 2*time.Hour)))
+	_, _, err = cache.FetchWithCache(ctx, "k", failing)
+	assert.ErrorIs(t, err, ErrRefreshCooldown, "the cooldown of failing keys survives a reconfiguration")
+
+	require.NoError(t, cache.Reconfigure(WithFailureCooldown(0, 0)))
+	_, _, err = cache.FetchWithCache(ctx, "k", failing)
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 2, calls)
+
+	assert.ErrorIs(t, cache.Reconfigure(WithNodeID("node-1")), ErrNotReconfigurable)
+	assert.ErrorIs(t, cache.Reconfigure(WithIDGenerator(SequentialIDs("x"))), ErrNotReconfigurable)
+	for _, opt := range []Option{
+		WithQueueSize(5), WithRefreshWorkers(4), WithLazyRefreshTimeout(time.Second),
+		WithLazyRefreshInterval(time.Minute), WithStalenessDeadline(time.Minute, time.Second),
+	} {
+		assert.ErrorIs(t, cache.Reconfigure(opt), ErrNotReconfigurable)
+	}
+}
+
+// TestEchoCacheLazy_ReconfigureWorkers verifies that the number of refresh workers can be changed at runtime.
+func TestEchoCacheLazy_ReconfigureWorkers(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Minute)
+	defer cache.ShutdownLazyRefresh()
+
+	require.NoError(t, cache.Reconfigure(WithRefreshWorkers(2)))
+	running := make(chan struct{}, 2)
+	release := make(chan struct{})
+	refresh := func(ctx context.Context) (string, error) {
+		running <- struct{}{}
+		<-release
+		return "fresh", nil
+	}
+	for _, key := range []string{"a", "b"} {
+		require.NoError(t, swr.Set(ctx, key, store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-time.Hour)}))
+		_, _, err := cache.FetchWithLazyRefresh(ctx, key, refresh, time.Minute)
+		require.NoError(t, err)
+	}
+	for range 2 {
+		select {
+		case <-running:
+		case <-time.After(time.Second):
+			t.Fatal("the refreshes did not run concurrently")
+		}
+	}
+	close(release)
+
+	require.NoError(t, cache.Reconfigure(WithRefreshWorkers(1)))
+	cache.workersMu.Lock()
+	defer cache.workersMu.Unlock()
+	assert.Len(t, cache.workers, 1)
+}
+
+// TestSameValue verifies that values that are not comparable are compared by address when they have one.
+func TestSameValue(t *testing.T) {
+	m := map[string]int{"a": 1}
+	s := []int{1, 2}
+	assert.True(t, sameValue(m, m))
+	assert.False(t, sameValue(m, map[string]int{"a": 1}))
+	assert.True(t, sameValue(s, s))
+	assert.False(t, sameValue(s, s[:1]))
+	assert.False(t, sameValue(s, []int{1, 2}))
+	assert.True(t, sameValue(struct{ s []int }{s}, struct{ s []int }{[]int{3}}))
+	assert.True(t, sameValue(nil, nil))
+	assert.False(t, sameValue(m, nil))
+}
+
+// TestEchoCacheLazy_Reconfigure verifies that the refresh interval, refresh timeout and queue size can be changed at runtime.
+func TestEchoCacheLazy_Reconfigure(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Second, WithQueueSize(10))
+	defer cache.ShutdownLazyRefresh()
+	value := func(ctx context.Context) (string, error) { return "v", nil }
+
+	_, _, err := cache.FetchWithRefresh(ctx, "k", value)
+	assert.Error(t, err)
+	require.NoError(t, cache.Reconfigure(WithLazyRefreshInterval(time.Minute)))
+	v, _, err := cache.FetchWithRefresh(ctx, "k", value)
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+
+	require.NoError(t, cache.Reconfigure(WithLazyRefreshTimeout(10*time.Millisecond)))
+	_, _, err = cache.FetchWithLazyRefresh(ctx, "slow", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}, time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, cache.Reconfigure(WithQueueSize(1)))
+	assert.Equal(t, 1, cache.settings.Load().opts.queueSize)
+	assert.Equal(t, 10, cache.Stats().QueueCapacity)
+}
+
+// TestReconfigureFrom verifies that option sets received from a watched source are applied in order.
+func TestReconfigureFrom(t *testing.T) {
+	cache := NewEchoCache[string](store.NewLRUCache[string](10))
+	updates := make(chan []Option)
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		ReconfigureFrom(context.Background(), cache, updates, func(err error) { errs = append(errs, err) })
+		close(done)
+	}()
+
+	updates <- []Option{WithDistributedLock(time.Second, 10*time.Millisecond)}
+	updates <- []Option{WithNodeID("node-1")}
+	close(updates)
+	<-done
+
+	assert.Equal(t, time.Second, cache.settings.Load().opts.lockTTL)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrNotReconfigurable)
+}
@@ -0,0 +1,85 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// refreshBudgetWeight is the weight of the latest sample in the moving average of refresh durations.
+const refreshBudgetWeight = 0.2
+
+// ErrBudgetExceeded is returned when the deadline of the caller's context is too close to plausibly compute a
+// missing value, judging from the recent refresh durations, so the computation is not started.
+var ErrBudgetExceeded = errors.New("context deadline too short to refresh the value")
+
+// WithDeadlineAwareRefresh skips the computation of a missing value when the deadline of the caller's context is
+// closer than margin times the moving average of the recent refresh durations, returning ErrBudgetExceeded at once
+// instead of starting a computation that would be abandoned. Stale values of EchoCacheLazy are still served, their
+// refresh running in the background. A non-positive margin defaults to 1. No computation is skipped before the first
+// successful refresh, nor for contexts without deadline.
+func WithDeadlineAwareRefresh(margin float64) Option {
+	if margin <= 0 {
+		margin = 1
+	}
+	return func(o *options) {
+		o.budgetMargin = margin
+	}
+}
+
+// refreshBudget tracks the exponentially weighted moving average of refresh durations.
+// A nil *refreshBudget is valid and never skips a refresh.
+type refreshBudget struct {
+	margin float64
+	avg    atomic.Uint64
+}
+
+// newRefreshBudget returns a budget with the given margin, or nil when margin is not positive.
+func newRefreshBudget(margin float64) *refreshBudget {
+	if margin <= 0 {
+		return nil
+	}
+	return &refreshBudget{margin: margin}
+}
+
+// observe adds the duration of a successful refresh to the moving average.
+func (b *refreshBudget) observe(d time.Duration) {
+	if b == nil {
+		return
+	}
+	for {
+		old := b.avg.Load()
+		next := float64(d)
+		if old != 0 {
+			prev := math.Float64frombits(old)
+			next = prev + refreshBudgetWeight*(float64(d)-prev)
+		}
+		if b.avg.CompareAndSwap(old, math.Float64bits(max(next, 1))) {
+			return
+		}
+	}
+}
+
+// average returns the moving average of refresh durations, or zero before the first observation.
+func (b *refreshBudget) average() time.Duration {
+	if b == nil {
+		return 0
+	}
+	bits := b.avg.Load()
+	if bits == 0 {
+		return 0
+	}
+	return time.Duration(math.Float64frombits(bits))
+}
+
+// exceeded reports whether the deadline of ctx leaves less than the expected refresh duration times the margin.
+func (b *refreshBudget) exceeded(ctx context.Context) bool {
+	avg := b.average()
+	if avg == 0 {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < time.Duration(float64(avg)*b.margin)
+}
@@ -0,0 +1,89 @@
+package echocache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_DeadlineAwareRefresh verifies that a miss is not computed when the deadline is shorter than the
+// usual refresh duration.
+func TestEchoCache_DeadlineAwareRefresh(t *testing.T) {
+	ec := NewEchoCache[string](store.NewLRUCache[string](10), WithDeadlineAwareRefresh(1))
+	var calls atomic.Int32
+	refresh := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return "v", nil
+	}
+
+	short := func() context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		t.Cleanup(cancel)
+		return ctx
+	}
+	_, _, err := ec.FetchWithCache(short(), "a", func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		return "v", nil
+	})
+	require.NoError(t, err, "no refresh has been observed yet")
+
+	_, _, err = ec.FetchWithCache(context.Background(), "b", refresh)
+	require.NoError(t, err)
+	_, _, err = ec.FetchWithCache(context.Background(), "c", refresh)
+	require.NoError(t, err)
+
+	_, _, err = ec.FetchWithCache(short(), "d", refresh)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, uint64(1), ec.Stats().Errors)
+
+	value, exists, err := ec.FetchWithCache(short(), "b", refresh)
+	require.NoError(t, err, "cached values are served whatever the deadline")
+	assert.True(t, exists)
+	assert.Equal(t, "v", value)
+
+	long, cancelLong := context.WithTimeout(context.Background(), time.Second)
+	defer cancelLong()
+	_, _, err = ec.FetchWithCache(long, "d", refresh)
+	assert.NoError(t, err)
+}
+
+// TestEchoCacheLazy_DeadlineAwareRefresh verifies that the lazy cache serves stale values and skips doomed misses.
+func TestEchoCacheLazy_DeadlineAwareRefresh(t *testing.T) {
+	ec := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Second, WithDeadlineAwareRefresh(2))
+	defer ec.ShutdownLazyRefresh()
+	refresh := func(ctx context.Context) (string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return "v", nil
+	}
+	_, _, err := ec.FetchWithLazyRefresh(context.Background(), "a", refresh, time.Nanosecond)
+	require.NoError(t, err)
+
+	short, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	value, exists, err := ec.FetchWithLazyRefresh(short, "a", refresh, time.Nanosecond)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "v", value)
+	_, _, err = ec.FetchWithLazyRefresh(short, "b", refresh, time.Minute)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+}
+
+// TestRefreshBudget_MovingAverage verifies the weighting of the moving average of refresh durations.
+func TestRefreshBudget_MovingAverage(t *testing.T) {
+	var b *refreshBudget
+	b.observe(time.Second)
+	assert.False(t, b.exceeded(context.Background()))
+
+	b = newRefreshBudget(1)
+	b.observe(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, b.average())
+	b.observe(200 * time.Millisecond)
+	assert.Equal(t, 120*time.Millisecond, b.average())
+}
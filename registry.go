@@ -0,0 +1,231 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// ErrDuplicateCache is returned when a cache is registered under a name already in use.
+var ErrDuplicateCache = errors.New("cache already registered")
+
+// Handle is a type-erased view of a cache, letting a Registry manage caches of different value types together.
+// Cache returns the underlying cache, such as an *EchoCache[T], for typed lookups.
+type Handle interface {
+	Stats() Stats
+	Clear(ctx context.Context) error
+	Shutdown()
+	Cache() any
+}
+
+// KeyInspector is implemented by handles able to report the metadata of a single key, such as the handles returned
+// by HandleOf and LazyHandleOf.
+type KeyInspector interface {
+	Inspect(ctx context.Context, key string) (store.KeyInfo, error)
+}
+
+// Resizable is implemented by handles able to change the capacity of their in-memory store, such as the handles
+// returned by HandleOf and LazyHandleOf.
+type Resizable interface {
+	Resize(size int) (int, error)
+}
+
+// cacheHandle is a Handle built from plain functions.
+type cacheHandle struct {
+	cache    any
+	stats    func() Stats
+	clear    func(ctx context.Context) error
+	local    func(ctx context.Context) error
+	drop     func(ctx context.Context, key string) error
+	evict    func(ctx context.Context, key string) error
+	inspect  func(ctx context.Context, key string) (store.KeyInfo, error)
+	resize   func(size int) (int, error)
+	shutdown func()
+	once     sync.Once
+}
+
+// Stats returns the statistics of the underlying cache.
+func (h *cacheHandle) Stats() Stats {
+	return h.stats()
+}
+
+// Clear removes every entry of the underlying cache.
+func (h *cacheHandle) Clear(ctx context.Context) error {
+	return h.clear(ctx)
+}
+
+// ClearLocal removes the entries the underlying cache holds in process memory.
+func (h *cacheHandle) ClearLocal(ctx context.Context) error {
+	return h.local(ctx)
+}
+
+// Invalidate removes the key from the underlying cache and writes a tombstone for it.
+func (h *cacheHandle) Invalidate(ctx context.Context, key string) error {
+	return h.drop(ctx, key)
+}
+
+// InvalidateLocal removes the key from the entries the underlying cache holds in process memory.
+func (h *cacheHandle) InvalidateLocal(ctx context.Context, key string) error {
+	return h.evict(ctx, key)
+}
+
+// Inspect reports the metadata of the key in the underlying cache.
+func (h *cacheHandle) Inspect(ctx context.Context, key string) (store.KeyInfo, error) {
+	return h.inspect(ctx, key)
+}
+
+// Resize changes the capacity of the store of the underlying cache.
+func (h *cacheHandle) Resize(size int) (int, error) {
+	return h.resize(size)
+}
+
+// Shutdown stops the background work of the underlying cache. Only the first call has an effect.
+func (h *cacheHandle) Shutdown() {
+	h.once.Do(func() {
+		if h.shutdown != nil {
+			h.shutdown()
+		}
+	})
+}
+
+// Cache returns the underlying cache.
+func (h *cacheHandle) Cache() any {
+	return h.cache
+}
+
+// HandleOf returns a Handle for an EchoCache. Shutting it down is a no-op since EchoCache runs no background work.
+func HandleOf[T any](ec *EchoCache[T]) Handle {
+	return &cacheHandle{cache: ec, stats: ec.Stats, clear: ec.Clear, local: ec.ClearLocal, drop: ec.Invalidate, evict: ec.InvalidateLocal, inspect: ec.Inspect, resize: ec.Resize}
+}
+
+// LazyHandleOf returns a Handle for an EchoCacheLazy. Shutting it down stops the background refresh worker.
+func LazyHandleOf[T any](ec *EchoCacheLazy[T]) Handle {
+	return &cacheHandle{cache: ec, stats: ec.Stats, clear: ec.Clear, local: ec.ClearLocal, drop: ec.Invalidate, evict: ec.InvalidateLocal, inspect: ec.Inspect, resize: ec.Resize, shutdown: ec.ShutdownLazyRefresh}
+}
+
+// Registry holds named caches, possibly of different value types, so that a service can report their statistics,
+// clear them and shut them down in bulk. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	handles map[string]Handle
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{handles: make(map[string]Handle)}
+}
+
+// Register adds the handle under the given name. Returns ErrDuplicateCache if the name is already in use.
+func (r *Registry) Register(name string, h Handle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handles[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateCache, name)
+	}
+	r.handles[name] = h
+	return nil
+}
+
+// Unregister removes the cache registered under the given name, without shutting it down.
+// Returns true when a cache was removed.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.handles[name]
+	delete(r.handles, name)
+	return ok
+}
+
+// Handle returns the handle registered under the given name.
+func (r *Registry) Handle(name string) (Handle, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.handles[name]
+	return h, ok
+}
+
+// Names returns the names of the registered caches, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.handles))
+	for name := range r.handles {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns the statistics of every registered cache, by name.
+func (r *Registry) Stats() map[string]Stats {
+	stats := make(map[string]Stats)
+	for name, h := range r.snapshot() {
+		stats[name] = h.Stats()
+	}
+	return stats
+}
+
+// ClearAll clears every registered cache and returns the joined errors, each prefixed with the cache name.
+func (r *Registry) ClearAll(ctx context.Context) error {
+	var errs []error
+	for name, h := range r.snapshot() {
+		if err := h.Clear(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown shuts down every registered cache. The caches stay registered.
+func (r *Registry) Shutdown() {
+	for _, h := range r.snapshot() {
+		h.Shutdown()
+	}
+}
+
+// snapshot returns a copy of the registered handles, so that they can be used without holding the lock.
+func (r *Registry) snapshot() map[string]Handle {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handles := make(map[string]Handle, len(r.handles))
+	for name, h := range r.handles {
+		handles[name] = h
+	}
+	return handles
+}
+
+// RegisterCache registers an EchoCache under the given name.
+func RegisterCache[T any](r *Registry, name string, ec *EchoCache[T]) error {
+	return r.Register(name, HandleOf(ec))
+}
+
+// RegisterLazy registers an EchoCacheLazy under the given name.
+func RegisterLazy[T any](r *Registry, name string, ec *EchoCacheLazy[T]) error {
+	return r.Register(name, LazyHandleOf(ec))
+}
+
+// Lookup returns the EchoCache registered under the given name. The boolean result is false when no cache is
+// registered under that name or when it is not an *EchoCache[T].
+func Lookup[T any](r *Registry, name string) (*EchoCache[T], bool) {
+	h, ok := r.Handle(name)
+	if !ok {
+		return nil, false
+	}
+	ec, ok := h.Cache().(*EchoCache[T])
+	return ec, ok
+}
+
+// LookupLazy returns the EchoCacheLazy registered under the given name. The boolean result is false when no cache is
+// registered under that name or when it is not an *EchoCacheLazy[T].
+func LookupLazy[T any](r *Registry, name string) (*EchoCacheLazy[T], bool) {
+	h, ok := r.Handle(name)
+	if !ok {
+		return nil, false
+	}
+	ec, ok := h.Cache().(*EchoCacheLazy[T])
+	return ec, ok
+}
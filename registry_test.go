@@ -0,0 +1,135 @@
+package echocache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegistry verifies that caches of different value types are registered, looked up, counted and cleared together.
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	users := NewEchoCache[string](store.NewLRUCache[string](10))
+	scores := NewLazyEchoCache[int](store.NewStaleWhileRevalidateLRUCache[int](10), time.Second)
+	require.NoError(t, RegisterCache(r, "users", users))
+	require.NoError(t, RegisterLazy(r, "scores", scores))
+	assert.ErrorIs(t, RegisterCache(r, "users", users), ErrDuplicateCache)
+	assert.Equal(t, []string{"scores", "users"}, r.Names())
+
+	for i := 0; i < 2; i++ {
+		_, _, err := users.FetchWithCache(ctx, "u", func(ctx context.Context) (string, error) {
+			return "alice", nil
+		})
+		require.NoError(t, err)
+	}
+	stats := r.Stats()
+	assert.Equal(t, Stats{Hits: 1, Misses: 1, Size: 1}, stats["users"])
+	assert.InDelta(t, 0.5, stats["users"].HitRatio(), 0.001)
+
+	typed, ok := Lookup[string](r, "users")
+	require.True(t, ok)
+	assert.Same(t, users, typed)
+	_, ok = Lookup[int](r, "users")
+	assert.False(t, ok, "value type must match")
+	_, ok = LookupLazy[int](r, "scores")
+	assert.True(t, ok)
+
+	require.NoError(t, r.ClearAll(ctx))
+	assert.Equal(t, 0, r.Stats()["users"].Size)
+
+	r.Shutdown()
+	r.Shutdown()
+	assert.True(t, r.Unregister("scores"))
+	assert.False(t, r.Unregister("scores"))
+}
+
+// TestRegistry_AdminHandler verifies the statistics and clear endpoints of the admin handler.
+func TestRegistry_AdminHandler(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	users := NewEchoCache[string](store.NewLRUCache[string](10))
+	require.NoError(t, RegisterCache(r, "users", users))
+	require.NoError(t, users.store.Set(ctx, "u", "alice"))
+	handler := r.AdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/caches", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var all map[string]Stats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &all))
+	assert.Equal(t, 1, all["users"].Size)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/caches/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/caches/users/clear", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, 0, users.Stats().Size)
+}
+
+// TestRegistry_AdminHandlerKey verifies the per-key drill-down of the admin handler.
+func TestRegistry_AdminHandlerKey(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	profiles := NewLazyEchoCache[string](store.NewStaleWhileRevalidateLRUCache[string](10), time.Second, WithNodeID("node-a"))
+	defer profiles.ShutdownLazyRefresh()
+	require.NoError(t, RegisterLazy(r, "profiles", profiles))
+	_, _, err := profiles.FetchWithLazyRefresh(ctx, "user:1", func(ctx context.Context) (string, error) {
+		return "alice", nil
+	}, time.Minute)
+	require.NoError(t, err)
+	handler := r.AdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/caches/profiles/keys/user:1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var info store.KeyInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.True(t, info.Exists)
+	assert.Equal(t, "node-a", info.Producer)
+	assert.NotEmpty(t, info.Age)
+	assert.False(t, info.CreatedAt.IsZero())
+	assert.Positive(t, info.Size)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/caches/profiles/keys/user:2", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestRegistry_AdminHandlerResize verifies that the admin handler resizes in-memory caches and rejects other stores.
+func TestRegistry_AdminHandlerResize(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	users := NewEchoCache[string](store.NewLRUCache[string](10))
+	require.NoError(t, RegisterCache(r, "users", users))
+	require.NoError(t, users.BulkSet(ctx, map[string]string{"a": "1", "b": "2", "c": "3"}))
+	require.NoError(t, RegisterCache(r, "single", NewEchoCache[string](store.NewSingleCache[string](time.Minute))))
+	handler := r.AdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/caches/users/resize?size=1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"size":1,"evicted":2}`, rec.Body.String())
+	assert.Equal(t, 1, users.Stats().Size)
+
+	for target, code := range map[string]int{
+		"/caches/users/resize?size=0":   http.StatusBadRequest,
+		"/caches/users/resize?size=x":   http.StatusBadRequest,
+		"/caches/single/resize?size=10": http.StatusNotImplemented,
+		"/caches/missing/resize?size=1": http.StatusNotFound,
+	} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		assert.Equal(t, code, rec.Code, target)
+	}
+}
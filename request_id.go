@@ -0,0 +1,41 @@
+package echocache
+
+import (
+	"context"
+	"time"
+)
+
+// requestIDKey is the context key under which the caller's request ID is stored.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context carrying the given request ID. Fetches made with it log the ID, report it to
+// refresh hooks and propagate it to the context of the refresh functions, including background refreshes, so that
+// their logs can be correlated with the originating request.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, or an empty string if there is none.
+// Refresh functions can use it to tag their own logs.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// correlationID returns the request ID carried by the context, falling back to the given generated one.
+func correlationID(ctx context.Context, generated string) string {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return id
+	}
+	return generated
+}
+
+// RefreshEvent describes a completed computation of a cache value, reported to the hook set with WithRefreshHook.
+// Background is true for refreshes run by the lazy refresh queue.
+type RefreshEvent struct {
+	Key        string
+	RequestID  string
+	Background bool
+	Duration   time.Duration
+	Err        error
+}
@@ -0,0 +1,80 @@
+package echocache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestIDFromContext verifies that request IDs round-trip through the context.
+func TestRequestIDFromContext(t *testing.T) {
+	assert.Empty(t, RequestIDFromContext(context.Background()))
+	assert.Equal(t, "req-1", RequestIDFromContext(ContextWithRequestID(context.Background(), "req-1")))
+}
+
+// TestEchoCache_RequestIDPropagation verifies that the caller's request ID reaches the refresh function and the hook.
+func TestEchoCache_RequestIDPropagation(t *testing.T) {
+	var events []RefreshEvent
+	cache := NewEchoCache[string](store.NewLRUCache[string](10), WithRefreshHook(func(e RefreshEvent) {
+		events = append(events, e)
+	}))
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+
+	value, _, err := cache.FetchWithCache(ctx, "k", func(ctx context.Context) (string, error) {
+		return RequestIDFromContext(ctx), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "req-1", value)
+	require.Len(t, events, 1)
+	assert.Equal(t, "k", events[0].Key)
+	assert.Equal(t, "req-1", events[0].RequestID)
+	assert.False(t, events[0].Background)
+	assert.NoError(t, events[0].Err)
+
+	_, _, err = cache.FetchWithCache(context.Background(), "other", func(ctx context.Context) (string, error) {
+		assert.NotEmpty(t, RequestIDFromContext(ctx), "a request ID is generated when the caller has none")
+		return "v", nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+// TestEchoCacheLazy_RequestIDPropagation verifies that background refreshes carry the request ID of the caller that scheduled them.
+func TestEchoCacheLazy_RequestIDPropagation(t *testing.T) {
+	var mu sync.Mutex
+	var events []RefreshEvent
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second, WithRefreshHook(func(e RefreshEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}))
+	defer cache.ShutdownLazyRefresh()
+	ctx := ContextWithRequestID(context.Background(), "req-2")
+	require.NoError(t, swr.Set(ctx, "k", store.StaleValue[string]{Value: "stale", CreatedAt: time.Now().Add(-time.Hour)}))
+
+	seen := make(chan string, 1)
+	_, _, notify, err := cache.FetchWithLazyRefreshNotify(ctx, "k", func(ctx context.Context) (string, error) {
+		seen <- RequestIDFromContext(ctx)
+		return "fresh", nil
+	}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, notify)
+
+	select {
+	case <-notify:
+	case <-time.After(time.Second):
+		t.Fatal("background refresh did not complete")
+	}
+	assert.Equal(t, "req-2", <-seen)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 1)
+	assert.Equal(t, "req-2", events[0].RequestID)
+	assert.True(t, events[0].Background)
+}
@@ -0,0 +1,46 @@
+package echocache
+
+import (
+	"hash/maphash"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultSingleflightShards is the number of singleflight groups a cache spreads its computations over.
+const defaultSingleflightShards = 32
+
+// WithSingleflightShards sets the number of singleflight groups the computations of the cache are spread over by key
+// hash. Each group serializes its calls on a single mutex, which becomes a contention point above about a million
+// lookups per second; more shards remove it at the cost of a hash per miss. Non-positive values keep the default of 32.
+func WithSingleflightShards(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.sfShards = n
+		}
+	}
+}
+
+// shardedGroup deduplicates concurrent calls per key like singleflight.Group, spreading the keys over several
+// groups so that calls for different keys rarely contend on the same mutex.
+type shardedGroup struct {
+	seed   maphash.Seed
+	shards []singleflight.Group
+}
+
+// newShardedGroup creates a group with n shards, at least one.
+func newShardedGroup(n int) *shardedGroup {
+	return &shardedGroup{seed: maphash.MakeSeed(), shards: make([]singleflight.Group, max(n, 1))}
+}
+
+// Do executes fn once for concurrent calls with the same key, like singleflight.Group.Do.
+func (g *shardedGroup) Do(key string, fn func() (any, error)) (any, error, bool) {
+	return g.shard(key).Do(key, fn)
+}
+
+// shard returns the group responsible for the key.
+func (g *shardedGroup) shard(key string) *singleflight.Group {
+	if len(g.shards) == 1 {
+		return &g.shards[0]
+	}
+	return &g.shards[maphash.String(g.seed, key)%uint64(len(g.shards))]
+}
@@ -0,0 +1,60 @@
+package echocache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShardedGroup verifies that concurrent calls are deduplicated per key whatever the number of shards.
+func TestShardedGroup(t *testing.T) {
+	for _, shards := range []int{0, 1, 8} {
+		t.Run(fmt.Sprint(shards), func(t *testing.T) {
+			g := newShardedGroup(shards)
+			var calls atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					key := fmt.Sprintf("key-%d", i%2)
+					value, err, _ := g.Do(key, func() (any, error) {
+						calls.Add(1)
+						time.Sleep(20 * time.Millisecond)
+						return key, nil
+					})
+					assert.NoError(t, err)
+					assert.Equal(t, key, value)
+				}()
+			}
+			wg.Wait()
+			assert.LessOrEqual(t, calls.Load(), int32(4))
+			assert.GreaterOrEqual(t, calls.Load(), int32(2))
+		})
+	}
+}
+
+// BenchmarkShardedGroup measures concurrent calls on distinct keys with a single group and with sharding.
+func BenchmarkShardedGroup(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	for _, shards := range []int{1, defaultSingleflightShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			g := newShardedGroup(shards)
+			var next atomic.Uint64
+			b.RunParallel(func(pb *testing.PB) {
+				i := next.Add(1)
+				for pb.Next() {
+					i++
+					_, _, _ = g.Do(keys[i%uint64(len(keys))], func() (any, error) { return nil, nil })
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,283 @@
+// Package soak hammers a cache with concurrent mixed hit/miss traffic and checks its invariants: no concurrent or
+// duplicate computations of the same key beyond the expected bounds, and no value served older than a maximum age.
+// It is meant to run in the CI of downstream applications against their own backends and options.
+package soak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache"
+	"github.com/logocomune/echocache/store"
+)
+
+// Value is the value cached during a soak run: the key it was computed for, the invalidation generation of the key
+// when the computation started and the time the computation completed.
+type Value struct {
+	Key        string
+	Generation uint64
+	ComputedAt time.Time
+}
+
+// Config describes a soak run. Exactly one of Store, used through an EchoCache, and LazyStore, used through an
+// EchoCacheLazy refreshing values older than RefreshInterval, must be set; the store should not expire entries
+// during the run, or expirations are reported as duplicate computations. Operations fetches of Keys keys are spread
+// over Concurrency goroutines; a fraction InvalidateRatio of them invalidates the key instead, which requires a
+// store implementing store.Deleter. Each computation lasts LoaderLatency.
+//
+// The invariants are: at most MaxConcurrentComputations concurrent computations of the same key (1 by default), at
+// most MaxDuplicateComputations computations of a key whose value should still have been cached, and, when MaxAge
+// is positive, no value served older than MaxAge.
+type Config struct {
+	Store           store.Cacher[Value]
+	LazyStore       store.StaleWhileRevalidateCache[Value]
+	RefreshInterval time.Duration
+	Options         []echocache.Option
+
+	Keys            int
+	Operations      int
+	Concurrency     int
+	InvalidateRatio float64
+	LoaderLatency   time.Duration
+	Seed            uint64
+
+	MaxConcurrentComputations int
+	MaxDuplicateComputations  int
+	MaxAge                    time.Duration
+}
+
+// Report summarizes a soak run. StaleAfterInvalidate counts fetches that returned a value computed before an
+// invalidation of the key completed before the fetch started, which happens when a refresh racing with the
+// invalidation stores the old value again (see echocache.WithTombstones).
+type Report struct {
+	Operations                int
+	Invalidations             int
+	Computations              int
+	DuplicateComputations     int
+	MaxConcurrentComputations int
+	StaleAfterInvalidate      int
+	MaxServedAge              time.Duration
+	Errors                    int
+	Elapsed                   time.Duration
+	Violations                []string
+}
+
+// Err returns the violated invariants joined in a single error, or nil.
+func (r Report) Err() error {
+	if len(r.Violations) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		errs = append(errs, errors.New(v))
+	}
+	return errors.Join(errs...)
+}
+
+// keyState tracks the computations and invalidations of a key during a run.
+type keyState struct {
+	mu             sync.Mutex
+	generation     uint64
+	running        int
+	lastDone       time.Time
+	doneGeneration uint64
+}
+
+// runner holds the state of a soak run.
+type runner struct {
+	cfg    Config
+	states []keyState
+
+	computations  atomic.Int64
+	duplicates    atomic.Int64
+	maxConcurrent atomic.Int64
+	invalidations atomic.Int64
+	staleReads    atomic.Int64
+	maxAge        atomic.Int64
+	errors        atomic.Int64
+}
+
+// Run executes the soak run described by cfg and returns its report. The error is about the configuration or the
+// context; violated invariants are listed in the report, see Report.Err.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if (cfg.Store == nil) == (cfg.LazyStore == nil) {
+		return Report{}, errors.New("soak: exactly one of Store and LazyStore must be set")
+	}
+	if cfg.LazyStore != nil && cfg.RefreshInterval <= 0 {
+		return Report{}, errors.New("soak: LazyStore requires a positive RefreshInterval")
+	}
+	if cfg.Keys <= 0 {
+		cfg.Keys = 100
+	}
+	if cfg.Operations <= 0 {
+		cfg.Operations = 10000
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 8
+	}
+	if cfg.MaxConcurrentComputations <= 0 {
+		cfg.MaxConcurrentComputations = 1
+	}
+
+	r := &runner{cfg: cfg, states: make([]keyState, cfg.Keys)}
+	fetch, invalidate, shutdown := r.cacheFuncs()
+	defer shutdown()
+
+	start := time.Now()
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for worker := range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(cfg.Seed, uint64(worker)))
+			for next.Add(1) <= int64(cfg.Operations) && ctx.Err() == nil {
+				index := rng.IntN(cfg.Keys)
+				if rng.Float64() < cfg.InvalidateRatio {
+					r.invalidate(ctx, index, invalidate)
+					continue
+				}
+				r.fetch(ctx, index, fetch)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return Report{}, err
+	}
+	return r.report(time.Since(start)), nil
+}
+
+// Check runs the soak described by cfg and reports every violated invariant as an error of t.
+func Check(t testing.TB, ctx context.Context, cfg Config) Report {
+	t.Helper()
+	report, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatalf("soak: %v", err)
+	}
+	for _, violation := range report.Violations {
+		t.Errorf("soak: %s", violation)
+	}
+	return report
+}
+
+// cacheFuncs builds the cache under test and returns its fetch, invalidate and shutdown operations.
+func (r *runner) cacheFuncs() (func(ctx context.Context, key string, fn store.RefreshFunc[Value]) (Value, error), func(ctx context.Context, key string) error, func()) {
+	if r.cfg.Store != nil {
+		ec := echocache.NewEchoCache[Value](r.cfg.Store, r.cfg.Options...)
+		return func(ctx context.Context, key string, fn store.RefreshFunc[Value]) (Value, error) {
+			v, _, err := ec.FetchWithCache(ctx, key, fn)
+			return v, err
+		}, ec.Invalidate, func() {}
+	}
+	ec := echocache.NewLazyEchoCache[Value](r.cfg.LazyStore, time.Minute, r.cfg.Options...)
+	return func(ctx context.Context, key string, fn store.RefreshFunc[Value]) (Value, error) {
+		v, _, err := ec.FetchWithLazyRefresh(ctx, key, fn, r.cfg.RefreshInterval)
+		return v, err
+	}, ec.Invalidate, ec.ShutdownLazyRefresh
+}
+
+// fetch fetches the key of the given index and checks the value served.
+func (r *runner) fetch(ctx context.Context, index int, fetch func(ctx context.Context, key string, fn store.RefreshFunc[Value]) (Value, error)) {
+	st := &r.states[index]
+	st.mu.Lock()
+	generation := st.generation
+	st.mu.Unlock()
+
+	key := "soak:" + strconv.Itoa(index)
+	v, err := fetch(ctx, key, func(ctx context.Context) (Value, error) {
+		return r.compute(ctx, key, st)
+	})
+	if err != nil {
+		r.errors.Add(1)
+		return
+	}
+	if v.Generation < generation {
+		r.staleReads.Add(1)
+	}
+	storeMax(&r.maxAge, int64(time.Since(v.ComputedAt)))
+}
+
+// compute is the refresh function of every key, recording concurrent and duplicate computations.
+func (r *runner) compute(ctx context.Context, key string, st *keyState) (Value, error) {
+	st.mu.Lock()
+	st.running++
+	storeMax(&r.maxConcurrent, int64(st.running))
+	generation := st.generation
+	if !st.lastDone.IsZero() && st.doneGeneration == generation && (r.cfg.LazyStore == nil || time.Since(st.lastDone) < r.cfg.RefreshInterval) {
+		r.duplicates.Add(1)
+	}
+	st.mu.Unlock()
+
+	if r.cfg.LoaderLatency > 0 {
+		timer := time.NewTimer(r.cfg.LoaderLatency)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+
+	now := time.Now()
+	st.mu.Lock()
+	st.running--
+	st.lastDone = now
+	st.doneGeneration = generation
+	st.mu.Unlock()
+	r.computations.Add(1)
+	return Value{Key: key, Generation: generation, ComputedAt: now}, ctx.Err()
+}
+
+// invalidate advances the generation of the key of the given index and invalidates it.
+func (r *runner) invalidate(ctx context.Context, index int, invalidate func(ctx context.Context, key string) error) {
+	st := &r.states[index]
+	st.mu.Lock()
+	st.generation++
+	st.mu.Unlock()
+	r.invalidations.Add(1)
+	if err := invalidate(ctx, "soak:"+strconv.Itoa(index)); err != nil {
+		r.errors.Add(1)
+	}
+}
+
+// report builds the report of the run and checks the invariants.
+func (r *runner) report(elapsed time.Duration) Report {
+	report := Report{
+		Operations:                r.cfg.Operations,
+		Invalidations:             int(r.invalidations.Load()),
+		Computations:              int(r.computations.Load()),
+		DuplicateComputations:     int(r.duplicates.Load()),
+		MaxConcurrentComputations: int(r.maxConcurrent.Load()),
+		StaleAfterInvalidate:      int(r.staleReads.Load()),
+		MaxServedAge:              time.Duration(r.maxAge.Load()),
+		Errors:                    int(r.errors.Load()),
+		Elapsed:                   elapsed,
+	}
+	if report.MaxConcurrentComputations > r.cfg.MaxConcurrentComputations {
+		report.Violations = append(report.Violations, fmt.Sprintf("%d concurrent computations of the same key, at most %d expected", report.MaxConcurrentComputations, r.cfg.MaxConcurrentComputations))
+	}
+	if report.DuplicateComputations > r.cfg.MaxDuplicateComputations {
+		report.Violations = append(report.Violations, fmt.Sprintf("%d duplicate computations, at most %d expected", report.DuplicateComputations, r.cfg.MaxDuplicateComputations))
+	}
+	if r.cfg.MaxAge > 0 && report.MaxServedAge > r.cfg.MaxAge {
+		report.Violations = append(report.Violations, fmt.Sprintf("served a value %s old, at most %s expected", report.MaxServedAge, r.cfg.MaxAge))
+	}
+	return report
+}
+
+// storeMax raises the value of m to v when v is larger.
+func storeMax(m *atomic.Int64, v int64) {
+	for {
+		current := m.Load()
+		if v <= current || m.CompareAndSwap(current, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package soak
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheck_EchoCache verifies that an in-memory EchoCache holds the invariants under mixed traffic.
+func TestCheck_EchoCache(t *testing.T) {
+	report := Check(t, context.Background(), Config{
+		Store:         store.NewLRUCache[Value](100),
+		Keys:          20,
+		Operations:    2000,
+		Concurrency:   8,
+		LoaderLatency: time.Millisecond,
+		MaxAge:        time.Minute,
+	})
+
+	assert.Equal(t, 2000, report.Operations)
+	assert.Equal(t, 20, report.Computations)
+	assert.Equal(t, 1, report.MaxConcurrentComputations)
+	assert.NoError(t, report.Err())
+}
+
+// TestCheck_Lazy verifies that an in-memory EchoCacheLazy refreshes without duplicate computations.
+func TestCheck_Lazy(t *testing.T) {
+	report := Check(t, context.Background(), Config{
+		LazyStore:       store.NewStaleWhileRevalidateLRUCache[Value](100),
+		RefreshInterval: 5 * time.Millisecond,
+		Keys:            10,
+		Operations:      3000,
+		Concurrency:     4,
+		LoaderLatency:   100 * time.Microsecond,
+	})
+
+	assert.GreaterOrEqual(t, report.Computations, 10)
+	assert.Zero(t, report.Errors)
+}
+
+// TestRun_Violations verifies that duplicate computations and old values are reported as violations.
+func TestRun_Violations(t *testing.T) {
+	report, err := Run(context.Background(), Config{
+		Store:       store.NewLRUCache[Value](1),
+		Keys:        10,
+		Operations:  500,
+		Concurrency: 2,
+		MaxAge:      time.Nanosecond,
+	})
+	require.NoError(t, err)
+
+	assert.Greater(t, report.DuplicateComputations, 0)
+	assert.Len(t, report.Violations, 2)
+	assert.Error(t, report.Err())
+}
+
+// TestRun_RequiresOneStore verifies configuration validation.
+func TestRun_RequiresOneStore(t *testing.T) {
+	_, err := Run(context.Background(), Config{})
+	assert.Error(t, err)
+
+	_, err = Run(context.Background(), Config{LazyStore: store.NewStaleWhileRevalidateLRUCache[Value](1)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package echocache
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+)
+
+// SQLQuerier is implemented by *sql.DB, *sql.Tx and *sql.Conn, as well as by the sqlx equivalents.
+type SQLQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// SQLScanFunc reads the result of a query from rows. Rows are closed by the caller.
+type SQLScanFunc[T any] func(rows *sql.Rows) (T, error)
+
+// SQLSelectFunc has the signature of sqlx style query helpers such as sqlx.DB.SelectContext and sqlx.DB.GetContext,
+// which scan the result of the query into dest.
+type SQLSelectFunc func(ctx context.Context, dest any, query string, args ...any) error
+
+// SQLQueryKey derives a cache key from a query and its arguments. Whitespace outside quoted literals and identifiers
+// is normalized, so the same statement formatted differently maps to the same key, and arguments are hashed like
+// ParamsKey parameters.
+func SQLQueryKey(prefix string, query string, args ...any) (string, error) {
+	return ParamsKey(prefix, struct {
+		Query string
+		Args  []any
+	}{Query: normalizeSQL(query), Args: args})
+}
+
+// normalizeSQL collapses the runs of whitespace of a query into single spaces and trims it, leaving the content of
+// single-quoted, double-quoted and backquoted sections untouched. A backslash inside quotes is taken as escaping the
+// next character, so a literal whose end is uncertain is kept verbatim up to the end of the query.
+func normalizeSQL(query string) string {
+	var (
+		b     strings.Builder
+		quote rune
+		space bool
+		esc   bool
+	)
+	b.Grow(len(query))
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			switch {
+			case esc:
+				esc = false
+			case r == '\\':
+				esc = true
+			case r == quote:
+				quote = 0
+			}
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// QueryMemo memoizes the results of read queries through an EchoCache, keyed on the query text and its arguments,
+// so read caching can be added to an existing repository layer without changing its queries.
+type QueryMemo[T any] struct {
+	ec     *EchoCache[T]
+	prefix string
+}
+
+// NewQueryMemo creates a query memoizer storing results in ec under keys starting with prefix.
+func NewQueryMemo[T any](ec *EchoCache[T], prefix string) *QueryMemo[T] {
+	return &QueryMemo[T]{ec: ec, prefix: prefix}
+}
+
+// Query runs the query on db and reads its result with scan on a cache miss, returning the cached result otherwise.
+// Returns the value, a boolean indicating if it was found or computed, and an error if the query or the scan fails.
+func (m *QueryMemo[T]) Query(ctx context.Context, db SQLQuerier, scan SQLScanFunc[T], query string, args ...any) (T, bool, error) {
+	return m.fetch(ctx, query, args, func(ctx context.Context) (T, error) {
+		var zeroValue T
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return zeroValue, err
+		}
+		defer rows.Close()
+
+		value, err := scan(rows)
+		if err != nil {
+			return zeroValue, err
+		}
+		if err := rows.Err(); err != nil {
+			return zeroValue, err
+		}
+		return value, nil
+	})
+}
+
+// Select runs a sqlx style query helper on a cache miss, returning the cached result otherwise.
+func (m *QueryMemo[T]) Select(ctx context.Context, fn SQLSelectFunc, query string, args ...any) (T, bool, error) {
+	return m.fetch(ctx, query, args, func(ctx context.Context) (T, error) {
+		var dest T
+		err := fn(ctx, &dest, query, args...)
+		return dest, err
+	})
+}
+
+// Invalidate removes the cached result of the query, typically after a write affecting it.
+// Returns store.ErrNotSupported if the store cannot delete entries.
+func (m *QueryMemo[T]) Invalidate(ctx context.Context, query string, args ...any) error {
+	key, err := SQLQueryKey(m.prefix, query, args...)
+	if err != nil {
+		return err
+	}
+	return m.ec.Invalidate(ctx, key)
+}
+
+// fetch derives the key of the query and fetches it through the cache.
+func (m *QueryMemo[T]) fetch(ctx context.Context, query string, args []any, fn func(ctx context.Context) (T, error)) (T, bool, error) {
+	key, err := SQLQueryKey(m.prefix, query, args...)
+	if err != nil {
+		var zeroValue T
+		return zeroValue, false, err
+	}
+	return m.ec.FetchWithCache(ctx, key, fn)
+}
@@ -0,0 +1,130 @@
+package echocache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoQueries counts the queries executed by the memoDriver.
+var memoQueries atomic.Int64
+
+func init() {
+	sql.Register("echocache-memo", memoDriver{})
+}
+
+// memoDriver is a database/sql driver returning the query arguments as a single-column result.
+type memoDriver struct{}
+
+func (memoDriver) Open(string) (driver.Conn, error) { return memoConn{}, nil }
+
+type memoConn struct{}
+
+func (memoConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (memoConn) Close() error                        { return nil }
+func (memoConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (memoConn) QueryContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Rows, error) {
+	memoQueries.Add(1)
+	return &memoRows{args: args}, nil
+}
+
+type memoRows struct {
+	args []driver.NamedValue
+	pos  int
+}
+
+func (r *memoRows) Columns() []string { return []string{"value"} }
+func (r *memoRows) Close() error      { return nil }
+
+func (r *memoRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.args) {
+		return io.EOF
+	}
+	dest[0] = r.args[r.pos].Value
+	r.pos++
+	return nil
+}
+
+// TestQueryMemo verifies that query results are cached per query and arguments, and can be invalidated.
+func TestQueryMemo(t *testing.T) {
+	db, err := sql.Open("echocache-memo", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	memo := NewQueryMemo(NewEchoCache[[]int64](store.NewLRUCache[[]int64](10)), "users")
+	scan := func(rows *sql.Rows) ([]int64, error) {
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+
+	memoQueries.Store(0)
+	ids, _, err := memo.Query(t.Context(), db, scan, "SELECT id FROM users WHERE id IN (?, ?)", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+	ids, _, err = memo.Query(t.Context(), db, scan, "SELECT id\n\tFROM users WHERE id IN (?, ?)", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+	assert.Equal(t, int64(1), memoQueries.Load())
+
+	_, _, err = memo.Query(t.Context(), db, scan, "SELECT id FROM users WHERE id IN (?, ?)", 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), memoQueries.Load())
+
+	require.NoError(t, memo.Invalidate(t.Context(), "SELECT id FROM users WHERE id IN (?, ?)", 1, 2))
+	_, _, err = memo.Query(t.Context(), db, scan, "SELECT id FROM users WHERE id IN (?, ?)", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), memoQueries.Load())
+}
+
+// TestSQLQueryKey verifies that whitespace is normalized outside quoted sections only.
+func TestSQLQueryKey(t *testing.T) {
+	key := func(query string) string {
+		k, err := SQLQueryKey("users", query, 1)
+		require.NoError(t, err)
+		return k
+	}
+
+	assert.Equal(t, key("SELECT name FROM users WHERE id = ?"), key("  SELECT name\n\tFROM   users\nWHERE id = ?  "))
+	assert.NotEqual(t, key("SELECT id FROM users WHERE name = 'a  b'"), key("SELECT id FROM users WHERE name = 'a b'"))
+	assert.NotEqual(t, key(`SELECT "first  name" FROM users`), key(`SELECT "first name" FROM users`))
+	assert.NotEqual(t, key("SELECT id FROM users WHERE name = 'it\\'s  x'"), key("SELECT id FROM users WHERE name = 'it\\'s x'"))
+	assert.Equal(t, key("SELECT id FROM users WHERE name = 'a  b'  AND id = ?"), key("SELECT id FROM users WHERE name = 'a  b' AND id = ?"))
+	assert.Equal(t, "SELECT 'a  b' FROM t", normalizeSQL(" SELECT\n'a  b'   FROM t\n"))
+}
+
+// TestQueryMemoSelect verifies that sqlx style helpers are memoized.
+func TestQueryMemoSelect(t *testing.T) {
+	memo := NewQueryMemo(NewEchoCache[[]string](store.NewLRUCache[[]string](10)), "names")
+	calls := 0
+	selectFn := func(_ context.Context, dest any, _ string, args ...any) error {
+		calls++
+		*dest.(*[]string) = []string{args[0].(string)}
+		return nil
+	}
+
+	for range 2 {
+		names, _, err := memo.Select(t.Context(), selectFn, "SELECT name FROM users WHERE country = ?", "IT")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"IT"}, names)
+	}
+	assert.Equal(t, 1, calls)
+
+	_, _, err := memo.Select(t.Context(), selectFn, "SELECT 1", func() {})
+	assert.Error(t, err)
+}
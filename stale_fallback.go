@@ -0,0 +1,79 @@
+package echocache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// WithStaleFallback lets EchoCache serve expired copies while a miss is computed: every value it computes is also
+// written, with its creation time, to s, a store expected to keep entries longer than the main one, and while the
+// value of a missing key is being computed, concurrent fetches of the key return the copy found in s instead of
+// waiting for the computation, provided it is not older than maxStale (zero for no limit). The caller running the
+// computation, and callers finding no copy, wait as usual. Invalidate removes the copy as well. EchoCacheLazy, which
+// already serves stale values, ignores this option.
+func WithStaleFallback[T any](s store.StaleWhileRevalidateCache[T], maxStale time.Duration) Option {
+	return func(o *options) {
+		o.staleFallback = s
+		o.staleFallbackAge = maxStale
+	}
+}
+
+// staleFallback holds the expired copies served by EchoCache while a miss is computed.
+// A nil *staleFallback is valid and serves nothing.
+type staleFallback[T any] struct {
+	store  store.StaleWhileRevalidateCache[T]
+	maxAge time.Duration
+}
+
+// newStaleFallback returns the fallback configured by the options, or nil when none matching the value type is.
+func newStaleFallback[T any](o options) *staleFallback[T] {
+	if o.staleFallback == nil {
+		return nil
+	}
+	s, ok := o.staleFallback.(store.StaleWhileRevalidateCache[T])
+	if !ok {
+		slog.Warn("Stale fallback store does not match the cache value type, ignoring it")
+		return nil
+	}
+	return &staleFallback[T]{store: s, maxAge: o.staleFallbackAge}
+}
+
+// get returns the copy of the key if it is recent enough to be served.
+func (f *staleFallback[T]) get(ctx context.Context, key string) (T, bool) {
+	var zeroValue T
+	if f == nil {
+		return zeroValue, false
+	}
+	copied, exists, err := f.store.Get(ctx, key)
+	if err != nil {
+		slog.Warn("Cannot get stale copy", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		return zeroValue, false
+	}
+	if !exists || (f.maxAge > 0 && time.Since(copied.CreatedAt) > f.maxAge) {
+		return zeroValue, false
+	}
+	return copied.Value, true
+}
+
+// set keeps a copy of the value computed at createdAt.
+func (f *staleFallback[T]) set(ctx context.Context, key string, value T, createdAt time.Time) {
+	if f == nil {
+		return
+	}
+	if err := f.store.Set(ctx, key, store.StaleValue[T]{Value: value, CreatedAt: createdAt}); err != nil {
+		slog.Warn("Failed to store stale copy", slog.String("cacheKey", key), slog.String("error", err.Error()))
+	}
+}
+
+// delete removes the copy of the key.
+func (f *staleFallback[T]) delete(ctx context.Context, key string) {
+	if f == nil {
+		return
+	}
+	if err := store.Delete(ctx, f.store, key); err != nil {
+		slog.Warn("Failed to delete stale copy", slog.String("cacheKey", key), slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,54 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_WithStaleFallback verifies that concurrent fetches of a key being computed are served the expired
+// copy instead of waiting, and that invalidation removes the copy.
+func TestEchoCache_WithStaleFallback(t *testing.T) {
+	ctx := context.Background()
+	main := store.NewLRUCache[string](10)
+	copies := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewEchoCache[string](main, WithStaleFallback[string](copies, time.Minute))
+
+	value, _, err := cache.FetchWithCache(ctx, "k", func(context.Context) (string, error) { return "v1", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "v1", value)
+	require.NoError(t, store.Delete(ctx, main, "k"))
+
+	release := make(chan struct{})
+	done := make(chan string)
+	go func() {
+		value, _, _ := cache.FetchWithCache(ctx, "k", func(context.Context) (string, error) {
+			<-release
+			return "v2", nil
+		})
+		done <- value
+	}()
+	require.Eventually(t, func() bool { return len(cache.InFlight()) == 1 }, time.Second, time.Millisecond)
+
+	value, exists, err := cache.FetchWithCache(ctx, "k", func(context.Context) (string, error) {
+		t.Error("the waiter must not compute")
+		return "", nil
+	})
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "v1", value)
+	assert.Equal(t, uint64(1), cache.Stats().StaleHits)
+
+	close(release)
+	assert.Equal(t, "v2", <-done)
+	copied, _, _ := copies.Get(ctx, "k")
+	assert.Equal(t, "v2", copied.Value)
+
+	require.NoError(t, cache.Invalidate(ctx, "k"))
+	_, exists, _ = copies.Get(ctx, "k")
+	assert.False(t, exists)
+}
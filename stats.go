@@ -0,0 +1,99 @@
+package echocache
+
+import (
+	"github.com/logocomune/echocache/store"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the activity of a cache since it was created.
+// Size is the number of entries held by the store, or -1 when the store cannot report it.
+// Pending and QueueCapacity describe the background refresh queue of lazy caches and are zero otherwise.
+// Compression is set when the store serializes values with a compressing codec, Locks when it reports refresh lock
+// statistics. TopKeys lists the most fetched keys when the cache is configured with WithKeyStats.
+type Stats struct {
+	Hits          uint64 `json:"hits"`
+	StaleHits     uint64 `json:"staleHits"`
+	Misses        uint64 `json:"misses"`
+	Errors        uint64 `json:"errors"`
+	InFlight      int    `json:"inFlight"`
+	Pending       int    `json:"pending"`
+	QueueCapacity int    `json:"queueCapacity"`
+	Size          int    `json:"size"`
+
+	Compression *store.CompressionStats `json:"compression,omitempty"`
+	Locks       *store.LockStats        `json:"locks,omitempty"`
+	TopKeys     []KeyStat               `json:"topKeys,omitempty"`
+}
+
+// HitRatio returns the fraction of fetches served from the store, stale hits included, or 0 before the first fetch.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.StaleHits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.StaleHits) / float64(total)
+}
+
+// cacheCounters counts the outcomes of fetches. A nil *cacheCounters is valid and counts nothing.
+type cacheCounters struct {
+	hits      atomic.Uint64
+	staleHits atomic.Uint64
+	misses    atomic.Uint64
+	errors    atomic.Uint64
+	keys      *keyTracker
+}
+
+// hit records a fetch served from the store.
+func (c *cacheCounters) hit(key string) {
+	if c != nil {
+		c.hits.Add(1)
+		c.keys.record(key, keyHit)
+	}
+}
+
+// staleHit records a fetch served from the store with a value past its refresh interval.
+func (c *cacheCounters) staleHit(key string) {
+	if c != nil {
+		c.staleHits.Add(1)
+		c.keys.record(key, keyHit)
+	}
+}
+
+// miss records a fetch that had to compute the value.
+func (c *cacheCounters) miss(key string) {
+	if c != nil {
+		c.misses.Add(1)
+		c.keys.record(key, keyMiss)
+	}
+}
+
+// failure records a fetch whose computation failed.
+func (c *cacheCounters) failure(key string) {
+	if c != nil {
+		c.errors.Add(1)
+		c.keys.record(key, keyError)
+	}
+}
+
+// snapshot returns the counters as Stats, reading the size from the given store.
+func (c *cacheCounters) snapshot(s any) Stats {
+	stats := Stats{Size: -1}
+	if size, ok := store.Len(s); ok {
+		stats.Size = size
+	}
+	if compression, ok := store.Compression(s); ok {
+		stats.Compression = &compression
+	}
+	if locks, ok := store.Locks(s); ok {
+		stats.Locks = &locks
+	}
+	if c == nil {
+		return stats
+	}
+	stats.Hits = c.hits.Load()
+	stats.StaleHits = c.staleHits.Load()
+	stats.Misses = c.misses.Load()
+	stats.Errors = c.errors.Load()
+	stats.TopKeys = c.keys.snapshot()
+	return stats
+}
@@ -0,0 +1,65 @@
+package echocache
+
+import (
+	"net/http"
+	"time"
+)
+
+// CacheStatsReport is the document served by Registry.StatsHandler.
+type CacheStatsReport struct {
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Caches      []CacheStatsEntry `json:"caches"`
+	Total       CacheStatsEntry   `json:"total"`
+}
+
+// CacheStatsEntry holds the statistics of a single cache, or of all caches in CacheStatsReport.Total, together with
+// the derived hit ratio. The total size only sums the caches able to report it.
+type CacheStatsEntry struct {
+	Name string `json:"name"`
+	Stats
+	HitRatio float64 `json:"hitRatio"`
+}
+
+// StatsReport aggregates the statistics of every registered cache, sorted by name.
+func (r *Registry) StatsReport() CacheStatsReport {
+	report := CacheStatsReport{
+		GeneratedAt: time.Now().UTC(),
+		Caches:      make([]CacheStatsEntry, 0),
+		Total:       CacheStatsEntry{Name: "total"},
+	}
+	stats := r.Stats()
+	for _, name := range r.Names() {
+		s, ok := stats[name]
+		if !ok {
+			continue
+		}
+		report.Caches = append(report.Caches, CacheStatsEntry{Name: name, Stats: s, HitRatio: s.HitRatio()})
+		t := &report.Total.Stats
+		t.Hits += s.Hits
+		t.StaleHits += s.StaleHits
+		t.Misses += s.Misses
+		t.Errors += s.Errors
+		t.InFlight += s.InFlight
+		t.Pending += s.Pending
+		t.QueueCapacity += s.QueueCapacity
+		if s.Size > 0 {
+			t.Size += s.Size
+		}
+	}
+	report.Total.HitRatio = report.Total.Stats.HitRatio()
+	return report
+}
+
+// StatsHandler returns an HTTP handler serving StatsReport as plain JSON, for teams scraping JSON into their own
+// dashboards rather than using a metrics sink. It is usually mounted on /cachestats and is also served by
+// AdminHandler under that path.
+func (r *Registry) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, r.StatsReport())
+	})
+}
@@ -0,0 +1,52 @@
+package echocache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegistry_StatsHandler verifies that the JSON report aggregates every registered cache, including queue depths.
+func TestRegistry_StatsHandler(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	users := NewEchoCache[string](store.NewLRUCache[string](10))
+	scores := NewLazyEchoCache[int](store.NewStaleWhileRevalidateLRUCache[int](10), time.Second, WithQueueSize(5))
+	defer scores.ShutdownLazyRefresh()
+	require.NoError(t, RegisterCache(r, "users", users))
+	require.NoError(t, RegisterLazy(r, "scores", scores))
+
+	for i := 0; i < 4; i++ {
+		_, _, err := users.FetchWithCache(ctx, "u", func(ctx context.Context) (string, error) {
+			return "alice", nil
+		})
+		require.NoError(t, err)
+	}
+
+	rec := httptest.NewRecorder()
+	r.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cachestats", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report CacheStatsReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.Len(t, report.Caches, 2)
+	assert.Equal(t, "scores", report.Caches[0].Name)
+	assert.Equal(t, 5, report.Caches[0].QueueCapacity)
+	assert.Equal(t, "users", report.Caches[1].Name)
+	assert.Equal(t, uint64(3), report.Caches[1].Hits)
+	assert.InDelta(t, 0.75, report.Caches[1].HitRatio, 0.001)
+	assert.Equal(t, 1, report.Total.Size)
+	assert.Equal(t, uint64(1), report.Total.Misses)
+
+	rec = httptest.NewRecorder()
+	r.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cachestats", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
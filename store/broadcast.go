@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// ClearCommand asks every instance to flush the caches of a namespace, all of them when Namespace is empty. When
+// Keys is set, only those keys are invalidated instead. Origin identifies the instance that issued it.
+type ClearCommand struct {
+	Namespace string    `json:"namespace"`
+	Keys      []string  `json:"keys,omitempty"`
+	Origin    string    `json:"origin,omitempty"`
+	IssuedAt  time.Time `json:"issuedAt"`
+}
+
+// ClearBroadcaster delivers clear commands to every instance of a cluster. Unlike a RefreshTransport, every
+// subscriber receives every command and nothing is persisted: instances that are not listening miss it.
+// SubscribeClear blocks until ctx is done, calling handler for every command received.
+type ClearBroadcaster interface {
+	PublishClear(ctx context.Context, cmd ClearCommand) error
+	SubscribeClear(ctx context.Context, handler func(ctx context.Context, cmd ClearCommand)) error
+}
+
+// LocalClearer is implemented by caches holding entries in process memory, which ClearLocal removes without
+// touching the shared backend: in-memory stores clear everything and TieredCache clears its L1.
+type LocalClearer interface {
+	ClearLocal(ctx context.Context) error
+}
+
+// LocalDeleter is implemented by caches holding entries both in process memory and in a shared backend, such as
+// TieredCache, which DeleteLocal removes from process memory only.
+type LocalDeleter interface {
+	DeleteLocal(ctx context.Context, key string) error
+}
+
+// DeleteLocal removes the key from the entries the cache holds in process memory: in-memory stores delete it and
+// LocalDeleter implementations drop their local copy. Caches holding no entries in process memory, such as the
+// Redis and NATS stores, are left untouched and nil is returned.
+func DeleteLocal(ctx context.Context, c any, key string) error {
+	if deleter, ok := c.(LocalDeleter); ok {
+		return deleter.DeleteLocal(ctx, key)
+	}
+	if _, ok := c.(LocalClearer); !ok {
+		return nil
+	}
+	if deleter, ok := c.(Deleter); ok {
+		return deleter.Delete(ctx, key)
+	}
+	return ErrNotSupported
+}
+
+// ClearLocal removes the entries the cache holds in process memory. Caches that do not implement LocalClearer,
+// such as the Redis and NATS stores, hold none and nil is returned.
+func ClearLocal(ctx context.Context, c any) error {
+	if clearer, ok := c.(LocalClearer); ok {
+		return clearer.ClearLocal(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClearLocal verifies that only entries held in process memory are removed.
+func TestClearLocal(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := NewLRUCache[string](10), NewLRUCache[string](10)
+	tiered := NewTieredCache[string](l1, l2)
+	require.NoError(t, tiered.Set(ctx, "k", "v"))
+
+	require.NoError(t, ClearLocal(ctx, tiered))
+	size, _ := Len(l1)
+	assert.Zero(t, size)
+	size, _ = Len(l2)
+	assert.Equal(t, 1, size)
+
+	rdb, _ := redismock.NewClientMock()
+	assert.NoError(t, ClearLocal(ctx, NewRedisCache[string](rdb, "test", time.Hour)))
+}
+
+// TestDeleteLocal verifies that a key is removed from process memory only.
+func TestDeleteLocal(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := NewLRUCache[string](10), NewLRUCache[string](10)
+	tiered := NewTieredCache[string](l1, l2)
+	require.NoError(t, tiered.Set(ctx, "k", "v"))
+
+	require.NoError(t, DeleteLocal(ctx, tiered, "k"))
+	_, exists, _ := l1.Get(ctx, "k")
+	assert.False(t, exists)
+	_, exists, _ = l2.Get(ctx, "k")
+	assert.True(t, exists)
+
+	require.NoError(t, DeleteLocal(ctx, l2, "k"))
+	_, exists, _ = l2.Get(ctx, "k")
+	assert.False(t, exists)
+
+	rdb, _ := redismock.NewClientMock()
+	assert.NoError(t, DeleteLocal(ctx, NewRedisCache[string](rdb, "test", time.Hour), "k"))
+}
+
+// TestRedisBroadcaster_PublishClear verifies that clear commands are published as JSON on the channel.
+func TestRedisBroadcaster_PublishClear(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	b := NewRedisBroadcaster(rdb, "echocache:clear")
+	issuedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectPublish("echocache:clear", []byte(`{"namespace":"users","origin":"a","issuedAt":"2026-01-02T03:04:05Z"}`)).SetVal(1)
+	require.NoError(t, b.PublishClear(context.Background(), ClearCommand{Namespace: "users", Origin: "a", IssuedAt: issuedAt}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers are not returned to the pool, so a few large values do not
+// pin memory for the lifetime of the process.
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool holds the buffers reused to serialize values.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// BufferedCodec is implemented by codecs able to serialize into a caller-provided buffer, letting the Redis and NATS
+// stores reuse pooled buffers instead of allocating a new slice on every write and read. JSONCodec and ChecksumCodec
+// implement it; MarshalTo must append to buf and produce the same bytes as Marshal, and Unmarshal must not retain
+// data after returning, as it is decoded from a pooled buffer.
+type BufferedCodec interface {
+	Codec
+	MarshalTo(buf *bytes.Buffer, v any) error
+}
+
+// encodePooled serializes the value with the given codec, into a pooled buffer when the codec implements
+// BufferedCodec. The returned data is only valid until the returned buffer, possibly nil, is released with
+// releaseBuffer.
+func encodePooled(c Codec, v any) ([]byte, *bytes.Buffer, error) {
+	if c == nil {
+		c = JSONCodec{}
+	}
+	bc, ok := c.(BufferedCodec)
+	if !ok {
+		data, err := c.Marshal(v)
+		return data, nil, err
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := bc.MarshalTo(buf, v); err != nil {
+		releaseBuffer(buf)
+		return nil, nil, err
+	}
+	return buf.Bytes(), buf, nil
+}
+
+// decodePooled deserializes the value read from a remote store with the given codec. When the codec implements
+// BufferedCodec the data is copied into a pooled buffer rather than a newly allocated slice.
+func decodePooled(c Codec, data string, v any) error {
+	if c == nil {
+		c = JSONCodec{}
+	}
+	if _, ok := c.(BufferedCodec); !ok {
+		return c.Unmarshal([]byte(data), v)
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.WriteString(data)
+	defer releaseBuffer(buf)
+	return c.Unmarshal(buf.Bytes(), v)
+}
+
+// releaseBuffer returns the buffer to the pool unless it is nil or grew too large.
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// marshalTo appends the value serialized with the codec to buf.
+func marshalTo(c Codec, buf *bytes.Buffer, v any) error {
+	if bc, ok := c.(BufferedCodec); ok {
+		return bc.MarshalTo(buf, v)
+	}
+	data, err := c.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type benchValue struct {
+	ID    int               `json:"id"`
+	Name  string            `json:"name"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+	HTML  string            `json:"html"`
+}
+
+var pooledValue = benchValue{
+	ID:    42,
+	Name:  "pooled buffers",
+	Tags:  []string{"a", "b", "c"},
+	Attrs: map[string]string{"region": "eu", "tier": "gold"},
+	HTML:  "<b>&</b>",
+}
+
+// TestEncodePooled verifies that pooled encoding and decoding match Marshal and Unmarshal for buffered and plain codecs.
+func TestEncodePooled(t *testing.T) {
+	compressing, err := NewCompressingCodec(JSONCodec{}, CompressionGzip, 0)
+	require.NoError(t, err)
+	codecs := []Codec{
+		nil,
+		JSONCodec{},
+		NewChecksumCodec(JSONCodec{}, ChecksumCRC32),
+		NewChecksumCodec(JSONCodec{}, ChecksumXXHash),
+		compressing,
+	}
+	for _, codec := range codecs {
+		want, err := encode(codec, pooledValue)
+		require.NoError(t, err)
+
+		data, buf, err := encodePooled(codec, pooledValue)
+		require.NoError(t, err)
+		assert.Equal(t, want, data)
+		releaseBuffer(buf)
+
+		var decoded benchValue
+		require.NoError(t, decode(codec, want, &decoded))
+		assert.Equal(t, pooledValue, decoded)
+
+		var pooled benchValue
+		require.NoError(t, decodePooled(codec, string(want), &pooled))
+		assert.Equal(t, pooledValue, pooled)
+	}
+
+	_, buf, err := encodePooled(JSONCodec{}, make(chan int))
+	assert.Error(t, err)
+	assert.Nil(t, buf)
+}
+
+// TestChecksumCodec_MarshalToAppends verifies that MarshalTo keeps the existing buffer content untouched.
+func TestChecksumCodec_MarshalToAppends(t *testing.T) {
+	codec := NewChecksumCodec(JSONCodec{}, ChecksumCRC32)
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.WriteString("prefix")
+	require.NoError(t, codec.MarshalTo(buf, "value"))
+
+	want, err := codec.Marshal("value")
+	require.NoError(t, err)
+	assert.Equal(t, "prefix"+string(want), buf.String())
+	releaseBuffer(buf)
+}
+
+// BenchmarkEncode measures allocations of the unpooled encoding path used before buffer reuse.
+func BenchmarkEncode(b *testing.B) {
+	codec := NewChecksumCodec(JSONCodec{}, ChecksumCRC32)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := encode(codec, pooledValue); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodePooled measures allocations of the pooled encoding path used by the Redis and NATS stores.
+func BenchmarkEncodePooled(b *testing.B) {
+	codec := NewChecksumCodec(JSONCodec{}, ChecksumCRC32)
+	b.ReportAllocs()
+	for b.Loop() {
+		_, buf, err := encodePooled(codec, pooledValue)
+		if err != nil {
+			b.Fatal(err)
+		}
+		releaseBuffer(buf)
+	}
+}
+
+// BenchmarkDecodePooled measures allocations of the pooled decoding path used by the Redis store.
+func BenchmarkDecodePooled(b *testing.B) {
+	data, err := json.Marshal(pooledValue)
+	require.NoError(b, err)
+	encoded := string(data)
+	b.ReportAllocs()
+	for b.Loop() {
+		var value benchValue
+		if err := decodePooled(JSONCodec{}, encoded, &value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRedisSetGet measures allocations per Set and Get round trip through the Redis store.
+func BenchmarkRedisSetGet(b *testing.B) {
+	ctx := context.Background()
+	rdb, mock := redismock.NewClientMock()
+	cache := NewRedisCache[benchValue](rdb, "bench", time.Minute)
+	data, err := json.Marshal(pooledValue)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		mock.ExpectSet("bench:k", data, time.Minute).SetVal("OK")
+		mock.ExpectGet("bench:k").SetVal(string(data))
+		if err := cache.Set(ctx, "k", pooledValue); err != nil {
+			b.Fatal(err)
+		}
+		if _, _, err := cache.Get(ctx, "k"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
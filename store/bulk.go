@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+)
+
+// defaultBulkBatchSize is the number of entries flushed at once by BulkSetStream when no batch size is given.
+const defaultBulkBatchSize = 500
+
+// BulkSetter is implemented by caches able to write many entries efficiently, e.g. with pipelined or batched writes.
+type BulkSetter[T any] interface {
+	BulkSet(ctx context.Context, entries map[string]T) error
+}
+
+// BulkGetter is implemented by caches able to read many entries in a single round-trip, e.g. with MGET. Missing
+// keys are absent from the returned map.
+type BulkGetter[T any] interface {
+	BulkGet(ctx context.Context, keys []string) (map[string]T, error)
+}
+
+// BulkGet reads the given keys from the cache, using the store's BulkGetter implementation when available and
+// falling back to sequential Get calls otherwise. Missing keys are absent from the returned map.
+func BulkGet[T any](ctx context.Context, c Cacher[T], keys []string) (map[string]T, error) {
+	if len(keys) == 0 {
+		return map[string]T{}, nil
+	}
+	if bulk, ok := c.(BulkGetter[T]); ok {
+		return bulk.BulkGet(ctx, keys)
+	}
+	values := make(map[string]T, len(keys))
+	for _, key := range keys {
+		value, exists, err := c.Get(ctx, key)
+		if err != nil {
+			return values, err
+		}
+		if exists {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// Entry is a single key-value pair used by streaming bulk loads.
+type Entry[T any] struct {
+	Key   string
+	Value T
+}
+
+// BulkSet stores all the given entries in the cache, using the store's BulkSetter implementation when available
+// and falling back to sequential Set calls otherwise.
+func BulkSet[T any](ctx context.Context, c Cacher[T], entries map[string]T) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if bulk, ok := c.(BulkSetter[T]); ok {
+		return bulk.BulkSet(ctx, entries)
+	}
+	for key, value := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.Set(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BulkSetStream consumes entries from the channel until it is closed, writing them in batches of batchSize.
+// It is intended for nightly jobs loading precomputed datasets that do not fit comfortably in memory.
+// Returns the number of entries written and the first error encountered.
+func BulkSetStream[T any](ctx context.Context, c Cacher[T], entries <-chan Entry[T], batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBulkBatchSize
+	}
+	written := 0
+	batch := make(map[string]T, batchSize)
+	flush := func() error {
+		if err := BulkSet(ctx, c, batch); err != nil {
+			return err
+		}
+		written += len(batch)
+		clear(batch)
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return written, ctx.Err()
+		case entry, ok := <-entries:
+			if !ok {
+				return written, flush()
+			}
+			batch[entry.Key] = entry.Value
+			if len(batch) >= batchSize {
+				if err := flush(); err != nil {
+					return written, err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBulkSet verifies the sequential fallback used by stores without native bulk support.
+func TestBulkSet(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache[int](10)
+
+	require.NoError(t, BulkSet[int](ctx, cache, map[string]int{"a": 1, "b": 2}))
+
+	value, found, _ := cache.Get(ctx, "b")
+	assert.True(t, found)
+	assert.Equal(t, 2, value)
+}
+
+// TestBulkSetStream verifies that streamed entries are written in batches until the channel is closed.
+func TestBulkSetStream(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache[int](100)
+	entries := make(chan Entry[int])
+
+	go func() {
+		defer close(entries)
+		for i := 0; i < 25; i++ {
+			entries <- Entry[int]{Key: fmt.Sprintf("k%d", i), Value: i}
+		}
+	}()
+
+	written, err := BulkSetStream[int](ctx, cache, entries, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 25, written)
+
+	value, found, _ := cache.Get(ctx, "k24")
+	assert.True(t, found)
+	assert.Equal(t, 24, value)
+}
+
+// TestBulkGet verifies the sequential fallback and the two-level read of the tiered cache.
+func TestBulkGet(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := NewLRUCache[int](10), NewLRUCache[int](10)
+	require.NoError(t, l1.Set(ctx, "a", 1))
+	require.NoError(t, l2.Set(ctx, "b", 2))
+
+	values, err := BulkGet[int](ctx, l1, []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, values)
+
+	values, err = BulkGet[int](ctx, NewTieredCache[int](l1, l2), []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, values)
+	value, found, _ := l1.Get(ctx, "b")
+	assert.True(t, found, "values read from L2 populate L1")
+	assert.Equal(t, 2, value)
+}
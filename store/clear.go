@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// Lener is implemented by caches able to report the number of entries they hold.
+type Lener interface {
+	Len() int
+}
+
+// Clearer is implemented by caches able to remove all their entries at once.
+type Clearer interface {
+	Clear(ctx context.Context) error
+}
+
+// Len returns the number of entries held by the cache. The boolean result is false when the cache does not implement Lener.
+func Len(c any) (int, bool) {
+	lener, ok := c.(Lener)
+	if !ok {
+		return 0, false
+	}
+	return lener.Len(), true
+}
+
+// Clear removes every entry of the cache. Caches that do not implement Clearer are emptied by scanning and deleting
+// their keys; ErrNotSupported is returned when the cache implements neither Clearer nor Scanner and Deleter.
+func Clear(ctx context.Context, c any) error {
+	if clearer, ok := c.(Clearer); ok {
+		return clearer.Clear(ctx)
+	}
+	scanner, ok := c.(Scanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	if _, ok := c.(Deleter); !ok {
+		return ErrNotSupported
+	}
+	keys, err := scanner.Scan(ctx, "*", 0)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, key := range keys {
+		if err := Delete(ctx, c, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClear verifies that in-memory stores report their size and can be emptied, and that stores without native
+// support are emptied through Scan and Delete.
+func TestClear(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRUCache[int](10)
+	require.NoError(t, BulkSet[int](ctx, lru, map[string]int{"a": 1, "b": 2}))
+
+	size, ok := Len(lru)
+	require.True(t, ok)
+	assert.Equal(t, 2, size)
+
+	require.NoError(t, Clear(ctx, lru))
+	size, _ = Len(lru)
+	assert.Equal(t, 0, size)
+
+	l1 := NewLRUCache[int](10)
+	tiered := NewTieredCache[int](l1, NewLRUCache[int](10))
+	require.NoError(t, tiered.Set(ctx, "a", 1))
+	require.NoError(t, Clear(ctx, tiered))
+	_, found, _ := tiered.Get(ctx, "a")
+	assert.False(t, found)
+
+	_, ok = Len(tiered)
+	assert.False(t, ok)
+	require.NoError(t, lru.Set(ctx, "a", 1))
+	require.NoError(t, Clear(ctx, Chain[int](lru, Timeout[int](time.Second))))
+	size, _ = Len(lru)
+	assert.Equal(t, 0, size)
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"log/slog"
+	"reflect"
+)
+
+// WithDeepCopy makes in-memory stores copy values on write and on read with DeepCopy, so callers can never mutate a
+// cached value in place and corrupt what other readers see. It trades CPU and allocations for isolation.
+func WithDeepCopy() Option {
+	return func(o *storeOptions) {
+		o.copyValues = true
+	}
+}
+
+// WithCloner makes in-memory stores copy values on write and on read with the given function instead of DeepCopy.
+// The function type must match the value type of the store; otherwise DeepCopy is used and a warning is logged.
+func WithCloner[T any](clone func(T) T) Option {
+	return func(o *storeOptions) {
+		o.copyValues = true
+		o.cloner = clone
+	}
+}
+
+// newCloner returns the copy function configured by the options, or nil when values are shared.
+func newCloner[T any](o storeOptions) func(T) T {
+	if !o.copyValues {
+		return nil
+	}
+	if o.cloner != nil {
+		if clone, ok := o.cloner.(func(T) T); ok {
+			return clone
+		}
+		var zero T
+		slog.Warn("Cloner does not match the store value type, falling back to DeepCopy", slog.String("type", reflect.TypeOf(&zero).Elem().String()))
+	}
+	return DeepCopy[T]
+}
+
+// cloneValue copies the value with clone, or returns it unchanged when clone is nil.
+func cloneValue[T any](clone func(T) T, value T) T {
+	if clone == nil {
+		return value
+	}
+	return clone(value)
+}
+
+// DeepCopy returns a deep copy of v built with reflection: pointers, slices, maps, arrays, interfaces and the exported
+// fields of structs are copied recursively, preserving shared references and cycles. Unexported struct fields,
+// functions and channels are copied shallowly.
+func DeepCopy[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	deepCopy(dst, src, make(map[copiedRef]reflect.Value))
+	return dst.Interface().(T)
+}
+
+// copiedRef identifies a pointer or map already copied by deepCopy. The type is part of the key because pointers of
+// different types share an address, such as a pointer to a struct and a pointer to its first field.
+type copiedRef struct {
+	typ  reflect.Type
+	addr uintptr
+}
+
+// deepCopy copies src into dst. seen maps the pointers and maps already copied to their copies.
+func deepCopy(dst reflect.Value, src reflect.Value, seen map[copiedRef]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		ref := copiedRef{typ: src.Type(), addr: src.Pointer()}
+		if cp, ok := seen[ref]; ok {
+			dst.Set(cp)
+			return
+		}
+		cp := reflect.New(src.Type().Elem())
+		seen[ref] = cp
+		deepCopy(cp.Elem(), src.Elem(), seen)
+		dst.Set(cp)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		cp := reflect.New(elem.Type()).Elem()
+		deepCopy(cp, elem, seen)
+		dst.Set(cp)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(cp.Index(i), src.Index(i), seen)
+		}
+		dst.Set(cp)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i), seen)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		ref := copiedRef{typ: src.Type(), addr: src.Pointer()}
+		if cp, ok := seen[ref]; ok {
+			dst.Set(cp)
+			return
+		}
+		cp := reflect.MakeMapWithSize(src.Type(), src.Len())
+		seen[ref] = cp
+		iter := src.MapRange()
+		for iter.Next() {
+			key := reflect.New(iter.Key().Type()).Elem()
+			deepCopy(key, iter.Key(), seen)
+			value := reflect.New(iter.Value().Type()).Elem()
+			deepCopy(value, iter.Value(), seen)
+			cp.SetMapIndex(key, value)
+		}
+		dst.Set(cp)
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopy(dst.Field(i), src.Field(i), seen)
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
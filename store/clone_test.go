@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cloneNode is a self-referencing type used to exercise DeepCopy.
+type cloneNode struct {
+	Name     string
+	Tags     []string
+	Attrs    map[string]int
+	Next     *cloneNode
+	Any      any
+	internal []int
+}
+
+// TestDeepCopy verifies that copies share no mutable state with the original while preserving cycles.
+func TestDeepCopy(t *testing.T) {
+	original := &cloneNode{
+		Name:     "a",
+		Tags:     []string{"x"},
+		Attrs:    map[string]int{"k": 1},
+		Any:      []int{1},
+		internal: []int{7},
+	}
+	original.Next = original
+
+	cp := DeepCopy(original)
+	cp.Tags[0] = "changed"
+	cp.Attrs["k"] = 2
+	cp.Any.([]int)[0] = 2
+
+	assert.Equal(t, "x", original.Tags[0])
+	assert.Equal(t, 1, original.Attrs["k"])
+	assert.Equal(t, 1, original.Any.([]int)[0])
+	assert.NotSame(t, original, cp)
+	assert.Same(t, cp, cp.Next)
+	assert.Equal(t, []int{7}, cp.internal)
+	assert.Nil(t, DeepCopy[*cloneNode](nil))
+}
+
+// aliasedPointers holds a pointer to a struct and a pointer to its first field, which share an address.
+type aliasedPointers struct {
+	Node *cloneNode
+	Name *string
+}
+
+// TestDeepCopy_AliasedPointersOfDifferentTypes verifies that pointers sharing an address but not a type are copied
+// separately instead of panicking.
+func TestDeepCopy_AliasedPointersOfDifferentTypes(t *testing.T) {
+	node := &cloneNode{Name: "a"}
+	original := aliasedPointers{Node: node, Name: &node.Name}
+
+	var cp aliasedPointers
+	assert.NotPanics(t, func() { cp = DeepCopy(original) })
+	assert.Equal(t, "a", cp.Node.Name)
+	assert.Equal(t, "a", *cp.Name)
+	assert.NotSame(t, node, cp.Node)
+	assert.NotSame(t, original.Name, cp.Name)
+}
+
+// TestInMemoryStores_DeepCopy verifies that stores configured with WithDeepCopy isolate cached values from callers.
+func TestInMemoryStores_DeepCopy(t *testing.T) {
+	wheel := NewTimingWheelCache[[]string](time.Minute, TimingWheelConfig[[]string]{}, WithDeepCopy())
+	defer wheel.Close()
+
+	tests := []struct {
+		name  string
+		cache Cacher[[]string]
+	}{
+		{name: "lru", cache: NewLRUCache[[]string](10, WithDeepCopy())},
+		{name: "lru expirable", cache: NewLRUExpirableCache[[]string](10, time.Minute, WithDeepCopy())},
+		{name: "single", cache: NewSingleCache[[]string](time.Minute, WithDeepCopy())},
+		{name: "timing wheel", cache: wheel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			value := []string{"original"}
+			assert.NoError(t, tt.cache.Set(ctx, "k", value))
+			value[0] = "mutated after set"
+
+			read, _, _ := tt.cache.Get(ctx, "k")
+			read[0] = "mutated after get"
+
+			again, found, err := tt.cache.Get(ctx, "k")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, []string{"original"}, again)
+		})
+	}
+}
+
+// TestWithCloner verifies that a custom cloner is used instead of reflection.
+func TestWithCloner(t *testing.T) {
+	calls := 0
+	cache := NewLRUCache[[]int](10, WithCloner(func(v []int) []int {
+		calls++
+		return append([]int(nil), v...)
+	}))
+	ctx := context.Background()
+	assert.NoError(t, cache.Set(ctx, "k", []int{1}))
+	_, _, _ = cache.Get(ctx, "k")
+	assert.Equal(t, 2, calls)
+}
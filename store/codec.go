@@ -0,0 +1,77 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// Codec converts cached values to and from their serialized representation in remote stores.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec is the default Codec, encoding values with encoding/json.
+type JSONCodec struct{}
+
+// Marshal encodes the value as JSON.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// MarshalTo appends the JSON encoding of the value to buf.
+func (JSONCodec) MarshalTo(buf *bytes.Buffer, v any) error {
+	e := jsonEncoderPool.Get().(*jsonEncoder)
+	e.buf = buf
+	err := e.enc.Encode(v)
+	e.buf = nil
+	jsonEncoderPool.Put(e)
+	if err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline that Marshal does not produce.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// jsonEncoder is a json.Encoder writing to a buffer set for each MarshalTo call, so encoders can be pooled.
+type jsonEncoder struct {
+	buf *bytes.Buffer
+	enc *json.Encoder
+}
+
+// Write appends p to the current buffer.
+func (e *jsonEncoder) Write(p []byte) (int, error) {
+	return e.buf.Write(p)
+}
+
+// jsonEncoderPool holds the encoders reused by JSONCodec.MarshalTo.
+var jsonEncoderPool = sync.Pool{
+	New: func() any {
+		e := &jsonEncoder{}
+		e.enc = json.NewEncoder(e)
+		return e
+	},
+}
+
+// Unmarshal decodes JSON data into the value pointed to by v.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// encode serializes the value with the given codec, falling back to JSON when no codec is configured.
+func encode(c Codec, v any) ([]byte, error) {
+	if c == nil {
+		return json.Marshal(v)
+	}
+	return c.Marshal(v)
+}
+
+// decode deserializes data with the given codec, falling back to JSON when no codec is configured.
+func decode(c Codec, data []byte, v any) error {
+	if c == nil {
+		return json.Unmarshal(data, v)
+	}
+	return c.Unmarshal(data, v)
+}
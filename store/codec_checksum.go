@@ -0,0 +1,128 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/cespare/xxhash/v2"
+	"hash/crc32"
+	"sync/atomic"
+)
+
+// ChecksumAlgorithm selects the hash function used by ChecksumCodec.
+type ChecksumAlgorithm byte
+
+const (
+	// ChecksumCRC32 uses the Castagnoli CRC32 polynomial.
+	ChecksumCRC32 ChecksumAlgorithm = 1
+	// ChecksumXXHash uses 64-bit xxHash.
+	ChecksumXXHash ChecksumAlgorithm = 2
+)
+
+// ErrCorruptedValue is returned when a stored value fails checksum verification.
+// Stores treat it as a cache miss and evict the corrupted entry.
+var ErrCorruptedValue = errors.New("cached value failed checksum verification")
+
+// crc32Table is the Castagnoli table used by ChecksumCRC32.
+var crc32Table = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumCodec wraps another Codec and prefixes its output with a checksum verified on every read,
+// so values truncated or altered in the backend (e.g. after a failover) are detected instead of decoded.
+type ChecksumCodec struct {
+	inner       Codec
+	algorithm   ChecksumAlgorithm
+	corruptions atomic.Uint64
+}
+
+// NewChecksumCodec creates a checksum codec around inner using the given algorithm.
+func NewChecksumCodec(inner Codec, algorithm ChecksumAlgorithm) *ChecksumCodec {
+	if inner == nil {
+		inner = JSONCodec{}
+	}
+	if algorithm != ChecksumXXHash {
+		algorithm = ChecksumCRC32
+	}
+	return &ChecksumCodec{
+		inner:     inner,
+		algorithm: algorithm,
+	}
+}
+
+// Marshal serializes the value with the inner codec and prepends the algorithm identifier and the checksum.
+func (c *ChecksumCodec) Marshal(v any) ([]byte, error) {
+	payload, err := c.inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, 1+checksumSize(c.algorithm)+len(payload))
+	out = append(out, byte(c.algorithm))
+	out = appendChecksum(out, c.algorithm, payload)
+	return append(out, payload...), nil
+}
+
+// MarshalTo appends the envelope to buf, serializing the value directly after the reserved header so the payload is
+// not copied.
+func (c *ChecksumCodec) MarshalTo(buf *bytes.Buffer, v any) error {
+	var header [9]byte
+	start := buf.Len()
+	size := 1 + checksumSize(c.algorithm)
+	buf.Write(header[:size])
+	if err := marshalTo(c.inner, buf, v); err != nil {
+		buf.Truncate(start)
+		return err
+	}
+	out := buf.Bytes()[start:]
+	out[0] = byte(c.algorithm)
+	appendChecksum(out[1:1], c.algorithm, out[size:])
+	return nil
+}
+
+// Unmarshal verifies the checksum and deserializes the payload with the inner codec.
+// Returns ErrCorruptedValue and increments the corruption counter when verification fails.
+func (c *ChecksumCodec) Unmarshal(data []byte, v any) error {
+	if len(data) < 1 {
+		return c.corrupted()
+	}
+	algorithm := ChecksumAlgorithm(data[0])
+	size := checksumSize(algorithm)
+	if size == 0 || len(data) < 1+size {
+		return c.corrupted()
+	}
+	stored, payload := data[1:1+size], data[1+size:]
+	expected := appendChecksum(nil, algorithm, payload)
+	if string(stored) != string(expected) {
+		return c.corrupted()
+	}
+	return c.inner.Unmarshal(payload, v)
+}
+
+// Corruptions returns the number of values that failed checksum verification since the codec was created.
+func (c *ChecksumCodec) Corruptions() uint64 {
+	return c.corruptions.Load()
+}
+
+// corrupted records a verification failure and returns ErrCorruptedValue.
+func (c *ChecksumCodec) corrupted() error {
+	c.corruptions.Add(1)
+	return ErrCorruptedValue
+}
+
+// checksumSize returns the size in bytes of the checksum produced by the algorithm, or 0 if it is unknown.
+func checksumSize(algorithm ChecksumAlgorithm) int {
+	switch algorithm {
+	case ChecksumCRC32:
+		return 4
+	case ChecksumXXHash:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// appendChecksum appends the big-endian checksum of payload computed with the given algorithm.
+func appendChecksum(out []byte, algorithm ChecksumAlgorithm, payload []byte) []byte {
+	if algorithm == ChecksumXXHash {
+		return binary.BigEndian.AppendUint64(out, xxhash.Sum64(payload))
+	}
+	return binary.BigEndian.AppendUint32(out, crc32.Checksum(payload, crc32Table))
+}
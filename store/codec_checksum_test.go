@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChecksumCodec verifies round trips and corruption detection for every supported algorithm.
+func TestChecksumCodec(t *testing.T) {
+	for _, algorithm := range []ChecksumAlgorithm{ChecksumCRC32, ChecksumXXHash} {
+		codec := NewChecksumCodec(JSONCodec{}, algorithm)
+
+		data, err := codec.Marshal("value")
+		require.NoError(t, err)
+
+		var decoded string
+		require.NoError(t, codec.Unmarshal(data, &decoded))
+		assert.Equal(t, "value", decoded)
+
+		assert.ErrorIs(t, codec.Unmarshal(data[:len(data)-2], &decoded), ErrCorruptedValue)
+		assert.ErrorIs(t, codec.Unmarshal(nil, &decoded), ErrCorruptedValue)
+		assert.Equal(t, uint64(2), codec.Corruptions())
+	}
+}
+
+// TestChecksumCodec_RedisEvictsCorrupted verifies that the Redis store turns corrupted values into misses and deletes them.
+func TestChecksumCodec_RedisEvictsCorrupted(t *testing.T) {
+	ctx := context.TODO()
+	codec := NewChecksumCodec(nil, ChecksumCRC32)
+	rdb, mock := redismock.NewClientMock()
+	cache := NewRedisCache[string](rdb, "test", time.Hour, WithCodec(codec))
+
+	data, _ := codec.Marshal("value")
+	mock.ExpectGet("test:key").SetVal(string(data[:len(data)-1]))
+	mock.ExpectDel("test:key").SetVal(1)
+
+	_, found, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, uint64(1), codec.Corruptions())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
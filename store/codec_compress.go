@@ -0,0 +1,283 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionAlgorithm selects the compression used by CompressingCodec.
+type CompressionAlgorithm byte
+
+const (
+	// CompressionNone marks payloads stored uncompressed because they were smaller than the codec threshold.
+	CompressionNone CompressionAlgorithm = 0
+	// CompressionGzip uses gzip from the standard library.
+	CompressionGzip CompressionAlgorithm = 1
+	// CompressionZstd uses Zstandard, which is faster and usually denser than gzip.
+	CompressionZstd CompressionAlgorithm = 2
+	// CompressionZstdDict uses Zstandard primed with a shared dictionary, see NewZstdDictionaryCodec.
+	CompressionZstdDict CompressionAlgorithm = 3
+)
+
+// ErrUnknownCompression is returned when a stored value was compressed with an unsupported algorithm.
+var ErrUnknownCompression = errors.New("value compressed with unknown algorithm")
+
+// CompressionStats reports how effective a CompressingCodec is, so users can check that compression is worth the
+// CPU for their payloads. RawBytes is the size produced by the inner codec and StoredBytes the size written to the
+// backend, envelope included.
+type CompressionStats struct {
+	Values           uint64        `json:"values"`
+	CompressedValues uint64        `json:"compressedValues"`
+	RawBytes         uint64        `json:"rawBytes"`
+	StoredBytes      uint64        `json:"storedBytes"`
+	CompressTime     time.Duration `json:"compressTime"`
+	DecompressTime   time.Duration `json:"decompressTime"`
+}
+
+// Ratio returns StoredBytes divided by RawBytes, lower is better, or 1 when nothing was written yet.
+func (s CompressionStats) Ratio() float64 {
+	if s.RawBytes == 0 {
+		return 1
+	}
+	return float64(s.StoredBytes) / float64(s.RawBytes)
+}
+
+// CompressionReporter is implemented by codecs and stores able to report compression statistics.
+type CompressionReporter interface {
+	CompressionStats() CompressionStats
+}
+
+// CompressingCodec wraps another Codec and compresses its output. Payloads smaller than the threshold are stored
+// uncompressed, since compression rarely pays off for them. The algorithm is stored in a one-byte envelope, so values
+// written with another algorithm or threshold remain readable. The Zstandard encoder and decoder are created on first
+// use and released by Close.
+type CompressingCodec struct {
+	inner     Codec
+	algorithm CompressionAlgorithm
+	minSize   int
+	dictID    uint32
+	dictIDs   map[uint32]struct{}
+
+	zstdMu         sync.Mutex
+	zstdEnc        *zstd.Encoder
+	zstdDec        *zstd.Decoder
+	encoderOptions []zstd.EOption
+	decoderOptions []zstd.DOption
+
+	values         atomic.Uint64
+	compressed     atomic.Uint64
+	rawBytes       atomic.Uint64
+	storedBytes    atomic.Uint64
+	compressTime   atomic.Int64
+	decompressTime atomic.Int64
+}
+
+// NewCompressingCodec creates a compressing codec around inner, which defaults to JSONCodec when nil.
+// Payloads shorter than minSize bytes are stored uncompressed.
+func NewCompressingCodec(inner Codec, algorithm CompressionAlgorithm, minSize int) (*CompressingCodec, error) {
+	if inner == nil {
+		inner = JSONCodec{}
+	}
+	if algorithm != CompressionGzip && algorithm != CompressionZstd {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCompression, algorithm)
+	}
+	return &CompressingCodec{
+		inner:     inner,
+		algorithm: algorithm,
+		minSize:   minSize,
+	}, nil
+}
+
+// Close releases the Zstandard encoder and decoder, if they were created. They are created again if the codec is
+// used afterwards.
+func (c *CompressingCodec) Close() error {
+	c.zstdMu.Lock()
+	defer c.zstdMu.Unlock()
+	var err error
+	if c.zstdEnc != nil {
+		err = c.zstdEnc.Close()
+		c.zstdEnc = nil
+	}
+	if c.zstdDec != nil {
+		c.zstdDec.Close()
+		c.zstdDec = nil
+	}
+	return err
+}
+
+// encoder returns the Zstandard encoder, creating it on first use.
+func (c *CompressingCodec) encoder() (*zstd.Encoder, error) {
+	c.zstdMu.Lock()
+	defer c.zstdMu.Unlock()
+	if c.zstdEnc == nil {
+		enc, err := zstd.NewWriter(nil, c.encoderOptions...)
+		if err != nil {
+			return nil, err
+		}
+		c.zstdEnc = enc
+	}
+	return c.zstdEnc, nil
+}
+
+// decoder returns the Zstandard decoder, creating it on first use.
+func (c *CompressingCodec) decoder() (*zstd.Decoder, error) {
+	c.zstdMu.Lock()
+	defer c.zstdMu.Unlock()
+	if c.zstdDec == nil {
+		dec, err := zstd.NewReader(nil, c.decoderOptions...)
+		if err != nil {
+			return nil, err
+		}
+		c.zstdDec = dec
+	}
+	return c.zstdDec, nil
+}
+
+// Marshal serializes the value with the inner codec, compresses it when it reaches the threshold and prepends the
+// algorithm identifier.
+func (c *CompressingCodec) Marshal(v any) ([]byte, error) {
+	payload, err := c.inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	c.values.Add(1)
+	c.rawBytes.Add(uint64(len(payload)))
+	if len(payload) < c.minSize {
+		out := append([]byte{byte(CompressionNone)}, payload...)
+		c.storedBytes.Add(uint64(len(out)))
+		return out, nil
+	}
+
+	start := time.Now()
+	out := []byte{byte(c.algorithm)}
+	switch c.algorithm {
+	case CompressionZstd, CompressionZstdDict:
+		enc, err := c.encoder()
+		if err != nil {
+			return nil, err
+		}
+		if c.algorithm == CompressionZstdDict {
+			out = binary.BigEndian.AppendUint32(out, c.dictID)
+		}
+		out = enc.EncodeAll(payload, out)
+	default:
+		var buf bytes.Buffer
+		buf.Write(out)
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		out = buf.Bytes()
+	}
+	c.compressTime.Add(int64(time.Since(start)))
+	c.compressed.Add(1)
+	c.storedBytes.Add(uint64(len(out)))
+	return out, nil
+}
+
+// Unmarshal decompresses the payload according to its envelope and deserializes it with the inner codec.
+func (c *CompressingCodec) Unmarshal(data []byte, v any) error {
+	if len(data) < 1 {
+		return ErrInvalidEnvelope
+	}
+	algorithm, payload := CompressionAlgorithm(data[0]), data[1:]
+	if algorithm == CompressionNone {
+		return c.inner.Unmarshal(payload, v)
+	}
+
+	start := time.Now()
+	var (
+		raw []byte
+		err error
+	)
+	switch algorithm {
+	case CompressionZstd:
+		var dec *zstd.Decoder
+		if dec, err = c.decoder(); err == nil {
+			raw, err = dec.DecodeAll(payload, nil)
+		}
+	case CompressionZstdDict:
+		if len(payload) < 4 {
+			return ErrInvalidEnvelope
+		}
+		id := binary.BigEndian.Uint32(payload)
+		if _, ok := c.dictIDs[id]; !ok {
+			return fmt.Errorf("%w: %d", ErrUnknownDictionary, id)
+		}
+		var dec *zstd.Decoder
+		if dec, err = c.decoder(); err == nil {
+			raw, err = dec.DecodeAll(payload[4:], nil)
+		}
+	case CompressionGzip:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(payload)); err == nil {
+			raw, err = io.ReadAll(r)
+		}
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownCompression, algorithm)
+	}
+	if err != nil {
+		return err
+	}
+	c.decompressTime.Add(int64(time.Since(start)))
+	return c.inner.Unmarshal(raw, v)
+}
+
+// CompressionStats returns the statistics accumulated since the codec was created.
+func (c *CompressingCodec) CompressionStats() CompressionStats {
+	return CompressionStats{
+		Values:           c.values.Load(),
+		CompressedValues: c.compressed.Load(),
+		RawBytes:         c.rawBytes.Load(),
+		StoredBytes:      c.storedBytes.Load(),
+		CompressTime:     time.Duration(c.compressTime.Load()),
+		DecompressTime:   time.Duration(c.decompressTime.Load()),
+	}
+}
+
+// compressionStats returns the statistics of the codec, or of the codecs it wraps, when one of them reports them.
+func compressionStats(c Codec) (CompressionStats, bool) {
+	for c != nil {
+		if reporter, ok := c.(CompressionReporter); ok {
+			return reporter.CompressionStats(), true
+		}
+		switch w := c.(type) {
+		case *ChecksumCodec:
+			c = w.inner
+		case *EncryptingCodec:
+			c = w.inner
+		case *HookedCodec:
+			c = w.inner
+		default:
+			return CompressionStats{}, false
+		}
+	}
+	return CompressionStats{}, false
+}
+
+// codecProvider is implemented by stores serializing values with a Codec.
+type codecProvider interface {
+	valueCodec() Codec
+}
+
+// Compression returns the compression statistics of the codec used by the store, when it is or wraps a
+// CompressionReporter such as CompressingCodec. The boolean result is false otherwise.
+func Compression(c any) (CompressionStats, bool) {
+	provider, ok := c.(codecProvider)
+	if !ok {
+		return CompressionStats{}, false
+	}
+	return compressionStats(provider.valueCodec())
+}
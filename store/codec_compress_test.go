@@ -0,0 +1,98 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompressingCodec verifies round trips for every algorithm, the size threshold and the statistics.
+func TestCompressingCodec(t *testing.T) {
+	large := strings.Repeat("compressible ", 100)
+	for _, algorithm := range []CompressionAlgorithm{CompressionGzip, CompressionZstd} {
+		codec, err := NewCompressingCodec(nil, algorithm, 64)
+		require.NoError(t, err)
+
+		data, err := codec.Marshal(large)
+		require.NoError(t, err)
+		assert.Equal(t, byte(algorithm), data[0])
+		var decoded string
+		require.NoError(t, codec.Unmarshal(data, &decoded))
+		assert.Equal(t, large, decoded)
+
+		small, err := codec.Marshal("tiny")
+		require.NoError(t, err)
+		assert.Equal(t, byte(CompressionNone), small[0])
+		require.NoError(t, codec.Unmarshal(small, &decoded))
+		assert.Equal(t, "tiny", decoded)
+
+		stats := codec.CompressionStats()
+		assert.Equal(t, uint64(2), stats.Values)
+		assert.Equal(t, uint64(1), stats.CompressedValues)
+		assert.Less(t, stats.Ratio(), 0.5)
+
+		assert.ErrorIs(t, codec.Unmarshal([]byte{9, 1}, &decoded), ErrUnknownCompression)
+	}
+
+	_, err := NewCompressingCodec(nil, CompressionNone, 0)
+	assert.ErrorIs(t, err, ErrUnknownCompression)
+}
+
+// TestCompressingCodec_LazyZstd verifies that the Zstandard encoder and decoder are created on first use only, and
+// that a closed codec creates them again when used.
+func TestCompressingCodec_LazyZstd(t *testing.T) {
+	gzipCodec, err := NewCompressingCodec(nil, CompressionGzip, 0)
+	require.NoError(t, err)
+	zstdCodec, err := NewCompressingCodec(nil, CompressionZstd, 0)
+	require.NoError(t, err)
+	assert.Nil(t, zstdCodec.zstdEnc)
+	assert.Nil(t, zstdCodec.zstdDec)
+
+	data, err := gzipCodec.Marshal("value")
+	require.NoError(t, err)
+	assert.Nil(t, gzipCodec.zstdEnc)
+	var decoded string
+	require.NoError(t, gzipCodec.Unmarshal(data, &decoded))
+	assert.Nil(t, gzipCodec.zstdDec)
+
+	data, err = zstdCodec.Marshal("value")
+	require.NoError(t, err)
+	assert.NotNil(t, zstdCodec.zstdEnc)
+	assert.Nil(t, zstdCodec.zstdDec)
+	require.NoError(t, gzipCodec.Unmarshal(data, &decoded))
+	assert.Equal(t, "value", decoded)
+	assert.NotNil(t, gzipCodec.zstdDec)
+	assert.Nil(t, gzipCodec.zstdEnc)
+
+	require.NoError(t, zstdCodec.Close())
+	require.NoError(t, gzipCodec.Close())
+	assert.Nil(t, zstdCodec.zstdEnc)
+	assert.Nil(t, gzipCodec.zstdDec)
+	require.NoError(t, zstdCodec.Unmarshal(data, &decoded))
+	assert.Equal(t, "value", decoded)
+	require.NoError(t, zstdCodec.Close())
+}
+
+// TestCompression verifies that stores report the statistics of a compressing codec, even when wrapped.
+func TestCompression(t *testing.T) {
+	compressing, err := NewCompressingCodec(nil, CompressionZstd, 0)
+	require.NoError(t, err)
+	rdb, mock := redismock.NewClientMock()
+	cache := NewRedisCache[string](rdb, "test", time.Hour, WithCodec(NewChecksumCodec(compressing, ChecksumCRC32)))
+
+	mock.Regexp().ExpectSet("test:k", `.*`, time.Hour).SetVal("OK")
+	require.NoError(t, cache.Set(t.Context(), "k", "value"))
+
+	stats, ok := Compression(cache)
+	require.True(t, ok)
+	assert.Equal(t, uint64(1), stats.Values)
+
+	_, ok = Compression(NewRedisCache[string](rdb, "plain", time.Hour))
+	assert.False(t, ok)
+	_, ok = Compression(NewLRUCache[string](1))
+	assert.False(t, ok)
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	// ErrUnknownDictionary is returned when a stored value was compressed with a dictionary the codec does not know.
+	ErrUnknownDictionary = errors.New("value compressed with unknown dictionary")
+	// ErrInvalidDictionary is returned for dictionaries without an ID or content.
+	ErrInvalidDictionary = errors.New("invalid zstd dictionary")
+)
+
+// ZstdDictionary is a raw Zstandard dictionary shared by the writers and readers of a cache namespace. Small similar
+// values, such as JSON documents sharing field names, compress several times better when primed with one. ID must
+// be non-zero and unique within the namespace, since it is stored with every value compressed with the dictionary.
+type ZstdDictionary struct {
+	ID      uint32
+	Content []byte
+}
+
+// TrainZstdDictionary builds a dictionary of at most maxSize bytes from sample payloads, typically values produced
+// by the inner codec of the namespace. Samples seen more often are preferred and placed at the end of the
+// dictionary, where matches are cheapest to reference.
+func TrainZstdDictionary(id uint32, samples [][]byte, maxSize int) (ZstdDictionary, error) {
+	if id == 0 || maxSize <= 0 {
+		return ZstdDictionary{}, ErrInvalidDictionary
+	}
+	counts := make(map[string]int, len(samples))
+	unique := make([]string, 0, len(samples))
+	for _, sample := range samples {
+		if len(sample) == 0 {
+			continue
+		}
+		if counts[string(sample)] == 0 {
+			unique = append(unique, string(sample))
+		}
+		counts[string(sample)]++
+	}
+	if len(unique) == 0 {
+		return ZstdDictionary{}, fmt.Errorf("%w: no samples", ErrInvalidDictionary)
+	}
+	slices.SortStableFunc(unique, func(a, b string) int { return counts[b] - counts[a] })
+
+	var selected []string
+	size := 0
+	for _, sample := range unique {
+		if size+len(sample) > maxSize {
+			continue
+		}
+		selected = append(selected, sample)
+		size += len(sample)
+	}
+	if len(selected) == 0 {
+		selected, size = []string{unique[0][len(unique[0])-maxSize:]}, maxSize
+	}
+
+	content := make([]byte, 0, size)
+	for i := len(selected) - 1; i >= 0; i-- {
+		content = append(content, selected[i]...)
+	}
+	return ZstdDictionary{ID: id, Content: content}, nil
+}
+
+// MarshalBinary encodes the dictionary so it can be shipped to other processes, for example through a shared store.
+func (d ZstdDictionary) MarshalBinary() ([]byte, error) {
+	if d.ID == 0 || len(d.Content) == 0 {
+		return nil, ErrInvalidDictionary
+	}
+	return append(binary.BigEndian.AppendUint32(nil, d.ID), d.Content...), nil
+}
+
+// UnmarshalBinary decodes a dictionary encoded by MarshalBinary.
+func (d *ZstdDictionary) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 {
+		return ErrInvalidDictionary
+	}
+	id := binary.BigEndian.Uint32(data)
+	if id == 0 {
+		return ErrInvalidDictionary
+	}
+	d.ID, d.Content = id, slices.Clone(data[4:])
+	return nil
+}
+
+// NewZstdDictionaryCodec creates a compressing codec that compresses payloads of at least minSize bytes with
+// Zstandard primed with dict. Previous dictionaries of the namespace remain readable, so a newly trained dictionary
+// can be rolled out while values written with the old one are still cached. Values written by a plain
+// CompressingCodec are readable too.
+func NewZstdDictionaryCodec(inner Codec, minSize int, dict ZstdDictionary, previous ...ZstdDictionary) (*CompressingCodec, error) {
+	if inner == nil {
+		inner = JSONCodec{}
+	}
+	dicts := append([]ZstdDictionary{dict}, previous...)
+	ids := make(map[uint32]struct{}, len(dicts))
+	decoderOptions := make([]zstd.DOption, 0, len(dicts))
+	for _, d := range dicts {
+		if d.ID == 0 || len(d.Content) == 0 {
+			return nil, ErrInvalidDictionary
+		}
+		ids[d.ID] = struct{}{}
+		decoderOptions = append(decoderOptions, zstd.WithDecoderDictRaw(d.ID, d.Content))
+	}
+	c := &CompressingCodec{
+		inner:          inner,
+		algorithm:      CompressionZstdDict,
+		minSize:        minSize,
+		dictID:         dict.ID,
+		dictIDs:        ids,
+		encoderOptions: []zstd.EOption{zstd.WithEncoderDictRaw(dict.ID, dict.Content)},
+		decoderOptions: decoderOptions,
+	}
+	// Create the encoder now, since every write needs it, so an unusable dictionary is reported here.
+	if _, err := c.encoder(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
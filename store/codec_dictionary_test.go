@@ -0,0 +1,75 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dictionaryUser struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Country   string `json:"country"`
+	Subscribe bool   `json:"subscribedToNewsletter"`
+}
+
+// TestZstdDictionaryCodec verifies that a trained dictionary shrinks small values, that previous dictionaries stay
+// readable and that unknown dictionaries are rejected.
+func TestZstdDictionaryCodec(t *testing.T) {
+	samples := make([][]byte, 0, 200)
+	for i := range 200 {
+		sample, err := json.Marshal(dictionaryUser{ID: i, Name: fmt.Sprintf("user %d", i), Email: fmt.Sprintf("user%d@example.com", i), Country: "IT"})
+		require.NoError(t, err)
+		samples = append(samples, sample)
+	}
+	dict, err := TrainZstdDictionary(1, samples, 4096)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(dict.Content), 4096)
+
+	codec, err := NewZstdDictionaryCodec(nil, 0, dict)
+	require.NoError(t, err)
+	plain, err := NewCompressingCodec(nil, CompressionZstd, 0)
+	require.NoError(t, err)
+
+	value := dictionaryUser{ID: 1000, Name: "user 1000", Email: "user1000@example.com", Country: "IT"}
+	data, err := codec.Marshal(value)
+	require.NoError(t, err)
+	assert.Equal(t, byte(CompressionZstdDict), data[0])
+	plainData, err := plain.Marshal(value)
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(plainData))
+
+	var decoded dictionaryUser
+	require.NoError(t, codec.Unmarshal(data, &decoded))
+	assert.Equal(t, value, decoded)
+	require.NoError(t, codec.Unmarshal(plainData, &decoded))
+	assert.ErrorIs(t, plain.Unmarshal(data, &decoded), ErrUnknownDictionary)
+
+	next, err := TrainZstdDictionary(2, samples[100:], 2048)
+	require.NoError(t, err)
+	rotated, err := NewZstdDictionaryCodec(nil, 0, next, dict)
+	require.NoError(t, err)
+	require.NoError(t, rotated.Unmarshal(data, &decoded))
+	assert.Equal(t, value, decoded)
+
+	_, err = TrainZstdDictionary(0, samples, 1024)
+	assert.ErrorIs(t, err, ErrInvalidDictionary)
+	_, err = NewZstdDictionaryCodec(nil, 0, ZstdDictionary{})
+	assert.ErrorIs(t, err, ErrInvalidDictionary)
+}
+
+// TestZstdDictionaryBinary verifies that dictionaries survive a MarshalBinary/UnmarshalBinary round trip.
+func TestZstdDictionaryBinary(t *testing.T) {
+	dict := ZstdDictionary{ID: 7, Content: []byte(`{"name":"","email":""}`)}
+	data, err := dict.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded ZstdDictionary
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, dict, decoded)
+	assert.ErrorIs(t, decoded.UnmarshalBinary([]byte{0, 0, 0, 0, 1}), ErrInvalidDictionary)
+}
@@ -0,0 +1,308 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// encryptedEnvelopeVersion identifies the layout of values produced by EncryptingCodec.
+const encryptedEnvelopeVersion byte = 1
+
+var (
+	// ErrUnknownEncryptionKey is returned when a value was encrypted with a key that is not part of the key ring.
+	ErrUnknownEncryptionKey = errors.New("value encrypted with unknown key")
+	// ErrInvalidEnvelope is returned when a stored value is not a valid encrypted envelope.
+	ErrInvalidEnvelope = errors.New("invalid encrypted envelope")
+	// ErrDerivedKeyRing is returned when the key ring of a codec returned by ForNamespace is modified directly.
+	ErrDerivedKeyRing = errors.New("keys of a namespace codec are managed by the codec it was derived from")
+)
+
+// namespaceKeyInfo prefixes the namespace in the HKDF info of the keys derived by ForNamespace.
+const namespaceKeyInfo = "echocache namespace key: "
+
+// KeyRing holds the AES keys known to an EncryptingCodec, indexed by key ID.
+// CurrentID selects the key used for new writes; every other key is only used to decrypt existing values.
+type KeyRing struct {
+	CurrentID string
+	Keys      map[string][]byte
+}
+
+// EncryptingCodec wraps another Codec and encrypts its output with AES-GCM.
+// The ID of the key used is stored in the envelope, so values written with older keys stay readable
+// while new values are always encrypted with the current key.
+type EncryptingCodec struct {
+	inner     Codec
+	mu        sync.RWMutex
+	currentID string
+	keys      map[string][]byte
+	aeads     map[string]cipher.AEAD
+	parent    *EncryptingCodec
+	namespace string
+	sources   map[string][]byte
+}
+
+// NewEncryptingCodec creates an encrypting codec around inner using the given key ring.
+// Keys must be 16, 24 or 32 bytes long and the current key ID must be present in the ring.
+func NewEncryptingCodec(inner Codec, ring KeyRing) (*EncryptingCodec, error) {
+	if inner == nil {
+		inner = JSONCodec{}
+	}
+	c := &EncryptingCodec{
+		inner: inner,
+		keys:  make(map[string][]byte, len(ring.Keys)),
+		aeads: make(map[string]cipher.AEAD, len(ring.Keys)),
+	}
+	for id, key := range ring.Keys {
+		if err := c.AddKey(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.SetCurrentKey(ring.CurrentID); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AddKey registers an additional key, making values encrypted with it readable.
+func (c *EncryptingCodec) AddKey(id string, key []byte) error {
+	if c.parent != nil {
+		return ErrDerivedKeyRing
+	}
+	if id == "" || len(id) > 255 {
+		return fmt.Errorf("invalid encryption key id %q", id)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[id] = bytes.Clone(key)
+	c.aeads[id] = aead
+	return nil
+}
+
+// RemoveKey retires a key. Values still encrypted with it can no longer be decrypted.
+func (c *EncryptingCodec) RemoveKey(id string) error {
+	if c.parent != nil {
+		return ErrDerivedKeyRing
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id == c.currentID {
+		return fmt.Errorf("cannot remove current encryption key %q", id)
+	}
+	delete(c.keys, id)
+	delete(c.aeads, id)
+	return nil
+}
+
+// SetCurrentKey selects the key used to encrypt new values. The key must have been registered first.
+func (c *EncryptingCodec) SetCurrentKey(id string) error {
+	if c.parent != nil {
+		return ErrDerivedKeyRing
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.aeads[id]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownEncryptionKey, id)
+	}
+	c.currentID = id
+	return nil
+}
+
+// CurrentKeyID returns the ID of the key used for new writes.
+func (c *EncryptingCodec) CurrentKeyID() string {
+	if c.parent != nil {
+		return c.parent.CurrentKeyID()
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.currentID
+}
+
+// ForNamespace returns a codec encrypting with keys derived, with HKDF-SHA256, from the keys of c and the namespace,
+// such as a tenant ID. Giving each tenant's store its own namespace codec means that a derived key leaked for one
+// tenant cannot decrypt the values of the others in a shared backend. The derived codec follows the key ring of c:
+// keys added, retired or made current on c apply to it, under the same IDs, so rotation and ReEncrypt work per
+// namespace. Namespace codecs can be derived again for nested scopes.
+func (c *EncryptingCodec) ForNamespace(namespace string) *EncryptingCodec {
+	return &EncryptingCodec{
+		inner:     c.inner,
+		aeads:     make(map[string]cipher.AEAD),
+		parent:    c,
+		namespace: namespace,
+		sources:   make(map[string][]byte),
+	}
+}
+
+// Namespace returns the namespace the codec keys are derived for, empty for a codec created with NewEncryptingCodec.
+func (c *EncryptingCodec) Namespace() string {
+	return c.namespace
+}
+
+// key returns the raw key registered, or derived, under id.
+func (c *EncryptingCodec) key(id string) ([]byte, bool) {
+	if c.parent == nil {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		key, ok := c.keys[id]
+		return key, ok
+	}
+	source, ok := c.parent.key(id)
+	if !ok {
+		return nil, false
+	}
+	key, err := hkdf.Key(sha256.New, source, nil, namespaceKeyInfo+c.namespace, len(source))
+	return key, err == nil
+}
+
+// aead returns the cipher of the key registered, or derived, under id. Derived ciphers are cached as long as the
+// key they derive from is unchanged.
+func (c *EncryptingCodec) aead(id string) (cipher.AEAD, bool) {
+	if c.parent == nil {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		aead, ok := c.aeads[id]
+		return aead, ok
+	}
+	source, ok := c.parent.key(id)
+	if !ok {
+		return nil, false
+	}
+	c.mu.RLock()
+	aead, cached := c.aeads[id]
+	cached = cached && bytes.Equal(c.sources[id], source)
+	c.mu.RUnlock()
+	if cached {
+		return aead, true
+	}
+	key, ok := c.key(id)
+	if !ok {
+		return nil, false
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aeads[id] = aead
+	c.sources[id] = source
+	return aead, true
+}
+
+// newGCM returns an AES-GCM cipher using key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Marshal serializes the value with the inner codec and encrypts it with the current key.
+// The envelope layout is: version | key ID length | key ID | nonce | ciphertext.
+func (c *EncryptingCodec) Marshal(v any) ([]byte, error) {
+	plain, err := c.inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	id := c.CurrentKeyID()
+	aead, ok := c.aead(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEncryptionKey, id)
+	}
+
+	header := make([]byte, 0, 2+len(id)+aead.NonceSize())
+	header = append(header, encryptedEnvelopeVersion, byte(len(id)))
+	header = append(header, id...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(header, nonce...)
+	return aead.Seal(out, nonce, plain, []byte(id)), nil
+}
+
+// Unmarshal decrypts the envelope with the key referenced in it and deserializes the result with the inner codec.
+func (c *EncryptingCodec) Unmarshal(data []byte, v any) error {
+	id, body, err := parseEncryptedEnvelope(data)
+	if err != nil {
+		return err
+	}
+	aead, ok := c.aead(id)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownEncryptionKey, id)
+	}
+	if len(body) < aead.NonceSize() {
+		return ErrInvalidEnvelope
+	}
+	nonce, ciphertext := body[:aead.NonceSize()], body[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return err
+	}
+	return c.inner.Unmarshal(plain, v)
+}
+
+// KeyID returns the ID of the key a serialized value was encrypted with.
+func (c *EncryptingCodec) KeyID(data []byte) (string, error) {
+	id, _, err := parseEncryptedEnvelope(data)
+	return id, err
+}
+
+// parseEncryptedEnvelope splits an envelope into its key ID and the nonce-prefixed ciphertext.
+func parseEncryptedEnvelope(data []byte) (string, []byte, error) {
+	if len(data) < 2 || data[0] != encryptedEnvelopeVersion {
+		return "", nil, ErrInvalidEnvelope
+	}
+	idLen := int(data[1])
+	if len(data) < 2+idLen {
+		return "", nil, ErrInvalidEnvelope
+	}
+	return string(data[2 : 2+idLen]), data[2+idLen:], nil
+}
+
+// ReEncrypt rewrites every entry of the cache matching the glob-style pattern, so that values encrypted with
+// older keys are re-encrypted with the current key of the store's EncryptingCodec. Once a sweep completes,
+// retired keys can be removed from the key ring. Rewriting an entry resets its TTL on stores that apply one.
+// The cache must implement Scanner. Returns the number of entries rewritten.
+func ReEncrypt[T any](ctx context.Context, c Cacher[T], pattern string) (int, error) {
+	scanner, ok := c.(Scanner)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	keys, err := scanner.Scan(ctx, pattern, 0)
+	if err != nil {
+		return 0, err
+	}
+	rewritten := 0
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return rewritten, err
+		}
+		value, exists, err := c.Get(ctx, key)
+		if err != nil {
+			slog.Warn("Cannot re-encrypt cache entry", slog.String("error", err.Error()), slog.String("cacheKey", key))
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if err := c.Set(ctx, key, value); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
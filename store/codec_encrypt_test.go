@@ -0,0 +1,161 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testKeyV1 = bytes.Repeat([]byte{1}, 32)
+	testKeyV2 = bytes.Repeat([]byte{2}, 32)
+)
+
+// TestEncryptingCodec_RoundTrip verifies that encrypted values can be decrypted and carry the current key ID.
+func TestEncryptingCodec_RoundTrip(t *testing.T) {
+	codec, err := NewEncryptingCodec(JSONCodec{}, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": testKeyV1}})
+	require.NoError(t, err)
+
+	data, err := codec.Marshal(map[string]int{"a": 1})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"a"`)
+
+	id, err := codec.KeyID(data)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", id)
+
+	var decoded map[string]int
+	require.NoError(t, codec.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]int{"a": 1}, decoded)
+}
+
+// TestEncryptingCodec_Rotation verifies that old values stay readable after rotation and new ones use the current key.
+func TestEncryptingCodec_Rotation(t *testing.T) {
+	codec, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": testKeyV1}})
+	require.NoError(t, err)
+	old, err := codec.Marshal("secret")
+	require.NoError(t, err)
+
+	require.NoError(t, codec.AddKey("v2", testKeyV2))
+	require.NoError(t, codec.SetCurrentKey("v2"))
+	assert.Error(t, codec.RemoveKey("v2"))
+
+	var decoded string
+	require.NoError(t, codec.Unmarshal(old, &decoded))
+	assert.Equal(t, "secret", decoded)
+
+	fresh, err := codec.Marshal("secret")
+	require.NoError(t, err)
+	id, _ := codec.KeyID(fresh)
+	assert.Equal(t, "v2", id)
+
+	require.NoError(t, codec.RemoveKey("v1"))
+	assert.ErrorIs(t, codec.Unmarshal(old, &decoded), ErrUnknownEncryptionKey)
+}
+
+// TestEncryptingCodec_Errors verifies rejection of invalid configurations and tampered envelopes.
+func TestEncryptingCodec_Errors(t *testing.T) {
+	_, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "missing", Keys: map[string][]byte{"v1": testKeyV1}})
+	assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+
+	_, err = NewEncryptingCodec(nil, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": []byte("short")}})
+	assert.Error(t, err)
+
+	codec, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": testKeyV1}})
+	require.NoError(t, err)
+	data, _ := codec.Marshal("value")
+	data[len(data)-1] ^= 0xff
+
+	var decoded string
+	assert.Error(t, codec.Unmarshal(data, &decoded))
+	assert.ErrorIs(t, codec.Unmarshal([]byte(`"plain"`), &decoded), ErrInvalidEnvelope)
+}
+
+// TestEncryptingCodec_ForNamespace verifies that namespace codecs cannot decrypt each other's values and follow the
+// key rotation of the codec they were derived from.
+func TestEncryptingCodec_ForNamespace(t *testing.T) {
+	root, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": testKeyV1}})
+	require.NoError(t, err)
+	acme, globex := root.ForNamespace("acme"), root.ForNamespace("globex")
+	assert.Equal(t, "acme", acme.Namespace())
+
+	data, err := acme.Marshal("secret")
+	require.NoError(t, err)
+	var decoded string
+	require.NoError(t, acme.Unmarshal(data, &decoded))
+	assert.Equal(t, "secret", decoded)
+	assert.Error(t, globex.Unmarshal(data, &decoded))
+	assert.Error(t, root.Unmarshal(data, &decoded))
+	assert.Error(t, acme.ForNamespace("eu").Unmarshal(data, &decoded))
+
+	require.NoError(t, root.AddKey("v2", testKeyV2))
+	require.NoError(t, root.SetCurrentKey("v2"))
+	fresh, err := acme.Marshal("secret")
+	require.NoError(t, err)
+	id, _ := acme.KeyID(fresh)
+	assert.Equal(t, "v2", id)
+	require.NoError(t, acme.Unmarshal(data, &decoded))
+
+	require.NoError(t, root.RemoveKey("v1"))
+	assert.ErrorIs(t, acme.Unmarshal(data, &decoded), ErrUnknownEncryptionKey)
+	assert.ErrorIs(t, acme.AddKey("v3", testKeyV1), ErrDerivedKeyRing)
+	assert.ErrorIs(t, acme.SetCurrentKey("v2"), ErrDerivedKeyRing)
+}
+
+// TestEncryptingCodec_RedisStore verifies that the Redis store writes and reads encrypted values.
+func TestEncryptingCodec_RedisStore(t *testing.T) {
+	ctx := context.TODO()
+	codec, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": testKeyV1}})
+	require.NoError(t, err)
+	rdb, mock := redismock.NewClientMock()
+	cache := NewRedisCache[string](rdb, "test", time.Hour, WithCodec(codec))
+
+	data, _ := codec.Marshal("value")
+	mock.ExpectGet("test:key").SetVal(string(data))
+
+	value, found, err := cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+}
+
+// TestReEncrypt verifies that the sweep rewrites every matching entry of a codec-backed store with the current key,
+// so that the stored bytes decrypt with the new key only.
+func TestReEncrypt(t *testing.T) {
+	ctx := context.Background()
+	codec, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": testKeyV1}})
+	require.NoError(t, err)
+	server := miniredis.RunT(t)
+	cache := NewRedisCache[string](redis.NewClient(&redis.Options{Addr: server.Addr()}), "test", time.Hour, WithCodec(codec))
+	require.NoError(t, cache.Set(ctx, "a", "1"))
+	require.NoError(t, cache.Set(ctx, "b", "2"))
+
+	require.NoError(t, codec.AddKey("v2", testKeyV2))
+	require.NoError(t, codec.SetCurrentKey("v2"))
+	rewritten, err := ReEncrypt[string](ctx, cache, "*")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rewritten)
+
+	onlyV1, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "v1", Keys: map[string][]byte{"v1": testKeyV1}})
+	require.NoError(t, err)
+	onlyV2, err := NewEncryptingCodec(nil, KeyRing{CurrentID: "v2", Keys: map[string][]byte{"v2": testKeyV2}})
+	require.NoError(t, err)
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		raw, err := server.Get("test:" + key)
+		require.NoError(t, err)
+		var value string
+		require.NoError(t, onlyV2.Unmarshal([]byte(raw), &value))
+		assert.Equal(t, want, value)
+		assert.ErrorIs(t, onlyV1.Unmarshal([]byte(raw), &value), ErrUnknownEncryptionKey)
+	}
+
+	_, err = ReEncrypt[string](ctx, NewSingleCache[string](time.Minute), "*")
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"reflect"
+)
+
+// SerdeHooks transform values around serialization. BeforeMarshal returns the value actually encoded, so it must
+// not modify its argument in place; AfterUnmarshal receives a pointer to the freshly decoded value and may modify it.
+// Either hook may be nil.
+type SerdeHooks struct {
+	BeforeMarshal  func(v any) (any, error)
+	AfterUnmarshal func(v any) error
+}
+
+// HookedCodec wraps another Codec and runs SerdeHooks around it, e.g. to strip or tokenize sensitive fields before
+// values reach a shared backend. Use it per cache with WithCodec.
+type HookedCodec struct {
+	inner Codec
+	hooks SerdeHooks
+}
+
+// NewHookedCodec creates a codec running hooks around inner, which defaults to JSONCodec when nil.
+func NewHookedCodec(inner Codec, hooks SerdeHooks) *HookedCodec {
+	if inner == nil {
+		inner = JSONCodec{}
+	}
+	return &HookedCodec{inner: inner, hooks: hooks}
+}
+
+// Marshal applies the BeforeMarshal hook and encodes its result with the inner codec.
+func (c *HookedCodec) Marshal(v any) ([]byte, error) {
+	if c.hooks.BeforeMarshal != nil {
+		var err error
+		if v, err = c.hooks.BeforeMarshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return c.inner.Marshal(v)
+}
+
+// Unmarshal decodes data with the inner codec and applies the AfterUnmarshal hook.
+func (c *HookedCodec) Unmarshal(data []byte, v any) error {
+	if err := c.inner.Unmarshal(data, v); err != nil {
+		return err
+	}
+	if c.hooks.AfterUnmarshal != nil {
+		return c.hooks.AfterUnmarshal(v)
+	}
+	return nil
+}
+
+// TypedSerdeHooks builds SerdeHooks operating on values of type T, including the T wrapped by StaleValue in
+// stale-while-revalidate stores. Values of other types are passed through unchanged. Either function may be nil.
+func TypedSerdeHooks[T any](before func(T) (T, error), after func(*T) error) SerdeHooks {
+	hooks := SerdeHooks{}
+	if before != nil {
+		hooks.BeforeMarshal = func(v any) (any, error) {
+			switch value := v.(type) {
+			case T:
+				return before(value)
+			case StaleValue[T]:
+				var err error
+				value.Value, err = before(value.Value)
+				return value, err
+			}
+			return v, nil
+		}
+	}
+	if after != nil {
+		hooks.AfterUnmarshal = func(v any) error {
+			switch value := v.(type) {
+			case *T:
+				return after(value)
+			case *StaleValue[T]:
+				return after(&value.Value)
+			}
+			return nil
+		}
+	}
+	return hooks
+}
+
+// SensitiveTag is the struct tag marking fields handled by RedactSensitive: `echocache:"sensitive"`.
+const SensitiveTag = "echocache"
+
+// Tokenizer replaces sensitive strings with opaque tokens before they are stored and resolves them on read,
+// typically backed by a vault or a tokenization service.
+type Tokenizer interface {
+	Tokenize(value string) (string, error)
+	Detokenize(token string) (string, error)
+}
+
+// RedactSensitive returns SerdeHooks handling the exported struct fields tagged `echocache:"sensitive"`, at any depth.
+// With a nil tokenizer the fields are stripped before serialization and read back blank. Otherwise string fields
+// are replaced by their token and restored on read, while fields of other types are stripped.
+// The caller's value is never modified: it is deep-copied before redaction.
+func RedactSensitive(tokenizer Tokenizer) SerdeHooks {
+	return SerdeHooks{
+		BeforeMarshal: func(v any) (any, error) {
+			if v == nil || !hasSensitiveFields(reflect.TypeOf(v), map[reflect.Type]bool{}) {
+				return v, nil
+			}
+			cp := reflect.New(reflect.TypeOf(v)).Elem()
+			cp.Set(reflect.ValueOf(DeepCopy(v)))
+			if err := redact(cp, false, func(s string) (string, error) {
+				if tokenizer == nil {
+					return "", nil
+				}
+				return tokenizer.Tokenize(s)
+			}); err != nil {
+				return nil, err
+			}
+			return cp.Interface(), nil
+		},
+		AfterUnmarshal: func(v any) error {
+			if tokenizer == nil {
+				return nil
+			}
+			return redact(reflect.ValueOf(v), false, tokenizer.Detokenize)
+		},
+	}
+}
+
+// hasSensitiveFields reports whether values of type t may contain fields tagged as sensitive.
+func hasSensitiveFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return hasSensitiveFields(t.Elem(), seen)
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.IsExported() && (f.Tag.Get(SensitiveTag) == "sensitive" || hasSensitiveFields(f.Type, seen)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redact walks v, which must be settable where sensitive fields are found, and rewrites sensitive fields with
+// transform. Sensitive fields that are not strings are reset to their zero value.
+func redact(v reflect.Value, sensitive bool, transform func(string) (string, error)) error {
+	if sensitive {
+		if v.Kind() == reflect.String {
+			if v.Len() == 0 {
+				return nil
+			}
+			s, err := transform(v.String())
+			if err != nil {
+				return err
+			}
+			v.SetString(s)
+			return nil
+		}
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return redact(v.Elem(), false, transform)
+		}
+	case reflect.Interface:
+		if !v.IsNil() && v.CanSet() {
+			elem := reflect.New(v.Elem().Type()).Elem()
+			elem.Set(v.Elem())
+			if err := redact(elem, false, transform); err != nil {
+				return err
+			}
+			v.Set(elem)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := redact(v.Index(i), false, transform); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := redact(elem, false, transform); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := redact(v.Field(i), t.Field(i).Tag.Get(SensitiveTag) == "sensitive", transform); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// account is a cached value carrying sensitive fields.
+type account struct {
+	Name    string
+	Email   string `echocache:"sensitive"`
+	PIN     int    `echocache:"sensitive"`
+	Backup  *account
+	Aliases []account
+}
+
+// reverseTokenizer tokenizes strings by reversing them, which makes tokens easy to assert on.
+type reverseTokenizer struct{}
+
+// Tokenize returns the reversed value with a token prefix.
+func (reverseTokenizer) Tokenize(value string) (string, error) {
+	return "tok:" + reverse(value), nil
+}
+
+// Detokenize reverses a token produced by Tokenize.
+func (reverseTokenizer) Detokenize(token string) (string, error) {
+	return reverse(strings.TrimPrefix(token, "tok:")), nil
+}
+
+// reverse returns s with its bytes in reverse order.
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// TestRedactSensitive verifies that sensitive fields are stripped or tokenized at any depth without touching the
+// caller's value, and restored on read when a tokenizer is configured.
+func TestRedactSensitive(t *testing.T) {
+	value := account{
+		Name:    "alice",
+		Email:   "alice@example.com",
+		PIN:     1234,
+		Backup:  &account{Name: "bob", Email: "bob@example.com"},
+		Aliases: []account{{Name: "al", Email: "al@example.com"}},
+	}
+
+	stripped := NewHookedCodec(nil, RedactSensitive(nil))
+	data, err := stripped.Marshal(value)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "example.com")
+	assert.Equal(t, "alice@example.com", value.Email, "the caller's value is not modified")
+	var decoded account
+	require.NoError(t, stripped.Unmarshal(data, &decoded))
+	assert.Equal(t, "alice", decoded.Name)
+	assert.Empty(t, decoded.Email)
+	assert.Zero(t, decoded.PIN)
+
+	tokenized := NewHookedCodec(nil, RedactSensitive(reverseTokenizer{}))
+	data, err = tokenized.Marshal(StaleValue[account]{Value: value, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "tok:moc.elpmaxe@bob")
+	var stale StaleValue[account]
+	require.NoError(t, tokenized.Unmarshal(data, &stale))
+	assert.Equal(t, "alice@example.com", stale.Value.Email)
+	assert.Equal(t, "bob@example.com", stale.Value.Backup.Email)
+	assert.Equal(t, "al@example.com", stale.Value.Aliases[0].Email)
+	assert.Zero(t, stale.Value.PIN, "non-string sensitive fields are stripped")
+}
+
+// TestTypedSerdeHooks verifies typed hooks applied through a store, including stale-while-revalidate envelopes.
+func TestTypedSerdeHooks(t *testing.T) {
+	hooks := TypedSerdeHooks[account](func(a account) (account, error) {
+		a.Email = ""
+		return a, nil
+	}, func(a *account) error {
+		a.Email = "redacted"
+		return nil
+	})
+	codec := NewHookedCodec(JSONCodec{}, hooks)
+
+	data, err := codec.Marshal(StaleValue[account]{Value: account{Name: "alice", Email: "a@b"}})
+	require.NoError(t, err)
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.NotContains(t, string(raw["Value"]), "a@b")
+
+	var decoded account
+	require.NoError(t, codec.Unmarshal([]byte(`{"Name":"alice"}`), &decoded))
+	assert.Equal(t, "redacted", decoded.Email)
+
+	var other string
+	require.NoError(t, codec.Unmarshal([]byte(`"x"`), &other), "other types pass through")
+	assert.Equal(t, "x", other)
+
+}
@@ -2,9 +2,14 @@ package store
 
 import (
 	"context"
+	"errors"
+	"path"
 	"time"
 )
 
+// ErrNotSupported is returned when an optional operation is not supported by the underlying store.
+var ErrNotSupported = errors.New("operation not supported by the underlying store")
+
 // RefreshFunc defines a function type for refreshing or computing a value in a cache, returning the value and an error.
 type RefreshFunc[T any] func(ctx context.Context) (T, error)
 
@@ -14,17 +19,124 @@ type Cacher[T any] interface {
 	Set(ctx context.Context, key string, value T) error
 }
 
+// RefreshLocker is implemented by caches able to coordinate refreshes of the same key across processes.
+type RefreshLocker interface {
+	TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error)
+	ReleaseRefreshLock(ctx context.Context, key string, randValue string) error
+}
+
 // StaleWhileRevalidateCache is a generic interface for a cache implementing stale-while-revalidate pattern.
 // The cache is capable of storing and retrieving stale values while allowing background refresh of data.
-// It embeds Cacher for basic caching operations and provides methods for managing refresh locks.
+// It embeds Cacher for basic caching operations and RefreshLocker for managing refresh locks.
 type StaleWhileRevalidateCache[T any] interface {
 	Cacher[StaleValue[T]]
-	TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error)
-	ReleaseRefreshLock(ctx context.Context, key string, randValue string) error
+	RefreshLocker
+}
+
+// Deleter is implemented by caches able to remove a single entry. Deleting a missing key is not an error.
+type Deleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Delete removes the key from the cache, returning ErrNotSupported if the cache does not implement Deleter.
+func Delete(ctx context.Context, c any, key string) error {
+	deleter, ok := c.(Deleter)
+	if !ok {
+		return ErrNotSupported
+	}
+	return deleter.Delete(ctx, key)
+}
+
+// Taker is implemented by caches able to atomically read and remove an entry, so that it is consumed at most once.
+type Taker[T any] interface {
+	Take(ctx context.Context, key string) (T, bool, error)
+}
+
+// Take reads and removes the key from the cache in a single step, returning ErrNotSupported if the cache does not implement Taker.
+func Take[T any](ctx context.Context, c Cacher[T], key string) (T, bool, error) {
+	taker, ok := c.(Taker[T])
+	if !ok {
+		var emptyValue T
+		return emptyValue, false, ErrNotSupported
+	}
+	return taker.Take(ctx, key)
+}
+
+// Populator is implemented by caches able to store a value only when the key is missing.
+// PopulateIfAbsent returns true when the value was written and false when the key already held a value.
+type Populator[T any] interface {
+	PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error)
 }
 
-// StaleValue represents a value associated with a timestamp indicating when it was created.
+// PopulateIfAbsent stores the value only if the key is missing, returning ErrNotSupported if the cache does not implement Populator.
+func PopulateIfAbsent[T any](ctx context.Context, c Cacher[T], key string, value T) (bool, error) {
+	populator, ok := c.(Populator[T])
+	if !ok {
+		return false, ErrNotSupported
+	}
+	return populator.PopulateIfAbsent(ctx, key, value)
+}
+
+// TTLSetter is implemented by caches able to store an entry with a time-to-live different from their default one.
+type TTLSetter[T any] interface {
+	SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error
+}
+
+// Scanner is implemented by caches able to enumerate their keys.
+// Scan returns at most limit keys matching the glob-style pattern; a limit <= 0 means no limit.
+type Scanner interface {
+	Scan(ctx context.Context, pattern string, limit int) ([]string, error)
+}
+
+// TTLInspector is implemented by caches able to report the remaining time-to-live of a key.
+// The boolean result is false when the key does not exist or has no expiration.
+type TTLInspector interface {
+	TTL(ctx context.Context, key string) (time.Duration, bool, error)
+}
+
+// VersionedCacher is implemented by caches exposing the revision of their entries, enabling optimistic concurrency:
+// SetIfRevision writes the value only if the entry is still at revision, 0 meaning that the key must not exist, and
+// reports false when another writer changed it first.
+type VersionedCacher[T any] interface {
+	GetVersioned(ctx context.Context, key string) (value T, revision uint64, exists bool, err error)
+	SetIfRevision(ctx context.Context, key string, value T, revision uint64) (bool, error)
+}
+
+// ErrWriteConflict is returned when an ordered write keeps losing the race against concurrent writers.
+var ErrWriteConflict = errors.New("write conflict: entry changed concurrently")
+
+// StaleValue represents a value associated with a timestamp indicating when it was created. Producer identifies the
+// node that computed it, when the cache is configured with a node ID.
 type StaleValue[T any] struct {
 	Value     T
 	CreatedAt time.Time
+	Producer  string `json:",omitempty"`
+}
+
+// createdAt returns the creation time of the stale value, allowing generic code to detect stale-while-revalidate entries.
+func (s StaleValue[T]) createdAt() time.Time {
+	return s.CreatedAt
+}
+
+// timestamped is implemented by values carrying their creation time, such as StaleValue.
+type timestamped interface {
+	createdAt() time.Time
+}
+
+// matchKeys filters keys, ordered from least to most recently used, returning up to limit matches most recent first.
+func matchKeys(keys []string, pattern string, limit int) ([]string, error) {
+	result := make([]string, 0)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		ok, err := path.Match(pattern, keys[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, keys[i])
+		}
+	}
+	return result, nil
 }
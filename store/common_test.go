@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInMemoryStores_CancelledContext verifies that in-memory stores return promptly with the context error once it is done.
+func TestInMemoryStores_CancelledContext(t *testing.T) {
+	wheel := NewTimingWheelCache[string](time.Minute, TimingWheelConfig[string]{})
+	defer wheel.Close()
+
+	tests := []struct {
+		name  string
+		cache Cacher[string]
+	}{
+		{name: "lru", cache: NewLRUCache[string](10)},
+		{name: "lru expirable", cache: NewLRUExpirableCache[string](10, time.Minute)},
+		{name: "single", cache: NewSingleCache[string](time.Minute)},
+		{name: "timing wheel", cache: wheel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, tt.cache.Set(context.Background(), "k", "v"))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, exists, err := tt.cache.Get(ctx, "k")
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.False(t, exists)
+			assert.ErrorIs(t, tt.cache.Set(ctx, "k", "other"), context.Canceled)
+			assert.ErrorIs(t, Delete(ctx, tt.cache, "k"), context.Canceled)
+			_, _, err = Take(ctx, tt.cache, "k")
+			assert.ErrorIs(t, err, context.Canceled)
+
+			value, exists, err := tt.cache.Get(context.Background(), "k")
+			assert.NoError(t, err)
+			assert.True(t, exists)
+			assert.Equal(t, "v", value)
+		})
+	}
+}
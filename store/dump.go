@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// DumpOptions controls which entries are written by Dump and how their values are rendered.
+// Pattern is a glob-style key filter (defaults to "*") and Limit bounds the number of entries (<= 0 means no limit).
+// OmitValues skips values entirely, while Redact, when set, replaces each value with the returned one.
+type DumpOptions struct {
+	Pattern    string
+	Limit      int
+	OmitValues bool
+	Redact     func(key string, value any) any
+}
+
+// DumpRecord is a single NDJSON line written by Dump. Age and TTL are empty when the store cannot report them.
+type DumpRecord struct {
+	Key   string `json:"key"`
+	Age   string `json:"age,omitempty"`
+	TTL   string `json:"ttl,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// Dump writes the entries of the cache as newline-delimited JSON, one DumpRecord per key.
+// The cache must implement Scanner; the entry age is reported for stale-while-revalidate values and the TTL
+// for caches implementing TTLInspector. Returns ErrNotSupported if the cache cannot enumerate its keys.
+func Dump[T any](ctx context.Context, c Cacher[T], w io.Writer, opts DumpOptions) error {
+	scanner, ok := c.(Scanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	pattern := opts.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	keys, err := scanner.Scan(ctx, pattern, opts.Limit)
+	if err != nil {
+		return err
+	}
+
+	inspector, _ := c.(TTLInspector)
+	enc := json.NewEncoder(w)
+	for _, key := range keys {
+		value, exists, err := c.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		record := DumpRecord{Key: key}
+		if ts, ok := any(value).(timestamped); ok {
+			record.Age = time.Since(ts.createdAt()).Round(time.Millisecond).String()
+		}
+		if inspector != nil {
+			ttl, hasTTL, err := inspector.TTL(ctx, key)
+			if err != nil {
+				return err
+			}
+			if hasTTL {
+				record.TTL = ttl.Round(time.Millisecond).String()
+			}
+		}
+		if !opts.OmitValues {
+			record.Value = value
+			if opts.Redact != nil {
+				record.Value = opts.Redact(key, value)
+			}
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
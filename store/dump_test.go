@@ -0,0 +1,63 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDump verifies NDJSON output, age reporting for stale values and value redaction.
+func TestDump(t *testing.T) {
+	ctx := context.Background()
+	cache := NewStaleWhileRevalidateLRUCache[string](10)
+	require.NoError(t, cache.Set(ctx, "user:1", StaleValue[string]{Value: "secret", CreatedAt: time.Now().Add(-time.Minute)}))
+	require.NoError(t, cache.Set(ctx, "order:1", StaleValue[string]{Value: "other", CreatedAt: time.Now()}))
+
+	var buf bytes.Buffer
+	err := Dump[StaleValue[string]](ctx, cache, &buf, DumpOptions{
+		Pattern: "user:*",
+		Redact: func(key string, value any) any {
+			return "[REDACTED]"
+		},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var record DumpRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "user:1", record.Key)
+	assert.Equal(t, "[REDACTED]", record.Value)
+	age, err := time.ParseDuration(record.Age)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, age, time.Minute)
+	assert.Empty(t, record.TTL)
+}
+
+// TestDump_TTLAndOmitValues verifies TTL reporting and value omission.
+func TestDump_TTLAndOmitValues(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTimingWheelCache[int](time.Hour, TimingWheelConfig[int]{})
+	defer cache.Close()
+	require.NoError(t, cache.Set(ctx, "k", 1))
+
+	var buf bytes.Buffer
+	require.NoError(t, Dump[int](ctx, cache, &buf, DumpOptions{OmitValues: true}))
+
+	var record DumpRecord
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "k", record.Key)
+	assert.Nil(t, record.Value)
+	ttl, err := time.ParseDuration(record.TTL)
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 59*time.Minute)
+
+	require.ErrorIs(t, Dump[int](ctx, NewSingleCache[int](time.Minute), &buf, DumpOptions{}), ErrNotSupported)
+}
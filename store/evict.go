@@ -0,0 +1,154 @@
+package store
+
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// EvictReason tells why an entry left an in-memory store.
+type EvictReason int
+
+const (
+	// evictSuppressed marks removals that must not be reported, such as an entry moving to the pinned set.
+	evictSuppressed EvictReason = iota
+	// EvictExpired is reported for entries removed because their time-to-live elapsed.
+	EvictExpired
+	// EvictCapacity is reported for least recently used entries removed to make room for others or after a Resize.
+	EvictCapacity
+	// EvictRemoved is reported for entries removed by Delete, Take or Clear.
+	EvictRemoved
+)
+
+// String returns the name of the reason.
+func (r EvictReason) String() string {
+	switch r {
+	case EvictExpired:
+		return "expired"
+	case EvictCapacity:
+		return "capacity"
+	case EvictRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// WithEvictCallback registers a function called with every entry leaving the LRU stores and the reason it left, so
+// applications can release associated resources or emit metrics. Overwriting a key does not evict it, and pinned
+// entries, which live outside the LRU, are not reported. The callback runs synchronously on the goroutine removing
+// the entry, possibly the background expiration goroutine of the expiring LRU, and must not call back into the store.
+// The timing-wheel store reports expirations in batches through TimingWheelConfig.OnEvict instead.
+func WithEvictCallback[T any](fn func(key string, value T, reason EvictReason)) Option {
+	return func(o *storeOptions) {
+		o.onEvict = fn
+	}
+}
+
+// evictNotifier classifies the removals of an LRU store and reports them to the evict callback.
+// A nil *evictNotifier is valid and reports nothing.
+type evictNotifier[T any] struct {
+	fn       func(key string, value T, reason EvictReason)
+	ttl      time.Duration
+	mu       sync.Mutex
+	removing map[string]EvictReason
+	clearing int
+	expires  map[string]time.Time
+}
+
+// newEvictNotifier returns the notifier configured by the options for a store expiring entries after ttl, zero for
+// none, or nil when no callback matching the value type is configured.
+func newEvictNotifier[T any](o storeOptions, ttl time.Duration) *evictNotifier[T] {
+	if o.onEvict == nil {
+		return nil
+	}
+	fn, ok := o.onEvict.(func(key string, value T, reason EvictReason))
+	if !ok {
+		var zero T
+		slog.Warn("Evict callback does not match the store value type, ignoring it", slog.String("type", reflect.TypeOf(&zero).Elem().String()))
+		return nil
+	}
+	return &evictNotifier[T]{
+		fn:       fn,
+		ttl:      ttl,
+		removing: make(map[string]EvictReason),
+		expires:  make(map[string]time.Time),
+	}
+}
+
+// callback returns the function to register as eviction callback of the underlying LRU, nil without notifier.
+func (n *evictNotifier[T]) callback() func(key string, value T) {
+	if n == nil {
+		return nil
+	}
+	return n.notify
+}
+
+// touch records that the key is being written, so that its expiration can be told from a capacity eviction. It must
+// be called before the entry is added to the LRU, which computes its own deadline afterwards.
+func (n *evictNotifier[T]) touch(key string) {
+	if n == nil || n.ttl <= 0 {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.expires[key] = time.Now().Add(n.ttl)
+}
+
+// remove runs fn, attributing the removal of the key it causes to reason.
+func (n *evictNotifier[T]) remove(key string, reason EvictReason, fn func()) {
+	if n == nil {
+		fn()
+		return
+	}
+	n.mu.Lock()
+	n.removing[key] = reason
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.removing, key)
+		n.mu.Unlock()
+	}()
+	fn()
+}
+
+// clear runs fn, attributing the removals it causes to EvictRemoved.
+func (n *evictNotifier[T]) clear(fn func()) {
+	if n == nil {
+		fn()
+		return
+	}
+	n.mu.Lock()
+	n.clearing++
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		n.clearing--
+		n.mu.Unlock()
+	}()
+	fn()
+}
+
+// notify classifies the removal of the entry and reports it.
+func (n *evictNotifier[T]) notify(key string, value T) {
+	n.mu.Lock()
+	reason, ok := n.removing[key]
+	switch {
+	case ok:
+	case n.clearing > 0:
+		reason = EvictRemoved
+	default:
+		reason = EvictCapacity
+		if expires, ok := n.expires[key]; ok && !time.Now().Before(expires) {
+			reason = EvictExpired
+		}
+	}
+	if reason != evictSuppressed {
+		delete(n.expires, key)
+	}
+	n.mu.Unlock()
+	if reason != evictSuppressed {
+		n.fn(key, value, reason)
+	}
+}
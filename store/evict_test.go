@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evictRecorder collects the evictions reported to its callback.
+type evictRecorder struct {
+	mu      sync.Mutex
+	evicted map[string]EvictReason
+}
+
+func (r *evictRecorder) record(key string, _ int, reason EvictReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evicted[key] = reason
+}
+
+func (r *evictRecorder) get() map[string]EvictReason {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	evicted := make(map[string]EvictReason, len(r.evicted))
+	for k, v := range r.evicted {
+		evicted[k] = v
+	}
+	return evicted
+}
+
+// TestEvictCallback verifies that the LRU stores report every entry leaving them with the reason it left, and
+// nothing for overwrites and pins.
+func TestEvictCallback(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]func(*evictRecorder) Cacher[int]{
+		"LRU": func(r *evictRecorder) Cacher[int] {
+			return NewLRUCache[int](3, WithEvictCallback(r.record))
+		},
+		"LRUExpirable": func(r *evictRecorder) Cacher[int] {
+			return NewLRUExpirableCache[int](3, time.Hour, WithEvictCallback(r.record))
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			r := &evictRecorder{evicted: make(map[string]EvictReason)}
+			c := newStore(r)
+			require.NoError(t, c.Set(ctx, "a", 1))
+			require.NoError(t, c.Set(ctx, "a", 2))
+			require.NoError(t, c.Set(ctx, "b", 3))
+			require.NoError(t, c.Set(ctx, "c", 4))
+			c.(Pinner).Pin("c")
+			require.NoError(t, c.Set(ctx, "d", 5))
+			assert.Equal(t, map[string]EvictReason{}, r.get(), "overwrites and pins are not evictions")
+
+			require.NoError(t, c.Set(ctx, "e", 6))
+			assert.Equal(t, map[string]EvictReason{"a": EvictCapacity}, r.get())
+
+			require.NoError(t, Delete(ctx, c, "b"))
+			_, _, err := Take(ctx, c, "d")
+			require.NoError(t, err)
+			require.NoError(t, Clear(ctx, c))
+			assert.Equal(t, map[string]EvictReason{
+				"a": EvictCapacity, "b": EvictRemoved, "d": EvictRemoved, "e": EvictRemoved,
+			}, r.get())
+		})
+	}
+}
+
+// TestEvictCallbackExpired verifies that the expirable LRU reports entries whose time-to-live elapsed as expired.
+func TestEvictCallbackExpired(t *testing.T) {
+	r := &evictRecorder{evicted: make(map[string]EvictReason)}
+	c := NewLRUExpirableCache[int](3, 50*time.Millisecond, WithEvictCallback(r.record))
+	require.NoError(t, c.Set(context.Background(), "a", 1))
+	assert.Eventually(t, func() bool {
+		return r.get()["a"] == EvictExpired
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "expired", EvictExpired.String())
+}
+
+// TestEvictCallbackType verifies that a callback whose value type does not match the store is ignored.
+func TestEvictCallbackType(t *testing.T) {
+	called := false
+	c := NewLRUCache[string](1, WithEvictCallback(func(string, int, EvictReason) { called = true }))
+	require.NoError(t, c.Set(context.Background(), "a", "1"))
+	require.NoError(t, c.Set(context.Background(), "b", "2"))
+	assert.False(t, called)
+}
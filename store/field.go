@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrFieldNotFound is returned when the path of a projection does not lead to a field of the stored value.
+var ErrFieldNotFound = errors.New("field not found")
+
+// FieldGetter is implemented by caches able to read a single field of a stored document, so that callers needing
+// one field of a large value do not pay for decoding all of it. Path is a dot-separated list of JSON field names,
+// map keys and slice indexes, such as "address.city" or "items.0.id", and dst a pointer receiving the field.
+// GetField returns false when the key is missing and ErrFieldNotFound when the path leads nowhere.
+type FieldGetter interface {
+	GetField(ctx context.Context, key string, path string, dst any) (bool, error)
+}
+
+// GetField reads the field at path of the value stored under key, returning ErrNotSupported if the cache does not
+// implement FieldGetter.
+func GetField[F any](ctx context.Context, c any, key string, path string) (F, bool, error) {
+	var field F
+	getter, ok := c.(FieldGetter)
+	if !ok {
+		return field, false, ErrNotSupported
+	}
+	found, err := getter.GetField(ctx, key, path, &field)
+	return field, found, err
+}
+
+// getField implements FieldGetter for in-memory stores, which hold decoded values and only walk them.
+func getField[T any](ctx context.Context, c Cacher[T], key string, path string, dst any) (bool, error) {
+	value, exists, err := c.Get(ctx, key)
+	if err != nil || !exists {
+		return false, err
+	}
+	return true, projectField(value, path, dst)
+}
+
+// projectField copies the field of value at path into dst, directly when the types match and through JSON
+// otherwise.
+func projectField(value any, path string, dst any) error {
+	out := reflect.ValueOf(dst)
+	if out.Kind() != reflect.Pointer || out.IsNil() {
+		return errors.New("field destination must be a non-nil pointer")
+	}
+	v := reflect.ValueOf(value)
+	if path != "" {
+		for _, segment := range strings.Split(path, ".") {
+			var ok bool
+			if v, ok = fieldStep(v, segment); !ok {
+				return fmt.Errorf("%w: %s", ErrFieldNotFound, path)
+			}
+		}
+	}
+	if v.IsValid() && v.Type().AssignableTo(out.Elem().Type()) {
+		out.Elem().Set(v)
+		return nil
+	}
+	var field any
+	if v.IsValid() {
+		field = v.Interface()
+	}
+	data, err := json.Marshal(field)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// fieldStep returns the struct field, map entry or slice element of v named by segment.
+func fieldStep(v reflect.Value, segment string) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return structField(v, segment)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		entry := v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()))
+		return entry, entry.IsValid()
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(segment)
+		if err != nil || i < 0 || i >= v.Len() {
+			return reflect.Value{}, false
+		}
+		return v.Index(i), true
+	}
+	return reflect.Value{}, false
+}
+
+// structField returns the exported field of the struct named segment in JSON, by its tag or, as encoding/json
+// does, by a case-insensitive match of its name.
+func structField(v reflect.Value, segment string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == segment || (name == "" && strings.EqualFold(f.Name, segment)) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fieldDoc is a nested document used to exercise projections.
+type fieldDoc struct {
+	Name    string            `json:"name"`
+	Address *fieldAddress     `json:"address"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Score   int
+	secret  string
+}
+
+// fieldAddress is the nested part of fieldDoc.
+type fieldAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+// TestGetField verifies projections through struct fields, pointers, slices and maps on the in-memory stores.
+func TestGetField(t *testing.T) {
+	ctx := context.Background()
+	doc := fieldDoc{
+		Name:    "ada",
+		Address: &fieldAddress{City: "Turin", Zip: "10100"},
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"tier": "gold"},
+		Score:   7,
+		secret:  "hidden",
+	}
+	wheel := NewTimingWheelCache[fieldDoc](time.Minute, TimingWheelConfig[fieldDoc]{})
+	defer wheel.Close()
+	for name, c := range map[string]Cacher[fieldDoc]{
+		"lru":         NewLRUCache[fieldDoc](10),
+		"expirable":   NewLRUExpirableCache[fieldDoc](10, time.Minute),
+		"timingwheel": wheel,
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, c.Set(ctx, "doc", doc))
+
+			city, found, err := GetField[string](ctx, c, "doc", "address.city")
+			require.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, "Turin", city)
+
+			tag, _, err := GetField[string](ctx, c, "doc", "tags.1")
+			require.NoError(t, err)
+			assert.Equal(t, "b", tag)
+
+			tier, _, err := GetField[string](ctx, c, "doc", "labels.tier")
+			require.NoError(t, err)
+			assert.Equal(t, "gold", tier)
+
+			score, _, err := GetField[float64](ctx, c, "doc", "score")
+			require.NoError(t, err)
+			assert.Equal(t, 7.0, score)
+
+			address, _, err := GetField[map[string]string](ctx, c, "doc", "address")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"city": "Turin", "zip": "10100"}, address)
+
+			for _, path := range []string{"missing", "tags.9", "secret", "name.first"} {
+				_, _, err = GetField[string](ctx, c, "doc", path)
+				assert.ErrorIs(t, err, ErrFieldNotFound, path)
+			}
+
+			_, found, err = GetField[string](ctx, c, "absent", "name")
+			assert.NoError(t, err)
+			assert.False(t, found)
+		})
+	}
+
+	_, _, err := GetField[string](ctx, failingCacher[fieldDoc]{}, "doc", "name")
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
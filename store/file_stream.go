@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileStreamCache is a filesystem-backed StreamCacher storing each key in its own file under a base directory.
+// File names are derived from a SHA-256 hash of the key and the file modification time drives expiration.
+// mu orders the replacement of a file by SetReader with the removal of an expired one by GetReader.
+type fileStreamCache struct {
+	dir string
+	ttl time.Duration
+	mu  sync.Mutex
+}
+
+// NewFileStreamCache creates a filesystem-backed streaming cache rooted at dir. Entries older than ttl are reported
+// as missing; a ttl <= 0 disables expiration. The directory is created if it does not exist.
+func NewFileStreamCache(dir string, ttl time.Duration) (StreamCacher, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileStreamCache{
+		dir: dir,
+		ttl: ttl,
+	}, nil
+}
+
+// GetReader opens the file holding the value of the given key. The caller must close the returned reader.
+func (f *fileStreamCache) GetReader(ctx context.Context, key string) (io.ReadCloser, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	file, err := os.Open(f.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if f.ttl > 0 {
+		info, err := file.Stat()
+		if err != nil {
+			_ = file.Close()
+			return nil, false, err
+		}
+		if time.Since(info.ModTime()) > f.ttl {
+			_ = file.Close()
+			f.removeExpired(f.path(key), info)
+			return nil, false, nil
+		}
+	}
+	return file, true, nil
+}
+
+// SetReader copies the reader into a temporary file and atomically renames it in place,
+// so concurrent readers never observe a partially written value.
+func (f *fileStreamCache) SetReader(ctx context.Context, key string, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+// removeExpired removes the file at path if it is still the expired file described by expired, leaving in place a
+// file written by a concurrent SetReader since it was opened.
+func (f *fileStreamCache) removeExpired(path string, expired fs.FileInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	current, err := os.Stat(path)
+	if err != nil || !os.SameFile(current, expired) {
+		return
+	}
+	_ = os.Remove(path)
+}
+
+// path returns the file path holding the value of the given key.
+func (f *fileStreamCache) path(key string) string {
+	keyHash := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(keyHash[:]))
+}
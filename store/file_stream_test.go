@@ -0,0 +1,72 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFileStreamCache verifies streaming writes and reads, misses and TTL expiration.
+func TestFileStreamCache(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewFileStreamCache(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+
+	_, found, err := cache.GetReader(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	payload := bytes.Repeat([]byte("artifact"), 1<<16)
+	require.NoError(t, cache.SetReader(ctx, "big", bytes.NewReader(payload)))
+
+	r, found, err := cache.GetReader(ctx, "big")
+	require.NoError(t, err)
+	require.True(t, found)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, payload, data)
+
+	fc := cache.(*fileStreamCache)
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(fc.path("big"), old, old))
+	_, found, err = cache.GetReader(ctx, "big")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+// TestFileStreamCache_ExpiredRemovalKeepsNewFile verifies that removing an expired file leaves in place the file
+// written by a SetReader that replaced it after it was opened.
+func TestFileStreamCache_ExpiredRemovalKeepsNewFile(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewFileStreamCache(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+	fc := cache.(*fileStreamCache)
+
+	require.NoError(t, cache.SetReader(ctx, "k", bytes.NewReader([]byte("old"))))
+	expired, err := os.Stat(fc.path("k"))
+	require.NoError(t, err)
+	require.NoError(t, cache.SetReader(ctx, "k", bytes.NewReader([]byte("new"))))
+
+	fc.removeExpired(fc.path("k"), expired)
+	r, found, err := cache.GetReader(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, found)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "new", string(data))
+
+	current, err := os.Stat(fc.path("k"))
+	require.NoError(t, err)
+	fc.removeExpired(fc.path("k"), current)
+	_, found, err = cache.GetReader(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
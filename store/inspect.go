@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// KeyInfo describes a single entry of a store, to investigate why a key serves stale data. Fields the store cannot
+// report are left empty: Age, CreatedAt and Producer require stale-while-revalidate values, TTL a TTLInspector and
+// Revision a VersionedCacher. Size is the length of the value encoded with the codec of the store, or with JSON for
+// stores keeping values in memory, and Codec the type of that codec.
+type KeyInfo struct {
+	Key       string    `json:"key"`
+	Exists    bool      `json:"exists"`
+	Age       string    `json:"age,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	Producer  string    `json:"producer,omitempty"`
+	TTL       string    `json:"ttl,omitempty"`
+	Revision  uint64    `json:"revision,omitempty"`
+	Size      int       `json:"size,omitempty"`
+	Codec     string    `json:"codec,omitempty"`
+}
+
+// produced is implemented by values recording the node that computed them, such as StaleValue.
+type produced interface {
+	producer() string
+}
+
+// producer returns the node that computed the stale value.
+func (s StaleValue[T]) producer() string {
+	return s.Producer
+}
+
+// Inspect reads the key and reports its metadata without modifying it.
+func Inspect[T any](ctx context.Context, c Cacher[T], key string) (KeyInfo, error) {
+	info := KeyInfo{Key: key}
+	var value T
+	var err error
+	if versioned, ok := c.(VersionedCacher[T]); ok {
+		value, info.Revision, info.Exists, err = versioned.GetVersioned(ctx, key)
+	} else {
+		value, info.Exists, err = c.Get(ctx, key)
+	}
+	if err != nil || !info.Exists {
+		return info, err
+	}
+	if ts, ok := any(value).(timestamped); ok {
+		info.CreatedAt = ts.createdAt()
+		info.Age = time.Since(info.CreatedAt).Round(time.Millisecond).String()
+	}
+	if p, ok := any(value).(produced); ok {
+		info.Producer = p.producer()
+	}
+	if inspector, ok := c.(TTLInspector); ok {
+		ttl, hasTTL, err := inspector.TTL(ctx, key)
+		if err != nil {
+			return info, err
+		}
+		if hasTTL {
+			info.TTL = ttl.Round(time.Millisecond).String()
+		}
+	}
+	var codec Codec
+	if provider, ok := c.(codecProvider); ok {
+		codec = provider.valueCodec()
+	}
+	if data, err := encode(codec, value); err == nil {
+		info.Size = len(data)
+	}
+	if codec != nil {
+		info.Codec = fmt.Sprintf("%T", codec)
+	}
+	return info, nil
+}
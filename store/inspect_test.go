@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInspect verifies the metadata reported for a Redis entry: age, producer, TTL, size and codec.
+func TestInspect(t *testing.T) {
+	ctx := context.TODO()
+	rdb, mock := redismock.NewClientMock()
+	cache := NewStaleWhileRevalidateRedisCache[string](rdb, "test", time.Hour)
+	value := StaleValue[string]{Value: "alice", CreatedAt: time.Now().Add(-time.Minute), Producer: "node-a"}
+	data, err := JSONCodec{}.Marshal(value)
+	require.NoError(t, err)
+
+	mock.ExpectGet("test:user").SetVal(string(data))
+	mock.ExpectPTTL("test:user").SetVal(30 * time.Minute)
+	info, err := Inspect[StaleValue[string]](ctx, cache, "user")
+	require.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, "node-a", info.Producer)
+	assert.Equal(t, "30m0s", info.TTL)
+	assert.Equal(t, len(data), info.Size)
+	assert.Equal(t, "store.JSONCodec", info.Codec)
+	assert.InDelta(t, time.Minute, time.Since(info.CreatedAt), float64(time.Second))
+
+	mock.ExpectGet("test:missing").RedisNil()
+	info, err = Inspect[StaleValue[string]](ctx, cache, "missing")
+	require.NoError(t, err)
+	assert.False(t, info.Exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"time"
+)
+
+const (
+	// MetricStoreOperationDuration is the latency of store operations, labelled by backend, op and result.
+	MetricStoreOperationDuration = "echocache_store_operation_duration"
+	// MetricStoreOperations counts store operations, labelled by backend, op and result.
+	MetricStoreOperations = "echocache_store_operations_total"
+	// MetricStoreErrors counts failed store operations, labelled by backend and op.
+	MetricStoreErrors = "echocache_store_errors_total"
+)
+
+// InstrumentOption customizes the labels attached to the metrics of an instrumented store.
+type InstrumentOption func(*instrumentConfig)
+
+// instrumentConfig holds the label settings of an instrumented store.
+type instrumentConfig struct {
+	staticLabels map[string]string
+	classify     func(key string) string
+}
+
+// WithStaticLabels attaches the given labels, such as service, cache name or tier, to every metric of the store.
+func WithStaticLabels(labels map[string]string) InstrumentOption {
+	return func(c *instrumentConfig) {
+		c.staticLabels = maps.Clone(labels)
+	}
+}
+
+// WithKeyClassifier labels every metric with key_class set to classify(key), so that hit rates can be graphed per key
+// family. The classifier must map keys to a small set of values to keep the number of series bounded.
+func WithKeyClassifier(classify func(key string) string) InstrumentOption {
+	return func(c *instrumentConfig) {
+		c.classify = classify
+	}
+}
+
+// PrefixClassifier returns a key classifier keeping the first parts segments of keys split by sep,
+// e.g. PrefixClassifier(":", 1) maps "user:42:profile" to "user".
+func PrefixClassifier(sep string, parts int) func(key string) string {
+	return func(key string) string {
+		segments := strings.SplitN(key, sep, parts+1)
+		if len(segments) > parts {
+			segments = segments[:parts]
+		}
+		return strings.Join(segments, sep)
+	}
+}
+
+// newInstrumentConfig applies the given options.
+func newInstrumentConfig(opts []InstrumentOption) instrumentConfig {
+	c := instrumentConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// labels builds the label set of a metric from the static labels, the key class and the given labels.
+func (c instrumentConfig) labels(key string, labels map[string]string) map[string]string {
+	for name, value := range c.staticLabels {
+		if _, ok := labels[name]; !ok {
+			labels[name] = value
+		}
+	}
+	if c.classify != nil {
+		labels["key_class"] = c.classify(key)
+	}
+	return labels
+}
+
+// instrumentedCache is a Cacher decorator measuring latency and error counts of every operation of the wrapped store.
+type instrumentedCache[T any] struct {
+	inner   Cacher[T]
+	backend string
+	sink    MetricsSink
+	cfg     instrumentConfig
+}
+
+// NewInstrumentedCache wraps inner so that Get and Set latency, outcomes and errors are reported to sink,
+// labelled with the given backend name. Any custom Cacher implementation gets observability this way. The optional
+// capabilities of inner, such as Deleter and Scanner, are forwarded to it without being measured.
+func NewInstrumentedCache[T any](inner Cacher[T], backend string, sink MetricsSink, opts ...InstrumentOption) Cacher[T] {
+	return newInstrumentedCache(inner, backend, sink, opts)
+}
+
+// newInstrumentedCache implements NewInstrumentedCache.
+func newInstrumentedCache[T any](inner Cacher[T], backend string, sink MetricsSink, opts []InstrumentOption) *forwardingCacher[T] {
+	return forward[T](&instrumentedCache[T]{
+		inner:   inner,
+		backend: backend,
+		sink:    sink,
+		cfg:     newInstrumentConfig(opts),
+	}, inner)
+}
+
+// Instrument returns a middleware reporting operation metrics of the wrapped store to sink.
+func Instrument[T any](backend string, sink MetricsSink, opts ...InstrumentOption) Middleware[T] {
+	return func(next Cacher[T]) Cacher[T] {
+		return NewInstrumentedCache[T](next, backend, sink, opts...)
+	}
+}
+
+// InstrumentStaleWhileRevalidate wraps a stale-while-revalidate cache, reporting metrics for Get, Set and lock operations.
+func InstrumentStaleWhileRevalidate[T any](inner StaleWhileRevalidateCache[T], backend string, sink MetricsSink, opts ...InstrumentOption) StaleWhileRevalidateCache[T] {
+	return staleWhileRevalidateAdapter[T]{
+		forwardingCacher: newInstrumentedCache[StaleValue[T]](inner, backend, sink, opts),
+		RefreshLocker:    &instrumentedLocker{inner: inner, backend: backend, sink: sink, cfg: newInstrumentConfig(opts)},
+	}
+}
+
+// Get retrieves the value from the wrapped store, recording the outcome as hit, miss or error.
+func (i *instrumentedCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	start := time.Now()
+	value, exists, err := i.inner.Get(ctx, key)
+	result := "miss"
+	if exists {
+		result = "hit"
+	}
+	observeOperation(i.sink, i.cfg, i.backend, "get", result, key, start, err)
+	return value, exists, err
+}
+
+// Set stores the value in the wrapped store, recording the outcome.
+func (i *instrumentedCache[T]) Set(ctx context.Context, key string, value T) error {
+	start := time.Now()
+	err := i.inner.Set(ctx, key, value)
+	observeOperation(i.sink, i.cfg, i.backend, "set", "ok", key, start, err)
+	return err
+}
+
+// instrumentedLocker is a RefreshLocker decorator measuring lock operations.
+type instrumentedLocker struct {
+	inner   RefreshLocker
+	backend string
+	sink    MetricsSink
+	cfg     instrumentConfig
+}
+
+// TryAcquireRefreshLock delegates to the wrapped locker, recording whether the lock was acquired.
+func (i *instrumentedLocker) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	acquired, err := i.inner.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	result := "contended"
+	if acquired {
+		result = "acquired"
+	}
+	observeOperation(i.sink, i.cfg, i.backend, "lock", result, key, start, err)
+	return acquired, err
+}
+
+// ReleaseRefreshLock delegates to the wrapped locker, recording the outcome.
+func (i *instrumentedLocker) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	start := time.Now()
+	err := i.inner.ReleaseRefreshLock(ctx, key, randValue)
+	observeOperation(i.sink, i.cfg, i.backend, "unlock", "ok", key, start, err)
+	return err
+}
+
+// observeOperation reports latency, count and errors of a single store operation.
+func observeOperation(sink MetricsSink, cfg instrumentConfig, backend string, op string, result string, key string, start time.Time, err error) {
+	if err != nil {
+		result = "error"
+		sink.IncCounter(MetricStoreErrors, cfg.labels(key, map[string]string{"backend": backend, "op": op}), 1)
+	}
+	labels := cfg.labels(key, map[string]string{"backend": backend, "op": op, "result": result})
+	sink.ObserveDuration(MetricStoreOperationDuration, labels, time.Since(start))
+	sink.IncCounter(MetricStoreOperations, labels, 1)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInstrumentedCache verifies that hits, misses, sets and errors are reported with latency samples.
+func TestInstrumentedCache(t *testing.T) {
+	ctx := context.Background()
+	sink := NewMemoryMetrics()
+	cache := Chain[string](NewLRUCache[string](10), Instrument[string]("lru", sink))
+
+	_, _, _ = cache.Get(ctx, "k")
+	_ = cache.Set(ctx, "k", "v")
+	_, _, _ = cache.Get(ctx, "k")
+
+	assert.Equal(t, 1.0, sink.Counter(MetricStoreOperations, map[string]string{"backend": "lru", "op": "get", "result": "miss"}))
+	assert.Equal(t, 1.0, sink.Counter(MetricStoreOperations, map[string]string{"backend": "lru", "op": "get", "result": "hit"}))
+	assert.Equal(t, 1.0, sink.Counter(MetricStoreOperations, map[string]string{"backend": "lru", "op": "set", "result": "ok"}))
+	assert.Len(t, sink.Durations(MetricStoreOperationDuration, map[string]string{"backend": "lru", "op": "get", "result": "hit"}), 1)
+
+	failing := NewInstrumentedCache[string](failingCacher[string]{err: errors.New("down")}, "broken", sink)
+	assert.Error(t, failing.Set(ctx, "k", "v"))
+	assert.Equal(t, 1.0, sink.Counter(MetricStoreErrors, map[string]string{"backend": "broken", "op": "set"}))
+}
+
+// TestInstrumentStaleWhileRevalidate verifies that lock operations are instrumented.
+func TestInstrumentStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	sink := NewMemoryMetrics()
+	cache := InstrumentStaleWhileRevalidate[string](NewStaleWhileRevalidateLRUCache[string](10), "lru", sink)
+
+	_, _ = cache.TryAcquireRefreshLock(ctx, "k", "v", time.Second)
+	_ = cache.ReleaseRefreshLock(ctx, "k", "v")
+
+	assert.Equal(t, 1.0, sink.Counter(MetricStoreOperations, map[string]string{"backend": "lru", "op": "lock", "result": "acquired"}))
+	assert.Equal(t, 1.0, sink.Counter(MetricStoreOperations, map[string]string{"backend": "lru", "op": "unlock", "result": "ok"}))
+}
+
+// TestInstrumentedCache_Labels verifies that static labels and key classes are attached to every metric.
+func TestInstrumentedCache_Labels(t *testing.T) {
+	ctx := context.Background()
+	sink := NewMemoryMetrics()
+	cache := NewInstrumentedCache[string](NewLRUCache[string](10), "lru", sink,
+		WithStaticLabels(map[string]string{"service": "api", "backend": "ignored"}),
+		WithKeyClassifier(PrefixClassifier(":", 1)))
+
+	_, _, _ = cache.Get(ctx, "user:1")
+	_, _, _ = cache.Get(ctx, "user:2")
+	_, _, _ = cache.Get(ctx, "order:1")
+
+	labels := map[string]string{"backend": "lru", "op": "get", "result": "miss", "service": "api", "key_class": "user"}
+	assert.Equal(t, 2.0, sink.Counter(MetricStoreOperations, labels))
+	labels["key_class"] = "order"
+	assert.Equal(t, 1.0, sink.Counter(MetricStoreOperations, labels))
+}
+
+// TestPrefixClassifier verifies the extraction of key families.
+func TestPrefixClassifier(t *testing.T) {
+	tests := []struct {
+		key   string
+		parts int
+		want  string
+	}{
+		{key: "user:42:profile", parts: 1, want: "user"},
+		{key: "user:42:profile", parts: 2, want: "user:42"},
+		{key: "plain", parts: 1, want: "plain"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, PrefixClassifier(":", tt.parts)(tt.key))
+	}
+}
+
+// TestSeriesName verifies stable rendering of labelled series names.
+func TestSeriesName(t *testing.T) {
+	assert.Equal(t, "m", SeriesName("m", nil))
+	assert.Equal(t, `m{a="1",b="2"}`, SeriesName("m", map[string]string{"b": "2", "a": "1"}))
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"iter"
+)
+
+// Lister is implemented by caches able to iterate over their entries with range-over-func, streaming them rather
+// than collecting every key and value first. Iteration stops when ctx is done.
+type Lister[T any] interface {
+	All(ctx context.Context) iter.Seq2[string, T]
+}
+
+// errLister is implemented by listers whose iteration can fail, recording in errp the error that stopped it.
+type errLister[T any] interface {
+	all(ctx context.Context, errp *error) iter.Seq2[string, T]
+}
+
+// All returns an iterator over the entries of the cache and a function reporting the error that stopped the
+// iteration, to be checked once the loop ends. Caches implementing Lister stream their entries; the other caches
+// implementing Scanner are enumerated with Scan and read one key at a time. The iterator is empty and the function
+// returns ErrNotSupported when the cache implements neither.
+func All[T any](ctx context.Context, c Cacher[T]) (iter.Seq2[string, T], func() error) {
+	var err error
+	errFunc := func() error { return err }
+	if l, ok := c.(errLister[T]); ok {
+		return l.all(ctx, &err), errFunc
+	}
+	if l, ok := c.(Lister[T]); ok {
+		return func(yield func(string, T) bool) {
+			for key, value := range l.All(ctx) {
+				if !yield(key, value) {
+					return
+				}
+			}
+			err = ctx.Err()
+		}, errFunc
+	}
+	scanner, ok := c.(Scanner)
+	if !ok {
+		err = ErrNotSupported
+		return func(func(string, T) bool) {}, errFunc
+	}
+	return func(yield func(string, T) bool) {
+		var keys []string
+		if keys, err = scanner.Scan(ctx, "*", 0); err != nil {
+			return
+		}
+		for _, key := range keys {
+			var value T
+			var exists bool
+			if value, exists, err = c.Get(ctx, key); err != nil {
+				return
+			}
+			if exists && !yield(key, value) {
+				return
+			}
+		}
+	}, errFunc
+}
+
+// snapshotSeq yields the values of the keys returned by snapshot when the iteration starts, read with get, skipping
+// keys removed since the snapshot was taken. Keys are yielded as reported by rawKey for the sanitizer s.
+func snapshotSeq[T any](ctx context.Context, s KeySanitizer, snapshot func() []string, get func(key string) (T, bool)) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for _, key := range snapshot() {
+			if ctx.Err() != nil {
+				return
+			}
+			value, exists := get(key)
+			if exists && !yield(rawKey(s, key), value) {
+				return
+			}
+		}
+	}
+}
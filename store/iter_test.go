@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAll_Memory verifies range-over-func iteration over the in-memory stores, including early termination.
+func TestAll_Memory(t *testing.T) {
+	ctx := context.Background()
+	wheel := NewTimingWheelCache[int](time.Minute, TimingWheelConfig[int]{})
+	defer wheel.Close()
+	stores := map[string]Cacher[int]{
+		"LRU":          NewLRUCache[int](10),
+		"LRUExpirable": NewLRUExpirableCache[int](10, time.Minute),
+		"TimingWheel":  wheel,
+	}
+	for name, c := range stores {
+		t.Run(name, func(t *testing.T) {
+			for i, key := range []string{"a", "b", "c"} {
+				require.NoError(t, c.Set(ctx, key, i))
+			}
+
+			entries, errFunc := All(ctx, c)
+			assert.Equal(t, map[string]int{"a": 0, "b": 1, "c": 2}, maps.Collect(entries))
+			assert.NoError(t, errFunc())
+
+			seen := 0
+			for range c.(Lister[int]).All(ctx) {
+				seen++
+				break
+			}
+			assert.Equal(t, 1, seen)
+		})
+	}
+
+	entries, errFunc := All(ctx, NewSingleCache[int](time.Minute))
+	assert.Empty(t, maps.Collect(entries))
+	assert.ErrorIs(t, errFunc(), ErrNotSupported)
+}
+
+// TestAll_Redis verifies that Redis entries are streamed page by page, skipping lock keys and expired entries, and
+// that errors stop the iteration.
+func TestAll_Redis(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	c := NewRedisCache[int](db, "test", time.Minute)
+
+	mock.ExpectScan(0, "test:*", scanBatchSize).SetVal([]string{"test:a", "test:lock:a", "test:b"}, 7)
+	mock.ExpectMGet("test:a", "test:b").SetVal([]any{"1", nil})
+	mock.ExpectScan(7, "test:*", scanBatchSize).SetVal([]string{"test:c"}, 0)
+	mock.ExpectMGet("test:c").SetVal([]any{"3"})
+	entries, errFunc := All(ctx, c)
+	assert.Equal(t, map[string]int{"a": 1, "c": 3}, maps.Collect(entries))
+	assert.NoError(t, errFunc())
+
+	mock.ExpectScan(0, "test:*", scanBatchSize).SetErr(errors.New("connection reset"))
+	entries, errFunc = All(ctx, c)
+	assert.Empty(t, maps.Collect(entries))
+	assert.EqualError(t, errFunc(), "connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// keyHashSuffixLen is the number of hex characters of the SHA-256 digest appended to truncated keys.
+const keyHashSuffixLen = 16
+
+// KeySanitizer normalizes user-supplied keys so they satisfy backend-specific constraints.
+// Stores apply it to every key before building the backend key, including refresh lock keys. Scan and All report
+// keys with their =XX escape sequences decoded, so that they can be passed to Get and Delete again: a custom
+// sanitizer must not produce '=' other than as the start of such a sequence.
+type KeySanitizer func(key string) string
+
+// KeySanitizerConfig describes how NewKeySanitizer normalizes keys.
+// MaxLength caps the sanitized key length: longer keys are truncated and suffixed with a hash of the full key, so
+// distinct keys never collide. Valid reports whether a byte may appear verbatim; any other byte, and '=' itself, is
+// escaped as =XX.
+type KeySanitizerConfig struct {
+	MaxLength int
+	Valid     func(c byte) bool
+}
+
+// NewKeySanitizer creates a KeySanitizer from the given configuration.
+func NewKeySanitizer(cfg KeySanitizerConfig) KeySanitizer {
+	return func(key string) string {
+		if cfg.Valid != nil {
+			key = escapeKey(key, cfg.Valid)
+		}
+		if cfg.MaxLength > 0 && len(key) > cfg.MaxLength {
+			key = truncateKey(key, cfg.MaxLength)
+		}
+		return key
+	}
+}
+
+// RedisKeySanitizer returns a sanitizer for Redis keys: control characters and spaces are escaped and keys longer than
+// maxLength are truncated with a hash suffix.
+func RedisKeySanitizer(maxLength int) KeySanitizer {
+	return NewKeySanitizer(KeySanitizerConfig{
+		MaxLength: maxLength,
+		Valid: func(c byte) bool {
+			return c > ' ' && c != 0x7f && c != '='
+		},
+	})
+}
+
+// NatsKeySanitizer returns a sanitizer producing subject-safe NATS KV key tokens: only letters, digits, '-', '_' and '/'
+// are kept verbatim (dots are escaped so user keys cannot introduce extra tokens) and keys longer than maxLength are
+// truncated with a hash suffix.
+func NatsKeySanitizer(maxLength int) KeySanitizer {
+	return NewKeySanitizer(KeySanitizerConfig{
+		MaxLength: maxLength,
+		Valid: func(c byte) bool {
+			return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '/'
+		},
+	})
+}
+
+// escapeKey replaces '=' and every byte not accepted by valid with '=' followed by its two-digit uppercase hex code.
+func escapeKey(key string, valid func(c byte) bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c != '=' && valid(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('=')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0x0f])
+	}
+	return b.String()
+}
+
+// unescapeKey decodes the escape sequences written by escapeKey. Keys that were truncated are returned in their
+// truncated form, which the sanitizer leaves unchanged.
+func unescapeKey(key string) string {
+	if strings.IndexByte(key, '=') < 0 {
+		return key
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == '=' && i+2 < len(key) {
+			if c, ok := unhex(key[i+1], key[i+2]); ok {
+				b.WriteByte(c)
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
+
+// unhex decodes two uppercase hex digits, as written by escapeKey.
+func unhex(hi, lo byte) (byte, bool) {
+	h := strings.IndexByte("0123456789ABCDEF", hi)
+	l := strings.IndexByte("0123456789ABCDEF", lo)
+	if h < 0 || l < 0 {
+		return 0, false
+	}
+	return byte(h<<4 | l), true
+}
+
+// truncateKey shortens key to maxLength characters, replacing the tail with a hash of the full key.
+func truncateKey(key string, maxLength int) string {
+	sum := sha256.Sum256([]byte(key))
+	suffix := hex.EncodeToString(sum[:])[:keyHashSuffixLen]
+	keep := maxLength - keyHashSuffixLen - 1
+	if keep < 0 {
+		return suffix[:min(maxLength, len(suffix))]
+	}
+	if i := strings.LastIndexByte(key[:keep], '='); i >= 0 && i > keep-3 {
+		// Do not split an escape sequence.
+		keep = i
+	}
+	return key[:keep] + "-" + suffix
+}
+
+// sanitizeKey applies the sanitizer when one is configured.
+func sanitizeKey(s KeySanitizer, key string) string {
+	if s == nil {
+		return key
+	}
+	return s(key)
+}
+
+// rawKey returns the key to report for a sanitized key, decoding its escape sequences when a sanitizer is configured.
+func rawKey(s KeySanitizer, key string) string {
+	if s == nil {
+		return key
+	}
+	return unescapeKey(key)
+}
+
+// rawKeys applies rawKey to every key, in place.
+func rawKeys(s KeySanitizer, keys []string) []string {
+	if s == nil {
+		return keys
+	}
+	for i, key := range keys {
+		keys[i] = unescapeKey(key)
+	}
+	return keys
+}
+
+// WithKeyDigestCache keeps the backend keys built for the size most recently used raw keys, so hot keys do not pay
+// for hashing, sanitizing and concatenating on every operation at high QPS. It applies to the Redis and NATS stores.
+func WithKeyDigestCache(size int) Option {
+	return func(o *storeOptions) {
+		o.keyCache = size
+	}
+}
+
+// keyDigestCache is a small LRU of raw keys to backend keys. A nil *keyDigestCache is valid and caches nothing.
+type keyDigestCache struct {
+	keys *lru.Cache[string, string]
+}
+
+// newKeyDigestCache creates a cache of the given size, or returns nil when size is not positive.
+func newKeyDigestCache(size int) *keyDigestCache {
+	if size <= 0 {
+		return nil
+	}
+	keys, _ := lru.New[string, string](size)
+	return &keyDigestCache{keys: keys}
+}
+
+// get returns the backend key of the raw key, computing it with build on a miss.
+func (c *keyDigestCache) get(key string, build func(string) string) string {
+	if c == nil {
+		return build(key)
+	}
+	if built, ok := c.keys.Get(key); ok {
+		return built
+	}
+	built := build(key)
+	c.keys.Add(key, built)
+	return built
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNatsKeySanitizer verifies escaping of characters that are not valid in NATS KV key tokens.
+func TestNatsKeySanitizer(t *testing.T) {
+	sanitize := NatsKeySanitizer(0)
+	assert.Equal(t, "user-1_a/b", sanitize("user-1_a/b"))
+	assert.Equal(t, "a=2Eb=20c=3Dd", sanitize("a.b c=d"))
+	assert.NotEqual(t, sanitize("a.b"), sanitize("a=2Eb"))
+}
+
+// TestRedisKeySanitizer verifies escaping of control characters and length capping with a hash suffix.
+func TestRedisKeySanitizer(t *testing.T) {
+	sanitize := RedisKeySanitizer(40)
+	assert.Equal(t, "user:1", sanitize("user:1"))
+	assert.Equal(t, "a=20b=0A", sanitize("a b\n"))
+
+	long1 := sanitize(strings.Repeat("x", 100) + "1")
+	long2 := sanitize(strings.Repeat("x", 100) + "2")
+	assert.Len(t, long1, 40)
+	assert.NotEqual(t, long1, long2)
+	assert.True(t, strings.HasPrefix(long1, strings.Repeat("x", 23)+"-"))
+}
+
+// TestKeySanitizer_AppliedByStores verifies that stores apply the configured sanitizer to every key.
+func TestKeySanitizer_AppliedByStores(t *testing.T) {
+	ctx := context.TODO()
+	rdb, mock := redismock.NewClientMock()
+	cache := NewRedisCache[string](rdb, "test", time.Hour, WithKeySanitizer(RedisKeySanitizer(0)))
+
+	mock.ExpectGet("test:a=20b").SetVal(`"v"`)
+	value, found, err := cache.Get(ctx, "a b")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "v", value)
+
+	lru := NewLRUCache[string](10, WithKeySanitizer(NatsKeySanitizer(0)))
+	assert.NoError(t, lru.Set(ctx, "a.b", "v"))
+	keys, _ := lru.(Scanner).Scan(ctx, "*", 0)
+	assert.Equal(t, []string{"a.b"}, keys)
+	value, found, _ = lru.Get(ctx, "a.b")
+	assert.True(t, found)
+	assert.Equal(t, "v", value)
+
+	wheel := NewTimingWheelCache[string](time.Minute, TimingWheelConfig[string]{}, WithKeySanitizer(RedisKeySanitizer(8)))
+	defer wheel.Close()
+	assert.NoError(t, wheel.Set(ctx, "a b", "v"))
+	assert.Equal(t, 1, wheel.Len())
+	value, found, _ = wheel.Get(ctx, "a b")
+	assert.True(t, found)
+	assert.Equal(t, "v", value)
+	assert.NoError(t, wheel.Delete(ctx, "a b"))
+	assert.Zero(t, wheel.Len())
+}
+
+// TestKeySanitizer_ScannedKeysRoundTrip verifies that keys reported by Scan and All address their entries again,
+// including escaped and truncated keys.
+func TestKeySanitizer_ScannedKeysRoundTrip(t *testing.T) {
+	ctx := context.TODO()
+	raw := []string{"a.b", "a=2Eb", "x=y z", strings.Repeat("k.", 40), strings.Repeat("=", 30)}
+	stores := map[string]Cacher[string]{
+		"lru":         NewLRUCache[string](10, WithKeySanitizer(NatsKeySanitizer(40))),
+		"lru-expire":  NewLRUExpirableCache[string](10, time.Minute, WithKeySanitizer(RedisKeySanitizer(40))),
+		"timingwheel": NewTimingWheelCache[string](time.Minute, TimingWheelConfig[string]{}, WithKeySanitizer(NatsKeySanitizer(40))),
+	}
+	defer stores["timingwheel"].(*TimingWheelCache[string]).Close()
+	for name, cache := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range raw {
+				assert.NoError(t, cache.Set(ctx, key, key))
+			}
+			keys, err := cache.(Scanner).Scan(ctx, "*", 0)
+			assert.NoError(t, err)
+			assert.Len(t, keys, len(raw))
+			for _, key := range keys {
+				_, found, err := cache.Get(ctx, key)
+				assert.NoError(t, err)
+				assert.True(t, found, key)
+			}
+			assert.Contains(t, keys, "a.b")
+			assert.Contains(t, keys, "a=2Eb")
+
+			seen := 0
+			for key := range cache.(Lister[string]).All(ctx) {
+				_, found, _ := cache.Get(ctx, key)
+				assert.True(t, found, key)
+				seen++
+			}
+			assert.Equal(t, len(raw), seen)
+
+			for _, key := range keys {
+				assert.NoError(t, Delete(ctx, cache, key))
+			}
+			keys, _ = cache.(Scanner).Scan(ctx, "*", 0)
+			assert.Empty(t, keys)
+		})
+	}
+}
+
+// TestKeyDigestCache verifies that backend keys are built once per cached raw key.
+func TestKeyDigestCache(t *testing.T) {
+	cache := newKeyDigestCache(1)
+	builds := 0
+	build := func(key string) string {
+		builds++
+		return "prefix:" + key
+	}
+	assert.Equal(t, "prefix:a", cache.get("a", build))
+	assert.Equal(t, "prefix:a", cache.get("a", build))
+	assert.Equal(t, 1, builds)
+	assert.Equal(t, "prefix:b", cache.get("b", build))
+	assert.Equal(t, "prefix:a", cache.get("a", build))
+	assert.Equal(t, 3, builds)
+
+	var disabled *keyDigestCache
+	assert.Equal(t, "prefix:c", disabled.get("c", build))
+}
+
+// BenchmarkNatsBuildKey measures building NATS keys with and without the key digest cache.
+func BenchmarkNatsBuildKey(b *testing.B) {
+	for _, size := range []int{0, 1024} {
+		cache := &natsCache[string]{prefix: "bench", keys: newKeyDigestCache(size)}
+		b.Run("cache="+strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; b.Loop(); i++ {
+				_ = cache.buildKey("user:profile:" + strconv.Itoa(i%100))
+			}
+		})
+	}
+}
+
+// BenchmarkRedisBuildKey measures building sanitized Redis keys with and without the key digest cache.
+func BenchmarkRedisBuildKey(b *testing.B) {
+	for _, size := range []int{0, 1024} {
+		cache := &redisCache[string]{prefix: "bench", sanitizer: RedisKeySanitizer(64), keys: newKeyDigestCache(size)}
+		b.Run("cache="+strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; b.Loop(); i++ {
+				_ = cache.buildKey("user:profile:" + strconv.Itoa(i%100))
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// lockRecordVersion is the LockRecord layout written by this release.
+const lockRecordVersion = 1
+
+// LockRecord is the payload of a NATS refresh lock. Holder is the owner value passed to TryAcquireRefreshLock and
+// TTL the duration after which other owners may take the lock over. Fence is the KV revision of the lock entry: it
+// increases on every acquisition, renewal and takeover, so writers can use it as a fencing token to reject work of
+// owners that lost the lock. It is derived from the entry and not serialized.
+type LockRecord struct {
+	Version    int           `json:"v"`
+	Holder     string        `json:"holder"`
+	Fence      uint64        `json:"-"`
+	AcquiredAt time.Time     `json:"acquiredAt"`
+	TTL        time.Duration `json:"ttl"`
+}
+
+// ExpiresAt returns the time after which the lock may be taken over. Records written by older releases carry no
+// TTL, in which case fallback is used.
+func (l LockRecord) ExpiresAt(fallback time.Duration) time.Time {
+	ttl := l.TTL
+	if ttl <= 0 {
+		ttl = fallback
+	}
+	return l.AcquiredAt.Add(ttl)
+}
+
+// LockAction identifies a transition of a refresh lock reported to lock hooks.
+type LockAction string
+
+const (
+	// LockAcquired reports a lock created by its owner.
+	LockAcquired LockAction = "acquired"
+	// LockRenewed reports a lock acquired again by the owner already holding it.
+	LockRenewed LockAction = "renewed"
+	// LockTakenOver reports an expired lock replaced by a new owner.
+	LockTakenOver LockAction = "takeover"
+	// LockContended reports an attempt that found the lock held by another owner. Record is the current holder.
+	LockContended LockAction = "contended"
+	// LockReleased reports a lock released by its owner.
+	LockReleased LockAction = "released"
+)
+
+// LockEvent describes a refresh lock transition of the NATS store.
+type LockEvent struct {
+	Key    string
+	Action LockAction
+	Record LockRecord
+}
+
+// WithLockCodec sets the codec serializing the LockRecord of NATS refresh locks, JSONCodec by default. Every node
+// sharing a bucket must use the same codec; locks written by older releases as "holder|RFC3339" remain readable.
+func WithLockCodec(c Codec) Option {
+	return func(o *storeOptions) {
+		o.lockCodec = c
+	}
+}
+
+// WithLegacyLockFormat makes the NATS store write refresh locks in the "holder|RFC3339" format of releases older than
+// LockRecord, which delete any other lock payload as invalid and would otherwise steal locks held by upgraded nodes.
+// Upgrade a cluster in two rolling deployments: first every node with this option, then every node without it once
+// no older release remains. Legacy locks carry neither their TTL nor sub-second acquisition times.
+func WithLegacyLockFormat() Option {
+	return func(o *storeOptions) {
+		o.legacyLocks = true
+	}
+}
+
+// WithLockHook calls fn on every refresh lock transition of the NATS store, with the lock record involved. fn runs
+// synchronously on the locking goroutine and must not block.
+func WithLockHook(fn func(LockEvent)) Option {
+	return func(o *storeOptions) {
+		o.lockHook = fn
+	}
+}
+
+// LockInspector is implemented by stores able to report the current holder of a refresh lock.
+type LockInspector interface {
+	InspectRefreshLock(ctx context.Context, key string) (LockRecord, bool, error)
+}
+
+// InspectRefreshLock returns the record of the refresh lock of key and whether it is held, returning ErrNotSupported
+// if the cache does not implement LockInspector.
+func InspectRefreshLock(ctx context.Context, c any, key string) (LockRecord, bool, error) {
+	inspector, ok := c.(LockInspector)
+	if !ok {
+		return LockRecord{}, false, ErrNotSupported
+	}
+	return inspector.InspectRefreshLock(ctx, key)
+}
+
+// errInvalidLock is returned when a lock payload is neither a LockRecord nor a legacy "holder|RFC3339" value.
+var errInvalidLock = errors.New("invalid lock payload")
+
+// encodeLockRecord serializes a lock record with the codec, or in the legacy "holder|RFC3339" format.
+func encodeLockRecord(c Codec, record LockRecord, legacy bool) ([]byte, error) {
+	if legacy {
+		return []byte(record.Holder + "|" + record.AcquiredAt.Format(time.RFC3339)), nil
+	}
+	return encode(c, record)
+}
+
+// decodeLockRecord deserializes a lock payload with the codec, falling back to the legacy "holder|RFC3339" format.
+func decodeLockRecord(c Codec, data []byte) (LockRecord, error) {
+	var record LockRecord
+	if err := decode(c, data, &record); err == nil && record.Holder != "" {
+		return record, nil
+	}
+	holder, acquiredAt, ok := strings.Cut(string(data), "|")
+	if !ok || holder == "" {
+		return LockRecord{}, errInvalidLock
+	}
+	at, err := time.Parse(time.RFC3339, acquiredAt)
+	if err != nil {
+		return LockRecord{}, err
+	}
+	return LockRecord{Holder: holder, AcquiredAt: at}, nil
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MetricLockAttempts counts refresh lock acquisition attempts, labelled by backend and result (acquired,
+	// contended or error).
+	MetricLockAttempts = "echocache_lock_attempts_total"
+	// MetricLockExpirations counts refresh locks that expired before their owner released them, labelled by backend
+	// and reason: "release" when the owner found its lock gone, "takeover" when another owner replaced a stale lock.
+	MetricLockExpirations = "echocache_lock_expirations_total"
+	// MetricLockHoldDuration is the time refresh locks are held, from acquisition to release, labelled by backend.
+	MetricLockHoldDuration = "echocache_lock_hold_duration"
+)
+
+// LockStats reports the refresh lock activity of a store, to debug nodes fighting over the same keys. Contended
+// counts attempts that found the lock held by another owner, Expired locks lost before their owner released them
+// and HoldTime the total time locks were held by this process.
+type LockStats struct {
+	Attempts  uint64        `json:"attempts"`
+	Acquired  uint64        `json:"acquired"`
+	Contended uint64        `json:"contended"`
+	Errors    uint64        `json:"errors"`
+	Expired   uint64        `json:"expired"`
+	HoldTime  time.Duration `json:"holdTime"`
+}
+
+// LockReporter is implemented by stores able to report refresh lock statistics.
+type LockReporter interface {
+	LockStats() LockStats
+}
+
+// Locks returns the refresh lock statistics of the store, when it implements LockReporter.
+func Locks(c any) (LockStats, bool) {
+	reporter, ok := c.(LockReporter)
+	if !ok {
+		return LockStats{}, false
+	}
+	return reporter.LockStats(), true
+}
+
+// WithLockMetrics publishes refresh lock metrics of the Redis and NATS stores, such as MetricLockAttempts, to sink.
+func WithLockMetrics(sink MetricsSink) Option {
+	return func(o *storeOptions) {
+		o.lockSink = sink
+	}
+}
+
+// lockCounters accumulates the refresh lock statistics of a store and publishes them to an optional sink.
+// A nil *lockCounters is valid and records nothing.
+type lockCounters struct {
+	backend   string
+	sink      MetricsSink
+	attempts  atomic.Uint64
+	acquired  atomic.Uint64
+	contended atomic.Uint64
+	errors    atomic.Uint64
+	expired   atomic.Uint64
+	holdTime  atomic.Int64
+
+	mu   sync.Mutex
+	held map[string]time.Time
+}
+
+// newLockCounters creates the lock statistics of a store of the given backend, publishing to sink when not nil.
+func newLockCounters(backend string, sink MetricsSink) *lockCounters {
+	return &lockCounters{
+		backend: backend,
+		sink:    sink,
+		held:    make(map[string]time.Time),
+	}
+}
+
+// attempt records the outcome of an acquisition attempt of the lock of key by the owner randValue.
+func (l *lockCounters) attempt(key string, randValue string, acquired bool, err error) {
+	if l == nil {
+		return
+	}
+	l.attempts.Add(1)
+	result := "contended"
+	switch {
+	case err != nil:
+		l.errors.Add(1)
+		result = "error"
+	case acquired:
+		l.acquired.Add(1)
+		result = "acquired"
+		l.mu.Lock()
+		if _, ok := l.held[key+"|"+randValue]; !ok {
+			l.held[key+"|"+randValue] = time.Now()
+		}
+		l.mu.Unlock()
+	default:
+		l.contended.Add(1)
+	}
+	if l.sink != nil {
+		l.sink.IncCounter(MetricLockAttempts, map[string]string{"backend": l.backend, "result": result}, 1)
+	}
+}
+
+// released records the release of the lock of key by the owner randValue. held is false when the lock had already
+// expired or been taken over.
+func (l *lockCounters) released(key string, randValue string, held bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	since, ok := l.held[key+"|"+randValue]
+	delete(l.held, key+"|"+randValue)
+	l.mu.Unlock()
+	if ok {
+		d := time.Since(since)
+		l.holdTime.Add(int64(d))
+		if l.sink != nil {
+			l.sink.ObserveDuration(MetricLockHoldDuration, map[string]string{"backend": l.backend}, d)
+		}
+	}
+	if !held {
+		l.expire("release")
+	}
+}
+
+// expire records a lock that expired before its owner released it.
+func (l *lockCounters) expire(reason string) {
+	if l == nil {
+		return
+	}
+	l.expired.Add(1)
+	if l.sink != nil {
+		l.sink.IncCounter(MetricLockExpirations, map[string]string{"backend": l.backend, "reason": reason}, 1)
+	}
+}
+
+// snapshot returns the statistics accumulated so far.
+func (l *lockCounters) snapshot() LockStats {
+	if l == nil {
+		return LockStats{}
+	}
+	return LockStats{
+		Attempts:  l.attempts.Load(),
+		Acquired:  l.acquired.Load(),
+		Contended: l.contended.Load(),
+		Errors:    l.errors.Load(),
+		Expired:   l.expired.Load(),
+		HoldTime:  time.Duration(l.holdTime.Load()),
+	}
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// arenaShards is the number of independently locked shards of an arena cache.
+	arenaShards = 16
+	// arenaChunksPerShard is the number of chunks each shard splits its budget into; filling the last one recycles
+	// the oldest, so at most a quarter of the entries is evicted at once.
+	arenaChunksPerShard = 4
+	// maxArenaChunkSize bounds the size of a chunk, so large budgets are split into more, smaller chunks.
+	maxArenaChunkSize = 4 << 20
+	// arenaHeaderSize is the size of the header preceding each entry: key hash, key length and value length.
+	arenaHeaderSize = 8 + 4 + 4
+)
+
+// ErrEntryTooLarge is returned by the arena cache when a serialized entry does not fit in a single chunk.
+var ErrEntryTooLarge = errors.New("store: entry larger than the arena chunk size")
+
+// arenaCache keeps values serialized in large byte chunks, indexed by a map of integers. Neither the chunks nor the
+// index hold pointers, so the garbage collector does not scan the cached entries however many there are.
+type arenaCache[T any] struct {
+	shards    [arenaShards]*arenaShard
+	codec     Codec
+	sanitizer KeySanitizer
+}
+
+// arenaShard is a ring of chunks written sequentially. When every chunk is full the oldest one is recycled and the
+// entries it held are dropped from the index, so eviction is first-in first-out.
+type arenaShard struct {
+	mu        sync.RWMutex
+	index     map[uint64]uint64
+	chunks    [][]byte
+	chunkSize int
+	current   int
+}
+
+// NewArenaCache creates an in-memory cache storing values serialized with the configured codec (JSON by default) in
+// chunks totalling about capacity bytes. Values are decoded on every Get, trading CPU for a heap the garbage
+// collector does not have to scan, which pays off for caches of millions of entries or several gigabytes. An entry,
+// its key and serialized value plus a 16-byte header, must fit in a chunk of capacity/64 bytes, at most 4 MiB: Set
+// rejects larger entries with ErrEntryTooLarge.
+func NewArenaCache[T any](capacity int, opts ...Option) Cacher[T] {
+	return newArenaCache[T](capacity, opts)
+}
+
+// NewStaleWhileRevalidateArenaCache creates a new arena-based StaleWhileRevalidateCache of about capacity bytes.
+func NewStaleWhileRevalidateArenaCache[T any](capacity int, opts ...Option) StaleWhileRevalidateCache[T] {
+	return newArenaCache[StaleValue[T]](capacity, opts)
+}
+
+// newArenaCache creates a new arena cache with the specified capacity in bytes and options.
+func newArenaCache[T any](capacity int, opts []Option) *arenaCache[T] {
+	o := newStoreOptions(opts)
+	chunkSize := capacity / (arenaShards * arenaChunksPerShard)
+	chunks := arenaChunksPerShard
+	if chunkSize > maxArenaChunkSize {
+		chunks = capacity / (arenaShards * maxArenaChunkSize)
+		chunkSize = maxArenaChunkSize
+	}
+	chunkSize = max(chunkSize, arenaHeaderSize)
+
+	c := &arenaCache[T]{codec: o.codec, sanitizer: o.sanitizer}
+	for i := range c.shards {
+		c.shards[i] = &arenaShard{
+			index:     make(map[uint64]uint64),
+			chunks:    make([][]byte, chunks),
+			chunkSize: chunkSize,
+		}
+	}
+	return c
+}
+
+// shard returns the shard holding the key and the hash of the key.
+func (a *arenaCache[T]) shard(key string) (*arenaShard, uint64) {
+	h := xxhash.Sum64String(sanitizeKey(a.sanitizer, key))
+	return a.shards[h%arenaShards], h
+}
+
+// Get decodes the value associated with the given key. A key whose hash collides with a newer entry is reported as missing.
+func (a *arenaCache[T]) Get(ctx context.Context, key string) (value T, exists bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return value, false, err
+	}
+	s, h := a.shard(key)
+	data, exists := s.get(h, sanitizeKey(a.sanitizer, key))
+	if !exists {
+		return value, false, nil
+	}
+	if err := decode(a.codec, data, &value); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+// Set serializes the value and appends it to the shard, recycling the oldest chunk when the shard is full.
+func (a *arenaCache[T]) Set(ctx context.Context, key string, value T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, buf, err := encodePooled(a.codec, value)
+	defer releaseBuffer(buf)
+	if err != nil {
+		return err
+	}
+	s, h := a.shard(key)
+	return s.set(h, sanitizeKey(a.sanitizer, key), data)
+}
+
+// Delete removes the key from the cache. Its bytes are reclaimed when their chunk is recycled.
+func (a *arenaCache[T]) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s, h := a.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop(h, sanitizeKey(a.sanitizer, key))
+	return nil
+}
+
+// Len returns the number of entries in the cache.
+func (a *arenaCache[T]) Len() int {
+	n := 0
+	for _, s := range a.shards {
+		s.mu.RLock()
+		n += len(s.index)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Clear removes every entry from the cache, keeping the chunks allocated for reuse.
+func (a *arenaCache[T]) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, s := range a.shards {
+		s.mu.Lock()
+		clear(s.index)
+		for i := range s.chunks {
+			s.chunks[i] = s.chunks[i][:0]
+		}
+		s.current = 0
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// ClearLocal removes every entry, the cache being held in process memory.
+func (a *arenaCache[T]) ClearLocal(ctx context.Context) error {
+	return a.Clear(ctx)
+}
+
+// TryAcquireRefreshLock attempts to acquire a refresh lock for the specified key, returning true if successful.
+func (a *arenaCache[T]) TryAcquireRefreshLock(_ context.Context, _ string, _ string, _ time.Duration) (bool, error) {
+	return true, nil
+}
+
+// ReleaseRefreshLock releases a previously acquired refresh lock for a cache key if applicable. Always returns nil.
+func (a *arenaCache[T]) ReleaseRefreshLock(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+// get returns a copy of the serialized value stored for the key.
+func (s *arenaShard) get(h uint64, key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.lookup(h, key)
+	if !ok {
+		return nil, false
+	}
+	return bytes.Clone(value), true
+}
+
+// lookup returns the serialized value indexed under the hash when it belongs to the key. Must be called with the
+// lock held.
+func (s *arenaShard) lookup(h uint64, key string) ([]byte, bool) {
+	loc, ok := s.index[h]
+	if !ok {
+		return nil, false
+	}
+	chunk, offset := s.chunks[loc>>32], uint32(loc)
+	keyLen := binary.LittleEndian.Uint32(chunk[offset+8:])
+	valueLen := binary.LittleEndian.Uint32(chunk[offset+12:])
+	start := offset + arenaHeaderSize
+	if string(chunk[start:start+keyLen]) != key {
+		return nil, false
+	}
+	return chunk[start+keyLen : start+keyLen+valueLen], true
+}
+
+// drop removes the key from the index, leaving an entry of another key with the same hash in place. Must be called
+// with the lock held.
+func (s *arenaShard) drop(h uint64, key string) {
+	if _, ok := s.lookup(h, key); ok {
+		delete(s.index, h)
+	}
+}
+
+// set appends the entry to the current chunk, moving to the next one when it does not fit. An entry too large for a
+// chunk is rejected, and the previous value of the key dropped so that it is not served any longer.
+func (s *arenaShard) set(h uint64, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	size := arenaHeaderSize + len(key) + len(value)
+	if size > s.chunkSize {
+		s.drop(h, key)
+		return ErrEntryTooLarge
+	}
+	if s.chunks[s.current] == nil {
+		s.chunks[s.current] = make([]byte, 0, s.chunkSize)
+	}
+	if len(s.chunks[s.current])+size > s.chunkSize {
+		s.current = (s.current + 1) % len(s.chunks)
+		s.recycle(s.current)
+	}
+	chunk := s.chunks[s.current]
+	offset := len(chunk)
+	chunk = binary.LittleEndian.AppendUint64(chunk, h)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(key)))
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(value)))
+	chunk = append(chunk, key...)
+	s.chunks[s.current] = append(chunk, value...)
+	s.index[h] = uint64(s.current)<<32 | uint64(offset)
+	return nil
+}
+
+// recycle empties the chunk, dropping from the index the entries it still holds.
+func (s *arenaShard) recycle(i int) {
+	chunk := s.chunks[i]
+	if chunk == nil {
+		s.chunks[i] = make([]byte, 0, s.chunkSize)
+		return
+	}
+	for offset := 0; offset < len(chunk); {
+		h := binary.LittleEndian.Uint64(chunk[offset:])
+		keyLen := binary.LittleEndian.Uint32(chunk[offset+8:])
+		valueLen := binary.LittleEndian.Uint32(chunk[offset+12:])
+		if s.index[h] == uint64(i)<<32|uint64(offset) {
+			delete(s.index, h)
+		}
+		offset += arenaHeaderSize + int(keyLen) + int(valueLen)
+	}
+	s.chunks[i] = chunk[:0]
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type arenaRecord struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// TestArenaCache verifies that values round-trip through their serialized form and can be deleted and cleared.
+func TestArenaCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewArenaCache[arenaRecord](1 << 20)
+	record := arenaRecord{ID: 1, Name: "alice", Tags: []string{"a", "b"}}
+
+	require.NoError(t, c.Set(ctx, "k", record))
+	value, exists, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, record, value)
+
+	value.Tags[0] = "changed"
+	value, _, _ = c.Get(ctx, "k")
+	assert.Equal(t, "a", value.Tags[0])
+
+	record.Name = "bob"
+	require.NoError(t, c.Set(ctx, "k", record))
+	value, _, _ = c.Get(ctx, "k")
+	assert.Equal(t, "bob", value.Name)
+	size, _ := Len(c)
+	assert.Equal(t, 1, size)
+
+	require.NoError(t, Delete(ctx, c, "k"))
+	_, exists, _ = c.Get(ctx, "k")
+	assert.False(t, exists)
+
+	require.NoError(t, c.Set(ctx, "k", record))
+	require.NoError(t, Clear(ctx, c))
+	size, _ = Len(c)
+	assert.Zero(t, size)
+}
+
+// TestArenaCache_Eviction verifies that the oldest entries are evicted once the capacity is exhausted and that
+// oversized entries are rejected.
+func TestArenaCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewArenaCache[string](64 << 10)
+	value := strings.Repeat("x", 100)
+	for i := 0; i < 2000; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("key-%d", i), value))
+	}
+
+	size, _ := Len(c)
+	assert.Less(t, size, 2000)
+	assert.Greater(t, size, 0)
+	_, exists, _ := c.Get(ctx, "key-0")
+	assert.False(t, exists)
+	_, exists, _ = c.Get(ctx, "key-1999")
+	assert.True(t, exists)
+
+	assert.ErrorIs(t, c.Set(ctx, "big", strings.Repeat("x", 2<<10)), ErrEntryTooLarge)
+}
+
+// TestArenaCache_StaleEntries verifies that a rejected oversized value does not leave the previous one served, and
+// that deleting a key leaves an entry of another key with the same hash in place.
+func TestArenaCache_StaleEntries(t *testing.T) {
+	ctx := context.Background()
+	c := NewArenaCache[string](64 << 10)
+	require.NoError(t, c.Set(ctx, "k", "old"))
+	assert.ErrorIs(t, c.Set(ctx, "k", strings.Repeat("x", 2<<10)), ErrEntryTooLarge)
+	_, exists, _ := c.Get(ctx, "k")
+	assert.False(t, exists)
+
+	shard := &arenaShard{index: make(map[uint64]uint64), chunks: make([][]byte, 1), chunkSize: 1 << 10}
+	require.NoError(t, shard.set(42, "a", []byte("1")))
+	shard.drop(42, "b")
+	value, exists := shard.get(42, "a")
+	assert.True(t, exists)
+	assert.Equal(t, []byte("1"), value)
+	shard.drop(42, "a")
+	_, exists = shard.get(42, "a")
+	assert.False(t, exists)
+}
+
+// BenchmarkArenaVersusLRU compares Set and Get throughput of the arena store and the pointer-based LRU.
+func BenchmarkArenaVersusLRU(b *testing.B) {
+	ctx := context.Background()
+	record := arenaRecord{ID: 1, Name: "alice", Tags: []string{"a", "b"}}
+	stores := map[string]Cacher[arenaRecord]{
+		"LRU":   NewLRUCache[arenaRecord](10000),
+		"Arena": NewArenaCache[arenaRecord](16 << 20),
+	}
+	for name, c := range stores {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				key := fmt.Sprintf("key-%d", i%10000)
+				_ = c.Set(ctx, key, record)
+				_, _, _ = c.Get(ctx, key)
+			}
+		})
+	}
+}
+
+// BenchmarkArenaVersusLRU_GC measures the duration of a full garbage collection with a million cached records.
+func BenchmarkArenaVersusLRU_GC(b *testing.B) {
+	ctx := context.Background()
+	const entries = 1_000_000
+	factories := map[string]func() Cacher[arenaRecord]{
+		"LRU":   func() Cacher[arenaRecord] { return NewLRUCache[arenaRecord](entries) },
+		"Arena": func() Cacher[arenaRecord] { return NewArenaCache[arenaRecord](256 << 20) },
+	}
+	for name, factory := range factories {
+		b.Run(name, func(b *testing.B) {
+			c := factory()
+			for i := 0; i < entries; i++ {
+				_ = c.Set(ctx, fmt.Sprintf("key-%d", i), arenaRecord{ID: i, Name: "alice", Tags: []string{"a", "b"}})
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+			}
+			runtime.KeepAlive(c)
+		})
+	}
+}
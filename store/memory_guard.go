@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultGuardInterval is the interval at which MemoryGuard samples the heap when none is configured.
+	defaultGuardInterval = time.Second
+	// defaultGuardShrink is the fraction of entries MemoryGuard evicts per step when none is configured.
+	defaultGuardShrink = 0.25
+	// guardRestoreRatio is the fraction of the threshold below which MemoryGuard restores the original capacities.
+	guardRestoreRatio = 0.8
+	// heapMetric is the runtime metric holding the bytes occupied by live and not yet collected heap objects.
+	heapMetric = "/memory/classes/heap/objects:bytes"
+)
+
+// ErrInvalidThreshold is returned when a MemoryGuard is configured without a positive heap threshold.
+var ErrInvalidThreshold = errors.New("memory guard threshold must be positive")
+
+// MemoryGuardConfig configures a MemoryGuard. Threshold, required, is the heap size in bytes above which the guarded
+// stores are shrunk, and Interval how often the heap is sampled, every second by default. Shrink is the fraction of
+// the entries of each store evicted per step, 0.25 by default. Heap, when set, replaces the runtime heap reading, for
+// instance with the cgroup memory usage. OnShrink, when set, is called after every step that evicted entries.
+type MemoryGuardConfig struct {
+	Threshold uint64
+	Interval  time.Duration
+	Shrink    float64
+	Heap      func() uint64
+	OnShrink  func(heap uint64, evicted int)
+}
+
+// guardedStore is a store watched by MemoryGuard with the capacity it is restored to.
+type guardedStore struct {
+	store  any
+	size   int
+	shrunk bool
+}
+
+// MemoryGuard protects the host application from cache-induced out-of-memory errors: while the heap exceeds the
+// threshold, it shrinks the guarded in-memory stores step by step, evicting their least recently used entries, and
+// restores their capacity once the heap falls below 80% of the threshold.
+type MemoryGuard struct {
+	cfg    MemoryGuardConfig
+	mu     sync.Mutex
+	stores []*guardedStore
+}
+
+// NewMemoryGuard creates a guard with the given configuration. Stores are added with Guard. ErrInvalidThreshold is
+// returned when the threshold is zero, which would keep every guarded store shrunk.
+func NewMemoryGuard(cfg MemoryGuardConfig) (*MemoryGuard, error) {
+	if cfg.Threshold == 0 {
+		return nil, ErrInvalidThreshold
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultGuardInterval
+	}
+	if cfg.Shrink <= 0 || cfg.Shrink >= 1 {
+		cfg.Shrink = defaultGuardShrink
+	}
+	if cfg.Heap == nil {
+		cfg.Heap = heapBytes
+	}
+	return &MemoryGuard{cfg: cfg}, nil
+}
+
+// Guard adds the store, whose normal capacity is size, to the stores shrunk under memory pressure.
+// ErrNotSupported is returned if the store implements neither Resizer nor Lener, ErrInvalidSize if size is not positive.
+func (g *MemoryGuard) Guard(c any, size int) error {
+	if _, ok := c.(Resizer); !ok {
+		return ErrNotSupported
+	}
+	if _, ok := c.(Lener); !ok {
+		return ErrNotSupported
+	}
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stores = append(g.stores, &guardedStore{store: c, size: size})
+	return nil
+}
+
+// Run samples the heap every interval and shrinks or restores the stores accordingly, until ctx is done.
+func (g *MemoryGuard) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.Check()
+		}
+	}
+}
+
+// Check samples the heap once, shrinking the stores when it exceeds the threshold and restoring them when it has
+// fallen below 80% of it. It returns the number of evicted entries.
+func (g *MemoryGuard) Check() int {
+	heap := g.cfg.Heap()
+	switch {
+	case heap > g.cfg.Threshold:
+		evicted := g.Shrink()
+		if evicted > 0 && g.cfg.OnShrink != nil {
+			g.cfg.OnShrink(heap, evicted)
+		}
+		return evicted
+	case float64(heap) < guardRestoreRatio*float64(g.cfg.Threshold):
+		g.restore()
+	}
+	return 0
+}
+
+// Shrink evicts the configured fraction of the least recently used entries of every guarded store, regardless of
+// the heap size, so that an external signal such as a cgroup memory notification can trigger it. It returns the
+// number of evicted entries.
+func (g *MemoryGuard) Shrink() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	evicted := 0
+	for _, s := range g.stores {
+		n, _ := Len(s.store)
+		if pinner, ok := s.store.(interface{ Pinned() int }); ok {
+			n -= pinner.Pinned()
+		}
+		if n <= 1 {
+			continue
+		}
+		evicted += s.store.(Resizer).Resize(max(int(float64(n)*(1-g.cfg.Shrink)), 1))
+		s.shrunk = true
+	}
+	return evicted
+}
+
+// restore gives back their normal capacity to the shrunk stores.
+func (g *MemoryGuard) restore() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, s := range g.stores {
+		if s.shrunk {
+			s.store.(Resizer).Resize(s.size)
+			s.shrunk = false
+		}
+	}
+}
+
+// heapBytes returns the bytes occupied by heap objects, reachable or not yet collected.
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
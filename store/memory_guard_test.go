@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryGuard verifies that stores are shrunk, coldest entries first, while the heap exceeds the threshold and
+// restored once it falls back.
+func TestMemoryGuard(t *testing.T) {
+	ctx := context.Background()
+	heap := uint64(0)
+	var shrinks []int
+	_, err := NewMemoryGuard(MemoryGuardConfig{Heap: func() uint64 { return heap }})
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+	guard, err := NewMemoryGuard(MemoryGuardConfig{
+		Threshold: 100,
+		Heap:      func() uint64 { return heap },
+		Shrink:    0.5,
+		OnShrink:  func(_ uint64, evicted int) { shrinks = append(shrinks, evicted) },
+	})
+	require.NoError(t, err)
+	c := NewLRUCache[int](10)
+	require.NoError(t, guard.Guard(c, 10))
+	assert.ErrorIs(t, guard.Guard(NewSingleCache[int](time.Minute), 1), ErrNotSupported)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprint(i), i))
+	}
+
+	assert.Zero(t, guard.Check())
+
+	heap = 150
+	assert.Equal(t, 5, guard.Check())
+	assert.Equal(t, 3, guard.Check())
+	assert.Equal(t, []int{5, 3}, shrinks)
+	_, exists, _ := c.Get(ctx, "0")
+	assert.False(t, exists, "the least recently used entries are evicted first")
+	_, exists, _ = c.Get(ctx, "9")
+	assert.True(t, exists)
+
+	heap = 90
+	assert.Zero(t, guard.Check())
+	require.NoError(t, c.Set(ctx, "new", 1))
+	size, _ := Len(c)
+	assert.Equal(t, 2, size, "capacity is kept until the heap falls below 80% of the threshold")
+
+	heap = 50
+	guard.Check()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprint(i), i))
+	}
+	size, _ = Len(c)
+	assert.Equal(t, 10, size)
+}
@@ -3,45 +3,220 @@ package store
 import (
 	"context"
 	lru "github.com/hashicorp/golang-lru/v2"
+	"iter"
 	"time"
 )
 
 // lruCache is a generic wrapper around an LRU cache for storing and retrieving key-value pairs in a thread-safe manner.
 type lruCache[T any] struct {
-	cache *lru.Cache[string, T]
+	cache     *lru.Cache[string, T]
+	sanitizer KeySanitizer
+	clone     func(T) T
+	pins      *pinSet[T]
+	evict     *evictNotifier[T]
 }
 
 // NewLRUCache creates a new instance of a generic LRU cache with the specified size and returns it as a Cacher interface.
-func NewLRUCache[T any](size int) Cacher[T] {
-	c, _ := lru.New[string, T](size)
-
-	return &lruCache[T]{
-		cache: c,
-	}
+func NewLRUCache[T any](size int, opts ...Option) Cacher[T] {
+	return newLRUCache[T](size, opts)
 }
 
 // NewStaleWhileRevalidateLRUCache creates a new LRU-based StaleWhileRevalidateCache with a specified size.
-func NewStaleWhileRevalidateLRUCache[T any](size int) StaleWhileRevalidateCache[T] {
-	c, _ := lru.New[string, StaleValue[T]](size)
+func NewStaleWhileRevalidateLRUCache[T any](size int, opts ...Option) StaleWhileRevalidateCache[T] {
+	return newLRUCache[StaleValue[T]](size, opts)
+}
 
-	return &lruCache[StaleValue[T]]{
-		cache: c,
+// newLRUCache creates a new LRU cache with the specified size and options.
+func newLRUCache[T any](size int, opts []Option) *lruCache[T] {
+	o := newStoreOptions(opts)
+	evict := newEvictNotifier[T](o, 0)
+	c, _ := lru.NewWithEvict[string, T](size, evict.callback())
+
+	return &lruCache[T]{
+		cache:     c,
+		sanitizer: o.sanitizer,
+		clone:     newCloner[T](o),
+		pins:      newPinSet[T]("lru", o.pinSink),
+		evict:     evict,
 	}
 }
 
 // Get retrieves the value associated with the given key from the cache.
-// It returns the value, a boolean indicating if the key exists, and an error which is always nil.
-func (l *lruCache[T]) Get(_ context.Context, key string) (value T, exists bool, err error) {
-	value, exists = l.cache.Get(key)
-	return value, exists, nil
+// It returns the value, a boolean indicating if the key exists, and the context error if the context is already done.
+func (l *lruCache[T]) Get(ctx context.Context, key string) (value T, exists bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return value, false, err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if value, exists, pinned := l.pins.get(k); pinned {
+		return cloneValue(l.clone, value), exists, nil
+	}
+	value, exists = l.cache.Get(k)
+	return cloneValue(l.clone, value), exists, nil
 }
 
 // Set inserts a key-value pair into the LRU cache, potentially evicting an older entry, and returns an error if any occurs.
-func (l *lruCache[T]) Set(_ context.Context, key string, value T) error {
-	l.cache.Add(key, value)
+func (l *lruCache[T]) Set(ctx context.Context, key string, value T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	value = cloneValue(l.clone, value)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if !l.pins.set(k, value) {
+		l.cache.Add(k, value)
+	}
+	return nil
+}
+
+// GetField copies the field at path of the value stored under key into dst, see FieldGetter.
+func (l *lruCache[T]) GetField(ctx context.Context, key string, path string, dst any) (bool, error) {
+	return getField[T](ctx, l, key, path, dst)
+}
+
+// Delete removes the key from the cache.
+func (l *lruCache[T]) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if _, _, pinned := l.pins.take(k); !pinned {
+		l.evict.remove(k, EvictRemoved, func() { l.cache.Remove(k) })
+	}
 	return nil
 }
 
+// Take returns the value and removes it from the cache. When several callers race on the same key only one of them gets the value.
+func (l *lruCache[T]) Take(ctx context.Context, key string) (T, bool, error) {
+	var emptyValue T
+	if err := ctx.Err(); err != nil {
+		return emptyValue, false, err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if value, exists, pinned := l.pins.take(k); pinned {
+		return value, exists, nil
+	}
+	value, exists := l.cache.Peek(k)
+	removed := false
+	l.evict.remove(k, EvictRemoved, func() { removed = l.cache.Remove(k) })
+	if !exists || !removed {
+		return emptyValue, false, nil
+	}
+	return value, true, nil
+}
+
+// PopulateIfAbsent adds the value only if the key is not already cached.
+func (l *lruCache[T]) PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	value = cloneValue(l.clone, value)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if added, pinned := l.pins.populate(k, value); pinned {
+		return added, nil
+	}
+	exists, _ := l.cache.ContainsOrAdd(k, value)
+	return !exists, nil
+}
+
+// Scan returns up to limit keys matching the glob-style pattern, most recently used first.
+func (l *lruCache[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	return matchKeys(rawKeys(l.sanitizer, append(l.pins.keys(), l.cache.Keys()...)), pattern, limit)
+}
+
+// Len returns the number of entries in the cache.
+func (l *lruCache[T]) Len() int {
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	return l.pins.len() + l.cache.Len()
+}
+
+// Clear removes every entry from the cache.
+func (l *lruCache[T]) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	l.pins.clear()
+	l.evict.clear(l.cache.Purge)
+	return nil
+}
+
+// All iterates over the entries of the cache, most recently used first, without updating their recency. Keys are
+// snapshotted when the iteration starts and values are read, and cloned, as they are yielded.
+func (l *lruCache[T]) All(ctx context.Context) iter.Seq2[string, T] {
+	snapshot := func() []string {
+		l.pins.mu.RLock()
+		defer l.pins.mu.RUnlock()
+		return append(l.pins.keys(), l.cache.Keys()...)
+	}
+	return snapshotSeq(ctx, l.sanitizer, snapshot, func(k string) (T, bool) {
+		l.pins.mu.RLock()
+		defer l.pins.mu.RUnlock()
+		value, exists, pinned := l.pins.get(k)
+		if !pinned {
+			value, exists = l.cache.Peek(k)
+		}
+		return cloneValue(l.clone, value), exists
+	})
+}
+
+// peek returns the value associated with the key without updating its recency. The value is not cloned and must not be modified.
+func (l *lruCache[T]) peek(key string) (T, bool) {
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if value, exists, pinned := l.pins.get(k); pinned {
+		return value, exists
+	}
+	return l.cache.Peek(k)
+}
+
+// Resize changes the maximum number of entries of the LRU, evicting the least recently used ones that no longer fit.
+func (l *lruCache[T]) Resize(size int) int {
+	return l.cache.Resize(size)
+}
+
+// Pin keeps the key out of the LRU, so that its value is never evicted, until Unpin is called.
+func (l *lruCache[T]) Pin(key string) {
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.pin(k, func() (T, bool) {
+		value, exists := l.cache.Peek(k)
+		if exists {
+			l.evict.remove(k, evictSuppressed, func() { l.cache.Remove(k) })
+		}
+		return value, exists
+	})
+}
+
+// Unpin moves the key back into the LRU, where it may be evicted again.
+func (l *lruCache[T]) Unpin(key string) {
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.unpin(k, func(value T) {
+		l.cache.Add(k, value)
+	})
+}
+
+// Pinned returns the number of pinned keys.
+func (l *lruCache[T]) Pinned() int {
+	return l.pins.size()
+}
+
 // TryAcquireRefreshLock attempts to acquire a refresh lock for the specified key, returning true if successful.
 func (l *lruCache[T]) TryAcquireRefreshLock(_ context.Context, _ string, _ string, _ time.Duration) (bool, error) {
 	return true, nil
@@ -51,3 +226,8 @@ func (l *lruCache[T]) TryAcquireRefreshLock(_ context.Context, _ string, _ strin
 func (l *lruCache[T]) ReleaseRefreshLock(_ context.Context, _ string, _ string) error {
 	return nil
 }
+
+// ClearLocal removes every entry, the cache being held in process memory.
+func (l *lruCache[T]) ClearLocal(ctx context.Context) error {
+	return l.Clear(ctx)
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"github.com/hashicorp/golang-lru/v2/expirable"
+	"iter"
+	"sync"
 	"time"
 )
 
@@ -10,39 +12,221 @@ import (
 // It wraps an expirable LRU cache implementation with string keys and generic type values.
 // Provides methods for getting, setting, and managing refresh locks on cached items.
 type lruExpirableCache[T any] struct {
-	cache *expirable.LRU[string, T]
+	cache      *expirable.LRU[string, T]
+	sanitizer  KeySanitizer
+	populateMu sync.Mutex
+	clone      func(T) T
+	pins       *pinSet[T]
+	evict      *evictNotifier[T]
 }
 
 // NewLRUExpirableCache creates a new LRU cache with a specified size and time-to-live (TTL) for each entry.
-func NewLRUExpirableCache[T any](size int, ttl time.Duration) Cacher[T] {
-	return newLRUExpirableCache[T](size, ttl)
+func NewLRUExpirableCache[T any](size int, ttl time.Duration, opts ...Option) Cacher[T] {
+	return newLRUExpirableCache[T](size, ttl, opts...)
 }
 
 // NewStaleWhileRevalidateExpiringLRUCache creates a new LRU-based cache with support for stale-while-revalidate and expirable items.
 // The cache allows a maximum of `size` items and applies a time-to-live duration defined by `ttl` for stored data.
-func NewStaleWhileRevalidateExpiringLRUCache[T any](size int, ttl time.Duration) StaleWhileRevalidateCache[T] {
-	return newLRUExpirableCache[StaleValue[T]](size, ttl)
+func NewStaleWhileRevalidateExpiringLRUCache[T any](size int, ttl time.Duration, opts ...Option) StaleWhileRevalidateCache[T] {
+	return newLRUExpirableCache[StaleValue[T]](size, ttl, opts...)
 }
 
 // newLRUExpirableCache creates a new expirable LRU cache with a specified size and time-to-live duration.
-func newLRUExpirableCache[T any](size int, ttl time.Duration) *lruExpirableCache[T] {
+func newLRUExpirableCache[T any](size int, ttl time.Duration, opts ...Option) *lruExpirableCache[T] {
+	o := newStoreOptions(opts)
+	evict := newEvictNotifier[T](o, ttl)
 	return &lruExpirableCache[T]{
-		cache: expirable.NewLRU[string, T](size, nil, ttl),
+		cache:     expirable.NewLRU[string, T](size, evict.callback(), ttl),
+		sanitizer: o.sanitizer,
+		clone:     newCloner[T](o),
+		pins:      newPinSet[T]("lru_expirable", o.pinSink),
+		evict:     evict,
 	}
 }
 
 // Get retrieves the value associated with the given key from the cache. Returns the value, if it exists, and any error encountered.
-func (l *lruExpirableCache[T]) Get(_ context.Context, key string) (value T, exists bool, err error) {
-	value, exists = l.cache.Get(key)
-	return value, exists, nil
+func (l *lruExpirableCache[T]) Get(ctx context.Context, key string) (value T, exists bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return value, false, err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if value, exists, pinned := l.pins.get(k); pinned {
+		return cloneValue(l.clone, value), exists, nil
+	}
+	value, exists = l.cache.Get(k)
+	return cloneValue(l.clone, value), exists, nil
 }
 
 // Set adds a key-value pair to the cache. If the key already exists, its value is updated. Returns an error if the operation fails.
-func (l *lruExpirableCache[T]) Set(_ context.Context, key string, value T) error {
-	l.cache.Add(key, value)
+func (l *lruExpirableCache[T]) Set(ctx context.Context, key string, value T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	value = cloneValue(l.clone, value)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if !l.pins.set(k, value) {
+		l.evict.touch(k)
+		l.cache.Add(k, value)
+	}
+	return nil
+}
+
+// GetField copies the field at path of the value stored under key into dst, see FieldGetter.
+func (l *lruExpirableCache[T]) GetField(ctx context.Context, key string, path string, dst any) (bool, error) {
+	return getField[T](ctx, l, key, path, dst)
+}
+
+// Delete removes the key from the cache.
+func (l *lruExpirableCache[T]) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if _, _, pinned := l.pins.take(k); !pinned {
+		l.evict.remove(k, EvictRemoved, func() { l.cache.Remove(k) })
+	}
 	return nil
 }
 
+// Take returns the value and removes it from the cache. When several callers race on the same key only one of them gets the value.
+func (l *lruExpirableCache[T]) Take(ctx context.Context, key string) (T, bool, error) {
+	var emptyValue T
+	if err := ctx.Err(); err != nil {
+		return emptyValue, false, err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if value, exists, pinned := l.pins.take(k); pinned {
+		return value, exists, nil
+	}
+	value, exists := l.cache.Peek(k)
+	removed := false
+	l.evict.remove(k, EvictRemoved, func() { removed = l.cache.Remove(k) })
+	if !exists || !removed {
+		return emptyValue, false, nil
+	}
+	return value, true, nil
+}
+
+// PopulateIfAbsent adds the value only if the key is not already cached or has expired.
+// Concurrent populations are serialized, but a concurrent Set of the same key may still be overwritten.
+func (l *lruExpirableCache[T]) PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if added, pinned := l.pins.populate(k, cloneValue(l.clone, value)); pinned {
+		return added, nil
+	}
+	l.populateMu.Lock()
+	defer l.populateMu.Unlock()
+	if l.cache.Contains(k) {
+		return false, nil
+	}
+	l.evict.touch(k)
+	l.cache.Add(k, cloneValue(l.clone, value))
+	return true, nil
+}
+
+// Scan returns up to limit keys matching the glob-style pattern, most recently used first.
+func (l *lruExpirableCache[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	return matchKeys(rawKeys(l.sanitizer, append(l.pins.keys(), l.cache.Keys()...)), pattern, limit)
+}
+
+// Len returns the number of entries in the cache, including expired entries not yet collected.
+func (l *lruExpirableCache[T]) Len() int {
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	return l.pins.len() + l.cache.Len()
+}
+
+// Clear removes every entry from the cache.
+func (l *lruExpirableCache[T]) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	l.pins.clear()
+	l.evict.clear(l.cache.Purge)
+	return nil
+}
+
+// All iterates over the entries of the cache, most recently used first, without updating their recency. Keys are
+// snapshotted when the iteration starts and values are read, and cloned, as they are yielded.
+func (l *lruExpirableCache[T]) All(ctx context.Context) iter.Seq2[string, T] {
+	snapshot := func() []string {
+		l.pins.mu.RLock()
+		defer l.pins.mu.RUnlock()
+		return append(l.pins.keys(), l.cache.Keys()...)
+	}
+	return snapshotSeq(ctx, l.sanitizer, snapshot, func(k string) (T, bool) {
+		l.pins.mu.RLock()
+		defer l.pins.mu.RUnlock()
+		value, exists, pinned := l.pins.get(k)
+		if !pinned {
+			value, exists = l.cache.Peek(k)
+		}
+		return cloneValue(l.clone, value), exists
+	})
+}
+
+// peek returns the value associated with the key without updating its recency. The value is not cloned and must not be modified.
+func (l *lruExpirableCache[T]) peek(key string) (T, bool) {
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.mu.RLock()
+	defer l.pins.mu.RUnlock()
+	if value, exists, pinned := l.pins.get(k); pinned {
+		return value, exists
+	}
+	return l.cache.Peek(k)
+}
+
+// Resize changes the maximum number of entries of the LRU, evicting the least recently used ones that no longer fit.
+func (l *lruExpirableCache[T]) Resize(size int) int {
+	return l.cache.Resize(size)
+}
+
+// Pin keeps the key out of the LRU, so that its value is never evicted, until Unpin is called.
+func (l *lruExpirableCache[T]) Pin(key string) {
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.pin(k, func() (T, bool) {
+		value, exists := l.cache.Peek(k)
+		if exists {
+			l.evict.remove(k, evictSuppressed, func() { l.cache.Remove(k) })
+		}
+		return value, exists
+	})
+}
+
+// Unpin moves the key back into the LRU, where it may be evicted again.
+func (l *lruExpirableCache[T]) Unpin(key string) {
+	k := sanitizeKey(l.sanitizer, key)
+	l.pins.unpin(k, func(value T) {
+		l.evict.touch(k)
+		l.cache.Add(k, value)
+	})
+}
+
+// Pinned returns the number of pinned keys.
+func (l *lruExpirableCache[T]) Pinned() int {
+	return l.pins.size()
+}
+
 // TryAcquireRefreshLock attempts to acquire a refresh lock for the specified key and duration.
 // Returns true if the lock is successfully acquired, false otherwise.
 // An error is returned if the lock acquisition fails unexpectedly.
@@ -54,3 +238,8 @@ func (l *lruExpirableCache[T]) TryAcquireRefreshLock(_ context.Context, _ string
 func (l *lruExpirableCache[T]) ReleaseRefreshLock(_ context.Context, _ string, _ string) error {
 	return nil
 }
+
+// ClearLocal removes every entry, the cache being held in process memory.
+func (l *lruExpirableCache[T]) ClearLocal(ctx context.Context) error {
+	return l.Clear(ctx)
+}
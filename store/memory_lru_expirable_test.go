@@ -126,3 +126,24 @@ func TestLRUExpirableCache_ReleaseRefreshLock(t *testing.T) {
 		})
 	}
 }
+
+// TestLRUExpirableCache_PopulateIfAbsent verifies that existing values are never overwritten by a population.
+func TestLRUExpirableCache_PopulateIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUExpirableCache[string](10, time.Minute)
+
+	written, err := PopulateIfAbsent(ctx, cache, "k", "seed")
+	if err != nil || !written {
+		t.Fatalf("expected first population to succeed, got written=%v err=%v", written, err)
+	}
+
+	_ = cache.Set(ctx, "k", "live")
+	written, err = PopulateIfAbsent(ctx, cache, "k", "seed")
+	if err != nil || written {
+		t.Fatalf("expected population of existing key to be skipped, got written=%v err=%v", written, err)
+	}
+
+	if value, _, _ := cache.Get(ctx, "k"); value != "live" {
+		t.Errorf("expected live value to be kept, got %q", value)
+	}
+}
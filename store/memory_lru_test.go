@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -160,3 +162,44 @@ func TestNewLRUCache(t *testing.T) {
 		assert.Equal(t, "value3", value)
 	})
 }
+
+// TestLRUCache_Delete verifies that deleted keys are reported as missing.
+func TestLRUCache_Delete(t *testing.T) {
+	cache := NewLRUCache[string](2)
+	assert.NoError(t, cache.Set(context.Background(), "key1", "value1"))
+	assert.NoError(t, Delete(context.Background(), cache, "key1"))
+	assert.NoError(t, Delete(context.Background(), cache, "missing"))
+
+	_, found, _ := cache.Get(context.Background(), "key1")
+	assert.False(t, found)
+	assert.ErrorIs(t, Delete(context.Background(), failingCacher[string]{}, "key1"), ErrNotSupported)
+}
+
+// TestLRUCache_Take verifies that a value can be taken only once, even by concurrent callers.
+func TestLRUCache_Take(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache[string](10)
+	assert.NoError(t, cache.Set(ctx, "token", "secret"))
+
+	var wg sync.WaitGroup
+	var taken atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, found, err := Take(ctx, cache, "token")
+			assert.NoError(t, err)
+			if found {
+				assert.Equal(t, "secret", value)
+				taken.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), taken.Load())
+
+	_, found, _ := cache.Get(ctx, "token")
+	assert.False(t, found)
+	_, _, err := Take(ctx, failingCacher[string]{}, "token")
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
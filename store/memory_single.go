@@ -2,76 +2,129 @@ package store
 
 import (
 	"context"
-	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// singleEntry is an immutable snapshot of the cached value together with the time it was stored.
+type singleEntry[T any] struct {
+	value       T
+	lastUpdated time.Time
+}
+
 // singleEntryCache represents a thread-safe cache for storing a single generic entry with a time-to-live (TTL) mechanism.
+// The entry is published through an atomic pointer so reads never take a lock and never observe a partially written value.
 type singleEntryCache[T any] struct {
-	cache       T
-	ttl         time.Duration
-	lastUpdated time.Time
-	cacheValid  bool
-	sync.RWMutex
+	entry atomic.Pointer[singleEntry[T]]
+	ttl   time.Duration
+	clone func(T) T
 }
 
-// newSingleEntryCache initializes a single-entry cache with the specified time-to-live duration.
-func newSingleEntryCache[T any](ttl time.Duration) *singleEntryCache[T] {
-	return &singleEntryCache[T]{ttl: ttl}
+// newSingleEntryCache initializes a single-entry cache with the specified time-to-live duration and options.
+func newSingleEntryCache[T any](ttl time.Duration, opts []Option) *singleEntryCache[T] {
+	o := newStoreOptions(opts)
+	return &singleEntryCache[T]{ttl: ttl, clone: newCloner[T](o)}
 }
 
 // NewSingleCache creates a single-entry cache with the specified TTL, returning a generic Cacher interface instance.
-func NewSingleCache[T any](ttl time.Duration) Cacher[T] {
-	return newSingleEntryCache[T](ttl)
+func NewSingleCache[T any](ttl time.Duration, opts ...Option) Cacher[T] {
+	return newSingleEntryCache[T](ttl, opts)
 }
 
 // NewStaleWhileRevalidateSingleCache creates a single-entry cache with a stale-while-revalidate pattern and a specified TTL.
-func NewStaleWhileRevalidateSingleCache[T any](ttl time.Duration) StaleWhileRevalidateCache[T] {
-	return newSingleEntryCache[StaleValue[T]](ttl)
+func NewStaleWhileRevalidateSingleCache[T any](ttl time.Duration, opts ...Option) StaleWhileRevalidateCache[T] {
+	return newSingleEntryCache[StaleValue[T]](ttl, opts)
 }
 
 // Get retrieves the cached value, a boolean indicating if the value exists, and an error if applicable.
-// Returns an empty value if the cache is invalid or expired.
-func (s *singleEntryCache[T]) Get(_ context.Context, _ string) (T, bool, error) {
-
+// Returns an empty value if the cache is invalid or expired. An expired entry is cleared only if it has not been replaced meanwhile.
+func (s *singleEntryCache[T]) Get(ctx context.Context, _ string) (T, bool, error) {
 	var emptyValue T
-	s.RWMutex.RLock()
-	updated := s.lastUpdated
-	ttl := s.ttl
-	exists := s.cacheValid
-	s.RWMutex.RUnlock()
-	cached := s.cache
-	if !exists {
+	if err := ctx.Err(); err != nil {
+		return emptyValue, false, err
+	}
+	current := s.entry.Load()
+	if current == nil {
 		return emptyValue, false, nil
 	}
 
-	if time.Since(updated) > ttl {
-		s.RWMutex.Lock()
-		s.cacheValid = false
-		s.cache = emptyValue
-		s.RWMutex.Unlock()
+	if time.Since(current.lastUpdated) > s.ttl {
+		s.entry.CompareAndSwap(current, nil)
 		return emptyValue, false, nil
 	}
-	return cached, exists, nil
+	return cloneValue(s.clone, current.value), true, nil
+}
 
+// Set replaces the cached value with a new immutable entry stamped with the current time.
+func (s *singleEntryCache[T]) Set(ctx context.Context, _ string, value T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.entry.Store(&singleEntry[T]{
+		value:       cloneValue(s.clone, value),
+		lastUpdated: time.Now(),
+	})
+	return nil
 }
 
-// Set updates the cached value, marks it as valid, and sets the last updated timestamp.
-func (s *singleEntryCache[T]) Set(_ context.Context, _ string, value T) error {
-	s.RWMutex.Lock()
-	defer s.RWMutex.Unlock()
-	s.cache = value
-	s.lastUpdated = time.Now()
-	s.cacheValid = true
+// Delete clears the cached entry regardless of the key.
+func (s *singleEntryCache[T]) Delete(ctx context.Context, _ string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.entry.Store(nil)
 	return nil
 }
 
+// Clear removes the cached entry.
+func (s *singleEntryCache[T]) Clear(ctx context.Context) error {
+	return s.Delete(ctx, "")
+}
+
+// Take returns the cached value and clears the entry regardless of the key. Only one concurrent caller gets the value.
+func (s *singleEntryCache[T]) Take(ctx context.Context, _ string) (T, bool, error) {
+	var emptyValue T
+	if err := ctx.Err(); err != nil {
+		return emptyValue, false, err
+	}
+	current := s.entry.Swap(nil)
+	if current == nil || time.Since(current.lastUpdated) > s.ttl {
+		return emptyValue, false, nil
+	}
+	return current.value, true, nil
+}
+
+// PopulateIfAbsent stores the value only if the cache is empty or its entry has expired.
+func (s *singleEntryCache[T]) PopulateIfAbsent(ctx context.Context, _ string, value T) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	next := &singleEntry[T]{
+		value:       cloneValue(s.clone, value),
+		lastUpdated: time.Now(),
+	}
+	for {
+		current := s.entry.Load()
+		if current != nil && time.Since(current.lastUpdated) <= s.ttl {
+			return false, nil
+		}
+		if s.entry.CompareAndSwap(current, next) {
+			return true, nil
+		}
+	}
+}
+
 // TryAcquireRefreshLock attempts to acquire a lock for refreshing the cache entry and returns true if successful.
-func (l *singleEntryCache[T]) TryAcquireRefreshLock(_ context.Context, _ string, _ string, _ time.Duration) (bool, error) {
+func (s *singleEntryCache[T]) TryAcquireRefreshLock(_ context.Context, _ string, _ string, _ time.Duration) (bool, error) {
 	return true, nil
 }
 
 // ReleaseRefreshLock releases a previously acquired refresh lock, allowing other processes to proceed. Returns an error if unsuccessful.
-func (l *singleEntryCache[T]) ReleaseRefreshLock(_ context.Context, _ string, _ string) error {
+func (s *singleEntryCache[T]) ReleaseRefreshLock(_ context.Context, _ string, _ string) error {
 	return nil
 }
+
+// ClearLocal removes every entry, the cache being held in process memory.
+func (s *singleEntryCache[T]) ClearLocal(ctx context.Context) error {
+	return s.Clear(ctx)
+}
@@ -110,3 +110,48 @@ func TestNewSingleCache(t *testing.T) {
 		}
 	})
 }
+
+// TestSingleEntryCache_Concurrent exercises concurrent readers and writers to make sure reads never observe torn values.
+func TestSingleEntryCache_Concurrent(t *testing.T) {
+	cache := NewSingleCache[[2]int](time.Minute)
+	ctx := context.Background()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = cache.Set(ctx, "key", [2]int{i, i})
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		value, exists, err := cache.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exists && value[0] != value[1] {
+			t.Fatalf("torn read: %v", value)
+		}
+	}
+	<-done
+}
+
+// TestSingleEntryCache_Take verifies that taking the entry clears it.
+func TestSingleEntryCache_Take(t *testing.T) {
+	ctx := context.Background()
+	cache := NewSingleCache[string](time.Minute)
+	_ = cache.Set(ctx, "k", "v")
+
+	value, exists, err := Take(ctx, cache, "k")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !exists || value != "v" {
+		t.Fatalf("expected to take %q, got %q (exists=%v)", "v", value, exists)
+	}
+
+	_, exists, _ = Take(ctx, cache, "k")
+	if exists {
+		t.Error("expected entry to be consumed")
+	}
+}
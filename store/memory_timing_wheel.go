@@ -0,0 +1,398 @@
+package store
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWheelTick   = 100 * time.Millisecond
+	defaultWheelSlots  = 256
+	defaultWheelLevels = 4
+)
+
+// TimingWheelConfig holds the tuning parameters of a TimingWheelCache.
+// Tick is the resolution of the wheel, SlotsPerLevel the number of buckets in each level and Levels the depth of the hierarchy.
+// OnEvict, when set, receives every batch of entries expired during a single tick.
+type TimingWheelConfig[T any] struct {
+	Tick          time.Duration
+	SlotsPerLevel int
+	Levels        int
+	OnEvict       func(batch []EvictedEntry[T])
+}
+
+// EvictedEntry represents a key-value pair removed from a cache because its time-to-live elapsed.
+type EvictedEntry[T any] struct {
+	Key   string
+	Value T
+}
+
+// wheelEntry is a single cached value together with its absolute expiration tick and its current position in the wheel.
+type wheelEntry[T any] struct {
+	key      string
+	value    T
+	expireAt time.Time
+	tick     uint64
+	level    int
+	slot     int
+}
+
+// TimingWheelCache is an in-memory cache whose expiration is driven by a hierarchical timing wheel.
+// Scheduling and expiring an entry costs O(1) regardless of the number of cached items, which makes it suitable for
+// caches holding millions of TTL'd entries. Expired entries are removed in batches by a background goroutine
+// that must be stopped with Close.
+type TimingWheelCache[T any] struct {
+	mu        sync.Mutex
+	entries   map[string]*wheelEntry[T]
+	wheel     [][]map[string]*wheelEntry[T]
+	ttl       time.Duration
+	tick      time.Duration
+	slots     uint64
+	start     time.Time
+	current   uint64
+	onEvict   func(batch []EvictedEntry[T])
+	clone     func(T) T
+	sanitizer KeySanitizer
+	stop      chan struct{}
+	once      sync.Once
+}
+
+// NewTimingWheelCache creates a timing-wheel based cache applying the given TTL to every entry.
+func NewTimingWheelCache[T any](ttl time.Duration, cfg TimingWheelConfig[T], opts ...Option) *TimingWheelCache[T] {
+	return newTimingWheelCache[T](ttl, cfg, opts)
+}
+
+// NewStaleWhileRevalidateTimingWheelCache creates a timing-wheel based cache storing stale-while-revalidate values with the given TTL.
+func NewStaleWhileRevalidateTimingWheelCache[T any](ttl time.Duration, cfg TimingWheelConfig[StaleValue[T]], opts ...Option) *TimingWheelCache[StaleValue[T]] {
+	return newTimingWheelCache[StaleValue[T]](ttl, cfg, opts)
+}
+
+// newTimingWheelCache builds the wheel levels, applies configuration defaults and starts the background ticker.
+func newTimingWheelCache[T any](ttl time.Duration, cfg TimingWheelConfig[T], opts []Option) *TimingWheelCache[T] {
+	if cfg.Tick <= 0 {
+		cfg.Tick = defaultWheelTick
+	}
+	if cfg.SlotsPerLevel <= 1 {
+		cfg.SlotsPerLevel = defaultWheelSlots
+	}
+	if cfg.Levels <= 0 {
+		cfg.Levels = defaultWheelLevels
+	}
+
+	wheel := make([][]map[string]*wheelEntry[T], cfg.Levels)
+	for i := range wheel {
+		wheel[i] = make([]map[string]*wheelEntry[T], cfg.SlotsPerLevel)
+		for j := range wheel[i] {
+			wheel[i][j] = make(map[string]*wheelEntry[T])
+		}
+	}
+
+	o := newStoreOptions(opts)
+	c := &TimingWheelCache[T]{
+		entries:   make(map[string]*wheelEntry[T]),
+		wheel:     wheel,
+		ttl:       ttl,
+		tick:      cfg.Tick,
+		slots:     uint64(cfg.SlotsPerLevel),
+		start:     time.Now(),
+		onEvict:   cfg.OnEvict,
+		clone:     newCloner[T](o),
+		sanitizer: o.sanitizer,
+		stop:      make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// Get retrieves the value associated with the given key. Entries whose TTL elapsed but were not yet collected are reported as missing.
+func (c *TimingWheelCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	key = sanitizeKey(c.sanitizer, key)
+	var emptyValue T
+	if err := ctx.Err(); err != nil {
+		return emptyValue, false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !time.Now().Before(e.expireAt) {
+		return emptyValue, false, nil
+	}
+	return cloneValue(c.clone, e.value), true, nil
+}
+
+// Set stores the value under the given key, replacing any previous entry and rescheduling its expiration.
+func (c *TimingWheelCache[T]) Set(ctx context.Context, key string, value T) error {
+	key = sanitizeKey(c.sanitizer, key)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.setWithTTL(key, value, c.ttl)
+	return nil
+}
+
+// SetWithTTL stores the value under the given key with an explicit time-to-live instead of the cache default.
+func (c *TimingWheelCache[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error {
+	key = sanitizeKey(c.sanitizer, key)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.setWithTTL(key, value, ttl)
+	return nil
+}
+
+// PopulateIfAbsent stores the value only if the key is missing or its TTL elapsed.
+func (c *TimingWheelCache[T]) PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error) {
+	key = sanitizeKey(c.sanitizer, key)
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok && now.Before(e.expireAt) {
+		return false, nil
+	}
+	c.insert(key, value, c.ttl, now)
+	return true, nil
+}
+
+// GetField copies the field at path of the value stored under key into dst, see FieldGetter.
+func (c *TimingWheelCache[T]) GetField(ctx context.Context, key string, path string, dst any) (bool, error) {
+	return getField[T](ctx, c, key, path, dst)
+}
+
+// Delete removes the key from the cache and from its wheel bucket.
+func (c *TimingWheelCache[T]) Delete(ctx context.Context, key string) error {
+	key = sanitizeKey(c.sanitizer, key)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		delete(c.wheel[e.level][e.slot], key)
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// Take returns the value and removes the key from the cache and from its wheel bucket.
+func (c *TimingWheelCache[T]) Take(ctx context.Context, key string) (T, bool, error) {
+	key = sanitizeKey(c.sanitizer, key)
+	var emptyValue T
+	if err := ctx.Err(); err != nil {
+		return emptyValue, false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return emptyValue, false, nil
+	}
+	delete(c.wheel[e.level][e.slot], key)
+	delete(c.entries, key)
+	if !time.Now().Before(e.expireAt) {
+		return emptyValue, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Scan returns up to limit non-expired keys matching the glob-style pattern, in no particular order.
+func (c *TimingWheelCache[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return matchKeys(rawKeys(c.sanitizer, c.liveKeys()), pattern, limit)
+}
+
+// All iterates over the non-expired entries of the cache, in no particular order. Keys are snapshotted when the
+// iteration starts and values are read, and cloned, as they are yielded.
+func (c *TimingWheelCache[T]) All(ctx context.Context) iter.Seq2[string, T] {
+	return snapshotSeq(ctx, c.sanitizer, c.liveKeys, func(key string) (T, bool) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		e, ok := c.entries[key]
+		if !ok || !time.Now().Before(e.expireAt) {
+			var emptyValue T
+			return emptyValue, false
+		}
+		return cloneValue(c.clone, e.value), true
+	})
+}
+
+// liveKeys returns the sanitized keys of the non-expired entries.
+func (c *TimingWheelCache[T]) liveKeys() []string {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.entries))
+	for key, e := range c.entries {
+		if now.Before(e.expireAt) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// TTL returns the remaining time-to-live of the given key.
+func (c *TimingWheelCache[T]) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	key = sanitizeKey(c.sanitizer, key)
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return 0, false, nil
+	}
+	remaining := time.Until(e.expireAt)
+	if remaining <= 0 {
+		return 0, false, nil
+	}
+	return remaining, true, nil
+}
+
+// Len returns the number of entries currently held by the wheel, including expired entries not yet collected.
+func (c *TimingWheelCache[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear removes every entry from the cache and from the wheel buckets. No eviction callback is invoked.
+func (c *TimingWheelCache[T]) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		delete(c.wheel[e.level][e.slot], key)
+	}
+	c.entries = make(map[string]*wheelEntry[T])
+	return nil
+}
+
+// Close stops the background expiration goroutine. The cache remains readable but entries are no longer collected.
+func (c *TimingWheelCache[T]) Close() {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+}
+
+// TryAcquireRefreshLock attempts to acquire a refresh lock for the specified key. Always succeeds for in-memory caches.
+func (c *TimingWheelCache[T]) TryAcquireRefreshLock(_ context.Context, _ string, _ string, _ time.Duration) (bool, error) {
+	return true, nil
+}
+
+// ReleaseRefreshLock releases a previously acquired refresh lock for a cache key. Always returns nil.
+func (c *TimingWheelCache[T]) ReleaseRefreshLock(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+// setWithTTL stores the value with an explicit time-to-live and places it into the appropriate wheel bucket.
+func (c *TimingWheelCache[T]) setWithTTL(key string, value T, ttl time.Duration) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insert(key, value, ttl, now)
+}
+
+// insert replaces any previous entry for the key and schedules the new one. Must be called with the lock held.
+func (c *TimingWheelCache[T]) insert(key string, value T, ttl time.Duration, now time.Time) {
+	if old, ok := c.entries[key]; ok {
+		delete(c.wheel[old.level][old.slot], key)
+	}
+	ticks := uint64((ttl + c.tick - 1) / c.tick)
+	if ticks == 0 {
+		ticks = 1
+	}
+	e := &wheelEntry[T]{
+		key:      key,
+		value:    cloneValue(c.clone, value),
+		expireAt: now.Add(ttl),
+		tick:     c.current + ticks,
+	}
+	c.entries[key] = e
+	c.schedule(e)
+}
+
+// schedule places the entry in the lowest level whose span covers the remaining ticks. Must be called with the lock held.
+func (c *TimingWheelCache[T]) schedule(e *wheelEntry[T]) {
+	delta := uint64(0)
+	if e.tick > c.current {
+		delta = e.tick - c.current
+	}
+	span := uint64(1)
+	level := 0
+	for ; level < len(c.wheel)-1; level++ {
+		if delta < span*c.slots {
+			break
+		}
+		span *= c.slots
+	}
+	e.level = level
+	e.slot = int((e.tick / span) % c.slots)
+	c.wheel[level][e.slot][e.key] = e
+}
+
+// run advances the wheel at every tick until Close is called.
+func (c *TimingWheelCache[T]) run() {
+	ticker := time.NewTicker(c.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.advance(uint64(time.Since(c.start) / c.tick))
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// advance moves the wheel forward up to the target tick, cascading higher levels and collecting expired entries in batch.
+func (c *TimingWheelCache[T]) advance(target uint64) {
+	var batch []EvictedEntry[T]
+	c.mu.Lock()
+	for c.current < target {
+		c.current++
+		span := uint64(1)
+		for level := 1; level < len(c.wheel); level++ {
+			span *= c.slots
+			if c.current%span != 0 {
+				break
+			}
+			slot := int((c.current / span) % c.slots)
+			bucket := c.wheel[level][slot]
+			c.wheel[level][slot] = make(map[string]*wheelEntry[T])
+			for _, e := range bucket {
+				c.schedule(e)
+			}
+		}
+		slot := int(c.current % c.slots)
+		bucket := c.wheel[0][slot]
+		for key, e := range bucket {
+			if e.tick > c.current {
+				continue
+			}
+			delete(bucket, key)
+			delete(c.entries, key)
+			if c.onEvict != nil {
+				batch = append(batch, EvictedEntry[T]{Key: key, Value: e.value})
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if len(batch) > 0 {
+		c.onEvict(batch)
+	}
+}
+
+// ClearLocal removes every entry, the cache being held in process memory.
+func (c *TimingWheelCache[T]) ClearLocal(ctx context.Context) error {
+	return c.Clear(ctx)
+}
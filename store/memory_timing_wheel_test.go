@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTimingWheelCache_GetSet verifies basic storage, overwrite and miss behavior of the timing-wheel cache.
+func TestTimingWheelCache_GetSet(t *testing.T) {
+	cache := NewTimingWheelCache[string](time.Minute, TimingWheelConfig[string]{})
+	defer cache.Close()
+
+	_, found, err := cache.Get(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, cache.Set(context.Background(), "key1", "value1"))
+	assert.NoError(t, cache.Set(context.Background(), "key1", "value2"))
+
+	value, found, err := cache.Get(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value2", value)
+	assert.Equal(t, 1, cache.Len())
+}
+
+// TestTimingWheelCache_Expiration verifies that entries expire and are delivered to the eviction callback in batch.
+func TestTimingWheelCache_Expiration(t *testing.T) {
+	var mu sync.Mutex
+	evicted := map[string]int{}
+	cache := NewTimingWheelCache[int](50*time.Millisecond, TimingWheelConfig[int]{
+		Tick:          10 * time.Millisecond,
+		SlotsPerLevel: 4,
+		Levels:        3,
+		OnEvict: func(batch []EvictedEntry[int]) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, e := range batch {
+				evicted[e.Key] = e.Value
+			}
+		},
+	})
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set(context.Background(), "a", 1))
+	assert.NoError(t, cache.Set(context.Background(), "b", 2))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(evicted) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	_, found, _ := cache.Get(context.Background(), "a")
+	assert.False(t, found)
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, evicted)
+}
+
+// TestTimingWheelCache_Cascade verifies that entries scheduled on higher levels cascade down and expire on time.
+func TestTimingWheelCache_Cascade(t *testing.T) {
+	cache := NewTimingWheelCache[string](time.Hour, TimingWheelConfig[string]{SlotsPerLevel: 4, Levels: 3})
+	cache.Close()
+
+	cache.setWithTTL("short", "s", 3*cache.tick)
+	cache.setWithTTL("long", "l", 40*cache.tick)
+
+	cache.advance(3)
+	assert.Equal(t, 1, cache.Len())
+
+	cache.advance(39)
+	assert.Equal(t, 1, cache.Len())
+
+	cache.advance(40)
+	assert.Equal(t, 0, cache.Len())
+}
+
+// TestTimingWheelCache_PopulateIfAbsent verifies that only missing keys are populated.
+func TestTimingWheelCache_PopulateIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTimingWheelCache[string](time.Minute, TimingWheelConfig[string]{})
+	defer cache.Close()
+
+	written, err := cache.PopulateIfAbsent(ctx, "k", "seed")
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	written, err = cache.PopulateIfAbsent(ctx, "k", "other")
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	value, _, _ := cache.Get(ctx, "k")
+	assert.Equal(t, "seed", value)
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsSink receives metrics emitted by stores and caches. It is intentionally minimal so it can be adapted
+// to Prometheus, OpenTelemetry or any other metrics backend.
+type MetricsSink interface {
+	IncCounter(name string, labels map[string]string, delta float64)
+	SetGauge(name string, labels map[string]string, value float64)
+	ObserveDuration(name string, labels map[string]string, d time.Duration)
+}
+
+// NopMetrics is a MetricsSink discarding every metric.
+type NopMetrics struct{}
+
+// IncCounter discards the counter increment.
+func (NopMetrics) IncCounter(string, map[string]string, float64) {}
+
+// SetGauge discards the gauge value.
+func (NopMetrics) SetGauge(string, map[string]string, float64) {}
+
+// ObserveDuration discards the observed duration.
+func (NopMetrics) ObserveDuration(string, map[string]string, time.Duration) {}
+
+// maxDurationSamples bounds the duration samples MemoryMetrics keeps for each series.
+const maxDurationSamples = 1024
+
+// MemoryMetrics is a thread-safe in-memory MetricsSink, useful for tests and for exposing metrics through custom endpoints.
+// Series are identified by the metric name followed by the labels sorted by name, e.g. `ops{backend="redis",op="get"}`.
+// Only the latest 1024 duration samples of each series are kept, so long-running processes do not grow without bound.
+type MemoryMetrics struct {
+	mu        sync.Mutex
+	counters  map[string]float64
+	gauges    map[string]float64
+	durations map[string]*durationRing
+}
+
+// durationRing holds the latest duration samples of a series, overwriting the oldest one once full.
+type durationRing struct {
+	samples []time.Duration
+	next    int
+}
+
+// add records a sample, replacing the oldest one when the ring is full.
+func (r *durationRing) add(d time.Duration) {
+	if len(r.samples) < maxDurationSamples {
+		r.samples = append(r.samples, d)
+		return
+	}
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % maxDurationSamples
+}
+
+// list returns a copy of the samples from the oldest to the latest.
+func (r *durationRing) list() []time.Duration {
+	out := make([]time.Duration, 0, len(r.samples))
+	out = append(out, r.samples[r.next:]...)
+	return append(out, r.samples[:r.next]...)
+}
+
+// NewMemoryMetrics creates an empty in-memory metrics sink.
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		counters:  make(map[string]float64),
+		gauges:    make(map[string]float64),
+		durations: make(map[string]*durationRing),
+	}
+}
+
+// IncCounter adds delta to the counter identified by name and labels.
+func (m *MemoryMetrics) IncCounter(name string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[SeriesName(name, labels)] += delta
+}
+
+// SetGauge sets the gauge identified by name and labels.
+func (m *MemoryMetrics) SetGauge(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[SeriesName(name, labels)] = value
+}
+
+// ObserveDuration records a duration sample for the series identified by name and labels, discarding the oldest
+// sample of the series once it holds 1024 of them.
+func (m *MemoryMetrics) ObserveDuration(name string, labels map[string]string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series := SeriesName(name, labels)
+	ring, ok := m.durations[series]
+	if !ok {
+		ring = &durationRing{}
+		m.durations[series] = ring
+	}
+	ring.add(d)
+}
+
+// Counter returns the current value of the counter identified by name and labels.
+func (m *MemoryMetrics) Counter(name string, labels map[string]string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[SeriesName(name, labels)]
+}
+
+// Gauge returns the current value of the gauge identified by name and labels.
+func (m *MemoryMetrics) Gauge(name string, labels map[string]string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.gauges[SeriesName(name, labels)]
+}
+
+// Durations returns a copy of the latest duration samples recorded for the series identified by name and labels,
+// from the oldest to the latest.
+func (m *MemoryMetrics) Durations(name string, labels map[string]string) []time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ring, ok := m.durations[SeriesName(name, labels)]
+	if !ok {
+		return nil
+	}
+	return ring.list()
+}
+
+// SeriesName renders a metric name and its labels, sorted by label name, in Prometheus exposition style.
+func SeriesName(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(labels[k])
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemoryMetrics_DurationsBounded verifies that only the latest samples of a series are kept, in order.
+func TestMemoryMetrics_DurationsBounded(t *testing.T) {
+	metrics := NewMemoryMetrics()
+	labels := map[string]string{"op": "get"}
+	assert.Nil(t, metrics.Durations("latency", labels))
+
+	for i := range maxDurationSamples + 10 {
+		metrics.ObserveDuration("latency", labels, time.Duration(i))
+	}
+
+	durations := metrics.Durations("latency", labels)
+	assert.Len(t, durations, maxDurationSamples)
+	assert.Equal(t, time.Duration(10), durations[0])
+	assert.Equal(t, time.Duration(maxDurationSamples+9), durations[len(durations)-1])
+	for i := 1; i < len(durations); i++ {
+		assert.Equal(t, durations[i-1]+1, durations[i])
+	}
+}
@@ -0,0 +1,432 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Middleware decorates a Cacher with additional behavior such as retries, timeouts or instrumentation.
+// Third parties can write their own decorators and compose them with the built-in ones using Chain.
+type Middleware[T any] func(Cacher[T]) Cacher[T]
+
+// Chain wraps inner with the given middlewares. The first middleware is the outermost one, so
+// Chain(c, a, b) behaves as a(b(c)). Optional capabilities are forwarded as described by forwardingCacher.
+func Chain[T any](inner Cacher[T], mws ...Middleware[T]) Cacher[T] {
+	if len(mws) == 0 {
+		return inner
+	}
+	return chain(inner, mws)
+}
+
+// chain implements Chain, wrapping the result of every middleware so that the capabilities of the layer below it
+// stay visible.
+func chain[T any](inner Cacher[T], mws []Middleware[T]) *forwardingCacher[T] {
+	f := forward(inner, inner)
+	for i := len(mws) - 1; i >= 0; i-- {
+		f = forward(mws[i](f), f)
+	}
+	return f
+}
+
+// ChainStaleWhileRevalidate wraps a stale-while-revalidate cache with the given middlewares,
+// preserving the refresh lock methods and the optional capabilities of inner.
+func ChainStaleWhileRevalidate[T any](inner StaleWhileRevalidateCache[T], mws ...Middleware[StaleValue[T]]) StaleWhileRevalidateCache[T] {
+	return staleWhileRevalidateAdapter[T]{
+		forwardingCacher: chain[StaleValue[T]](inner, mws),
+		RefreshLocker:    inner,
+	}
+}
+
+// staleWhileRevalidateAdapter combines a decorated Cacher of stale values with the refresh locker of the original store.
+type staleWhileRevalidateAdapter[T any] struct {
+	*forwardingCacher[StaleValue[T]]
+	RefreshLocker
+}
+
+// forwardingCacher is a decorated Cacher that also implements the optional capabilities of the store it decorates:
+// Deleter, Clearer, Scanner, TTLSetter, TTLInspector, LocalClearer and LocalDeleter. Each of them is served by the
+// decorator when it implements it, and by the inner store otherwise, bypassing the decorator. A capability neither
+// implements returns ErrNotSupported, except TTL, which reports no expiration, and the local operations, which leave
+// the store untouched as ClearLocal and DeleteLocal do. The bulk operations always go through Get and Set of the
+// decorator.
+type forwardingCacher[T any] struct {
+	Cacher[T]
+	inner any
+}
+
+// forward wraps the decorator outer of inner.
+func forward[T any](outer Cacher[T], inner any) *forwardingCacher[T] {
+	return &forwardingCacher[T]{Cacher: outer, inner: inner}
+}
+
+// capability returns the decorator when it implements I, and the inner store otherwise. The boolean result is false
+// when neither implements I.
+func capability[I any](outer any, inner any) (I, bool) {
+	if c, ok := outer.(I); ok {
+		return c, true
+	}
+	c, ok := inner.(I)
+	return c, ok
+}
+
+// Delete removes the key, returning ErrNotSupported when neither the decorator nor the inner store is a Deleter.
+func (f *forwardingCacher[T]) Delete(ctx context.Context, key string) error {
+	if deleter, ok := capability[Deleter](f.Cacher, f.inner); ok {
+		return deleter.Delete(ctx, key)
+	}
+	return ErrNotSupported
+}
+
+// Clear removes every entry, with the Clear function of this package applied to the inner store when neither the
+// decorator nor the inner store is a Clearer.
+func (f *forwardingCacher[T]) Clear(ctx context.Context) error {
+	if clearer, ok := capability[Clearer](f.Cacher, f.inner); ok {
+		return clearer.Clear(ctx)
+	}
+	return Clear(ctx, f.inner)
+}
+
+// Scan enumerates the keys, returning ErrNotSupported when neither the decorator nor the inner store is a Scanner.
+func (f *forwardingCacher[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if scanner, ok := capability[Scanner](f.Cacher, f.inner); ok {
+		return scanner.Scan(ctx, pattern, limit)
+	}
+	return nil, ErrNotSupported
+}
+
+// SetWithTTL stores the value with the given time-to-live, returning ErrNotSupported when neither the decorator nor
+// the inner store is a TTLSetter.
+func (f *forwardingCacher[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if setter, ok := capability[TTLSetter[T]](f.Cacher, f.inner); ok {
+		return setter.SetWithTTL(ctx, key, value, ttl)
+	}
+	return ErrNotSupported
+}
+
+// TTL reports the remaining time-to-live of the key, or no expiration when neither the decorator nor the inner store
+// is a TTLInspector.
+func (f *forwardingCacher[T]) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	if inspector, ok := capability[TTLInspector](f.Cacher, f.inner); ok {
+		return inspector.TTL(ctx, key)
+	}
+	return 0, false, nil
+}
+
+// ClearLocal removes the entries held in process memory, doing nothing when neither the decorator nor the inner store
+// is a LocalClearer.
+func (f *forwardingCacher[T]) ClearLocal(ctx context.Context) error {
+	if clearer, ok := capability[LocalClearer](f.Cacher, f.inner); ok {
+		return clearer.ClearLocal(ctx)
+	}
+	return nil
+}
+
+// DeleteLocal removes the key from the entries held in process memory, with the DeleteLocal function of this package
+// applied to the inner store when neither the decorator nor the inner store is a LocalDeleter.
+func (f *forwardingCacher[T]) DeleteLocal(ctx context.Context, key string) error {
+	if deleter, ok := capability[LocalDeleter](f.Cacher, f.inner); ok {
+		return deleter.DeleteLocal(ctx, key)
+	}
+	return DeleteLocal(ctx, f.inner, key)
+}
+
+// valueCodec returns the codec of the decorator or, failing that, of the inner store.
+func (f *forwardingCacher[T]) valueCodec() Codec {
+	if provider, ok := capability[codecProvider](f.Cacher, f.inner); ok {
+		return provider.valueCodec()
+	}
+	return nil
+}
+
+// middlewareCacher is a Cacher built from plain functions, used to implement middlewares concisely.
+type middlewareCacher[T any] struct {
+	get func(ctx context.Context, key string) (T, bool, error)
+	set func(ctx context.Context, key string, value T) error
+}
+
+// Get delegates to the configured get function.
+func (m middlewareCacher[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	return m.get(ctx, key)
+}
+
+// Set delegates to the configured set function.
+func (m middlewareCacher[T]) Set(ctx context.Context, key string, value T) error {
+	return m.set(ctx, key, value)
+}
+
+// Retry returns a middleware retrying failed Get and Set operations up to attempts times in total,
+// waiting backoff between attempts. Context cancellation stops the retries immediately.
+func Retry[T any](attempts int, backoff time.Duration) Middleware[T] {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(next Cacher[T]) Cacher[T] {
+		return middlewareCacher[T]{
+			get: func(ctx context.Context, key string) (T, bool, error) {
+				var (
+					value  T
+					exists bool
+					err    error
+				)
+				for i := 0; i < attempts; i++ {
+					value, exists, err = next.Get(ctx, key)
+					if err == nil || !waitRetry(ctx, i, attempts, backoff) {
+						break
+					}
+				}
+				return value, exists, err
+			},
+			set: func(ctx context.Context, key string, value T) error {
+				var err error
+				for i := 0; i < attempts; i++ {
+					err = next.Set(ctx, key, value)
+					if err == nil || !waitRetry(ctx, i, attempts, backoff) {
+						break
+					}
+				}
+				return err
+			},
+		}
+	}
+}
+
+// waitRetry sleeps for backoff before the next attempt and reports whether another attempt should be made.
+func waitRetry(ctx context.Context, attempt int, attempts int, backoff time.Duration) bool {
+	if attempt == attempts-1 {
+		return false
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Timeout returns a middleware bounding every Get and Set by d when the caller's context has no deadline.
+func Timeout[T any](d time.Duration) Middleware[T] {
+	return func(next Cacher[T]) Cacher[T] {
+		return middlewareCacher[T]{
+			get: func(ctx context.Context, key string) (T, bool, error) {
+				ctx, cancel := withDefaultTimeout(ctx, d)
+				defer cancel()
+				return next.Get(ctx, key)
+			},
+			set: func(ctx context.Context, key string, value T) error {
+				ctx, cancel := withDefaultTimeout(ctx, d)
+				defer cancel()
+				return next.Set(ctx, key, value)
+			},
+		}
+	}
+}
+
+// Bounded returns a middleware limiting the number of concurrent Get and Set operations reaching the wrapped store
+// to getLimit and setLimit, so bursty cache traffic cannot overwhelm a small Redis instance or a rate-limited KV
+// service. Operations over the limit wait for a slot until their context is done. A non-positive limit leaves the
+// corresponding operation unbounded.
+func Bounded[T any](getLimit int64, setLimit int64) Middleware[T] {
+	return func(next Cacher[T]) Cacher[T] {
+		getSem, setSem := newOptionalSemaphore(getLimit), newOptionalSemaphore(setLimit)
+		return middlewareCacher[T]{
+			get: func(ctx context.Context, key string) (T, bool, error) {
+				if getSem != nil {
+					if err := getSem.Acquire(ctx, 1); err != nil {
+						var emptyValue T
+						return emptyValue, false, err
+					}
+					defer getSem.Release(1)
+				}
+				return next.Get(ctx, key)
+			},
+			set: func(ctx context.Context, key string, value T) error {
+				if setSem != nil {
+					if err := setSem.Acquire(ctx, 1); err != nil {
+						return err
+					}
+					defer setSem.Release(1)
+				}
+				return next.Set(ctx, key, value)
+			},
+		}
+	}
+}
+
+// defaultFanOutMaxInFlight bounds the writes forwarded concurrently by FanOut by default.
+const defaultFanOutMaxInFlight = 100
+
+// defaultFanOutTimeout bounds each write forwarded by FanOut by default.
+const defaultFanOutTimeout = 5 * time.Second
+
+// FanOutConfig tunes the FanOut middleware. Percentage, from 0 to 100, is the share of keys whose writes are
+// forwarded; keys are selected by hash, so the same keys stay warm in the secondary. At most MaxInFlight writes are
+// forwarded concurrently, 100 by default, and further ones are dropped. Each forwarded write is bounded by Timeout,
+// 5s by default. OnError, when set, is called with the key of every forwarded write or delete that fails or is
+// dropped, and with "*" for a failed Clear.
+type FanOutConfig struct {
+	Percentage  float64
+	MaxInFlight int
+	Timeout     time.Duration
+	OnError     func(key string, err error)
+}
+
+// ErrFanOutDropped is reported to FanOutConfig.OnError for writes not forwarded because MaxInFlight were in flight.
+var ErrFanOutDropped = errors.New("fan-out write dropped: too many writes in flight")
+
+// FanOut returns a middleware forwarding successful Sets of a percentage of the keys to secondary in the background,
+// so a standby region keeps a warm cache without adding its latency to the primary write path. Reads are served by
+// the wrapped store only. Deletes and Clears are applied to the wrapped store and then forwarded to secondary before
+// returning, for every key, so the standby never keeps a value removed from the primary; a forwarded Set overtaken by
+// a Delete or Clear is removed again from secondary. Forwarding failures are logged and reported to OnError.
+func FanOut[T any](secondary Cacher[T], cfg FanOutConfig) Middleware[T] {
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = defaultFanOutMaxInFlight
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultFanOutTimeout
+	}
+	threshold := uint64(min(max(cfg.Percentage, 0), 100) * 100)
+	return func(next Cacher[T]) Cacher[T] {
+		return &fanOutCacher[T]{
+			next:      next,
+			secondary: secondary,
+			cfg:       cfg,
+			threshold: threshold,
+			slots:     make(chan struct{}, cfg.MaxInFlight),
+			pending:   make(map[string]*fanOutKey),
+		}
+	}
+}
+
+// fanOutCacher is the Cacher built by FanOut.
+type fanOutCacher[T any] struct {
+	next      Cacher[T]
+	secondary Cacher[T]
+	cfg       FanOutConfig
+	threshold uint64
+	slots     chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]*fanOutKey
+	clears  uint64
+}
+
+// fanOutKey tracks the Sets of a key being forwarded and the Deletes of the key since the first of them started.
+type fanOutKey struct {
+	sets    int
+	deletes uint64
+}
+
+// Get reads from the wrapped store only.
+func (f *fanOutCacher[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	return f.next.Get(ctx, key)
+}
+
+// Set writes to the wrapped store and forwards the write of a selected key to secondary in the background.
+func (f *fanOutCacher[T]) Set(ctx context.Context, key string, value T) error {
+	if err := f.next.Set(ctx, key, value); err != nil {
+		return err
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	if h.Sum64()%10000 >= f.threshold {
+		return nil
+	}
+	select {
+	case f.slots <- struct{}{}:
+	default:
+		f.failed(key, ErrFanOutDropped)
+		return nil
+	}
+	f.mu.Lock()
+	pk, ok := f.pending[key]
+	if !ok {
+		pk = &fanOutKey{}
+		f.pending[key] = pk
+	}
+	pk.sets++
+	deletes, clears := pk.deletes, f.clears
+	f.mu.Unlock()
+
+	go func() {
+		defer func() { <-f.slots }()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), f.cfg.Timeout)
+		defer cancel()
+		if err := f.secondary.Set(ctx, key, value); err != nil {
+			f.failed(key, err)
+		}
+		f.mu.Lock()
+		pk.sets--
+		overtaken := pk.deletes != deletes || f.clears != clears
+		if pk.sets == 0 {
+			delete(f.pending, key)
+		}
+		f.mu.Unlock()
+		if overtaken {
+			if err := Delete(ctx, f.secondary, key); err != nil {
+				f.failed(key, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Delete removes the key from the wrapped store and then from secondary. Returns ErrNotSupported if the wrapped store
+// cannot delete entries.
+func (f *fanOutCacher[T]) Delete(ctx context.Context, key string) error {
+	if err := Delete(ctx, f.next, key); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	if pk, ok := f.pending[key]; ok {
+		pk.deletes++
+	}
+	f.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), f.cfg.Timeout)
+	defer cancel()
+	if err := Delete(ctx, f.secondary, key); err != nil {
+		f.failed(key, err)
+	}
+	return nil
+}
+
+// Clear removes every entry from the wrapped store and then from secondary. Returns ErrNotSupported if the wrapped
+// store can be neither cleared nor enumerated.
+func (f *fanOutCacher[T]) Clear(ctx context.Context) error {
+	if err := Clear(ctx, f.next); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.clears++
+	f.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), f.cfg.Timeout)
+	defer cancel()
+	if err := Clear(ctx, f.secondary); err != nil {
+		f.failed("*", err)
+	}
+	return nil
+}
+
+// failed logs a forwarding failure and reports it to the OnError hook, if any.
+func (f *fanOutCacher[T]) failed(key string, err error) {
+	slog.Warn("Cannot forward cache write to secondary", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	if f.cfg.OnError != nil {
+		f.cfg.OnError(key, err)
+	}
+}
+
+// newOptionalSemaphore returns a semaphore of the given size, or nil when size is not positive.
+func newOptionalSemaphore(size int64) *semaphore.Weighted {
+	if size <= 0 {
+		return nil
+	}
+	return semaphore.NewWeighted(size)
+}
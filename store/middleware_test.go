@@ -0,0 +1,306 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyCacher fails the first failures operations and then delegates to an LRU cache.
+type flakyCacher[T any] struct {
+	failures int
+	calls    int
+	inner    Cacher[T]
+}
+
+// Get fails until the configured number of failures has been reached.
+func (f *flakyCacher[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		var zero T
+		return zero, false, errors.New("transient")
+	}
+	return f.inner.Get(ctx, key)
+}
+
+// Set fails until the configured number of failures has been reached.
+func (f *flakyCacher[T]) Set(ctx context.Context, key string, value T) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("transient")
+	}
+	return f.inner.Set(ctx, key, value)
+}
+
+// TestChain verifies that middlewares are applied with the first one outermost.
+func TestChain(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware[string] {
+		return func(next Cacher[string]) Cacher[string] {
+			return middlewareCacher[string]{
+				get: func(ctx context.Context, key string) (string, bool, error) {
+					order = append(order, name)
+					return next.Get(ctx, key)
+				},
+				set: next.Set,
+			}
+		}
+	}
+
+	cache := Chain[string](NewLRUCache[string](10), trace("outer"), trace("inner"))
+	_, _, _ = cache.Get(context.Background(), "k")
+	assert.Equal(t, []string{"outer", "inner"}, order)
+}
+
+// TestRetry verifies that transient failures are retried up to the configured attempts.
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	flaky := &flakyCacher[string]{failures: 2, inner: NewLRUCache[string](10)}
+	cache := Chain[string](flaky, Retry[string](3, time.Millisecond))
+
+	assert.NoError(t, cache.Set(ctx, "k", "v"))
+	assert.Equal(t, 3, flaky.calls)
+
+	flaky.calls, flaky.failures = 0, 5
+	_, _, err := cache.Get(ctx, "k")
+	assert.Error(t, err)
+	assert.Equal(t, 3, flaky.calls)
+}
+
+// TestTimeout verifies that the timeout middleware bounds operations without a deadline.
+func TestTimeout(t *testing.T) {
+	var deadline time.Time
+	probe := func(next Cacher[string]) Cacher[string] {
+		return middlewareCacher[string]{
+			get: func(ctx context.Context, key string) (string, bool, error) {
+				deadline, _ = ctx.Deadline()
+				return next.Get(ctx, key)
+			},
+			set: next.Set,
+		}
+	}
+
+	cache := Chain[string](NewLRUCache[string](10), Timeout[string](time.Second), probe)
+	_, _, _ = cache.Get(context.Background(), "k")
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
+
+// TestChainStaleWhileRevalidate verifies that refresh locks survive decoration.
+func TestChainStaleWhileRevalidate(t *testing.T) {
+	cache := ChainStaleWhileRevalidate[string](NewStaleWhileRevalidateLRUCache[string](10), Retry[StaleValue[string]](2, time.Millisecond))
+	ok, err := cache.TryAcquireRefreshLock(context.Background(), "k", "v", time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestChain_ForwardsCapabilities verifies that the optional capabilities of the decorated store stay visible through
+// a chain, served by the innermost middleware implementing them.
+func TestChain_ForwardsCapabilities(t *testing.T) {
+	ctx := context.Background()
+	wheel := NewTimingWheelCache[string](time.Hour, TimingWheelConfig[string]{})
+	defer wheel.Close()
+	cache := Chain[string](wheel, Retry[string](2, time.Millisecond), Timeout[string](time.Second))
+
+	assert.NoError(t, cache.(TTLSetter[string]).SetWithTTL(ctx, "a", "1", time.Minute))
+	ttl, hasTTL, err := cache.(TTLInspector).TTL(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, hasTTL)
+	assert.LessOrEqual(t, ttl, time.Minute)
+	keys, err := cache.(Scanner).Scan(ctx, "*", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+	assert.NoError(t, Delete(ctx, cache, "a"))
+	_, exists, _ := wheel.Get(ctx, "a")
+	assert.False(t, exists)
+
+	primary, secondary := NewLRUCache[string](10), NewLRUCache[string](10)
+	fanned := Chain[string](primary, Retry[string](2, time.Millisecond), FanOut[string](secondary, FanOutConfig{Percentage: 100}))
+	assert.NoError(t, primary.Set(ctx, "b", "2"))
+	assert.NoError(t, secondary.Set(ctx, "b", "2"))
+	assert.NoError(t, Delete(ctx, fanned, "b"))
+	_, exists, _ = secondary.Get(ctx, "b")
+	assert.False(t, exists, "the delete must go through the FanOut middleware")
+
+	bare := Chain[string](&flakyCacher[string]{inner: NewLRUCache[string](10)}, Retry[string](2, time.Millisecond))
+	assert.ErrorIs(t, Delete(ctx, bare, "c"), ErrNotSupported)
+	assert.ErrorIs(t, bare.(TTLSetter[string]).SetWithTTL(ctx, "c", "3", time.Minute), ErrNotSupported)
+	_, hasTTL, err = bare.(TTLInspector).TTL(ctx, "c")
+	assert.NoError(t, err)
+	assert.False(t, hasTTL)
+
+	swr := ChainStaleWhileRevalidate[string](NewStaleWhileRevalidateLRUCache[string](10), Retry[StaleValue[string]](2, time.Millisecond))
+	assert.NoError(t, swr.Set(ctx, "d", StaleValue[string]{Value: "4"}))
+	keys, err = swr.(Scanner).Scan(ctx, "*", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, keys)
+	assert.NoError(t, ClearLocal(ctx, swr))
+	_, exists, _ = swr.Get(ctx, "d")
+	assert.False(t, exists)
+}
+
+// slowCacher records the peak number of concurrent operations, each lasting delay.
+type slowCacher struct {
+	delay   time.Duration
+	current atomic.Int32
+	peak    atomic.Int32
+}
+
+// track marks an operation as running for delay.
+func (s *slowCacher) track() {
+	n := s.current.Add(1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	s.current.Add(-1)
+}
+
+func (s *slowCacher) Get(context.Context, string) (string, bool, error) {
+	s.track()
+	return "", false, nil
+}
+
+func (s *slowCacher) Set(context.Context, string, string) error {
+	s.track()
+	return nil
+}
+
+// TestBounded verifies that concurrent operations are capped and that waiting honors the context.
+func TestBounded(t *testing.T) {
+	inner := &slowCacher{delay: 10 * time.Millisecond}
+	c := Chain[string](inner, Bounded[string](2, 0))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = c.Get(context.Background(), "k")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), inner.peak.Load())
+
+	inner.peak.Store(0)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = c.Set(context.Background(), "k", "v")
+		}()
+	}
+	wg.Wait()
+	assert.Greater(t, inner.peak.Load(), int32(2), "sets are unbounded")
+
+	blocked := Chain[string](&slowCacher{delay: 100 * time.Millisecond}, Bounded[string](1, 1))
+	go func() { _ = blocked.Set(context.Background(), "k", "v") }()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, blocked.Set(ctx, "k", "v"), context.DeadlineExceeded)
+}
+
+// TestFanOut verifies that writes of the selected share of keys are forwarded to the secondary in the background.
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+
+	secondary := NewLRUCache[int](2000)
+	all := Chain[int](NewLRUCache[int](10), FanOut[int](secondary, FanOutConfig{Percentage: 100}))
+	assert.NoError(t, all.Set(ctx, "k", 1))
+	assert.Eventually(t, func() bool {
+		value, exists, _ := secondary.Get(ctx, "k")
+		return exists && value == 1
+	}, time.Second, time.Millisecond)
+
+	var forwarded atomic.Int32
+	counting := middlewareCacher[int]{
+		get: secondary.Get,
+		set: func(ctx context.Context, key string, value int) error {
+			forwarded.Add(1)
+			return nil
+		},
+	}
+	half := Chain[int](NewLRUCache[int](10), FanOut[int](counting, FanOutConfig{Percentage: 50, MaxInFlight: 1000}))
+	for i := range 1000 {
+		assert.NoError(t, half.Set(ctx, "key:"+strconv.Itoa(i), i))
+	}
+	assert.Eventually(t, func() bool {
+		n := forwarded.Load()
+		return n > 400 && n < 600
+	}, time.Second, time.Millisecond)
+
+	none := Chain[int](NewLRUCache[int](10), FanOut[int](secondary, FanOutConfig{}))
+	assert.NoError(t, none.Set(ctx, "other", 2))
+	time.Sleep(10 * time.Millisecond)
+	_, exists, _ := secondary.Get(ctx, "other")
+	assert.False(t, exists)
+}
+
+// TestFanOut_DeleteClear verifies that deletes and clears reach the secondary, including over a forwarded write still
+// in flight.
+func TestFanOut_DeleteClear(t *testing.T) {
+	ctx := context.Background()
+	secondary := NewLRUCache[int](10)
+	release := make(chan struct{})
+	var blocked atomic.Bool
+	slow := middlewareCacher[int]{
+		get: secondary.Get,
+		set: func(ctx context.Context, key string, value int) error {
+			if blocked.Load() {
+				<-release
+			}
+			return secondary.Set(ctx, key, value)
+		},
+	}
+	c := Chain[int](NewLRUCache[int](10), FanOut[int](&removingCacher[int]{Cacher: slow, remover: secondary.(interface {
+		Deleter
+		Clearer
+	})}, FanOutConfig{Percentage: 100}))
+
+	assert.NoError(t, c.Set(ctx, "a", 1))
+	assert.NoError(t, c.Set(ctx, "b", 2))
+	assert.Eventually(t, func() bool {
+		n, _ := Len(secondary)
+		return n == 2
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, Delete(ctx, c, "a"))
+	_, exists, _ := secondary.Get(ctx, "a")
+	assert.False(t, exists)
+	assert.NoError(t, Clear(ctx, c))
+	n, _ := Len(secondary)
+	assert.Zero(t, n)
+
+	blocked.Store(true)
+	assert.NoError(t, c.Set(ctx, "c", 3))
+	assert.NoError(t, Delete(ctx, c, "c"))
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+	_, exists, _ = secondary.Get(ctx, "c")
+	assert.False(t, exists, "a forwarded write overtaken by a delete must not survive")
+}
+
+// removingCacher adds the deletes and clears of remover to a Cacher.
+type removingCacher[T any] struct {
+	Cacher[T]
+	remover interface {
+		Deleter
+		Clearer
+	}
+}
+
+func (r *removingCacher[T]) Delete(ctx context.Context, key string) error {
+	return r.remover.Delete(ctx, key)
+}
+
+func (r *removingCacher[T]) Clear(ctx context.Context) error {
+	return r.remover.Clear(ctx)
+}
@@ -0,0 +1,23 @@
+package store
+
+import "context"
+
+// AtomicSetter is implemented by caches able to write several entries as a single transaction, so readers see
+// either none or all of them, e.g. with MULTI/EXEC on Redis.
+type AtomicSetter[T any] interface {
+	SetAtomic(ctx context.Context, entries map[string]T) error
+}
+
+// SetMulti stores related entries together, such as an entity and its index entries. When atomic is true and the
+// cache implements AtomicSetter, the entries are written in a single transaction; otherwise they are written with
+// best-effort batching through BulkSet, and readers may briefly observe some of them only. Callers requiring the
+// guarantee can check for AtomicSetter beforehand.
+func SetMulti[T any](ctx context.Context, c Cacher[T], entries map[string]T, atomic bool) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if setter, ok := c.(AtomicSetter[T]); ok && atomic {
+		return setter.SetAtomic(ctx, entries)
+	}
+	return BulkSet(ctx, c, entries)
+}
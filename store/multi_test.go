@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSetMulti_Redis verifies that atomic multi-key writes use a MULTI/EXEC transaction on Redis.
+func TestSetMulti_Redis(t *testing.T) {
+	ctx := context.TODO()
+	rdb, mock := redismock.NewClientMock()
+	cache := NewRedisCache[string](rdb, "test", time.Hour)
+
+	mock.ExpectTxPipeline()
+	mock.ExpectSet("test:user:1", []byte(`"alice"`), time.Hour).SetVal("OK")
+	mock.ExpectTxPipelineExec()
+	require.NoError(t, SetMulti(ctx, cache, map[string]string{"user:1": "alice"}, true))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectSet("test:user:2", []byte(`"bob"`), time.Hour).SetVal("OK")
+	require.NoError(t, SetMulti(ctx, cache, map[string]string{"user:2": "bob"}, false))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSetMulti_Fallback verifies that stores without transactions write every entry with best-effort batching.
+func TestSetMulti_Fallback(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache[string](10)
+	require.NoError(t, SetMulti(ctx, cache, map[string]string{"user:1": "alice", "email:alice": "user:1"}, true))
+
+	value, exists, err := cache.Get(ctx, "email:alice")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "user:1", value)
+}
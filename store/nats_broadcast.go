@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NatsBroadcaster is a ClearBroadcaster backed by a core NATS subject.
+type NatsBroadcaster struct {
+	nc      *nats.Conn
+	subject string
+}
+
+// NewNatsBroadcaster creates a broadcaster publishing to and subscribing to the given subject.
+func NewNatsBroadcaster(nc *nats.Conn, subject string) *NatsBroadcaster {
+	return &NatsBroadcaster{nc: nc, subject: subject}
+}
+
+// PublishClear publishes the command on the subject and flushes the connection, so the command is sent on return.
+func (n *NatsBroadcaster) PublishClear(ctx context.Context, cmd ClearCommand) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	if err := n.nc.Publish(n.subject, data); err != nil {
+		return err
+	}
+	return n.nc.FlushWithContext(ctx)
+}
+
+// SubscribeClear subscribes to the subject until ctx is done, skipping messages that are not clear commands.
+// Commands are handled one at a time.
+func (n *NatsBroadcaster) SubscribeClear(ctx context.Context, handler func(ctx context.Context, cmd ClearCommand)) error {
+	messages := make(chan *nats.Msg, 64)
+	sub, err := n.nc.ChanSubscribe(n.subject, messages)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-messages:
+			var cmd ClearCommand
+			if err := json.Unmarshal(msg.Data, &cmd); err != nil {
+				slog.Warn("Cannot decode clear command", slog.String("subject", n.subject), slog.String("error", err.Error()))
+				continue
+			}
+			handler(ctx, cmd)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"github.com/nats-io/nats.go/jetstream"
 	"log/slog"
@@ -12,173 +11,402 @@ import (
 	"time"
 )
 
+// maxOrderedSetAttempts bounds the optimistic concurrency retries of a stale-while-revalidate Set.
+const maxOrderedSetAttempts = 5
+
 // natsCache is a generic structure representing a cache using a NATS KeyValue store with a configurable prefix.
 type natsCache[T any] struct {
-	kv     jetstream.KeyValue
-	prefix string
+	kv          jetstream.KeyValue
+	prefix      string
+	codec       Codec
+	timeouts    timeouts
+	sanitizer   KeySanitizer
+	locks       *lockCounters
+	serde       serdeGuard
+	keys        *keyDigestCache
+	ordered     bool
+	lockCodec   Codec
+	lockHook    func(LockEvent)
+	legacyLocks bool
 }
 
 // NewNatsCache creates a new instance of a NATS-based cache with the specified key-value store and key prefix.
-func NewNatsCache[T any](kv jetstream.KeyValue, prefix string) Cacher[T] {
-	return &natsCache[T]{
-		kv:     kv,
-		prefix: prefix,
-	}
+func NewNatsCache[T any](kv jetstream.KeyValue, prefix string, opts ...Option) Cacher[T] {
+	return newNatsCache[T](kv, prefix, newStoreOptions(opts), false)
 }
 
 // NewStaleWhileRevalidateNatsCache creates a new StaleWhileRevalidateCache instance backed by NATS JetStream KeyValue store.
+// Writes are conditional on the revision read before them and never replace a value created later, so background
+// refreshes running on different nodes cannot store their results out of order.
 // T is the type of data to be cached.
 // kv specifies the KeyValue store to use for storing cached values.
 // prefix defines the key prefix to use within the KeyValue store.
-func NewStaleWhileRevalidateNatsCache[T any](kv jetstream.KeyValue, prefix string) StaleWhileRevalidateCache[T] {
-	return &natsCache[StaleValue[T]]{
-		kv:     kv,
-		prefix: prefix,
+func NewStaleWhileRevalidateNatsCache[T any](kv jetstream.KeyValue, prefix string, opts ...Option) StaleWhileRevalidateCache[T] {
+	return newNatsCache[StaleValue[T]](kv, prefix, newStoreOptions(opts), true)
+}
+
+// newNatsCache creates a NATS cache with the given options; ordered writes never replace a value created later.
+func newNatsCache[T any](kv jetstream.KeyValue, prefix string, o storeOptions, ordered bool) *natsCache[T] {
+	return &natsCache[T]{
+		kv:          kv,
+		prefix:      prefix,
+		codec:       o.codec,
+		timeouts:    o.timeouts,
+		sanitizer:   o.sanitizer,
+		locks:       newLockCounters("nats", o.lockSink),
+		serde:       o.serde,
+		keys:        newKeyDigestCache(o.keyCache),
+		lockCodec:   o.lockCodec,
+		lockHook:    o.lockHook,
+		legacyLocks: o.legacyLocks,
+		ordered:     ordered,
 	}
 }
 
 // Get retrieves the cached value for the given key. Returns the value, a boolean indicating existence, and an error.
 func (r *natsCache[T]) Get(ctx context.Context, k string) (T, bool, error) {
+	value, _, exists, err := r.GetVersioned(ctx, k)
+	return value, exists, err
+}
+
+// GetVersioned retrieves the cached value for the given key together with its KV revision.
+func (r *natsCache[T]) GetVersioned(ctx context.Context, k string) (T, uint64, bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.get)
+	defer cancel()
 	var emptyValue T
 	key := r.buildKey(k)
 	result, err := r.kv.Get(ctx, key)
 	if err != nil {
 		if err == jetstream.ErrKeyNotFound {
-			return emptyValue, false, nil
+			return emptyValue, 0, false, nil
 		}
-		return emptyValue, false, err
+		return emptyValue, 0, false, err
 	}
 	var value T
 
-	// Assuming the value can be unmarshalled into T
-	err = json.Unmarshal(result.Value(), &value)
-	if err != nil {
-		return emptyValue, false, err
+	if err := decode(r.codec, result.Value(), &value); err != nil {
+		return emptyValue, 0, false, r.serde.unmarshalFailed(k, err, func() error {
+			return r.kv.Delete(ctx, key)
+		})
 	}
-	return value, true, nil
+	return value, result.Revision(), true, nil
 }
 
 // Set stores a value in the cache associated with the specified key. Returns an error if the operation fails.
 func (r *natsCache[T]) Set(ctx context.Context, k string, value T) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
 	key := r.buildKey(k)
 
-	// Assuming the value can be marshalled to JSON
-	data, err := json.Marshal(value)
+	data, buf, err := encodePooled(r.codec, value)
 	if err != nil {
-		return err
+		return r.serde.marshalFailed(k, err, func() error {
+			return r.Delete(ctx, k)
+		})
+	}
+	// The KeyValue publishes the data before returning, so the pooled buffer is reused only after the write.
+	defer releaseBuffer(buf)
+	if stamped, ok := any(value).(timestamped); ok && r.ordered {
+		err = r.setOrdered(ctx, key, data, stamped.createdAt())
+	} else {
+		_, err = r.kv.Put(ctx, key, data)
 	}
-	_, err = r.kv.Put(ctx, key, data)
 	if err != nil {
 		slog.Error("Cannot set value in cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
 	}
 	return err
 }
 
-// buildKey generates a namespaced and hashed key using the provided key and the prefix from the natsCache instance.
+// setOrdered writes data with optimistic concurrency, unless the key already holds a value created after createdAt.
+// Returns ErrWriteConflict if concurrent writers keep changing the entry.
+func (r *natsCache[T]) setOrdered(ctx context.Context, key string, data []byte, createdAt time.Time) error {
+	for range maxOrderedSetAttempts {
+		var revision uint64
+		entry, err := r.kv.Get(ctx, key)
+		switch {
+		case errors.Is(err, jetstream.ErrKeyNotFound):
+		case err != nil:
+			return err
+		default:
+			revision = entry.Revision()
+			var current T
+			if decode(r.codec, entry.Value(), &current) == nil {
+				if stamped, ok := any(current).(timestamped); ok && stamped.createdAt().After(createdAt) {
+					return nil
+				}
+			}
+		}
+		if written, err := r.writeIfRevision(ctx, key, data, revision); written || err != nil {
+			return err
+		}
+	}
+	return ErrWriteConflict
+}
+
+// SetIfRevision stores the value only if the entry is still at the given revision, 0 meaning that the key must not
+// exist. Returns false when the entry was changed by another writer.
+func (r *natsCache[T]) SetIfRevision(ctx context.Context, k string, value T, revision uint64) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	data, buf, err := encodePooled(r.codec, value)
+	if err != nil {
+		return false, err
+	}
+	defer releaseBuffer(buf)
+	return r.writeIfRevision(ctx, r.buildKey(k), data, revision)
+}
+
+// writeIfRevision creates the key when revision is 0 and updates it otherwise, reporting a revision mismatch as false.
+func (r *natsCache[T]) writeIfRevision(ctx context.Context, key string, data []byte, revision uint64) (bool, error) {
+	var err error
+	if revision == 0 {
+		_, err = r.kv.Create(ctx, key, data)
+	} else {
+		_, err = r.kv.Update(ctx, key, data, revision)
+	}
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// PopulateIfAbsent stores the value with Create, which fails when the key already holds a value.
+func (r *natsCache[T]) PopulateIfAbsent(ctx context.Context, k string, value T) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	data, buf, err := encodePooled(r.codec, value)
+	if err != nil {
+		return false, r.serde.marshalFailed(k, err, nil)
+	}
+	defer releaseBuffer(buf)
+	_, err = r.kv.Create(ctx, r.buildKey(k), data)
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the key from the KeyValue store.
+func (r *natsCache[T]) Delete(ctx context.Context, k string) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	err := r.kv.Delete(ctx, r.buildKey(k))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// deleteExisting removes the key only when the bucket holds it, sparing absent keys a delete marker.
+func (r *natsCache[T]) deleteExisting(ctx context.Context, k string) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	key := r.buildKey(k)
+	if _, err := r.kv.Get(ctx, key); err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	err := r.kv.Delete(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Take reads the key and deletes it only if its revision did not change in the meantime.
+// When another caller took or rewrote the entry first, the take is reported as a miss.
+func (r *natsCache[T]) Take(ctx context.Context, k string) (T, bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	var emptyValue T
+	key := r.buildKey(k)
+	entry, err := r.kv.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return emptyValue, false, nil
+		}
+		return emptyValue, false, err
+	}
+	err = r.kv.Delete(ctx, key, jetstream.LastRevision(entry.Revision()))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return emptyValue, false, nil
+	}
+	if err != nil {
+		return emptyValue, false, err
+	}
+
+	var value T
+	if err := decode(r.codec, entry.Value(), &value); err != nil {
+		return emptyValue, false, r.serde.unmarshalFailed(k, err, nil)
+	}
+	return value, true, nil
+}
+
+// valueCodec returns the codec used to serialize values.
+func (r *natsCache[T]) valueCodec() Codec {
+	return r.codec
+}
+
+// buildKey generates a namespaced key using the provided key and the prefix from the natsCache instance.
+// Keys are hashed unless a KeySanitizer is configured, in which case the sanitized key is used verbatim.
 func (r *natsCache[T]) buildKey(key string) string {
-	// Example implementation, customizable as needed
+	return r.keys.get(key, r.computeKey)
+}
+
+// computeKey builds the backend key of the given raw key.
+func (r *natsCache[T]) computeKey(key string) string {
+	if r.sanitizer != nil {
+		return strings.TrimRight(r.prefix, ".") + "." + r.sanitizer(key)
+	}
 
 	keyHash := md5.Sum([]byte(key))
 	return strings.TrimRight(r.prefix, ".") + "." + hex.EncodeToString(keyHash[:])
 
 }
 
-// TryAcquireRefreshLock attempts to acquire a distributed lock for a specific key with a random value and TTL.
-// If the lock does not already exist, it is successfully acquired and true is returned with no error.
-// If the lock exists, it checks the random value and TTL to decide whether the lock can still be acquired.
+// TryAcquireRefreshLock attempts to acquire a distributed lock for a specific key, writing a LockRecord held by
+// randValue for ttl. If the lock does not exist, it is created and true is returned. If it exists, it is renewed when
+// already held by randValue and taken over when its TTL has elapsed; otherwise false is returned.
 func (r *natsCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
-	lockKey := r.buildKey("lock:" + key)
-	now := time.Now()
+	acquired, err := r.tryAcquireRefreshLock(ctx, key, randValue, ttl)
+	r.locks.attempt(key, randValue, acquired, err)
+	return acquired, err
+}
 
-	_, err := r.kv.Create(ctx, lockKey, []byte(randValue+"|"+now.Format(time.RFC3339)))
+// tryAcquireRefreshLock implements TryAcquireRefreshLock, retrying after removing undecodable locks.
+func (r *natsCache[T]) tryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.lock)
+	defer cancel()
+	lockKey := r.buildKey("lock:" + key)
+	record := LockRecord{Version: lockRecordVersion, Holder: randValue, AcquiredAt: time.Now(), TTL: ttl}
+	data, err := encodeLockRecord(r.lockCodec, record, r.legacyLocks)
+	if err != nil {
+		return false, err
+	}
 
+	revision, err := r.kv.Create(ctx, lockKey, data)
 	if err == nil {
-		return true, err
+		record.Fence = revision
+		r.lockEvent(key, LockAcquired, record)
+		return true, nil
 	}
 	if errors.Is(err, jetstream.ErrKeyExists) {
-		return r.checkRandValue(ctx, key, randValue, ttl, lockKey, now)
+		return r.checkLockRecord(ctx, key, lockKey, record, data)
 	}
 	return false, err
 }
 
-// checkRandValue verifies if the stored random value matches the given one and TTL has not expired.
-// It updates refresh lock timestamp if conditions are met or attempts to reacquire lock.
-func (r *natsCache[T]) checkRandValue(ctx context.Context, key string, randValue string, ttl time.Duration, lockKey string, now time.Time) (bool, error) {
-	storedValue, err := r.kv.Get(ctx, lockKey)
-
-	if err != nil || storedValue == nil {
-
-		_ = r.kv.Delete(ctx, lockKey)
-		return false, err
+// checkLockRecord decides whether the existing lock can be acquired by the owner of record: it is renewed when held
+// by the same owner and taken over when expired. Both writes are conditional on the revision read, so concurrent
+// owners cannot both succeed.
+func (r *natsCache[T]) checkLockRecord(ctx context.Context, key string, lockKey string, record LockRecord, data []byte) (bool, error) {
+	entry, err := r.kv.Get(ctx, lockKey)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		// Released between the Create and the Get.
+		return r.tryAcquireRefreshLock(ctx, key, record.Holder, record.TTL)
 	}
-	value := storedValue.Value()
-	if value == nil {
-		err = r.kv.Delete(ctx, lockKey)
-		if err != nil {
-			slog.Error("Cannot delete lock", slog.String("error", err.Error()), slog.String("cacheKey", lockKey))
-		}
-		return r.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	if err != nil {
+		return false, err
 	}
 
-	innerValues := strings.Split(string(value), "|")
-	if len(innerValues) != 2 {
-		err = r.kv.Delete(ctx, lockKey)
-		if err != nil {
+	stored, err := decodeLockRecord(r.lockCodec, entry.Value())
+	if err != nil {
+		slog.Warn("Cannot decode lock", slog.String("error", err.Error()), slog.String("cacheKey", lockKey))
+		if err := r.kv.Delete(ctx, lockKey, jetstream.LastRevision(entry.Revision())); err != nil {
 			slog.Error("Cannot delete lock", slog.String("error", err.Error()), slog.String("cacheKey", lockKey))
 		}
-		return r.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+		return r.tryAcquireRefreshLock(ctx, key, record.Holder, record.TTL)
 	}
-	if innerValues[0] != randValue {
+	stored.Fence = entry.Revision()
+
+	expired := record.AcquiredAt.After(stored.ExpiresAt(record.TTL))
+	if stored.Holder != record.Holder && !expired {
+		r.lockEvent(key, LockContended, stored)
 		return false, nil
 	}
+	if expired {
+		r.locks.expire("takeover")
+	}
 
-	parse, err := time.Parse(time.RFC3339, innerValues[1])
+	revision, err := r.kv.Update(ctx, lockKey, data, entry.Revision())
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return false, nil
+	}
 	if err != nil {
-		slog.Warn("Cannot parse lock timestamp", slog.String("error", err.Error()), slog.String("cacheKey", lockKey))
-		err = r.kv.Delete(ctx, lockKey)
-		if err != nil {
-			slog.Error("Cannot delete lock", slog.String("error", err.Error()), slog.String("cacheKey", lockKey))
-		}
-		return r.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+		return false, err
 	}
-
-	if time.Since(parse) > ttl {
-		err = r.kv.Delete(ctx, lockKey)
-		if err != nil {
-			slog.Error("Cannot delete lock", slog.String("error", err.Error()), slog.String("cacheKey", lockKey))
-		}
-		return r.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	record.Fence = revision
+	if stored.Holder == record.Holder {
+		r.lockEvent(key, LockRenewed, record)
+	} else {
+		r.lockEvent(key, LockTakenOver, record)
 	}
-	_, err = r.kv.Put(ctx, lockKey, []byte(randValue+"|"+now.Format(time.RFC3339)))
-	return true, err
+	return true, nil
 }
 
-// ReleaseRefreshLock releases the refresh lock for the given key if the supplied randValue matches the stored lock value.
+// ReleaseRefreshLock releases the refresh lock for the given key if the supplied randValue matches the stored lock holder.
 func (r *natsCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.lock)
+	defer cancel()
 	lockKey := r.buildKey("lock:" + key)
-	storedValue, err := r.kv.Get(ctx, lockKey)
+	entry, err := r.kv.Get(ctx, lockKey)
 	if err != nil {
-		if err == jetstream.ErrKeyExists {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			r.locks.released(key, randValue, false)
 			return nil // Lock does not exist
 		}
 		return err
 	}
 
-	value := storedValue.Value()
-	if value == nil {
-		err = r.kv.Delete(ctx, lockKey)
+	stored, err := decodeLockRecord(r.lockCodec, entry.Value())
+	if err != nil {
+		return r.kv.Delete(ctx, lockKey, jetstream.LastRevision(entry.Revision()))
+	}
+	if stored.Holder != randValue {
+		r.locks.released(key, randValue, false)
+		return nil
+	}
+	if err := r.kv.Delete(ctx, lockKey, jetstream.LastRevision(entry.Revision())); err != nil {
 		return err
 	}
+	r.locks.released(key, randValue, true)
+	stored.Fence = entry.Revision()
+	r.lockEvent(key, LockReleased, stored)
+	return nil
+}
 
-	innerValues := strings.Split(string(value), "|")
-
-	if len(innerValues) != 2 {
-		err = r.kv.Delete(ctx, lockKey)
-		return err
+// InspectRefreshLock returns the record of the refresh lock of key, with false when the lock is not held.
+func (r *natsCache[T]) InspectRefreshLock(ctx context.Context, key string) (LockRecord, bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.lock)
+	defer cancel()
+	entry, err := r.kv.Get(ctx, r.buildKey("lock:"+key))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return LockRecord{}, false, nil
 	}
-	if innerValues[0] != randValue {
-		return nil
+	if err != nil {
+		return LockRecord{}, false, err
 	}
-	err = r.kv.Delete(ctx, lockKey)
-	return err
+	record, err := decodeLockRecord(r.lockCodec, entry.Value())
+	if err != nil {
+		return LockRecord{}, false, err
+	}
+	record.Fence = entry.Revision()
+	return record, true, nil
+}
+
+// lockEvent reports a lock transition to the configured hook.
+func (r *natsCache[T]) lockEvent(key string, action LockAction, record LockRecord) {
+	if r.lockHook != nil {
+		r.lockHook(LockEvent{Key: key, Action: action, Record: record})
+	}
+}
+
+// LockStats returns the refresh lock statistics of this store.
+func (r *natsCache[T]) LockStats() LockStats {
+	return r.locks.snapshot()
 }
@@ -0,0 +1,289 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKVEntry is a jetstream.KeyValueEntry held by fakeKV.
+type fakeKVEntry struct {
+	jetstream.KeyValueEntry
+	key      string
+	value    []byte
+	revision uint64
+}
+
+func (e fakeKVEntry) Key() string      { return e.key }
+func (e fakeKVEntry) Value() []byte    { return e.value }
+func (e fakeKVEntry) Revision() uint64 { return e.revision }
+
+// fakeKV is an in-memory subset of jetstream.KeyValue with revision checks, for unit tests of natsCache.
+type fakeKV struct {
+	jetstream.KeyValue
+	mu       sync.Mutex
+	revision uint64
+	entries  map[string]fakeKVEntry
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{entries: make(map[string]fakeKVEntry)}
+}
+
+func (f *fakeKV) Get(_ context.Context, key string) (jetstream.KeyValueEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.entries[key]
+	if !ok {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	return entry, nil
+}
+
+func (f *fakeKV) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.write(key, value), nil
+}
+
+func (f *fakeKV) Create(ctx context.Context, key string, value []byte) (uint64, error) {
+	return f.Update(ctx, key, value, 0)
+}
+
+func (f *fakeKV) Update(_ context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.entries[key].revision != revision {
+		return 0, jetstream.ErrKeyExists
+	}
+	return f.write(key, value), nil
+}
+
+func (f *fakeKV) Delete(_ context.Context, key string, opts ...jetstream.KVDeleteOpt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if revision := deleteRevision(opts); revision != 0 && f.entries[key].revision != revision {
+		return errFakeWrongRevision
+	}
+	delete(f.entries, key)
+	return nil
+}
+
+// errFakeWrongRevision is returned by fakeKV for conditional deletes of a key changed since the given revision.
+var errFakeWrongRevision = errors.New("wrong last sequence")
+
+// deleteRevision returns the revision set by jetstream.LastRevision among the delete options, or 0. The options only
+// expose an unexported method, so they are applied through reflection.
+func deleteRevision(opts []jetstream.KVDeleteOpt) uint64 {
+	var revision uint64
+	for _, opt := range opts {
+		fn := reflect.ValueOf(opt)
+		if fn.Kind() != reflect.Func {
+			continue
+		}
+		target := reflect.New(fn.Type().In(0).Elem())
+		fn.Call([]reflect.Value{target})
+		if field := target.Elem().FieldByName("revision"); field.IsValid() && field.Uint() != 0 {
+			revision = field.Uint()
+		}
+	}
+	return revision
+}
+
+// write stores a copy of the value under a new revision, as the server does with published data.
+func (f *fakeKV) write(key string, value []byte) uint64 {
+	f.revision++
+	f.entries[key] = fakeKVEntry{key: key, value: bytes.Clone(value), revision: f.revision}
+	return f.revision
+}
+
+// TestNatsCache_Versioned verifies that revisions are exposed and that conditional writes detect concurrent changes.
+func TestNatsCache_Versioned(t *testing.T) {
+	ctx := context.Background()
+	cache := NewNatsCache[string](newFakeKV(), "test").(VersionedCacher[string])
+
+	written, err := cache.SetIfRevision(ctx, "k", "v1", 0)
+	require.NoError(t, err)
+	assert.True(t, written)
+	value, revision, exists, err := cache.GetVersioned(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, "v1", value)
+
+	written, err = cache.SetIfRevision(ctx, "k", "v2", revision)
+	require.NoError(t, err)
+	assert.True(t, written)
+	written, err = cache.SetIfRevision(ctx, "k", "stale", revision)
+	require.NoError(t, err)
+	assert.False(t, written)
+	written, err = cache.SetIfRevision(ctx, "k", "stale", 0)
+	require.NoError(t, err)
+	assert.False(t, written)
+}
+
+// TestNatsCache_OrderedSet verifies that stale-while-revalidate writes never replace a value created later.
+func TestNatsCache_OrderedSet(t *testing.T) {
+	ctx := context.Background()
+	cache := NewStaleWhileRevalidateNatsCache[string](newFakeKV(), "test")
+	now := time.Now()
+
+	require.NoError(t, cache.Set(ctx, "k", StaleValue[string]{Value: "newer", CreatedAt: now}))
+	require.NoError(t, cache.Set(ctx, "k", StaleValue[string]{Value: "older", CreatedAt: now.Add(-time.Second)}))
+	value, _, err := cache.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "newer", value.Value)
+
+	require.NoError(t, cache.Set(ctx, "k", StaleValue[string]{Value: "newest", CreatedAt: now.Add(time.Second)}))
+	value, _, err = cache.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "newest", value.Value)
+}
+
+// TestNatsCache_LockRecord verifies the lock transitions reported to hooks, with increasing fence tokens.
+func TestNatsCache_LockRecord(t *testing.T) {
+	ctx := context.Background()
+	var events []LockEvent
+	cache := NewNatsCache[string](newFakeKV(), "test", WithLockHook(func(e LockEvent) {
+		events = append(events, e)
+	})).(*natsCache[string])
+
+	acquired, err := cache.TryAcquireRefreshLock(ctx, "k", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = cache.TryAcquireRefreshLock(ctx, "k", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	acquired, err = cache.TryAcquireRefreshLock(ctx, "k", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	record, held, err := InspectRefreshLock(ctx, cache, "k")
+	require.NoError(t, err)
+	require.True(t, held)
+	assert.Equal(t, "a", record.Holder)
+	assert.Equal(t, lockRecordVersion, record.Version)
+	assert.Equal(t, time.Minute, record.TTL)
+
+	require.NoError(t, cache.ReleaseRefreshLock(ctx, "k", "b"))
+	require.NoError(t, cache.ReleaseRefreshLock(ctx, "k", "a"))
+	require.NoError(t, cache.ReleaseRefreshLock(ctx, "k", "a"))
+	_, held, err = cache.InspectRefreshLock(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	require.Len(t, events, 4)
+	assert.Equal(t, LockAcquired, events[0].Action)
+	assert.Equal(t, LockContended, events[1].Action)
+	assert.Equal(t, "a", events[1].Record.Holder)
+	assert.Equal(t, LockRenewed, events[2].Action)
+	assert.Greater(t, events[2].Record.Fence, events[0].Record.Fence)
+	assert.Equal(t, LockReleased, events[3].Action)
+	assert.Equal(t, record.Fence, events[3].Record.Fence)
+}
+
+// TestNatsCache_LockTakeover verifies that an expired lock is taken over by another owner.
+func TestNatsCache_LockTakeover(t *testing.T) {
+	ctx := context.Background()
+	cache := NewNatsCache[string](newFakeKV(), "test").(*natsCache[string])
+
+	acquired, err := cache.TryAcquireRefreshLock(ctx, "k", "a", time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	time.Sleep(5 * time.Millisecond)
+
+	acquired, err = cache.TryAcquireRefreshLock(ctx, "k", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	record, _, err := InspectRefreshLock(ctx, cache, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "b", record.Holder)
+	assert.Equal(t, uint64(1), cache.LockStats().Expired)
+}
+
+// TestNatsCache_LegacyLock verifies that locks written in the former "holder|RFC3339" format are still honored.
+func TestNatsCache_LegacyLock(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	cache := NewNatsCache[string](kv, "test").(*natsCache[string])
+	_, err := kv.Put(ctx, cache.buildKey("lock:k"), []byte("a|"+time.Now().Format(time.RFC3339)))
+	require.NoError(t, err)
+
+	acquired, err := cache.TryAcquireRefreshLock(ctx, "k", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	acquired, err = cache.TryAcquireRefreshLock(ctx, "k", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	record, held, err := cache.InspectRefreshLock(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, held)
+	assert.Equal(t, lockRecordVersion, record.Version)
+}
+
+// TestNatsCache_LegacyLockFormat verifies that WithLegacyLockFormat writes locks that releases older than LockRecord
+// can parse, and that they are honored.
+func TestNatsCache_LegacyLockFormat(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	cache := NewNatsCache[string](kv, "test", WithLegacyLockFormat()).(*natsCache[string])
+
+	acquired, err := cache.TryAcquireRefreshLock(ctx, "k", "a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	entry, err := kv.Get(ctx, cache.buildKey("lock:k"))
+	require.NoError(t, err)
+	holder, acquiredAt, ok := strings.Cut(string(entry.Value()), "|")
+	require.True(t, ok)
+	assert.Equal(t, "a", holder)
+	_, err = time.Parse(time.RFC3339, acquiredAt)
+	require.NoError(t, err)
+
+	acquired, err = cache.TryAcquireRefreshLock(ctx, "k", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+}
+
+// TestNatsCache_ReleaseChangedLock verifies that a lock changed after it was read is not deleted on release and that
+// the failed release is not recorded.
+func TestNatsCache_ReleaseChangedLock(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	var events []LockEvent
+	cache := NewNatsCache[string](&changingKV{fakeKV: kv}, "test", WithLockHook(func(e LockEvent) {
+		events = append(events, e)
+	})).(*natsCache[string])
+
+	acquired, err := cache.TryAcquireRefreshLock(ctx, "k", "a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	require.Error(t, cache.ReleaseRefreshLock(ctx, "k", "a"))
+	_, err = kv.Get(ctx, cache.buildKey("lock:k"))
+	require.NoError(t, err)
+	assert.Zero(t, cache.LockStats().HoldTime)
+	require.Len(t, events, 1)
+	assert.Equal(t, LockAcquired, events[0].Action)
+}
+
+// changingKV rewrites every entry it returns, as another node renewing or taking over a lock right after the read.
+type changingKV struct {
+	*fakeKV
+}
+
+func (c *changingKV) Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error) {
+	entry, err := c.fakeKV.Get(ctx, key)
+	if err == nil {
+		_, err = c.fakeKV.Put(ctx, key, entry.Value())
+	}
+	return entry, err
+}
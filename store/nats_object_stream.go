@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"github.com/nats-io/nats.go/jetstream"
+	"io"
+	"strings"
+	"time"
+)
+
+// natsObjectStreamCache is a StreamCacher backed by a NATS JetStream Object Store, which chunks large values
+// transparently so they can be streamed in and out without buffering them entirely.
+type natsObjectStreamCache struct {
+	os     jetstream.ObjectStore
+	prefix string
+	ttl    time.Duration
+}
+
+// NewNatsObjectStreamCache creates a streaming cache backed by the given NATS Object Store.
+// Objects older than ttl are reported as missing; a ttl <= 0 relies solely on the bucket configuration.
+func NewNatsObjectStreamCache(os jetstream.ObjectStore, prefix string, ttl time.Duration) StreamCacher {
+	return &natsObjectStreamCache{
+		os:     os,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// GetReader returns a reader streaming the object stored under the given key. The caller must close the returned reader.
+func (n *natsObjectStreamCache) GetReader(ctx context.Context, key string) (io.ReadCloser, bool, error) {
+	result, err := n.os.Get(ctx, n.buildKey(key))
+	if err != nil {
+		if errors.Is(err, jetstream.ErrObjectNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if n.ttl > 0 {
+		info, err := result.Info()
+		if err != nil {
+			_ = result.Close()
+			return nil, false, err
+		}
+		if time.Since(info.ModTime) > n.ttl {
+			_ = result.Close()
+			return nil, false, nil
+		}
+	}
+	return result, true, nil
+}
+
+// SetReader streams the reader into the object store under the given key, replacing any previous object.
+func (n *natsObjectStreamCache) SetReader(ctx context.Context, key string, r io.Reader) error {
+	_, err := n.os.Put(ctx, jetstream.ObjectMeta{Name: n.buildKey(key)}, r)
+	return err
+}
+
+// buildKey generates a namespaced and hashed object name from the given key.
+func (n *natsObjectStreamCache) buildKey(key string) string {
+	keyHash := md5.Sum([]byte(key))
+	return strings.TrimRight(n.prefix, ".") + "." + hex.EncodeToString(keyHash[:])
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeObject is an object held by fakeObjectStore.
+type fakeObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+// fakeObjectStore is an in-memory subset of jetstream.ObjectStore, for unit tests of natsObjectStreamCache.
+type fakeObjectStore struct {
+	jetstream.ObjectStore
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	err     error
+	closed  int
+}
+
+// newFakeObjectStore creates an empty fakeObjectStore.
+func newFakeObjectStore() *fakeObjectStore {
+	return &fakeObjectStore{objects: make(map[string]fakeObject)}
+}
+
+// Get returns a reader over the named object, jetstream.ErrObjectNotFound when it is missing, or the injected error.
+func (f *fakeObjectStore) Get(_ context.Context, name string, _ ...jetstream.GetObjectOpt) (jetstream.ObjectResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	obj, ok := f.objects[name]
+	if !ok {
+		return nil, jetstream.ErrObjectNotFound
+	}
+	return &fakeObjectResult{
+		Reader: bytes.NewReader(obj.data),
+		info:   &jetstream.ObjectInfo{ObjectMeta: jetstream.ObjectMeta{Name: name}, Size: uint64(len(obj.data)), ModTime: obj.modTime},
+		store:  f,
+	}, nil
+}
+
+// Put stores the content of r under the name of meta, stamped with the current time, unless an error is injected.
+func (f *fakeObjectStore) Put(_ context.Context, meta jetstream.ObjectMeta, r io.Reader) (*jetstream.ObjectInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.objects[meta.Name] = fakeObject{data: data, modTime: time.Now()}
+	return &jetstream.ObjectInfo{ObjectMeta: meta, Size: uint64(len(data))}, nil
+}
+
+// fakeObjectResult is the jetstream.ObjectResult returned by fakeObjectStore, counting closes on its store.
+type fakeObjectResult struct {
+	*bytes.Reader
+	info  *jetstream.ObjectInfo
+	store *fakeObjectStore
+}
+
+// Info returns the metadata of the object.
+func (r *fakeObjectResult) Info() (*jetstream.ObjectInfo, error) { return r.info, nil }
+
+// Error reports no read error.
+func (r *fakeObjectResult) Error() error { return nil }
+
+// Close counts the close on the store of the object.
+func (r *fakeObjectResult) Close() error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.closed++
+	return nil
+}
+
+// TestNatsObjectStreamCache verifies streaming round trips, hashed object names, expiry and error propagation.
+func TestNatsObjectStreamCache(t *testing.T) {
+	ctx := context.Background()
+	objects := newFakeObjectStore()
+	cache := NewNatsObjectStreamCache(objects, "blobs.", time.Minute)
+
+	r, exists, err := cache.GetReader(ctx, "report")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Nil(t, r)
+
+	payload := strings.Repeat("chunk ", 1000)
+	require.NoError(t, cache.SetReader(ctx, "report", strings.NewReader(payload)))
+	require.Len(t, objects.objects, 1)
+	for name := range objects.objects {
+		assert.True(t, strings.HasPrefix(name, "blobs."))
+		assert.NotContains(t, name, "report")
+	}
+
+	r, exists, err = cache.GetReader(ctx, "report")
+	require.NoError(t, err)
+	require.True(t, exists)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, payload, string(data))
+
+	for name, obj := range objects.objects {
+		obj.modTime = time.Now().Add(-time.Hour)
+		objects.objects[name] = obj
+	}
+	r, exists, err = cache.GetReader(ctx, "report")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Nil(t, r)
+	assert.Equal(t, 2, objects.closed)
+
+	unbounded := NewNatsObjectStreamCache(objects, "blobs", 0)
+	r, exists, err = unbounded.GetReader(ctx, "report")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.NoError(t, r.Close())
+
+	objects.err = errors.New("object store unavailable")
+	_, _, err = cache.GetReader(ctx, "report")
+	assert.EqualError(t, err, "object store unavailable")
+	assert.EqualError(t, cache.SetReader(ctx, "report", strings.NewReader(payload)), "object store unavailable")
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ErrNoTTLBuckets is returned when a partitioned NATS store is created without buckets.
+var ErrNoTTLBuckets = errors.New("partitioned NATS store requires at least one TTL bucket")
+
+// DefaultNatsTTLClasses are the TTL classes of the buckets created by CreateNatsTTLBuckets when none are given.
+var DefaultNatsTTLClasses = []time.Duration{time.Minute, 10 * time.Minute, time.Hour}
+
+// NatsTTLBucket is a JetStream key-value bucket whose TTL, bucket-wide, applies to every key routed to it.
+type NatsTTLBucket struct {
+	TTL time.Duration
+	KV  jetstream.KeyValue
+}
+
+// CreateNatsTTLBuckets creates, or updates, one bucket per TTL class, DefaultNatsTTLClasses when none are given,
+// named after name and the class in seconds, such as "cache_60s".
+func CreateNatsTTLBuckets(ctx context.Context, js jetstream.KeyValueManager, name string, ttls ...time.Duration) ([]NatsTTLBucket, error) {
+	if len(ttls) == 0 {
+		ttls = DefaultNatsTTLClasses
+	}
+	buckets := make([]NatsTTLBucket, 0, len(ttls))
+	for _, ttl := range ttls {
+		bucket := fmt.Sprintf("%s_%ds", name, int64(ttl/time.Second))
+		kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: bucket, TTL: ttl})
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, NatsTTLBucket{TTL: ttl, KV: kv})
+	}
+	return buckets, nil
+}
+
+// partitionedNatsCache routes keys to the NATS bucket of their TTL class, emulating per-key TTLs on JetStream
+// key-value buckets whose TTL is bucket-wide.
+type partitionedNatsCache[T any] struct {
+	parts      []*natsCache[T]
+	ttls       []time.Duration
+	defaultTTL time.Duration
+}
+
+// NewPartitionedNatsCache creates a NATS store spreading keys over buckets by TTL class: SetWithTTL writes to the
+// bucket with the shortest TTL not below the requested one, or the longest bucket when none is long enough, and
+// Set uses defaultTTL. Writing a key removes it from the other buckets, so a key lives in a single class at a time.
+// Refresh locks are held in the bucket of defaultTTL. ErrNoTTLBuckets is returned without buckets.
+func NewPartitionedNatsCache[T any](buckets []NatsTTLBucket, prefix string, defaultTTL time.Duration, opts ...Option) (Cacher[T], error) {
+	return newPartitionedNatsCache[T](buckets, prefix, defaultTTL, newStoreOptions(opts), false)
+}
+
+// NewStaleWhileRevalidatePartitionedNatsCache creates a stale-while-revalidate NATS store partitioned by TTL class,
+// with the ordered writes of NewStaleWhileRevalidateNatsCache within each bucket.
+func NewStaleWhileRevalidatePartitionedNatsCache[T any](buckets []NatsTTLBucket, prefix string, defaultTTL time.Duration, opts ...Option) (StaleWhileRevalidateCache[T], error) {
+	return newPartitionedNatsCache[StaleValue[T]](buckets, prefix, defaultTTL, newStoreOptions(opts), true)
+}
+
+// newPartitionedNatsCache creates the partitioned store, sorting the buckets by TTL.
+func newPartitionedNatsCache[T any](buckets []NatsTTLBucket, prefix string, defaultTTL time.Duration, o storeOptions, ordered bool) (*partitionedNatsCache[T], error) {
+	if len(buckets) == 0 {
+		return nil, ErrNoTTLBuckets
+	}
+	sorted := slices.SortedFunc(slices.Values(buckets), func(a, b NatsTTLBucket) int {
+		return cmp.Compare(a.TTL, b.TTL)
+	})
+	c := &partitionedNatsCache[T]{defaultTTL: defaultTTL}
+	for _, b := range sorted {
+		c.parts = append(c.parts, newNatsCache[T](b.KV, prefix, o, ordered))
+		c.ttls = append(c.ttls, b.TTL)
+	}
+	return c, nil
+}
+
+// class returns the index of the bucket keys written for ttl are routed to.
+func (c *partitionedNatsCache[T]) class(ttl time.Duration) int {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+	for i, classTTL := range c.ttls {
+		if classTTL >= ttl {
+			return i
+		}
+	}
+	return len(c.ttls) - 1
+}
+
+// Get returns the value of the key from the bucket holding it. Errors of single buckets are only reported when no
+// bucket holds the key.
+func (c *partitionedNatsCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	var errs []error
+	for _, part := range c.parts {
+		value, exists, err := part.Get(ctx, key)
+		if exists {
+			return value, true, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var zeroValue T
+	return zeroValue, false, errors.Join(errs...)
+}
+
+// Set stores the value in the bucket of the default TTL.
+func (c *partitionedNatsCache[T]) Set(ctx context.Context, key string, value T) error {
+	return c.SetWithTTL(ctx, key, value, c.defaultTTL)
+}
+
+// SetWithTTL removes the key from the other buckets holding it, then stores the value in the bucket of the TTL class
+// of ttl. The value is not written when a removal fails, so a previous value can never shadow it; the joined errors
+// are returned.
+func (c *partitionedNatsCache[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error {
+	target := c.class(ttl)
+	var errs []error
+	for i, part := range c.parts {
+		if i == target {
+			continue
+		}
+		if err := part.deleteExisting(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("remove key from the %s TTL class: %w", c.ttls[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return c.parts[target].Set(ctx, key, value)
+}
+
+// Delete removes the key from every bucket.
+func (c *partitionedNatsCache[T]) Delete(ctx context.Context, key string) error {
+	var errs []error
+	for _, part := range c.parts {
+		if err := part.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TryAcquireRefreshLock acquires the refresh lock of the key in the bucket of the default TTL.
+func (c *partitionedNatsCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	return c.parts[c.class(0)].TryAcquireRefreshLock(ctx, key, randValue, ttl)
+}
+
+// ReleaseRefreshLock releases the refresh lock of the key in the bucket of the default TTL.
+func (c *partitionedNatsCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	return c.parts[c.class(0)].ReleaseRefreshLock(ctx, key, randValue)
+}
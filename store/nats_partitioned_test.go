@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPartitionedNatsCache verifies that keys are routed to the bucket of their TTL class and live in one bucket at
+// a time.
+func TestPartitionedNatsCache(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewPartitionedNatsCache[string](nil, "test", time.Minute)
+	assert.ErrorIs(t, err, ErrNoTTLBuckets)
+
+	hour, minute, tenMinutes := newFakeKV(), newFakeKV(), newFakeKV()
+	c, err := NewPartitionedNatsCache[string]([]NatsTTLBucket{
+		{TTL: time.Hour, KV: hour},
+		{TTL: time.Minute, KV: minute},
+		{TTL: 10 * time.Minute, KV: tenMinutes},
+	}, "test", 10*time.Minute)
+	require.NoError(t, err)
+	setter := c.(TTLSetter[string])
+
+	require.NoError(t, c.Set(ctx, "k", "default"))
+	assert.Len(t, tenMinutes.entries, 1)
+
+	require.NoError(t, setter.SetWithTTL(ctx, "k", "short", 30*time.Second))
+	assert.Len(t, minute.entries, 1)
+	assert.Empty(t, tenMinutes.entries, "a key lives in a single class")
+	value, exists, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "short", value)
+
+	require.NoError(t, setter.SetWithTTL(ctx, "k", "long", 24*time.Hour))
+	assert.Len(t, hour.entries, 1, "TTLs above every class go to the longest bucket")
+	assert.Empty(t, minute.entries)
+
+	require.NoError(t, Delete(ctx, c, "k"))
+	_, exists, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// TestPartitionedNatsCache_SetWithTTLRemovals verifies that only buckets holding the key receive a delete and that a
+// failed removal is returned without writing the value.
+func TestPartitionedNatsCache_SetWithTTLRemovals(t *testing.T) {
+	ctx := context.Background()
+	minute, hour := &deleteCountingKV{fakeKV: newFakeKV()}, &deleteCountingKV{fakeKV: newFakeKV()}
+	c, err := NewPartitionedNatsCache[string]([]NatsTTLBucket{
+		{TTL: time.Minute, KV: minute},
+		{TTL: time.Hour, KV: hour},
+	}, "test", time.Minute)
+	require.NoError(t, err)
+	setter := c.(TTLSetter[string])
+
+	require.NoError(t, c.Set(ctx, "k", "short"))
+	assert.Zero(t, hour.deletes, "absent keys get no delete marker")
+
+	hour.err = errors.New("unavailable")
+	require.NoError(t, setter.SetWithTTL(ctx, "k", "long", time.Hour))
+	assert.Equal(t, 1, minute.deletes)
+	hour.err = nil
+	require.NoError(t, c.Set(ctx, "k", "short"))
+
+	minute.err = errors.New("unavailable")
+	require.ErrorIs(t, setter.SetWithTTL(ctx, "k", "long", time.Hour), minute.err)
+	assert.Empty(t, hour.entries)
+	value, _, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "short", value)
+}
+
+// deleteCountingKV counts the deletes reaching the bucket and fails them with err when set.
+type deleteCountingKV struct {
+	*fakeKV
+	deletes int
+	err     error
+}
+
+func (d *deleteCountingKV) Delete(ctx context.Context, key string, opts ...jetstream.KVDeleteOpt) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.deletes++
+	return d.fakeKV.Delete(ctx, key, opts...)
+}
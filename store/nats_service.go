@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Operations of the cache service, appended to its subject: a service on subject "echocache" answers requests on
+// "echocache.get", "echocache.set" and "echocache.invalidate", and publishes invalidated keys on
+// "echocache.invalidated".
+const (
+	ServiceGet         = "get"
+	ServiceSet         = "set"
+	ServiceInvalidate  = "invalidate"
+	ServiceInvalidated = "invalidated"
+)
+
+const (
+	// defaultServiceQueue is the queue group shared by the instances of the cache service.
+	defaultServiceQueue = "echocache-server"
+	// defaultServiceTimeout bounds the requests of natsServiceCache when neither the context nor the options do.
+	defaultServiceTimeout = 5 * time.Second
+)
+
+// ServiceRequest is the JSON body of the requests of the cache service. Value, for set, is any JSON document;
+// TTLMillis, when positive, overrides the default time-to-live of the backend.
+type ServiceRequest struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	TTLMillis int64           `json:"ttlMs,omitempty"`
+}
+
+// ServiceReply is the JSON body of the replies of the cache service. Found and Value answer get requests; Error is
+// set when the operation failed.
+type ServiceReply struct {
+	Found bool            `json:"found,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// NatsService exposes a store over NATS request/reply, so that services written in other languages and edge
+// processes share the cache through plain JSON messages, while keys are prefixed, sanitized and hashed and values
+// encoded by the codec of the store exactly as for Go processes using it directly. Instances serving the same
+// subject form a queue group, so requests are balanced among them.
+type NatsService struct {
+	nc      *nats.Conn
+	subject string
+	cache   Cacher[json.RawMessage]
+}
+
+// NewNatsService creates a service answering requests on the subjects derived from subject with the given store.
+func NewNatsService(nc *nats.Conn, subject string, cache Cacher[json.RawMessage]) *NatsService {
+	return &NatsService{nc: nc, subject: subject, cache: cache}
+}
+
+// Serve answers requests until ctx is done.
+func (s *NatsService) Serve(ctx context.Context) error {
+	for _, op := range []string{ServiceGet, ServiceSet, ServiceInvalidate} {
+		sub, err := s.nc.QueueSubscribe(s.subject+"."+op, defaultServiceQueue, func(msg *nats.Msg) {
+			if err := msg.Respond(s.handle(ctx, op, msg.Data)); err != nil {
+				slog.Warn("Cannot reply to cache request", slog.String("subject", msg.Subject), slog.String("error", err.Error()))
+			}
+		})
+		if err != nil {
+			return err
+		}
+		defer sub.Unsubscribe()
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// handle runs the operation described by the request body and returns the encoded reply.
+func (s *NatsService) handle(ctx context.Context, op string, data []byte) []byte {
+	var req ServiceRequest
+	reply := ServiceReply{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		reply.Error = "invalid request: " + err.Error()
+	} else if err := s.run(ctx, op, req, &reply); err != nil {
+		reply.Error = err.Error()
+	}
+	out, _ := json.Marshal(reply)
+	return out
+}
+
+// run runs the operation, filling the reply.
+func (s *NatsService) run(ctx context.Context, op string, req ServiceRequest, reply *ServiceReply) error {
+	if req.Key == "" {
+		return errors.New("missing key")
+	}
+	switch op {
+	case ServiceGet:
+		value, found, err := s.cache.Get(ctx, req.Key)
+		reply.Found, reply.Value = found, value
+		return err
+	case ServiceSet:
+		if !json.Valid(req.Value) {
+			return errors.New("value is not valid JSON")
+		}
+		if req.TTLMillis <= 0 {
+			return s.cache.Set(ctx, req.Key, req.Value)
+		}
+		setter, ok := s.cache.(TTLSetter[json.RawMessage])
+		if !ok {
+			return ErrNotSupported
+		}
+		return setter.SetWithTTL(ctx, req.Key, req.Value, time.Duration(req.TTLMillis)*time.Millisecond)
+	case ServiceInvalidate:
+		if err := Delete(ctx, s.cache, req.Key); err != nil {
+			return err
+		}
+		if s.nc == nil {
+			return nil
+		}
+		return s.nc.Publish(s.subject+"."+ServiceInvalidated, []byte(req.Key))
+	}
+	return errors.New("unknown operation " + op)
+}
+
+// natsRequester sends requests and waits for their reply, as nats.Conn does.
+type natsRequester interface {
+	RequestWithContext(ctx context.Context, subject string, data []byte) (*nats.Msg, error)
+}
+
+// natsServiceCache is a store backed by a cache service reached over NATS.
+type natsServiceCache[T any] struct {
+	nc       natsRequester
+	subject  string
+	timeouts timeouts
+}
+
+// NewNatsServiceCache creates a store reading and writing through the cache service answering on subject. Values
+// travel as JSON; keying and encoding are applied by the service. Requests time out after the get and set timeouts
+// of the options, five seconds by default, when the context has no deadline.
+func NewNatsServiceCache[T any](nc *nats.Conn, subject string, opts ...Option) Cacher[T] {
+	return newNatsServiceCache[T](nc, subject, opts)
+}
+
+// newNatsServiceCache creates the store with the given requester.
+func newNatsServiceCache[T any](nc natsRequester, subject string, opts []Option) *natsServiceCache[T] {
+	o := newStoreOptions(opts)
+	t := o.timeouts
+	if t.get <= 0 {
+		t.get = defaultServiceTimeout
+	}
+	if t.set <= 0 {
+		t.set = defaultServiceTimeout
+	}
+	return &natsServiceCache[T]{nc: nc, subject: subject, timeouts: t}
+}
+
+// Get retrieves the value of the key from the service.
+func (c *natsServiceCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	var value T
+	reply, err := c.request(ctx, ServiceGet, ServiceRequest{Key: key}, c.timeouts.get)
+	if err != nil || !reply.Found {
+		return value, false, err
+	}
+	if err := json.Unmarshal(reply.Value, &value); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores the value with the default time-to-live of the service backend.
+func (c *natsServiceCache[T]) Set(ctx context.Context, key string, value T) error {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL stores the value for ttl, or with the default time-to-live of the service backend when ttl is not
+// positive. The service returns ErrNotSupported when its backend cannot set per-key TTLs.
+func (c *natsServiceCache[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = c.request(ctx, ServiceSet, ServiceRequest{Key: key, Value: data, TTLMillis: ttl.Milliseconds()}, c.timeouts.set)
+	return err
+}
+
+// Delete invalidates the key on the service, which also announces the invalidation to its subscribers.
+func (c *natsServiceCache[T]) Delete(ctx context.Context, key string) error {
+	_, err := c.request(ctx, ServiceInvalidate, ServiceRequest{Key: key}, c.timeouts.set)
+	return err
+}
+
+// request sends the request for the operation and decodes the reply, turning its error message into an error.
+func (c *natsServiceCache[T]) request(ctx context.Context, op string, req ServiceRequest, timeout time.Duration) (ServiceReply, error) {
+	ctx, cancel := withDefaultTimeout(ctx, timeout)
+	defer cancel()
+	var reply ServiceReply
+	data, err := json.Marshal(req)
+	if err != nil {
+		return reply, err
+	}
+	msg, err := c.nc.RequestWithContext(ctx, c.subject+"."+op, data)
+	if err != nil {
+		return reply, err
+	}
+	if err := json.Unmarshal(msg.Data, &reply); err != nil {
+		return reply, err
+	}
+	if reply.Error != "" {
+		if reply.Error == ErrNotSupported.Error() {
+			return reply, ErrNotSupported
+		}
+		return reply, errors.New(reply.Error)
+	}
+	return reply, nil
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loopbackRequester delivers the requests of a natsServiceCache straight to a NatsService.
+type loopbackRequester struct {
+	service *NatsService
+}
+
+func (l loopbackRequester) RequestWithContext(ctx context.Context, subject string, data []byte) (*nats.Msg, error) {
+	op := subject[strings.LastIndex(subject, ".")+1:]
+	return &nats.Msg{Subject: subject, Data: l.service.handle(ctx, op, data)}, nil
+}
+
+// TestNatsService verifies that the client store reads, writes and invalidates through the service, and that
+// service errors are reported to the client.
+func TestNatsService(t *testing.T) {
+	ctx := context.Background()
+	backend := NewLRUCache[json.RawMessage](10)
+	service := NewNatsService(nil, "echocache", backend)
+	client := newNatsServiceCache[map[string]int](loopbackRequester{service: service}, "echocache", nil)
+
+	_, exists, err := client.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, client.Set(ctx, "k", map[string]int{"a": 1}))
+	value, exists, err := client.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, map[string]int{"a": 1}, value)
+	raw, _, _ := backend.Get(ctx, "k")
+	assert.JSONEq(t, `{"a":1}`, string(raw), "non-Go clients read the same JSON document")
+
+	assert.ErrorIs(t, client.SetWithTTL(ctx, "k", nil, time.Minute), ErrNotSupported)
+	require.NoError(t, Delete(ctx, client, "k"))
+	_, exists, _ = backend.Get(ctx, "k")
+	assert.False(t, exists)
+
+	var reply ServiceReply
+	require.NoError(t, json.Unmarshal(service.handle(ctx, ServiceSet, []byte(`{"key":"k","value":"not json`)), &reply))
+	assert.Contains(t, reply.Error, "invalid request")
+	require.NoError(t, json.Unmarshal(service.handle(ctx, ServiceGet, []byte(`{}`)), &reply))
+	assert.Equal(t, "missing key", reply.Error)
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures optional behavior of stores. Each store applies the options relevant to it and ignores the others.
+type Option func(*storeOptions)
+
+// storeOptions holds the optional settings shared by stores.
+type storeOptions struct {
+	codec       Codec
+	timeouts    timeouts
+	sanitizer   KeySanitizer
+	copyValues  bool
+	cloner      any
+	lockSink    MetricsSink
+	serde       serdeGuard
+	keyCache    int
+	lockCodec   Codec
+	lockHook    func(LockEvent)
+	legacyLocks bool
+	pinSink     MetricsSink
+	onEvict     any
+}
+
+// timeouts holds the default deadlines applied to store operations when the caller's context has none.
+type timeouts struct {
+	get  time.Duration
+	set  time.Duration
+	lock time.Duration
+}
+
+// newStoreOptions applies the given options on top of the defaults.
+func newStoreOptions(opts []Option) storeOptions {
+	o := storeOptions{
+		codec:     JSONCodec{},
+		lockCodec: JSONCodec{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithCodec sets the codec used to serialize values before they are written to the store.
+func WithCodec(c Codec) Option {
+	return func(o *storeOptions) {
+		o.codec = c
+	}
+}
+
+// WithKeySanitizer sets the sanitizer applied to every key before it reaches the backend.
+func WithKeySanitizer(s KeySanitizer) Option {
+	return func(o *storeOptions) {
+		o.sanitizer = s
+	}
+}
+
+// WithGetTimeout sets the default timeout applied to read operations when the caller's context has no deadline.
+func WithGetTimeout(d time.Duration) Option {
+	return func(o *storeOptions) {
+		o.timeouts.get = d
+	}
+}
+
+// WithSetTimeout sets the default timeout applied to write operations when the caller's context has no deadline.
+func WithSetTimeout(d time.Duration) Option {
+	return func(o *storeOptions) {
+		o.timeouts.set = d
+	}
+}
+
+// WithLockTimeout sets the default timeout applied to refresh lock operations when the caller's context has no deadline.
+func WithLockTimeout(d time.Duration) Option {
+	return func(o *storeOptions) {
+		o.timeouts.lock = d
+	}
+}
+
+// withDefaultTimeout derives a context bounded by d when d is positive and ctx carries no deadline,
+// so a hung backend connection cannot block the calling goroutine indefinitely.
+func withDefaultTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithDefaultTimeout verifies that default timeouts only apply to contexts without a deadline.
+func TestWithDefaultTimeout(t *testing.T) {
+	ctx, cancel := withDefaultTimeout(context.Background(), time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Hour)
+	defer parentCancel()
+	ctx, cancel = withDefaultTimeout(parent, time.Second)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Second)
+
+	ctx, cancel = withDefaultTimeout(context.Background(), 0)
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+// TestNewStoreOptions verifies that store options are applied to Redis caches.
+func TestNewStoreOptions(t *testing.T) {
+	rdb, _ := redismock.NewClientMock()
+	cache := NewRedisCache[string](rdb, "test", time.Hour, WithGetTimeout(time.Second), WithSetTimeout(2*time.Second), WithLockTimeout(3*time.Second))
+
+	rc := cache.(*redisCache[string])
+	assert.Equal(t, timeouts{get: time.Second, set: 2 * time.Second, lock: 3 * time.Second}, rc.timeouts)
+	assert.Equal(t, JSONCodec{}, rc.codec)
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// MetricPinnedEntries is the number of pinned keys of an in-memory store, labelled by backend.
+const MetricPinnedEntries = "echocache_pinned_entries"
+
+// Pinner is implemented by in-memory stores able to exempt keys from eviction. A pinned key keeps its value, current
+// and future, outside the LRU until it is unpinned, so critical entries such as configuration are never evicted by
+// size pressure or expired. Deleting a pinned key removes its value but keeps the key pinned.
+type Pinner interface {
+	Pin(key string)
+	Unpin(key string)
+	Pinned() int
+}
+
+// Pin exempts the key from eviction, returning ErrNotSupported if the cache does not implement Pinner.
+func Pin(c any, key string) error {
+	pinner, ok := c.(Pinner)
+	if !ok {
+		return ErrNotSupported
+	}
+	pinner.Pin(key)
+	return nil
+}
+
+// Unpin makes the key evictable again, returning ErrNotSupported if the cache does not implement Pinner.
+func Unpin(c any, key string) error {
+	pinner, ok := c.(Pinner)
+	if !ok {
+		return ErrNotSupported
+	}
+	pinner.Unpin(key)
+	return nil
+}
+
+// WithPinMetrics publishes the MetricPinnedEntries gauge of in-memory stores to sink.
+func WithPinMetrics(sink MetricsSink) Option {
+	return func(o *storeOptions) {
+		o.pinSink = sink
+	}
+}
+
+// pinSet holds the values of pinned keys outside the LRU of a store. Store operations run with mu read-locked, so
+// that Pin and Unpin, which move values between the LRU and the set, never interleave with them.
+type pinSet[T any] struct {
+	mu      sync.RWMutex
+	entries map[string]*atomic.Pointer[T]
+	backend string
+	sink    MetricsSink
+}
+
+// newPinSet creates the pinned set of a store of the given backend.
+func newPinSet[T any](backend string, sink MetricsSink) *pinSet[T] {
+	return &pinSet[T]{entries: make(map[string]*atomic.Pointer[T]), backend: backend, sink: sink}
+}
+
+// get returns the value of key; pinned is false when the key is not pinned and must be read from the LRU.
+// Must be called with mu held.
+func (p *pinSet[T]) get(key string) (value T, exists bool, pinned bool) {
+	slot, ok := p.entries[key]
+	if !ok {
+		return value, false, false
+	}
+	if v := slot.Load(); v != nil {
+		return *v, true, true
+	}
+	return value, false, true
+}
+
+// set stores the value of a pinned key, reporting false when the key is not pinned. Must be called with mu held.
+func (p *pinSet[T]) set(key string, value T) bool {
+	slot, ok := p.entries[key]
+	if ok {
+		slot.Store(&value)
+	}
+	return ok
+}
+
+// populate stores the value of a pinned key unless it already has one. Must be called with mu held.
+func (p *pinSet[T]) populate(key string, value T) (added bool, pinned bool) {
+	slot, ok := p.entries[key]
+	if !ok {
+		return false, false
+	}
+	return slot.CompareAndSwap(nil, &value), true
+}
+
+// take removes and returns the value of a pinned key. Must be called with mu held.
+func (p *pinSet[T]) take(key string) (value T, exists bool, pinned bool) {
+	slot, ok := p.entries[key]
+	if !ok {
+		return value, false, false
+	}
+	if v := slot.Swap(nil); v != nil {
+		return *v, true, true
+	}
+	return value, false, true
+}
+
+// keys returns the pinned keys holding a value. Must be called with mu held.
+func (p *pinSet[T]) keys() []string {
+	keys := make([]string, 0, len(p.entries))
+	for key, slot := range p.entries {
+		if slot.Load() != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// len returns the number of pinned keys holding a value. Must be called with mu held.
+func (p *pinSet[T]) len() int {
+	n := 0
+	for _, slot := range p.entries {
+		if slot.Load() != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// clear removes the values of the pinned keys, which stay pinned. Must be called with mu held.
+func (p *pinSet[T]) clear() {
+	for _, slot := range p.entries {
+		slot.Store(nil)
+	}
+}
+
+// pin moves the key into the set, taking its current value from the LRU with take.
+func (p *pinSet[T]) pin(key string, take func() (T, bool)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.entries[key]; ok {
+		return
+	}
+	slot := &atomic.Pointer[T]{}
+	if value, ok := take(); ok {
+		slot.Store(&value)
+	}
+	p.entries[key] = slot
+	p.publish()
+}
+
+// unpin moves the key back to the LRU, handing its value, if any, to put.
+func (p *pinSet[T]) unpin(key string, put func(T)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	slot, ok := p.entries[key]
+	if !ok {
+		return
+	}
+	delete(p.entries, key)
+	if v := slot.Load(); v != nil {
+		put(*v)
+	}
+	p.publish()
+}
+
+// size returns the number of pinned keys.
+func (p *pinSet[T]) size() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.entries)
+}
+
+// publish reports the number of pinned keys to the sink. Must be called with mu held.
+func (p *pinSet[T]) publish() {
+	if p.sink != nil {
+		p.sink.SetGauge(MetricPinnedEntries, map[string]string{"backend": p.backend}, float64(len(p.entries)))
+	}
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPin_SurvivesEviction verifies that pinned keys are never evicted by LRU pressure until they are unpinned.
+func TestPin_SurvivesEviction(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewMemoryMetrics()
+	caches := map[string]Cacher[string]{
+		"lru":           NewLRUCache[string](2, WithPinMetrics(metrics)),
+		"lru_expirable": NewLRUExpirableCache[string](2, time.Hour, WithPinMetrics(metrics)),
+	}
+	for backend, cache := range caches {
+		t.Run(backend, func(t *testing.T) {
+			require.NoError(t, cache.Set(ctx, "config", "v1"))
+			require.NoError(t, Pin(cache, "config"))
+			require.NoError(t, Pin(cache, "flags"))
+			assert.Equal(t, 2, cache.(Pinner).Pinned())
+			assert.Equal(t, 2.0, metrics.Gauge(MetricPinnedEntries, map[string]string{"backend": backend}))
+
+			require.NoError(t, cache.Set(ctx, "flags", "on"))
+			for i := range 10 {
+				require.NoError(t, cache.Set(ctx, fmt.Sprintf("k%d", i), "x"))
+			}
+			value, exists, err := cache.Get(ctx, "config")
+			require.NoError(t, err)
+			assert.True(t, exists)
+			assert.Equal(t, "v1", value)
+			size, _ := Len(cache)
+			assert.Equal(t, 4, size)
+			keys, err := cache.(Scanner).Scan(ctx, "*", 100)
+			require.NoError(t, err)
+			assert.Subset(t, keys, []string{"config", "flags"})
+
+			require.NoError(t, Delete(ctx, cache, "flags"))
+			added, err := PopulateIfAbsent(ctx, cache, "flags", "off")
+			require.NoError(t, err)
+			assert.True(t, added)
+			require.NoError(t, Unpin(cache, "flags"))
+			assert.Equal(t, 1.0, metrics.Gauge(MetricPinnedEntries, map[string]string{"backend": backend}))
+
+			require.NoError(t, cache.Set(ctx, "config", "v2"))
+			for i := range 10 {
+				require.NoError(t, cache.Set(ctx, fmt.Sprintf("k%d", i), "x"))
+			}
+			_, exists, err = cache.Get(ctx, "flags")
+			require.NoError(t, err)
+			assert.False(t, exists)
+			value, _, err = cache.Get(ctx, "config")
+			require.NoError(t, err)
+			assert.Equal(t, "v2", value)
+		})
+	}
+	assert.ErrorIs(t, Pin(NewSingleCache[string](time.Hour), "k"), ErrNotSupported)
+}
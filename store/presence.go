@@ -0,0 +1,42 @@
+package store
+
+import "context"
+
+// Optional is a cache envelope carrying an explicit presence marker. Refresh functions often return the zero value,
+// such as an empty struct or a nil slice, to mean "nothing found"; once unwrapped by application code such results
+// look like a missing entry and are recomputed on every request. Caching Optional values keeps "found but empty" and
+// "not found" apart: both are cache hits, and Present tells them apart.
+type Optional[T any] struct {
+	Value   T    `json:"value"`
+	Present bool `json:"present"`
+}
+
+// Some returns an Optional holding value, even when value is the zero value of T.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Present: true}
+}
+
+// None returns an Optional recording that no value exists.
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// Get returns the value and whether it is present.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present
+}
+
+// OptionalRefresh adapts a refresh function reporting whether a value was found into a RefreshFunc of Optional
+// values, so that results not found are cached as None instead of being recomputed.
+func OptionalRefresh[T any](fn func(ctx context.Context) (T, bool, error)) RefreshFunc[Optional[T]] {
+	return func(ctx context.Context) (Optional[T], error) {
+		value, found, err := fn(ctx)
+		if err != nil {
+			return Optional[T]{}, err
+		}
+		if !found {
+			return None[T](), nil
+		}
+		return Some(value), nil
+	}
+}
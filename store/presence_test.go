@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOptional_RedisRoundTrip verifies that empty and missing results survive serialization as distinct cache hits.
+func TestOptional_RedisRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	rdb, mock := redismock.NewClientMock()
+	cache := NewRedisCache[Optional[[]string]](rdb, "test", time.Hour)
+
+	mock.ExpectSet("test:a", []byte(`{"value":[],"present":true}`), time.Hour).SetVal("OK")
+	mock.ExpectSet("test:b", []byte(`{"value":null,"present":false}`), time.Hour).SetVal("OK")
+	require.NoError(t, cache.Set(ctx, "a", Some([]string{})))
+	require.NoError(t, cache.Set(ctx, "b", None[[]string]()))
+
+	mock.ExpectGet("test:a").SetVal(`{"value":[],"present":true}`)
+	mock.ExpectGet("test:b").SetVal(`{"value":null,"present":false}`)
+	value, exists, err := cache.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.True(t, value.Present)
+	value, exists, err = cache.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	_, present := value.Get()
+	assert.False(t, present)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestOptionalRefresh verifies the conversion of found, not found and failed results.
+func TestOptionalRefresh(t *testing.T) {
+	ctx := context.Background()
+	found := OptionalRefresh(func(context.Context) (struct{}, bool, error) { return struct{}{}, true, nil })
+	missing := OptionalRefresh(func(context.Context) (struct{}, bool, error) { return struct{}{}, false, nil })
+	failing := OptionalRefresh(func(context.Context) (struct{}, bool, error) { return struct{}{}, true, assert.AnError })
+
+	value, err := found(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Some(struct{}{}), value)
+	value, err = missing(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, None[struct{}](), value)
+	_, err = failing(ctx)
+	assert.ErrorIs(t, err, assert.AnError)
+}
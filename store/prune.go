@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrInvalidMaxStale is returned when a StalePruner is configured without a positive max-stale bound.
+var ErrInvalidMaxStale = errors.New("max stale must be positive")
+
+// StalePrunerConfig configures a StalePruner.
+// MaxStale is the age past which an entry is evicted, Interval how often the store is swept (defaults to MaxStale)
+// and Pattern the glob-style key filter (defaults to "*"). OnPrune, when set, is called after every sweep that was
+// not interrupted by Close.
+type StalePrunerConfig struct {
+	MaxStale time.Duration
+	Interval time.Duration
+	Pattern  string
+	OnPrune  func(pruned int, err error)
+}
+
+// StalePruner periodically evicts stale-while-revalidate entries older than a max-stale bound.
+// It is meant for stores without a backend TTL, such as a plain LRU, where entries for keys that are no longer
+// requested are never refreshed and would otherwise linger forever. It must be stopped with Close.
+type StalePruner struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// peeker is implemented by stores able to read an entry without updating its recency.
+type peeker[T any] interface {
+	peek(key string) (T, bool)
+}
+
+// NewStalePruner starts a background goroutine sweeping the cache every interval.
+// The cache must implement Scanner and Deleter, otherwise ErrNotSupported is returned.
+func NewStalePruner[T any](c Cacher[StaleValue[T]], cfg StalePrunerConfig) (*StalePruner, error) {
+	if cfg.MaxStale <= 0 {
+		return nil, ErrInvalidMaxStale
+	}
+	if _, ok := c.(Scanner); !ok {
+		return nil, ErrNotSupported
+	}
+	if _, ok := c.(Deleter); !ok {
+		return nil, ErrNotSupported
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = cfg.MaxStale
+	}
+	p := &StalePruner{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go p.run(func(ctx context.Context) (int, error) {
+		return PruneStale[T](ctx, c, cfg.Pattern, cfg.MaxStale)
+	}, cfg)
+	return p, nil
+}
+
+// Close stops the pruner and waits for an in-progress sweep to finish.
+func (p *StalePruner) Close() {
+	p.once.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+// run sweeps the cache at every interval until Close is called.
+func (p *StalePruner) run(sweep func(ctx context.Context) (int, error), cfg StalePrunerConfig) {
+	defer close(p.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			pruned, err := sweep(ctx)
+			if ctx.Err() != nil {
+				// Interrupted by Close.
+				return
+			}
+			if err != nil {
+				slog.Warn("Cannot prune stale entries", slog.String("error", err.Error()), slog.Int("pruned", pruned))
+			}
+			if cfg.OnPrune != nil {
+				cfg.OnPrune(pruned, err)
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// PruneStale runs a single sweep deleting every entry matching the glob-style pattern whose age exceeds maxStale.
+// In-memory LRU stores are inspected without touching the recency of the entries. An entry refreshed between the
+// check and the deletion may be dropped, in which case it is recomputed on the next fetch.
+// The cache must implement Scanner and Deleter. Returns the number of entries deleted.
+func PruneStale[T any](ctx context.Context, c Cacher[StaleValue[T]], pattern string, maxStale time.Duration) (int, error) {
+	scanner, ok := c.(Scanner)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	if _, ok := c.(Deleter); !ok {
+		return 0, ErrNotSupported
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	keys, err := scanner.Scan(ctx, pattern, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	p, canPeek := c.(peeker[StaleValue[T]])
+	cutoff := time.Now().Add(-maxStale)
+	pruned := 0
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return pruned, err
+		}
+		var value StaleValue[T]
+		exists := false
+		if canPeek {
+			value, exists = p.peek(key)
+		} else if value, exists, err = c.Get(ctx, key); err != nil {
+			slog.Warn("Cannot inspect cache entry for pruning", slog.String("error", err.Error()), slog.String("cacheKey", key))
+			continue
+		}
+		if !exists || !value.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if err := Delete(ctx, c, key); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
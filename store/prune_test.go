@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPruneStale verifies that only entries older than the max-stale bound are deleted.
+func TestPruneStale(t *testing.T) {
+	ctx := context.Background()
+	for name, cache := range map[string]StaleWhileRevalidateCache[string]{
+		"lru":           NewStaleWhileRevalidateLRUCache[string](10),
+		"lru-expirable": NewStaleWhileRevalidateExpiringLRUCache[string](10, time.Hour),
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, cache.Set(ctx, "old", StaleValue[string]{Value: "o", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+			require.NoError(t, cache.Set(ctx, "fresh", StaleValue[string]{Value: "f", CreatedAt: time.Now()}))
+			require.NoError(t, cache.Set(ctx, "other:old", StaleValue[string]{Value: "x", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+
+			pruned, err := PruneStale[string](ctx, cache, "old*", time.Hour)
+			require.NoError(t, err)
+			assert.Equal(t, 1, pruned)
+
+			_, found, _ := cache.Get(ctx, "old")
+			assert.False(t, found)
+			_, found, _ = cache.Get(ctx, "fresh")
+			assert.True(t, found)
+			_, found, _ = cache.Get(ctx, "other:old")
+			assert.True(t, found, "keys outside the pattern are kept")
+		})
+	}
+}
+
+// TestPruneStale_NotSupported verifies that stores unable to enumerate their keys are rejected.
+func TestPruneStale_NotSupported(t *testing.T) {
+	cache := NewStaleWhileRevalidateSingleCache[string](time.Hour)
+
+	_, err := PruneStale[string](context.Background(), cache, "*", time.Hour)
+	assert.ErrorIs(t, err, ErrNotSupported)
+	_, err = NewStalePruner[string](cache, StalePrunerConfig{MaxStale: time.Hour})
+	assert.ErrorIs(t, err, ErrNotSupported)
+	_, err = NewStalePruner[string](NewStaleWhileRevalidateLRUCache[string](10), StalePrunerConfig{})
+	assert.ErrorIs(t, err, ErrInvalidMaxStale)
+}
+
+// TestStalePruner verifies that the background pruner sweeps the store periodically until closed.
+func TestStalePruner(t *testing.T) {
+	ctx := context.Background()
+	cache := NewStaleWhileRevalidateLRUCache[string](10)
+	require.NoError(t, cache.Set(ctx, "k", StaleValue[string]{Value: "v", CreatedAt: time.Now()}))
+
+	var sweeps atomic.Int32
+	pruner, err := NewStalePruner[string](cache, StalePrunerConfig{
+		MaxStale: 50 * time.Millisecond,
+		Interval: 10 * time.Millisecond,
+		OnPrune: func(_ int, err error) {
+			assert.NoError(t, err)
+			sweeps.Add(1)
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, found, _ := cache.Get(ctx, "k")
+		return !found
+	}, time.Second, 10*time.Millisecond)
+
+	pruner.Close()
+	pruner.Close()
+	after := sweeps.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, after, sweeps.Load())
+}
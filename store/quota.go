@@ -0,0 +1,349 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrQuotaExceeded is returned by a QuotaCache when writing a key would exceed the quota of its tenant.
+var ErrQuotaExceeded = errors.New("tenant cache quota exceeded")
+
+// QuotaPolicy selects what a QuotaCache does when a write would exceed the quota of a tenant.
+type QuotaPolicy int
+
+const (
+	// QuotaReject refuses the write with ErrQuotaExceeded.
+	QuotaReject QuotaPolicy = iota
+	// QuotaEvictOldest deletes the oldest entries of the same tenant until the new entry fits.
+	QuotaEvictOldest
+)
+
+// TenantQuota bounds the number of entries and the serialized size of the values of a tenant. Zero means unlimited.
+type TenantQuota struct {
+	MaxEntries int
+	MaxBytes   int64
+}
+
+// TenantUsage reports the entries and bytes accounted to a tenant.
+type TenantUsage struct {
+	Entries int
+	Bytes   int64
+}
+
+// QuotaConfig configures a QuotaCache. Tenant maps a key to its tenant and defaults to the key prefix up to the first
+// ':'. Size returns the accounted size of a value and defaults to the length of its JSON encoding. Default applies to
+// every tenant without an entry in Overrides.
+type QuotaConfig[T any] struct {
+	Tenant    func(key string) string
+	Size      func(value T) int64
+	Default   TenantQuota
+	Overrides map[string]TenantQuota
+	Policy    QuotaPolicy
+}
+
+// quotaEntry is an accounted key with the size of its value.
+type quotaEntry struct {
+	key  string
+	size int64
+}
+
+// tenantLock serializes the writes of a tenant. refs counts the writers holding or waiting for it, so that it is
+// dropped once unused.
+type tenantLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// tenantState holds the keys of a tenant in write order, oldest first.
+type tenantState struct {
+	order *list.List
+	keys  map[string]*list.Element
+	bytes int64
+}
+
+// QuotaCache wraps a shared cache and enforces per-tenant quotas on entries and bytes, so that one tenant cannot evict
+// everyone else's keys from a shared LRU or exhaust the memory budget of a remote store.
+// Accounting covers writes made through the wrapper; entries removed by the inner store on its own (TTL, LRU
+// eviction) are forgotten when a Get reports them missing, when a write would exceed the quota of their tenant, which
+// first checks the accounted keys of the tenant against the store, and by Reconcile. Usage is an upper bound in between.
+// Writes of the same tenant are serialized, while writes of different tenants reach the wrapped cache concurrently.
+type QuotaCache[T any] struct {
+	inner   Cacher[T]
+	cfg     QuotaConfig[T]
+	mu      sync.Mutex
+	tenants map[string]*tenantState
+	locks   map[string]*tenantLock
+}
+
+// NewQuotaCache wraps inner with per-tenant quota accounting.
+func NewQuotaCache[T any](inner Cacher[T], cfg QuotaConfig[T]) *QuotaCache[T] {
+	if cfg.Tenant == nil {
+		cfg.Tenant = defaultTenant
+	}
+	if cfg.Size == nil {
+		cfg.Size = func(value T) int64 {
+			data, err := encode(nil, value)
+			if err != nil {
+				return 0
+			}
+			return int64(len(data))
+		}
+	}
+	return &QuotaCache[T]{
+		inner:   inner,
+		cfg:     cfg,
+		tenants: make(map[string]*tenantState),
+		locks:   make(map[string]*tenantLock),
+	}
+}
+
+// defaultTenant returns the key prefix up to the first ':', or the empty tenant for keys without one.
+func defaultTenant(key string) string {
+	tenant, _, found := strings.Cut(key, ":")
+	if !found {
+		return ""
+	}
+	return tenant
+}
+
+// Get delegates to the wrapped cache, forgetting accounted keys that are no longer stored.
+func (q *QuotaCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	value, exists, err := q.inner.Get(ctx, key)
+	if err == nil && !exists {
+		q.mu.Lock()
+		q.forget(key)
+		q.mu.Unlock()
+	}
+	return value, exists, err
+}
+
+// Set writes the value if the tenant quota allows it. Depending on the policy, a write exceeding the quota is
+// rejected with ErrQuotaExceeded or makes room by deleting the oldest entries of the tenant, which requires the
+// wrapped cache to implement Deleter.
+func (q *QuotaCache[T]) Set(ctx context.Context, key string, value T) error {
+	tenant := q.cfg.Tenant(key)
+	quota := q.quota(tenant)
+	size := q.cfg.Size(value)
+	if quota.MaxBytes > 0 && size > quota.MaxBytes {
+		return fmt.Errorf("%w: value of %d bytes exceeds tenant %q limit of %d bytes", ErrQuotaExceeded, size, tenant, quota.MaxBytes)
+	}
+
+	lock := q.lockTenant(tenant)
+	defer q.unlockTenant(tenant, lock)
+	entries, bytes := q.usageWith(tenant, key, size)
+	if exceeds(quota, entries+1, bytes) {
+		// Entries expired by the store may still be accounted.
+		q.reconcile(ctx, tenant)
+		entries, bytes = q.usageWith(tenant, key, size)
+	}
+
+	for exceeds(quota, entries+1, bytes) {
+		if q.cfg.Policy != QuotaEvictOldest {
+			return fmt.Errorf("%w: tenant %q", ErrQuotaExceeded, tenant)
+		}
+		victim, ok := q.oldest(tenant, key)
+		if !ok {
+			break
+		}
+		if err := Delete(ctx, q.inner, victim.key); err != nil {
+			return err
+		}
+		entries--
+		bytes -= victim.size
+		q.mu.Lock()
+		q.forget(victim.key)
+		q.mu.Unlock()
+	}
+
+	if err := q.inner.Set(ctx, key, value); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.forget(key)
+	st := q.tenant(tenant)
+	st.keys[key] = st.order.PushBack(&quotaEntry{key: key, size: size})
+	st.bytes += size
+	return nil
+}
+
+// Delete removes the key from the wrapped cache and from the tenant accounting.
+func (q *QuotaCache[T]) Delete(ctx context.Context, key string) error {
+	if err := Delete(ctx, q.inner, key); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.forget(key)
+	return nil
+}
+
+// Reconcile checks every accounted key against the wrapped cache and forgets those it no longer stores, such as
+// entries expired or evicted by the store, returning the number of keys forgotten. In-memory LRU stores are inspected
+// without touching the recency of the entries.
+func (q *QuotaCache[T]) Reconcile(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	tenants := make([]string, 0, len(q.tenants))
+	for tenant := range q.tenants {
+		tenants = append(tenants, tenant)
+	}
+	q.mu.Unlock()
+	forgotten := 0
+	for _, tenant := range tenants {
+		if err := ctx.Err(); err != nil {
+			return forgotten, err
+		}
+		lock := q.lockTenant(tenant)
+		forgotten += q.reconcile(ctx, tenant)
+		q.unlockTenant(tenant, lock)
+	}
+	return forgotten, nil
+}
+
+// Usage returns the entries and bytes currently accounted to the tenant.
+func (q *QuotaCache[T]) Usage(tenant string) TenantUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st, ok := q.tenants[tenant]
+	if !ok {
+		return TenantUsage{}
+	}
+	return TenantUsage{Entries: st.order.Len(), Bytes: st.bytes}
+}
+
+// lockTenant acquires the write lock of the tenant, which must be released with unlockTenant.
+func (q *QuotaCache[T]) lockTenant(tenant string) *tenantLock {
+	q.mu.Lock()
+	lock, ok := q.locks[tenant]
+	if !ok {
+		lock = &tenantLock{}
+		q.locks[tenant] = lock
+	}
+	lock.refs++
+	q.mu.Unlock()
+	lock.mu.Lock()
+	return lock
+}
+
+// unlockTenant releases the write lock of the tenant acquired with lockTenant.
+func (q *QuotaCache[T]) unlockTenant(tenant string, lock *tenantLock) {
+	lock.mu.Unlock()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lock.refs--
+	if lock.refs == 0 {
+		delete(q.locks, tenant)
+	}
+}
+
+// usageWith returns the entries and bytes of the tenant once the key is written with a value of size, not counting
+// the entry being written.
+func (q *QuotaCache[T]) usageWith(tenant string, key string, size int64) (int, int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st, ok := q.tenants[tenant]
+	if !ok {
+		return 0, size
+	}
+	entries, bytes := st.order.Len(), st.bytes+size
+	if el, ok := st.keys[key]; ok {
+		entries--
+		bytes -= el.Value.(*quotaEntry).size
+	}
+	return entries, bytes
+}
+
+// oldest returns the oldest accounted entry of the tenant other than key. The boolean result is false when there is
+// none.
+func (q *QuotaCache[T]) oldest(tenant string, key string) (quotaEntry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st, ok := q.tenants[tenant]
+	if !ok {
+		return quotaEntry{}, false
+	}
+	for el := st.order.Front(); el != nil; el = el.Next() {
+		if entry := el.Value.(*quotaEntry); entry.key != key {
+			return *entry, true
+		}
+	}
+	return quotaEntry{}, false
+}
+
+// reconcile forgets the keys of the tenant that the wrapped cache no longer stores, returning how many were
+// forgotten. Keys whose lookup fails stay accounted. Must be called with the write lock of the tenant held.
+func (q *QuotaCache[T]) reconcile(ctx context.Context, tenant string) int {
+	q.mu.Lock()
+	var keys []string
+	if st, ok := q.tenants[tenant]; ok {
+		for el := st.order.Front(); el != nil; el = el.Next() {
+			keys = append(keys, el.Value.(*quotaEntry).key)
+		}
+	}
+	q.mu.Unlock()
+
+	p, canPeek := q.inner.(peeker[T])
+	var missing []string
+	for _, key := range keys {
+		var exists bool
+		var err error
+		if canPeek {
+			_, exists = p.peek(key)
+		} else {
+			_, exists, err = q.inner.Get(ctx, key)
+		}
+		if err == nil && !exists {
+			missing = append(missing, key)
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, key := range missing {
+		q.forget(key)
+	}
+	return len(missing)
+}
+
+// quota returns the quota applying to the tenant.
+func (q *QuotaCache[T]) quota(tenant string) TenantQuota {
+	if quota, ok := q.cfg.Overrides[tenant]; ok {
+		return quota
+	}
+	return q.cfg.Default
+}
+
+// tenant returns the state of the tenant, creating it if needed. Must be called with the lock held.
+func (q *QuotaCache[T]) tenant(tenant string) *tenantState {
+	st, ok := q.tenants[tenant]
+	if !ok {
+		st = &tenantState{order: list.New(), keys: make(map[string]*list.Element)}
+		q.tenants[tenant] = st
+	}
+	return st
+}
+
+// forget removes the key from the accounting of its tenant. Must be called with the lock held.
+func (q *QuotaCache[T]) forget(key string) {
+	tenant := q.cfg.Tenant(key)
+	st, ok := q.tenants[tenant]
+	if !ok {
+		return
+	}
+	if el, ok := st.keys[key]; ok {
+		st.bytes -= el.Value.(*quotaEntry).size
+		st.order.Remove(el)
+		delete(st.keys, key)
+	}
+	if st.order.Len() == 0 {
+		delete(q.tenants, tenant)
+	}
+}
+
+// exceeds reports whether the given usage is over the quota.
+func exceeds(quota TenantQuota, entries int, bytes int64) bool {
+	return (quota.MaxEntries > 0 && entries > quota.MaxEntries) || (quota.MaxBytes > 0 && bytes > quota.MaxBytes)
+}
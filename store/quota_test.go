@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQuotaCache_Reject verifies that writes over the tenant quota are rejected without affecting other tenants.
+func TestQuotaCache_Reject(t *testing.T) {
+	ctx := context.Background()
+	cache := NewQuotaCache[string](NewLRUCache[string](100), QuotaConfig[string]{
+		Default:   TenantQuota{MaxEntries: 2},
+		Overrides: map[string]TenantQuota{"big": {MaxEntries: 10}},
+	})
+
+	require.NoError(t, cache.Set(ctx, "a:1", "v"))
+	require.NoError(t, cache.Set(ctx, "a:2", "v"))
+	require.NoError(t, cache.Set(ctx, "a:2", "overwrite"))
+	assert.ErrorIs(t, cache.Set(ctx, "a:3", "v"), ErrQuotaExceeded)
+
+	for _, key := range []string{"b:1", "big:1", "big:2", "big:3"} {
+		assert.NoError(t, cache.Set(ctx, key, "v"))
+	}
+	assert.Equal(t, TenantUsage{Entries: 2, Bytes: int64(len(`"v"`) + len(`"overwrite"`))}, cache.Usage("a"))
+	assert.Equal(t, 3, cache.Usage("big").Entries)
+
+	require.NoError(t, cache.Delete(ctx, "a:1"))
+	assert.NoError(t, cache.Set(ctx, "a:3", "v"))
+}
+
+// TestQuotaCache_EvictOldest verifies that the oldest entries of the tenant make room for new ones.
+func TestQuotaCache_EvictOldest(t *testing.T) {
+	ctx := context.Background()
+	inner := NewLRUCache[string](100)
+	cache := NewQuotaCache[string](inner, QuotaConfig[string]{
+		Default: TenantQuota{MaxBytes: 10},
+		Size:    func(value string) int64 { return int64(len(value)) },
+		Policy:  QuotaEvictOldest,
+	})
+
+	require.NoError(t, cache.Set(ctx, "a:1", "1234"))
+	require.NoError(t, cache.Set(ctx, "b:1", "1234"))
+	require.NoError(t, cache.Set(ctx, "a:2", "1234"))
+	require.NoError(t, cache.Set(ctx, "a:3", "1234"))
+
+	_, found, _ := inner.Get(ctx, "a:1")
+	assert.False(t, found)
+	_, found, _ = inner.Get(ctx, "b:1")
+	assert.True(t, found)
+	assert.Equal(t, TenantUsage{Entries: 2, Bytes: 8}, cache.Usage("a"))
+
+	assert.ErrorIs(t, cache.Set(ctx, "a:4", "12345678901"), ErrQuotaExceeded)
+}
+
+// TestQuotaCache_ForgetsMissingKeys verifies that keys evicted by the inner store are released from the accounting.
+func TestQuotaCache_ForgetsMissingKeys(t *testing.T) {
+	ctx := context.Background()
+	cache := NewQuotaCache[string](NewLRUCache[string](1), QuotaConfig[string]{Default: TenantQuota{MaxEntries: 2}})
+
+	require.NoError(t, cache.Set(ctx, "a:1", "v"))
+	require.NoError(t, cache.Set(ctx, "a:2", "v"))
+	_, found, _ := cache.Get(ctx, "a:1")
+	assert.False(t, found)
+	assert.Equal(t, 1, cache.Usage("a").Entries)
+}
+
+// TestQuotaCache_ReconcilesExpiredKeys verifies that keys expired by the inner store stop counting against the quota
+// without being read.
+func TestQuotaCache_ReconcilesExpiredKeys(t *testing.T) {
+	ctx := context.Background()
+	cache := NewQuotaCache[string](NewLRUExpirableCache[string](10, 10*time.Millisecond), QuotaConfig[string]{Default: TenantQuota{MaxEntries: 1}})
+
+	require.NoError(t, cache.Set(ctx, "a:1", "v"))
+	require.NoError(t, cache.Set(ctx, "b:1", "v"))
+	assert.ErrorIs(t, cache.Set(ctx, "a:2", "v"), ErrQuotaExceeded)
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, cache.Set(ctx, "a:2", "v"))
+	assert.Equal(t, 1, cache.Usage("a").Entries)
+	forgotten, err := cache.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, forgotten)
+	assert.Zero(t, cache.Usage("b").Entries)
+}
+
+// prefixGatedCacher blocks the writes of the keys with the given prefix until its gate is closed.
+type prefixGatedCacher struct {
+	Cacher[string]
+	prefix string
+	gate   chan struct{}
+}
+
+// Set waits for the gate before writing a key with the prefix.
+func (g prefixGatedCacher) Set(ctx context.Context, key string, value string) error {
+	if strings.HasPrefix(key, g.prefix) {
+		<-g.gate
+	}
+	return g.Cacher.Set(ctx, key, value)
+}
+
+// TestQuotaCache_ConcurrentTenants verifies that a slow write of a tenant blocks neither the writes of other tenants
+// nor the usage accounting.
+func TestQuotaCache_ConcurrentTenants(t *testing.T) {
+	ctx := context.Background()
+	gated := prefixGatedCacher{Cacher: NewLRUCache[string](10), prefix: "a:", gate: make(chan struct{})}
+	cache := NewQuotaCache[string](gated, QuotaConfig[string]{Default: TenantQuota{MaxEntries: 2}})
+
+	done := make(chan error, 1)
+	go func() { done <- cache.Set(ctx, "a:1", "v") }()
+	time.Sleep(20 * time.Millisecond)
+
+	finished := make(chan error, 1)
+	go func() { finished <- cache.Set(ctx, "b:1", "v") }()
+	select {
+	case err := <-finished:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the write of tenant b waited for the write of tenant a")
+	}
+	assert.Equal(t, TenantUsage{Entries: 1, Bytes: int64(len(`"v"`))}, cache.Usage("b"))
+	assert.Equal(t, TenantUsage{}, cache.Usage("a"))
+
+	close(gated.gate)
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, cache.Usage("a").Entries)
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Recorded operation names.
+const (
+	RecordOpGet = "get"
+	RecordOpSet = "set"
+)
+
+// Recording is a single NDJSON line written by a recording cache. Value holds the value serialized with the store codec,
+// Found reports whether a Get hit and Error carries the error message of a failed operation.
+type Recording struct {
+	Op       string        `json:"op"`
+	Key      string        `json:"key"`
+	Value    []byte        `json:"value,omitempty"`
+	Found    bool          `json:"found,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// recordingCache wraps a cache and writes every Get and Set to an io.Writer as NDJSON.
+type recordingCache[T any] struct {
+	inner Cacher[T]
+	codec Codec
+	mu    sync.Mutex
+	enc   *json.Encoder
+}
+
+// NewRecordingCache wraps inner so that every Get and Set, with its key, serialized value, outcome and timing, is
+// written to w as a Recording. The recording can later be served by NewReplayCache to reproduce cache-dependent bugs
+// offline. Values are serialized with the codec configured by WithCodec, JSON by default.
+func NewRecordingCache[T any](inner Cacher[T], w io.Writer, opts ...Option) Cacher[T] {
+	o := newStoreOptions(opts)
+	return &recordingCache[T]{
+		inner: inner,
+		codec: o.codec,
+		enc:   json.NewEncoder(w),
+	}
+}
+
+// Get delegates to the wrapped cache and records the outcome.
+func (r *recordingCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	start := time.Now()
+	value, exists, err := r.inner.Get(ctx, key)
+	rec := Recording{Op: RecordOpGet, Key: key, Found: exists, Start: start, Duration: time.Since(start)}
+	if exists {
+		rec.Value = r.serialize(key, value)
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	r.write(rec)
+	return value, exists, err
+}
+
+// Set delegates to the wrapped cache and records the written value.
+func (r *recordingCache[T]) Set(ctx context.Context, key string, value T) error {
+	start := time.Now()
+	err := r.inner.Set(ctx, key, value)
+	rec := Recording{Op: RecordOpSet, Key: key, Value: r.serialize(key, value), Start: start, Duration: time.Since(start)}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	r.write(rec)
+	return err
+}
+
+// serialize encodes the value for the recording, logging and omitting values the codec cannot encode.
+func (r *recordingCache[T]) serialize(key string, value T) []byte {
+	data, err := encode(r.codec, value)
+	if err != nil {
+		slog.Warn("Cannot serialize recorded value", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		return nil
+	}
+	return data
+}
+
+// write appends the recording to the output. Write failures are logged and do not affect the cache operation.
+func (r *recordingCache[T]) write(rec Recording) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(rec); err != nil {
+		slog.Warn("Cannot write cache recording", slog.String("error", err.Error()), slog.String("cacheKey", rec.Key))
+	}
+}
+
+// replayCache serves the Get results captured by a recording cache.
+type replayCache[T any] struct {
+	codec Codec
+	mu    sync.Mutex
+	gets  map[string][]Recording
+}
+
+// NewReplayCache reads recordings produced by NewRecordingCache and returns a cache that replays them: successive Gets
+// of a key return the recorded results for that key in their original order, and a key with no recorded Gets left is a
+// miss. Sets are accepted and discarded so that the replayed results do not depend on the code under test.
+// The codec configured by WithCodec must match the one used when recording.
+func NewReplayCache[T any](r io.Reader, opts ...Option) (Cacher[T], error) {
+	o := newStoreOptions(opts)
+	c := &replayCache[T]{
+		codec: o.codec,
+		gets:  make(map[string][]Recording),
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var rec Recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid recording at line %d: %w", line, err)
+		}
+		if rec.Op == RecordOpGet {
+			c.gets[rec.Key] = append(c.gets[rec.Key], rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get returns the next recorded result for the key, including recorded errors.
+func (c *replayCache[T]) Get(_ context.Context, key string) (T, bool, error) {
+	var emptyValue T
+	c.mu.Lock()
+	queue := c.gets[key]
+	if len(queue) == 0 {
+		c.mu.Unlock()
+		return emptyValue, false, nil
+	}
+	rec := queue[0]
+	c.gets[key] = queue[1:]
+	c.mu.Unlock()
+
+	if rec.Error != "" {
+		return emptyValue, false, errors.New(rec.Error)
+	}
+	if !rec.Found {
+		return emptyValue, false, nil
+	}
+	var value T
+	if err := decode(c.codec, rec.Value, &value); err != nil {
+		return emptyValue, false, err
+	}
+	return value, true, nil
+}
+
+// Set discards the value; replayed results only come from the recording.
+func (c *replayCache[T]) Set(_ context.Context, _ string, _ T) error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecordingCache_Replay verifies that a recorded session is replayed in order, including misses and errors.
+func TestRecordingCache_Replay(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	recorder := NewRecordingCache[int](NewLRUCache[int](10), &buf)
+
+	_, _, _ = recorder.Get(ctx, "k")
+	require.NoError(t, recorder.Set(ctx, "k", 1))
+	_, _, _ = recorder.Get(ctx, "k")
+	require.NoError(t, recorder.Set(ctx, "k", 2))
+	_, _, _ = recorder.Get(ctx, "k")
+
+	failing := NewRecordingCache[int](failingCacher[int]{err: errors.New("boom")}, &buf)
+	_, _, _ = failing.Get(ctx, "broken")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 6)
+	var first Recording
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &first))
+	assert.Equal(t, RecordOpSet, first.Op)
+	assert.Equal(t, "1", string(first.Value))
+
+	replay, err := NewReplayCache[int](&buf)
+	require.NoError(t, err)
+	require.NoError(t, replay.Set(ctx, "k", 42))
+
+	_, exists, err := replay.Get(ctx, "k")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	for _, expected := range []int{1, 2} {
+		value, exists, err := replay.Get(ctx, "k")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, expected, value)
+	}
+	_, exists, _ = replay.Get(ctx, "k")
+	assert.False(t, exists)
+
+	_, _, err = replay.Get(ctx, "broken")
+	assert.EqualError(t, err, "boom")
+}
+
+// TestNewReplayCache_InvalidRecording verifies that malformed recordings are rejected with their line number.
+func TestNewReplayCache_InvalidRecording(t *testing.T) {
+	_, err := NewReplayCache[int](strings.NewReader("{\"op\":\"get\"}\nnot json\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
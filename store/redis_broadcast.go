@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBroadcaster is a ClearBroadcaster backed by a Redis pub/sub channel.
+type RedisBroadcaster struct {
+	db      *redis.Client
+	channel string
+}
+
+// NewRedisBroadcaster creates a broadcaster publishing to and subscribing to the given channel.
+func NewRedisBroadcaster(db *redis.Client, channel string) *RedisBroadcaster {
+	return &RedisBroadcaster{db: db, channel: channel}
+}
+
+// PublishClear publishes the command on the channel.
+func (r *RedisBroadcaster) PublishClear(ctx context.Context, cmd ClearCommand) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	return r.db.Publish(ctx, r.channel, data).Err()
+}
+
+// SubscribeClear subscribes to the channel until ctx is done, skipping messages that are not clear commands.
+func (r *RedisBroadcaster) SubscribeClear(ctx context.Context, handler func(ctx context.Context, cmd ClearCommand)) error {
+	sub := r.db.Subscribe(ctx, r.channel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var cmd ClearCommand
+			if err := json.Unmarshal([]byte(msg.Payload), &cmd); err != nil {
+				slog.Warn("Cannot decode clear command", slog.String("channel", r.channel), slog.String("error", err.Error()))
+				continue
+			}
+			handler(ctx, cmd)
+		}
+	}
+}
@@ -2,39 +2,66 @@ package store
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/redis/go-redis/v9"
+	"iter"
+	"slices"
+	"strings"
 	"time"
 )
 
+// scanBatchSize is the COUNT hint passed to Redis SCAN calls.
+const scanBatchSize = 100
+
 // redisCache is a generic type that implements caching functionality using Redis for storing and retrieving data.
 // It requires a Redis client, a key prefix, and a time-to-live (TTL) duration for cached entries.
 type redisCache[T any] struct {
-	db     *redis.Client
-	prefix string
-	ttl    time.Duration
+	db        *redis.Client
+	prefix    string
+	ttl       time.Duration
+	codec     Codec
+	timeouts  timeouts
+	sanitizer KeySanitizer
+	locks     *lockCounters
+	serde     serdeGuard
+	keys      *keyDigestCache
 }
 
 // NewRedisCache creates a new Redis-based generic cache with a specified prefix and time-to-live duration.
-func NewRedisCache[T any](db *redis.Client, prefix string, ttl time.Duration) Cacher[T] {
+func NewRedisCache[T any](db *redis.Client, prefix string, ttl time.Duration, opts ...Option) Cacher[T] {
+	o := newStoreOptions(opts)
 	return &redisCache[T]{
-		db:     db,
-		prefix: prefix,
-		ttl:    ttl,
+		db:        db,
+		prefix:    prefix,
+		ttl:       ttl,
+		codec:     o.codec,
+		timeouts:  o.timeouts,
+		sanitizer: o.sanitizer,
+		locks:     newLockCounters("redis", o.lockSink),
+		serde:     o.serde,
+		keys:      newKeyDigestCache(o.keyCache),
 	}
 }
 
 // NewStaleWhileRevalidateRedisCache creates a Redis-backed stale-while-revalidate cache with the specified prefix and TTL.
-func NewStaleWhileRevalidateRedisCache[T any](db *redis.Client, prefix string, ttl time.Duration) StaleWhileRevalidateCache[T] {
+func NewStaleWhileRevalidateRedisCache[T any](db *redis.Client, prefix string, ttl time.Duration, opts ...Option) StaleWhileRevalidateCache[T] {
+	o := newStoreOptions(opts)
 	return &redisCache[StaleValue[T]]{
-		db:     db,
-		prefix: prefix,
-		ttl:    ttl,
+		db:        db,
+		prefix:    prefix,
+		ttl:       ttl,
+		codec:     o.codec,
+		timeouts:  o.timeouts,
+		sanitizer: o.sanitizer,
+		locks:     newLockCounters("redis", o.lockSink),
+		serde:     o.serde,
+		keys:      newKeyDigestCache(o.keyCache),
 	}
 }
 
 // Get retrieves a cached value by key from Redis. It returns the value, a boolean indicating existence, and an error if any.
 func (r *redisCache[T]) Get(ctx context.Context, k string) (value T, exists bool, err error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.get)
+	defer cancel()
 	var emptyValue T
 	key := r.buildKey(k)
 	result, err := r.db.Get(ctx, key).Result()
@@ -46,35 +73,304 @@ func (r *redisCache[T]) Get(ctx context.Context, k string) (value T, exists bool
 		return emptyValue, false, err
 	}
 
-	// Assuming the value can be unmarshalled into T
-	err = json.Unmarshal([]byte(result), &value)
-	if err != nil {
-		return emptyValue, false, err
+	if err := decodePooled(r.codec, result, &value); err != nil {
+		return emptyValue, false, r.serde.unmarshalFailed(k, err, func() error {
+			return r.db.Del(ctx, key).Err()
+		})
 	}
 	return value, true, nil
 }
 
-// Set stores the given value in the cache using the specified key and TTL, marshaling the value to JSON format.
+// Set stores the given value in the cache using the specified key and TTL, serializing the value with the configured codec.
 // Returns an error if the marshaling or Redis operation fails.
 func (r *redisCache[T]) Set(ctx context.Context, k string, value T) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
 	key := r.buildKey(k)
-	// Assuming the value can be marshalled to JSON
-	data, err := json.Marshal(value)
+	data, buf, err := encodePooled(r.codec, value)
 	if err != nil {
-		return err
+		return r.serde.marshalFailed(k, err, func() error {
+			return r.db.Del(ctx, key).Err()
+		})
+	}
+	defer releaseBuffer(buf)
+	// The client writes the bytes to the connection before returning, so the pooled buffer is reused only after the write.
+	return r.db.Set(ctx, key, data, r.ttl).Err()
+}
+
+// SetWithTTL stores the value like Set but with an explicit TTL instead of the cache default.
+func (r *redisCache[T]) SetWithTTL(ctx context.Context, k string, value T, ttl time.Duration) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	data, buf, err := encodePooled(r.codec, value)
+	if err != nil {
+		return r.serde.marshalFailed(k, err, func() error {
+			return r.db.Del(ctx, r.buildKey(k)).Err()
+		})
 	}
-	return r.db.Set(ctx, key, string(data), r.ttl).Err()
+	defer releaseBuffer(buf)
+	return r.db.Set(ctx, r.buildKey(k), data, ttl).Err()
 }
 
-// buildKey constructs a complete key by appending a prefix and delimiter to the input key string.
+// PopulateIfAbsent stores the value with SETNX semantics, leaving any existing value untouched.
+func (r *redisCache[T]) PopulateIfAbsent(ctx context.Context, k string, value T) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	data, buf, err := encodePooled(r.codec, value)
+	if err != nil {
+		return false, r.serde.marshalFailed(k, err, nil)
+	}
+	defer releaseBuffer(buf)
+	return r.db.SetNX(ctx, r.buildKey(k), data, r.ttl).Result()
+}
+
+// Delete removes the key from Redis.
+func (r *redisCache[T]) Delete(ctx context.Context, k string) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	return r.db.Del(ctx, r.buildKey(k)).Err()
+}
+
+// Take reads and removes the key atomically with GETDEL, so the value is returned to a single caller.
+func (r *redisCache[T]) Take(ctx context.Context, k string) (T, bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	var value T
+	result, err := r.db.GetDel(ctx, r.buildKey(k)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return value, false, nil
+		}
+		return value, false, err
+	}
+	if err := decodePooled(r.codec, result, &value); err != nil {
+		var emptyValue T
+		return emptyValue, false, r.serde.unmarshalFailed(k, err, nil)
+	}
+	return value, true, nil
+}
+
+// Scan iterates the keyspace with SCAN and returns up to limit cache keys matching the glob-style pattern.
+// The pattern is matched against the stored keys as is, without sanitizing. Keys are returned without the store prefix
+// and decoded as described by KeySanitizer, and refresh lock keys are skipped.
+func (r *redisCache[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.get)
+	defer cancel()
+	prefix := r.keyPattern("")
+	lockPrefix := r.buildKey("lock:")
+	pattern = r.keyPattern(pattern)
+	result := make([]string, 0)
+	var cursor uint64
+	for {
+		keys, next, err := r.db.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if strings.HasPrefix(key, lockPrefix) {
+				continue
+			}
+			result = append(result, rawKey(r.sanitizer, strings.TrimPrefix(key, prefix)))
+			if limit > 0 && len(result) >= limit {
+				return result, nil
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return result, nil
+		}
+	}
+}
+
+// All iterates over the cache entries page by page with SCAN and MGET, holding a single page of keys in memory.
+// Keys are yielded without the store prefix, refresh lock keys are skipped and iteration stops at the first error;
+// use the All function of this package to retrieve it.
+func (r *redisCache[T]) All(ctx context.Context) iter.Seq2[string, T] {
+	return r.all(ctx, new(error))
+}
+
+// all implements All, recording in errp the error that stopped the iteration.
+func (r *redisCache[T]) all(ctx context.Context, errp *error) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		prefix := r.keyPattern("")
+		lockPrefix := r.buildKey("lock:")
+		pattern := r.keyPattern("*")
+		var cursor uint64
+		for {
+			keys, next, err := r.scanPage(ctx, cursor, pattern)
+			if err != nil {
+				*errp = err
+				return
+			}
+			keys = slices.DeleteFunc(keys, func(key string) bool { return strings.HasPrefix(key, lockPrefix) })
+			values, err := r.mget(ctx, keys)
+			if err != nil {
+				*errp = err
+				return
+			}
+			for i, result := range values {
+				data, ok := result.(string)
+				if !ok {
+					continue
+				}
+				key := rawKey(r.sanitizer, strings.TrimPrefix(keys[i], prefix))
+				var value T
+				if err := decodePooled(r.codec, data, &value); err != nil {
+					if err := r.serde.unmarshalFailed(key, err, nil); err != nil {
+						*errp = err
+						return
+					}
+					continue
+				}
+				if !yield(key, value) {
+					return
+				}
+			}
+			if cursor = next; cursor == 0 {
+				return
+			}
+		}
+	}
+}
+
+// scanPage returns the keys of one SCAN page matching the pattern and the cursor of the next page.
+func (r *redisCache[T]) scanPage(ctx context.Context, cursor uint64, pattern string) ([]string, uint64, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.get)
+	defer cancel()
+	return r.db.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+}
+
+// mget reads the raw values of the given full keys, nil for keys that expired since they were scanned.
+func (r *redisCache[T]) mget(ctx context.Context, keys []string) ([]any, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.get)
+	defer cancel()
+	return r.db.MGet(ctx, keys...).Result()
+}
+
+// TTL returns the remaining time-to-live of the given key using PTTL.
+func (r *redisCache[T]) TTL(ctx context.Context, k string) (time.Duration, bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.get)
+	defer cancel()
+	ttl, err := r.db.PTTL(ctx, r.buildKey(k)).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	if ttl < 0 {
+		return 0, false, nil
+	}
+	return ttl, true, nil
+}
+
+// BulkGet reads the given keys with a single MGET. Values that cannot be decoded are handled by the serde policy,
+// and reported as missing unless it fails the read.
+func (r *redisCache[T]) BulkGet(ctx context.Context, keys []string) (map[string]T, error) {
+	fullKeys := make([]string, len(keys))
+	for i, k := range keys {
+		fullKeys[i] = r.buildKey(k)
+	}
+	results, err := r.mget(ctx, fullKeys)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]T, len(keys))
+	for i, result := range results {
+		data, ok := result.(string)
+		if !ok {
+			continue
+		}
+		var value T
+		if err := decodePooled(r.codec, data, &value); err != nil {
+			if err := r.serde.unmarshalFailed(keys[i], err, nil); err != nil {
+				return values, err
+			}
+			continue
+		}
+		values[keys[i]] = value
+	}
+	return values, nil
+}
+
+// BulkSet stores all entries using a single pipelined round-trip, applying the cache TTL to each of them.
+func (r *redisCache[T]) BulkSet(ctx context.Context, entries map[string]T) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	pipe := r.db.Pipeline()
+	for k, value := range entries {
+		data, err := encode(r.codec, value)
+		if err != nil {
+			if err := r.serde.marshalFailed(k, err, nil); err != nil {
+				return err
+			}
+			if r.serde.policy == SerdeEvictAndMiss {
+				pipe.Del(ctx, r.buildKey(k))
+			}
+			continue
+		}
+		pipe.Set(ctx, r.buildKey(k), data, r.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SetAtomic stores all entries in a single MULTI/EXEC transaction, applying the cache TTL to each of them. Entries
+// are encoded before the transaction starts, and any encoding error aborts the whole write whatever the serde policy.
+func (r *redisCache[T]) SetAtomic(ctx context.Context, entries map[string]T) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.set)
+	defer cancel()
+	encoded := make(map[string][]byte, len(entries))
+	for k, value := range entries {
+		data, err := encode(r.codec, value)
+		if err != nil {
+			return err
+		}
+		encoded[r.buildKey(k)] = data
+	}
+	_, err := r.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, data := range encoded {
+			pipe.Set(ctx, key, data, r.ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// valueCodec returns the codec used to serialize values.
+func (r *redisCache[T]) valueCodec() Codec {
+	return r.codec
+}
+
+// buildKey constructs a complete key by appending a prefix and delimiter to the sanitized input key string, using the
+// key digest cache when configured.
 func (r *redisCache[T]) buildKey(key string) string {
-	// Example implementation, customizable as needed
-	return r.prefix + ":" + key
+	return r.keys.get(key, r.computeKey)
+}
+
+// computeKey builds the backend key of the given raw key.
+func (r *redisCache[T]) computeKey(key string) string {
+	return r.prefix + ":" + sanitizeKey(r.sanitizer, key)
+}
+
+// keyPattern prefixes a SCAN pattern with the store prefix. Unlike buildKey, the pattern is neither sanitized, which
+// would escape or hash its glob characters, nor kept in the key digest cache.
+func (r *redisCache[T]) keyPattern(pattern string) string {
+	return r.prefix + ":" + pattern
 }
 
 // TryAcquireRefreshLock attempts to acquire a refresh lock identified by the given key and random value within a TTL duration.
 // Returns true if the lock is acquired, false if the lock is held by another instance, or an error if an operation fails.
 func (r *redisCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	acquired, err := r.tryAcquireRefreshLock(ctx, key, randValue, ttl)
+	r.locks.attempt(key, randValue, acquired, err)
+	return acquired, err
+}
+
+// tryAcquireRefreshLock implements TryAcquireRefreshLock.
+func (r *redisCache[T]) tryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.lock)
+	defer cancel()
 	lockKey := r.buildKey("lock:" + key)
 	result, err := r.db.SetNX(ctx, lockKey, randValue, ttl).Result()
 	if err != nil {
@@ -90,7 +386,7 @@ func (r *redisCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, r
 	if storedValue != randValue {
 		return false, nil
 	}
-	_, err = r.db.Expire(ctx, lockKey, time.Minute).Result()
+	_, err = r.db.Expire(ctx, lockKey, ttl).Result()
 	if err != nil {
 		return false, err
 	}
@@ -101,17 +397,27 @@ func (r *redisCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, r
 // It checks if the stored lock value matches the provided randValue before deletion.
 // Returns an error if any issues occur during the retrieval or deletion of the lock.
 func (r *redisCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.timeouts.lock)
+	defer cancel()
 	lockKey := r.buildKey("lock:" + key)
 	storedValue, err := r.db.Get(ctx, lockKey).Result()
 	if err != nil {
 		if err == redis.Nil {
+			r.locks.released(key, randValue, false)
 			return nil // Lock does not exist
 		}
 		return err
 	}
 	if storedValue != randValue {
+		r.locks.released(key, randValue, false)
 		return nil // Current lock was not acquired by this instance
 	}
 	_, err = r.db.Del(ctx, lockKey).Result()
+	r.locks.released(key, randValue, true)
 	return err
 }
+
+// LockStats returns the refresh lock statistics of this store.
+func (r *redisCache[T]) LockStats() LockStats {
+	return r.locks.snapshot()
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-redis/redismock/v9"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
 )
@@ -114,12 +115,12 @@ func TestRedisCache_Set(t *testing.T) {
 			data, err := json.Marshal(tc.value)
 			if err == nil {
 				if (tc.mockError != nil) || (tc.expectedErr != nil) {
-					mock.ExpectSet(prefix+":"+tc.key, string(data), cache.ttl).SetErr(tc.mockError)
+					mock.ExpectSet(prefix+":"+tc.key, data, cache.ttl).SetErr(tc.mockError)
 				} else {
-					mock.ExpectSet(prefix+":"+tc.key, string(data), cache.ttl).SetVal("OK")
+					mock.ExpectSet(prefix+":"+tc.key, data, cache.ttl).SetVal("OK")
 				}
 			} else {
-				mock.ExpectSet(prefix+":"+tc.key, string(data), cache.ttl).SetErr(err)
+				mock.ExpectSet(prefix+":"+tc.key, data, cache.ttl).SetErr(err)
 
 			}
 
@@ -132,3 +133,143 @@ func TestRedisCache_Set(t *testing.T) {
 		})
 	}
 }
+
+// TestRedisCache_Scan verifies that Scan walks every batch of the cursor, strips the prefix, skips lock keys and stops
+// at the limit.
+func TestRedisCache_Scan(t *testing.T) {
+	ctx := context.TODO()
+	const prefix = "test"
+	rdb, mock := redismock.NewClientMock()
+	cache := redisCache[string]{db: rdb, prefix: prefix, ttl: time.Hour}
+
+	mock.ExpectScan(0, prefix+":user:*", scanBatchSize).SetVal([]string{prefix + ":user:1", prefix + ":lock:user:1"}, 7)
+	mock.ExpectScan(7, prefix+":user:*", scanBatchSize).SetVal([]string{prefix + ":user:2", prefix + ":user:3"}, 0)
+
+	keys, err := cache.Scan(ctx, "user:*", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:1", "user:2"}, keys)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRedisCache_ScanPatternNotSanitized verifies that scan patterns are sent verbatim, neither escaped nor truncated,
+// and are not kept in the key digest cache.
+func TestRedisCache_ScanPatternNotSanitized(t *testing.T) {
+	ctx := context.TODO()
+	const prefix = "test"
+	rdb, mock := redismock.NewClientMock()
+	cache := redisCache[string]{db: rdb, prefix: prefix, ttl: time.Hour, sanitizer: RedisKeySanitizer(16), keys: newKeyDigestCache(10)}
+	pattern := "user:profile:[a-z]* = long pattern"
+
+	mock.ExpectScan(0, prefix+":"+pattern, scanBatchSize).SetVal([]string{prefix + ":user:profile:a"}, 0)
+
+	keys, err := cache.Scan(ctx, pattern, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:profile:a"}, keys)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.False(t, cache.keys.keys.Contains(pattern))
+}
+
+// TestRedisCache_BulkSet verifies that every entry is written under its prefixed key with the TTL of the cache.
+func TestRedisCache_BulkSet(t *testing.T) {
+	ctx := context.TODO()
+	const prefix = "test"
+	rdb, mock := redismock.NewClientMock()
+	mock.MatchExpectationsInOrder(false)
+	cache := redisCache[string]{db: rdb, prefix: prefix, ttl: time.Hour}
+
+	mock.ExpectSet(prefix+":a", []byte(`"1"`), time.Hour).SetVal("OK")
+	mock.ExpectSet(prefix+":b", []byte(`"2"`), time.Hour).SetVal("OK")
+
+	err := BulkSet[string](ctx, &cache, map[string]string{"a": "1", "b": "2"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRedisCache_BulkGet verifies that keys are read with a single MGET and that missing keys are left out.
+func TestRedisCache_BulkGet(t *testing.T) {
+	ctx := context.TODO()
+	const prefix = "test"
+	rdb, mock := redismock.NewClientMock()
+	cache := redisCache[string]{db: rdb, prefix: prefix, ttl: time.Hour}
+
+	mock.ExpectMGet(prefix+":a", prefix+":b", prefix+":c").SetVal([]any{`"1"`, nil, `"3"`})
+
+	values, err := BulkGet[string](ctx, &cache, []string{"a", "b", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "c": "3"}, values)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisCache_Take(t *testing.T) {
+	ctx := context.TODO()
+	const prefix = "test"
+	rdb, mock := redismock.NewClientMock()
+	cache := redisCache[string]{db: rdb, prefix: prefix, ttl: time.Hour}
+
+	mock.ExpectGetDel(prefix + ":token").SetVal(`"secret"`)
+	mock.ExpectGetDel(prefix + ":token").RedisNil()
+
+	value, exists, err := cache.Take(ctx, "token")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "secret", value)
+
+	_, exists, err = cache.Take(ctx, "token")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedisCache_PopulateIfAbsent(t *testing.T) {
+	ctx := context.TODO()
+	const prefix = "test"
+	rdb, mock := redismock.NewClientMock()
+	cache := redisCache[string]{db: rdb, prefix: prefix, ttl: time.Hour}
+
+	mock.ExpectSetNX(prefix+":seed", []byte(`"v1"`), time.Hour).SetVal(true)
+	mock.ExpectSetNX(prefix+":seed", []byte(`"v2"`), time.Hour).SetVal(false)
+
+	written, err := cache.PopulateIfAbsent(ctx, "seed", "v1")
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	written, err = cache.PopulateIfAbsent(ctx, "seed", "v2")
+	assert.NoError(t, err)
+	assert.False(t, written)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRedisCache_LockStats verifies that lock attempts, contention, expirations and metrics are recorded.
+func TestRedisCache_LockStats(t *testing.T) {
+	ctx := context.TODO()
+	rdb, mock := redismock.NewClientMock()
+	metrics := NewMemoryMetrics()
+	cache := NewStaleWhileRevalidateRedisCache[string](rdb, "test", time.Hour, WithLockMetrics(metrics))
+
+	mock.ExpectSetNX("test:lock:k", "a", time.Second).SetVal(true)
+	mock.ExpectSetNX("test:lock:k", "b", time.Second).SetVal(false)
+	mock.ExpectGet("test:lock:k").SetVal("a")
+	mock.ExpectGet("test:lock:k").SetVal("a")
+	mock.ExpectDel("test:lock:k").SetVal(1)
+	mock.ExpectGet("test:lock:k").RedisNil()
+
+	acquired, err := cache.TryAcquireRefreshLock(ctx, "k", "a", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = cache.TryAcquireRefreshLock(ctx, "k", "b", time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	require.NoError(t, cache.ReleaseRefreshLock(ctx, "k", "a"))
+	require.NoError(t, cache.ReleaseRefreshLock(ctx, "k", "a"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	stats, ok := Locks(cache)
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), stats.Attempts)
+	assert.Equal(t, uint64(1), stats.Acquired)
+	assert.Equal(t, uint64(1), stats.Contended)
+	assert.Equal(t, uint64(1), stats.Expired)
+	assert.Equal(t, 1.0, metrics.Counter(MetricLockAttempts, map[string]string{"backend": "redis", "result": "contended"}))
+	assert.Equal(t, 1.0, metrics.Counter(MetricLockExpirations, map[string]string{"backend": "redis", "reason": "release"}))
+	assert.Len(t, metrics.Durations(MetricLockHoldDuration, map[string]string{"backend": "redis"}), 1)
+}
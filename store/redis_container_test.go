@@ -0,0 +1,60 @@
+//go:build !miniredis
+
+package store
+
+import (
+	"context"
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// redisContainer represents a Redis container instance used for testing with testcontainers.
+// It includes the container instance, host address, and port number.
+type redisContainer struct {
+	Container testcontainers.Container
+	Host      string
+	Port      string
+}
+
+// setupRedisForTest creates and starts a Redis container for testing, returning its host and port information.
+func setupRedisForTest(ctx context.Context) (*redisContainer, error) {
+	port := "6379"
+	proto := "tcp"
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7",
+		ExposedPorts: []string{port + "/" + proto},
+		WaitingFor:   wait.ForLog("Ready to accept connections"),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	var redisC *redisContainer
+	if container != nil {
+		redisC = &redisContainer{
+			Container: container,
+		}
+	}
+	if err != nil {
+		return redisC, err
+	}
+	host, err := container.Host(ctx)
+	if err != nil {
+		return redisC, err
+	}
+	redisC.Host = host
+
+	natP, err := nat.NewPort(proto, port)
+	if err != nil {
+		return redisC, err
+	}
+	mappedPort, err := container.MappedPort(ctx, natP)
+	if err != nil {
+		return redisC, err
+	}
+	redisC.Port = mappedPort.Port()
+
+	return redisC, err
+
+}
@@ -0,0 +1,90 @@
+//go:build miniredis
+
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// embeddedClockInterval is how often EmbeddedRedis advances the clock of the embedded server.
+const embeddedClockInterval = 10 * time.Millisecond
+
+// EmbeddedRedis is an in-process Redis server, backed by miniredis, for local development and CI runs without Docker.
+// It is only available with the miniredis build tag:
+//
+//	go test -tags miniredis ./...
+//
+// The stores created on Client, with NewRedisCache or NewStaleWhileRevalidateRedisCache, behave as on a real server,
+// refresh locks included. The clock of the server follows the wall clock, so entries and locks expire after their TTL.
+type EmbeddedRedis struct {
+	Server *miniredis.Miniredis
+	Client *redis.Client
+	stop   chan struct{}
+	done   sync.WaitGroup
+}
+
+// StartEmbeddedRedis starts an embedded Redis server listening on a random local port and connects a client to it.
+func StartEmbeddedRedis() (*EmbeddedRedis, error) {
+	server, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+	e := &EmbeddedRedis{
+		Server: server,
+		Client: redis.NewClient(&redis.Options{Addr: server.Addr()}),
+		stop:   make(chan struct{}),
+	}
+	e.done.Add(1)
+	go e.tick()
+	return e, nil
+}
+
+// NewEmbeddedRedisCache starts an embedded Redis server and returns a Redis store on it, along with the function
+// stopping the server.
+func NewEmbeddedRedisCache[T any](prefix string, ttl time.Duration, opts ...Option) (Cacher[T], func() error, error) {
+	e, err := StartEmbeddedRedis()
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewRedisCache[T](e.Client, prefix, ttl, opts...), e.Close, nil
+}
+
+// NewStaleWhileRevalidateEmbeddedRedisCache starts an embedded Redis server and returns a stale-while-revalidate
+// Redis store on it, along with the function stopping the server.
+func NewStaleWhileRevalidateEmbeddedRedisCache[T any](prefix string, ttl time.Duration, opts ...Option) (StaleWhileRevalidateCache[T], func() error, error) {
+	e, err := StartEmbeddedRedis()
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewStaleWhileRevalidateRedisCache[T](e.Client, prefix, ttl, opts...), e.Close, nil
+}
+
+// Close disconnects the client and stops the server.
+func (e *EmbeddedRedis) Close() error {
+	close(e.stop)
+	e.done.Wait()
+	err := e.Client.Close()
+	e.Server.Close()
+	return err
+}
+
+// tick advances the clock of the server, which does not expire keys on its own, along with the wall clock.
+func (e *EmbeddedRedis) tick() {
+	defer e.done.Done()
+	ticker := time.NewTicker(embeddedClockInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case now := <-ticker.C:
+			e.Server.FastForward(now.Sub(last))
+			last = now
+		}
+	}
+}
@@ -0,0 +1,27 @@
+//go:build miniredis
+
+package store
+
+import (
+	"context"
+	"github.com/testcontainers/testcontainers-go"
+	"net"
+)
+
+// redisContainer holds the address of the Redis server used by the integration tests. With the miniredis build tag
+// the server is embedded and Container is nil.
+type redisContainer struct {
+	Container testcontainers.Container
+	Host      string
+	Port      string
+}
+
+// setupRedisForTest starts an embedded Redis server, running until the test binary exits, in place of a container.
+func setupRedisForTest(_ context.Context) (*redisContainer, error) {
+	e, err := StartEmbeddedRedis()
+	if err != nil {
+		return &redisContainer{}, err
+	}
+	host, port, err := net.SplitHostPort(e.Server.Addr())
+	return &redisContainer{Host: host, Port: port}, err
+}
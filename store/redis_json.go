@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// jsonPathIdentifier matches the path segments usable with the dot notation of JSONPath.
+var jsonPathIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FieldSetter is implemented by caches able to update a single field of a stored document in place, without
+// reading and rewriting the whole value. Path has the syntax of FieldGetter and value is encoded as JSON. Setting a
+// field of a missing key is an error, and the expiry of the key is left unchanged.
+type FieldSetter interface {
+	SetField(ctx context.Context, key string, path string, value any) error
+}
+
+// SetField updates the field at path of the value stored under key, returning ErrNotSupported if the cache does not
+// implement FieldSetter.
+func SetField(ctx context.Context, c any, key string, path string, value any) error {
+	setter, ok := c.(FieldSetter)
+	if !ok {
+		return ErrNotSupported
+	}
+	return setter.SetField(ctx, key, path, value)
+}
+
+// redisJSONCache stores values as RedisJSON documents, so that single fields can be read and updated on the server.
+// Keys, TTLs and refresh locks are handled as by redisCache, which it delegates them to.
+type redisJSONCache[T any] struct {
+	base *redisCache[T]
+}
+
+// NewRedisJSONCache creates a Redis store keeping values as documents of the RedisJSON module, which the server
+// must provide. Besides the operations of NewRedisCache, it implements FieldGetter, reading a field with JSON.GET,
+// and FieldSetter, updating one in place with JSON.SET. Values are always encoded as JSON: the codec option does not
+// apply, and paths use the field names of the JSON encoding.
+func NewRedisJSONCache[T any](db *redis.Client, prefix string, ttl time.Duration, opts ...Option) Cacher[T] {
+	return newRedisJSONCache[T](db, prefix, ttl, opts)
+}
+
+// NewStaleWhileRevalidateRedisJSONCache creates a stale-while-revalidate store keeping values as RedisJSON
+// documents. Paths of FieldGetter and FieldSetter start at the stale value, such as "Value.address.city".
+func NewStaleWhileRevalidateRedisJSONCache[T any](db *redis.Client, prefix string, ttl time.Duration, opts ...Option) StaleWhileRevalidateCache[T] {
+	return newRedisJSONCache[StaleValue[T]](db, prefix, ttl, opts)
+}
+
+// newRedisJSONCache creates the RedisJSON store with the given options.
+func newRedisJSONCache[T any](db *redis.Client, prefix string, ttl time.Duration, opts []Option) *redisJSONCache[T] {
+	o := newStoreOptions(opts)
+	return &redisJSONCache[T]{base: &redisCache[T]{
+		db:        db,
+		prefix:    prefix,
+		ttl:       ttl,
+		codec:     JSONCodec{},
+		timeouts:  o.timeouts,
+		sanitizer: o.sanitizer,
+		locks:     newLockCounters("redisjson", o.lockSink),
+		serde:     o.serde,
+		keys:      newKeyDigestCache(o.keyCache),
+	}}
+}
+
+// Get retrieves the document stored under the key with JSON.GET.
+func (r *redisJSONCache[T]) Get(ctx context.Context, k string) (T, bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.base.timeouts.get)
+	defer cancel()
+	var value T
+	key := r.base.buildKey(k)
+	result, err := r.base.db.JSONGet(ctx, key).Result()
+	if errors.Is(err, redis.Nil) || (err == nil && result == "") {
+		return value, false, nil
+	}
+	if err != nil {
+		return value, false, err
+	}
+	if err := json.Unmarshal([]byte(result), &value); err != nil {
+		var emptyValue T
+		return emptyValue, false, r.base.serde.unmarshalFailed(k, err, func() error {
+			return r.base.db.Del(ctx, key).Err()
+		})
+	}
+	return value, true, nil
+}
+
+// Set stores the value as the document of the key with the cache TTL.
+func (r *redisJSONCache[T]) Set(ctx context.Context, k string, value T) error {
+	return r.SetWithTTL(ctx, k, value, r.base.ttl)
+}
+
+// SetWithTTL replaces the document of the key and sets its expiry in a single transaction. A non-positive ttl
+// stores the document without expiry.
+func (r *redisJSONCache[T]) SetWithTTL(ctx context.Context, k string, value T, ttl time.Duration) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.base.timeouts.set)
+	defer cancel()
+	key := r.base.buildKey(k)
+	data, err := json.Marshal(value)
+	if err != nil {
+		return r.base.serde.marshalFailed(k, err, func() error {
+			return r.base.db.Del(ctx, key).Err()
+		})
+	}
+	_, err = r.base.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.JSONSet(ctx, key, "$", data)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		} else {
+			pipe.Persist(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// PopulateIfAbsent stores the document only when the key is missing, with JSON.SET NX, giving it the cache TTL.
+func (r *redisJSONCache[T]) PopulateIfAbsent(ctx context.Context, k string, value T) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.base.timeouts.set)
+	defer cancel()
+	key := r.base.buildKey(k)
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, r.base.serde.marshalFailed(k, err, nil)
+	}
+	var written *redis.StatusCmd
+	_, err = r.base.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		written = pipe.JSONSetMode(ctx, key, "$", data, "NX")
+		if r.base.ttl > 0 {
+			// EXPIRE NX only applies to the key just created, documents already stored keeping their expiry.
+			pipe.ExpireNX(ctx, key, r.base.ttl)
+		}
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return written.Val() == "OK", nil
+}
+
+// GetField reads the field at path of the document with JSON.GET, without transferring the rest of the document.
+func (r *redisJSONCache[T]) GetField(ctx context.Context, k string, path string, dst any) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, r.base.timeouts.get)
+	defer cancel()
+	result, err := r.base.db.JSONGet(ctx, r.base.buildKey(k), jsonPath(path)).Result()
+	if errors.Is(err, redis.Nil) || (err == nil && result == "") {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var matches []json.RawMessage
+	if err := json.Unmarshal([]byte(result), &matches); err != nil {
+		return false, err
+	}
+	if len(matches) == 0 {
+		return true, fmt.Errorf("%w: %s", ErrFieldNotFound, path)
+	}
+	return true, json.Unmarshal(matches[0], dst)
+}
+
+// SetField updates the field at path of the document in place with JSON.SET, keeping the expiry of the key.
+func (r *redisJSONCache[T]) SetField(ctx context.Context, k string, path string, value any) error {
+	ctx, cancel := withDefaultTimeout(ctx, r.base.timeouts.set)
+	defer cancel()
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.base.db.JSONSet(ctx, r.base.buildKey(k), jsonPath(path), data).Err()
+}
+
+// Delete removes the document of the key.
+func (r *redisJSONCache[T]) Delete(ctx context.Context, k string) error {
+	return r.base.Delete(ctx, k)
+}
+
+// Scan returns up to limit cache keys matching the glob-style pattern, see the Redis store.
+func (r *redisJSONCache[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	return r.base.Scan(ctx, pattern, limit)
+}
+
+// TTL returns the remaining time-to-live of the key.
+func (r *redisJSONCache[T]) TTL(ctx context.Context, k string) (time.Duration, bool, error) {
+	return r.base.TTL(ctx, k)
+}
+
+// TryAcquireRefreshLock acquires the refresh lock of the key as the Redis store does.
+func (r *redisJSONCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	return r.base.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+}
+
+// ReleaseRefreshLock releases the refresh lock of the key as the Redis store does.
+func (r *redisJSONCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	return r.base.ReleaseRefreshLock(ctx, key, randValue)
+}
+
+// LockStats returns the refresh lock statistics of this store.
+func (r *redisJSONCache[T]) LockStats() LockStats {
+	return r.base.LockStats()
+}
+
+// jsonPath converts a dot-separated field path into a JSONPath expression, numeric segments becoming array indexes
+// and segments that are not identifiers being quoted.
+func jsonPath(path string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	if path == "" {
+		return b.String()
+	}
+	for _, segment := range strings.Split(path, ".") {
+		switch {
+		case jsonPathIdentifier.MatchString(segment):
+			b.WriteString("." + segment)
+		case isIndex(segment):
+			b.WriteString("[" + segment + "]")
+		default:
+			b.WriteString("[" + strconv.Quote(segment) + "]")
+		}
+	}
+	return b.String()
+}
+
+// isIndex reports whether the path segment is an array index.
+func isIndex(segment string) bool {
+	i, err := strconv.Atoi(segment)
+	return err == nil && i >= 0
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedisJSON is a go-redis hook answering the commands of the RedisJSON store from memory, standing for a server
+// with the RedisJSON module. It supports the JSONPath subset produced by jsonPath.
+type fakeRedisJSON struct {
+	mu   sync.Mutex
+	docs map[string]any
+	ttls map[string]time.Duration
+}
+
+// newFakeRedisJSON returns a client whose commands are answered by a fakeRedisJSON.
+func newFakeRedisJSON() (*redis.Client, *fakeRedisJSON) {
+	fake := &fakeRedisJSON{docs: make(map[string]any), ttls: make(map[string]time.Duration)}
+	db := redis.NewClient(&redis.Options{Addr: "fake:6379"})
+	db.AddHook(fake)
+	return db, fake
+}
+
+// DialHook leaves dialing untouched; no connection is ever needed.
+func (f *fakeRedisJSON) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) { return next(ctx, network, addr) }
+}
+
+// ProcessHook answers single commands.
+func (f *fakeRedisJSON) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(_ context.Context, cmd redis.Cmder) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.process(cmd)
+		return cmd.Err()
+	}
+}
+
+// ProcessPipelineHook answers pipelines and transactions, the first failing command failing the whole pipeline.
+func (f *fakeRedisJSON) ProcessPipelineHook(redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(_ context.Context, cmds []redis.Cmder) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, cmd := range cmds {
+			f.process(cmd)
+		}
+		for _, cmd := range cmds {
+			if cmd.Err() != nil {
+				return cmd.Err()
+			}
+		}
+		return nil
+	}
+}
+
+// process runs the command against the documents.
+func (f *fakeRedisJSON) process(cmd redis.Cmder) {
+	args := cmd.Args()
+	key := func() string { return args[1].(string) }
+	switch strings.ToLower(cmd.Name()) {
+	case "multi", "exec":
+	case "json.get":
+		doc, ok := f.docs[key()]
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		if len(args) == 2 {
+			data, _ := json.Marshal(doc)
+			cmd.(*redis.JSONCmd).SetVal(string(data))
+			return
+		}
+		matches := []any{}
+		if v, ok := fakeLookup(doc, args[2].(string)); ok {
+			matches = append(matches, v)
+		}
+		data, _ := json.Marshal(matches)
+		cmd.(*redis.JSONCmd).SetVal(string(data))
+	case "json.set":
+		var value any
+		_ = json.Unmarshal([]byte(args[3].(string)), &value)
+		path := args[2].(string)
+		_, exists := f.docs[key()]
+		if len(args) == 5 && args[4] == "NX" && exists {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		if path == "$" {
+			f.docs[key()] = value
+		} else if !exists || !fakeAssign(f.docs[key()], path, value) {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		cmd.(*redis.StatusCmd).SetVal("OK")
+	case "expire":
+		if len(args) == 4 && f.ttls[key()] > 0 {
+			cmd.(*redis.BoolCmd).SetVal(false)
+			return
+		}
+		f.ttls[key()] = time.Duration(args[2].(int64)) * time.Second
+		cmd.(*redis.BoolCmd).SetVal(true)
+	case "persist":
+		delete(f.ttls, key())
+		cmd.(*redis.BoolCmd).SetVal(true)
+	case "del":
+		delete(f.docs, key())
+		delete(f.ttls, key())
+		cmd.(*redis.IntCmd).SetVal(1)
+	default:
+		cmd.SetErr(redis.Nil)
+	}
+}
+
+// fakePath splits a JSONPath of the form $.a[0]["b c"] into its segments.
+func fakePath(path string) []string {
+	path = strings.TrimPrefix(path, "$")
+	var segments []string
+	for path != "" {
+		switch {
+		case strings.HasPrefix(path, "."):
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+			segments, path = append(segments, path[1:end+1]), path[end+1:]
+		case strings.HasPrefix(path, `["`):
+			end := strings.Index(path, `"]`)
+			unquoted, _ := strconv.Unquote(path[1 : end+1])
+			segments, path = append(segments, unquoted), path[end+2:]
+		default:
+			end := strings.Index(path, "]")
+			segments, path = append(segments, path[1:end]), path[end+1:]
+		}
+	}
+	return segments
+}
+
+// fakeLookup returns the value of the document at path.
+func fakeLookup(doc any, path string) (any, bool) {
+	for _, segment := range fakePath(path) {
+		switch node := doc.(type) {
+		case map[string]any:
+			var ok bool
+			if doc, ok = node[segment]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i >= len(node) {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// fakeAssign sets the object member at path, reporting whether its parent exists.
+func fakeAssign(doc any, path string, value any) bool {
+	segments := fakePath(path)
+	parent, ok := fakeLookup(doc, "$"+strings.Join(quoteAll(segments[:len(segments)-1]), ""))
+	object, isObject := parent.(map[string]any)
+	if !ok || !isObject {
+		return false
+	}
+	object[segments[len(segments)-1]] = value
+	return true
+}
+
+// quoteAll turns segments back into bracketed JSONPath segments.
+func quoteAll(segments []string) []string {
+	quoted := make([]string, len(segments))
+	for i, s := range segments {
+		quoted[i] = "[" + strconv.Quote(s) + "]"
+	}
+	return quoted
+}
+
+// jsonProfile is the document stored in the RedisJSON tests.
+type jsonProfile struct {
+	Name    string            `json:"name"`
+	Visits  int               `json:"visits"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+// TestJSONPath verifies the conversion of field paths into JSONPath expressions.
+func TestJSONPath(t *testing.T) {
+	assert.Equal(t, "$", jsonPath(""))
+	assert.Equal(t, "$.address.city", jsonPath("address.city"))
+	assert.Equal(t, "$.items[0].id", jsonPath("items.0.id"))
+	assert.Equal(t, `$.labels["team-a"]`, jsonPath("labels.team-a"))
+}
+
+// TestRedisJSONCache verifies whole-document operations, field projection and in-place field updates.
+func TestRedisJSONCache(t *testing.T) {
+	ctx := context.Background()
+	db, fake := newFakeRedisJSON()
+	c := NewRedisJSONCache[jsonProfile](db, "profiles", time.Hour)
+
+	profile := jsonProfile{Name: "ada", Visits: 1, Tags: []string{"admin"}, Labels: map[string]string{"team-a": "core"}}
+	profile.Address.City = "Turin"
+	require.NoError(t, c.Set(ctx, "u1", profile))
+	assert.Equal(t, time.Hour, fake.ttls["profiles:u1"])
+
+	stored, exists, err := c.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, profile, stored)
+
+	city, found, err := GetField[string](ctx, c, "u1", "address.city")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Turin", city)
+	tag, _, err := GetField[string](ctx, c, "u1", "tags.0")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", tag)
+	team, _, err := GetField[string](ctx, c, "u1", "labels.team-a")
+	require.NoError(t, err)
+	assert.Equal(t, "core", team)
+	_, _, err = GetField[string](ctx, c, "u1", "missing")
+	assert.ErrorIs(t, err, ErrFieldNotFound)
+	_, found, err = GetField[string](ctx, c, "absent", "name")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, SetField(ctx, c, "u1", "visits", 2))
+	require.NoError(t, SetField(ctx, c, "u1", "address.city", "Milan"))
+	stored, _, _ = c.Get(ctx, "u1")
+	assert.Equal(t, 2, stored.Visits)
+	assert.Equal(t, "Milan", stored.Address.City)
+	assert.Equal(t, time.Hour, fake.ttls["profiles:u1"], "partial updates keep the expiry")
+
+	written, err := PopulateIfAbsent(ctx, c, "u1", jsonProfile{Name: "other"})
+	require.NoError(t, err)
+	assert.False(t, written)
+	written, err = PopulateIfAbsent(ctx, c, "u2", jsonProfile{Name: "bob"})
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	require.NoError(t, Delete(ctx, c, "u1"))
+	_, exists, err = c.Get(ctx, "u1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.ErrorIs(t, SetField(ctx, NewLRUCache[jsonProfile](1), "u1", "visits", 3), ErrNotSupported)
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrInvalidStreamConfig is returned by Consume when the stream, the group or the consumer name is missing.
+var ErrInvalidStreamConfig = errors.New("redis stream transport requires a stream, a group and a consumer name")
+
+// RedisStreamConfig configures a RedisStreamTransport. Stream and Group are required; Consumer names this process
+// within the group, is required to consume and must be unique among running workers. Block is how long a read waits
+// for new requests (5s by default) and Count how many requests are read at once (10 by default). MaxLen, when
+// positive, approximately caps the stream length. Requests left unacknowledged for longer than ClaimIdle (30s by
+// default), by a failed handler or a consumer that stopped, are claimed and delivered again, up to MaxDeliveries
+// times in total (5 by default). A request claimed once more is a poison request: it is acknowledged without being
+// handled and, when DeadLetterStream is set, appended to that stream for inspection.
+type RedisStreamConfig struct {
+	Stream           string
+	Group            string
+	Consumer         string
+	Block            time.Duration
+	Count            int64
+	MaxLen           int64
+	ClaimIdle        time.Duration
+	MaxDeliveries    int64
+	DeadLetterStream string
+}
+
+// RedisStreamTransport is a RefreshTransport backed by a Redis Stream with a consumer group, giving Redis-only
+// deployments durable, acknowledged distributed refresh.
+type RedisStreamTransport struct {
+	db  *redis.Client
+	cfg RedisStreamConfig
+}
+
+// NewRedisStreamTransport creates a transport publishing to and consuming from the configured stream.
+func NewRedisStreamTransport(db *redis.Client, cfg RedisStreamConfig) *RedisStreamTransport {
+	if cfg.Block <= 0 {
+		cfg.Block = 5 * time.Second
+	}
+	if cfg.Count <= 0 {
+		cfg.Count = 10
+	}
+	if cfg.ClaimIdle <= 0 {
+		cfg.ClaimIdle = 30 * time.Second
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = 5
+	}
+	return &RedisStreamTransport{db: db, cfg: cfg}
+}
+
+// Publish appends the request to the stream.
+func (r *RedisStreamTransport) Publish(ctx context.Context, req RefreshRequest) error {
+	if req.EnqueuedAt.IsZero() {
+		req.EnqueuedAt = time.Now()
+	}
+	args := &redis.XAddArgs{
+		Stream: r.cfg.Stream,
+		Values: []any{
+			"key", req.Key,
+			"requestId", req.RequestID,
+			"observedAt", strconv.FormatInt(unixNano(req.ObservedAt), 10),
+			"enqueuedAt", strconv.FormatInt(unixNano(req.EnqueuedAt), 10),
+		},
+	}
+	if r.cfg.MaxLen > 0 {
+		args.MaxLen = r.cfg.MaxLen
+		args.Approx = true
+	}
+	return r.db.XAdd(ctx, args).Err()
+}
+
+// Consume creates the consumer group if needed and delivers the requests of the stream to handler until ctx is done,
+// acknowledging those handled without error. A group created by Consume starts at the beginning of the stream, so
+// requests published before the first consumer started are delivered too. Requests without a key and poison requests
+// are acknowledged without being handled. Read errors are logged and retried after Block.
+func (r *RedisStreamTransport) Consume(ctx context.Context, handler func(ctx context.Context, req RefreshRequest) error) error {
+	if r.cfg.Stream == "" || r.cfg.Group == "" || r.cfg.Consumer == "" {
+		return ErrInvalidStreamConfig
+	}
+	err := r.db.XGroupCreateMkStream(ctx, r.cfg.Stream, r.cfg.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	for ctx.Err() == nil {
+		messages, claimed, err := r.read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			slog.Warn("Cannot read refresh requests from stream", slog.String("stream", r.cfg.Stream), slog.String("error", err.Error()))
+			select {
+			case <-ctx.Done():
+			case <-time.After(r.cfg.Block):
+			}
+			continue
+		}
+		for _, message := range messages {
+			req := parseRefreshRequest(message)
+			switch {
+			case req.Key == "":
+				slog.Warn("Dropping refresh request without a key", slog.String("stream", r.cfg.Stream), slog.String("id", message.ID))
+			case claimed && r.poisoned(ctx, message):
+				r.park(ctx, message)
+			default:
+				if err := handler(ctx, req); err != nil {
+					slog.Warn("Refresh request failed, left pending", slog.String("stream", r.cfg.Stream), slog.String("id", message.ID), slog.String("error", err.Error()))
+					continue
+				}
+			}
+			r.ack(ctx, message.ID)
+		}
+	}
+	return nil
+}
+
+// ack acknowledges the request, logging failures.
+func (r *RedisStreamTransport) ack(ctx context.Context, id string) {
+	if err := r.db.XAck(context.WithoutCancel(ctx), r.cfg.Stream, r.cfg.Group, id).Err(); err != nil {
+		slog.Warn("Cannot acknowledge refresh request", slog.String("stream", r.cfg.Stream), slog.String("id", id), slog.String("error", err.Error()))
+	}
+}
+
+// poisoned reports whether the claimed request has been delivered more than MaxDeliveries times. A request whose
+// delivery count cannot be read is not considered poisoned.
+func (r *RedisStreamTransport) poisoned(ctx context.Context, message redis.XMessage) bool {
+	pending, err := r.db.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: r.cfg.Stream,
+		Group:  r.cfg.Group,
+		Start:  message.ID,
+		End:    message.ID,
+		Count:  1,
+	}).Result()
+	if err != nil {
+		slog.Warn("Cannot read refresh request deliveries", slog.String("stream", r.cfg.Stream), slog.String("id", message.ID), slog.String("error", err.Error()))
+		return false
+	}
+	return len(pending) > 0 && pending[0].RetryCount > r.cfg.MaxDeliveries
+}
+
+// park appends the poison request to DeadLetterStream, when set, along with its original ID.
+func (r *RedisStreamTransport) park(ctx context.Context, message redis.XMessage) {
+	slog.Warn("Dropping refresh request delivered too many times", slog.String("stream", r.cfg.Stream), slog.String("id", message.ID), slog.Int64("maxDeliveries", r.cfg.MaxDeliveries))
+	if r.cfg.DeadLetterStream == "" {
+		return
+	}
+	values := []any{"id", message.ID}
+	for _, name := range []string{"key", "requestId", "observedAt", "enqueuedAt"} {
+		if value, ok := message.Values[name]; ok {
+			values = append(values, name, value)
+		}
+	}
+	err := r.db.XAdd(context.WithoutCancel(ctx), &redis.XAddArgs{Stream: r.cfg.DeadLetterStream, Values: values}).Err()
+	if err != nil {
+		slog.Warn("Cannot park refresh request", slog.String("stream", r.cfg.DeadLetterStream), slog.String("id", message.ID), slog.String("error", err.Error()))
+	}
+}
+
+// read returns the requests idle for longer than ClaimIdle, reporting that they were claimed, or else the next new
+// requests.
+func (r *RedisStreamTransport) read(ctx context.Context) ([]redis.XMessage, bool, error) {
+	claimed, _, err := r.db.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   r.cfg.Stream,
+		Group:    r.cfg.Group,
+		Consumer: r.cfg.Consumer,
+		MinIdle:  r.cfg.ClaimIdle,
+		Start:    "0-0",
+		Count:    r.cfg.Count,
+	}).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(claimed) > 0 {
+		return claimed, true, nil
+	}
+	streams, err := r.db.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    r.cfg.Group,
+		Consumer: r.cfg.Consumer,
+		Streams:  []string{r.cfg.Stream, ">"},
+		Count:    r.cfg.Count,
+		Block:    r.cfg.Block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil || len(streams) == 0 {
+		return nil, false, err
+	}
+	return streams[0].Messages, false, nil
+}
+
+// parseRefreshRequest decodes a stream entry written by Publish.
+func parseRefreshRequest(message redis.XMessage) RefreshRequest {
+	field := func(name string) string {
+		value, _ := message.Values[name].(string)
+		return value
+	}
+	return RefreshRequest{
+		Key:        field("key"),
+		RequestID:  field("requestId"),
+		ObservedAt: parseUnixNano(field("observedAt")),
+		EnqueuedAt: parseUnixNano(field("enqueuedAt")),
+	}
+}
+
+// unixNano returns t in nanoseconds since the epoch, or 0 for the zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// parseUnixNano parses nanoseconds since the epoch, returning the zero time for 0 or invalid input.
+func parseUnixNano(s string) time.Time {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
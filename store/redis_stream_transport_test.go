@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisStreamTransport_Publish verifies that requests are appended to the capped stream.
+func TestRedisStreamTransport_Publish(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	transport := NewRedisStreamTransport(rdb, RedisStreamConfig{Stream: "refresh", Group: "workers", MaxLen: 1000})
+	observed := time.Unix(0, 1000)
+	enqueued := time.Unix(0, 2000)
+
+	mock.ExpectXAdd(&redis.XAddArgs{
+		Stream: "refresh",
+		MaxLen: 1000,
+		Approx: true,
+		Values: []any{"key", "k", "requestId", "r1", "observedAt", "1000", "enqueuedAt", "2000"},
+	}).SetVal("1-0")
+
+	err := transport.Publish(t.Context(), RefreshRequest{Key: "k", RequestID: "r1", ObservedAt: observed, EnqueuedAt: enqueued})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRedisStreamTransport_Consume verifies that requests are read through the consumer group and acknowledged only
+// when handled successfully.
+func TestRedisStreamTransport_Consume(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	transport := NewRedisStreamTransport(rdb, RedisStreamConfig{Stream: "refresh", Group: "workers", Consumer: "c1", Block: time.Second})
+
+	mock.ExpectXGroupCreateMkStream("refresh", "workers", "0").SetErr(errors.New("BUSYGROUP Consumer Group name already exists"))
+	expectNoClaim(mock)
+	mock.ExpectXReadGroup(&redis.XReadGroupArgs{
+		Group:    "workers",
+		Consumer: "c1",
+		Streams:  []string{"refresh", ">"},
+		Count:    10,
+		Block:    time.Second,
+	}).SetVal([]redis.XStream{{
+		Stream: "refresh",
+		Messages: []redis.XMessage{
+			{ID: "1-0", Values: map[string]any{"key": "ok", "requestId": "r1", "observedAt": strconv.Itoa(1000)}},
+			{ID: "2-0", Values: map[string]any{"key": "fail", "requestId": "r2"}},
+		},
+	}})
+	mock.ExpectXAck("refresh", "workers", "1-0").SetVal(1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var handled []RefreshRequest
+	err := transport.Consume(ctx, func(ctx context.Context, req RefreshRequest) error {
+		handled = append(handled, req)
+		if req.Key == "fail" {
+			cancel()
+			return assert.AnError
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, handled, 2)
+	assert.Equal(t, "ok", handled[0].Key)
+	assert.Equal(t, time.Unix(0, 1000), handled[0].ObservedAt)
+	assert.True(t, handled[1].ObservedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRedisStreamTransport_ConsumeClaimsPending verifies that requests left pending by failed handlers are claimed
+// and delivered again, and that a consumer name is required.
+func TestRedisStreamTransport_ConsumeClaimsPending(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	transport := NewRedisStreamTransport(rdb, RedisStreamConfig{Stream: "refresh", Group: "workers", Consumer: "c1"})
+
+	mock.ExpectXGroupCreateMkStream("refresh", "workers", "0").SetVal("OK")
+	mock.ExpectXAutoClaim(&redis.XAutoClaimArgs{
+		Stream:   "refresh",
+		Group:    "workers",
+		Consumer: "c1",
+		MinIdle:  30 * time.Second,
+		Start:    "0-0",
+		Count:    10,
+	}).SetVal([]redis.XMessage{{ID: "1-0", Values: map[string]any{"key": "retried"}}}, "0-0")
+	expectDeliveries(mock, "1-0", 2)
+	mock.ExpectXAck("refresh", "workers", "1-0").SetVal(1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	err := transport.Consume(ctx, func(ctx context.Context, req RefreshRequest) error {
+		assert.Equal(t, "retried", req.Key)
+		cancel()
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	unnamed := NewRedisStreamTransport(rdb, RedisStreamConfig{Stream: "refresh", Group: "workers"})
+	err = unnamed.Consume(t.Context(), func(ctx context.Context, req RefreshRequest) error { return nil })
+	assert.ErrorIs(t, err, ErrInvalidStreamConfig)
+}
+
+// TestRedisStreamTransport_ConsumeDropsPoison verifies that requests without a key and requests delivered more than
+// MaxDeliveries times are acknowledged without being handled, the latter being parked in the dead letter stream.
+func TestRedisStreamTransport_ConsumeDropsPoison(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	transport := NewRedisStreamTransport(rdb, RedisStreamConfig{Stream: "refresh", Group: "workers", Consumer: "c1", DeadLetterStream: "refresh-dead"})
+
+	mock.ExpectXGroupCreateMkStream("refresh", "workers", "0").SetVal("OK")
+	mock.ExpectXAutoClaim(&redis.XAutoClaimArgs{
+		Stream:   "refresh",
+		Group:    "workers",
+		Consumer: "c1",
+		MinIdle:  30 * time.Second,
+		Start:    "0-0",
+		Count:    10,
+	}).SetVal([]redis.XMessage{
+		{ID: "1-0", Values: map[string]any{"key": "poison", "requestId": "r1"}},
+		{ID: "2-0"},
+		{ID: "3-0", Values: map[string]any{"key": "ok"}},
+	}, "0-0")
+	expectDeliveries(mock, "1-0", 6)
+	mock.ExpectXAdd(&redis.XAddArgs{Stream: "refresh-dead", Values: []any{"id", "1-0", "key", "poison", "requestId", "r1"}}).SetVal("1-0")
+	mock.ExpectXAck("refresh", "workers", "1-0").SetVal(1)
+	mock.ExpectXAck("refresh", "workers", "2-0").SetVal(1)
+	expectDeliveries(mock, "3-0", 5)
+	mock.ExpectXAck("refresh", "workers", "3-0").SetVal(1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var handled []string
+	err := transport.Consume(ctx, func(ctx context.Context, req RefreshRequest) error {
+		handled = append(handled, req.Key)
+		cancel()
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ok"}, handled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectDeliveries expects a lookup of the delivery count of the request, returning deliveries.
+func expectDeliveries(mock redismock.ClientMock, id string, deliveries int64) {
+	mock.ExpectXPendingExt(&redis.XPendingExtArgs{
+		Stream: "refresh",
+		Group:  "workers",
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).SetVal([]redis.XPendingExt{{ID: id, Consumer: "c1", RetryCount: deliveries}})
+}
+
+// expectNoClaim expects a claim of pending requests returning none, with the default settings of consumer c1.
+func expectNoClaim(mock redismock.ClientMock) {
+	mock.ExpectXAutoClaim(&redis.XAutoClaimArgs{
+		Stream:   "refresh",
+		Group:    "workers",
+		Consumer: "c1",
+		MinIdle:  30 * time.Second,
+		Start:    "0-0",
+		Count:    10,
+	}).SetVal(nil, "0-0")
+}
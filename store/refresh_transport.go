@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshRequest asks a worker to recompute a key. ObservedAt is the creation time of the stale value that triggered
+// the request, so a worker can skip requests already satisfied by a newer value.
+type RefreshRequest struct {
+	Key        string
+	RequestID  string
+	ObservedAt time.Time
+	EnqueuedAt time.Time
+}
+
+// RefreshTransport ships refresh requests between processes, so background refreshes survive restarts and are
+// spread over a pool of workers. Consume blocks until ctx is done, calling handler for every request; a request is
+// acknowledged when handler returns nil and delivered again later otherwise.
+type RefreshTransport interface {
+	Publish(ctx context.Context, req RefreshRequest) error
+	Consume(ctx context.Context, handler func(ctx context.Context, req RefreshRequest) error) error
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidSize is returned when a store is resized to a non-positive capacity.
+var ErrInvalidSize = errors.New("invalid cache size")
+
+// Resizer is implemented by in-memory stores whose capacity can be changed at runtime. Resize returns the number of
+// entries evicted to fit the new size; pinned entries are neither counted nor evicted.
+type Resizer interface {
+	Resize(size int) (evicted int)
+}
+
+// Resize changes the capacity of the store, returning the number of evicted entries. ErrNotSupported is returned if
+// the cache does not implement Resizer and ErrInvalidSize if size is not positive.
+func Resize(c any, size int) (int, error) {
+	resizer, ok := c.(Resizer)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidSize, size)
+	}
+	return resizer.Resize(size), nil
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResize verifies shrinking and growing the LRU stores, with pinned entries left untouched.
+func TestResize(t *testing.T) {
+	ctx := context.Background()
+	for _, cache := range []Cacher[int]{NewLRUCache[int](10), NewLRUExpirableCache[int](10, time.Hour)} {
+		require.NoError(t, Pin(cache, "pinned"))
+		require.NoError(t, cache.Set(ctx, "pinned", -1))
+		for i := range 10 {
+			require.NoError(t, cache.Set(ctx, fmt.Sprintf("k%d", i), i))
+		}
+
+		evicted, err := Resize(cache, 4)
+		require.NoError(t, err)
+		assert.Equal(t, 6, evicted)
+		size, _ := Len(cache)
+		assert.Equal(t, 5, size)
+		_, exists, err := cache.Get(ctx, "k9")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		_, exists, err = cache.Get(ctx, "pinned")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		evicted, err = Resize(cache, 20)
+		require.NoError(t, err)
+		assert.Zero(t, evicted)
+		_, err = Resize(cache, 0)
+		assert.ErrorIs(t, err, ErrInvalidSize)
+	}
+	_, err := Resize(NewSingleCache[int](time.Hour), 1)
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidRetention is returned when a retention namespace has no prefix, a non-positive maximum age or a prefix
+// declared twice.
+var ErrInvalidRetention = errors.New("invalid retention namespace")
+
+// RetentionNamespace declares that no entry whose key starts with Prefix may survive longer than MaxAge.
+type RetentionNamespace struct {
+	Name   string
+	Prefix string
+	MaxAge time.Duration
+}
+
+// RetentionAction describes what happened to an entry of a retention namespace.
+type RetentionAction string
+
+const (
+	// RetentionExpiryScheduled reports that an entry was written with a backend TTL bounded by the maximum age.
+	RetentionExpiryScheduled RetentionAction = "expiry_scheduled"
+	// RetentionDeleted reports that an entry older than the maximum age was deleted by a sweep or on read.
+	RetentionDeleted RetentionAction = "deleted"
+)
+
+// RetentionEvent is delivered to the audit hook of a RetentionCache. ExpiresAt is the time the entry expires from
+// the backend for RetentionExpiryScheduled and the time it became overdue for RetentionDeleted. Err is set when the
+// deletion failed and will be retried by the next sweep.
+type RetentionEvent struct {
+	Namespace string
+	Key       string
+	Action    RetentionAction
+	At        time.Time
+	ExpiresAt time.Time
+	Err       error
+}
+
+// RetentionConfig configures a RetentionCache. Interval, when positive, starts a background sweep at that period,
+// stopped by Close. OnEvent, when set, receives an audit event for every scheduled expiry and every deletion.
+type RetentionConfig struct {
+	Namespaces []RetentionNamespace
+	Interval   time.Duration
+	OnEvent    func(RetentionEvent)
+}
+
+// RetentionCache enforces GDPR-style retention on top of a store: entries of a declared namespace are written with a
+// backend TTL bounded by its maximum age when the store implements TTLSetter, are reported as misses and deleted
+// once older than the maximum age, and are deleted by sweeps. Write times are tracked in memory; entries written by
+// other processes are also swept when the store implements Scanner and the values carry their creation time, such
+// as StaleValue. Keys outside every namespace are passed through untouched.
+type RetentionCache[T any] struct {
+	inner      Cacher[T]
+	namespaces []RetentionNamespace
+	onEvent    func(RetentionEvent)
+
+	mu      sync.Mutex
+	written map[string]time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewRetentionCache wraps inner with the given retention namespaces. The store must implement Deleter or TTLSetter,
+// otherwise ErrNotSupported is returned.
+func NewRetentionCache[T any](inner Cacher[T], cfg RetentionConfig) (*RetentionCache[T], error) {
+	_, canDelete := inner.(Deleter)
+	_, canExpire := inner.(TTLSetter[T])
+	if !canDelete && !canExpire {
+		return nil, ErrNotSupported
+	}
+	seen := make(map[string]bool, len(cfg.Namespaces))
+	for _, ns := range cfg.Namespaces {
+		if ns.Prefix == "" || ns.MaxAge <= 0 || seen[ns.Prefix] {
+			return nil, ErrInvalidRetention
+		}
+		seen[ns.Prefix] = true
+	}
+	r := &RetentionCache[T]{
+		inner:      inner,
+		namespaces: cfg.Namespaces,
+		onEvent:    cfg.OnEvent,
+		written:    make(map[string]time.Time),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if cfg.Interval <= 0 {
+		close(r.done)
+		return r, nil
+	}
+	go r.run(cfg.Interval)
+	return r, nil
+}
+
+// Get reads the value from the store, deleting it and reporting a miss when it is older than the maximum age of its namespace.
+func (r *RetentionCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	value, exists, err := r.inner.Get(ctx, key)
+	if !exists || err != nil {
+		return value, exists, err
+	}
+	ns, ok := r.namespace(key)
+	if !ok {
+		return value, true, nil
+	}
+	if overdue, expiresAt := r.overdue(key, value, ns, time.Now()); overdue {
+		r.expire(ctx, ns, key, expiresAt)
+		var zeroValue T
+		return zeroValue, false, nil
+	}
+	return value, true, nil
+}
+
+// Set stores the value, with a TTL bounded by the maximum age of its namespace when the store supports per-entry TTLs.
+// The age of values carrying their creation time, such as StaleValue, counts from that time: a value already older
+// than the maximum age is not written and the entry is deleted instead.
+func (r *RetentionCache[T]) Set(ctx context.Context, key string, value T) error {
+	ns, ok := r.namespace(key)
+	if !ok {
+		return r.inner.Set(ctx, key, value)
+	}
+	now := time.Now()
+	at := now
+	if stamped, ok := any(value).(timestamped); ok && !stamped.createdAt().IsZero() && stamped.createdAt().Before(now) {
+		at = stamped.createdAt()
+	}
+	expiresAt := at.Add(ns.MaxAge)
+	ttl := expiresAt.Sub(now)
+	if ttl <= 0 {
+		return r.expire(ctx, ns, key, expiresAt)
+	}
+	setter, canExpire := r.inner.(TTLSetter[T])
+	var err error
+	if canExpire {
+		err = setter.SetWithTTL(ctx, key, value, ttl)
+	} else {
+		err = r.inner.Set(ctx, key, value)
+	}
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.written[key] = at
+	r.mu.Unlock()
+	if canExpire {
+		r.audit(RetentionEvent{Namespace: ns.Name, Key: key, Action: RetentionExpiryScheduled, At: now, ExpiresAt: expiresAt})
+	}
+	return nil
+}
+
+// Delete removes the key from the store.
+func (r *RetentionCache[T]) Delete(ctx context.Context, key string) error {
+	if err := Delete(ctx, r.inner, key); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	delete(r.written, key)
+	r.mu.Unlock()
+	return nil
+}
+
+// Scan enumerates the keys of the store when it implements Scanner.
+func (r *RetentionCache[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if scanner, ok := r.inner.(Scanner); ok {
+		return scanner.Scan(ctx, pattern, limit)
+	}
+	return nil, ErrNotSupported
+}
+
+// TryAcquireRefreshLock takes the refresh lock of the store when it supports refresh locks.
+func (r *RetentionCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	if locker, ok := r.inner.(RefreshLocker); ok {
+		return locker.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	}
+	return true, nil
+}
+
+// ReleaseRefreshLock releases the refresh lock of the store when it supports refresh locks.
+func (r *RetentionCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	if locker, ok := r.inner.(RefreshLocker); ok {
+		return locker.ReleaseRefreshLock(ctx, key, randValue)
+	}
+	return nil
+}
+
+// Sweep deletes every tracked entry older than the maximum age of its namespace and, when the store implements
+// Scanner and values carry their creation time, every such entry found in the store. Returns the number of
+// entries deleted.
+func (r *RetentionCache[T]) Sweep(ctx context.Context) (int, error) {
+	now := time.Now()
+	type overdueKey struct {
+		ns        RetentionNamespace
+		expiresAt time.Time
+	}
+	due := make(map[string]overdueKey)
+	r.mu.Lock()
+	for key, at := range r.written {
+		if ns, ok := r.namespace(key); ok && now.Sub(at) > ns.MaxAge {
+			due[key] = overdueKey{ns: ns, expiresAt: at.Add(ns.MaxAge)}
+		}
+	}
+	r.mu.Unlock()
+
+	if scanner, ok := r.inner.(Scanner); ok {
+		p, canPeek := r.inner.(peeker[T])
+		for _, ns := range r.namespaces {
+			keys, err := scanner.Scan(ctx, ns.Prefix+"*", 0)
+			if err != nil {
+				return 0, err
+			}
+			for _, key := range keys {
+				if _, ok := due[key]; ok {
+					continue
+				}
+				if owner, ok := r.namespace(key); !ok || owner.Prefix != ns.Prefix {
+					continue
+				}
+				var value T
+				var exists bool
+				if canPeek {
+					value, exists = p.peek(key)
+				} else {
+					value, exists, err = r.inner.Get(ctx, key)
+				}
+				stamped, isStamped := any(value).(timestamped)
+				if err != nil || !exists || !isStamped {
+					continue
+				}
+				if expiresAt := stamped.createdAt().Add(ns.MaxAge); now.After(expiresAt) {
+					due[key] = overdueKey{ns: ns, expiresAt: expiresAt}
+				}
+			}
+		}
+	}
+
+	deleted := 0
+	for key, d := range due {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		if r.expire(ctx, d.ns, key, d.expiresAt) == nil {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Close stops the background sweep, if any, and waits for an in-progress sweep to finish.
+func (r *RetentionCache[T]) Close() {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+// run sweeps the store at every interval until Close is called.
+func (r *RetentionCache[T]) run(interval time.Duration) {
+	defer close(r.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if deleted, err := r.Sweep(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("Cannot sweep retention namespaces", slog.String("error", err.Error()), slog.Int("deleted", deleted))
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// namespace returns the namespace with the longest prefix matching the key.
+func (r *RetentionCache[T]) namespace(key string) (RetentionNamespace, bool) {
+	var best RetentionNamespace
+	found := false
+	for _, ns := range r.namespaces {
+		if strings.HasPrefix(key, ns.Prefix) && len(ns.Prefix) > len(best.Prefix) {
+			best, found = ns, true
+		}
+	}
+	return best, found
+}
+
+// overdue reports whether the entry is older than the maximum age of its namespace, from its tracked write time or
+// the creation time carried by the value, and when it became overdue.
+func (r *RetentionCache[T]) overdue(key string, value T, ns RetentionNamespace, now time.Time) (bool, time.Time) {
+	r.mu.Lock()
+	at, tracked := r.written[key]
+	r.mu.Unlock()
+	if stamped, ok := any(value).(timestamped); ok && (!tracked || stamped.createdAt().Before(at)) {
+		at, tracked = stamped.createdAt(), true
+	}
+	if !tracked {
+		return false, time.Time{}
+	}
+	expiresAt := at.Add(ns.MaxAge)
+	return now.After(expiresAt), expiresAt
+}
+
+// expire deletes an overdue entry and audits the deletion, returning the error of a failed deletion. Failed deletions
+// stay tracked so the next sweep retries them.
+func (r *RetentionCache[T]) expire(ctx context.Context, ns RetentionNamespace, key string, expiresAt time.Time) error {
+	err := Delete(ctx, r.inner, key)
+	if errors.Is(err, ErrNotSupported) {
+		// The backend TTL set on write removes the entry.
+		err = nil
+	}
+	if err == nil {
+		r.mu.Lock()
+		delete(r.written, key)
+		r.mu.Unlock()
+	}
+	r.audit(RetentionEvent{Namespace: ns.Name, Key: key, Action: RetentionDeleted, At: time.Now(), ExpiresAt: expiresAt, Err: err})
+	return err
+}
+
+// audit delivers the event to the audit hook, if any.
+func (r *RetentionCache[T]) audit(event RetentionEvent) {
+	if r.onEvent != nil {
+		r.onEvent(event)
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetentionCache verifies that entries older than the maximum age of their namespace are never served and are
+// deleted with an audit event.
+func TestRetentionCache(t *testing.T) {
+	ctx := context.Background()
+	inner := NewLRUCache[string](10)
+	var mu sync.Mutex
+	var events []RetentionEvent
+	cache, err := NewRetentionCache[string](inner, RetentionConfig{
+		Namespaces: []RetentionNamespace{{Name: "pii", Prefix: "user:", MaxAge: 20 * time.Millisecond}},
+		OnEvent: func(e RetentionEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		},
+	})
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.NoError(t, cache.Set(ctx, "user:1", "alice"))
+	require.NoError(t, cache.Set(ctx, "user:2", "bob"))
+	require.NoError(t, cache.Set(ctx, "config", "kept"))
+	value, exists, err := cache.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "alice", value)
+
+	time.Sleep(30 * time.Millisecond)
+	_, exists, err = cache.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	deleted, err := cache.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, exists, _ = inner.Get(ctx, "user:2")
+	assert.False(t, exists)
+	_, exists, _ = cache.Get(ctx, "config")
+	assert.True(t, exists)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, RetentionDeleted, events[0].Action)
+	assert.Equal(t, "pii", events[0].Namespace)
+	assert.Equal(t, "user:1", events[0].Key)
+	assert.NoError(t, events[1].Err)
+}
+
+// TestRetentionCache_SweepsForeignEntries verifies that timestamped entries written by other processes are swept.
+func TestRetentionCache_SweepsForeignEntries(t *testing.T) {
+	ctx := context.Background()
+	inner := NewStaleWhileRevalidateLRUCache[string](10)
+	cache, err := NewRetentionCache[StaleValue[string]](inner, RetentionConfig{
+		Namespaces: []RetentionNamespace{{Name: "sessions", Prefix: "session:", MaxAge: time.Hour}},
+		Interval:   5 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.NoError(t, inner.Set(ctx, "session:old", StaleValue[string]{Value: "x", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+	require.NoError(t, inner.Set(ctx, "session:new", StaleValue[string]{Value: "y", CreatedAt: time.Now()}))
+
+	assert.Eventually(t, func() bool {
+		_, exists, _ := inner.Get(ctx, "session:old")
+		return !exists
+	}, time.Second, 5*time.Millisecond)
+	_, exists, _ := inner.Get(ctx, "session:new")
+	assert.True(t, exists)
+}
+
+// TestRetentionCache_SetTimestamped verifies that the TTL of a timestamped value counts from its creation time and that
+// a value already older than the maximum age replaces nothing but deletes the entry.
+func TestRetentionCache_SetTimestamped(t *testing.T) {
+	ctx := context.Background()
+	inner := NewStaleWhileRevalidateTimingWheelCache[string](time.Hour, TimingWheelConfig[StaleValue[string]]{})
+	defer inner.Close()
+	cache, err := NewRetentionCache[StaleValue[string]](inner, RetentionConfig{
+		Namespaces: []RetentionNamespace{{Name: "sessions", Prefix: "session:", MaxAge: time.Hour}},
+	})
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.NoError(t, cache.Set(ctx, "session:1", StaleValue[string]{Value: "x", CreatedAt: time.Now().Add(-50 * time.Minute)}))
+	ttl, exists, err := inner.TTL(ctx, "session:1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.LessOrEqual(t, ttl, 10*time.Minute)
+
+	require.NoError(t, cache.Set(ctx, "session:1", StaleValue[string]{Value: "y", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+	_, exists, err = inner.Get(ctx, "session:1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// TestNewRetentionCache_Invalid verifies configuration validation.
+func TestNewRetentionCache_Invalid(t *testing.T) {
+	_, err := NewRetentionCache[string](NewLRUCache[string](1), RetentionConfig{Namespaces: []RetentionNamespace{{Prefix: "a:"}}})
+	assert.ErrorIs(t, err, ErrInvalidRetention)
+
+	_, err = NewRetentionCache[string](NewLRUCache[string](1), RetentionConfig{Namespaces: []RetentionNamespace{
+		{Prefix: "a:", MaxAge: time.Hour},
+		{Prefix: "a:", MaxAge: time.Minute},
+	}})
+	assert.ErrorIs(t, err, ErrInvalidRetention)
+}
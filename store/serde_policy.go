@@ -0,0 +1,96 @@
+package store
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// SerdePolicy selects how serializing stores react to values their codec cannot encode or decode.
+// Values failing checksum verification, see ErrCorruptedValue, are always evicted and reported as misses.
+type SerdePolicy int
+
+const (
+	// SerdeFailFast returns encoding and decoding errors to the caller. It is the default.
+	SerdeFailFast SerdePolicy = iota
+	// SerdeLogAndSkip logs the error and skips the operation: a write is dropped, leaving any previous value in
+	// place, and an undecodable entry is reported as a miss and left in the store.
+	SerdeLogAndSkip
+	// SerdeEvictAndMiss logs the error and removes the entry: a write that cannot be encoded deletes the previous
+	// value, so it is not served in place of the new one, and an undecodable entry is deleted and reported as a miss.
+	SerdeEvictAndMiss
+)
+
+// SerdeErrorHooks are called with the key and the error whenever a value cannot be encoded or decoded, whatever the
+// policy. Hooks run synchronously and must not block.
+type SerdeErrorHooks struct {
+	OnMarshalError   func(key string, err error)
+	OnUnmarshalError func(key string, err error)
+}
+
+// WithSerdeErrorPolicy sets how the Redis and NATS stores handle serialization errors and the hooks notified of them.
+// The policy applies to Get, Set, SetWithTTL, PopulateIfAbsent, Take and BulkSet; conditional writes such as
+// SetIfRevision always fail fast, since their callers must know whether the value was written.
+func WithSerdeErrorPolicy(policy SerdePolicy, hooks SerdeErrorHooks) Option {
+	return func(o *storeOptions) {
+		o.serde = serdeGuard{policy: policy, hooks: hooks}
+	}
+}
+
+// serdeGuard applies a SerdePolicy and its hooks.
+type serdeGuard struct {
+	policy SerdePolicy
+	hooks  SerdeErrorHooks
+}
+
+// marshalFailed handles a value of the key that cannot be encoded. It returns the error to report to the caller, nil
+// when the write must be skipped. evict, when not nil, removes the previous value under SerdeEvictAndMiss.
+func (g serdeGuard) marshalFailed(key string, err error, evict func() error) error {
+	if g.hooks.OnMarshalError != nil {
+		g.hooks.OnMarshalError(key, err)
+	}
+	switch g.policy {
+	case SerdeLogAndSkip:
+		slog.Warn("Skipping cache write of unencodable value", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		return nil
+	case SerdeEvictAndMiss:
+		slog.Warn("Evicting cache entry of unencodable value", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		evictEntry(key, evict)
+		return nil
+	default:
+		return err
+	}
+}
+
+// unmarshalFailed handles an entry of the key that cannot be decoded. It returns the error to report to the caller,
+// nil when the entry must be reported as a miss. evict, when not nil, removes the entry under SerdeEvictAndMiss and
+// for corrupted values.
+func (g serdeGuard) unmarshalFailed(key string, err error, evict func() error) error {
+	if g.hooks.OnUnmarshalError != nil {
+		g.hooks.OnUnmarshalError(key, err)
+	}
+	switch {
+	case errors.Is(err, ErrCorruptedValue):
+		slog.Warn("Evicting corrupted cache entry", slog.String("cacheKey", key))
+		evictEntry(key, evict)
+		return nil
+	case g.policy == SerdeLogAndSkip:
+		slog.Warn("Skipping undecodable cache entry", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		return nil
+	case g.policy == SerdeEvictAndMiss:
+		slog.Warn("Evicting undecodable cache entry", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		evictEntry(key, evict)
+		return nil
+	default:
+		return err
+	}
+}
+
+// evictEntry runs evict, if any, logging its failure.
+func evictEntry(key string, evict func() error) {
+	if evict == nil {
+		return
+	}
+	if err := evict(); err != nil {
+		slog.Error("Cannot evict cache entry", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSerdePolicy_Redis verifies how each policy handles values that cannot be encoded or decoded.
+func TestSerdePolicy_Redis(t *testing.T) {
+	ctx := context.TODO()
+	unencodable := func() {}
+
+	t.Run("fail fast", func(t *testing.T) {
+		rdb, mock := redismock.NewClientMock()
+		cache := NewRedisCache[any](rdb, "test", time.Hour)
+
+		assert.Error(t, cache.Set(ctx, "key", unencodable))
+
+		mock.ExpectGet("test:key").SetVal("{")
+		_, _, err := cache.Get(ctx, "key")
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("log and skip", func(t *testing.T) {
+		var marshalled, unmarshalled []string
+		rdb, mock := redismock.NewClientMock()
+		cache := NewRedisCache[any](rdb, "test", time.Hour, WithSerdeErrorPolicy(SerdeLogAndSkip, SerdeErrorHooks{
+			OnMarshalError:   func(key string, err error) { marshalled = append(marshalled, key) },
+			OnUnmarshalError: func(key string, err error) { unmarshalled = append(unmarshalled, key) },
+		}))
+
+		assert.NoError(t, cache.Set(ctx, "key", unencodable))
+
+		mock.ExpectGet("test:key").SetVal("{")
+		_, found, err := cache.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.Equal(t, []string{"key"}, marshalled)
+		assert.Equal(t, []string{"key"}, unmarshalled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("evict and miss", func(t *testing.T) {
+		rdb, mock := redismock.NewClientMock()
+		cache := NewRedisCache[any](rdb, "test", time.Hour, WithSerdeErrorPolicy(SerdeEvictAndMiss, SerdeErrorHooks{}))
+
+		mock.ExpectDel("test:key").SetVal(1)
+		assert.NoError(t, cache.Set(ctx, "key", unencodable))
+
+		mock.ExpectGet("test:key").SetVal("{")
+		mock.ExpectDel("test:key").SetVal(1)
+		_, found, err := cache.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,420 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MetricShadowReads counts the reads of a ShadowCache, labelled by cache (primary or candidate) and result
+	// (hit, miss or error).
+	MetricShadowReads = "echocache_shadow_reads_total"
+	// MetricShadowMismatches counts the reads where the primary and the candidate disagree, labelled by kind: value
+	// when both hold different values, hit when only the primary holds the key and miss when only the candidate does.
+	MetricShadowMismatches = "echocache_shadow_mismatches_total"
+	// MetricShadowDuration is the latency of the operations of a ShadowCache, labelled by cache and op.
+	MetricShadowDuration = "echocache_shadow_operation_duration"
+)
+
+const (
+	// defaultShadowMaxInFlight bounds the candidate operations running concurrently by default.
+	defaultShadowMaxInFlight = 100
+	// defaultShadowTimeout bounds each candidate operation by default.
+	defaultShadowTimeout = time.Second
+)
+
+// ShadowConfig tunes a ShadowCache. Equal compares the values read from both caches, defaulting to the equality of
+// their JSON encodings. At most MaxInFlight candidate operations run concurrently, 100 by default; further ones are
+// dropped rather than slowing down the primary path. Each candidate operation is bounded by Timeout, 1s by default.
+// Metrics, when set, receives the MetricShadow* series.
+type ShadowConfig[T any] struct {
+	Equal       func(a, b T) bool
+	MaxInFlight int
+	Timeout     time.Duration
+	Metrics     MetricsSink
+}
+
+// ShadowStats summarizes the comparison of the primary and the candidate of a ShadowCache.
+type ShadowStats struct {
+	PrimaryHits       uint64
+	PrimaryMisses     uint64
+	CandidateHits     uint64
+	CandidateMisses   uint64
+	CandidateErrors   uint64
+	ValueMismatches   uint64
+	HitMismatches     uint64
+	MissMismatches    uint64
+	Dropped           uint64
+	PrimaryLatency    time.Duration
+	CandidateLatency  time.Duration
+	PrimaryHitRatio   float64
+	CandidateHitRatio float64
+}
+
+// ShadowCache is a dark-launch wrapper serving every operation from the primary cache while mirroring reads and
+// writes asynchronously to a candidate backend and comparing the results, so a migration, for instance from Redis
+// to NATS or to a new codec, can be validated on production traffic before switching. The candidate never affects
+// the values or errors returned to callers. Candidate operations on the same key run one at a time in the order of
+// the primary ones, and a Clear runs after the operations started before it and before the ones started after it,
+// so reads are compared against a candidate that received the same writes as the primary.
+type ShadowCache[T any] struct {
+	primary   Cacher[T]
+	candidate Cacher[T]
+	cfg       ShadowConfig[T]
+	slots     chan struct{}
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queues  map[string][]shadowOp
+	pending int
+	running int
+	gen     uint64
+	cleared uint64
+
+	primaryHits, primaryMisses                     atomic.Uint64
+	candidateHits, candidateMisses, candidateErrs  atomic.Uint64
+	valueMismatches, hitMismatches, missMismatches atomic.Uint64
+	dropped                                        atomic.Uint64
+	primaryOps, primaryNanos                       atomic.Int64
+	candidateOps, candidateNanos                   atomic.Int64
+}
+
+// shadowOp is a candidate operation waiting for the previous operations on its key. gen is the number of Clears
+// started before it.
+type shadowOp struct {
+	ctx  context.Context
+	name string
+	gen  uint64
+	run  func(ctx context.Context) error
+}
+
+// NewShadowCache creates a store serving from primary and shadowing its operations on candidate.
+func NewShadowCache[T any](primary Cacher[T], candidate Cacher[T], cfg ShadowConfig[T]) *ShadowCache[T] {
+	if cfg.Equal == nil {
+		cfg.Equal = jsonEqual[T]
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = defaultShadowMaxInFlight
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultShadowTimeout
+	}
+	s := &ShadowCache[T]{
+		primary:   primary,
+		candidate: candidate,
+		cfg:       cfg,
+		slots:     make(chan struct{}, cfg.MaxInFlight),
+		queues:    make(map[string][]shadowOp),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Get reads the value from the primary, then reads it from the candidate in the background and compares them.
+func (s *ShadowCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	start := time.Now()
+	value, exists, err := s.primary.Get(ctx, key)
+	s.observePrimary("get", start)
+	switch {
+	case err != nil:
+		s.record(MetricShadowReads, "cache", "primary", "result", "error")
+		return value, exists, err
+	case exists:
+		s.primaryHits.Add(1)
+		s.record(MetricShadowReads, "cache", "primary", "result", "hit")
+	default:
+		s.primaryMisses.Add(1)
+		s.record(MetricShadowReads, "cache", "primary", "result", "miss")
+	}
+	s.shadow(ctx, key, "get", func(ctx context.Context) error {
+		shadowValue, shadowExists, err := s.candidate.Get(ctx, key)
+		if err != nil {
+			s.candidateErrs.Add(1)
+			s.record(MetricShadowReads, "cache", "candidate", "result", "error")
+			return err
+		}
+		if shadowExists {
+			s.candidateHits.Add(1)
+			s.record(MetricShadowReads, "cache", "candidate", "result", "hit")
+		} else {
+			s.candidateMisses.Add(1)
+			s.record(MetricShadowReads, "cache", "candidate", "result", "miss")
+		}
+		s.compare(key, value, exists, shadowValue, shadowExists)
+		return nil
+	})
+	return value, exists, nil
+}
+
+// Set stores the value in the primary and, in the background, in the candidate.
+func (s *ShadowCache[T]) Set(ctx context.Context, key string, value T) error {
+	start := time.Now()
+	err := s.primary.Set(ctx, key, value)
+	s.observePrimary("set", start)
+	if err != nil {
+		return err
+	}
+	s.shadow(ctx, key, "set", func(ctx context.Context) error {
+		return s.candidate.Set(ctx, key, value)
+	})
+	return nil
+}
+
+// Delete removes the key from the primary and, in the background, from the candidate.
+func (s *ShadowCache[T]) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := Delete(ctx, s.primary, key)
+	s.observePrimary("delete", start)
+	if err != nil {
+		return err
+	}
+	s.shadow(ctx, key, "delete", func(ctx context.Context) error {
+		return Delete(ctx, s.candidate, key)
+	})
+	return nil
+}
+
+// Clear removes every entry from the primary and, in the background, from the candidate.
+func (s *ShadowCache[T]) Clear(ctx context.Context) error {
+	if err := Clear(ctx, s.primary); err != nil {
+		return err
+	}
+	s.shadowClear(ctx)
+	return nil
+}
+
+// TryAcquireRefreshLock takes the refresh lock on the primary when it supports refresh locks.
+func (s *ShadowCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	if locker, ok := s.primary.(RefreshLocker); ok {
+		return locker.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	}
+	return true, nil
+}
+
+// ReleaseRefreshLock releases the refresh lock on the primary when it supports refresh locks.
+func (s *ShadowCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	if locker, ok := s.primary.(RefreshLocker); ok {
+		return locker.ReleaseRefreshLock(ctx, key, randValue)
+	}
+	return nil
+}
+
+// valueCodec returns the codec of the primary, when it serializes values.
+func (s *ShadowCache[T]) valueCodec() Codec {
+	if provider, ok := s.primary.(codecProvider); ok {
+		return provider.valueCodec()
+	}
+	return nil
+}
+
+// Wait blocks until the candidate operations started so far have completed, for instance before reading the
+// statistics in tests or at shutdown.
+func (s *ShadowCache[T]) Wait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.pending > 0 {
+		s.cond.Wait()
+	}
+}
+
+// Stats returns a snapshot of the comparison of the primary and the candidate. Latencies are means.
+func (s *ShadowCache[T]) Stats() ShadowStats {
+	stats := ShadowStats{
+		PrimaryHits:      s.primaryHits.Load(),
+		PrimaryMisses:    s.primaryMisses.Load(),
+		CandidateHits:    s.candidateHits.Load(),
+		CandidateMisses:  s.candidateMisses.Load(),
+		CandidateErrors:  s.candidateErrs.Load(),
+		ValueMismatches:  s.valueMismatches.Load(),
+		HitMismatches:    s.hitMismatches.Load(),
+		MissMismatches:   s.missMismatches.Load(),
+		Dropped:          s.dropped.Load(),
+		PrimaryLatency:   meanDuration(s.primaryNanos.Load(), s.primaryOps.Load()),
+		CandidateLatency: meanDuration(s.candidateNanos.Load(), s.candidateOps.Load()),
+	}
+	if total := stats.PrimaryHits + stats.PrimaryMisses; total > 0 {
+		stats.PrimaryHitRatio = float64(stats.PrimaryHits) / float64(total)
+	}
+	if total := stats.CandidateHits + stats.CandidateMisses; total > 0 {
+		stats.CandidateHitRatio = float64(stats.CandidateHits) / float64(total)
+	}
+	return stats
+}
+
+// shadow queues op against the candidate behind the previous operations on the key, unless MaxInFlight operations
+// are already queued or running.
+func (s *ShadowCache[T]) shadow(ctx context.Context, key string, name string, op func(ctx context.Context) error) {
+	if !s.acquire() {
+		return
+	}
+	s.mu.Lock()
+	queue, busy := s.queues[key]
+	s.queues[key] = append(queue, shadowOp{ctx: ctx, name: name, gen: s.gen, run: op})
+	s.mu.Unlock()
+	if !busy {
+		go s.drain(key)
+	}
+}
+
+// drain runs the operations queued on the key one at a time until the queue is empty. Operations wait for the Clears
+// started before them, and are skipped when a Clear started after them, as the Clear supersedes them.
+func (s *ShadowCache[T]) drain(key string) {
+	for {
+		s.mu.Lock()
+		queue := s.queues[key]
+		if len(queue) == 0 {
+			delete(s.queues, key)
+			s.mu.Unlock()
+			return
+		}
+		op := queue[0]
+		s.queues[key] = queue[1:]
+		for op.gen == s.gen && s.cleared < op.gen {
+			s.cond.Wait()
+		}
+		skip := op.gen < s.gen
+		if !skip {
+			s.running++
+		}
+		s.mu.Unlock()
+
+		if !skip {
+			s.exec(op)
+			s.mu.Lock()
+			s.running--
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		}
+		s.release()
+	}
+}
+
+// shadowClear clears the candidate in the background once the Clears and the operations started before have
+// completed, unless MaxInFlight operations are already queued or running.
+func (s *ShadowCache[T]) shadowClear(ctx context.Context) {
+	if !s.acquire() {
+		return
+	}
+	s.mu.Lock()
+	s.gen++
+	gen := s.gen
+	s.mu.Unlock()
+	go func() {
+		s.mu.Lock()
+		for s.cleared < gen-1 || s.running > 0 {
+			s.cond.Wait()
+		}
+		s.mu.Unlock()
+		s.exec(shadowOp{ctx: ctx, name: "clear", gen: gen, run: func(ctx context.Context) error {
+			return Clear(ctx, s.candidate)
+		}})
+		s.mu.Lock()
+		s.cleared = gen
+		s.cond.Broadcast()
+		s.mu.Unlock()
+		s.release()
+	}()
+}
+
+// acquire takes a slot for a candidate operation, counting it as dropped when none is available.
+func (s *ShadowCache[T]) acquire() bool {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.dropped.Add(1)
+		return false
+	}
+	s.mu.Lock()
+	s.pending++
+	s.mu.Unlock()
+	return true
+}
+
+// release frees the slot of a completed or skipped candidate operation.
+func (s *ShadowCache[T]) release() {
+	<-s.slots
+	s.mu.Lock()
+	s.pending--
+	if s.pending == 0 {
+		s.cond.Broadcast()
+	}
+	s.mu.Unlock()
+}
+
+// exec runs a candidate operation bounded by Timeout and records its latency.
+func (s *ShadowCache[T]) exec(op shadowOp) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(op.ctx), s.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	err := op.run(ctx)
+	elapsed := time.Since(start)
+	s.candidateOps.Add(1)
+	s.candidateNanos.Add(int64(elapsed))
+	if s.cfg.Metrics != nil {
+		s.cfg.Metrics.ObserveDuration(MetricShadowDuration, map[string]string{"cache": "candidate", "op": op.name}, elapsed)
+	}
+	if err != nil {
+		slog.Debug("Shadow cache operation failed", slog.String("op", op.name), slog.String("error", err.Error()))
+	}
+}
+
+// compare records the divergence between the values read from the primary and the candidate.
+func (s *ShadowCache[T]) compare(key string, value T, exists bool, shadowValue T, shadowExists bool) {
+	switch {
+	case exists && shadowExists:
+		if !s.cfg.Equal(value, shadowValue) {
+			s.valueMismatches.Add(1)
+			s.record(MetricShadowMismatches, "kind", "value")
+			slog.Debug("Shadow cache value mismatch", slog.String("cacheKey", key))
+		}
+	case exists:
+		s.hitMismatches.Add(1)
+		s.record(MetricShadowMismatches, "kind", "hit")
+	case shadowExists:
+		s.missMismatches.Add(1)
+		s.record(MetricShadowMismatches, "kind", "miss")
+	}
+}
+
+// observePrimary records the latency of a primary operation.
+func (s *ShadowCache[T]) observePrimary(op string, start time.Time) {
+	elapsed := time.Since(start)
+	s.primaryOps.Add(1)
+	s.primaryNanos.Add(int64(elapsed))
+	if s.cfg.Metrics != nil {
+		s.cfg.Metrics.ObserveDuration(MetricShadowDuration, map[string]string{"cache": "primary", "op": op}, elapsed)
+	}
+}
+
+// record increments the counter with the given label pairs on the metrics sink, if any.
+func (s *ShadowCache[T]) record(name string, labels ...string) {
+	if s.cfg.Metrics == nil {
+		return
+	}
+	m := make(map[string]string, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		m[labels[i]] = labels[i+1]
+	}
+	s.cfg.Metrics.IncCounter(name, m, 1)
+}
+
+// jsonEqual reports whether both values have the same JSON encoding, so values decoded by different backends or
+// codecs compare equal when they carry the same data.
+func jsonEqual[T any](a, b T) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// meanDuration returns total divided by count, or zero when count is zero.
+func meanDuration(total int64, count int64) time.Duration {
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(total / count)
+}
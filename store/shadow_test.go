@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShadowCache verifies that reads are served by the primary while divergences of the candidate are reported.
+func TestShadowCache(t *testing.T) {
+	ctx := context.Background()
+	primary := NewLRUCache[string](10)
+	candidate := NewLRUCache[string](10)
+	metrics := NewMemoryMetrics()
+	cache := NewShadowCache[string](primary, candidate, ShadowConfig[string]{Metrics: metrics})
+
+	require.NoError(t, cache.Set(ctx, "same", "v1"))
+	cache.Wait()
+	require.NoError(t, primary.Set(ctx, "different", "primary"))
+	require.NoError(t, candidate.Set(ctx, "different", "candidate"))
+	require.NoError(t, primary.Set(ctx, "primary-only", "v"))
+	require.NoError(t, candidate.Set(ctx, "candidate-only", "v"))
+
+	for _, key := range []string{"same", "different", "primary-only", "candidate-only"} {
+		_, _, err := cache.Get(ctx, key)
+		require.NoError(t, err)
+	}
+	value, exists, err := cache.Get(ctx, "different")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "primary", value)
+	cache.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(4), stats.PrimaryHits)
+	assert.Equal(t, uint64(1), stats.PrimaryMisses)
+	assert.Equal(t, uint64(4), stats.CandidateHits)
+	assert.Equal(t, uint64(2), stats.ValueMismatches)
+	assert.Equal(t, uint64(1), stats.HitMismatches)
+	assert.Equal(t, uint64(1), stats.MissMismatches)
+	assert.InDelta(t, 0.8, stats.PrimaryHitRatio, 0.001)
+	assert.Equal(t, 2.0, metrics.Counter(MetricShadowMismatches, map[string]string{"kind": "value"}))
+
+	require.NoError(t, cache.Delete(ctx, "same"))
+	cache.Wait()
+	_, exists, _ = candidate.Get(ctx, "same")
+	assert.False(t, exists)
+}
+
+// TestShadowCache_DropsWhenSaturated verifies that candidate operations beyond MaxInFlight are dropped.
+func TestShadowCache_DropsWhenSaturated(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	slow := Chain[string](NewLRUCache[string](10), Middleware[string](func(next Cacher[string]) Cacher[string] {
+		return middlewareCacher[string]{
+			get: next.Get,
+			set: func(ctx context.Context, key string, value string) error {
+				<-release
+				return next.Set(ctx, key, value)
+			},
+		}
+	}))
+	cache := NewShadowCache[string](NewLRUCache[string](10), slow, ShadowConfig[string]{MaxInFlight: 1, Timeout: time.Minute})
+
+	require.NoError(t, cache.Set(ctx, "a", "1"))
+	require.NoError(t, cache.Set(ctx, "b", "2"))
+	close(release)
+	cache.Wait()
+	assert.Equal(t, uint64(1), cache.Stats().Dropped)
+}
+
+// gatedCacher blocks candidate writes until its gate is closed.
+type gatedCacher struct {
+	Cacher[string]
+	gate chan struct{}
+}
+
+// Set waits for the gate before writing.
+func (g gatedCacher) Set(ctx context.Context, key string, value string) error {
+	<-g.gate
+	return g.Cacher.Set(ctx, key, value)
+}
+
+// Clear clears the wrapped store.
+func (g gatedCacher) Clear(ctx context.Context) error {
+	return Clear(ctx, g.Cacher)
+}
+
+// TestShadowCache_OrderedPerKey verifies that candidate reads run after the writes started before them, including
+// Clears, so slow candidate writes are not reported as mismatches.
+func TestShadowCache_OrderedPerKey(t *testing.T) {
+	ctx := context.Background()
+	newCache := func() (*ShadowCache[string], gatedCacher) {
+		candidate := gatedCacher{Cacher: NewLRUCache[string](10), gate: make(chan struct{})}
+		return NewShadowCache[string](NewLRUCache[string](10), candidate, ShadowConfig[string]{Timeout: time.Minute}), candidate
+	}
+
+	cache, candidate := newCache()
+	require.NoError(t, cache.Set(ctx, "k", "v1"))
+	_, exists, err := cache.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	time.Sleep(20 * time.Millisecond)
+	close(candidate.gate)
+	cache.Wait()
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1), stats.CandidateHits)
+	assert.Zero(t, stats.ValueMismatches+stats.HitMismatches+stats.MissMismatches)
+
+	cache, candidate = newCache()
+	require.NoError(t, cache.Set(ctx, "k", "v1"))
+	require.NoError(t, cache.Clear(ctx))
+	_, exists, err = cache.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	time.Sleep(20 * time.Millisecond)
+	close(candidate.gate)
+	cache.Wait()
+	stats = cache.Stats()
+	assert.Equal(t, uint64(1), stats.CandidateMisses)
+	assert.Zero(t, stats.ValueMismatches+stats.HitMismatches+stats.MissMismatches)
+	_, exists, err = candidate.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// splitSweepThreshold is the number of tracked writes above which expired ones are swept on every write.
+const splitSweepThreshold = 1024
+
+// SplitConfig tunes the consistency of a SplitCache. ReadYourWrites, when positive, routes reads of keys written by
+// this process within that window to the writer, so a replica lagging behind the primary cannot serve an older value
+// or a miss right after a write. FallbackOnMiss routes reads missing from the reader to the writer, trading an extra
+// round trip on misses for never missing a value the replica has not received yet.
+type SplitConfig struct {
+	ReadYourWrites time.Duration
+	FallbackOnMiss bool
+}
+
+// SplitCache is a composite store with distinct read and write targets, such as a Redis reader endpoint or a NATS
+// mirror for reads and the primary for writes. Writes, deletions and refresh locks always go to the writer.
+type SplitCache[T any] struct {
+	reader Cacher[T]
+	writer Cacher[T]
+	cfg    SplitConfig
+
+	mu      sync.Mutex
+	written map[string]time.Time
+}
+
+// NewSplitCache creates a store reading from reader and writing to writer.
+func NewSplitCache[T any](reader Cacher[T], writer Cacher[T], cfg SplitConfig) *SplitCache[T] {
+	return &SplitCache[T]{
+		reader:  reader,
+		writer:  writer,
+		cfg:     cfg,
+		written: make(map[string]time.Time),
+	}
+}
+
+// NewStaleWhileRevalidateSplitCache creates a split stale-while-revalidate store. Refresh locks are taken on the
+// writer, so they are consistent across processes.
+func NewStaleWhileRevalidateSplitCache[T any](reader Cacher[StaleValue[T]], writer StaleWhileRevalidateCache[T], cfg SplitConfig) *SplitCache[StaleValue[T]] {
+	return NewSplitCache[StaleValue[T]](reader, writer, cfg)
+}
+
+// Get reads the value from the reader, or from the writer for keys recently written by this process and, with
+// FallbackOnMiss, for keys missing from the reader.
+func (s *SplitCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	if s.recentlyWritten(key) {
+		return s.writer.Get(ctx, key)
+	}
+	value, exists, err := s.reader.Get(ctx, key)
+	if exists || !s.cfg.FallbackOnMiss {
+		return value, exists, err
+	}
+	if err != nil {
+		slog.Warn("Cannot get value from read replica", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+	return s.writer.Get(ctx, key)
+}
+
+// Set stores the value in the writer.
+func (s *SplitCache[T]) Set(ctx context.Context, key string, value T) error {
+	if err := s.writer.Set(ctx, key, value); err != nil {
+		return err
+	}
+	s.track(key)
+	return nil
+}
+
+// Delete removes the key from the writer.
+func (s *SplitCache[T]) Delete(ctx context.Context, key string) error {
+	if err := Delete(ctx, s.writer, key); err != nil {
+		return err
+	}
+	s.track(key)
+	return nil
+}
+
+// PopulateIfAbsent stores the value in the writer only if the key is missing there.
+func (s *SplitCache[T]) PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error) {
+	written, err := PopulateIfAbsent(ctx, s.writer, key, value)
+	if written {
+		s.track(key)
+	}
+	return written, err
+}
+
+// Scan enumerates the keys of the reader, or of the writer when the reader cannot enumerate them.
+func (s *SplitCache[T]) Scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if scanner, ok := s.reader.(Scanner); ok {
+		return scanner.Scan(ctx, pattern, limit)
+	}
+	if scanner, ok := s.writer.(Scanner); ok {
+		return scanner.Scan(ctx, pattern, limit)
+	}
+	return nil, ErrNotSupported
+}
+
+// Clear removes every entry from the writer.
+func (s *SplitCache[T]) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	clear(s.written)
+	s.mu.Unlock()
+	return Clear(ctx, s.writer)
+}
+
+// valueCodec returns the codec of the writer, when it serializes values.
+func (s *SplitCache[T]) valueCodec() Codec {
+	if provider, ok := s.writer.(codecProvider); ok {
+		return provider.valueCodec()
+	}
+	return nil
+}
+
+// TryAcquireRefreshLock takes the refresh lock on the writer when it supports refresh locks.
+func (s *SplitCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	if locker, ok := s.writer.(RefreshLocker); ok {
+		return locker.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	}
+	return true, nil
+}
+
+// ReleaseRefreshLock releases the refresh lock on the writer when it supports refresh locks.
+func (s *SplitCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	if locker, ok := s.writer.(RefreshLocker); ok {
+		return locker.ReleaseRefreshLock(ctx, key, randValue)
+	}
+	return nil
+}
+
+// track records a write of the key for the read-your-writes window.
+func (s *SplitCache[T]) track(key string) {
+	if s.cfg.ReadYourWrites <= 0 {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.written) >= splitSweepThreshold {
+		for k, at := range s.written {
+			if now.Sub(at) >= s.cfg.ReadYourWrites {
+				delete(s.written, k)
+			}
+		}
+	}
+	s.written[key] = now
+}
+
+// recentlyWritten reports whether the key was written by this process within the read-your-writes window.
+func (s *SplitCache[T]) recentlyWritten(key string) bool {
+	if s.cfg.ReadYourWrites <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, ok := s.written[key]
+	if ok && time.Since(at) >= s.cfg.ReadYourWrites {
+		delete(s.written, key)
+		return false
+	}
+	return ok
+}
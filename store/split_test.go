@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitCache verifies that writes go to the writer and reads to the reader, honoring the consistency settings.
+func TestSplitCache(t *testing.T) {
+	ctx := context.Background()
+	replica, primary := NewLRUCache[string](10), NewLRUCache[string](10)
+
+	eventual := NewSplitCache(replica, primary, SplitConfig{})
+	require.NoError(t, eventual.Set(ctx, "k", "v1"))
+	_, exists, err := eventual.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, exists, "the replica has not received the write yet")
+
+	consistent := NewSplitCache(replica, primary, SplitConfig{ReadYourWrites: 20 * time.Millisecond})
+	require.NoError(t, consistent.Set(ctx, "k", "v2"))
+	value, exists, err := consistent.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "v2", value)
+	time.Sleep(30 * time.Millisecond)
+	_, exists, _ = consistent.Get(ctx, "k")
+	assert.False(t, exists, "reads go back to the replica after the window")
+
+	fallback := NewSplitCache(replica, primary, SplitConfig{FallbackOnMiss: true})
+	value, exists, err = fallback.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "v2", value)
+
+	require.NoError(t, replica.Set(ctx, "k", "replicated"))
+	value, _, _ = fallback.Get(ctx, "k")
+	assert.Equal(t, "replicated", value)
+
+	require.NoError(t, fallback.Delete(ctx, "k"))
+	_, exists, _ = primary.Get(ctx, "k")
+	assert.False(t, exists)
+}
@@ -0,0 +1,31 @@
+//go:build miniredis
+
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmbeddedRedis verifies that the Redis stores pass the suites on the embedded server, locks included.
+func TestEmbeddedRedis(t *testing.T) {
+	t.Run("Conformance", func(t *testing.T) {
+		ConformanceSuite(t, func(t *testing.T) store.Cacher[string] {
+			c, closeFn, err := store.NewEmbeddedRedisCache[string]("conformance", 200*time.Millisecond)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = closeFn() })
+			return c
+		}, WithTTL(200*time.Millisecond))
+	})
+	t.Run("StaleWhileRevalidate", func(t *testing.T) {
+		StaleWhileRevalidateSuite(t, func(t *testing.T) store.StaleWhileRevalidateCache[string] {
+			c, closeFn, err := store.NewStaleWhileRevalidateEmbeddedRedisCache[string]("swr", time.Minute)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = closeFn() })
+			return c
+		}, WithExclusiveLocks(), WithLockExpiry(100*time.Millisecond))
+	})
+}
@@ -0,0 +1,354 @@
+// Package storetest provides conformance tests for store.Cacher and store.StaleWhileRevalidateCache implementations,
+// so third-party backends can verify they meet the contract expected by EchoCache and EchoCacheLazy.
+//
+// A backend test typically looks like:
+//
+//	func TestMyStore(t *testing.T) {
+//		storetest.ConformanceSuite(t, func(t *testing.T) store.Cacher[string] {
+//			return mystore.New[string](...)
+//		}, storetest.WithTTL(time.Second))
+//	}
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory creates an empty store for a single test. Cleanup of external resources should be registered on t.
+type Factory func(t *testing.T) store.Cacher[string]
+
+// StaleWhileRevalidateFactory creates an empty stale-while-revalidate store for a single test.
+type StaleWhileRevalidateFactory func(t *testing.T) store.StaleWhileRevalidateCache[string]
+
+// config holds the suite settings.
+type config struct {
+	ttl            time.Duration
+	exclusiveLocks bool
+	lockTTL        time.Duration
+	concurrency    int
+}
+
+// Option customizes the conformance suites.
+type Option func(*config)
+
+// WithTTL declares the time-to-live the factory configures on its stores, enabling the expiration tests.
+// Keep it short, since the tests wait for entries to expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithExclusiveLocks declares that the store coordinates refresh locks, so a lock held by one owner cannot be
+// acquired by another until it is released or expires. In-memory stores, which always grant locks, must not set it.
+func WithExclusiveLocks() Option {
+	return func(c *config) {
+		c.exclusiveLocks = true
+	}
+}
+
+// WithLockExpiry declares the shortest refresh lock TTL the store honors, enabling the test checking that abandoned
+// exclusive locks expire.
+func WithLockExpiry(ttl time.Duration) Option {
+	return func(c *config) {
+		c.lockTTL = ttl
+	}
+}
+
+// WithConcurrency sets the number of goroutines used by the concurrency tests, 16 by default.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// newConfig applies the options over the defaults.
+func newConfig(opts []Option) config {
+	c := config{concurrency: 16}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// ConformanceSuite runs the behavioral tests every Cacher must pass: miss semantics, overwrites, key isolation,
+// expiration when a TTL is declared, concurrent access and the semantics of the optional capabilities (Deleter,
+// Taker, Populator, Scanner, TTLInspector, TTLSetter) the store implements.
+func ConformanceSuite(t *testing.T, factory Factory, opts ...Option) {
+	t.Helper()
+	runCacherTests(t, factory, func(i int) string { return fmt.Sprintf("value-%d", i) }, newConfig(opts))
+}
+
+// StaleWhileRevalidateSuite runs ConformanceSuite on stale values, checks that creation times round-trip and
+// verifies the refresh lock semantics.
+func StaleWhileRevalidateSuite(t *testing.T, factory StaleWhileRevalidateFactory, opts ...Option) {
+	t.Helper()
+	cfg := newConfig(opts)
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	value := func(i int) store.StaleValue[string] {
+		return store.StaleValue[string]{Value: fmt.Sprintf("value-%d", i), CreatedAt: base.Add(time.Duration(i) * time.Second)}
+	}
+	runCacherTests(t, func(t *testing.T) store.Cacher[store.StaleValue[string]] { return factory(t) }, value, cfg)
+
+	t.Run("CreatedAtRoundTrip", func(t *testing.T) {
+		c := factory(t)
+		want := value(7)
+		require.NoError(t, c.Set(t.Context(), "stale", want))
+		got, exists, err := c.Get(t.Context(), "stale")
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.Equal(t, want.Value, got.Value)
+		assert.WithinDuration(t, want.CreatedAt, got.CreatedAt, time.Millisecond)
+	})
+	t.Run("RefreshLock", func(t *testing.T) {
+		testRefreshLock(t, factory(t), cfg)
+	})
+}
+
+// runCacherTests runs the Cacher tests with values generated by value, which must return distinct values for
+// distinct indexes.
+func runCacherTests[T any](t *testing.T, factory func(t *testing.T) store.Cacher[T], value func(i int) T, cfg config) {
+	t.Run("Miss", func(t *testing.T) {
+		got, exists, err := factory(t).Get(t.Context(), "missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		var zeroValue T
+		assert.Equal(t, zeroValue, got)
+	})
+	t.Run("SetGet", func(t *testing.T) {
+		c := factory(t)
+		require.NoError(t, c.Set(t.Context(), "k", value(1)))
+		assertValue(t, c, "k", value(1))
+		require.NoError(t, c.Set(t.Context(), "k", value(2)))
+		assertValue(t, c, "k", value(2))
+	})
+	t.Run("KeyIsolation", func(t *testing.T) {
+		c := factory(t)
+		for i := range 5 {
+			require.NoError(t, c.Set(t.Context(), fmt.Sprintf("k%d", i), value(i)))
+		}
+		for i := range 5 {
+			assertValue(t, c, fmt.Sprintf("k%d", i), value(i))
+		}
+	})
+	t.Run("Expiration", func(t *testing.T) {
+		if cfg.ttl <= 0 {
+			t.Skip("no TTL declared, see WithTTL")
+		}
+		c := factory(t)
+		require.NoError(t, c.Set(t.Context(), "k", value(1)))
+		assertValue(t, c, "k", value(1))
+		assert.Eventually(t, func() bool {
+			_, exists, err := c.Get(t.Context(), "k")
+			return err == nil && !exists
+		}, cfg.ttl*10+time.Second, cfg.ttl/10+time.Millisecond)
+	})
+	t.Run("Concurrency", func(t *testing.T) {
+		testConcurrency(t, factory(t), value, cfg)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		c := factory(t)
+		if _, ok := c.(store.Deleter); !ok {
+			t.Skip("store does not implement store.Deleter")
+		}
+		require.NoError(t, c.Set(t.Context(), "k", value(1)))
+		require.NoError(t, store.Delete(t.Context(), c, "k"))
+		assertMissing(t, c, "k")
+		assert.NoError(t, store.Delete(t.Context(), c, "missing"), "deleting a missing key must not fail")
+	})
+	t.Run("Take", func(t *testing.T) {
+		c := factory(t)
+		if _, ok := c.(store.Taker[T]); !ok {
+			t.Skip("store does not implement store.Taker")
+		}
+		require.NoError(t, c.Set(t.Context(), "k", value(1)))
+		got, exists, err := store.Take(t.Context(), c, "k")
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.Equal(t, value(1), got)
+		_, exists, err = store.Take(t.Context(), c, "k")
+		require.NoError(t, err)
+		assert.False(t, exists, "a value must be taken at most once")
+		assertMissing(t, c, "k")
+	})
+	t.Run("PopulateIfAbsent", func(t *testing.T) {
+		c := factory(t)
+		if _, ok := c.(store.Populator[T]); !ok {
+			t.Skip("store does not implement store.Populator")
+		}
+		written, err := store.PopulateIfAbsent(t.Context(), c, "k", value(1))
+		require.NoError(t, err)
+		assert.True(t, written)
+		written, err = store.PopulateIfAbsent(t.Context(), c, "k", value(2))
+		require.NoError(t, err)
+		assert.False(t, written)
+		assertValue(t, c, "k", value(1))
+	})
+	t.Run("Scan", func(t *testing.T) {
+		c := factory(t)
+		scanner, ok := c.(store.Scanner)
+		if !ok {
+			t.Skip("store does not implement store.Scanner")
+		}
+		for _, key := range []string{"user:1", "user:2", "order:1"} {
+			require.NoError(t, c.Set(t.Context(), key, value(1)))
+		}
+		keys, err := scanner.Scan(t.Context(), "user:*", 0)
+		require.NoError(t, err)
+		slices.Sort(keys)
+		assert.Equal(t, []string{"user:1", "user:2"}, keys)
+		keys, err = scanner.Scan(t.Context(), "*", 1)
+		require.NoError(t, err)
+		assert.Len(t, keys, 1, "scan must honor the limit")
+	})
+	t.Run("SetWithTTL", func(t *testing.T) {
+		c := factory(t)
+		setter, ok := c.(store.TTLSetter[T])
+		if !ok {
+			t.Skip("store does not implement store.TTLSetter")
+		}
+		require.NoError(t, setter.SetWithTTL(t.Context(), "k", value(1), time.Hour))
+		assertValue(t, c, "k", value(1))
+		if inspector, ok := c.(store.TTLInspector); ok {
+			ttl, exists, err := inspector.TTL(t.Context(), "k")
+			require.NoError(t, err)
+			if exists {
+				assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+			}
+		}
+	})
+	t.Run("TTLMissing", func(t *testing.T) {
+		inspector, ok := factory(t).(store.TTLInspector)
+		if !ok {
+			t.Skip("store does not implement store.TTLInspector")
+		}
+		_, exists, err := inspector.TTL(t.Context(), "missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+// testConcurrency writes and reads from several goroutines, on distinct and shared keys, checking that no operation
+// fails and that readers only observe written values.
+func testConcurrency[T any](t *testing.T, c store.Cacher[T], value func(i int) T, cfg config) {
+	ctx := t.Context()
+	written := make([]T, cfg.concurrency)
+	for i := range written {
+		written[i] = value(i)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, cfg.concurrency*2)
+	for i := range cfg.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			own := fmt.Sprintf("own-%d", i)
+			for range 20 {
+				if err := c.Set(ctx, own, written[i]); err != nil {
+					errs <- err
+					return
+				}
+				if err := c.Set(ctx, "shared", written[i]); err != nil {
+					errs <- err
+					return
+				}
+				got, exists, err := c.Get(ctx, "shared")
+				if err != nil {
+					errs <- err
+					return
+				}
+				if exists && !containsValue(written, got) {
+					errs <- fmt.Errorf("read a value that was never written: %v", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	for i := range cfg.concurrency {
+		assertValue(t, c, fmt.Sprintf("own-%d", i), written[i])
+	}
+}
+
+// testRefreshLock verifies that locks can be acquired and released and, for stores declaring exclusive locks, that
+// they exclude other owners, are released only by their owner and, when WithLockExpiry is set, expire after their TTL.
+func testRefreshLock(t *testing.T, locker store.RefreshLocker, cfg config) {
+	ctx := context.Background()
+	acquired, err := locker.TryAcquireRefreshLock(ctx, "lock", "owner-a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired, "an unlocked key must be lockable")
+
+	if cfg.exclusiveLocks {
+		acquired, err = locker.TryAcquireRefreshLock(ctx, "lock", "owner-b", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, acquired, "a held lock must not be granted to another owner")
+
+		require.NoError(t, locker.ReleaseRefreshLock(ctx, "lock", "owner-b"))
+		acquired, err = locker.TryAcquireRefreshLock(ctx, "lock", "owner-b", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, acquired, "a lock must only be released by its owner")
+
+		acquired, err = locker.TryAcquireRefreshLock(ctx, "other", "owner-b", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired, "locks on different keys must be independent")
+	}
+
+	require.NoError(t, locker.ReleaseRefreshLock(ctx, "lock", "owner-a"))
+	acquired, err = locker.TryAcquireRefreshLock(ctx, "lock", "owner-b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "a released lock must be lockable again")
+	require.NoError(t, locker.ReleaseRefreshLock(ctx, "lock", "owner-b"))
+
+	if cfg.exclusiveLocks && cfg.lockTTL > 0 {
+		acquired, err = locker.TryAcquireRefreshLock(ctx, "expiring", "owner-a", cfg.lockTTL)
+		require.NoError(t, err)
+		require.True(t, acquired)
+		assert.Eventually(t, func() bool {
+			acquired, err := locker.TryAcquireRefreshLock(ctx, "expiring", "owner-b", cfg.lockTTL)
+			return err == nil && acquired
+		}, cfg.lockTTL*10+time.Second, cfg.lockTTL/10+time.Millisecond, "an abandoned lock must expire")
+	}
+}
+
+// assertValue checks that the key holds want.
+func assertValue[T any](t *testing.T, c store.Cacher[T], key string, want T) {
+	t.Helper()
+	got, exists, err := c.Get(t.Context(), key)
+	require.NoError(t, err)
+	require.True(t, exists, "key %q must exist", key)
+	assert.Equal(t, want, got)
+}
+
+// assertMissing checks that the key does not exist.
+func assertMissing[T any](t *testing.T, c store.Cacher[T], key string) {
+	t.Helper()
+	_, exists, err := c.Get(t.Context(), key)
+	require.NoError(t, err)
+	assert.False(t, exists, "key %q must not exist", key)
+}
+
+// containsValue reports whether values contains v, comparing with assert.ObjectsAreEqual.
+func containsValue[T any](values []T, v T) bool {
+	for _, candidate := range values {
+		if assert.ObjectsAreEqual(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package storetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// TestConformanceSuite verifies that the in-memory stores pass the suite.
+func TestConformanceSuite(t *testing.T) {
+	t.Run("LRU", func(t *testing.T) {
+		ConformanceSuite(t, func(t *testing.T) store.Cacher[string] {
+			return store.NewLRUCache[string](100)
+		})
+	})
+	t.Run("LRUExpirable", func(t *testing.T) {
+		ConformanceSuite(t, func(t *testing.T) store.Cacher[string] {
+			return store.NewLRUExpirableCache[string](100, 50*time.Millisecond)
+		}, WithTTL(50*time.Millisecond))
+	})
+	t.Run("Tiered", func(t *testing.T) {
+		ConformanceSuite(t, func(t *testing.T) store.Cacher[string] {
+			return store.NewTieredCache(store.NewLRUCache[string](100), store.NewLRUCache[string](100))
+		})
+	})
+	t.Run("Arena", func(t *testing.T) {
+		ConformanceSuite(t, func(t *testing.T) store.Cacher[string] {
+			return store.NewArenaCache[string](1 << 20)
+		})
+	})
+}
+
+// TestStaleWhileRevalidateSuite verifies the stale-while-revalidate suite against an in-memory store and a store with
+// exclusive locks.
+func TestStaleWhileRevalidateSuite(t *testing.T) {
+	t.Run("LRU", func(t *testing.T) {
+		StaleWhileRevalidateSuite(t, func(t *testing.T) store.StaleWhileRevalidateCache[string] {
+			return store.NewStaleWhileRevalidateLRUCache[string](100)
+		})
+	})
+	t.Run("Arena", func(t *testing.T) {
+		StaleWhileRevalidateSuite(t, func(t *testing.T) store.StaleWhileRevalidateCache[string] {
+			return store.NewStaleWhileRevalidateArenaCache[string](1 << 20)
+		})
+	})
+	t.Run("ExclusiveLocks", func(t *testing.T) {
+		StaleWhileRevalidateSuite(t, func(t *testing.T) store.StaleWhileRevalidateCache[string] {
+			return &exclusiveLockCache{
+				StaleWhileRevalidateCache: store.NewStaleWhileRevalidateLRUCache[string](100),
+				owners:                    make(map[string]lockOwner),
+			}
+		}, WithExclusiveLocks(), WithLockExpiry(20*time.Millisecond))
+	})
+}
+
+// lockOwner is the owner and expiration of a lock held by exclusiveLockCache.
+type lockOwner struct {
+	value   string
+	expires time.Time
+}
+
+// exclusiveLockCache adds exclusive, expiring refresh locks to an in-memory store, mirroring the remote backends.
+type exclusiveLockCache struct {
+	store.StaleWhileRevalidateCache[string]
+	mu     sync.Mutex
+	owners map[string]lockOwner
+}
+
+func (c *exclusiveLockCache) TryAcquireRefreshLock(_ context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if owner, ok := c.owners[key]; ok && time.Now().Before(owner.expires) {
+		return false, nil
+	}
+	c.owners[key] = lockOwner{value: randValue, expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (c *exclusiveLockCache) ReleaseRefreshLock(_ context.Context, key string, randValue string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.owners[key].value == randValue {
+		delete(c.owners, key)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package store
+
+import (
+	"context"
+	"io"
+)
+
+// StreamCacher is implemented by stores able to read and write large values as streams,
+// so multi-megabyte artifacts do not have to be fully buffered and encoded in memory.
+type StreamCacher interface {
+	GetReader(ctx context.Context, key string) (io.ReadCloser, bool, error)
+	SetReader(ctx context.Context, key string, r io.Reader) error
+}
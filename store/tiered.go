@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// TieredCache is a two-level cache combining a fast in-process layer (L1) with a shared remote layer (L2).
+// Reads are served from L1 when possible and fall back to L2, populating L1 on the way back.
+// Writes go to L2 first and then to L1 so that the shared layer always holds the most recent value.
+type TieredCache[T any] struct {
+	l1 Cacher[T]
+	l2 Cacher[T]
+}
+
+// NewTieredCache creates a two-level cache backed by the given in-process and remote caches.
+func NewTieredCache[T any](l1 Cacher[T], l2 Cacher[T]) *TieredCache[T] {
+	return &TieredCache[T]{
+		l1: l1,
+		l2: l2,
+	}
+}
+
+// NewStaleWhileRevalidateTieredCache creates a two-level stale-while-revalidate cache.
+// Refresh locks are delegated to the remote layer so that they are shared across processes.
+func NewStaleWhileRevalidateTieredCache[T any](l1 StaleWhileRevalidateCache[T], l2 StaleWhileRevalidateCache[T]) *TieredCache[StaleValue[T]] {
+	return &TieredCache[StaleValue[T]]{
+		l1: l1,
+		l2: l2,
+	}
+}
+
+// Get retrieves the value from L1, falling back to L2 and populating L1 when the value is found remotely.
+func (t *TieredCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	value, exists, err := t.l1.Get(ctx, key)
+	if exists {
+		return value, true, nil
+	}
+	if err != nil {
+		slog.Warn("Cannot get value from L1 cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+
+	value, exists, err = t.l2.Get(ctx, key)
+	if err != nil || !exists {
+		return value, exists, err
+	}
+	if err := t.l1.Set(ctx, key, value); err != nil {
+		slog.Warn("Cannot populate L1 cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+	return value, true, nil
+}
+
+// BulkGet reads the keys from L1 and the missing ones from L2 in a single bulk read, populating L1 with the values
+// found remotely.
+func (t *TieredCache[T]) BulkGet(ctx context.Context, keys []string) (map[string]T, error) {
+	values, err := BulkGet(ctx, t.l1, keys)
+	if err != nil {
+		slog.Warn("Cannot get values from L1 cache", slog.String("error", err.Error()))
+	}
+	if values == nil {
+		values = make(map[string]T, len(keys))
+	}
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+	remote, err := BulkGet(ctx, t.l2, missing)
+	if err != nil {
+		return values, err
+	}
+	if err := BulkSet(ctx, t.l1, remote); err != nil {
+		slog.Warn("Cannot populate L1 cache", slog.String("error", err.Error()))
+	}
+	for key, value := range remote {
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Set stores the value in L2 and then in L1. An L2 failure is returned without touching L1.
+func (t *TieredCache[T]) Set(ctx context.Context, key string, value T) error {
+	if err := t.l2.Set(ctx, key, value); err != nil {
+		return err
+	}
+	return t.l1.Set(ctx, key, value)
+}
+
+// PopulateIfAbsent stores the value in L2 only if the key is missing there, and copies it to L1 when it was written.
+// Returns ErrNotSupported if the remote layer does not implement Populator.
+func (t *TieredCache[T]) PopulateIfAbsent(ctx context.Context, key string, value T) (bool, error) {
+	written, err := PopulateIfAbsent[T](ctx, t.l2, key, value)
+	if err != nil || !written {
+		return written, err
+	}
+	if err := t.l1.Set(ctx, key, value); err != nil {
+		slog.Warn("Cannot populate L1 cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+	return true, nil
+}
+
+// Delete removes the key from both layers, starting from the remote one.
+func (t *TieredCache[T]) Delete(ctx context.Context, key string) error {
+	if err := Delete(ctx, t.l2, key); err != nil {
+		return err
+	}
+	return Delete(ctx, t.l1, key)
+}
+
+// Take consumes the value from the remote layer, which arbitrates between concurrent callers, and drops the local copy.
+// Returns ErrNotSupported if the remote layer does not implement Taker.
+func (t *TieredCache[T]) Take(ctx context.Context, key string) (T, bool, error) {
+	value, exists, err := Take[T](ctx, t.l2, key)
+	if err != nil {
+		return value, exists, err
+	}
+	if err := Delete(ctx, t.l1, key); err != nil && !errors.Is(err, ErrNotSupported) {
+		slog.Warn("Cannot drop taken entry from L1 cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
+	}
+	return value, exists, nil
+}
+
+// Clear empties both layers, starting from the remote one.
+// Returns ErrNotSupported if either layer can be neither cleared nor enumerated.
+func (t *TieredCache[T]) Clear(ctx context.Context) error {
+	if err := Clear(ctx, t.l2); err != nil {
+		return err
+	}
+	return Clear(ctx, t.l1)
+}
+
+// ClearLocal empties the in-process layer, leaving the remote one untouched.
+func (t *TieredCache[T]) ClearLocal(ctx context.Context) error {
+	return Clear(ctx, t.l1)
+}
+
+// DeleteLocal removes the key from the in-process layer, leaving the remote one untouched.
+func (t *TieredCache[T]) DeleteLocal(ctx context.Context, key string) error {
+	return Delete(ctx, t.l1, key)
+}
+
+// WarmFromL2 scans the remote layer for keys matching the glob-style pattern and preloads up to limit of them into L1.
+// It is meant to be called at startup to avoid serving every request from a cold L1 after a deploy.
+// Returns the number of entries loaded, or ErrNotSupported if the remote layer cannot enumerate its keys.
+func (t *TieredCache[T]) WarmFromL2(ctx context.Context, pattern string, limit int) (int, error) {
+	scanner, ok := t.l2.(Scanner)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	keys, err := scanner.Scan(ctx, pattern, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	loaded := 0
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return loaded, err
+		}
+		value, exists, err := t.l2.Get(ctx, key)
+		if err != nil {
+			slog.Warn("Cannot warm L1 cache", slog.String("error", err.Error()), slog.String("cacheKey", key))
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if err := t.l1.Set(ctx, key, value); err != nil {
+			return loaded, err
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+// valueCodec returns the codec of the remote layer, whose serialized size is the one worth measuring.
+func (t *TieredCache[T]) valueCodec() Codec {
+	if provider, ok := t.l2.(codecProvider); ok {
+		return provider.valueCodec()
+	}
+	return nil
+}
+
+// TryAcquireRefreshLock delegates lock acquisition to the remote layer when it supports refresh locks.
+func (t *TieredCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	if locker, ok := t.l2.(RefreshLocker); ok {
+		return locker.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	}
+	return true, nil
+}
+
+// ReleaseRefreshLock delegates lock release to the remote layer when it supports refresh locks.
+func (t *TieredCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	if locker, ok := t.l2.(RefreshLocker); ok {
+		return locker.ReleaseRefreshLock(ctx, key, randValue)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingCacher is a Cacher whose operations always fail, used to exercise error paths of wrappers.
+type failingCacher[T any] struct {
+	err error
+}
+
+// Get always returns the configured error.
+func (f failingCacher[T]) Get(_ context.Context, _ string) (T, bool, error) {
+	var zero T
+	return zero, false, f.err
+}
+
+// Set always returns the configured error.
+func (f failingCacher[T]) Set(_ context.Context, _ string, _ T) error {
+	return f.err
+}
+
+// TestTieredCache_Get verifies L1 hits, L2 fallbacks and L1 population.
+func TestTieredCache_Get(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewLRUCache[string](10)
+	l2 := NewLRUCache[string](10)
+	cache := NewTieredCache[string](l1, l2)
+
+	assert.NoError(t, l2.Set(ctx, "remote", "r"))
+
+	value, found, err := cache.Get(ctx, "remote")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "r", value)
+
+	value, found, _ = l1.Get(ctx, "remote")
+	assert.True(t, found)
+	assert.Equal(t, "r", value)
+
+	_, found, err = cache.Get(ctx, "missing")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+// TestTieredCache_Set verifies write-through semantics and that L2 failures leave L1 untouched.
+func TestTieredCache_Set(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewLRUCache[string](10)
+	l2 := NewLRUCache[string](10)
+
+	assert.NoError(t, NewTieredCache[string](l1, l2).Set(ctx, "k", "v"))
+	_, found, _ := l1.Get(ctx, "k")
+	assert.True(t, found)
+	_, found, _ = l2.Get(ctx, "k")
+	assert.True(t, found)
+
+	failing := NewTieredCache[string](l1, failingCacher[string]{err: errors.New("down")})
+	assert.Error(t, failing.Set(ctx, "other", "v"))
+	_, found, _ = l1.Get(ctx, "other")
+	assert.False(t, found)
+}
+
+// TestTieredCache_WarmFromL2 verifies that matching remote entries are preloaded into L1 up to the limit.
+func TestTieredCache_WarmFromL2(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewLRUCache[int](10)
+	l2 := NewLRUCache[int](10)
+	for i, key := range []string{"user:1", "user:2", "user:3", "order:1"} {
+		assert.NoError(t, l2.Set(ctx, key, i))
+	}
+	cache := NewTieredCache[int](l1, l2)
+
+	loaded, err := cache.WarmFromL2(ctx, "user:*", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, loaded)
+
+	keys, _ := l1.(Scanner).Scan(ctx, "*", 0)
+	assert.ElementsMatch(t, []string{"user:3", "user:2"}, keys)
+
+	_, err = NewTieredCache[int](l1, failingCacher[int]{}).WarmFromL2(ctx, "*", 10)
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+// TestTieredCache_Take verifies that taking a value consumes it from both layers.
+func TestTieredCache_Take(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewLRUCache[string](10)
+	l2 := NewLRUCache[string](10)
+	cache := NewTieredCache[string](l1, l2)
+	assert.NoError(t, cache.Set(ctx, "k", "v"))
+
+	value, found, err := cache.Take(ctx, "k")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "v", value)
+
+	_, found, _ = l1.Get(ctx, "k")
+	assert.False(t, found)
+	_, found, _ = l2.Get(ctx, "k")
+	assert.False(t, found)
+}
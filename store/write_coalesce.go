@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// defaultCoalesceInterval is the interval at which WriteCoalescingCache persists the latest values by default.
+const defaultCoalesceInterval = 100 * time.Millisecond
+
+// WriteCoalescingConfig configures a WriteCoalescingCache. Interval is how often the latest values are written to
+// the remote store, every 100ms by default. OnFlushError, when set, is called when a flush fails; the values of the
+// failed flush that were not overwritten since are retried at the next interval.
+type WriteCoalescingConfig struct {
+	Interval     time.Duration
+	OnFlushError func(err error)
+}
+
+// WriteCoalescingCache cuts the write load of keys updated many times per second, such as live metrics: Set only
+// records the value locally, and every interval the latest value of each key written since the previous flush is
+// persisted to the remote store, with pipelined writes when it supports them. Reads return the pending value when
+// there is one, so the process always sees its freshest write, and fall back to the remote store otherwise. Writes
+// not yet flushed are lost if the process dies; Close flushes them on shutdown. Put an in-process layer in front of
+// it with NewTieredCache to keep serving the flushed values locally.
+type WriteCoalescingCache[T any] struct {
+	remote   Cacher[T]
+	cfg      WriteCoalescingConfig
+	mu       sync.Mutex
+	pending  map[string]T
+	flushing map[string]T
+	flushMu  sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewWriteCoalescingCache creates a coalescing cache in front of remote and starts its flush loop. Close must be
+// called to stop it.
+func NewWriteCoalescingCache[T any](remote Cacher[T], cfg WriteCoalescingConfig) *WriteCoalescingCache[T] {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultCoalesceInterval
+	}
+	c := &WriteCoalescingCache[T]{
+		remote:  remote,
+		cfg:     cfg,
+		pending: make(map[string]T),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// Get returns the latest value written by this process and not yet flushed, or the value of the remote store.
+func (c *WriteCoalescingCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	c.mu.Lock()
+	value, ok := c.pending[key]
+	if !ok {
+		value, ok = c.flushing[key]
+	}
+	c.mu.Unlock()
+	if ok {
+		return value, true, nil
+	}
+	return c.remote.Get(ctx, key)
+}
+
+// Set records the value, to be written to the remote store at the next flush unless overwritten before.
+func (c *WriteCoalescingCache[T]) Set(ctx context.Context, key string, value T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[key] = value
+	return nil
+}
+
+// Delete drops the pending value of the key and removes it from the remote store. A flush in progress completes
+// first, so that it cannot write the key back afterwards.
+func (c *WriteCoalescingCache[T]) Delete(ctx context.Context, key string) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+	c.mu.Lock()
+	delete(c.pending, key)
+	c.mu.Unlock()
+	return Delete(ctx, c.remote, key)
+}
+
+// Pending returns the number of keys written since the last flush.
+func (c *WriteCoalescingCache[T]) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// Flush writes the pending values to the remote store now.
+func (c *WriteCoalescingCache[T]) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	batch := c.pending
+	c.flushing, c.pending = batch, make(map[string]T)
+	c.mu.Unlock()
+
+	err := BulkSet(ctx, c.remote, batch)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		for key, value := range batch {
+			if _, overwritten := c.pending[key]; !overwritten {
+				c.pending[key] = value
+			}
+		}
+	}
+	c.flushing = nil
+	return err
+}
+
+// Close stops the flush loop after a last flush of the pending values.
+func (c *WriteCoalescingCache[T]) Close() {
+	c.once.Do(func() {
+		close(c.stop)
+		<-c.done
+	})
+}
+
+// TryAcquireRefreshLock delegates lock acquisition to the remote store when it supports refresh locks.
+func (c *WriteCoalescingCache[T]) TryAcquireRefreshLock(ctx context.Context, key string, randValue string, ttl time.Duration) (bool, error) {
+	if locker, ok := c.remote.(RefreshLocker); ok {
+		return locker.TryAcquireRefreshLock(ctx, key, randValue, ttl)
+	}
+	return true, nil
+}
+
+// ReleaseRefreshLock delegates lock release to the remote store when it supports refresh locks.
+func (c *WriteCoalescingCache[T]) ReleaseRefreshLock(ctx context.Context, key string, randValue string) error {
+	if locker, ok := c.remote.(RefreshLocker); ok {
+		return locker.ReleaseRefreshLock(ctx, key, randValue)
+	}
+	return nil
+}
+
+// run flushes the pending values every interval until Close is called, then flushes a last time.
+func (c *WriteCoalescingCache[T]) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			c.flush()
+			return
+		case <-ticker.C:
+			c.flush()
+		}
+	}
+}
+
+// flush writes the pending values, reporting failures.
+func (c *WriteCoalescingCache[T]) flush() {
+	err := c.Flush(context.Background())
+	if err == nil {
+		return
+	}
+	if c.cfg.OnFlushError != nil {
+		c.cfg.OnFlushError(err)
+		return
+	}
+	slog.Warn("Cannot flush coalesced writes", slog.String("error", err.Error()))
+}
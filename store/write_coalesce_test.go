@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCacher counts the writes reaching the wrapped store.
+type countingCacher[T any] struct {
+	Cacher[T]
+	sets int
+}
+
+// Set counts the write and forwards it.
+func (c *countingCacher[T]) Set(ctx context.Context, key string, value T) error {
+	c.sets++
+	return c.Cacher.Set(ctx, key, value)
+}
+
+// Delete forwards the deletion.
+func (c *countingCacher[T]) Delete(ctx context.Context, key string) error {
+	return Delete(ctx, c.Cacher, key)
+}
+
+// TestWriteCoalescingCache verifies that only the latest value of each key reaches the remote store, and that
+// reads see pending values.
+func TestWriteCoalescingCache(t *testing.T) {
+	ctx := context.Background()
+	remote := &countingCacher[int]{Cacher: NewLRUCache[int](10)}
+	c := NewWriteCoalescingCache[int](remote, WriteCoalescingConfig{Interval: time.Hour})
+	defer c.Close()
+
+	for i := range 100 {
+		assert.NoError(t, c.Set(ctx, "metric", i))
+	}
+	assert.NoError(t, c.Set(ctx, "other", 7))
+	assert.Equal(t, 2, c.Pending())
+
+	value, exists, err := c.Get(ctx, "metric")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 99, value)
+	_, exists, _ = remote.Get(ctx, "metric")
+	assert.False(t, exists)
+
+	assert.NoError(t, c.Flush(ctx))
+	assert.Equal(t, 0, c.Pending())
+	assert.Equal(t, 2, remote.sets)
+	value, exists, _ = remote.Get(ctx, "metric")
+	assert.True(t, exists)
+	assert.Equal(t, 99, value)
+
+	assert.NoError(t, c.Set(ctx, "metric", 100))
+	assert.NoError(t, c.Delete(ctx, "metric"))
+	assert.NoError(t, c.Flush(ctx))
+	_, exists, _ = c.Get(ctx, "metric")
+	assert.False(t, exists)
+}
+
+// TestWriteCoalescingCache_FlushError verifies that failed flushes are reported and retried, and that Close flushes
+// the pending values.
+func TestWriteCoalescingCache_FlushError(t *testing.T) {
+	ctx := context.Background()
+	errBackend := errors.New("backend down")
+	c := NewWriteCoalescingCache[int](failingCacher[int]{err: errBackend}, WriteCoalescingConfig{Interval: time.Hour})
+
+	assert.NoError(t, c.Set(ctx, "metric", 1))
+	assert.ErrorIs(t, c.Flush(ctx), errBackend)
+	assert.Equal(t, 1, c.Pending())
+	value, exists, err := c.Get(ctx, "metric")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 1, value)
+	c.Close()
+
+	remote := NewLRUCache[int](10)
+	c = NewWriteCoalescingCache[int](remote, WriteCoalescingConfig{Interval: time.Hour})
+	assert.NoError(t, c.Set(ctx, "metric", 2))
+	c.Close()
+	value, exists, _ = remote.Get(ctx, "metric")
+	assert.True(t, exists)
+	assert.Equal(t, 2, value)
+}
@@ -0,0 +1,181 @@
+package echocache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// tombstoneSweepThreshold is the number of tombstones above which expired ones are swept on every new tombstone.
+const tombstoneSweepThreshold = 1024
+
+// tombstoneTracker records recently invalidated keys, so that a refresh racing with the invalidation cannot store
+// the old value again. A nil *tombstoneTracker is valid and never reports a tombstone.
+type tombstoneTracker struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	seq   uint64
+	until map[string]tombstone
+}
+
+// tombstone is the expiry of the tombstone of a key and the sequence number of the bury that wrote it.
+type tombstone struct {
+	until time.Time
+	seq   uint64
+}
+
+// newTombstoneTracker creates a tracker keeping tombstones for ttl.
+func newTombstoneTracker(ttl time.Duration) *tombstoneTracker {
+	return &tombstoneTracker{
+		ttl:   ttl,
+		until: make(map[string]tombstone),
+	}
+}
+
+// bury writes a tombstone for the key, replacing any previous one.
+func (t *tombstoneTracker) bury(key string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.until) >= tombstoneSweepThreshold {
+		for k, stone := range t.until {
+			if !now.Before(stone.until) {
+				delete(t.until, k)
+			}
+		}
+	}
+	t.seq++
+	t.until[key] = tombstone{until: now.Add(t.ttl), seq: t.seq}
+}
+
+// active reports whether the key has an unexpired tombstone.
+func (t *tombstoneTracker) active(key string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.activeLocked(key)
+	return ok
+}
+
+// activeLocked reports whether the key has an unexpired tombstone and the sequence number of the bury that wrote it.
+// Must be called with the lock held.
+func (t *tombstoneTracker) activeLocked(key string) (uint64, bool) {
+	stone, ok := t.until[key]
+	if ok && !time.Now().Before(stone.until) {
+		delete(t.until, key)
+		return 0, false
+	}
+	return stone.seq, ok
+}
+
+// write runs set unless the key has an active tombstone and reports whether the value was kept. When the key is
+// buried while set runs, the write raced with an invalidation and is undone with undo, so the value it stored cannot
+// outlive the invalidation.
+func (t *tombstoneTracker) write(key string, set func() error, undo func()) (bool, error) {
+	if t == nil {
+		return true, set()
+	}
+	t.mu.Lock()
+	if _, ok := t.activeLocked(key); ok {
+		t.mu.Unlock()
+		return false, nil
+	}
+	seq := t.seq
+	t.mu.Unlock()
+
+	if err := set(); err != nil {
+		return false, err
+	}
+	t.mu.Lock()
+	buried, ok := t.activeLocked(key)
+	t.mu.Unlock()
+	if ok && buried > seq {
+		undo()
+		return false, nil
+	}
+	return true, nil
+}
+
+// writeEntries is write for many keys: it runs set with the entries whose key has no active tombstone, then undoes
+// with undo the entries whose key was buried while set ran. Returns the entries kept, along with the error of set.
+func writeEntries[V any](t *tombstoneTracker, entries map[string]V, set func(entries map[string]V) error, undo func(key string)) (map[string]V, error) {
+	if t == nil {
+		return entries, set(entries)
+	}
+	t.mu.Lock()
+	filtered := make(map[string]V, len(entries))
+	for key, value := range entries {
+		if _, ok := t.activeLocked(key); !ok {
+			filtered[key] = value
+		}
+	}
+	seq := t.seq
+	t.mu.Unlock()
+
+	err := set(filtered)
+	t.mu.Lock()
+	var buried []string
+	for key := range filtered {
+		if stone, ok := t.activeLocked(key); ok && stone > seq {
+			buried = append(buried, key)
+			delete(filtered, key)
+		}
+	}
+	t.mu.Unlock()
+	for _, key := range buried {
+		undo(key)
+	}
+	return filtered, err
+}
+
+// filterTombstoned returns the entries whose key has no active tombstone.
+func filterTombstoned[V any](t *tombstoneTracker, entries map[string]V) map[string]V {
+	if t == nil {
+		return entries
+	}
+	filtered := make(map[string]V, len(entries))
+	for key, value := range entries {
+		if !t.active(key) {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+// filterTombstonedStream forwards the entries whose key has no active tombstone until entries is closed or ctx is
+// done, then closes the returned channel.
+func filterTombstonedStream[V any](ctx context.Context, t *tombstoneTracker, entries <-chan store.Entry[V]) <-chan store.Entry[V] {
+	if t == nil {
+		return entries
+	}
+	filtered := make(chan store.Entry[V])
+	go func() {
+		defer close(filtered)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry, ok := <-entries:
+				if !ok {
+					return
+				}
+				if t.active(entry.Key) {
+					continue
+				}
+				select {
+				case filtered <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return filtered
+}
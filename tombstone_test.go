@@ -0,0 +1,128 @@
+package echocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_Tombstones verifies that a refresh racing with Invalidate does not store the old value and that
+// fetches report the key as not found until the tombstone expires.
+func TestEchoCache_Tombstones(t *testing.T) {
+	lru := store.NewLRUCache[string](10)
+	ec := NewEchoCache[string](lru, WithTombstones(50*time.Millisecond))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = ec.FetchWithCache(context.Background(), "user", func(ctx context.Context) (string, error) {
+			close(started)
+			<-release
+			return "old", nil
+		})
+	}()
+	<-started
+	require.NoError(t, ec.Invalidate(t.Context(), "user"))
+	close(release)
+	<-done
+
+	_, exists, _ := lru.Get(t.Context(), "user")
+	assert.False(t, exists, "a refresh racing with the invalidation must not be stored")
+
+	calls := 0
+	refresh := func(ctx context.Context) (string, error) {
+		calls++
+		return "new", nil
+	}
+	value, found, err := ec.FetchWithCache(t.Context(), "user", refresh)
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, value)
+	assert.Zero(t, calls)
+
+	written, err := ec.PopulateIfAbsent(t.Context(), "user", "seed")
+	require.NoError(t, err)
+	assert.False(t, written)
+
+	time.Sleep(60 * time.Millisecond)
+	value, found, err = ec.FetchWithCache(t.Context(), "user", refresh)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "new", value)
+}
+
+// TestEchoCacheLazy_Tombstones verifies that Invalidate on the lazy cache leaves a tombstone blocking fetches.
+func TestEchoCacheLazy_Tombstones(t *testing.T) {
+	lru := store.NewStaleWhileRevalidateLRUCache[string](10)
+	ec := NewLazyEchoCache[string](lru, time.Second, WithTombstones(time.Hour))
+	defer ec.ShutdownLazyRefresh()
+
+	refresh := func(ctx context.Context) (string, error) { return "value", nil }
+	_, _, err := ec.FetchWithLazyRefresh(t.Context(), "k", refresh, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, ec.Invalidate(t.Context(), "k"))
+	_, found, err := ec.FetchWithLazyRefresh(t.Context(), "k", refresh, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, found)
+	require.NoError(t, ec.BulkSet(t.Context(), map[string]string{"k": "bulk", "other": "bulk"}))
+	_, exists, _ := lru.Get(t.Context(), "k")
+	assert.False(t, exists)
+	_, exists, _ = lru.Get(t.Context(), "other")
+	assert.True(t, exists)
+}
+
+// invalidatingStore invalidates the key through ec right before writing it, as an Invalidate landing between the
+// tombstone check of a refresh and its write.
+type invalidatingStore struct {
+	store.Cacher[string]
+	ec *EchoCache[string]
+}
+
+func (s *invalidatingStore) Set(ctx context.Context, key string, value string) error {
+	if err := s.ec.Invalidate(ctx, key); err != nil {
+		return err
+	}
+	return s.Cacher.Set(ctx, key, value)
+}
+
+func (s *invalidatingStore) Delete(ctx context.Context, key string) error {
+	return store.Delete(ctx, s.Cacher, key)
+}
+
+// TestEchoCache_TombstoneWriteRace verifies that a value written while the key is invalidated does not survive the
+// invalidation, and that streamed bulk loads skip buried keys.
+func TestEchoCache_TombstoneWriteRace(t *testing.T) {
+	lru := store.NewLRUCache[string](10)
+	s := &invalidatingStore{Cacher: lru}
+	ec := NewEchoCache[string](s, WithTombstones(time.Hour))
+	s.ec = ec
+
+	value, found, err := ec.FetchWithCache(t.Context(), "user", func(ctx context.Context) (string, error) {
+		return "old", nil
+	})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "old", value)
+	_, exists, _ := lru.Get(t.Context(), "user")
+	assert.False(t, exists, "a value written during the invalidation must be removed")
+
+	bulk := NewEchoCache[string](lru, WithTombstones(time.Hour))
+	require.NoError(t, bulk.Invalidate(t.Context(), "user"))
+	entries := make(chan store.Entry[string], 2)
+	entries <- store.Entry[string]{Key: "user", Value: "bulk"}
+	entries <- store.Entry[string]{Key: "other", Value: "bulk"}
+	close(entries)
+	written, err := bulk.BulkSetStream(t.Context(), entries, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+	_, exists, _ = lru.Get(t.Context(), "user")
+	assert.False(t, exists)
+	_, exists, _ = lru.Get(t.Context(), "other")
+	assert.True(t, exists)
+}
@@ -0,0 +1,80 @@
+package echocache
+
+import "sync"
+
+// versionTracker detects refreshes superseded by UpdateThrough: a refresh notes the version of the cache before
+// computing, and a value it computed must not be kept once the key was updated since, as it may predate the update.
+// Keys are tracked only while a refresh is running on them. A nil *versionTracker is valid and never reports a
+// superseded refresh.
+type versionTracker struct {
+	mu      sync.Mutex
+	version uint64
+	keys    map[string]*keyVersion
+}
+
+// keyVersion counts the refreshes running on a key and records the version of its last update.
+type keyVersion struct {
+	refreshes int
+	updated   uint64
+}
+
+// newVersionTracker creates an empty tracker.
+func newVersionTracker() *versionTracker {
+	return &versionTracker{keys: make(map[string]*keyVersion)}
+}
+
+// begin records a refresh starting on the key and returns the current version, to be passed to superseded. Every
+// call must be paired with a call to end.
+func (v *versionTracker) begin(key string) uint64 {
+	if v == nil {
+		return 0
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	kv, ok := v.keys[key]
+	if !ok {
+		kv = &keyVersion{}
+		v.keys[key] = kv
+	}
+	kv.refreshes++
+	return v.version
+}
+
+// end records the end of a refresh started with begin.
+func (v *versionTracker) end(key string) {
+	if v == nil {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if kv, ok := v.keys[key]; ok {
+		kv.refreshes--
+		if kv.refreshes <= 0 {
+			delete(v.keys, key)
+		}
+	}
+}
+
+// update records an update of the key, superseding the refreshes running on it.
+func (v *versionTracker) update(key string) {
+	if v == nil {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.version++
+	if kv, ok := v.keys[key]; ok {
+		kv.updated = v.version
+	}
+}
+
+// superseded reports whether the key was updated after the refresh that began at version.
+func (v *versionTracker) superseded(key string, version uint64) bool {
+	if v == nil {
+		return false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	kv, ok := v.keys[key]
+	return ok && kv.updated > version
+}
@@ -0,0 +1,83 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"github.com/logocomune/echocache/store"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrInvalidWarmTTL is returned by EchoCache.Warm when a Spread is set without a base TTL longer than the Spread.
+var ErrInvalidWarmTTL = errors.New("warm spread requires a base TTL longer than the spread")
+
+// WarmOptions configures how Warm preloads values into a cache.
+// Spread is the window over which the expiration (EchoCache) or the next refresh (EchoCacheLazy) of the warmed entries
+// is randomly distributed, so that thousands of keys loaded at once do not all expire or refresh at the same moment.
+// TTL is the base time-to-live of the warmed entries and is only used by EchoCache when Spread is set.
+type WarmOptions struct {
+	TTL    time.Duration
+	Spread time.Duration
+}
+
+// jitter returns a random duration in [0, spread), or zero when spread is not positive.
+func (o WarmOptions) jitter() time.Duration {
+	if o.Spread <= 0 {
+		return 0
+	}
+	return rand.N(o.Spread)
+}
+
+// Warm preloads precomputed values into the underlying store. With a Spread, each entry is written with a TTL randomly
+// shortened by up to Spread from the base TTL, which requires the store to implement store.TTLSetter; otherwise
+// store.ErrNotSupported is returned, and a TTL longer than the Spread, otherwise ErrInvalidWarmTTL is returned.
+// Without a Spread, Warm behaves like BulkSet. Keys with an active tombstone are skipped.
+func (ec *EchoCache[T]) Warm(ctx context.Context, entries map[string]T, opts WarmOptions) error {
+	if opts.Spread <= 0 {
+		return ec.BulkSet(ctx, entries)
+	}
+	if opts.TTL <= opts.Spread {
+		return ErrInvalidWarmTTL
+	}
+	setter, ok := ec.store.(store.TTLSetter[T])
+	if !ok {
+		return store.ErrNotSupported
+	}
+	for key, value := range ec.keyedEntries(entries) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ttl := opts.TTL - opts.jitter()
+		_, err := ec.graves.write(key, func() error {
+			return setter.SetWithTTL(ctx, key, value, ttl)
+		}, func() {
+			if err := store.Delete(context.WithoutCancel(ctx), ec.store, key); err != nil {
+				slog.Warn("Cannot delete invalidated warm value", slog.String("error", err.Error()), slog.String("cacheKey", key))
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Warm preloads precomputed values into the underlying store. Each entry is stamped with a creation time randomly
+// backdated by up to Spread, so the lazy refreshes of the warmed keys are spread over that window instead of
+// being scheduled all at once when the refresh interval elapses. Keys with an active tombstone are skipped.
+func (ec *EchoCacheLazy[T]) Warm(ctx context.Context, entries map[string]T, opts WarmOptions) error {
+	now := time.Now()
+	staleEntries := make(map[string]store.StaleValue[T], len(entries))
+	for key, value := range entries {
+		staleEntries[key] = store.StaleValue[T]{Value: value, CreatedAt: now.Add(-opts.jitter()), Producer: ec.opts.nodeID}
+	}
+	_, err := writeEntries(ec.graves, staleEntries, func(entries map[string]store.StaleValue[T]) error {
+		return store.BulkSet[store.StaleValue[T]](ctx, ec.store, entries)
+	}, func(key string) {
+		if err := store.Delete(context.WithoutCancel(ctx), ec.store, key); err != nil {
+			slog.Warn("Cannot delete invalidated warm value", slog.String("error", err.Error()), slog.String("cacheKey", key))
+		}
+	})
+	return err
+}
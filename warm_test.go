@@ -0,0 +1,104 @@
+package echocache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warmEntries builds n entries keyed by their index.
+func warmEntries(n int) map[string]int {
+	entries := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		entries[strconv.Itoa(i)] = i
+	}
+	return entries
+}
+
+// TestEchoCache_Warm verifies that warmed entries get TTLs spread over the configured window.
+func TestEchoCache_Warm(t *testing.T) {
+	ctx := context.Background()
+	wheel := store.NewTimingWheelCache[int](time.Hour, store.TimingWheelConfig[int]{})
+	defer wheel.Close()
+	cache := NewEchoCache[int](wheel)
+
+	require.NoError(t, cache.Warm(ctx, warmEntries(100), WarmOptions{TTL: time.Hour, Spread: 10 * time.Minute}))
+
+	lowest, highest := time.Hour, time.Duration(0)
+	for key := range warmEntries(100) {
+		ttl, exists, err := wheel.TTL(ctx, key)
+		require.NoError(t, err)
+		require.True(t, exists)
+		lowest, highest = min(lowest, ttl), max(highest, ttl)
+	}
+	assert.GreaterOrEqual(t, lowest, 50*time.Minute)
+	assert.LessOrEqual(t, highest, time.Hour)
+	assert.Greater(t, highest-lowest, time.Minute)
+	assert.ErrorIs(t, cache.Warm(ctx, warmEntries(1), WarmOptions{Spread: time.Minute}), ErrInvalidWarmTTL)
+	assert.ErrorIs(t, cache.Warm(ctx, warmEntries(1), WarmOptions{TTL: time.Minute, Spread: time.Hour}), ErrInvalidWarmTTL)
+
+	lru := NewEchoCache[int](store.NewLRUCache[int](10))
+	assert.ErrorIs(t, lru.Warm(ctx, warmEntries(1), WarmOptions{TTL: time.Hour, Spread: time.Minute}), store.ErrNotSupported)
+	assert.NoError(t, lru.Warm(ctx, warmEntries(1), WarmOptions{}))
+}
+
+// TestEchoCacheLazy_Warm verifies that warmed entries get creation times spread over the configured window.
+func TestEchoCacheLazy_Warm(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[int](200)
+	cache := NewLazyEchoCache[int](swr, time.Second)
+	defer cache.ShutdownLazyRefresh()
+
+	start := time.Now()
+	require.NoError(t, cache.Warm(ctx, warmEntries(100), WarmOptions{Spread: time.Hour}))
+
+	oldest, newest := start, start.Add(-2*time.Hour)
+	for key := range warmEntries(100) {
+		value, exists, err := swr.Get(ctx, key)
+		require.NoError(t, err)
+		require.True(t, exists)
+		if value.CreatedAt.Before(oldest) {
+			oldest = value.CreatedAt
+		}
+		if value.CreatedAt.After(newest) {
+			newest = value.CreatedAt
+		}
+	}
+	assert.True(t, oldest.After(start.Add(-time.Hour)))
+	assert.Greater(t, newest.Sub(oldest), time.Minute)
+}
+
+// TestWarm_SkipsTombstoned verifies that warming does not write keys with an active tombstone.
+func TestWarm_SkipsTombstoned(t *testing.T) {
+	ctx := context.Background()
+	wheel := store.NewTimingWheelCache[int](time.Hour, store.TimingWheelConfig[int]{})
+	defer wheel.Close()
+	cache := NewEchoCache[int](wheel, WithTombstones(time.Hour))
+	require.NoError(t, cache.Invalidate(ctx, "0"))
+
+	require.NoError(t, cache.Warm(ctx, warmEntries(2), WarmOptions{TTL: time.Hour, Spread: time.Minute}))
+	_, exists, err := wheel.Get(ctx, "0")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	_, exists, err = wheel.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	swr := store.NewStaleWhileRevalidateLRUCache[int](10)
+	lazy := NewLazyEchoCache[int](swr, time.Second, WithTombstones(time.Hour))
+	defer lazy.ShutdownLazyRefresh()
+	require.NoError(t, lazy.Invalidate(ctx, "0"))
+
+	require.NoError(t, lazy.Warm(ctx, warmEntries(2), WarmOptions{Spread: time.Minute}))
+	_, exists, err = swr.Get(ctx, "0")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	_, exists, err = swr.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
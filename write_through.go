@@ -0,0 +1,75 @@
+package echocache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+)
+
+// MutateFunc performs a write on the source of truth and returns the new value of the key.
+type MutateFunc[T any] func(ctx context.Context) (T, error)
+
+// UpdateThrough runs mutateFn, the write to the source of truth, and then stores the value it returns in the cache,
+// sharing it with the other caches of the topic with WithEventBus. When mutateFn fails the cache is left untouched;
+// when the cache cannot be updated, the key is invalidated instead, so that readers never keep the value preceding
+// the mutation. Fetches of this cache computing the key concurrently do not keep the value they computed, which may
+// precede the mutation: it is deleted once stored, and the next fetch computes the key again. Cache operations run
+// even if ctx is cancelled once the mutation succeeded.
+func (ec *EchoCache[T]) UpdateThrough(ctx context.Context, key string, mutateFn MutateFunc[T]) (T, error) {
+	value, err := mutateFn(ctx)
+	if err != nil {
+		return value, err
+	}
+	ctx = context.WithoutCancel(ctx)
+	key = ec.key(key)
+	ec.versions.update(key)
+	if err := ec.store.Set(ctx, key, value); err != nil {
+		slog.Warn("Failed to store updated value, invalidating key", slog.String("cacheKey", key), slog.String("error", err.Error()))
+		return value, ec.invalidate(ctx, key)
+	}
+	ec.fallback.set(ctx, key, value, time.Now())
+	ec.bus.Publish(BusEvent{Topic: ec.busTopic, Key: key, Kind: BusWarmed, Value: value})
+	return value, nil
+}
+
+// DeleteThrough runs deleteFn, the deletion from the source of truth, and then invalidates the key as Invalidate
+// does. When deleteFn fails the cache is left untouched.
+func (ec *EchoCache[T]) DeleteThrough(ctx context.Context, key string, deleteFn func(ctx context.Context) error) error {
+	if err := deleteFn(ctx); err != nil {
+		return err
+	}
+	return ec.Invalidate(context.WithoutCancel(ctx), key)
+}
+
+// UpdateThrough runs mutateFn, the write to the source of truth, and then stores the value it returns in the cache,
+// stamped with the current time, dropping the background refresh pending for the key and sharing the value with the
+// other caches of the topic with WithEventBus. When mutateFn fails the cache is left untouched; when the cache cannot
+// be updated, the key is invalidated instead. Refreshes of this cache running concurrently on the key do not keep
+// the value they computed, which may precede the mutation.
+func (ec *EchoCacheLazy[T]) UpdateThrough(ctx context.Context, key string, mutateFn MutateFunc[T]) (T, error) {
+	value, err := mutateFn(ctx)
+	if err != nil {
+		return value, err
+	}
+	ctx = context.WithoutCancel(ctx)
+	ec.versions.update(key)
+	ec.CancelPending(key)
+	cachedItem := store.StaleValue[T]{Value: value, CreatedAt: time.Now(), Producer: ec.opts.nodeID}
+	if err := ec.store.Set(ctx, key, cachedItem); err != nil {
+		slog.Warn("Failed to store updated value, invalidating key", slog.String("cacheKey", key), slog.String("error", err.Error()))
+		return value, ec.Invalidate(ctx, key)
+	}
+	ec.opts.bus.Publish(BusEvent{Topic: ec.opts.busTopic, Key: key, Kind: BusWarmed, Value: cachedItem})
+	return value, nil
+}
+
+// DeleteThrough runs deleteFn, the deletion from the source of truth, and then invalidates the key as Invalidate
+// does. When deleteFn fails the cache is left untouched.
+func (ec *EchoCacheLazy[T]) DeleteThrough(ctx context.Context, key string, deleteFn func(ctx context.Context) error) error {
+	if err := deleteFn(ctx); err != nil {
+		return err
+	}
+	return ec.Invalidate(context.WithoutCancel(ctx), key)
+}
@@ -0,0 +1,98 @@
+package echocache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/logocomune/echocache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEchoCache_UpdateDeleteThrough verifies that the cache follows successful writes to the source of truth,
+// shares them on the bus, and is left untouched by failed ones.
+func TestEchoCache_UpdateDeleteThrough(t *testing.T) {
+	ctx := context.Background()
+	bus := NewEventBus(time.Minute)
+	lru := store.NewLRUCache[string](10)
+	ec := NewEchoCache[string](lru, WithEventBus(bus, "users"))
+	var events []BusEvent
+	bus.Subscribe("users", func(e BusEvent) { events = append(events, e) })
+	require.NoError(t, lru.Set(ctx, "user", "old"))
+
+	errWrite := errors.New("write failed")
+	_, err := ec.UpdateThrough(ctx, "user", func(ctx context.Context) (string, error) { return "", errWrite })
+	assert.ErrorIs(t, err, errWrite)
+	value, _, _ := lru.Get(ctx, "user")
+	assert.Equal(t, "old", value)
+
+	value, err = ec.UpdateThrough(ctx, "user", func(ctx context.Context) (string, error) { return "new", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "new", value)
+	value, _, _ = lru.Get(ctx, "user")
+	assert.Equal(t, "new", value)
+	require.Len(t, events, 1)
+	assert.Equal(t, BusWarmed, events[0].Kind)
+
+	assert.ErrorIs(t, ec.DeleteThrough(ctx, "user", func(ctx context.Context) error { return errWrite }), errWrite)
+	_, exists, _ := lru.Get(ctx, "user")
+	assert.True(t, exists)
+
+	require.NoError(t, ec.DeleteThrough(ctx, "user", func(ctx context.Context) error { return nil }))
+	_, exists, _ = lru.Get(ctx, "user")
+	assert.False(t, exists)
+	require.Len(t, events, 2)
+	assert.Equal(t, BusInvalidated, events[1].Kind)
+}
+
+// TestEchoCacheLazy_UpdateThrough verifies that the lazy cache stores the updated value stamped with the current time.
+func TestEchoCacheLazy_UpdateThrough(t *testing.T) {
+	ctx := context.Background()
+	swr := store.NewStaleWhileRevalidateLRUCache[string](10)
+	cache := NewLazyEchoCache[string](swr, time.Second)
+	defer cache.ShutdownLazyRefresh()
+
+	_, err := cache.UpdateThrough(ctx, "user", func(ctx context.Context) (string, error) { return "new", nil })
+	require.NoError(t, err)
+	stored, exists, _ := swr.Get(ctx, "user")
+	require.True(t, exists)
+	assert.Equal(t, "new", stored.Value)
+	assert.WithinDuration(t, time.Now(), stored.CreatedAt, time.Second)
+
+	require.NoError(t, cache.DeleteThrough(ctx, "user", func(ctx context.Context) error { return nil }))
+	_, exists, _ = swr.Get(ctx, "user")
+	assert.False(t, exists)
+}
+
+// TestEchoCache_UpdateThroughDuringFetch verifies that a fetch computing the key while it is updated does not leave
+// the value it computed, which precedes the update, in the cache.
+func TestEchoCache_UpdateThroughDuringFetch(t *testing.T) {
+	ctx := context.Background()
+	lru := store.NewLRUCache[string](10)
+	ec := NewEchoCache[string](lru)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = ec.FetchWithCache(ctx, "user", func(ctx context.Context) (string, error) {
+			close(started)
+			<-release
+			return "old", nil
+		})
+	}()
+	<-started
+	_, err := ec.UpdateThrough(ctx, "user", func(ctx context.Context) (string, error) { return "new", nil })
+	require.NoError(t, err)
+	close(release)
+	<-done
+
+	value, exists, _ := lru.Get(ctx, "user")
+	assert.False(t, exists, "the fetch must not keep %q", value)
+	value, found, err := ec.FetchWithCache(ctx, "user", func(ctx context.Context) (string, error) { return "new", nil })
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "new", value)
+}